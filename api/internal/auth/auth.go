@@ -2,12 +2,15 @@ package auth
 
 import (
 	"bytes"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"image/png"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -70,6 +73,58 @@ func ValidateJWT(tokenString, tokenSecret string) (*ApcClaims, error) {
 	return claims, nil
 }
 
+// streamTokenIssuer marks tokens that only open the live results stream of a gathering
+const streamTokenIssuer = "APC results stream"
+
+// MakeStreamToken returns a short-lived token that lets userLogin open the live results stream of
+// one gathering, and when it expires. Browsers cannot send an Authorization header with an
+// EventSource, so the token travels in the URL; it is signed with a key of its own so it is never
+// accepted as a bearer token.
+func MakeStreamToken(userLogin, tokenSecret string, gatheringID int64, expiresIn time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(expiresIn)
+	claims := jwt.RegisteredClaims{
+		Issuer:    streamTokenIssuer,
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		Subject:   userLogin,
+		Audience:  jwt.ClaimStrings{strconv.FormatInt(gatheringID, 10)},
+		ID:        uuid.NewString(),
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(streamTokenKey(tokenSecret))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// ValidateStreamToken checks a stream token was issued for the gathering and has not expired, and
+// returns the login of the user it was issued to
+func ValidateStreamToken(tokenString, tokenSecret string, gatheringID int64) (string, error) {
+	claims := &jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return streamTokenKey(tokenSecret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(streamTokenIssuer),
+		jwt.WithAudience(strconv.FormatInt(gatheringID, 10)),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return "", err
+	}
+	if !token.Valid {
+		return "", errors.New("token is invalid")
+	}
+	return claims.Subject, nil
+}
+
+// streamTokenKey derives the key stream tokens are signed with from the token secret
+func streamTokenKey(tokenSecret string) []byte {
+	mac := hmac.New(sha256.New, []byte(tokenSecret))
+	mac.Write([]byte(streamTokenIssuer))
+	return mac.Sum(nil)
+}
+
 func GetBearerToken(headers http.Header) (string, error) {
 	parts, err := authHeaderParts(headers)
 	if err != nil {
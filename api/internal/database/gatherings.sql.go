@@ -18,17 +18,24 @@ SET participant_id = ?,
     updated_at     = CURRENT_TIMESTAMP
 WHERE gathering_id = ?
   AND participant_id IS NULL
-  AND unit_id = ? RETURNING id, gathering_id, unit_id, participant_id, created_at, updated_at
+  AND unit_id = ?
+  AND owner_id IN (0, ?) RETURNING id, gathering_id, unit_id, participant_id, created_at, updated_at, owner_id, share
 `
 
 type AssignUnitSlotParams struct {
 	ParticipantID interface{}
 	GatheringID   int64
 	UnitID        int64
+	OwnerID       int64
 }
 
 func (q *Queries) AssignUnitSlot(ctx context.Context, arg AssignUnitSlotParams) (UnitSlot, error) {
-	row := q.db.QueryRowContext(ctx, assignUnitSlot, arg.ParticipantID, arg.GatheringID, arg.UnitID)
+	row := q.db.QueryRowContext(ctx, assignUnitSlot,
+		arg.ParticipantID,
+		arg.GatheringID,
+		arg.UnitID,
+		arg.OwnerID,
+	)
 	var i UnitSlot
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +44,8 @@ func (q *Queries) AssignUnitSlot(ctx context.Context, arg AssignUnitSlotParams)
 		&i.ParticipantID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OwnerID,
+		&i.Share,
 	)
 	return i, err
 }
@@ -59,6 +68,171 @@ func (q *Queries) CheckInParticipant(ctx context.Context, arg CheckInParticipant
 	return err
 }
 
+const checkOutParticipant = `-- name: CheckOutParticipant :execrows
+UPDATE gathering_participants
+SET check_out_time = CURRENT_TIMESTAMP,
+    updated_at     = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND gathering_id = ?
+  AND check_in_time IS NOT NULL
+  AND check_out_time IS NULL
+`
+
+type CheckOutParticipantParams struct {
+	ID          int64
+	GatheringID int64
+}
+
+func (q *Queries) CheckOutParticipant(ctx context.Context, arg CheckOutParticipantParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, checkOutParticipant, arg.ID, arg.GatheringID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const clearPassedGatheringClose = `-- name: ClearPassedGatheringClose :exec
+UPDATE gatherings
+SET closes_at  = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND closes_at <= ?
+`
+
+type ClearPassedGatheringCloseParams struct {
+	ID  int64
+	Now sql.NullTime
+}
+
+func (q *Queries) ClearPassedGatheringClose(ctx context.Context, arg ClearPassedGatheringCloseParams) error {
+	_, err := q.db.ExecContext(ctx, clearPassedGatheringClose, arg.ID, arg.Now)
+	return err
+}
+
+const closeOpenVotingMatters = `-- name: CloseOpenVotingMatters :execrows
+UPDATE voting_matters
+SET voting_state     = 'closed',
+    voting_closed_at = CURRENT_TIMESTAMP,
+    updated_at       = CURRENT_TIMESTAMP
+WHERE gathering_id = ?
+  AND voting_state = 'open'
+`
+
+func (q *Queries) CloseOpenVotingMatters(ctx context.Context, gatheringID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, closeOpenVotingMatters, gatheringID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const closeVotingMatter = `-- name: CloseVotingMatter :one
+UPDATE voting_matters
+SET voting_state     = 'closed',
+    voting_closed_at = CURRENT_TIMESTAMP,
+    updated_at       = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND gathering_id = ?
+  AND voting_state = 'open' RETURNING id, gathering_id, order_index, title, description, matter_type, voting_config, created_at, updated_at, is_informative, title_ru, description_ru, voting_state, voting_opened_at, voting_closed_at
+`
+
+type CloseVotingMatterParams struct {
+	ID          int64
+	GatheringID int64
+}
+
+func (q *Queries) CloseVotingMatter(ctx context.Context, arg CloseVotingMatterParams) (VotingMatter, error) {
+	row := q.db.QueryRowContext(ctx, closeVotingMatter, arg.ID, arg.GatheringID)
+	var i VotingMatter
+	err := row.Scan(
+		&i.ID,
+		&i.GatheringID,
+		&i.OrderIndex,
+		&i.Title,
+		&i.Description,
+		&i.MatterType,
+		&i.VotingConfig,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsInformative,
+		&i.TitleRu,
+		&i.DescriptionRu,
+		&i.VotingState,
+		&i.VotingOpenedAt,
+		&i.VotingClosedAt,
+	)
+	return i, err
+}
+
+const closeVotingWindow = `-- name: CloseVotingWindow :exec
+UPDATE voting_matters
+SET voting_closed_at = CURRENT_TIMESTAMP,
+    updated_at       = CURRENT_TIMESTAMP
+WHERE gathering_id = ?
+  AND voting_closed_at IS NULL
+`
+
+func (q *Queries) CloseVotingWindow(ctx context.Context, gatheringID int64) error {
+	_, err := q.db.ExecContext(ctx, closeVotingWindow, gatheringID)
+	return err
+}
+
+const copyVotingMatters = `-- name: CopyVotingMatters :execrows
+INSERT INTO voting_matters (gathering_id, order_index, title, title_ru, description, description_ru, matter_type,
+                            voting_config, is_informative)
+SELECT ?,
+       order_index,
+       title,
+       title_ru,
+       description,
+       description_ru,
+       matter_type,
+       voting_config,
+       is_informative
+FROM voting_matters
+WHERE gathering_id = ?
+ORDER BY order_index
+`
+
+type CopyVotingMattersParams struct {
+	ToGatheringID   int64
+	FromGatheringID int64
+}
+
+func (q *Queries) CopyVotingMatters(ctx context.Context, arg CopyVotingMattersParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, copyVotingMatters, arg.ToGatheringID, arg.FromGatheringID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countGatheringUnitSlots = `-- name: CountGatheringUnitSlots :one
+SELECT COUNT(*) as count
+FROM unit_slots
+WHERE gathering_id = ?
+`
+
+func (q *Queries) CountGatheringUnitSlots(ctx context.Context, gatheringID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countGatheringUnitSlots, gatheringID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countVotingMatters = `-- name: CountVotingMatters :one
+SELECT COUNT(*) as count
+FROM voting_matters
+WHERE gathering_id = ?
+`
+
+func (q *Queries) CountVotingMatters(ctx context.Context, gatheringID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countVotingMatters, gatheringID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createAuditLog = `-- name: CreateAuditLog :exec
 INSERT INTO voting_audit_log (gathering_id, entity_type, entity_id, action,
                               performed_by, ip_address, details)
@@ -91,7 +265,7 @@ func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams)
 const createBallot = `-- name: CreateBallot :one
 INSERT INTO voting_ballots (gathering_id, participant_id, ballot_content, ballot_hash,
                             submitted_ip, submitted_user_agent)
-VALUES (?, ?, ?, ?, ?, ?) RETURNING id, gathering_id, participant_id, ballot_content, ballot_hash, submitted_at, submitted_ip, submitted_user_agent, signature, signature_timestamp, signature_certificate, is_valid, invalidated_at, invalidation_reason
+VALUES (?, ?, ?, ?, ?, ?) RETURNING id, gathering_id, participant_id, ballot_content, ballot_hash, submitted_at, submitted_ip, submitted_user_agent, signature, signature_timestamp, signature_certificate, is_valid, invalidated_at, invalidation_reason, replaces_ballot_id, prev_hash, chain_hash
 `
 
 type CreateBallotParams struct {
@@ -128,6 +302,9 @@ func (q *Queries) CreateBallot(ctx context.Context, arg CreateBallotParams) (Vot
 		&i.IsValid,
 		&i.InvalidatedAt,
 		&i.InvalidationReason,
+		&i.ReplacesBallotID,
+		&i.PrevHash,
+		&i.ChainHash,
 	)
 	return i, err
 }
@@ -137,8 +314,8 @@ INSERT INTO gatherings (association_id, title, description, intent, location, ga
                         gathering_type, voting_mode, status, qualification_unit_types,
                         qualification_floors, qualification_entrances,
                         qualification_custom_rule, qualified_units_count, qualified_units_total_part,
-                        qualified_units_total_area)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode
+                        qualified_units_total_area, opens_at, closes_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode, opens_at, closes_at, voting_rules, repeated_from_id, register_frozen_at, member_verification, co_ownership_policy, voting_windows
 `
 
 type CreateGatheringParams struct {
@@ -158,6 +335,8 @@ type CreateGatheringParams struct {
 	QualifiedUnitsCount     sql.NullInt64
 	QualifiedUnitsTotalPart sql.NullFloat64
 	QualifiedUnitsTotalArea sql.NullFloat64
+	OpensAt                 sql.NullTime
+	ClosesAt                sql.NullTime
 }
 
 func (q *Queries) CreateGathering(ctx context.Context, arg CreateGatheringParams) (Gathering, error) {
@@ -178,6 +357,8 @@ func (q *Queries) CreateGathering(ctx context.Context, arg CreateGatheringParams
 		arg.QualifiedUnitsCount,
 		arg.QualifiedUnitsTotalPart,
 		arg.QualifiedUnitsTotalArea,
+		arg.OpensAt,
+		arg.ClosesAt,
 	)
 	var i Gathering
 	err := row.Scan(
@@ -203,6 +384,14 @@ func (q *Queries) CreateGathering(ctx context.Context, arg CreateGatheringParams
 		&i.UpdatedAt,
 		&i.Location,
 		&i.VotingMode,
+		&i.OpensAt,
+		&i.ClosesAt,
+		&i.VotingRules,
+		&i.RepeatedFromID,
+		&i.RegisterFrozenAt,
+		&i.MemberVerification,
+		&i.CoOwnershipPolicy,
+		&i.VotingWindows,
 	)
 	return i, err
 }
@@ -211,7 +400,7 @@ const createGatheringParticipant = `-- name: CreateGatheringParticipant :one
 INSERT INTO gathering_participants (gathering_id, participant_type, participant_name,
                                     participant_identification, owner_id, delegating_owner_id,
                                     delegation_document_ref, units_info, units_area, units_part)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, gathering_id, participant_type, participant_name, participant_identification, owner_id, delegating_owner_id, delegation_document_ref, units_info, units_area, units_part, check_in_time, created_at, updated_at
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, gathering_id, participant_type, participant_name, participant_identification, owner_id, delegating_owner_id, delegation_document_ref, units_info, units_area, units_part, check_in_time, created_at, updated_at, check_out_time
 `
 
 type CreateGatheringParticipantParams struct {
@@ -256,6 +445,7 @@ func (q *Queries) CreateGatheringParticipant(ctx context.Context, arg CreateGath
 		&i.CheckInTime,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CheckOutTime,
 	)
 	return i, err
 }
@@ -266,7 +456,7 @@ VALUES (?, ?, ?, ?) ON CONFLICT (gathering_id, owner_id, notification_type)
 DO
 UPDATE SET
     sent_at = CURRENT_TIMESTAMP,
-    sent_via = ?
+    sent_via = excluded.sent_via
     RETURNING id, gathering_id, owner_id, notification_type, sent_at, sent_via, read_at
 `
 
@@ -297,18 +487,150 @@ func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotification
 	return i, err
 }
 
+const createRepeatedGathering = `-- name: CreateRepeatedGathering :one
+INSERT INTO gatherings (association_id, title, description, intent, location, gathering_date,
+                        gathering_type, voting_mode, status, qualification_unit_types,
+                        qualification_floors, qualification_entrances, qualification_custom_rule,
+                        repeated_from_id)
+SELECT association_id,
+       ?,
+       description,
+       intent,
+       ?,
+       ?,
+       'repeated',
+       voting_mode,
+       'draft',
+       qualification_unit_types,
+       qualification_floors,
+       qualification_entrances,
+       qualification_custom_rule,
+       id
+FROM gatherings
+WHERE id = ?
+  AND association_id = ? RETURNING id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode, opens_at, closes_at, voting_rules, repeated_from_id, register_frozen_at, member_verification, co_ownership_policy, voting_windows
+`
+
+type CreateRepeatedGatheringParams struct {
+	Title         string
+	Location      string
+	GatheringDate time.Time
+	SourceID      int64
+	AssociationID int64
+}
+
+func (q *Queries) CreateRepeatedGathering(ctx context.Context, arg CreateRepeatedGatheringParams) (Gathering, error) {
+	row := q.db.QueryRowContext(ctx, createRepeatedGathering,
+		arg.Title,
+		arg.Location,
+		arg.GatheringDate,
+		arg.SourceID,
+		arg.AssociationID,
+	)
+	var i Gathering
+	err := row.Scan(
+		&i.ID,
+		&i.AssociationID,
+		&i.Title,
+		&i.Description,
+		&i.Intent,
+		&i.GatheringDate,
+		&i.GatheringType,
+		&i.Status,
+		&i.QualificationUnitTypes,
+		&i.QualificationFloors,
+		&i.QualificationEntrances,
+		&i.QualificationCustomRule,
+		&i.QualifiedUnitsCount,
+		&i.QualifiedUnitsTotalPart,
+		&i.QualifiedUnitsTotalArea,
+		&i.ParticipatingUnitsCount,
+		&i.ParticipatingUnitsTotalPart,
+		&i.ParticipatingUnitsTotalArea,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Location,
+		&i.VotingMode,
+		&i.OpensAt,
+		&i.ClosesAt,
+		&i.VotingRules,
+		&i.RepeatedFromID,
+		&i.RegisterFrozenAt,
+		&i.MemberVerification,
+		&i.CoOwnershipPolicy,
+		&i.VotingWindows,
+	)
+	return i, err
+}
+
+const createReplacementBallot = `-- name: CreateReplacementBallot :one
+INSERT INTO voting_ballots (gathering_id, participant_id, ballot_content, ballot_hash,
+                            submitted_ip, submitted_user_agent, replaces_ballot_id)
+VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id, gathering_id, participant_id, ballot_content, ballot_hash, submitted_at, submitted_ip, submitted_user_agent, signature, signature_timestamp, signature_certificate, is_valid, invalidated_at, invalidation_reason, replaces_ballot_id, prev_hash, chain_hash
+`
+
+type CreateReplacementBallotParams struct {
+	GatheringID        int64
+	ParticipantID      int64
+	BallotContent      string
+	BallotHash         string
+	SubmittedIp        sql.NullString
+	SubmittedUserAgent sql.NullString
+	ReplacesBallotID   sql.NullInt64
+}
+
+func (q *Queries) CreateReplacementBallot(ctx context.Context, arg CreateReplacementBallotParams) (VotingBallot, error) {
+	row := q.db.QueryRowContext(ctx, createReplacementBallot,
+		arg.GatheringID,
+		arg.ParticipantID,
+		arg.BallotContent,
+		arg.BallotHash,
+		arg.SubmittedIp,
+		arg.SubmittedUserAgent,
+		arg.ReplacesBallotID,
+	)
+	var i VotingBallot
+	err := row.Scan(
+		&i.ID,
+		&i.GatheringID,
+		&i.ParticipantID,
+		&i.BallotContent,
+		&i.BallotHash,
+		&i.SubmittedAt,
+		&i.SubmittedIp,
+		&i.SubmittedUserAgent,
+		&i.Signature,
+		&i.SignatureTimestamp,
+		&i.SignatureCertificate,
+		&i.IsValid,
+		&i.InvalidatedAt,
+		&i.InvalidationReason,
+		&i.ReplacesBallotID,
+		&i.PrevHash,
+		&i.ChainHash,
+	)
+	return i, err
+}
+
 const createUnitSlot = `-- name: CreateUnitSlot :one
-INSERT INTO unit_slots (gathering_id, unit_id)
-VALUES (?, ?) RETURNING id, gathering_id, unit_id, participant_id, created_at, updated_at
+INSERT INTO unit_slots (gathering_id, unit_id, owner_id, share)
+VALUES (?, ?, ?, ?) RETURNING id, gathering_id, unit_id, participant_id, created_at, updated_at, owner_id, share
 `
 
 type CreateUnitSlotParams struct {
 	GatheringID int64
 	UnitID      int64
+	OwnerID     int64
+	Share       float64
 }
 
 func (q *Queries) CreateUnitSlot(ctx context.Context, arg CreateUnitSlotParams) (UnitSlot, error) {
-	row := q.db.QueryRowContext(ctx, createUnitSlot, arg.GatheringID, arg.UnitID)
+	row := q.db.QueryRowContext(ctx, createUnitSlot,
+		arg.GatheringID,
+		arg.UnitID,
+		arg.OwnerID,
+		arg.Share,
+	)
 	var i UnitSlot
 	err := row.Scan(
 		&i.ID,
@@ -317,13 +639,15 @@ func (q *Queries) CreateUnitSlot(ctx context.Context, arg CreateUnitSlotParams)
 		&i.ParticipantID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OwnerID,
+		&i.Share,
 	)
 	return i, err
 }
 
 const createVotingMatter = `-- name: CreateVotingMatter :one
 INSERT INTO voting_matters (gathering_id, order_index, title, title_ru, description, description_ru, matter_type, voting_config, is_informative)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, gathering_id, order_index, title, description, matter_type, voting_config, created_at, updated_at, is_informative, title_ru, description_ru
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, gathering_id, order_index, title, description, matter_type, voting_config, created_at, updated_at, is_informative, title_ru, description_ru, voting_state, voting_opened_at, voting_closed_at
 `
 
 type CreateVotingMatterParams struct {
@@ -364,6 +688,9 @@ func (q *Queries) CreateVotingMatter(ctx context.Context, arg CreateVotingMatter
 		&i.IsInformative,
 		&i.TitleRu,
 		&i.DescriptionRu,
+		&i.VotingState,
+		&i.VotingOpenedAt,
+		&i.VotingClosedAt,
 	)
 	return i, err
 }
@@ -385,6 +712,18 @@ func (q *Queries) DeleteGatheringParticipant(ctx context.Context, arg DeleteGath
 	return err
 }
 
+const deleteUnassignedUnitSlots = `-- name: DeleteUnassignedUnitSlots :exec
+DELETE
+FROM unit_slots
+WHERE gathering_id = ?
+  AND participant_id IS NULL
+`
+
+func (q *Queries) DeleteUnassignedUnitSlots(ctx context.Context, gatheringID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUnassignedUnitSlots, gatheringID)
+	return err
+}
+
 const deleteVotingMatter = `-- name: DeleteVotingMatter :exec
 DELETE
 FROM voting_matters
@@ -417,6 +756,7 @@ SELECT o.id                    as owner_id,
        u.unit_type,
        u.floor,
        u.entrance,
+       u.room_count,
        b.id                    as building_id,
        b.name                  as building_name,
        b.address               as building_address,
@@ -460,6 +800,7 @@ type GetActiveOwnerUnitsForGatheringRow struct {
 	UnitType            string
 	Floor               int64
 	Entrance            int64
+	RoomCount           int64
 	BuildingID          int64
 	BuildingName        string
 	BuildingAddress     string
@@ -521,6 +862,7 @@ func (q *Queries) GetActiveOwnerUnitsForGathering(ctx context.Context, arg GetAc
 			&i.UnitType,
 			&i.Floor,
 			&i.Entrance,
+			&i.RoomCount,
 			&i.BuildingID,
 			&i.BuildingName,
 			&i.BuildingAddress,
@@ -541,7 +883,7 @@ func (q *Queries) GetActiveOwnerUnitsForGathering(ctx context.Context, arg GetAc
 }
 
 const getAllVoteTallies = `-- name: GetAllVoteTallies :many
-SELECT vt.id, vt.gathering_id, vt.voting_matter_id, vt.tally_data, vt.last_updated,
+SELECT vt.id, vt.gathering_id, vt.voting_matter_id, vt.tally_data, vt.last_updated, vt.breakdown_data,
        vm.title as matter_title,
        vm.matter_type,
        vm.voting_config
@@ -557,6 +899,7 @@ type GetAllVoteTalliesRow struct {
 	VotingMatterID int64
 	TallyData      string
 	LastUpdated    sql.NullTime
+	BreakdownData  sql.NullString
 	MatterTitle    string
 	MatterType     string
 	VotingConfig   string
@@ -577,6 +920,7 @@ func (q *Queries) GetAllVoteTallies(ctx context.Context, gatheringID int64) ([]G
 			&i.VotingMatterID,
 			&i.TallyData,
 			&i.LastUpdated,
+			&i.BreakdownData,
 			&i.MatterTitle,
 			&i.MatterType,
 			&i.VotingConfig,
@@ -594,8 +938,70 @@ func (q *Queries) GetAllVoteTallies(ctx context.Context, gatheringID int64) ([]G
 	return items, nil
 }
 
+const getAuditLogChain = `-- name: GetAuditLogChain :many
+SELECT id, gathering_id, entity_type, entity_id, "action", performed_by, performed_at, ip_address, details, prev_hash, chain_hash
+FROM voting_audit_log
+WHERE gathering_id = ?
+ORDER BY id
+`
+
+func (q *Queries) GetAuditLogChain(ctx context.Context, gatheringID int64) ([]VotingAuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, getAuditLogChain, gatheringID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VotingAuditLog
+	for rows.Next() {
+		var i VotingAuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.GatheringID,
+			&i.EntityType,
+			&i.EntityID,
+			&i.Action,
+			&i.PerformedBy,
+			&i.PerformedAt,
+			&i.IpAddress,
+			&i.Details,
+			&i.PrevHash,
+			&i.ChainHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAuditLogChainHead = `-- name: GetAuditLogChainHead :one
+SELECT id, chain_hash
+FROM voting_audit_log
+WHERE gathering_id = ?
+  AND chain_hash IS NOT NULL
+ORDER BY id DESC LIMIT 1
+`
+
+type GetAuditLogChainHeadRow struct {
+	ID        int64
+	ChainHash sql.NullString
+}
+
+func (q *Queries) GetAuditLogChainHead(ctx context.Context, gatheringID int64) (GetAuditLogChainHeadRow, error) {
+	row := q.db.QueryRowContext(ctx, getAuditLogChainHead, gatheringID)
+	var i GetAuditLogChainHeadRow
+	err := row.Scan(&i.ID, &i.ChainHash)
+	return i, err
+}
+
 const getAuditLogs = `-- name: GetAuditLogs :many
-SELECT id, gathering_id, entity_type, entity_id, "action", performed_by, performed_at, ip_address, details
+SELECT id, gathering_id, entity_type, entity_id, "action", performed_by, performed_at, ip_address, details, prev_hash, chain_hash
 FROM voting_audit_log
 WHERE gathering_id = ?
 ORDER BY performed_at DESC LIMIT ?
@@ -625,6 +1031,8 @@ func (q *Queries) GetAuditLogs(ctx context.Context, arg GetAuditLogsParams) ([]V
 			&i.PerformedAt,
 			&i.IpAddress,
 			&i.Details,
+			&i.PrevHash,
+			&i.ChainHash,
 		); err != nil {
 			return nil, err
 		}
@@ -639,20 +1047,20 @@ func (q *Queries) GetAuditLogs(ctx context.Context, arg GetAuditLogsParams) ([]V
 	return items, nil
 }
 
-const getBallotByParticipant = `-- name: GetBallotByParticipant :one
-SELECT id, gathering_id, participant_id, ballot_content, ballot_hash, submitted_at, submitted_ip, submitted_user_agent, signature, signature_timestamp, signature_certificate, is_valid, invalidated_at, invalidation_reason
+const getBallot = `-- name: GetBallot :one
+SELECT id, gathering_id, participant_id, ballot_content, ballot_hash, submitted_at, submitted_ip, submitted_user_agent, signature, signature_timestamp, signature_certificate, is_valid, invalidated_at, invalidation_reason, replaces_ballot_id, prev_hash, chain_hash
 FROM voting_ballots
-WHERE gathering_id = ?
-  AND participant_id = ?
+WHERE id = ?
+  AND gathering_id = ?
 `
 
-type GetBallotByParticipantParams struct {
-	GatheringID   int64
-	ParticipantID int64
+type GetBallotParams struct {
+	ID          int64
+	GatheringID int64
 }
 
-func (q *Queries) GetBallotByParticipant(ctx context.Context, arg GetBallotByParticipantParams) (VotingBallot, error) {
-	row := q.db.QueryRowContext(ctx, getBallotByParticipant, arg.GatheringID, arg.ParticipantID)
+func (q *Queries) GetBallot(ctx context.Context, arg GetBallotParams) (VotingBallot, error) {
+	row := q.db.QueryRowContext(ctx, getBallot, arg.ID, arg.GatheringID)
 	var i VotingBallot
 	err := row.Scan(
 		&i.ID,
@@ -669,15 +1077,125 @@ func (q *Queries) GetBallotByParticipant(ctx context.Context, arg GetBallotByPar
 		&i.IsValid,
 		&i.InvalidatedAt,
 		&i.InvalidationReason,
+		&i.ReplacesBallotID,
+		&i.PrevHash,
+		&i.ChainHash,
 	)
 	return i, err
 }
 
-const getBallotsForGathering = `-- name: GetBallotsForGathering :many
-SELECT vb.id, vb.gathering_id, vb.participant_id, vb.ballot_content, vb.ballot_hash, vb.submitted_at, vb.submitted_ip, vb.submitted_user_agent, vb.signature, vb.signature_timestamp, vb.signature_certificate, vb.is_valid, vb.invalidated_at, vb.invalidation_reason,
-       gp.participant_name,
-       gp.units_info,
-       gp.units_area,
+const getBallotByParticipant = `-- name: GetBallotByParticipant :one
+SELECT id, gathering_id, participant_id, ballot_content, ballot_hash, submitted_at, submitted_ip, submitted_user_agent, signature, signature_timestamp, signature_certificate, is_valid, invalidated_at, invalidation_reason, replaces_ballot_id, prev_hash, chain_hash
+FROM voting_ballots
+WHERE gathering_id = ?
+  AND participant_id = ?
+ORDER BY is_valid DESC, id DESC
+LIMIT 1
+`
+
+type GetBallotByParticipantParams struct {
+	GatheringID   int64
+	ParticipantID int64
+}
+
+func (q *Queries) GetBallotByParticipant(ctx context.Context, arg GetBallotByParticipantParams) (VotingBallot, error) {
+	row := q.db.QueryRowContext(ctx, getBallotByParticipant, arg.GatheringID, arg.ParticipantID)
+	var i VotingBallot
+	err := row.Scan(
+		&i.ID,
+		&i.GatheringID,
+		&i.ParticipantID,
+		&i.BallotContent,
+		&i.BallotHash,
+		&i.SubmittedAt,
+		&i.SubmittedIp,
+		&i.SubmittedUserAgent,
+		&i.Signature,
+		&i.SignatureTimestamp,
+		&i.SignatureCertificate,
+		&i.IsValid,
+		&i.InvalidatedAt,
+		&i.InvalidationReason,
+		&i.ReplacesBallotID,
+		&i.PrevHash,
+		&i.ChainHash,
+	)
+	return i, err
+}
+
+const getBallotChain = `-- name: GetBallotChain :many
+SELECT id, gathering_id, participant_id, ballot_content, ballot_hash, submitted_at, submitted_ip, submitted_user_agent, signature, signature_timestamp, signature_certificate, is_valid, invalidated_at, invalidation_reason, replaces_ballot_id, prev_hash, chain_hash
+FROM voting_ballots
+WHERE gathering_id = ?
+ORDER BY id
+`
+
+func (q *Queries) GetBallotChain(ctx context.Context, gatheringID int64) ([]VotingBallot, error) {
+	rows, err := q.db.QueryContext(ctx, getBallotChain, gatheringID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VotingBallot
+	for rows.Next() {
+		var i VotingBallot
+		if err := rows.Scan(
+			&i.ID,
+			&i.GatheringID,
+			&i.ParticipantID,
+			&i.BallotContent,
+			&i.BallotHash,
+			&i.SubmittedAt,
+			&i.SubmittedIp,
+			&i.SubmittedUserAgent,
+			&i.Signature,
+			&i.SignatureTimestamp,
+			&i.SignatureCertificate,
+			&i.IsValid,
+			&i.InvalidatedAt,
+			&i.InvalidationReason,
+			&i.ReplacesBallotID,
+			&i.PrevHash,
+			&i.ChainHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBallotChainHead = `-- name: GetBallotChainHead :one
+SELECT id, chain_hash
+FROM voting_ballots
+WHERE gathering_id = ?
+  AND chain_hash IS NOT NULL
+ORDER BY id DESC LIMIT 1
+`
+
+type GetBallotChainHeadRow struct {
+	ID        int64
+	ChainHash sql.NullString
+}
+
+func (q *Queries) GetBallotChainHead(ctx context.Context, gatheringID int64) (GetBallotChainHeadRow, error) {
+	row := q.db.QueryRowContext(ctx, getBallotChainHead, gatheringID)
+	var i GetBallotChainHeadRow
+	err := row.Scan(&i.ID, &i.ChainHash)
+	return i, err
+}
+
+const getBallotsForGathering = `-- name: GetBallotsForGathering :many
+SELECT vb.id, vb.gathering_id, vb.participant_id, vb.ballot_content, vb.ballot_hash, vb.submitted_at, vb.submitted_ip, vb.submitted_user_agent, vb.signature, vb.signature_timestamp, vb.signature_certificate, vb.is_valid, vb.invalidated_at, vb.invalidation_reason, vb.replaces_ballot_id, vb.prev_hash, vb.chain_hash,
+       gp.participant_name,
+       gp.units_info,
+       gp.units_area,
        gp.units_part
 FROM voting_ballots vb
          JOIN gathering_participants gp ON vb.participant_id = gp.id
@@ -700,6 +1218,9 @@ type GetBallotsForGatheringRow struct {
 	IsValid              sql.NullBool
 	InvalidatedAt        sql.NullTime
 	InvalidationReason   sql.NullString
+	ReplacesBallotID     sql.NullInt64
+	PrevHash             sql.NullString
+	ChainHash            sql.NullString
 	ParticipantName      string
 	UnitsInfo            string
 	UnitsArea            float64
@@ -730,6 +1251,9 @@ func (q *Queries) GetBallotsForGathering(ctx context.Context, gatheringID int64)
 			&i.IsValid,
 			&i.InvalidatedAt,
 			&i.InvalidationReason,
+			&i.ReplacesBallotID,
+			&i.PrevHash,
+			&i.ChainHash,
 			&i.ParticipantName,
 			&i.UnitsInfo,
 			&i.UnitsArea,
@@ -748,33 +1272,86 @@ func (q *Queries) GetBallotsForGathering(ctx context.Context, gatheringID int64)
 	return items, nil
 }
 
+const getCoOwnerUnitSlots = `-- name: GetCoOwnerUnitSlots :many
+SELECT us.unit_id,
+       us.owner_id,
+       us.participant_id,
+       us.share,
+       vru.part
+FROM unit_slots us
+         JOIN voter_register_units vru ON vru.gathering_id = us.gathering_id AND vru.unit_id = us.unit_id
+WHERE us.gathering_id = ?
+  AND us.owner_id != 0
+  AND us.share < 1
+ORDER BY us.unit_id, us.owner_id
+`
+
+type GetCoOwnerUnitSlotsRow struct {
+	UnitID        int64
+	OwnerID       int64
+	ParticipantID interface{}
+	Share         float64
+	Part          float64
+}
+
+func (q *Queries) GetCoOwnerUnitSlots(ctx context.Context, gatheringID int64) ([]GetCoOwnerUnitSlotsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCoOwnerUnitSlots, gatheringID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCoOwnerUnitSlotsRow
+	for rows.Next() {
+		var i GetCoOwnerUnitSlotsRow
+		if err := rows.Scan(
+			&i.UnitID,
+			&i.OwnerID,
+			&i.ParticipantID,
+			&i.Share,
+			&i.Part,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getEligibleVotersWithUnits = `-- name: GetEligibleVotersWithUnits :many
-SELECT o.id                    as owner_id,
-       o.name                  as owner_name,
-       o.identification_number as owner_identification,
-       o.contact_email         as owner_contact_email,
-       o.contact_phone         as owner_contact_phone,
-       u.id                    as unit_id,
-       u.unit_number,
-       u.cadastral_number,
-       u.floor,
-       u.entrance,
-       u.area,
-       u.part                  as voting_weight,
-       u.unit_type,
-       b.name                  as building_name,
-       b.address               as building_address,
+SELECT vro.owner_id,
+       vro.owner_name,
+       vro.owner_identification,
+       vro.owner_contact_email,
+       vro.owner_contact_phone,
+       vru.unit_id,
+       vru.unit_number,
+       vru.cadastral_number,
+       vru.floor,
+       vru.entrance,
+       vru.area,
+       vru.part * us.share     as voting_weight,
+       vru.unit_type,
+       vru.building_name,
+       vru.building_address,
+       us.share,
        us.participant_id       as assigned_participant_id,
        CASE WHEN us.participant_id IS NULL THEN 1 ELSE 0 END as is_available
-FROM owners o
-         JOIN ownerships own ON o.id = own.owner_id
-         JOIN units u ON own.unit_id = u.id
-         JOIN buildings b ON u.building_id = b.id
-         JOIN unit_slots us ON us.unit_id = u.id AND us.gathering_id = ?
-WHERE own.is_active = TRUE
-  AND own.is_voting = TRUE
-  AND b.association_id = ?
-ORDER BY o.name, u.unit_number
+FROM voter_register_units vru
+         JOIN voter_register_owners vro
+              ON vro.gathering_id = vru.gathering_id AND vro.unit_id = vru.unit_id
+         JOIN unit_slots us ON us.unit_id = vru.unit_id AND us.gathering_id = vru.gathering_id
+                                   AND us.owner_id IN (0, vro.owner_id)
+         JOIN gatherings g ON g.id = vru.gathering_id
+WHERE vru.gathering_id = ?
+  AND g.association_id = ?
+  AND (g.co_ownership_policy != 'designated' OR vro.is_voting = TRUE)
+ORDER BY vro.owner_name, vru.unit_number
 `
 
 type GetEligibleVotersWithUnitsParams struct {
@@ -798,6 +1375,7 @@ type GetEligibleVotersWithUnitsRow struct {
 	UnitType              string
 	BuildingName          string
 	BuildingAddress       string
+	Share                 float64
 	AssignedParticipantID interface{}
 	IsAvailable           int64
 }
@@ -827,6 +1405,7 @@ func (q *Queries) GetEligibleVotersWithUnits(ctx context.Context, arg GetEligibl
 			&i.UnitType,
 			&i.BuildingName,
 			&i.BuildingAddress,
+			&i.Share,
 			&i.AssignedParticipantID,
 			&i.IsAvailable,
 		); err != nil {
@@ -844,7 +1423,7 @@ func (q *Queries) GetEligibleVotersWithUnits(ctx context.Context, arg GetEligibl
 }
 
 const getGathering = `-- name: GetGathering :one
-SELECT id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode
+SELECT id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode, opens_at, closes_at, voting_rules, repeated_from_id, register_frozen_at, member_verification, co_ownership_policy, voting_windows
 FROM gatherings
 WHERE id = ?
   AND association_id = ?
@@ -881,6 +1460,14 @@ func (q *Queries) GetGathering(ctx context.Context, arg GetGatheringParams) (Gat
 		&i.UpdatedAt,
 		&i.Location,
 		&i.VotingMode,
+		&i.OpensAt,
+		&i.ClosesAt,
+		&i.VotingRules,
+		&i.RepeatedFromID,
+		&i.RegisterFrozenAt,
+		&i.MemberVerification,
+		&i.CoOwnershipPolicy,
+		&i.VotingWindows,
 	)
 	return i, err
 }
@@ -899,7 +1486,7 @@ func (q *Queries) GetGatheringBallotCount(ctx context.Context, gatheringID int64
 }
 
 const getGatheringByID = `-- name: GetGatheringByID :one
-SELECT id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode
+SELECT id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode, opens_at, closes_at, voting_rules, repeated_from_id, register_frozen_at, member_verification, co_ownership_policy, voting_windows
 FROM gatherings
 WHERE id = ?
 `
@@ -930,12 +1517,20 @@ func (q *Queries) GetGatheringByID(ctx context.Context, id int64) (Gathering, er
 		&i.UpdatedAt,
 		&i.Location,
 		&i.VotingMode,
+		&i.OpensAt,
+		&i.ClosesAt,
+		&i.VotingRules,
+		&i.RepeatedFromID,
+		&i.RegisterFrozenAt,
+		&i.MemberVerification,
+		&i.CoOwnershipPolicy,
+		&i.VotingWindows,
 	)
 	return i, err
 }
 
 const getGatheringParticipant = `-- name: GetGatheringParticipant :one
-SELECT id, gathering_id, participant_type, participant_name, participant_identification, owner_id, delegating_owner_id, delegation_document_ref, units_info, units_area, units_part, check_in_time, created_at, updated_at
+SELECT id, gathering_id, participant_type, participant_name, participant_identification, owner_id, delegating_owner_id, delegation_document_ref, units_info, units_area, units_part, check_in_time, created_at, updated_at, check_out_time
 FROM gathering_participants
 WHERE id = ?
   AND gathering_id = ?
@@ -964,12 +1559,13 @@ func (q *Queries) GetGatheringParticipant(ctx context.Context, arg GetGatheringP
 		&i.CheckInTime,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CheckOutTime,
 	)
 	return i, err
 }
 
 const getGatheringParticipantByOwner = `-- name: GetGatheringParticipantByOwner :one
-SELECT id, gathering_id, participant_type, participant_name, participant_identification, owner_id, delegating_owner_id, delegation_document_ref, units_info, units_area, units_part, check_in_time, created_at, updated_at
+SELECT id, gathering_id, participant_type, participant_name, participant_identification, owner_id, delegating_owner_id, delegation_document_ref, units_info, units_area, units_part, check_in_time, created_at, updated_at, check_out_time
 FROM gathering_participants
 WHERE gathering_id = ?
   AND owner_id = ?
@@ -999,6 +1595,7 @@ func (q *Queries) GetGatheringParticipantByOwner(ctx context.Context, arg GetGat
 		&i.CheckInTime,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CheckOutTime,
 	)
 	return i, err
 }
@@ -1017,7 +1614,7 @@ func (q *Queries) GetGatheringParticipantCount(ctx context.Context, gatheringID
 }
 
 const getGatheringParticipants = `-- name: GetGatheringParticipants :many
-SELECT gp.id, gp.gathering_id, gp.participant_type, gp.participant_name, gp.participant_identification, gp.owner_id, gp.delegating_owner_id, gp.delegation_document_ref, gp.units_info, gp.units_area, gp.units_part, gp.check_in_time, gp.created_at, gp.updated_at,
+SELECT gp.id, gp.gathering_id, gp.participant_type, gp.participant_name, gp.participant_identification, gp.owner_id, gp.delegating_owner_id, gp.delegation_document_ref, gp.units_info, gp.units_area, gp.units_part, gp.check_in_time, gp.created_at, gp.updated_at, gp.check_out_time,
        o.name                  as owner_name,
        o.identification_number as owner_identification,
        delo.name               as delegating_owner_name
@@ -1043,6 +1640,7 @@ type GetGatheringParticipantsRow struct {
 	CheckInTime               sql.NullTime
 	CreatedAt                 sql.NullTime
 	UpdatedAt                 sql.NullTime
+	CheckOutTime              sql.NullTime
 	OwnerName                 sql.NullString
 	OwnerIdentification       sql.NullString
 	DelegatingOwnerName       sql.NullString
@@ -1072,6 +1670,7 @@ func (q *Queries) GetGatheringParticipants(ctx context.Context, gatheringID int6
 			&i.CheckInTime,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CheckOutTime,
 			&i.OwnerName,
 			&i.OwnerIdentification,
 			&i.DelegatingOwnerName,
@@ -1090,7 +1689,7 @@ func (q *Queries) GetGatheringParticipants(ctx context.Context, gatheringID int6
 }
 
 const getGatheringStats = `-- name: GetGatheringStats :one
-SELECT id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode
+SELECT id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode, opens_at, closes_at, voting_rules, repeated_from_id, register_frozen_at, member_verification, co_ownership_policy, voting_windows
 FROM gatherings
 WHERE id = ?
   AND association_id = ?
@@ -1127,12 +1726,20 @@ func (q *Queries) GetGatheringStats(ctx context.Context, arg GetGatheringStatsPa
 		&i.UpdatedAt,
 		&i.Location,
 		&i.VotingMode,
+		&i.OpensAt,
+		&i.ClosesAt,
+		&i.VotingRules,
+		&i.RepeatedFromID,
+		&i.RegisterFrozenAt,
+		&i.MemberVerification,
+		&i.CoOwnershipPolicy,
+		&i.VotingWindows,
 	)
 	return i, err
 }
 
 const getGatheringUnitSlots = `-- name: GetGatheringUnitSlots :many
-SELECT us.id, us.gathering_id, us.unit_id, us.participant_id, us.created_at, us.updated_at,
+SELECT us.id, us.gathering_id, us.unit_id, us.participant_id, us.created_at, us.updated_at, us.owner_id, us.share,
        u.unit_number,
        u.floor,
        u.entrance,
@@ -1150,6 +1757,8 @@ type GetGatheringUnitSlotsRow struct {
 	ParticipantID interface{}
 	CreatedAt     sql.NullTime
 	UpdatedAt     sql.NullTime
+	OwnerID       int64
+	Share         float64
 	UnitNumber    string
 	Floor         int64
 	Entrance      int64
@@ -1172,6 +1781,8 @@ func (q *Queries) GetGatheringUnitSlots(ctx context.Context, gatheringID int64)
 			&i.ParticipantID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.OwnerID,
+			&i.Share,
 			&i.UnitNumber,
 			&i.Floor,
 			&i.Entrance,
@@ -1191,7 +1802,7 @@ func (q *Queries) GetGatheringUnitSlots(ctx context.Context, gatheringID int64)
 }
 
 const getGatherings = `-- name: GetGatherings :many
-SELECT id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode
+SELECT id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode, opens_at, closes_at, voting_rules, repeated_from_id, register_frozen_at, member_verification, co_ownership_policy, voting_windows
 FROM gatherings
 WHERE association_id = ?
 ORDER BY gathering_date DESC
@@ -1229,6 +1840,142 @@ func (q *Queries) GetGatherings(ctx context.Context, associationID int64) ([]Gat
 			&i.UpdatedAt,
 			&i.Location,
 			&i.VotingMode,
+			&i.OpensAt,
+			&i.ClosesAt,
+			&i.VotingRules,
+			&i.RepeatedFromID,
+			&i.RegisterFrozenAt,
+			&i.MemberVerification,
+			&i.CoOwnershipPolicy,
+			&i.VotingWindows,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGatheringsDueToClose = `-- name: GetGatheringsDueToClose :many
+SELECT id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode, opens_at, closes_at, voting_rules, repeated_from_id, register_frozen_at, member_verification, co_ownership_policy, voting_windows
+FROM gatherings
+WHERE status = 'active'
+  AND gathering_type = 'remote'
+  AND closes_at IS NOT NULL
+  AND closes_at <= ?
+ORDER BY closes_at
+`
+
+func (q *Queries) GetGatheringsDueToClose(ctx context.Context, now sql.NullTime) ([]Gathering, error) {
+	rows, err := q.db.QueryContext(ctx, getGatheringsDueToClose, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Gathering
+	for rows.Next() {
+		var i Gathering
+		if err := rows.Scan(
+			&i.ID,
+			&i.AssociationID,
+			&i.Title,
+			&i.Description,
+			&i.Intent,
+			&i.GatheringDate,
+			&i.GatheringType,
+			&i.Status,
+			&i.QualificationUnitTypes,
+			&i.QualificationFloors,
+			&i.QualificationEntrances,
+			&i.QualificationCustomRule,
+			&i.QualifiedUnitsCount,
+			&i.QualifiedUnitsTotalPart,
+			&i.QualifiedUnitsTotalArea,
+			&i.ParticipatingUnitsCount,
+			&i.ParticipatingUnitsTotalPart,
+			&i.ParticipatingUnitsTotalArea,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Location,
+			&i.VotingMode,
+			&i.OpensAt,
+			&i.ClosesAt,
+			&i.VotingRules,
+			&i.RepeatedFromID,
+			&i.RegisterFrozenAt,
+			&i.MemberVerification,
+			&i.CoOwnershipPolicy,
+			&i.VotingWindows,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGatheringsDueToOpen = `-- name: GetGatheringsDueToOpen :many
+SELECT id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode, opens_at, closes_at, voting_rules, repeated_from_id, register_frozen_at, member_verification, co_ownership_policy, voting_windows
+FROM gatherings
+WHERE status = 'published'
+  AND gathering_type = 'remote'
+  AND opens_at IS NOT NULL
+  AND opens_at <= ?
+ORDER BY opens_at
+`
+
+func (q *Queries) GetGatheringsDueToOpen(ctx context.Context, now sql.NullTime) ([]Gathering, error) {
+	rows, err := q.db.QueryContext(ctx, getGatheringsDueToOpen, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Gathering
+	for rows.Next() {
+		var i Gathering
+		if err := rows.Scan(
+			&i.ID,
+			&i.AssociationID,
+			&i.Title,
+			&i.Description,
+			&i.Intent,
+			&i.GatheringDate,
+			&i.GatheringType,
+			&i.Status,
+			&i.QualificationUnitTypes,
+			&i.QualificationFloors,
+			&i.QualificationEntrances,
+			&i.QualificationCustomRule,
+			&i.QualifiedUnitsCount,
+			&i.QualifiedUnitsTotalPart,
+			&i.QualifiedUnitsTotalArea,
+			&i.ParticipatingUnitsCount,
+			&i.ParticipatingUnitsTotalPart,
+			&i.ParticipatingUnitsTotalArea,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Location,
+			&i.VotingMode,
+			&i.OpensAt,
+			&i.ClosesAt,
+			&i.VotingRules,
+			&i.RepeatedFromID,
+			&i.RegisterFrozenAt,
+			&i.MemberVerification,
+			&i.CoOwnershipPolicy,
+			&i.VotingWindows,
 		); err != nil {
 			return nil, err
 		}
@@ -1263,6 +2010,7 @@ WHERE b.association_id = ?
                  AND (false = ? OR u2.unit_type IN (/*SLICE:unit_types*/?))
                  AND (false = ? OR u2.floor IN (/*SLICE:unit_floors*/?))
                  AND (false = ? OR u2.entrance IN (/*SLICE:unit_entrances*/?)))
+  AND (false = ? OR u.id IN (/*SLICE:unit_ids*/?))
   AND u.id NOT IN (SELECT unit_id
                    FROM gathering_participants
                    WHERE gathering_id = ?)
@@ -1279,6 +2027,8 @@ type GetNonParticipatingOwnersParams struct {
 	UnitFloors      []int64
 	Column7         interface{}
 	UnitEntrances   []int64
+	Column9         interface{}
+	UnitIds         []int64
 	GatheringID     int64
 }
 
@@ -1323,6 +2073,15 @@ func (q *Queries) GetNonParticipatingOwners(ctx context.Context, arg GetNonParti
 	} else {
 		query = strings.Replace(query, "/*SLICE:unit_entrances*/?", "NULL", 1)
 	}
+	queryParams = append(queryParams, arg.Column9)
+	if len(arg.UnitIds) > 0 {
+		for _, v := range arg.UnitIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:unit_ids*/?", strings.Repeat(",?", len(arg.UnitIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:unit_ids*/?", "NULL", 1)
+	}
 	queryParams = append(queryParams, arg.GatheringID)
 	rows, err := q.db.QueryContext(ctx, query, queryParams...)
 	if err != nil {
@@ -1412,7 +2171,7 @@ func (q *Queries) GetNotifications(ctx context.Context, gatheringID int64) ([]Ge
 }
 
 const getParticipatingUnitSlots = `-- name: GetParticipatingUnitSlots :many
-SELECT us.id, us.gathering_id, us.unit_id, us.participant_id, us.created_at, us.updated_at,
+SELECT us.id, us.gathering_id, us.unit_id, us.participant_id, us.created_at, us.updated_at, us.owner_id, us.share,
        u.unit_number,
        u.floor,
        u.entrance,
@@ -1437,6 +2196,8 @@ type GetParticipatingUnitSlotsRow struct {
 	ParticipantID interface{}
 	CreatedAt     sql.NullTime
 	UpdatedAt     sql.NullTime
+	OwnerID       int64
+	Share         float64
 	UnitNumber    string
 	Floor         int64
 	Entrance      int64
@@ -1459,6 +2220,8 @@ func (q *Queries) GetParticipatingUnitSlots(ctx context.Context, arg GetParticip
 			&i.ParticipantID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.OwnerID,
+			&i.Share,
 			&i.UnitNumber,
 			&i.Floor,
 			&i.Entrance,
@@ -1478,11 +2241,11 @@ func (q *Queries) GetParticipatingUnitSlots(ctx context.Context, arg GetParticip
 }
 
 const getParticipatingUnitsStats = `-- name: GetParticipatingUnitsStats :one
-SELECT COUNT(DISTINCT us.unit_id)     as participating_units_count,
-       COALESCE(SUM(u.part), 0)       as participating_units_total_part,
-       COALESCE(SUM(u.area), 0)       as participating_units_total_area
+SELECT COUNT(DISTINCT us.unit_id)          as participating_units_count,
+       COALESCE(SUM(vru.part * us.share), 0) as participating_units_total_part,
+       COALESCE(SUM(vru.area * us.share), 0) as participating_units_total_area
 FROM unit_slots us
-         JOIN units u ON us.unit_id = u.id
+         JOIN voter_register_units vru ON vru.gathering_id = us.gathering_id AND vru.unit_id = us.unit_id
 WHERE us.gathering_id = ?
   AND us.participant_id IS NOT NULL
 `
@@ -1506,6 +2269,7 @@ SELECT u.id,
        u.cadastral_number,
        u.floor,
        u.entrance,
+       u.room_count,
        u.area,
        u.part,
        u.unit_type,
@@ -1536,6 +2300,7 @@ type GetQualifiedUnitsRow struct {
 	CadastralNumber string
 	Floor           int64
 	Entrance        int64
+	RoomCount       int64
 	Area            float64
 	Part            float64
 	UnitType        string
@@ -1588,6 +2353,7 @@ func (q *Queries) GetQualifiedUnits(ctx context.Context, arg GetQualifiedUnitsPa
 			&i.CadastralNumber,
 			&i.Floor,
 			&i.Entrance,
+			&i.RoomCount,
 			&i.Area,
 			&i.Part,
 			&i.UnitType,
@@ -1607,23 +2373,171 @@ func (q *Queries) GetQualifiedUnits(ctx context.Context, arg GetQualifiedUnitsPa
 	return items, nil
 }
 
-const getUnitSlot = `-- name: GetUnitSlot :one
-SELECT us.id, us.gathering_id, us.unit_id, us.participant_id, us.created_at, us.updated_at,
-       u.unit_number,
-       u.floor,
-       u.entrance,
-       b.name as building_name
-FROM unit_slots us
-         JOIN units u ON us.unit_id = u.id
-         JOIN buildings b ON u.building_id = b.id
-WHERE us.gathering_id = ?
-  AND us.id = ?
+const getRepeatedGathering = `-- name: GetRepeatedGathering :one
+SELECT id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode, opens_at, closes_at, voting_rules, repeated_from_id, register_frozen_at, member_verification, co_ownership_policy, voting_windows
+FROM gatherings
+WHERE repeated_from_id = ?
 `
 
-type GetUnitSlotParams struct {
-	GatheringID int64
-	ID          int64
-}
+func (q *Queries) GetRepeatedGathering(ctx context.Context, repeatedFromID sql.NullInt64) (Gathering, error) {
+	row := q.db.QueryRowContext(ctx, getRepeatedGathering, repeatedFromID)
+	var i Gathering
+	err := row.Scan(
+		&i.ID,
+		&i.AssociationID,
+		&i.Title,
+		&i.Description,
+		&i.Intent,
+		&i.GatheringDate,
+		&i.GatheringType,
+		&i.Status,
+		&i.QualificationUnitTypes,
+		&i.QualificationFloors,
+		&i.QualificationEntrances,
+		&i.QualificationCustomRule,
+		&i.QualifiedUnitsCount,
+		&i.QualifiedUnitsTotalPart,
+		&i.QualifiedUnitsTotalArea,
+		&i.ParticipatingUnitsCount,
+		&i.ParticipatingUnitsTotalPart,
+		&i.ParticipatingUnitsTotalArea,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Location,
+		&i.VotingMode,
+		&i.OpensAt,
+		&i.ClosesAt,
+		&i.VotingRules,
+		&i.RepeatedFromID,
+		&i.RegisterFrozenAt,
+		&i.MemberVerification,
+		&i.CoOwnershipPolicy,
+		&i.VotingWindows,
+	)
+	return i, err
+}
+
+const getUnchainedAuditLogs = `-- name: GetUnchainedAuditLogs :many
+SELECT id, gathering_id, entity_type, entity_id, "action", performed_by, performed_at, ip_address, details, prev_hash, chain_hash
+FROM voting_audit_log
+WHERE gathering_id = ?
+  AND id > ?
+  AND chain_hash IS NULL
+ORDER BY id
+`
+
+type GetUnchainedAuditLogsParams struct {
+	GatheringID int64
+	ID          int64
+}
+
+func (q *Queries) GetUnchainedAuditLogs(ctx context.Context, arg GetUnchainedAuditLogsParams) ([]VotingAuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, getUnchainedAuditLogs, arg.GatheringID, arg.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VotingAuditLog
+	for rows.Next() {
+		var i VotingAuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.GatheringID,
+			&i.EntityType,
+			&i.EntityID,
+			&i.Action,
+			&i.PerformedBy,
+			&i.PerformedAt,
+			&i.IpAddress,
+			&i.Details,
+			&i.PrevHash,
+			&i.ChainHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnchainedBallots = `-- name: GetUnchainedBallots :many
+SELECT id, gathering_id, participant_id, ballot_content, ballot_hash, submitted_at, submitted_ip, submitted_user_agent, signature, signature_timestamp, signature_certificate, is_valid, invalidated_at, invalidation_reason, replaces_ballot_id, prev_hash, chain_hash
+FROM voting_ballots
+WHERE gathering_id = ?
+  AND id > ?
+  AND chain_hash IS NULL
+ORDER BY id
+`
+
+type GetUnchainedBallotsParams struct {
+	GatheringID int64
+	ID          int64
+}
+
+func (q *Queries) GetUnchainedBallots(ctx context.Context, arg GetUnchainedBallotsParams) ([]VotingBallot, error) {
+	rows, err := q.db.QueryContext(ctx, getUnchainedBallots, arg.GatheringID, arg.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VotingBallot
+	for rows.Next() {
+		var i VotingBallot
+		if err := rows.Scan(
+			&i.ID,
+			&i.GatheringID,
+			&i.ParticipantID,
+			&i.BallotContent,
+			&i.BallotHash,
+			&i.SubmittedAt,
+			&i.SubmittedIp,
+			&i.SubmittedUserAgent,
+			&i.Signature,
+			&i.SignatureTimestamp,
+			&i.SignatureCertificate,
+			&i.IsValid,
+			&i.InvalidatedAt,
+			&i.InvalidationReason,
+			&i.ReplacesBallotID,
+			&i.PrevHash,
+			&i.ChainHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnitSlot = `-- name: GetUnitSlot :one
+SELECT us.id, us.gathering_id, us.unit_id, us.participant_id, us.created_at, us.updated_at, us.owner_id, us.share,
+       u.unit_number,
+       u.floor,
+       u.entrance,
+       b.name as building_name
+FROM unit_slots us
+         JOIN units u ON us.unit_id = u.id
+         JOIN buildings b ON u.building_id = b.id
+WHERE us.gathering_id = ?
+  AND us.id = ?
+`
+
+type GetUnitSlotParams struct {
+	GatheringID int64
+	ID          int64
+}
 
 type GetUnitSlotRow struct {
 	ID            int64
@@ -1632,6 +2546,8 @@ type GetUnitSlotRow struct {
 	ParticipantID interface{}
 	CreatedAt     sql.NullTime
 	UpdatedAt     sql.NullTime
+	OwnerID       int64
+	Share         float64
 	UnitNumber    string
 	Floor         int64
 	Entrance      int64
@@ -1648,6 +2564,8 @@ func (q *Queries) GetUnitSlot(ctx context.Context, arg GetUnitSlotParams) (GetUn
 		&i.ParticipantID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OwnerID,
+		&i.Share,
 		&i.UnitNumber,
 		&i.Floor,
 		&i.Entrance,
@@ -1657,7 +2575,7 @@ func (q *Queries) GetUnitSlot(ctx context.Context, arg GetUnitSlotParams) (GetUn
 }
 
 const getVoteTally = `-- name: GetVoteTally :one
-SELECT id, gathering_id, voting_matter_id, tally_data, last_updated
+SELECT id, gathering_id, voting_matter_id, tally_data, last_updated, breakdown_data
 FROM vote_tallies
 WHERE gathering_id = ?
   AND voting_matter_id = ?
@@ -1677,16 +2595,17 @@ func (q *Queries) GetVoteTally(ctx context.Context, arg GetVoteTallyParams) (Vot
 		&i.VotingMatterID,
 		&i.TallyData,
 		&i.LastUpdated,
+		&i.BreakdownData,
 	)
 	return i, err
 }
 
 const getVotedUnitsStats = `-- name: GetVotedUnitsStats :one
-SELECT COUNT(DISTINCT us.unit_id)     as voted_units_count,
-       COALESCE(SUM(u.part), 0)       as voted_units_total_part,
-       COALESCE(SUM(u.area), 0)       as voted_units_total_area
+SELECT COUNT(DISTINCT us.unit_id)          as voted_units_count,
+       COALESCE(SUM(vru.part * us.share), 0) as voted_units_total_part,
+       COALESCE(SUM(vru.area * us.share), 0) as voted_units_total_area
 FROM unit_slots us
-         JOIN units u ON us.unit_id = u.id
+         JOIN voter_register_units vru ON vru.gathering_id = us.gathering_id AND vru.unit_id = us.unit_id
          JOIN gathering_participants gp ON us.participant_id = gp.id
          JOIN voting_ballots vb ON gp.id = vb.participant_id AND vb.gathering_id = us.gathering_id
 WHERE us.gathering_id = ?
@@ -1707,7 +2626,7 @@ func (q *Queries) GetVotedUnitsStats(ctx context.Context, gatheringID int64) (Ge
 }
 
 const getVotingMatter = `-- name: GetVotingMatter :one
-SELECT id, gathering_id, order_index, title, description, matter_type, voting_config, created_at, updated_at, is_informative, title_ru, description_ru
+SELECT id, gathering_id, order_index, title, description, matter_type, voting_config, created_at, updated_at, is_informative, title_ru, description_ru, voting_state, voting_opened_at, voting_closed_at
 FROM voting_matters
 WHERE id = ?
   AND gathering_id = ?
@@ -1734,12 +2653,15 @@ func (q *Queries) GetVotingMatter(ctx context.Context, arg GetVotingMatterParams
 		&i.IsInformative,
 		&i.TitleRu,
 		&i.DescriptionRu,
+		&i.VotingState,
+		&i.VotingOpenedAt,
+		&i.VotingClosedAt,
 	)
 	return i, err
 }
 
 const getVotingMatters = `-- name: GetVotingMatters :many
-SELECT id, gathering_id, order_index, title, description, matter_type, voting_config, created_at, updated_at, is_informative, title_ru, description_ru
+SELECT id, gathering_id, order_index, title, description, matter_type, voting_config, created_at, updated_at, is_informative, title_ru, description_ru, voting_state, voting_opened_at, voting_closed_at
 FROM voting_matters
 WHERE gathering_id = ?
 ORDER BY order_index
@@ -1767,6 +2689,9 @@ func (q *Queries) GetVotingMatters(ctx context.Context, gatheringID int64) ([]Vo
 			&i.IsInformative,
 			&i.TitleRu,
 			&i.DescriptionRu,
+			&i.VotingState,
+			&i.VotingOpenedAt,
+			&i.VotingClosedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -1781,12 +2706,13 @@ func (q *Queries) GetVotingMatters(ctx context.Context, gatheringID int64) ([]Vo
 	return items, nil
 }
 
-const invalidateBallot = `-- name: InvalidateBallot :exec
+const invalidateBallot = `-- name: InvalidateBallot :execrows
 UPDATE voting_ballots
 SET is_valid            = FALSE,
     invalidated_at      = CURRENT_TIMESTAMP,
     invalidation_reason = ?
 WHERE id = ?
+  AND is_valid = TRUE
 `
 
 type InvalidateBallotParams struct {
@@ -1794,8 +2720,62 @@ type InvalidateBallotParams struct {
 	ID                 int64
 }
 
-func (q *Queries) InvalidateBallot(ctx context.Context, arg InvalidateBallotParams) error {
-	_, err := q.db.ExecContext(ctx, invalidateBallot, arg.InvalidationReason, arg.ID)
+func (q *Queries) InvalidateBallot(ctx context.Context, arg InvalidateBallotParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, invalidateBallot, arg.InvalidationReason, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const openVotingMatter = `-- name: OpenVotingMatter :one
+UPDATE voting_matters
+SET voting_state     = 'open',
+    voting_opened_at = CURRENT_TIMESTAMP,
+    updated_at       = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND gathering_id = ?
+  AND voting_state = 'pending' RETURNING id, gathering_id, order_index, title, description, matter_type, voting_config, created_at, updated_at, is_informative, title_ru, description_ru, voting_state, voting_opened_at, voting_closed_at
+`
+
+type OpenVotingMatterParams struct {
+	ID          int64
+	GatheringID int64
+}
+
+func (q *Queries) OpenVotingMatter(ctx context.Context, arg OpenVotingMatterParams) (VotingMatter, error) {
+	row := q.db.QueryRowContext(ctx, openVotingMatter, arg.ID, arg.GatheringID)
+	var i VotingMatter
+	err := row.Scan(
+		&i.ID,
+		&i.GatheringID,
+		&i.OrderIndex,
+		&i.Title,
+		&i.Description,
+		&i.MatterType,
+		&i.VotingConfig,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsInformative,
+		&i.TitleRu,
+		&i.DescriptionRu,
+		&i.VotingState,
+		&i.VotingOpenedAt,
+		&i.VotingClosedAt,
+	)
+	return i, err
+}
+
+const openVotingWindow = `-- name: OpenVotingWindow :exec
+UPDATE voting_matters
+SET voting_opened_at = COALESCE(voting_opened_at, CURRENT_TIMESTAMP),
+    voting_closed_at = NULL,
+    updated_at       = CURRENT_TIMESTAMP
+WHERE gathering_id = ?
+`
+
+func (q *Queries) OpenVotingWindow(ctx context.Context, gatheringID int64) error {
+	_, err := q.db.ExecContext(ctx, openVotingWindow, gatheringID)
 	return err
 }
 
@@ -1810,6 +2790,217 @@ func (q *Queries) RemoveUnitSlot(ctx context.Context, gatheringID int64) error {
 	return err
 }
 
+const setAuditLogChainHash = `-- name: SetAuditLogChainHash :execrows
+UPDATE voting_audit_log
+SET prev_hash  = ?,
+    chain_hash = ?
+WHERE id = ?
+  AND chain_hash IS NULL
+`
+
+type SetAuditLogChainHashParams struct {
+	PrevHash  sql.NullString
+	ChainHash sql.NullString
+	ID        int64
+}
+
+func (q *Queries) SetAuditLogChainHash(ctx context.Context, arg SetAuditLogChainHashParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setAuditLogChainHash, arg.PrevHash, arg.ChainHash, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setBallotChainHash = `-- name: SetBallotChainHash :execrows
+UPDATE voting_ballots
+SET prev_hash  = ?,
+    chain_hash = ?
+WHERE id = ?
+  AND chain_hash IS NULL
+`
+
+type SetBallotChainHashParams struct {
+	PrevHash  sql.NullString
+	ChainHash sql.NullString
+	ID        int64
+}
+
+func (q *Queries) SetBallotChainHash(ctx context.Context, arg SetBallotChainHashParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setBallotChainHash, arg.PrevHash, arg.ChainHash, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setGatheringCoOwnershipPolicy = `-- name: SetGatheringCoOwnershipPolicy :exec
+UPDATE gatherings
+SET co_ownership_policy = ?,
+    updated_at          = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type SetGatheringCoOwnershipPolicyParams struct {
+	CoOwnershipPolicy string
+	ID                int64
+}
+
+func (q *Queries) SetGatheringCoOwnershipPolicy(ctx context.Context, arg SetGatheringCoOwnershipPolicyParams) error {
+	_, err := q.db.ExecContext(ctx, setGatheringCoOwnershipPolicy, arg.CoOwnershipPolicy, arg.ID)
+	return err
+}
+
+const setGatheringMemberVerification = `-- name: SetGatheringMemberVerification :exec
+UPDATE gatherings
+SET member_verification = ?,
+    updated_at          = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type SetGatheringMemberVerificationParams struct {
+	MemberVerification string
+	ID                 int64
+}
+
+func (q *Queries) SetGatheringMemberVerification(ctx context.Context, arg SetGatheringMemberVerificationParams) error {
+	_, err := q.db.ExecContext(ctx, setGatheringMemberVerification, arg.MemberVerification, arg.ID)
+	return err
+}
+
+const setGatheringRegisterFrozenAt = `-- name: SetGatheringRegisterFrozenAt :exec
+UPDATE gatherings
+SET register_frozen_at = ?
+WHERE id = ?
+`
+
+type SetGatheringRegisterFrozenAtParams struct {
+	RegisterFrozenAt sql.NullTime
+	ID               int64
+}
+
+func (q *Queries) SetGatheringRegisterFrozenAt(ctx context.Context, arg SetGatheringRegisterFrozenAtParams) error {
+	_, err := q.db.ExecContext(ctx, setGatheringRegisterFrozenAt, arg.RegisterFrozenAt, arg.ID)
+	return err
+}
+
+const setGatheringVotingRules = `-- name: SetGatheringVotingRules :exec
+UPDATE gatherings
+SET voting_rules = ?
+WHERE id = ?
+`
+
+type SetGatheringVotingRulesParams struct {
+	VotingRules sql.NullString
+	ID          int64
+}
+
+func (q *Queries) SetGatheringVotingRules(ctx context.Context, arg SetGatheringVotingRulesParams) error {
+	_, err := q.db.ExecContext(ctx, setGatheringVotingRules, arg.VotingRules, arg.ID)
+	return err
+}
+
+const setGatheringVotingWindows = `-- name: SetGatheringVotingWindows :exec
+UPDATE gatherings
+SET voting_windows = ?,
+    updated_at     = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type SetGatheringVotingWindowsParams struct {
+	VotingWindows string
+	ID            int64
+}
+
+func (q *Queries) SetGatheringVotingWindows(ctx context.Context, arg SetGatheringVotingWindowsParams) error {
+	_, err := q.db.ExecContext(ctx, setGatheringVotingWindows, arg.VotingWindows, arg.ID)
+	return err
+}
+
+const signBallot = `-- name: SignBallot :exec
+UPDATE voting_ballots
+SET signature             = ?,
+    signature_timestamp   = ?,
+    signature_certificate = ?
+WHERE id = ?
+`
+
+type SignBallotParams struct {
+	Signature            sql.NullString
+	SignatureTimestamp   sql.NullTime
+	SignatureCertificate sql.NullString
+	ID                   int64
+}
+
+func (q *Queries) SignBallot(ctx context.Context, arg SignBallotParams) error {
+	_, err := q.db.ExecContext(ctx, signBallot,
+		arg.Signature,
+		arg.SignatureTimestamp,
+		arg.SignatureCertificate,
+		arg.ID,
+	)
+	return err
+}
+
+const transitionGatheringStatus = `-- name: TransitionGatheringStatus :one
+UPDATE gatherings
+SET status     = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND association_id = ?
+  AND status = ? RETURNING id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode, opens_at, closes_at, voting_rules, repeated_from_id, register_frozen_at, member_verification, co_ownership_policy, voting_windows
+`
+
+type TransitionGatheringStatusParams struct {
+	ToStatus      string
+	ID            int64
+	AssociationID int64
+	FromStatus    string
+}
+
+func (q *Queries) TransitionGatheringStatus(ctx context.Context, arg TransitionGatheringStatusParams) (Gathering, error) {
+	row := q.db.QueryRowContext(ctx, transitionGatheringStatus,
+		arg.ToStatus,
+		arg.ID,
+		arg.AssociationID,
+		arg.FromStatus,
+	)
+	var i Gathering
+	err := row.Scan(
+		&i.ID,
+		&i.AssociationID,
+		&i.Title,
+		&i.Description,
+		&i.Intent,
+		&i.GatheringDate,
+		&i.GatheringType,
+		&i.Status,
+		&i.QualificationUnitTypes,
+		&i.QualificationFloors,
+		&i.QualificationEntrances,
+		&i.QualificationCustomRule,
+		&i.QualifiedUnitsCount,
+		&i.QualifiedUnitsTotalPart,
+		&i.QualifiedUnitsTotalArea,
+		&i.ParticipatingUnitsCount,
+		&i.ParticipatingUnitsTotalPart,
+		&i.ParticipatingUnitsTotalArea,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Location,
+		&i.VotingMode,
+		&i.OpensAt,
+		&i.ClosesAt,
+		&i.VotingRules,
+		&i.RepeatedFromID,
+		&i.RegisterFrozenAt,
+		&i.MemberVerification,
+		&i.CoOwnershipPolicy,
+		&i.VotingWindows,
+	)
+	return i, err
+}
+
 const updateGathering = `-- name: UpdateGathering :one
 UPDATE gatherings
 SET title                          = ?,
@@ -1819,6 +3010,8 @@ SET title                          = ?,
     gathering_date                 = ?,
     gathering_type                 = ?,
     voting_mode                    = ?,
+    opens_at                       = ?,
+    closes_at                      = ?,
     qualification_unit_types       = ?,
     qualification_floors           = ?,
     qualification_entrances        = ?,
@@ -1831,7 +3024,7 @@ SET title                          = ?,
     participating_units_total_area = ?,
     updated_at                     = CURRENT_TIMESTAMP
 WHERE id = ?
-  AND association_id = ? RETURNING id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode
+  AND association_id = ? RETURNING id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode, opens_at, closes_at, voting_rules, repeated_from_id, register_frozen_at, member_verification, co_ownership_policy, voting_windows
 `
 
 type UpdateGatheringParams struct {
@@ -1842,6 +3035,8 @@ type UpdateGatheringParams struct {
 	GatheringDate               time.Time
 	GatheringType               string
 	VotingMode                  string
+	OpensAt                     sql.NullTime
+	ClosesAt                    sql.NullTime
 	QualificationUnitTypes      sql.NullString
 	QualificationFloors         sql.NullString
 	QualificationEntrances      sql.NullString
@@ -1865,6 +3060,8 @@ func (q *Queries) UpdateGathering(ctx context.Context, arg UpdateGatheringParams
 		arg.GatheringDate,
 		arg.GatheringType,
 		arg.VotingMode,
+		arg.OpensAt,
+		arg.ClosesAt,
 		arg.QualificationUnitTypes,
 		arg.QualificationFloors,
 		arg.QualificationEntrances,
@@ -1902,6 +3099,14 @@ func (q *Queries) UpdateGathering(ctx context.Context, arg UpdateGatheringParams
 		&i.UpdatedAt,
 		&i.Location,
 		&i.VotingMode,
+		&i.OpensAt,
+		&i.ClosesAt,
+		&i.VotingRules,
+		&i.RepeatedFromID,
+		&i.RegisterFrozenAt,
+		&i.MemberVerification,
+		&i.CoOwnershipPolicy,
+		&i.VotingWindows,
 	)
 	return i, err
 }
@@ -1913,7 +3118,7 @@ SET units_info = ?,
     units_part = ?,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-  AND gathering_id = ? RETURNING id, gathering_id, participant_type, participant_name, participant_identification, owner_id, delegating_owner_id, delegation_document_ref, units_info, units_area, units_part, check_in_time, created_at, updated_at
+  AND gathering_id = ? RETURNING id, gathering_id, participant_type, participant_name, participant_identification, owner_id, delegating_owner_id, delegation_document_ref, units_info, units_area, units_part, check_in_time, created_at, updated_at, check_out_time
 `
 
 type UpdateGatheringParticipantsUnitsParams struct {
@@ -1948,6 +3153,7 @@ func (q *Queries) UpdateGatheringParticipantsUnits(ctx context.Context, arg Upda
 		&i.CheckInTime,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CheckOutTime,
 	)
 	return i, err
 }
@@ -1983,7 +3189,7 @@ UPDATE gatherings
 SET status     = ?,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-  AND association_id = ? RETURNING id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode
+  AND association_id = ? RETURNING id, association_id, title, description, intent, gathering_date, gathering_type, status, qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule, qualified_units_count, qualified_units_total_part, qualified_units_total_area, participating_units_count, participating_units_total_part, participating_units_total_area, created_at, updated_at, location, voting_mode, opens_at, closes_at, voting_rules, repeated_from_id, register_frozen_at, member_verification, co_ownership_policy, voting_windows
 `
 
 type UpdateGatheringStatusParams struct {
@@ -2018,6 +3224,14 @@ func (q *Queries) UpdateGatheringStatus(ctx context.Context, arg UpdateGathering
 		&i.UpdatedAt,
 		&i.Location,
 		&i.VotingMode,
+		&i.OpensAt,
+		&i.ClosesAt,
+		&i.VotingRules,
+		&i.RepeatedFromID,
+		&i.RegisterFrozenAt,
+		&i.MemberVerification,
+		&i.CoOwnershipPolicy,
+		&i.VotingWindows,
 	)
 	return i, err
 }
@@ -2079,7 +3293,7 @@ SET title          = ?,
     is_informative = ?,
     updated_at     = CURRENT_TIMESTAMP
 WHERE id = ?
-  AND gathering_id = ? RETURNING id, gathering_id, order_index, title, description, matter_type, voting_config, created_at, updated_at, is_informative, title_ru, description_ru
+  AND gathering_id = ? RETURNING id, gathering_id, order_index, title, description, matter_type, voting_config, created_at, updated_at, is_informative, title_ru, description_ru, voting_state, voting_opened_at, voting_closed_at
 `
 
 type UpdateVotingMatterParams struct {
@@ -2122,28 +3336,38 @@ func (q *Queries) UpdateVotingMatter(ctx context.Context, arg UpdateVotingMatter
 		&i.IsInformative,
 		&i.TitleRu,
 		&i.DescriptionRu,
+		&i.VotingState,
+		&i.VotingOpenedAt,
+		&i.VotingClosedAt,
 	)
 	return i, err
 }
 
 const upsertVoteTally = `-- name: UpsertVoteTally :one
-INSERT INTO vote_tallies (gathering_id, voting_matter_id, tally_data)
-VALUES (?, ?, ?)
+INSERT INTO vote_tallies (gathering_id, voting_matter_id, tally_data, breakdown_data)
+VALUES (?, ?, ?, ?)
 ON CONFLICT (gathering_id, voting_matter_id)
 DO UPDATE SET
     tally_data = EXCLUDED.tally_data,
+    breakdown_data = EXCLUDED.breakdown_data,
     last_updated = CURRENT_TIMESTAMP
-RETURNING id, gathering_id, voting_matter_id, tally_data, last_updated
+RETURNING id, gathering_id, voting_matter_id, tally_data, last_updated, breakdown_data
 `
 
 type UpsertVoteTallyParams struct {
 	GatheringID    int64
 	VotingMatterID int64
 	TallyData      string
+	BreakdownData  sql.NullString
 }
 
 func (q *Queries) UpsertVoteTally(ctx context.Context, arg UpsertVoteTallyParams) (VoteTally, error) {
-	row := q.db.QueryRowContext(ctx, upsertVoteTally, arg.GatheringID, arg.VotingMatterID, arg.TallyData)
+	row := q.db.QueryRowContext(ctx, upsertVoteTally,
+		arg.GatheringID,
+		arg.VotingMatterID,
+		arg.TallyData,
+		arg.BreakdownData,
+	)
 	var i VoteTally
 	err := row.Scan(
 		&i.ID,
@@ -2151,6 +3375,7 @@ func (q *Queries) UpsertVoteTally(ctx context.Context, arg UpsertVoteTallyParams
 		&i.VotingMatterID,
 		&i.TallyData,
 		&i.LastUpdated,
+		&i.BreakdownData,
 	)
 	return i, err
 }
@@ -42,6 +42,23 @@ type ActiveOwnerUnit struct {
 	OwnershipID         int64
 }
 
+type AnonymousVote struct {
+	Receipt        string
+	GatheringID    int64
+	VotingMatterID int64
+	VoteValues     string
+	IsValid        bool
+}
+
+type AnonymousVoteTotal struct {
+	GatheringID    int64
+	VotingMatterID int64
+	VoteValues     string
+	Votes          int64
+	UnitsPart      float64
+	UnitsCount     int64
+}
+
 type Association struct {
 	ID            int64
 	Name          string
@@ -51,6 +68,24 @@ type Association struct {
 	UpdatedAt     sql.NullTime
 }
 
+type AssociationSigningKey struct {
+	ID            int64
+	AssociationID int64
+	KeyID         string
+	PublicKey     string
+	PrivateKey    string
+	CreatedAt     sql.NullTime
+}
+
+type AttendanceEvent struct {
+	ID            int64
+	GatheringID   int64
+	ParticipantID int64
+	EventType     string
+	OccurredAt    time.Time
+	RecordedBy    sql.NullString
+}
+
 type Building struct {
 	ID              int64
 	Name            string
@@ -74,6 +109,35 @@ type Category struct {
 	OriginalLabels sql.NullString
 }
 
+type Delegation struct {
+	ID                     int64
+	GatheringID            int64
+	DelegateName           string
+	DelegateIdentification string
+	DocumentRef            string
+	ValidFrom              time.Time
+	ValidUntil             time.Time
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+}
+
+type DelegationDocument struct {
+	DelegationID int64
+	FileName     string
+	ContentType  string
+	Content      []byte
+	UploadedAt   time.Time
+}
+
+type DelegationUnit struct {
+	DelegationID   int64
+	OwnerID        int64
+	UnitID         int64
+	RevokedAt      sql.NullTime
+	RevocationNote string
+	CreatedAt      time.Time
+}
+
 type Expense struct {
 	ID          int64
 	Amount      float64
@@ -112,6 +176,14 @@ type Gathering struct {
 	UpdatedAt                   sql.NullTime
 	Location                    string
 	VotingMode                  string
+	OpensAt                     sql.NullTime
+	ClosesAt                    sql.NullTime
+	VotingRules                 sql.NullString
+	RepeatedFromID              sql.NullInt64
+	RegisterFrozenAt            sql.NullTime
+	MemberVerification          string
+	CoOwnershipPolicy           string
+	VotingWindows               string
 }
 
 type GatheringParticipant struct {
@@ -129,6 +201,38 @@ type GatheringParticipant struct {
 	CheckInTime               sql.NullTime
 	CreatedAt                 sql.NullTime
 	UpdatedAt                 sql.NullTime
+	CheckOutTime              sql.NullTime
+}
+
+type GatheringTemplate struct {
+	ID                      int64
+	AssociationID           int64
+	Name                    string
+	Title                   string
+	Description             string
+	Intent                  string
+	Location                string
+	GatheringType           string
+	VotingMode              string
+	QualificationUnitTypes  sql.NullString
+	QualificationFloors     sql.NullString
+	QualificationEntrances  sql.NullString
+	QualificationCustomRule sql.NullString
+	CreatedAt               sql.NullTime
+	UpdatedAt               sql.NullTime
+}
+
+type GatheringTemplateMatter struct {
+	ID            int64
+	TemplateID    int64
+	OrderIndex    int64
+	Title         string
+	TitleRu       string
+	Description   sql.NullString
+	DescriptionRu sql.NullString
+	MatterType    string
+	VotingConfig  string
+	IsInformative int64
 }
 
 type MemberInvitation struct {
@@ -142,6 +246,53 @@ type MemberInvitation struct {
 	UpdatedAt   time.Time
 }
 
+type MemberSession struct {
+	ID           int64
+	InvitationID int64
+	TokenHash    string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+type MemberVerification struct {
+	InvitationID   int64
+	PinHash        sql.NullString
+	CodeHash       sql.NullString
+	CodeChannel    sql.NullString
+	CodeExpiresAt  sql.NullTime
+	CodeSentAt     sql.NullTime
+	FailedAttempts int64
+	LockedAt       sql.NullTime
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type MinutesTemplate struct {
+	ID            int64
+	AssociationID int64
+	FileName      string
+	Format        string
+	Content       []byte
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type NotificationOutbox struct {
+	ID                 int64
+	NotificationID     int64
+	Recipient          string
+	Subject            string
+	Body               string
+	Status             string
+	Attempts           int64
+	NextAttemptAt      time.Time
+	LastError          sql.NullString
+	SentAt             sql.NullTime
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	ReminderScheduleID sql.NullInt64
+}
+
 type Owner struct {
 	ID                   int64
 	Name                 string
@@ -168,6 +319,7 @@ type Ownership struct {
 	CreatedAt            sql.NullTime
 	UpdatedAt            sql.NullTime
 	IsVoting             bool
+	Share                float64
 }
 
 type PasswordResetToken struct {
@@ -200,6 +352,20 @@ type RegistrationToken struct {
 	IsAdmin     bool
 }
 
+type ReminderSchedule struct {
+	ID            int64
+	GatheringID   int64
+	OffsetMinutes int64
+	Lang          string
+	Status        string
+	RanAt         sql.NullTime
+	EligibleCount int64
+	SkippedCount  int64
+	LastError     sql.NullString
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
 type RevokedToken struct {
 	Jti       string
 	RevokedAt time.Time
@@ -229,6 +395,8 @@ type UnitSlot struct {
 	ParticipantID interface{}
 	CreatedAt     sql.NullTime
 	UpdatedAt     sql.NullTime
+	OwnerID       int64
+	Share         float64
 }
 
 type User struct {
@@ -255,6 +423,38 @@ type VoteTally struct {
 	VotingMatterID int64
 	TallyData      string
 	LastUpdated    sql.NullTime
+	BreakdownData  sql.NullString
+}
+
+type VoterRegisterOwner struct {
+	ID                  int64
+	GatheringID         int64
+	UnitID              int64
+	OwnerID             int64
+	OwnershipID         int64
+	OwnerName           string
+	OwnerIdentification string
+	OwnerContactEmail   string
+	OwnerContactPhone   string
+	IsVoting            bool
+	CreatedAt           sql.NullTime
+	Share               float64
+}
+
+type VoterRegisterUnit struct {
+	ID              int64
+	GatheringID     int64
+	UnitID          int64
+	UnitNumber      string
+	CadastralNumber string
+	UnitType        string
+	Floor           int64
+	Entrance        int64
+	Area            float64
+	Part            float64
+	BuildingName    string
+	BuildingAddress string
+	CreatedAt       sql.NullTime
 }
 
 type VotingAuditLog struct {
@@ -267,6 +467,8 @@ type VotingAuditLog struct {
 	PerformedAt sql.NullTime
 	IpAddress   sql.NullString
 	Details     sql.NullString
+	PrevHash    sql.NullString
+	ChainHash   sql.NullString
 }
 
 type VotingBallot struct {
@@ -284,21 +486,27 @@ type VotingBallot struct {
 	IsValid              sql.NullBool
 	InvalidatedAt        sql.NullTime
 	InvalidationReason   sql.NullString
+	ReplacesBallotID     sql.NullInt64
+	PrevHash             sql.NullString
+	ChainHash            sql.NullString
 }
 
 type VotingMatter struct {
-	ID            int64
-	GatheringID   int64
-	OrderIndex    int64
-	Title         string
-	Description   sql.NullString
-	MatterType    string
-	VotingConfig  string
-	CreatedAt     sql.NullTime
-	UpdatedAt     sql.NullTime
-	IsInformative int64
-	TitleRu       string
-	DescriptionRu sql.NullString
+	ID             int64
+	GatheringID    int64
+	OrderIndex     int64
+	Title          string
+	Description    sql.NullString
+	MatterType     string
+	VotingConfig   string
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+	IsInformative  int64
+	TitleRu        string
+	DescriptionRu  sql.NullString
+	VotingState    string
+	VotingOpenedAt sql.NullTime
+	VotingClosedAt sql.NullTime
 }
 
 type VotingNotification struct {
@@ -322,4 +530,21 @@ type VotingResult struct {
 	QuorumThresholdPercentage float64
 	QuorumMet                 bool
 	ComputedAt                time.Time
+	BallotMerkleRoot          sql.NullString
+}
+
+type VotingRulesProfile struct {
+	ID                        int64
+	AssociationID             int64
+	QuorumInitial             float64
+	QuorumRepeated            float64
+	QuorumRemote              float64
+	MajoritySimple            float64
+	MajorityAbsolute          float64
+	MajorityAbsoluteTwoThirds float64
+	MajorityQualified         float64
+	MajorityUnanimous         float64
+	CreatedAt                 time.Time
+	UpdatedAt                 time.Time
+	MaxProxiesPerDelegate     int64
 }
@@ -57,8 +57,8 @@ const createOwnership = `-- name: CreateOwnership :one
 
 INSERT INTO ownerships (unit_id, owner_id, association_id,
                         start_date, end_date, is_active, is_voting,
-                        registration_document, registration_date)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, unit_id, owner_id, association_id, start_date, end_date, is_active, registration_document, registration_date, created_at, updated_at, is_voting
+                        registration_document, registration_date, share)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, unit_id, owner_id, association_id, start_date, end_date, is_active, registration_document, registration_date, created_at, updated_at, is_voting, share
 `
 
 type CreateOwnershipParams struct {
@@ -71,6 +71,7 @@ type CreateOwnershipParams struct {
 	IsVoting             bool
 	RegistrationDocument string
 	RegistrationDate     time.Time
+	Share                float64
 }
 
 func (q *Queries) CreateOwnership(ctx context.Context, arg CreateOwnershipParams) (Ownership, error) {
@@ -84,6 +85,7 @@ func (q *Queries) CreateOwnership(ctx context.Context, arg CreateOwnershipParams
 		arg.IsVoting,
 		arg.RegistrationDocument,
 		arg.RegistrationDate,
+		arg.Share,
 	)
 	var i Ownership
 	err := row.Scan(
@@ -99,6 +101,7 @@ func (q *Queries) CreateOwnership(ctx context.Context, arg CreateOwnershipParams
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.IsVoting,
+		&i.Share,
 	)
 	return i, err
 }
@@ -212,14 +215,15 @@ SELECT o.id                    as owner_id,
        u.part,
        u.unit_type,
        b.name                  as building_name,
-       b.address               as building_address
+       b.address               as building_address,
+       os.is_voting,
+       os.share
 FROM owners o
          JOIN ownerships os ON o.id = os.owner_id
          JOIN units u ON os.unit_id = u.id
          JOIN buildings b ON u.building_id = b.id
 WHERE o.association_id = ?
   AND os.is_active = true
-  AND os.is_voting = true
   AND (false=? OR u.unit_type in(/*SLICE:unit_types*/?))
   AND (false=? OR u.floor in(/*SLICE:unit_floors*/?))
   AND (false=? OR u.entrance in(/*SLICE:unit_entrances*/?))
@@ -253,6 +257,8 @@ type GetAssociationVotersRow struct {
 	UnitType                  string
 	BuildingName              string
 	BuildingAddress           string
+	IsVoting                  bool
+	Share                     float64
 }
 
 func (q *Queries) GetAssociationVoters(ctx context.Context, arg GetAssociationVotersParams) ([]GetAssociationVotersRow, error) {
@@ -311,6 +317,8 @@ func (q *Queries) GetAssociationVoters(ctx context.Context, arg GetAssociationVo
 			&i.UnitType,
 			&i.BuildingName,
 			&i.BuildingAddress,
+			&i.IsVoting,
+			&i.Share,
 		); err != nil {
 			return nil, err
 		}
@@ -359,6 +367,8 @@ SELECT u.id                     as unit_id,
        u.unit_type,
        b.name                   as building_name,
        b.address                as building_address,
+       os.is_voting,
+       os.share,
        o2.id                    as co_owner_id,
        o2.name                  as co_owner_name,
        o2.normalized_name       as co_owner_normalized_name,
@@ -387,6 +397,8 @@ type GetOwnerUnitsWithDetailsRow struct {
 	UnitType                    string
 	BuildingName                string
 	BuildingAddress             string
+	IsVoting                    bool
+	Share                       float64
 	CoOwnerID                   sql.NullInt64
 	CoOwnerName                 sql.NullString
 	CoOwnerNormalizedName       sql.NullString
@@ -412,6 +424,8 @@ func (q *Queries) GetOwnerUnitsWithDetails(ctx context.Context, arg GetOwnerUnit
 			&i.UnitType,
 			&i.BuildingName,
 			&i.BuildingAddress,
+			&i.IsVoting,
+			&i.Share,
 			&i.CoOwnerID,
 			&i.CoOwnerName,
 			&i.CoOwnerNormalizedName,
@@ -453,6 +467,8 @@ SELECT o.id                     as owner_id,
        u.building_id            as building_id,
        b.name                   as building_name,
        b.address                as building_address,
+       os.is_voting,
+       os.share,
        o2.id                    as co_owner_id,
        o2.name                  as co_owner_name,
        o2.normalized_name       as co_owner_normalized_name,
@@ -502,6 +518,8 @@ type GetOwnerUnitsWithDetailsForReportRow struct {
 	BuildingID                  int64
 	BuildingName                string
 	BuildingAddress             string
+	IsVoting                    bool
+	Share                       float64
 	CoOwnerID                   sql.NullInt64
 	CoOwnerName                 sql.NullString
 	CoOwnerNormalizedName       sql.NullString
@@ -539,6 +557,8 @@ func (q *Queries) GetOwnerUnitsWithDetailsForReport(ctx context.Context, arg Get
 			&i.BuildingID,
 			&i.BuildingName,
 			&i.BuildingAddress,
+			&i.IsVoting,
+			&i.Share,
 			&i.CoOwnerID,
 			&i.CoOwnerName,
 			&i.CoOwnerNormalizedName,
@@ -32,7 +32,7 @@ func (q *Queries) DisableActiveVoting(ctx context.Context, arg DisableActiveVoti
 
 const getActiveUnitOwnerships = `-- name: GetActiveUnitOwnerships :many
 
-SELECT o.id, o.unit_id, o.owner_id, o.association_id, o.start_date, o.end_date, o.is_active, o.registration_document, o.registration_date, o.created_at, o.updated_at, o.is_voting,
+SELECT o.id, o.unit_id, o.owner_id, o.association_id, o.start_date, o.end_date, o.is_active, o.registration_document, o.registration_date, o.created_at, o.updated_at, o.is_voting, o.share,
        ow.name            as owner_name,
        ow.normalized_name as owner_normalized_name,
        ow.identification_number
@@ -62,6 +62,7 @@ type GetActiveUnitOwnershipsRow struct {
 	CreatedAt            sql.NullTime
 	UpdatedAt            sql.NullTime
 	IsVoting             bool
+	Share                float64
 	OwnerName            string
 	OwnerNormalizedName  string
 	IdentificationNumber string
@@ -89,6 +90,7 @@ func (q *Queries) GetActiveUnitOwnerships(ctx context.Context, arg GetActiveUnit
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.IsVoting,
+			&i.Share,
 			&i.OwnerName,
 			&i.OwnerNormalizedName,
 			&i.IdentificationNumber,
@@ -108,7 +110,7 @@ func (q *Queries) GetActiveUnitOwnerships(ctx context.Context, arg GetActiveUnit
 
 const getOwnership = `-- name: GetOwnership :one
 
-SELECT id, unit_id, owner_id, association_id, start_date, end_date, is_active, registration_document, registration_date, created_at, updated_at, is_voting
+SELECT id, unit_id, owner_id, association_id, start_date, end_date, is_active, registration_document, registration_date, created_at, updated_at, is_voting, share
 FROM ownerships
 WHERE id = ? LIMIT 1
 `
@@ -129,13 +131,14 @@ func (q *Queries) GetOwnership(ctx context.Context, id int64) (Ownership, error)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.IsVoting,
+		&i.Share,
 	)
 	return i, err
 }
 
 const getUnitOwnership = `-- name: GetUnitOwnership :one
 
-SELECT o.id, o.unit_id, o.owner_id, o.association_id, o.start_date, o.end_date, o.is_active, o.registration_document, o.registration_date, o.created_at, o.updated_at, o.is_voting,
+SELECT o.id, o.unit_id, o.owner_id, o.association_id, o.start_date, o.end_date, o.is_active, o.registration_document, o.registration_date, o.created_at, o.updated_at, o.is_voting, o.share,
        ow.name            as owner_name,
        ow.normalized_name as owner_normalized_name,
        ow.identification_number
@@ -166,6 +169,7 @@ type GetUnitOwnershipRow struct {
 	CreatedAt            sql.NullTime
 	UpdatedAt            sql.NullTime
 	IsVoting             bool
+	Share                float64
 	OwnerName            string
 	OwnerNormalizedName  string
 	IdentificationNumber string
@@ -187,6 +191,7 @@ func (q *Queries) GetUnitOwnership(ctx context.Context, arg GetUnitOwnershipPara
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.IsVoting,
+		&i.Share,
 		&i.OwnerName,
 		&i.OwnerNormalizedName,
 		&i.IdentificationNumber,
@@ -195,7 +200,7 @@ func (q *Queries) GetUnitOwnership(ctx context.Context, arg GetUnitOwnershipPara
 }
 
 const getUnitOwnerships = `-- name: GetUnitOwnerships :many
-SELECT o.id, o.unit_id, o.owner_id, o.association_id, o.start_date, o.end_date, o.is_active, o.registration_document, o.registration_date, o.created_at, o.updated_at, o.is_voting,
+SELECT o.id, o.unit_id, o.owner_id, o.association_id, o.start_date, o.end_date, o.is_active, o.registration_document, o.registration_date, o.created_at, o.updated_at, o.is_voting, o.share,
        ow.name            as owner_name,
        ow.normalized_name as owner_normalized_name,
        ow.identification_number
@@ -224,6 +229,7 @@ type GetUnitOwnershipsRow struct {
 	CreatedAt            sql.NullTime
 	UpdatedAt            sql.NullTime
 	IsVoting             bool
+	Share                float64
 	OwnerName            string
 	OwnerNormalizedName  string
 	IdentificationNumber string
@@ -251,6 +257,7 @@ func (q *Queries) GetUnitOwnerships(ctx context.Context, arg GetUnitOwnershipsPa
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.IsVoting,
+			&i.Share,
 			&i.OwnerName,
 			&i.OwnerNormalizedName,
 			&i.IdentificationNumber,
@@ -268,6 +275,34 @@ func (q *Queries) GetUnitOwnerships(ctx context.Context, arg GetUnitOwnershipsPa
 	return items, nil
 }
 
+const setOwnershipShare = `-- name: SetOwnershipShare :exec
+
+UPDATE ownerships
+SET share      = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND unit_id = ?
+  AND association_id = ?
+  AND is_active = true
+`
+
+type SetOwnershipShareParams struct {
+	Share         float64
+	ID            int64
+	UnitID        int64
+	AssociationID int64
+}
+
+func (q *Queries) SetOwnershipShare(ctx context.Context, arg SetOwnershipShareParams) error {
+	_, err := q.db.ExecContext(ctx, setOwnershipShare,
+		arg.Share,
+		arg.ID,
+		arg.UnitID,
+		arg.AssociationID,
+	)
+	return err
+}
+
 const setVoting = `-- name: SetVoting :exec
 
 UPDATE ownerships
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createVotingResults = `-- name: CreateVotingResults :one
@@ -18,9 +19,10 @@ INSERT INTO voting_results (
     total_possible_votes_weight,
     total_possible_votes_count,
     quorum_threshold_percentage,
-    quorum_met
+    quorum_met,
+    ballot_merkle_root
 )
-VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, gathering_id, results_data, voting_mode, gathering_type, total_possible_votes_weight, total_possible_votes_count, quorum_threshold_percentage, quorum_met, computed_at
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, gathering_id, results_data, voting_mode, gathering_type, total_possible_votes_weight, total_possible_votes_count, quorum_threshold_percentage, quorum_met, computed_at, ballot_merkle_root
 `
 
 type CreateVotingResultsParams struct {
@@ -32,6 +34,7 @@ type CreateVotingResultsParams struct {
 	TotalPossibleVotesCount   int64
 	QuorumThresholdPercentage float64
 	QuorumMet                 bool
+	BallotMerkleRoot          sql.NullString
 }
 
 func (q *Queries) CreateVotingResults(ctx context.Context, arg CreateVotingResultsParams) (VotingResult, error) {
@@ -44,6 +47,7 @@ func (q *Queries) CreateVotingResults(ctx context.Context, arg CreateVotingResul
 		arg.TotalPossibleVotesCount,
 		arg.QuorumThresholdPercentage,
 		arg.QuorumMet,
+		arg.BallotMerkleRoot,
 	)
 	var i VotingResult
 	err := row.Scan(
@@ -57,6 +61,7 @@ func (q *Queries) CreateVotingResults(ctx context.Context, arg CreateVotingResul
 		&i.QuorumThresholdPercentage,
 		&i.QuorumMet,
 		&i.ComputedAt,
+		&i.BallotMerkleRoot,
 	)
 	return i, err
 }
@@ -72,7 +77,7 @@ func (q *Queries) DeleteVotingResults(ctx context.Context, gatheringID int64) er
 }
 
 const getVotingResults = `-- name: GetVotingResults :one
-SELECT id, gathering_id, results_data, voting_mode, gathering_type, total_possible_votes_weight, total_possible_votes_count, quorum_threshold_percentage, quorum_met, computed_at FROM voting_results
+SELECT id, gathering_id, results_data, voting_mode, gathering_type, total_possible_votes_weight, total_possible_votes_count, quorum_threshold_percentage, quorum_met, computed_at, ballot_merkle_root FROM voting_results
 WHERE gathering_id = ?
 `
 
@@ -90,6 +95,7 @@ func (q *Queries) GetVotingResults(ctx context.Context, gatheringID int64) (Voti
 		&i.QuorumThresholdPercentage,
 		&i.QuorumMet,
 		&i.ComputedAt,
+		&i.BallotMerkleRoot,
 	)
 	return i, err
 }
@@ -103,8 +109,9 @@ SET results_data = ?,
     total_possible_votes_count = ?,
     quorum_threshold_percentage = ?,
     quorum_met = ?,
+    ballot_merkle_root = ?,
     computed_at = CURRENT_TIMESTAMP
-WHERE gathering_id = ? RETURNING id, gathering_id, results_data, voting_mode, gathering_type, total_possible_votes_weight, total_possible_votes_count, quorum_threshold_percentage, quorum_met, computed_at
+WHERE gathering_id = ? RETURNING id, gathering_id, results_data, voting_mode, gathering_type, total_possible_votes_weight, total_possible_votes_count, quorum_threshold_percentage, quorum_met, computed_at, ballot_merkle_root
 `
 
 type UpdateVotingResultsParams struct {
@@ -115,6 +122,7 @@ type UpdateVotingResultsParams struct {
 	TotalPossibleVotesCount   int64
 	QuorumThresholdPercentage float64
 	QuorumMet                 bool
+	BallotMerkleRoot          sql.NullString
 	GatheringID               int64
 }
 
@@ -127,6 +135,7 @@ func (q *Queries) UpdateVotingResults(ctx context.Context, arg UpdateVotingResul
 		arg.TotalPossibleVotesCount,
 		arg.QuorumThresholdPercentage,
 		arg.QuorumMet,
+		arg.BallotMerkleRoot,
 		arg.GatheringID,
 	)
 	var i VotingResult
@@ -141,6 +150,7 @@ func (q *Queries) UpdateVotingResults(ctx context.Context, arg UpdateVotingResul
 		&i.QuorumThresholdPercentage,
 		&i.QuorumMet,
 		&i.ComputedAt,
+		&i.BallotMerkleRoot,
 	)
 	return i, err
 }
@@ -0,0 +1,140 @@
+package ballotimport
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Column headers of an import sheet, matched case-insensitively
+const (
+	ColumnOwnerIdentification   = "owner_identification"
+	ColumnDelegateName          = "delegate_name"
+	ColumnDelegationDocumentRef = "delegation_document_ref"
+	ColumnUnitNumbers           = "unit_numbers"
+	MatterColumnPrefix          = "matter_"
+)
+
+// MaxRows bounds the number of ballots in one import
+const MaxRows = 5000
+
+// Sheet is a parsed import sheet
+type Sheet struct {
+	MatterIDs []int64 // Matters with a column, in column order
+	Rows      []Row
+}
+
+// Row is one voter's paper ballot
+type Row struct {
+	Line                  int // Position in the sheet, the header being line 1
+	OwnerIdentification   string
+	DelegateName          string
+	DelegationDocumentRef string
+	UnitNumbers           []string           // Empty for all units the owner votes for
+	Votes                 map[int64][]string // Values chosen per matter, empty when left blank
+}
+
+// IsDelegate reports whether the ballot was cast by a delegate on the owner's behalf
+func (r Row) IsDelegate() bool {
+	return r.DelegateName != "" || r.DelegationDocumentRef != ""
+}
+
+// MatterColumn returns the header of a matter's column
+func MatterColumn(matterID int64) string {
+	return MatterColumnPrefix + strconv.FormatInt(matterID, 10)
+}
+
+// Parse checks the header and returns the sheet's non-blank rows. Only the layout is
+// checked here, the rows are validated against the gathering by the caller.
+func Parse(records [][]string) (Sheet, error) {
+	if len(records) == 0 {
+		return Sheet{}, errors.New("the sheet is empty")
+	}
+
+	var sheet Sheet
+	columns := make(map[string]int)
+	matterColumns := make(map[int]int64)
+	for i, cell := range records[0] {
+		name := strings.ToLower(strings.TrimSpace(cell))
+		if name == "" {
+			continue
+		}
+		if _, duplicate := columns[name]; duplicate {
+			return Sheet{}, fmt.Errorf("column %q appears more than once", name)
+		}
+		columns[name] = i
+
+		switch name {
+		case ColumnOwnerIdentification, ColumnDelegateName, ColumnDelegationDocumentRef, ColumnUnitNumbers:
+		default:
+			matterID, err := strconv.ParseInt(strings.TrimPrefix(name, MatterColumnPrefix), 10, 64)
+			if !strings.HasPrefix(name, MatterColumnPrefix) || err != nil || matterID <= 0 {
+				return Sheet{}, fmt.Errorf("unknown column %q, matter columns are named %s<id>", name, MatterColumnPrefix)
+			}
+			matterColumns[i] = matterID
+			sheet.MatterIDs = append(sheet.MatterIDs, matterID)
+		}
+	}
+	if _, ok := columns[ColumnOwnerIdentification]; !ok {
+		return Sheet{}, fmt.Errorf("the %s column is required", ColumnOwnerIdentification)
+	}
+	if len(sheet.MatterIDs) == 0 {
+		return Sheet{}, errors.New("the sheet has no matter columns")
+	}
+
+	cellAt := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	for i, record := range records[1:] {
+		if isBlank(record) {
+			continue
+		}
+		row := Row{
+			Line:                  i + 2,
+			OwnerIdentification:   cellAt(record, ColumnOwnerIdentification),
+			DelegateName:          cellAt(record, ColumnDelegateName),
+			DelegationDocumentRef: cellAt(record, ColumnDelegationDocumentRef),
+			UnitNumbers:           splitList(cellAt(record, ColumnUnitNumbers)),
+			Votes:                 make(map[int64][]string, len(matterColumns)),
+		}
+		for column, matterID := range matterColumns {
+			if column < len(record) {
+				row.Votes[matterID] = splitList(record[column])
+			}
+		}
+		sheet.Rows = append(sheet.Rows, row)
+		if len(sheet.Rows) > MaxRows {
+			return Sheet{}, fmt.Errorf("the sheet has more than %d ballots", MaxRows)
+		}
+	}
+	if len(sheet.Rows) == 0 {
+		return Sheet{}, errors.New("the sheet has no ballots")
+	}
+	return sheet, nil
+}
+
+// splitList splits a cell holding several values separated by ';' or ','
+func splitList(cell string) []string {
+	var values []string
+	for _, value := range strings.FieldsFunc(cell, func(r rune) bool { return r == ';' || r == ',' }) {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func isBlank(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
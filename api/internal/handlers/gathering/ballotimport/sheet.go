@@ -0,0 +1,241 @@
+// Package ballotimport reads paper ballots typed into a spreadsheet, one row per voter:
+//
+//	owner_identification,delegate_name,delegation_document_ref,unit_numbers,matter_12,matter_13
+//	2001234567890,,,12;14,yes,abstain
+//	2009876543210,Ion Popescu,PROC-7,3,no,b;a
+//
+// unit_numbers lists the units the ballot covers, empty for all units the owner votes for.
+// Each matter_<id> column holds the values chosen on that matter, several values (ranking
+// order) separated by ';' or ','. Sheets are CSV, comma or semicolon separated, or XLSX,
+// of which the first worksheet is read.
+package ballotimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedFormat is returned for files that are neither CSV nor XLSX
+var ErrUnsupportedFormat = errors.New("unsupported file format, expected .csv or .xlsx")
+
+// ReadFile returns the rows of a CSV or XLSX file, picking the format from the file name
+func ReadFile(name string, r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(path.Ext(name)) {
+	case ".csv":
+		return ReadCSV(data)
+	case ".xlsx":
+		return ReadXLSX(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// ReadCSV returns the rows of a CSV file. Semicolons are used as the separator when the
+// header has more of them than commas, as in spreadsheets exported with a European locale.
+func ReadCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	return rows, nil
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+// xlsxText is plain (t) or rich (r/t) cell text
+type xlsxText struct {
+	Text string   `xml:"t"`
+	Runs []string `xml:"r>t"`
+}
+
+func (t xlsxText) String() string {
+	return t.Text + strings.Join(t.Runs, "")
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXLSX returns the rows of the first worksheet of an XLSX workbook. Rows keep their
+// position in the sheet, so blank rows are returned empty.
+func ReadXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX: %w", err)
+	}
+
+	sheetPath, err := firstSheetPath(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared xlsxSharedStrings
+	if err := readXMLPart(archive, "xl/sharedStrings.xml", &shared); err != nil && !errors.Is(err, errMissingPart) {
+		return nil, err
+	}
+
+	var sheet xlsxWorksheet
+	if err := readXMLPart(archive, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		number := row.Number
+		if number == 0 {
+			number = len(rows) + 1
+		}
+		if number <= len(rows) || number-len(rows) > maxBlankRows {
+			return nil, fmt.Errorf("invalid XLSX: unexpected row %d", number)
+		}
+		for len(rows) < number {
+			rows = append(rows, nil)
+		}
+
+		var values []string
+		for j, cell := range row.Cells {
+			column := j
+			if cell.Ref != "" {
+				if column, err = columnIndex(cell.Ref); err != nil {
+					return nil, err
+				}
+			}
+			if column > maxColumns {
+				return nil, fmt.Errorf("invalid XLSX: unexpected cell %s", cell.Ref)
+			}
+			for len(values) <= column {
+				values = append(values, "")
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(shared.Items) {
+					return nil, fmt.Errorf("invalid XLSX: cell %s refers to a missing string", cell.Ref)
+				}
+				value = shared.Items[index].String()
+			case "inlineStr":
+				value = cell.Inline.String()
+			}
+			values[column] = value
+		}
+		rows[number-1] = values
+	}
+	return rows, nil
+}
+
+// maxColumns, maxBlankRows and maxPartSize keep a malformed or hostile workbook from
+// allocating huge tables
+const (
+	maxColumns   = 1000
+	maxBlankRows = 1000
+	maxPartSize  = 32 << 20
+)
+
+var errMissingPart = errors.New("missing part")
+
+// firstSheetPath resolves the archive path of the workbook's first worksheet
+func firstSheetPath(archive *zip.Reader) (string, error) {
+	var workbook xlsxWorkbook
+	if err := readXMLPart(archive, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", errors.New("invalid XLSX: the workbook has no sheets")
+	}
+
+	var rels xlsxRelationships
+	if err := readXMLPart(archive, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RelID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", errors.New("invalid XLSX: the first sheet has no content")
+}
+
+// readXMLPart decodes a part of the archive
+func readXMLPart(archive *zip.Reader, name string, v interface{}) error {
+	file, err := archive.Open(name)
+	if err != nil {
+		return fmt.Errorf("invalid XLSX: %s: %w", name, errMissingPart)
+	}
+	defer file.Close()
+	if err := xml.NewDecoder(io.LimitReader(file, maxPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("invalid XLSX: %s: %w", name, err)
+	}
+	return nil
+}
+
+// columnIndex converts the column letters of a cell reference such as "AB12" to a
+// zero-based index
+func columnIndex(ref string) (int, error) {
+	column := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+		letters++
+		if column > maxColumns {
+			break
+		}
+	}
+	if letters == 0 {
+		return 0, fmt.Errorf("invalid XLSX: bad cell reference %q", ref)
+	}
+	return column - 1, nil
+}
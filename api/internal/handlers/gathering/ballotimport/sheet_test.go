@@ -0,0 +1,181 @@
+package ballotimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// buildXLSX packs a minimal workbook whose first sheet has the given sheetData
+func buildXLSX(t *testing.T, sharedStrings, sheetData string) []byte {
+	t.Helper()
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Ballots" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":     `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` + sharedStrings + `</sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + sheetData + `</sheetData></worksheet>`,
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// TestReadFile tests that CSV and XLSX sheets are read into the same rows
+func TestReadFile(t *testing.T) {
+	xlsx := buildXLSX(t,
+		`<si><t>owner_identification</t></si><si><t>matter_1</t></si><si><r><t>ye</t></r><r><t>s</t></r></si>`,
+		`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>`+
+			`<row r="3"><c r="A3"><v>2001234567890</v></c><c r="C3" t="inlineStr"><is><t>note</t></is></c><c r="B3" t="s"><v>2</v></c></row>`)
+
+	tests := []struct {
+		name     string
+		fileName string
+		data     []byte
+		expected [][]string
+		wantErr  bool
+	}{
+		{
+			name:     "comma separated",
+			fileName: "ballots.csv",
+			data:     []byte("owner_identification,matter_1\n2001234567890,yes\n"),
+			expected: [][]string{{"owner_identification", "matter_1"}, {"2001234567890", "yes"}},
+		},
+		{
+			name:     "semicolon separated with byte order mark",
+			fileName: "ballots.CSV",
+			data:     []byte("\xef\xbb\xbfowner_identification;matter_1\n2001234567890;\"b;a\"\n"),
+			expected: [][]string{{"owner_identification", "matter_1"}, {"2001234567890", "b;a"}},
+		},
+		{
+			name:     "xlsx with shared, rich and inline strings",
+			fileName: "ballots.xlsx",
+			data:     xlsx,
+			expected: [][]string{{"owner_identification", "matter_1"}, nil, {"2001234567890", "yes", "note"}},
+		},
+		{
+			name:     "unsupported format",
+			fileName: "ballots.ods",
+			data:     []byte("x"),
+			wantErr:  true,
+		},
+		{
+			name:     "not a zip",
+			fileName: "ballots.xlsx",
+			data:     []byte("owner_identification,matter_1"),
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ReadFile(tt.fileName, bytes.NewReader(tt.data))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ReadFile() expected an error, got %v", rows)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadFile() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(rows, tt.expected) {
+				t.Errorf("ReadFile() = %q, want %q", rows, tt.expected)
+			}
+		})
+	}
+}
+
+// TestParse tests the sheet layout checks and how rows are read
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		records     [][]string
+		expected    Sheet
+		errContains string
+	}{
+		{
+			name: "owner and delegate rows",
+			records: [][]string{
+				{"Owner_Identification", "delegate_name", "delegation_document_ref", "unit_numbers", "matter_3", "matter_4"},
+				{"200", "", "", "12; 14", "yes", ""},
+				{"", "", "", "", "", ""},
+				{"300", "Ion", "PROC-7", "", "no", "b;a"},
+			},
+			expected: Sheet{
+				MatterIDs: []int64{3, 4},
+				Rows: []Row{
+					{Line: 2, OwnerIdentification: "200", UnitNumbers: []string{"12", "14"},
+						Votes: map[int64][]string{3: {"yes"}, 4: nil}},
+					{Line: 4, OwnerIdentification: "300", DelegateName: "Ion", DelegationDocumentRef: "PROC-7",
+						Votes: map[int64][]string{3: {"no"}, 4: {"b", "a"}}},
+				},
+			},
+		},
+		{
+			name:    "short rows leave missing matters out",
+			records: [][]string{{"owner_identification", "matter_3", "matter_4"}, {"200", "yes"}},
+			expected: Sheet{
+				MatterIDs: []int64{3, 4},
+				Rows:      []Row{{Line: 2, OwnerIdentification: "200", Votes: map[int64][]string{3: {"yes"}}}},
+			},
+		},
+		{
+			name:        "missing identification column",
+			records:     [][]string{{"unit_numbers", "matter_3"}, {"1", "yes"}},
+			errContains: "owner_identification column is required",
+		},
+		{
+			name:        "unknown column",
+			records:     [][]string{{"owner_identification", "matter_x"}, {"200", "yes"}},
+			errContains: `unknown column "matter_x"`,
+		},
+		{
+			name:        "duplicate column",
+			records:     [][]string{{"owner_identification", "matter_3", "MATTER_3"}, {"200", "yes", "no"}},
+			errContains: "appears more than once",
+		},
+		{
+			name:        "no matters",
+			records:     [][]string{{"owner_identification"}, {"200"}},
+			errContains: "no matter columns",
+		},
+		{
+			name:        "no ballots",
+			records:     [][]string{{"owner_identification", "matter_3"}, {" ", ""}},
+			errContains: "no ballots",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sheet, err := Parse(tt.records)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("Parse() error = %v, want one containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(sheet, tt.expected) {
+				t.Errorf("Parse() = %+v, want %+v", sheet, tt.expected)
+			}
+		})
+	}
+}
@@ -14,47 +14,404 @@ const (
 	VotingMatterIDPathValue = "matterId"
 	ParticipantIDPathValue  = "participantId"
 	InvitationIDPathValue   = "invitationId"
+	BallotIDPathValue       = "ballotId"
+	SigningKeyIDPathValue   = "keyId"
+	TemplateIDPathValue     = "templateId"
+	DelegationIDPathValue   = "delegationId"
 )
 
 // Gathering represents a gathering event
 type Gathering struct {
-	ID                          int64     `json:"id"`
-	AssociationID               int64     `json:"association_id"`
-	Title                       string    `json:"title"`
-	Description                 string    `json:"description"`
-	Intent                      string    `json:"intent"`
-	Location                    string    `json:"location"`
-	GatheringDate               time.Time `json:"scheduled_date"` // Frontend expects scheduled_date
-	GatheringType               string    `json:"type"`           // Frontend expects type
-	VotingMode                  string    `json:"voting_mode"`    // by_weight or by_unit
-	Status                      string    `json:"status"`
-	QualificationUnitTypes      []string  `json:"qualification_unit_types"`
-	QualificationFloors         []int64   `json:"qualification_floors"`
-	QualificationEntrances      []int64   `json:"qualification_entrances"`
-	QualificationCustomRule     string    `json:"qualification_custom_rule"`
-	QualifiedUnitsCount         int       `json:"qualified_units"`
-	QualifiedUnitsTotalPart     float64   `json:"qualified_weight"`
-	QualifiedUnitsTotalArea     float64   `json:"qualified_area"`
-	ParticipatingUnitsCount     int       `json:"participating_units"`
-	ParticipatingUnitsTotalPart float64   `json:"participating_weight"`
-	ParticipatingUnitsTotalArea float64   `json:"participating_area"`
-	CreatedAt                   time.Time `json:"created_at"`
-	UpdatedAt                   time.Time `json:"updated_at"`
+	ID                          int64        `json:"id"`
+	AssociationID               int64        `json:"association_id"`
+	Title                       string       `json:"title"`
+	Description                 string       `json:"description"`
+	Intent                      string       `json:"intent"`
+	Location                    string       `json:"location"`
+	GatheringDate               time.Time    `json:"scheduled_date"` // Frontend expects scheduled_date
+	GatheringType               string       `json:"type"`           // Frontend expects type
+	VotingMode                  string       `json:"voting_mode"`    // by_weight or by_unit
+	Status                      string       `json:"status"`
+	QualificationUnitTypes      []string     `json:"qualification_unit_types"`
+	QualificationFloors         []int64      `json:"qualification_floors"`
+	QualificationEntrances      []int64      `json:"qualification_entrances"`
+	QualificationCustomRule     string       `json:"qualification_custom_rule"`
+	QualifiedUnitsCount         int          `json:"qualified_units"`
+	QualifiedUnitsTotalPart     float64      `json:"qualified_weight"`
+	QualifiedUnitsTotalArea     float64      `json:"qualified_area"`
+	ParticipatingUnitsCount     int          `json:"participating_units"`
+	ParticipatingUnitsTotalPart float64      `json:"participating_weight"`
+	ParticipatingUnitsTotalArea float64      `json:"participating_area"`
+	OpensAt                     *time.Time   `json:"opens_at"`                   // Scheduled opening (remote gatherings only)
+	ClosesAt                    *time.Time   `json:"closes_at"`                  // Scheduled closing (remote gatherings only)
+	VotingRules                 *VotingRules `json:"voting_rules,omitempty"`     // Rules snapshotted when the gathering was published
+	RepeatedFromID              *int64       `json:"repeated_from_id,omitempty"` // Gathering that missed quorum and is repeated by this one
+	RegisterFrozenAt            *time.Time   `json:"register_frozen_at"`         // When the voter register was frozen, set on publication
+	MemberVerification          string       `json:"member_verification"`        // Second factor of owners voting with their link: none, code or pin
+	CoOwnershipPolicy           string       `json:"co_ownership_policy"`        // How co-owned units vote: designated, split or agreement
+	VotingWindows               string       `json:"voting_windows"`             // When matters take votes: gathering (while active) or per_matter (opened by the chair)
+	CreatedAt                   time.Time    `json:"created_at"`
+	UpdatedAt                   time.Time    `json:"updated_at"`
 }
 
 // CreateGatheringRequest represents the request to create a gathering
 type CreateGatheringRequest struct {
-	Title                   string    `json:"title"`
-	Description             string    `json:"description"`
-	Intent                  string    `json:"intent"`
-	Location                string    `json:"location"`
-	GatheringDate           time.Time `json:"gathering_date"`
-	GatheringType           string    `json:"gathering_type"`
-	VotingMode              string    `json:"voting_mode"` // by_weight or by_unit
-	QualificationUnitTypes  []string  `json:"qualification_unit_types"`
-	QualificationFloors     []int64   `json:"qualification_floors"`
-	QualificationEntrances  []int64   `json:"qualification_entrances"`
-	QualificationCustomRule string    `json:"qualification_custom_rule"`
+	Title                   string     `json:"title"`
+	Description             string     `json:"description"`
+	Intent                  string     `json:"intent"`
+	Location                string     `json:"location"`
+	GatheringDate           time.Time  `json:"gathering_date"`
+	GatheringType           string     `json:"gathering_type"`
+	VotingMode              string     `json:"voting_mode"` // by_weight or by_unit
+	QualificationUnitTypes  []string   `json:"qualification_unit_types"`
+	QualificationFloors     []int64    `json:"qualification_floors"`
+	QualificationEntrances  []int64    `json:"qualification_entrances"`
+	QualificationCustomRule string     `json:"qualification_custom_rule"`
+	OpensAt                 *time.Time `json:"opens_at,omitempty"`
+	ClosesAt                *time.Time `json:"closes_at,omitempty"`
+}
+
+// UpdateGatheringRequest represents the request to edit a draft gathering. It carries the same
+// setup as the create request, including the scheduled opening and closing.
+type UpdateGatheringRequest CreateGatheringRequest
+
+// RepeatGatheringRequest represents the request to convene a repeated gathering with the
+// agenda of one that missed quorum. Title and location default to the original's.
+type RepeatGatheringRequest struct {
+	Title         string    `json:"title"`
+	Location      string    `json:"location"`
+	GatheringDate time.Time `json:"gathering_date"`
+}
+
+// CopyGatheringRequest represents the request to create a gathering from a template or
+// by cloning an earlier gathering. The gathering date is required, other empty fields keep the
+// template's or the original's values.
+type CopyGatheringRequest struct {
+	Title         string     `json:"title"`
+	Location      string     `json:"location"`
+	GatheringDate time.Time  `json:"gathering_date"`
+	OpensAt       *time.Time `json:"opens_at,omitempty"`
+	ClosesAt      *time.Time `json:"closes_at,omitempty"`
+}
+
+// Apply overrides the copied gathering setup with the fields set on the request
+func (r CopyGatheringRequest) Apply(base CreateGatheringRequest) CreateGatheringRequest {
+	if r.Title != "" {
+		base.Title = r.Title
+	}
+	if r.Location != "" {
+		base.Location = r.Location
+	}
+	base.GatheringDate = r.GatheringDate
+	base.OpensAt = r.OpensAt
+	base.ClosesAt = r.ClosesAt
+	return base
+}
+
+// GatheringTemplate is a reusable gathering setup and agenda, e.g. for the annual general meeting
+type GatheringTemplate struct {
+	ID                      int64          `json:"id"`
+	AssociationID           int64          `json:"association_id"`
+	Name                    string         `json:"name"`
+	Title                   string         `json:"title"`
+	Description             string         `json:"description"`
+	Intent                  string         `json:"intent"`
+	Location                string         `json:"location"`
+	GatheringType           string         `json:"gathering_type"`
+	VotingMode              string         `json:"voting_mode"`
+	QualificationUnitTypes  []string       `json:"qualification_unit_types"`
+	QualificationFloors     []int64        `json:"qualification_floors"`
+	QualificationEntrances  []int64        `json:"qualification_entrances"`
+	QualificationCustomRule string         `json:"qualification_custom_rule"`
+	Matters                 []VotingMatter `json:"matters,omitempty"` // Agenda, without gathering IDs
+	CreatedAt               time.Time      `json:"created_at"`
+	UpdatedAt               time.Time      `json:"updated_at"`
+}
+
+// SaveTemplateRequest represents the request to save a gathering as a template
+type SaveTemplateRequest struct {
+	Name string `json:"name"`
+}
+
+// MinutesTemplate describes the template an association's minutes are generated from
+type MinutesTemplate struct {
+	Custom            bool       `json:"custom"`              // False while the default template is used
+	FileName          string     `json:"file_name,omitempty"` // As uploaded
+	Format            string     `json:"format,omitempty"`
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"`
+	TextPlaceholders  []string   `json:"text_placeholders"`  // Replaced by text anywhere in the template
+	BlockPlaceholders []string   `json:"block_placeholders"` // Replaced by paragraphs and tables when alone in a paragraph
+}
+
+// SendNotificationRequest represents the request to notify owners of a gathering by email
+type SendNotificationRequest struct {
+	NotificationType string  `json:"notification_type"`   // invitation, reminder, results
+	OwnerIDs         []int64 `json:"owner_ids,omitempty"` // Every voting owner when empty, for a reminder those who have not voted
+	SendVia          string  `json:"send_via"`            // email
+	Lang             string  `json:"lang"`                // ro, ru or en, Romanian by default
+}
+
+// NotificationDelivery is one message of a notification and how its delivery went
+type NotificationDelivery struct {
+	ID               int64      `json:"id"`
+	NotificationID   int64      `json:"notification_id"`
+	OwnerID          int64      `json:"owner_id"`
+	OwnerName        string     `json:"owner_name"`
+	NotificationType string     `json:"notification_type"`
+	Recipient        string     `json:"recipient"`
+	Subject          string     `json:"subject"`
+	Status           string     `json:"status"` // pending, sent, failed
+	Attempts         int64      `json:"attempts"`
+	NextAttemptAt    *time.Time `json:"next_attempt_at,omitempty"` // While pending
+	LastError        string     `json:"last_error,omitempty"`
+	SentAt           *time.Time `json:"sent_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// IssuedInvitation is a member invitation with its plaintext token, which is only shown when it
+// is issued
+type IssuedInvitation struct {
+	InvitationID int64     `json:"invitation_id"`
+	OwnerID      int64     `json:"owner_id"`
+	OwnerName    string    `json:"owner_name"`
+	Units        []string  `json:"units"` // The units the owner votes for, e.g. "12 (Bloc A)"
+	Token        string    `json:"token"`
+	Link         string    `json:"link"`
+	PIN          string    `json:"pin,omitempty"` // Asked before the ballot is submitted, when the gathering requires a PIN
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// BulkInvitationResponse lists the invitations issued to every voting owner who had none active
+type BulkInvitationResponse struct {
+	IssuedCount  int                `json:"issued_count"`
+	SkippedCount int                `json:"skipped_count"` // Owners who already had an active invitation
+	Invitations  []IssuedInvitation `json:"invitations"`
+}
+
+// MemberVerificationRequest sets the second factor owners give before submitting a ballot with
+// their invitation link
+type MemberVerificationRequest struct {
+	Method string `json:"method"` // none, code or pin
+}
+
+// MemberVerificationSettings is the second factor a gathering requires of owners voting with
+// their invitation link
+type MemberVerificationSettings struct {
+	GatheringID           int64  `json:"gathering_id"`
+	Method                string `json:"method"`
+	InvitationsWithoutPIN int64  `json:"invitations_without_pin"` // Active invitations issued without a PIN, to reissue when one is required
+}
+
+// CoOwnershipRequest sets how units held by several owners vote in a gathering
+type CoOwnershipRequest struct {
+	Policy string `json:"policy"` // designated, split or agreement
+}
+
+// CoOwnershipSettings is how units held by several owners vote in a gathering
+type CoOwnershipSettings struct {
+	GatheringID int64  `json:"gathering_id"`
+	Policy      string `json:"policy"`
+	VotingMode  string `json:"voting_mode"` // Co-owners vote their shares only when voting by weight
+	Editable    bool   `json:"editable"`    // The policy can change while the gathering is a draft
+}
+
+// MatterVotingRequest sets when the matters of a gathering take votes
+type MatterVotingRequest struct {
+	VotingWindows string `json:"voting_windows"` // gathering or per_matter
+}
+
+// MatterVotingSettings is when the matters of a gathering take votes, and where voting on each stands
+type MatterVotingSettings struct {
+	GatheringID   int64               `json:"gathering_id"`
+	VotingWindows string              `json:"voting_windows"`
+	Editable      bool                `json:"editable"` // The voting windows can change until voting opens
+	Matters       []MatterVotingState `json:"matters"`
+}
+
+// MatterVotingState is where voting on a matter stands
+type MatterVotingState struct {
+	MatterID       int64      `json:"matter_id"`
+	OrderIndex     int        `json:"order_index"`
+	Title          string     `json:"title"`
+	IsInformative  bool       `json:"is_informative"`
+	VotingState    string     `json:"voting_state"` // pending, open or closed
+	VotingOpenedAt *time.Time `json:"voting_opened_at"`
+	VotingClosedAt *time.Time `json:"voting_closed_at"`
+}
+
+// MatterVoteRequest records a participant's vote on an open matter, added to their ballot
+type MatterVoteRequest struct {
+	ParticipantID int64    `json:"participant_id"`
+	Values        []string `json:"values"`
+	Receipt       string   `json:"receipt,omitempty"` // Needed on anonymous matters once the ballot holds anonymous votes
+}
+
+// VerificationChannel is where an owner can be sent a one-time code
+type VerificationChannel struct {
+	Channel     string `json:"channel"`     // email or sms
+	Destination string `json:"destination"` // Masked, e.g. "i***@example.com"
+}
+
+// MemberVerificationStatus tells the member app whether the owner has to verify before voting
+type MemberVerificationStatus struct {
+	Method       string                `json:"method"`
+	Verified     bool                  `json:"verified"` // The session sent along is open
+	Locked       bool                  `json:"locked"`   // Too many failed attempts, until an administrator unlocks the invitation
+	AttemptsLeft int64                 `json:"attempts_left"`
+	Channels     []VerificationChannel `json:"channels,omitempty"` // Where a one-time code can be sent
+}
+
+// VerificationCodeRequest asks for a one-time code
+type VerificationCodeRequest struct {
+	Channel string `json:"channel"` // email or sms
+	Lang    string `json:"lang"`    // ro, ru or en, Romanian by default
+}
+
+// VerificationCodeSent tells where a one-time code was sent and until when it is valid
+type VerificationCodeSent struct {
+	VerificationChannel
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// MemberVerifyRequest carries the one-time code or the PIN
+type MemberVerifyRequest struct {
+	Code string `json:"code"`
+}
+
+// MemberSession is opened by a verified second factor. Its token is sent with the ballot, in the
+// X-Member-Session header.
+type MemberSession struct {
+	SessionToken string    `json:"session_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// ReminderScheduleRequest sets when a gathering's owners who have not voted are reminded
+type ReminderScheduleRequest struct {
+	OffsetsMinutes []int64 `json:"offsets_minutes"` // How long before voting closes each reminder is sent, e.g. [10080, 2880, 360]
+	Lang           string  `json:"lang"`            // ro, ru or en, Romanian by default
+}
+
+// ReminderSchedule is a reminder sent automatically before voting closes, and how it went
+type ReminderSchedule struct {
+	ID             int64      `json:"id"`
+	OffsetMinutes  int64      `json:"offset_minutes"`
+	DueAt          time.Time  `json:"due_at"`
+	Lang           string     `json:"lang"`
+	Status         string     `json:"status"` // scheduled, sent, missed, failed
+	RanAt          *time.Time `json:"ran_at,omitempty"`
+	EligibleCount  int64      `json:"eligible_count"` // Owners who had not voted when it was sent
+	SkippedCount   int64      `json:"skipped_count"`  // Of those, owners already reminded since the previous reminder
+	QueuedCount    int64      `json:"queued_count"`
+	PendingCount   int64      `json:"pending_count"`
+	DeliveredCount int64      `json:"delivered_count"`
+	FailedCount    int64      `json:"failed_count"`
+	LastError      string     `json:"last_error,omitempty"`
+}
+
+// ReminderCampaign is the progress of a gathering's automatic reminders
+type ReminderCampaign struct {
+	GatheringID    int64              `json:"gathering_id"`
+	ClosesAt       time.Time          `json:"closes_at"`
+	Schedules      []ReminderSchedule `json:"schedules"`
+	QueuedCount    int64              `json:"queued_count"`
+	DeliveredCount int64              `json:"delivered_count"`
+	FailedCount    int64              `json:"failed_count"`
+}
+
+// VoterRegister lists a gathering's qualified units with their owners
+type VoterRegister struct {
+	GatheringID int64          `json:"gathering_id"`
+	FrozenAt    *time.Time     `json:"frozen_at"` // Nil while the gathering is a draft and the register is computed live
+	Units       []RegisterUnit `json:"units"`
+	TotalPart   float64        `json:"total_part"`
+	TotalArea   float64        `json:"total_area"`
+}
+
+// RegisterUnit is a qualified unit with its active owners
+type RegisterUnit struct {
+	UnitID          int64           `json:"unit_id"`
+	UnitNumber      string          `json:"unit_number"`
+	CadastralNumber string          `json:"cadastral_number"`
+	UnitType        string          `json:"unit_type"`
+	Floor           int64           `json:"floor"`
+	Entrance        int64           `json:"entrance"`
+	Area            float64         `json:"area"`
+	Part            float64         `json:"part"`
+	BuildingName    string          `json:"building_name"`
+	BuildingAddress string          `json:"building_address"`
+	Owners          []RegisterOwner `json:"owners"`
+}
+
+// VotingOwner returns the owner who votes for the unit, or nil if none is designated
+func (u RegisterUnit) VotingOwner() *RegisterOwner {
+	for i := range u.Owners {
+		if u.Owners[i].IsVoting {
+			return &u.Owners[i]
+		}
+	}
+	return nil
+}
+
+// RegisterOwner is an active owner of a registered unit
+type RegisterOwner struct {
+	OwnerID        int64   `json:"owner_id"`
+	OwnershipID    int64   `json:"ownership_id"`
+	Name           string  `json:"name"`
+	Identification string  `json:"identification"`
+	ContactEmail   string  `json:"contact_email"`
+	ContactPhone   string  `json:"contact_phone"`
+	IsVoting       bool    `json:"is_voting"`
+	Share          float64 `json:"share"` // Fraction of the unit held, the owners of a unit add up to 1
+}
+
+// RegisterDiff shows how the live register has drifted from the one frozen at publication
+type RegisterDiff struct {
+	GatheringID int64                `json:"gathering_id"`
+	FrozenAt    time.Time            `json:"frozen_at"`
+	InSync      bool                 `json:"in_sync"`
+	Added       []RegisterUnit       `json:"added"`   // Units that qualify now but were not registered
+	Removed     []RegisterUnit       `json:"removed"` // Registered units that no longer qualify
+	Changed     []RegisterUnitChange `json:"changed"`
+}
+
+// RegisterUnitChange is a registered unit whose attributes or owners have changed since publication
+type RegisterUnitChange struct {
+	UnitID     int64        `json:"unit_id"`
+	UnitNumber string       `json:"unit_number"`
+	Fields     []string     `json:"fields"` // e.g. part, area, owners, voting_owner
+	Frozen     RegisterUnit `json:"frozen"`
+	Live       RegisterUnit `json:"live"`
+}
+
+// QualificationDryRunRequest represents qualification criteria to try out before creating a gathering
+type QualificationDryRunRequest struct {
+	Rule      string   `json:"rule"`
+	UnitTypes []string `json:"unit_types"`
+	Floors    []int64  `json:"floors"`
+	Entrances []int64  `json:"entrances"`
+}
+
+// QualificationDryRunResult lists the units the criteria of a dry run select
+type QualificationDryRunResult struct {
+	Units      []QualificationDryRunUnit `json:"units"`
+	Count      int                       `json:"count"`
+	TotalPart  float64                   `json:"total_part"`
+	TotalArea  float64                   `json:"total_area"`
+	Attributes []string                  `json:"attributes"` // Attributes a rule can refer to
+}
+
+// QualificationDryRunUnit is a unit selected by a dry run
+type QualificationDryRunUnit struct {
+	ID              int64   `json:"id"`
+	UnitNumber      string  `json:"unit_number"`
+	CadastralNumber string  `json:"cadastral_number"`
+	UnitType        string  `json:"unit_type"`
+	Floor           int64   `json:"floor"`
+	Entrance        int64   `json:"entrance"`
+	RoomCount       int64   `json:"room_count"`
+	Area            float64 `json:"area"`
+	Part            float64 `json:"part"`
+	BuildingName    string  `json:"building_name"`
 }
 
 // QuorumInfo contains detailed information about quorum calculation
@@ -68,6 +425,29 @@ type QuorumInfo struct {
 	GatheringType      string  `json:"gathering_type"`      // initial, repeated, remote
 }
 
+// VotingRules holds an association's quorum and majority thresholds, in percent, and how many
+// owners a delegate may represent. Simple and absolute majorities must be exceeded, the other
+// thresholds reached.
+type VotingRules struct {
+	QuorumInitial             float64 `json:"quorum_initial"`
+	QuorumRepeated            float64 `json:"quorum_repeated"`
+	QuorumRemote              float64 `json:"quorum_remote"`
+	MajoritySimple            float64 `json:"majority_simple"`
+	MajorityAbsolute          float64 `json:"majority_absolute"`
+	MajorityAbsoluteTwoThirds float64 `json:"majority_absolute_two_thirds"`
+	MajorityQualified         float64 `json:"majority_qualified"` // Used when the matter sets no required_majority_value
+	MajorityUnanimous         float64 `json:"majority_unanimous"`
+	MaxProxiesPerDelegate     int64   `json:"max_proxies_per_delegate"` // Owners one delegate may represent, 0 for no limit
+}
+
+// VotingRulesProfile is an association's current voting rules
+type VotingRulesProfile struct {
+	AssociationID int64       `json:"association_id"`
+	Rules         VotingRules `json:"rules"`
+	IsDefault     bool        `json:"is_default"` // No profile stored yet, the built-in rules apply
+	UpdatedAt     *time.Time  `json:"updated_at,omitempty"`
+}
+
 // VotingResultsCached represents cached voting results in the database
 type VotingResultsCached struct {
 	ID                        int64       `json:"id"`
@@ -84,18 +464,21 @@ type VotingResultsCached struct {
 
 // VotingMatter represents a matter to be voted on
 type VotingMatter struct {
-	ID            int64        `json:"id"`
-	GatheringID   int64        `json:"gathering_id"`
-	OrderIndex    int          `json:"order_index"`
-	Title         string       `json:"title"`
-	TitleRu       string       `json:"title_ru"`
-	Description   string       `json:"description"`
-	DescriptionRu string       `json:"description_ru"`
-	MatterType    string       `json:"matter_type"`
-	VotingConfig  VotingConfig `json:"voting_config"`
-	IsInformative bool         `json:"is_informative"`
-	CreatedAt     time.Time    `json:"created_at"`
-	UpdatedAt     time.Time    `json:"updated_at"`
+	ID             int64        `json:"id"`
+	GatheringID    int64        `json:"gathering_id"`
+	OrderIndex     int          `json:"order_index"`
+	Title          string       `json:"title"`
+	TitleRu        string       `json:"title_ru"`
+	Description    string       `json:"description"`
+	DescriptionRu  string       `json:"description_ru"`
+	MatterType     string       `json:"matter_type"`
+	VotingConfig   VotingConfig `json:"voting_config"`
+	IsInformative  bool         `json:"is_informative"`
+	VotingState    string       `json:"voting_state"` // pending, open or closed, set by the chair in per_matter gatherings
+	VotingOpenedAt *time.Time   `json:"voting_opened_at"`
+	VotingClosedAt *time.Time   `json:"voting_closed_at"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
 }
 
 // VotingConfig contains the configuration for a voting matter
@@ -108,6 +491,8 @@ type VotingConfig struct {
 	AllowAbstention         bool           `json:"allow_abstention"`
 	IsAnonymous             bool           `json:"is_anonymous"`
 	ShowResultsDuringVoting bool           `json:"show_results_during_voting"`
+	RankingMethod           string         `json:"ranking_method,omitempty"` // borda (default), irv, schulze
+	Seats                   int            `json:"seats,omitempty"`          // Election matters: seats to fill, more than one is counted by STV
 }
 
 // VotingOption represents an option in a multiple choice vote
@@ -130,11 +515,106 @@ type GatheringParticipant struct {
 	UnitsPart                 float64    `json:"units_part"`
 	UnitsArea                 float64    `json:"units_area"`
 	CheckInTime               *time.Time `json:"check_in_time"`
+	CheckOutTime              *time.Time `json:"check_out_time"` // When the participant left the meeting
 	HasVoted                  bool       `json:"has_voted"`
 	CreatedAt                 time.Time  `json:"created_at"`
 	UpdatedAt                 time.Time  `json:"updated_at"`
 }
 
+// AttendanceEvent is a participant arriving, leaving or coming back during a gathering, with the
+// attendance and quorum it left the meeting with
+type AttendanceEvent struct {
+	ID              int64      `json:"id"`
+	ParticipantID   int64      `json:"participant_id"`
+	ParticipantName string     `json:"participant_name"`
+	EventType       string     `json:"event_type"` // check_in, check_out or re_entry
+	OccurredAt      time.Time  `json:"occurred_at"`
+	RecordedBy      string     `json:"recorded_by,omitempty"`
+	Present         Attendance `json:"present"`
+	QuorumMet       bool       `json:"quorum_met"`
+}
+
+// Attendance counts the participants present and the units and weight they hold
+type Attendance struct {
+	Participants int     `json:"participants"`
+	Units        int     `json:"units"`
+	Weight       float64 `json:"weight"`
+}
+
+// AttendanceTimeline is the attendance of a gathering over the meeting and at a given moment
+type AttendanceTimeline struct {
+	GatheringID int64             `json:"gathering_id"`
+	Events      []AttendanceEvent `json:"events"`
+	At          time.Time         `json:"at"`
+	Present     Attendance        `json:"present"` // Attendance at the given moment
+	Quorum      QuorumInfo        `json:"quorum"`  // Quorum at the given moment
+}
+
+// MatterAttendance is the attendance a matter was voted with: participants present while it took
+// votes, and those who voted on it
+type MatterAttendance struct {
+	Attendance
+	From *time.Time `json:"from,omitempty"` // When the matter started taking votes, nil while it has not opened
+	To   time.Time  `json:"to"`             // When it stopped, or when the attendance was computed while it still takes votes
+}
+
+// DelegationRequest registers a power of attorney for a gathering
+type DelegationRequest struct {
+	DelegateName           string                   `json:"delegate_name"`
+	DelegateIdentification string                   `json:"delegate_identification"`
+	DocumentRef            string                   `json:"document_ref"`
+	ValidFrom              *time.Time               `json:"valid_from,omitempty"` // Defaults to now
+	ValidUntil             time.Time                `json:"valid_until"`
+	Owners                 []DelegationOwnerRequest `json:"owners"`
+}
+
+// DelegationOwnerRequest names an owner a delegation represents, for all their voting units
+// unless some are listed
+type DelegationOwnerRequest struct {
+	OwnerID int64   `json:"owner_id"`
+	UnitIDs []int64 `json:"unit_ids,omitempty"`
+}
+
+// DelegationRevocationRequest records an owner revoking a delegation, or all of its owners when
+// none is given
+type DelegationRevocationRequest struct {
+	OwnerID *int64 `json:"owner_id,omitempty"`
+	Note    string `json:"note"`
+}
+
+// Delegation is a power of attorney registered for a gathering
+type Delegation struct {
+	ID                     int64               `json:"id"`
+	GatheringID            int64               `json:"gathering_id"`
+	DelegateName           string              `json:"delegate_name"`
+	DelegateIdentification string              `json:"delegate_identification"`
+	DocumentRef            string              `json:"document_ref"`
+	ValidFrom              time.Time           `json:"valid_from"`
+	ValidUntil             time.Time           `json:"valid_until"`
+	Status                 string              `json:"status"` // pending, active, expired or revoked
+	Owners                 []DelegatedOwner    `json:"owners"`
+	Document               *DelegationDocument `json:"document,omitempty"`
+	CreatedAt              time.Time           `json:"created_at"`
+}
+
+// DelegatedOwner is an owner a delegation represents
+type DelegatedOwner struct {
+	OwnerID        int64      `json:"owner_id"`
+	OwnerName      string     `json:"owner_name"`
+	UnitIDs        []int64    `json:"unit_ids"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	RevocationNote string     `json:"revocation_note,omitempty"`
+	Used           bool       `json:"used"` // The delegate has taken part on the owner's behalf
+}
+
+// DelegationDocument describes the scanned power of attorney of a delegation
+type DelegationDocument struct {
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+}
+
 // Ballot represents a submitted ballot
 type Ballot struct {
 	ID                 int64                 `json:"id"`
@@ -153,11 +633,160 @@ type Ballot struct {
 //   - yes_no: ["yes"], ["no"], or ["abstain"]
 //   - single_choice: ["<option_id>"]
 //   - multiple_choice: ["<opt1>", "<opt2>", ...]
-//   - ranking: option IDs ordered by preference (Borda count scoring; IRV and
-//     first-choice plurality are alternative methods not currently used)
+//   - ranking: option IDs ordered by preference, counted with the matter's
+//     ranking_method (Borda count, instant-runoff or Schulze)
+//
+// On anonymous matters the stored ballot keeps no values: Anonymous records that the
+// participant voted, and the choice is stored apart under the ballot's receipt.
 type BallotVote struct {
-	MatterID int64    `json:"matter_id"`
-	Values   []string `json:"values"`
+	MatterID  int64    `json:"matter_id"`
+	Values    []string `json:"values"`
+	Anonymous bool     `json:"anonymous,omitempty"`
+}
+
+// BallotMatterError describes a problem with the vote cast on a single matter
+type BallotMatterError struct {
+	MatterID string `json:"matter_id"`
+	Message  string `json:"message"`
+}
+
+// BallotValidationErrorResponse is returned when ballot content does not fit the gathering's matters
+type BallotValidationErrorResponse struct {
+	Msg    string              `json:"msg"`
+	Code   int                 `json:"code"`
+	Errors []BallotMatterError `json:"errors"`
+}
+
+// InvalidateBallotRequest represents the request to void a ballot
+type InvalidateBallotRequest struct {
+	Reason  string `json:"reason"`
+	Receipt string `json:"receipt,omitempty"` // Voter's receipt, required when the ballot has anonymous votes
+}
+
+// ReplaceBallotRequest represents the request to void a ballot and record its corrected content
+type ReplaceBallotRequest struct {
+	Reason        string                `json:"reason"`
+	Receipt       string                `json:"receipt,omitempty"` // Voter's receipt, required when the ballot has anonymous votes
+	BallotContent map[string]BallotVote `json:"ballot_content"`
+}
+
+// VerifyReceiptRequest represents the request to check a signed ballot receipt
+type VerifyReceiptRequest struct {
+	Receipt string `json:"receipt"`
+}
+
+// VerifyReceiptResponse reports whether a receipt is authentic and its ballot recorded unchanged
+type VerifyReceiptResponse struct {
+	SignatureValid bool       `json:"signature_valid"`
+	Recorded       bool       `json:"recorded"`  // The ballot exists in the gathering
+	Unchanged      bool       `json:"unchanged"` // Its content still matches the signed hash
+	IsValid        bool       `json:"is_valid"`  // The ballot is still counted (not invalidated)
+	KeyID          string     `json:"key_id"`
+	AssociationID  int64      `json:"association_id,omitempty"`
+	GatheringID    int64      `json:"gathering_id,omitempty"`
+	BallotID       int64      `json:"ballot_id,omitempty"`
+	BallotHash     string     `json:"ballot_hash,omitempty"`
+	SignedAt       *time.Time `json:"signed_at,omitempty"`
+}
+
+// BallotImportReport is the outcome of validating, and possibly importing, a sheet of paper ballots
+type BallotImportReport struct {
+	FileName   string               `json:"file_name"`
+	Committed  bool                 `json:"committed"` // False for a dry run or when the sheet has errors
+	RowCount   int                  `json:"row_count"`
+	ErrorCount int                  `json:"error_count"`
+	Errors     []BallotImportError  `json:"errors"`
+	Ballots    []BallotImportBallot `json:"ballots"` // The rows without errors
+	TotalUnits int                  `json:"total_units"`
+	TotalPart  float64              `json:"total_part"`
+	TotalArea  float64              `json:"total_area"`
+}
+
+// BallotImportError is a problem found on one row of an imported sheet, row 1 being the header
+type BallotImportError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// BallotImportBallot is a ballot read from one row of an imported sheet
+type BallotImportBallot struct {
+	Row           int      `json:"row"`
+	VoterType     string   `json:"voter_type"` // owner or delegate
+	OwnerID       int64    `json:"owner_id"`
+	OwnerName     string   `json:"owner_name"`
+	DelegateName  string   `json:"delegate_name,omitempty"`
+	UnitIDs       []int64  `json:"unit_ids"`
+	UnitNumbers   []string `json:"unit_numbers"`
+	UnitsPart     float64  `json:"units_part"`
+	UnitsArea     float64  `json:"units_area"`
+	ParticipantID *int64   `json:"participant_id,omitempty"` // Set once imported
+	BallotID      *int64   `json:"ballot_id,omitempty"`
+}
+
+// SigningKey is the published public key an association signs ballot receipts with
+type SigningKey struct {
+	KeyID         string     `json:"key_id"`
+	AssociationID int64      `json:"association_id"`
+	Algorithm     string     `json:"algorithm"`
+	PublicKey     string     `json:"public_key"` // base64
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+}
+
+// LiveResults is the update pushed to live results subscribers whenever ballots, check-ins or
+// status changes arrive
+type LiveResults struct {
+	GatheringID   int64              `json:"gathering_id"`
+	Status        string             `json:"status"`
+	Participation LiveParticipation  `json:"participation"`
+	Matters       []LiveMatterResult `json:"matters"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+// LiveParticipation is the running participation of a gathering
+type LiveParticipation struct {
+	GatheringSummary
+	Participants int `json:"participants"`
+	CheckedIn    int `json:"checked_in"`
+	Ballots      int `json:"ballots"`
+}
+
+// LiveMatterResult is the running tally of a matter. The tally is only included for matters
+// that show results during voting, or once voting has ended.
+type LiveMatterResult struct {
+	MatterID       int64                  `json:"matter_id"`
+	Title          string                 `json:"title"`
+	ResultsVisible bool                   `json:"results_visible"`
+	Tally          map[string]TallyResult `json:"tally,omitempty"`
+}
+
+// IntegrityReport is the outcome of re-walking a gathering's ballot and audit log chains
+type IntegrityReport struct {
+	GatheringID int64           `json:"gathering_id"`
+	Intact      bool            `json:"intact"`
+	CheckedAt   time.Time       `json:"checked_at"`
+	Ballots     ChainReport     `json:"ballots"`
+	AuditLog    ChainReport     `json:"audit_log"`
+	FirstBreak  *IntegrityBreak `json:"first_break,omitempty"` // First problem found, ballots first
+
+	// Merkle root over the current ballot hashes and the one published with the results, if any
+	BallotMerkleRoot    string `json:"ballot_merkle_root"`
+	PublishedMerkleRoot string `json:"published_merkle_root,omitempty"`
+}
+
+// ChainReport summarizes one hash chain
+type ChainReport struct {
+	Entries    int             `json:"entries"`
+	Head       string          `json:"head,omitempty"` // Chain hash of the last sealed entry
+	FirstBreak *IntegrityBreak `json:"first_break,omitempty"`
+}
+
+// IntegrityBreak locates the first entry that does not link to its predecessor or does not match its hash
+type IntegrityBreak struct {
+	Chain    string `json:"chain"` // ballots, audit_log, cross_reference or merkle_root
+	EntryID  int64  `json:"entry_id,omitempty"`
+	Position int    `json:"position,omitempty"` // 1-based position in the chain
+	Reason   string `json:"reason"`
 }
 
 // VoteResults represents the results of all voting matters
@@ -166,6 +795,9 @@ type VoteResults struct {
 	Results     []VoteMatterResult `json:"results"`
 	Summary     GatheringSummary   `json:"statistics"` // JSON tag 'statistics' for frontend compatibility
 	GeneratedAt string             `json:"generated_at"`
+
+	// Merkle root over the hashes of every recorded ballot, in submission order
+	BallotMerkleRoot string `json:"ballot_merkle_root,omitempty"`
 }
 
 // VoteMatterResult represents the result for a single voting matter
@@ -179,6 +811,10 @@ type VoteMatterResult struct {
 	QuorumInfo   *QuorumInfo      `json:"quorum_info,omitempty"` // Detailed quorum information
 	Result       string           `json:"result"`
 	IsPassed     bool             `json:"is_passed"`
+	// Breakdown explains how a ranking outcome was reached (nil for other matter types)
+	Breakdown *RankingBreakdown `json:"breakdown,omitempty"`
+	// Attendance is who was present when the matter was voted
+	Attendance *MatterAttendance `json:"attendance,omitempty"`
 	// Keep internal fields for calculations
 	Tally          map[string]TallyResult `json:"-"`
 	TotalVoted     float64                `json:"-"`
@@ -203,6 +839,41 @@ type TallyResult struct {
 	WeightPercentage float64 `json:"weight_percentage"` // weight-based percentage among votes cast
 }
 
+// RankingBreakdown explains how the outcome of a ranking matter was reached
+type RankingBreakdown struct {
+	Method string   `json:"method"`           // borda, irv, schulze or stv
+	Winner string   `json:"winner,omitempty"` // Empty when the outcome is an unresolved tie (and for stv)
+	Order  []string `json:"order"`            // Option IDs from best to worst
+	// STV: seats to fill, the Droop quota in vote weight and the candidates in order of election
+	Seats   int      `json:"seats,omitempty"`
+	Quota   float64  `json:"quota,omitempty"`
+	Elected []string `json:"elected,omitempty"`
+	// Ranked ballots counted and the weight they carry (scores are not ballot weights)
+	BallotCount  int     `json:"ballot_count"`
+	BallotWeight float64 `json:"ballot_weight"`
+	// Weight of the ballots ranking the winner above the runner-up, which the required majority applies to
+	Support float64 `json:"support,omitempty"`
+	// Instant-runoff and STV: standing after each counting round
+	Rounds []RankingRound `json:"rounds,omitempty"`
+	// Schulze: weight preferring the row option over the column option, and the strongest path strengths
+	Pairwise       map[string]map[string]float64 `json:"pairwise,omitempty"`
+	StrongestPaths map[string]map[string]float64 `json:"strongest_paths,omitempty"`
+}
+
+// RankingRound holds the standing of the continuing options in one counting round
+type RankingRound struct {
+	Round      int                    `json:"round"`
+	Tally      map[string]TallyResult `json:"tally"`     // Votes currently held by each continuing option
+	Exhausted  TallyResult            `json:"exhausted"` // Ballots with no continuing preference left
+	Elected    []string               `json:"elected,omitempty"`
+	Eliminated []string               `json:"eliminated,omitempty"`
+	TieBroken  bool                   `json:"tie_broken,omitempty"` // Elimination decided by a tie-break
+	// STV: votes passed on from the elected or eliminated candidate to each continuing candidate,
+	// and the part that could not be transferred because the ballots had no further preference
+	Transfers         map[string]TallyResult `json:"transfers,omitempty"`
+	TransferExhausted *TallyResult           `json:"transfer_exhausted,omitempty"`
+}
+
 // MatterStatistics holds statistics for a voting matter
 type MatterStatistics struct {
 	TotalParticipants int     `json:"total_participants"`
@@ -245,6 +916,13 @@ func DBGatheringToResponse(g database.Gathering) Gathering {
 	if g.QualificationEntrances.Valid {
 		json.Unmarshal([]byte(g.QualificationEntrances.String), &entrances)
 	}
+	var votingRules *VotingRules
+	if g.VotingRules.Valid {
+		votingRules = &VotingRules{}
+		if err := json.Unmarshal([]byte(g.VotingRules.String), votingRules); err != nil {
+			votingRules = nil
+		}
+	}
 
 	return Gathering{
 		ID:                          g.ID,
@@ -267,6 +945,14 @@ func DBGatheringToResponse(g database.Gathering) Gathering {
 		ParticipatingUnitsCount:     int(g.ParticipatingUnitsCount.Int64),
 		ParticipatingUnitsTotalPart: g.ParticipatingUnitsTotalPart.Float64,
 		ParticipatingUnitsTotalArea: g.ParticipatingUnitsTotalArea.Float64,
+		OpensAt:                     NullTimeToPtr(g.OpensAt),
+		ClosesAt:                    NullTimeToPtr(g.ClosesAt),
+		VotingRules:                 votingRules,
+		RepeatedFromID:              NullInt64ToPtr(g.RepeatedFromID),
+		RegisterFrozenAt:            NullTimeToPtr(g.RegisterFrozenAt),
+		MemberVerification:          g.MemberVerification,
+		CoOwnershipPolicy:           g.CoOwnershipPolicy,
+		VotingWindows:               g.VotingWindows,
 		CreatedAt:                   g.CreatedAt.Time,
 		UpdatedAt:                   g.UpdatedAt.Time,
 	}
@@ -278,18 +964,74 @@ func DBVotingMatterToResponse(m database.VotingMatter) VotingMatter {
 	json.Unmarshal([]byte(m.VotingConfig), &config)
 
 	return VotingMatter{
-		ID:            m.ID,
-		GatheringID:   m.GatheringID,
-		OrderIndex:    int(m.OrderIndex),
-		Title:         m.Title,
-		TitleRu:       m.TitleRu,
-		Description:   m.Description.String,
-		DescriptionRu: m.DescriptionRu.String,
-		MatterType:    m.MatterType,
-		VotingConfig:  config,
-		IsInformative: m.IsInformative != 0,
-		CreatedAt:     m.CreatedAt.Time,
-		UpdatedAt:     m.UpdatedAt.Time,
+		ID:             m.ID,
+		GatheringID:    m.GatheringID,
+		OrderIndex:     int(m.OrderIndex),
+		Title:          m.Title,
+		TitleRu:        m.TitleRu,
+		Description:    m.Description.String,
+		DescriptionRu:  m.DescriptionRu.String,
+		MatterType:     m.MatterType,
+		VotingConfig:   config,
+		IsInformative:  m.IsInformative != 0,
+		VotingState:    m.VotingState,
+		VotingOpenedAt: NullTimeToPtr(m.VotingOpenedAt),
+		VotingClosedAt: NullTimeToPtr(m.VotingClosedAt),
+		CreatedAt:      m.CreatedAt.Time,
+		UpdatedAt:      m.UpdatedAt.Time,
+	}
+}
+
+// DBGatheringTemplateToResponse converts a database GatheringTemplate and its agenda to a response GatheringTemplate
+func DBGatheringTemplateToResponse(t database.GatheringTemplate, matters []database.GatheringTemplateMatter) GatheringTemplate {
+	var unitTypes []string
+	var floors []int64
+	var entrances []int64
+
+	if t.QualificationUnitTypes.Valid {
+		json.Unmarshal([]byte(t.QualificationUnitTypes.String), &unitTypes)
+	}
+	if t.QualificationFloors.Valid {
+		json.Unmarshal([]byte(t.QualificationFloors.String), &floors)
+	}
+	if t.QualificationEntrances.Valid {
+		json.Unmarshal([]byte(t.QualificationEntrances.String), &entrances)
+	}
+
+	var agenda []VotingMatter
+	for _, m := range matters {
+		var config VotingConfig
+		json.Unmarshal([]byte(m.VotingConfig), &config)
+		agenda = append(agenda, VotingMatter{
+			ID:            m.ID,
+			OrderIndex:    int(m.OrderIndex),
+			Title:         m.Title,
+			TitleRu:       m.TitleRu,
+			Description:   m.Description.String,
+			DescriptionRu: m.DescriptionRu.String,
+			MatterType:    m.MatterType,
+			VotingConfig:  config,
+			IsInformative: m.IsInformative != 0,
+		})
+	}
+
+	return GatheringTemplate{
+		ID:                      t.ID,
+		AssociationID:           t.AssociationID,
+		Name:                    t.Name,
+		Title:                   t.Title,
+		Description:             t.Description,
+		Intent:                  t.Intent,
+		Location:                t.Location,
+		GatheringType:           t.GatheringType,
+		VotingMode:              t.VotingMode,
+		QualificationUnitTypes:  unitTypes,
+		QualificationFloors:     floors,
+		QualificationEntrances:  entrances,
+		QualificationCustomRule: t.QualificationCustomRule.String,
+		Matters:                 agenda,
+		CreatedAt:               t.CreatedAt.Time,
+		UpdatedAt:               t.UpdatedAt.Time,
 	}
 }
 
@@ -311,6 +1053,7 @@ func DBParticipantToResponse(p database.GatheringParticipant) GatheringParticipa
 		UnitsPart:                 p.UnitsPart,
 		UnitsArea:                 p.UnitsArea,
 		CheckInTime:               NullTimeToPtr(p.CheckInTime),
+		CheckOutTime:              NullTimeToPtr(p.CheckOutTime),
 		CreatedAt:                 p.CreatedAt.Time,
 		UpdatedAt:                 p.UpdatedAt.Time,
 	}
@@ -334,11 +1077,53 @@ func DBParticipantRowToResponse(p database.GetGatheringParticipantsRow) Gatherin
 		UnitsPart:                 p.UnitsPart,
 		UnitsArea:                 p.UnitsArea,
 		CheckInTime:               NullTimeToPtr(p.CheckInTime),
+		CheckOutTime:              NullTimeToPtr(p.CheckOutTime),
 		CreatedAt:                 p.CreatedAt.Time,
 		UpdatedAt:                 p.UpdatedAt.Time,
 	}
 }
 
+// DBNotificationDeliveryToResponse converts a database outbox message to a response NotificationDelivery
+func DBNotificationDeliveryToResponse(d database.GetNotificationDeliveriesRow) NotificationDelivery {
+	delivery := NotificationDelivery{
+		ID:               d.ID,
+		NotificationID:   d.NotificationID,
+		OwnerID:          d.OwnerID,
+		OwnerName:        d.OwnerName,
+		NotificationType: d.NotificationType,
+		Recipient:        d.Recipient,
+		Subject:          d.Subject,
+		Status:           d.Status,
+		Attempts:         d.Attempts,
+		LastError:        d.LastError.String,
+		SentAt:           NullTimeToPtr(d.SentAt),
+		CreatedAt:        d.CreatedAt,
+	}
+	if d.Status == "pending" {
+		delivery.NextAttemptAt = &d.NextAttemptAt
+	}
+	return delivery
+}
+
+// DBReminderScheduleToResponse converts a database reminder schedule to a response ReminderSchedule
+func DBReminderScheduleToResponse(r database.GetReminderSchedulesRow, closesAt time.Time) ReminderSchedule {
+	return ReminderSchedule{
+		ID:             r.ID,
+		OffsetMinutes:  r.OffsetMinutes,
+		DueAt:          closesAt.Add(-time.Duration(r.OffsetMinutes) * time.Minute),
+		Lang:           r.Lang,
+		Status:         r.Status,
+		RanAt:          NullTimeToPtr(r.RanAt),
+		EligibleCount:  r.EligibleCount,
+		SkippedCount:   r.SkippedCount,
+		QueuedCount:    r.QueuedCount,
+		PendingCount:   r.PendingCount,
+		DeliveredCount: r.DeliveredCount,
+		FailedCount:    r.FailedCount,
+		LastError:      r.LastError.String,
+	}
+}
+
 // Helper functions
 
 // NullInt64ToPtr converts sql.NullInt64 to *int64
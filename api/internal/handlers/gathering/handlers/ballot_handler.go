@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"context"
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/alexmarian/apc/api/internal/database"
@@ -15,6 +19,7 @@ import (
 	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
 	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
 	"github.com/alexmarian/apc/api/internal/logging"
+	"github.com/alexmarian/apc/api/receipt"
 	"go.uber.org/zap"
 )
 
@@ -25,10 +30,13 @@ type BallotHandler struct {
 	statsService         *services.StatsService
 	tallyService         *services.TallyService
 	votingResultsService *services.VotingResultsService
+	ballotValidator      *services.BallotValidator
+	liveResults          *services.LiveResults
+	delegationService    *services.DelegationService
 }
 
 // NewBallotHandler creates a new BallotHandler
-func NewBallotHandler(cfg *handlers.ApiConfig, gatheringHandler *GatheringHandler) *BallotHandler {
+func NewBallotHandler(cfg *handlers.ApiConfig, gatheringHandler *GatheringHandler, liveResults *services.LiveResults, delegationService *services.DelegationService) *BallotHandler {
 	tallyService := services.NewTallyService(cfg.Db)
 	quorumService := services.NewQuorumService(cfg.Db)
 	return &BallotHandler{
@@ -37,22 +45,26 @@ func NewBallotHandler(cfg *handlers.ApiConfig, gatheringHandler *GatheringHandle
 		statsService:         services.NewStatsService(cfg.Db),
 		tallyService:         tallyService,
 		votingResultsService: services.NewVotingResultsService(cfg.Db, quorumService, tallyService),
+		ballotValidator:      services.NewBallotValidator(cfg.Db),
+		liveResults:          liveResults,
+		delegationService:    delegationService,
 	}
 }
 
-// HandleSubmitBallot handles ballot submission
+// HandleSubmitBallot handles ballot submission. A delegate votes under a delegation from the
+// gathering's registry, for the units it covers.
 func (h *BallotHandler) HandleSubmitBallot() func(http.ResponseWriter, *http.Request) {
 	return func(rw http.ResponseWriter, req *http.Request) {
 		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
 		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
 
 		var ballotReq struct {
-			VoterType             string                       `json:"voter_type"`
-			OwnerID               int64                        `json:"owner_id"`
-			DelegatingOwnerID     *int64                       `json:"delegating_owner_id,omitempty"`
-			DelegationDocumentRef string                       `json:"delegation_document_ref,omitempty"`
-			UnitIDs               []int64                      `json:"unit_ids"`
-			BallotContent         map[string]domain.BallotVote `json:"ballot_content"`
+			VoterType         string                       `json:"voter_type"`
+			OwnerID           int64                        `json:"owner_id"`
+			DelegationID      *int64                       `json:"delegation_id,omitempty"`
+			DelegatingOwnerID *int64                       `json:"delegating_owner_id,omitempty"` // Needed when the delegation represents several owners, alone it picks the owner's current delegation
+			UnitIDs           []int64                      `json:"unit_ids"`
+			BallotContent     map[string]domain.BallotVote `json:"ballot_content"`
 		}
 		decoder := json.NewDecoder(req.Body)
 		if err := decoder.Decode(&ballotReq); err != nil {
@@ -73,29 +85,62 @@ func (h *BallotHandler) HandleSubmitBallot() func(http.ResponseWriter, *http.Req
 			return
 		}
 
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error getting gathering", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get gathering")
+			return
+		}
+
+		if !validateBallotContent(rw, req, h.ballotValidator.Validate, gathering, ballotReq.BallotContent) {
+			return
+		}
+
+		// The delegations are checked in the transaction recording the ballot, so that an owner and
+		// their delegate cannot both take part
+		tx, err := h.cfg.Conn.BeginTx(req.Context(), nil)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error starting transaction", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to submit ballot")
+			return
+		}
+		defer tx.Rollback()
+		qtx := h.cfg.Db.WithTx(tx)
+		delegations := h.delegationService.WithTx(tx)
+
 		// Determine the effective owner ID
 		var effectiveOwnerID int64
+		var proxy services.Proxy
 		if ballotReq.VoterType == "owner" {
+			if err := delegations.CheckDirectVote(req.Context(), gathering.ID, ballotReq.OwnerID, time.Now()); err != nil {
+				respondWithDelegationError(rw, err, "Failed to check delegations")
+				return
+			}
 			effectiveOwnerID = ballotReq.OwnerID
-		} else if ballotReq.VoterType == "delegate" && ballotReq.DelegatingOwnerID != nil {
-			effectiveOwnerID = *ballotReq.DelegatingOwnerID
+		} else if ballotReq.VoterType == "delegate" && (ballotReq.DelegationID != nil || ballotReq.DelegatingOwnerID != nil) {
+			proxy, err = delegations.ResolveProxy(req.Context(), gathering, ballotReq.DelegationID, ballotReq.DelegatingOwnerID, time.Now())
+			if err != nil {
+				respondWithDelegationError(rw, err, "Failed to check delegation")
+				return
+			}
+			effectiveOwnerID = proxy.OwnerID
 		} else {
-			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid voter type or missing owner information")
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid voter type or missing owner or delegation information")
 			return
 		}
 
 		// Get owner information
-		owner, err := h.cfg.Db.GetOwnerById(req.Context(), effectiveOwnerID)
+		owner, err := qtx.GetOwnerById(req.Context(), effectiveOwnerID)
 		if err != nil {
 			handlers.RespondWithError(rw, http.StatusBadRequest, "Owner not found")
 			return
 		}
 
 		// Get eligible voters to validate units and calculate weights
-		eligibleRows, err := h.cfg.Db.GetEligibleVotersWithUnits(req.Context(), database.GetEligibleVotersWithUnitsParams{
-			GatheringID:   int64(gatheringID),
-			AssociationID: int64(associationID),
-		})
+		eligibleRows, err := services.NewVoterRegisterService(qtx).EligibleVoters(req.Context(), gathering)
 		if err != nil {
 			logging.Logger.Log(zap.WarnLevel, "Error getting eligible voters", zap.Error(err))
 			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to validate units")
@@ -120,31 +165,37 @@ func (h *BallotHandler) HandleSubmitBallot() func(http.ResponseWriter, *http.Req
 				handlers.RespondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Unit %d is not owned by this owner or not qualified", unitID))
 				return
 			}
+			if ballotReq.VoterType == "delegate" && !slices.Contains(proxy.UnitIDs, unitID) {
+				handlers.RespondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Unit %d is not covered by the delegation", unitID))
+				return
+			}
 			if unit.IsAvailable == 0 {
 				handlers.RespondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Unit %d is not available (already assigned)", unitID))
 				return
 			}
 			validUnitIDs = append(validUnitIDs, unitID)
-			totalArea += unit.Area
+			totalArea += unit.Area * unit.Share
 			totalWeight += unit.VotingWeight
 		}
 
 		// Get or create participant
+		participantName := owner.Name
 		participantID := sql.NullString{String: owner.IdentificationNumber, Valid: true}
 		if ballotReq.VoterType == "delegate" {
-			participantID = sql.NullString{String: ballotReq.DelegationDocumentRef, Valid: true}
+			participantName = proxy.Delegation.DelegateName
+			participantID = sql.NullString{String: proxy.Delegation.DelegateIdentification, Valid: true}
 		}
 
 		unitsJSON, _ := json.Marshal(validUnitIDs)
 
-		participant, err := h.cfg.Db.CreateGatheringParticipant(req.Context(), database.CreateGatheringParticipantParams{
+		participant, err := qtx.CreateGatheringParticipant(req.Context(), database.CreateGatheringParticipantParams{
 			GatheringID:               int64(gatheringID),
 			ParticipantType:           ballotReq.VoterType,
-			ParticipantName:           owner.Name,
+			ParticipantName:           participantName,
 			ParticipantIdentification: participantID,
 			OwnerID:                   sql.NullInt64{Int64: ballotReq.OwnerID, Valid: ballotReq.VoterType == "owner"},
 			DelegatingOwnerID:         sql.NullInt64{Int64: effectiveOwnerID, Valid: ballotReq.VoterType == "delegate"},
-			DelegationDocumentRef:     sql.NullString{String: ballotReq.DelegationDocumentRef, Valid: ballotReq.VoterType == "delegate"},
+			DelegationDocumentRef:     sql.NullString{String: proxy.Delegation.DocumentRef, Valid: ballotReq.VoterType == "delegate"},
 			UnitsInfo:                 string(unitsJSON),
 			UnitsArea:                 totalArea,
 			UnitsPart:                 totalWeight,
@@ -156,55 +207,39 @@ func (h *BallotHandler) HandleSubmitBallot() func(http.ResponseWriter, *http.Req
 		}
 
 		// Assign unit slots to this participant
-		for _, unitID := range validUnitIDs {
-			_, err := h.cfg.Db.AssignUnitSlot(req.Context(), database.AssignUnitSlotParams{
-				ParticipantID: participant.ID,
-				GatheringID:   int64(gatheringID),
-				UnitID:        unitID,
-			})
-			if err != nil {
-				logging.Logger.Log(zap.WarnLevel, "Error assigning unit slot", zap.Error(err), zap.Int64("unit_id", unitID))
-			}
-		}
-
-		// Create ballot JSON
-		ballotJSON, err := json.Marshal(ballotReq.BallotContent)
-		if err != nil {
-			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid ballot content")
+		if !assignUnitSlots(rw, req, qtx, participant, validUnitIDs, effectiveOwnerID) {
 			return
 		}
 
-		// Calculate ballot hash
-		hash := sha256.Sum256(ballotJSON)
-		ballotHash := hex.EncodeToString(hash[:])
-
 		// Submit ballot
-		ballot, err := h.cfg.Db.CreateBallot(req.Context(), database.CreateBallotParams{
-			GatheringID:        int64(gatheringID),
-			ParticipantID:      participant.ID,
-			BallotContent:      string(ballotJSON),
-			BallotHash:         ballotHash,
-			SubmittedIp:        sql.NullString{String: req.RemoteAddr, Valid: true},
-			SubmittedUserAgent: sql.NullString{String: req.UserAgent(), Valid: true},
-		})
-
+		ballot, err := recordBallot(req, h.cfg, qtx, int64(associationID), participant, ballotReq.BallotContent)
 		if err != nil {
 			logging.Logger.Log(zap.WarnLevel, "Error creating ballot", zap.Error(err))
 			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to submit ballot")
 			return
 		}
+		if err := tx.Commit(); err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error committing ballot", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to submit ballot")
+			return
+		}
+		sealBallots(req.Context(), h.cfg, participant.GatheringID)
+		ballotHash := ballot.BallotHash
 
 		// Update gathering stats
 		go h.statsService.UpdateGatheringParticipationStats(int64(gatheringID), int64(associationID))
 
-		// Update vote tallies asynchronously
-		go h.tallyService.UpdateVoteTallies(int64(gatheringID), int(participant.ID))
+		// Update vote tallies asynchronously, then push them to live results subscribers
+		go func() {
+			h.tallyService.UpdateVoteTallies(int64(gatheringID), int(participant.ID))
+			h.liveResults.Publish(int64(gatheringID))
+		}()
 
 		// Invalidate cached results so next fetch recomputes from fresh tallies
 		h.votingResultsService.InvalidateResults(req.Context(), int64(gatheringID))
 
 		// Log audit
-		h.cfg.Db.CreateAuditLog(req.Context(), database.CreateAuditLogParams{
+		services.NewIntegrityService(h.cfg.Db).RecordAudit(req.Context(), database.CreateAuditLogParams{
 			GatheringID: int64(gatheringID),
 			EntityType:  "ballot",
 			EntityID:    ballot.ID,
@@ -214,12 +249,16 @@ func (h *BallotHandler) HandleSubmitBallot() func(http.ResponseWriter, *http.Req
 			Details:     sql.NullString{String: fmt.Sprintf(`{"hash":"%s","voter_type":"%s"}`, ballotHash, ballotReq.VoterType), Valid: true},
 		})
 
-		handlers.RespondWithJSON(rw, http.StatusCreated, map[string]interface{}{
+		// The receipt of anonymous votes is left out, as next to the participant it would reveal
+		// their choices
+		response := map[string]interface{}{
 			"status":         "ballot_submitted",
 			"ballot_hash":    ballotHash,
 			"ballot_id":      ballot.ID,
 			"participant_id": participant.ID,
-		})
+			"signed_receipt": ballot.SignedReceipt,
+		}
+		handlers.RespondWithJSON(rw, http.StatusCreated, response)
 	}
 }
 
@@ -246,6 +285,10 @@ func (h *BallotHandler) HandleGetBallots() func(http.ResponseWriter, *http.Reque
 			BallotHash      string  `json:"ballot_hash"`
 			SubmittedAt     string  `json:"submitted_at"`
 			IsValid         bool    `json:"is_valid"`
+			// Set for ballots voided or corrected by the counting commission
+			InvalidatedAt      string `json:"invalidated_at,omitempty"`
+			InvalidationReason string `json:"invalidation_reason,omitempty"`
+			ReplacesBallotID   *int64 `json:"replaces_ballot_id,omitempty"`
 		}
 
 		response := make([]BallotMetadata, len(ballots))
@@ -255,6 +298,11 @@ func (h *BallotHandler) HandleGetBallots() func(http.ResponseWriter, *http.Reque
 				submittedAt = b.SubmittedAt.Time.Format(time.RFC3339)
 			}
 
+			invalidatedAt := ""
+			if b.InvalidatedAt.Valid {
+				invalidatedAt = b.InvalidatedAt.Time.Format(time.RFC3339)
+			}
+
 			var unitIDs []int64
 			json.Unmarshal([]byte(b.UnitsInfo), &unitIDs)
 
@@ -267,6 +315,10 @@ func (h *BallotHandler) HandleGetBallots() func(http.ResponseWriter, *http.Reque
 				BallotHash:      b.BallotHash,
 				SubmittedAt:     submittedAt,
 				IsValid:         b.IsValid.Bool,
+
+				InvalidatedAt:      invalidatedAt,
+				InvalidationReason: b.InvalidationReason.String,
+				ReplacesBallotID:   domain.NullInt64ToPtr(b.ReplacesBallotID),
 			}
 		}
 
@@ -324,6 +376,637 @@ func (h *BallotHandler) HandleVerifyBallot() func(http.ResponseWriter, *http.Req
 	}
 }
 
+// HandleVerifyReceipt checks a signed ballot receipt against the association's key and the
+// recorded ballot. Ballot details are only reported for authentic receipts.
+func (h *BallotHandler) HandleVerifyReceipt() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		var verifyReq domain.VerifyReceiptRequest
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&verifyReq); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+
+		payload, _, err := receipt.Parse(verifyReq.Receipt)
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Malformed receipt")
+			return
+		}
+
+		key, err := h.cfg.Db.GetSigningKeyByKeyID(req.Context(), payload.KeyID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				handlers.RespondWithError(rw, http.StatusNotFound, "Unknown signing key")
+			} else {
+				logging.Logger.Log(zap.WarnLevel, "Error getting signing key", zap.Error(err))
+				handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to verify receipt")
+			}
+			return
+		}
+
+		response := domain.VerifyReceiptResponse{KeyID: key.KeyID}
+		publicKey, err := receipt.DecodePublicKey(key.PublicKey)
+		if err != nil {
+			logging.Logger.Log(zap.ErrorLevel, "Stored signing key is invalid", zap.String("key_id", key.KeyID))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to verify receipt")
+			return
+		}
+		if _, err := receipt.Verify(publicKey, verifyReq.Receipt); err != nil || payload.AssociationID != key.AssociationID {
+			handlers.RespondWithJSON(rw, http.StatusOK, response)
+			return
+		}
+
+		signedAt := payload.SignedTime()
+		response.SignatureValid = true
+		response.AssociationID = payload.AssociationID
+		response.GatheringID = payload.GatheringID
+		response.BallotID = payload.BallotID
+		response.BallotHash = payload.BallotHash
+		response.SignedAt = &signedAt
+
+		ballot, err := h.cfg.Db.GetBallot(req.Context(), database.GetBallotParams{
+			ID:          payload.BallotID,
+			GatheringID: payload.GatheringID,
+		})
+		if err != nil {
+			if err != sql.ErrNoRows {
+				logging.Logger.Log(zap.WarnLevel, "Error getting ballot", zap.Error(err))
+				handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to verify receipt")
+				return
+			}
+			handlers.RespondWithJSON(rw, http.StatusOK, response)
+			return
+		}
+
+		hash := sha256.Sum256([]byte(ballot.BallotContent))
+		response.Recorded = true
+		response.Unchanged = ballot.BallotHash == payload.BallotHash &&
+			hex.EncodeToString(hash[:]) == payload.BallotHash &&
+			ballot.Signature.String == receipt.Signature(verifyReq.Receipt)
+		response.IsValid = ballot.IsValid.Bool
+
+		handlers.RespondWithJSON(rw, http.StatusOK, response)
+	}
+}
+
+// HandleGetSigningKey publishes the public key behind a receipt's key ID for offline verification
+func (h *BallotHandler) HandleGetSigningKey() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		key, err := h.cfg.Db.GetSigningKeyByKeyID(req.Context(), req.PathValue(domain.SigningKeyIDPathValue))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				handlers.RespondWithError(rw, http.StatusNotFound, "Signing key not found")
+			} else {
+				logging.Logger.Log(zap.WarnLevel, "Error getting signing key", zap.Error(err))
+				handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get signing key")
+			}
+			return
+		}
+
+		var createdAt *time.Time
+		if key.CreatedAt.Valid {
+			createdAt = &key.CreatedAt.Time
+		}
+		handlers.RespondWithJSON(rw, http.StatusOK, domain.SigningKey{
+			KeyID:         key.KeyID,
+			AssociationID: key.AssociationID,
+			Algorithm:     "Ed25519",
+			PublicKey:     key.PublicKey,
+			CreatedAt:     createdAt,
+		})
+	}
+}
+
+// HandleInvalidateBallot voids a ballot, e.g. a spoiled paper ballot found by the counting commission
+func (h *BallotHandler) HandleInvalidateBallot() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+		ballotID, _ := strconv.Atoi(req.PathValue(domain.BallotIDPathValue))
+
+		var invalidateReq domain.InvalidateBallotRequest
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&invalidateReq); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+		if strings.TrimSpace(invalidateReq.Reason) == "" {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalidation reason is required")
+			return
+		}
+
+		gathering, ballot, ok := h.getCorrectableBallot(rw, req, int64(gatheringID), int64(associationID), int64(ballotID))
+		if !ok {
+			return
+		}
+
+		tx, err := h.cfg.Conn.BeginTx(req.Context(), nil)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error starting transaction", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to invalidate ballot")
+			return
+		}
+		defer tx.Rollback()
+		qtx := h.cfg.Db.WithTx(tx)
+
+		affected, err := qtx.InvalidateBallot(req.Context(), database.InvalidateBallotParams{
+			InvalidationReason: sql.NullString{String: invalidateReq.Reason, Valid: true},
+			ID:                 ballot.ID,
+		})
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error invalidating ballot", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to invalidate ballot")
+			return
+		}
+		if affected == 0 {
+			handlers.RespondWithError(rw, http.StatusConflict, "Ballot is already invalid")
+			return
+		}
+
+		if !withdrawAnonymousVotes(rw, req, qtx, ballot, invalidateReq.Receipt) {
+			return
+		}
+
+		// Audited in the transaction, so that no ballot is invalidated without a record of it
+		details, _ := json.Marshal(map[string]interface{}{
+			"reason": invalidateReq.Reason,
+			"hash":   ballot.BallotHash,
+		})
+		err = qtx.CreateAuditLog(req.Context(), database.CreateAuditLogParams{
+			GatheringID: gathering.ID,
+			EntityType:  "ballot",
+			EntityID:    ballot.ID,
+			Action:      "invalidated",
+			PerformedBy: sql.NullString{String: handlers.GetUserIdFromContext(req), Valid: true},
+			IpAddress:   sql.NullString{String: req.RemoteAddr, Valid: true},
+			Details:     sql.NullString{String: string(details), Valid: true},
+		})
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error recording ballot invalidation", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to invalidate ballot")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error committing ballot invalidation", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to invalidate ballot")
+			return
+		}
+		sealBallots(req.Context(), h.cfg, gathering.ID)
+
+		h.recount(req.Context(), gathering, ballot.ParticipantID)
+
+		handlers.RespondWithJSON(rw, http.StatusOK, map[string]interface{}{
+			"status":    "ballot_invalidated",
+			"ballot_id": ballot.ID,
+		})
+	}
+}
+
+// HandleReplaceBallot voids a ballot and records a corrected ballot for the same participant.
+// The original is kept for the record and linked from the replacement.
+func (h *BallotHandler) HandleReplaceBallot() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+		ballotID, _ := strconv.Atoi(req.PathValue(domain.BallotIDPathValue))
+
+		var replaceReq domain.ReplaceBallotRequest
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&replaceReq); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+		if strings.TrimSpace(replaceReq.Reason) == "" {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Replacement reason is required")
+			return
+		}
+		if len(replaceReq.BallotContent) == 0 {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Ballot content is required")
+			return
+		}
+
+		gathering, original, ok := h.getCorrectableBallot(rw, req, int64(gatheringID), int64(associationID), int64(ballotID))
+		if !ok {
+			return
+		}
+
+		if !validateBallotContent(rw, req, h.ballotValidator.ValidateCorrection, gathering, replaceReq.BallotContent) {
+			return
+		}
+
+		participant, err := h.cfg.Db.GetGatheringParticipant(req.Context(), database.GetGatheringParticipantParams{
+			ID:          original.ParticipantID,
+			GatheringID: gathering.ID,
+		})
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error getting participant", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to replace ballot")
+			return
+		}
+
+		tx, err := h.cfg.Conn.BeginTx(req.Context(), nil)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error starting transaction", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to replace ballot")
+			return
+		}
+		defer tx.Rollback()
+		qtx := h.cfg.Db.WithTx(tx)
+
+		affected, err := qtx.InvalidateBallot(req.Context(), database.InvalidateBallotParams{
+			InvalidationReason: sql.NullString{String: replaceReq.Reason, Valid: true},
+			ID:                 original.ID,
+		})
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error invalidating ballot", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to replace ballot")
+			return
+		}
+		if affected == 0 {
+			handlers.RespondWithError(rw, http.StatusConflict, "Ballot is already invalid")
+			return
+		}
+
+		if !withdrawAnonymousVotes(rw, req, qtx, original, replaceReq.Receipt) {
+			return
+		}
+
+		// The new receipt is not handed out: here it would be known together with the participant
+		content, _, err := services.NewAnonymousVoteService(qtx).Seal(req.Context(), participant, replaceReq.BallotContent)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error sealing anonymous votes", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to replace ballot")
+			return
+		}
+		ballotJSON, ballotHash, err := encodeBallotContent(content)
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid ballot content")
+			return
+		}
+
+		replacement, err := qtx.CreateReplacementBallot(req.Context(), database.CreateReplacementBallotParams{
+			GatheringID:        gathering.ID,
+			ParticipantID:      original.ParticipantID,
+			BallotContent:      string(ballotJSON),
+			BallotHash:         ballotHash,
+			SubmittedIp:        sql.NullString{String: req.RemoteAddr, Valid: true},
+			SubmittedUserAgent: sql.NullString{String: req.UserAgent(), Valid: true},
+			ReplacesBallotID:   sql.NullInt64{Int64: original.ID, Valid: true},
+		})
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error creating replacement ballot", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to replace ballot")
+			return
+		}
+
+		signedReceipt, err := services.NewBallotSigner(qtx, h.cfg.SigningKey).Sign(req.Context(), gathering.AssociationID, replacement)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error signing replacement ballot", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to replace ballot")
+			return
+		}
+
+		// Audited in the transaction, so that no ballot is replaced without a record of it
+		details, _ := json.Marshal(map[string]interface{}{
+			"reason":                replaceReq.Reason,
+			"original_hash":         original.BallotHash,
+			"replacement_ballot_id": replacement.ID,
+			"replacement_hash":      ballotHash,
+		})
+		err = qtx.CreateAuditLog(req.Context(), database.CreateAuditLogParams{
+			GatheringID: gathering.ID,
+			EntityType:  "ballot",
+			EntityID:    original.ID,
+			Action:      "replaced",
+			PerformedBy: sql.NullString{String: handlers.GetUserIdFromContext(req), Valid: true},
+			IpAddress:   sql.NullString{String: req.RemoteAddr, Valid: true},
+			Details:     sql.NullString{String: string(details), Valid: true},
+		})
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error recording ballot replacement", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to replace ballot")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error committing ballot replacement", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to replace ballot")
+			return
+		}
+		sealBallots(req.Context(), h.cfg, gathering.ID)
+
+		h.recount(req.Context(), gathering, original.ParticipantID)
+
+		response := map[string]interface{}{
+			"status":             "ballot_replaced",
+			"ballot_id":          replacement.ID,
+			"ballot_hash":        ballotHash,
+			"replaces_ballot_id": original.ID,
+			"signed_receipt":     signedReceipt,
+		}
+		handlers.RespondWithJSON(rw, http.StatusCreated, response)
+	}
+}
+
+// getCorrectableBallot loads a ballot the counting commission may still correct.
+// Corrections are allowed while voting is open and after closing, but not once results are tallied.
+func (h *BallotHandler) getCorrectableBallot(rw http.ResponseWriter, req *http.Request, gatheringID, associationID, ballotID int64) (database.Gathering, database.VotingBallot, bool) {
+	gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+		ID:            gatheringID,
+		AssociationID: associationID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+		} else {
+			logging.Logger.Log(zap.WarnLevel, "Error getting gathering", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get gathering")
+		}
+		return gathering, database.VotingBallot{}, false
+	}
+	if gathering.Status != services.GatheringStatusActive && gathering.Status != services.GatheringStatusClosed {
+		handlers.RespondWithError(rw, http.StatusBadRequest, "Ballots can only be corrected while the gathering is active or closed")
+		return gathering, database.VotingBallot{}, false
+	}
+
+	ballot, err := h.cfg.Db.GetBallot(req.Context(), database.GetBallotParams{
+		ID:          ballotID,
+		GatheringID: gatheringID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Ballot not found")
+		} else {
+			logging.Logger.Log(zap.WarnLevel, "Error getting ballot", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get ballot")
+		}
+		return gathering, ballot, false
+	}
+	if !ballot.IsValid.Bool {
+		handlers.RespondWithError(rw, http.StatusConflict, "Ballot is already invalid")
+		return gathering, ballot, false
+	}
+
+	return gathering, ballot, true
+}
+
+// recount rebuilds the tallies after a ballot correction and refreshes the results cache.
+// Closed gatherings get their cached results recomputed; open ones are recomputed on the next fetch.
+func (h *BallotHandler) recount(ctx context.Context, gathering database.Gathering, participantID int64) {
+	h.tallyService.UpdateVoteTallies(gathering.ID, int(participantID))
+	go h.liveResults.Publish(gathering.ID)
+
+	if gathering.Status == services.GatheringStatusClosed {
+		if _, err := h.votingResultsService.ComputeAndStoreResults(ctx, gathering.ID, gathering.AssociationID); err != nil {
+			logging.Logger.Log(zap.ErrorLevel, "Error recomputing results after ballot correction",
+				zap.Int64("gathering_id", gathering.ID),
+				zap.Error(err))
+		}
+		return
+	}
+
+	if err := h.votingResultsService.InvalidateResults(ctx, gathering.ID); err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error invalidating cached results",
+			zap.Int64("gathering_id", gathering.ID),
+			zap.Error(err))
+	}
+}
+
+// recordedBallot is a stored ballot together with the receipts handed to the voter
+type recordedBallot struct {
+	database.VotingBallot
+	Receipt       string // Key of the anonymous votes, empty when the ballot has none
+	SignedReceipt string // Server-signed proof that the ballot was recorded
+}
+
+// mergeMatterVotes adds votes on matters to a participant's ballot in a per_matter gathering. The
+// ballot in force is superseded by one holding its votes and the new ones, linked to it like a
+// correction; a participant without a ballot in force gets one holding just the new votes.
+// Anonymous votes join the ballot's receipt, which the voter hands back once they have one. The
+// superseded ballot is recorded as replaced in the audit log, as a correction is.
+func mergeMatterVotes(req *http.Request, cfg *handlers.ApiConfig, associationID int64, participant database.GatheringParticipant, votes map[string]domain.BallotVote, anonymousReceipt, performedBy string) (recordedBallot, error) {
+	tx, err := cfg.Conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		return recordedBallot{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	q := cfg.Db.WithTx(tx)
+
+	current, err := q.GetBallotByParticipant(req.Context(), database.GetBallotByParticipantParams{
+		GatheringID:   participant.GatheringID,
+		ParticipantID: participant.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !current.IsValid.Bool) {
+		ballot, err := recordBallot(req, cfg, q, associationID, participant, votes)
+		if err != nil {
+			return recordedBallot{}, err
+		}
+		if err := tx.Commit(); err != nil {
+			return recordedBallot{}, err
+		}
+		sealBallots(req.Context(), cfg, participant.GatheringID)
+		return ballot, nil
+	}
+	if err != nil {
+		return recordedBallot{}, fmt.Errorf("failed to get ballot: %w", err)
+	}
+
+	var content map[string]domain.BallotVote
+	if err := json.Unmarshal([]byte(current.BallotContent), &content); err != nil {
+		return recordedBallot{}, fmt.Errorf("failed to read ballot content: %w", err)
+	}
+	if _, err := services.MergeMatterVotes(content, votes); err != nil {
+		return recordedBallot{}, err
+	}
+	stored, anonymousReceipt, err := services.NewAnonymousVoteService(q).SealAdded(req.Context(), participant, current, votes, anonymousReceipt)
+	if err != nil {
+		return recordedBallot{}, err
+	}
+	merged, err := services.MergeMatterVotes(content, stored)
+	if err != nil {
+		return recordedBallot{}, err
+	}
+	ballotJSON, ballotHash, err := encodeBallotContent(merged)
+	if err != nil {
+		return recordedBallot{}, err
+	}
+
+	matterIDs := make([]string, 0, len(votes))
+	for matterID := range votes {
+		matterIDs = append(matterIDs, matterID)
+	}
+	slices.Sort(matterIDs)
+	reason := fmt.Sprintf("Superseded by votes on matters %s", strings.Join(matterIDs, ", "))
+	affected, err := q.InvalidateBallot(req.Context(), database.InvalidateBallotParams{
+		InvalidationReason: sql.NullString{String: reason, Valid: true},
+		ID:                 current.ID,
+	})
+	if err != nil {
+		return recordedBallot{}, fmt.Errorf("failed to supersede ballot: %w", err)
+	}
+	if affected == 0 {
+		return recordedBallot{}, fmt.Errorf("ballot %d was superseded concurrently", current.ID)
+	}
+
+	ballot, err := q.CreateReplacementBallot(req.Context(), database.CreateReplacementBallotParams{
+		GatheringID:        participant.GatheringID,
+		ParticipantID:      participant.ID,
+		BallotContent:      string(ballotJSON),
+		BallotHash:         ballotHash,
+		SubmittedIp:        sql.NullString{String: req.RemoteAddr, Valid: true},
+		SubmittedUserAgent: sql.NullString{String: req.UserAgent(), Valid: true},
+		ReplacesBallotID:   sql.NullInt64{Int64: current.ID, Valid: true},
+	})
+	if err != nil {
+		return recordedBallot{}, err
+	}
+
+	signedReceipt, err := services.NewBallotSigner(q, cfg.SigningKey).Sign(req.Context(), associationID, ballot)
+	if err != nil {
+		return recordedBallot{}, err
+	}
+
+	details, _ := json.Marshal(map[string]interface{}{
+		"reason":                reason,
+		"original_hash":         current.BallotHash,
+		"replacement_ballot_id": ballot.ID,
+		"replacement_hash":      ballotHash,
+	})
+	err = q.CreateAuditLog(req.Context(), database.CreateAuditLogParams{
+		GatheringID: participant.GatheringID,
+		EntityType:  "ballot",
+		EntityID:    current.ID,
+		Action:      "replaced",
+		PerformedBy: sql.NullString{String: performedBy, Valid: true},
+		IpAddress:   sql.NullString{String: req.RemoteAddr, Valid: true},
+		Details:     sql.NullString{String: string(details), Valid: true},
+	})
+	if err != nil {
+		return recordedBallot{}, fmt.Errorf("failed to record the superseded ballot: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return recordedBallot{}, err
+	}
+	sealBallots(req.Context(), cfg, participant.GatheringID)
+	return recordedBallot{VotingBallot: ballot, Receipt: anonymousReceipt, SignedReceipt: signedReceipt}, nil
+}
+
+// recordBallot seals, stores and signs a ballot with the given queries, leaving the
+// transaction and the sealing of the ballot chain to the caller
+func recordBallot(req *http.Request, cfg *handlers.ApiConfig, q *database.Queries, associationID int64, participant database.GatheringParticipant, content map[string]domain.BallotVote) (recordedBallot, error) {
+	stored, anonymousReceipt, err := services.NewAnonymousVoteService(q).Seal(req.Context(), participant, content)
+	if err != nil {
+		return recordedBallot{}, err
+	}
+	ballotJSON, ballotHash, err := encodeBallotContent(stored)
+	if err != nil {
+		return recordedBallot{}, err
+	}
+
+	ballot, err := q.CreateBallot(req.Context(), database.CreateBallotParams{
+		GatheringID:        participant.GatheringID,
+		ParticipantID:      participant.ID,
+		BallotContent:      string(ballotJSON),
+		BallotHash:         ballotHash,
+		SubmittedIp:        sql.NullString{String: req.RemoteAddr, Valid: true},
+		SubmittedUserAgent: sql.NullString{String: req.UserAgent(), Valid: true},
+	})
+	if err != nil {
+		return recordedBallot{}, err
+	}
+
+	signedReceipt, err := services.NewBallotSigner(q, cfg.SigningKey).Sign(req.Context(), associationID, ballot)
+	if err != nil {
+		return recordedBallot{}, err
+	}
+	return recordedBallot{VotingBallot: ballot, Receipt: anonymousReceipt, SignedReceipt: signedReceipt}, nil
+}
+
+// assignUnitSlots claims the units' slots for the participant within the transaction recording
+// them. It writes the error response itself and reports whether every slot was claimed.
+func assignUnitSlots(rw http.ResponseWriter, req *http.Request, q *database.Queries, participant database.GatheringParticipant, unitIDs []int64, ownerID int64) bool {
+	for _, unitID := range unitIDs {
+		_, err := q.AssignUnitSlot(req.Context(), database.AssignUnitSlotParams{
+			ParticipantID: participant.ID,
+			GatheringID:   participant.GatheringID,
+			UnitID:        unitID,
+			OwnerID:       ownerID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			handlers.RespondWithError(rw, http.StatusConflict, fmt.Sprintf("Voting rights for unit %d have already been exercised", unitID))
+			return false
+		}
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error assigning unit slot", zap.Error(err), zap.Int64("unit_id", unitID))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to assign unit slots")
+			return false
+		}
+	}
+	return true
+}
+
+// sealBallots links newly committed ballots and audit entries into the gathering's chains. They
+// are already recorded, so a failure is only logged; the next seal picks them up.
+func sealBallots(ctx context.Context, cfg *handlers.ApiConfig, gatheringID int64) {
+	if err := services.NewIntegrityService(cfg.Db).Seal(ctx, gatheringID); err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error sealing ballots",
+			zap.Int64("gathering_id", gatheringID),
+			zap.Error(err))
+	}
+}
+
+// encodeBallotContent serializes ballot content and computes its SHA256 hash
+func encodeBallotContent(content map[string]domain.BallotVote) ([]byte, string, error) {
+	ballotJSON, err := json.Marshal(content)
+	if err != nil {
+		return nil, "", err
+	}
+	hash := sha256.Sum256(ballotJSON)
+	return ballotJSON, hex.EncodeToString(hash[:]), nil
+}
+
+// withdrawAnonymousVotes invalidates the anonymous votes cast with a ballot being corrected and
+// responds with the problem when the voter's receipt is missing or wrong. It reports whether
+// the correction may go ahead.
+func withdrawAnonymousVotes(rw http.ResponseWriter, req *http.Request, q *database.Queries, ballot database.VotingBallot, anonymousReceipt string) bool {
+	err := services.NewAnonymousVoteService(q).Withdraw(req.Context(), ballot, anonymousReceipt)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, services.ErrReceiptRequired), errors.Is(err, services.ErrReceiptMismatch):
+		handlers.RespondWithError(rw, http.StatusUnprocessableEntity, err.Error())
+	default:
+		logging.Logger.Log(zap.WarnLevel, "Error withdrawing anonymous votes", zap.Error(err))
+		handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to withdraw anonymous votes")
+	}
+	return false
+}
+
+// ballotCheck checks ballot content against the voting matters of a gathering
+type ballotCheck func(ctx context.Context, gathering database.Gathering, content map[string]domain.BallotVote) ([]domain.BallotMatterError, error)
+
+// validateBallotContent checks ballot content against the gathering's voting matters and
+// responds with the per-matter errors when it is invalid. It reports whether the ballot may be stored.
+func validateBallotContent(rw http.ResponseWriter, req *http.Request, check ballotCheck, gathering database.Gathering, content map[string]domain.BallotVote) bool {
+	matterErrors, err := check(req.Context(), gathering, content)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error validating ballot", zap.Error(err))
+		handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to validate ballot")
+		return false
+	}
+	if len(matterErrors) > 0 {
+		handlers.RespondWithJSON(rw, http.StatusBadRequest, domain.BallotValidationErrorResponse{
+			Msg:    "Invalid ballot content",
+			Code:   http.StatusBadRequest,
+			Errors: matterErrors,
+		})
+		return false
+	}
+	return true
+}
+
 // Helper function to convert ballot row to voting ballot
 func ballotRowToVotingBallot(b database.GetBallotsForGatheringRow) database.VotingBallot {
 	return database.VotingBallot{
@@ -341,5 +1024,6 @@ func ballotRowToVotingBallot(b database.GetBallotsForGatheringRow) database.Voti
 		Signature:            b.Signature,
 		InvalidationReason:   b.InvalidationReason,
 		InvalidatedAt:        b.InvalidatedAt,
+		ReplacesBallotID:     b.ReplacesBallotID,
 	}
 }
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/handlers"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
+)
+
+// newActiveBallotTest sets up an active gathering with one matter and a single owner voting for
+// its only unit, and returns the handler of its ballots
+func newActiveBallotTest(t *testing.T) (*handlers.ApiConfig, *BallotHandler) {
+	t.Helper()
+	cfg := newTestConfig(t)
+	mustExec(t, cfg.Conn,
+		`INSERT INTO associations (id, name, address, administrator) VALUES (1, 'Association', 'Street 1', 'Admin')`,
+		`INSERT INTO buildings (id, name, address, cadastral_number, total_area, association_id) VALUES (1, 'Block A', 'Street 1', 'B-1', 100, 1)`,
+		`INSERT INTO units (id, cadastral_number, building_id, unit_number, address, area, part, floor) VALUES (1, 'U-1', 1, '1', 'Street 1', 50, 1, 1)`,
+		`INSERT INTO owners (id, name, normalized_name, identification_number, association_id) VALUES (1, 'Owner', 'owner', '1000000000001', 1)`,
+		`INSERT INTO ownerships (unit_id, owner_id, association_id, registration_document, registration_date, is_voting)
+			VALUES (1, 1, 1, 'Deed', '2020-01-01', TRUE)`,
+		`INSERT INTO gatherings (id, association_id, title, description, intent, gathering_date, gathering_type)
+			VALUES (1, 1, 'Annual meeting', '', '', '2026-05-12 18:00:00', 'initial')`,
+		`INSERT INTO voting_matters (id, gathering_id, order_index, title, matter_type, voting_config)
+			VALUES (1, 1, 1, 'Budget', 'budget', '{"type":"yes_no"}')`,
+	)
+	lifecycle := services.NewLifecycleService(cfg.Db, cfg.Conn, nil, nil)
+	for _, status := range []string{services.GatheringStatusPublished, services.GatheringStatusActive} {
+		gathering, err := cfg.Db.GetGatheringByID(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("failed to get gathering: %v", err)
+		}
+		if _, err := lifecycle.Transition(context.Background(), gathering, status, services.TransitionActor{Trigger: "manual"}); err != nil {
+			t.Fatalf("Transition(%s) error = %v", status, err)
+		}
+	}
+
+	liveResults := services.NewLiveResults(cfg.Db, services.NewStatsService(cfg.Db))
+	return cfg, NewBallotHandler(cfg, NewGatheringHandler(cfg, nil, lifecycle), liveResults, services.NewDelegationService(cfg.Db, cfg.Conn))
+}
+
+// submitBallot submits a yes vote for unit 1 with the given voter fields and returns the response
+func submitBallot(h *BallotHandler, voter string) *httptest.ResponseRecorder {
+	body := fmt.Sprintf(`{%s,"unit_ids":[1],"ballot_content":{"1":{"matter_id":1,"values":["yes"]}}}`, voter)
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.SetPathValue(handlers.AssociationIdPathValue, "1")
+	req.SetPathValue(domain.GatheringIDPathValue, "1")
+	rw := httptest.NewRecorder()
+	h.HandleSubmitBallot()(rw, req)
+	return rw
+}
+
+// TestOwnerAndDelegateVoteOnce tests that an owner and their delegate, voting while the
+// delegation is revoked, record one ballot at most, and only the one the registry allows
+func TestOwnerAndDelegateVoteOnce(t *testing.T) {
+	ctx := context.Background()
+	cfg, h := newActiveBallotTest(t)
+	gathering, _ := cfg.Db.GetGatheringByID(ctx, 1)
+	delegationService := services.NewDelegationService(cfg.Db, cfg.Conn)
+	now := time.Now()
+	delegation, err := delegationService.Create(ctx, gathering, domain.DelegationRequest{
+		DelegateName:           "Delegate",
+		DelegateIdentification: "2000000000001",
+		ValidFrom:              &now,
+		ValidUntil:             now.Add(24 * time.Hour),
+		Owners:                 []domain.DelegationOwnerRequest{{OwnerID: 1}},
+	}, now)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := delegationService.SaveDocument(ctx, gathering, delegation.ID, "proxy.pdf", []byte("%PDF-1.4\n"), now); err != nil {
+		t.Fatalf("SaveDocument() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	recorded := make(map[string]int)
+	var revokeErr error
+	for i := 0; i < 4; i++ {
+		for voterType, voter := range map[string]string{
+			"owner":    `"voter_type":"owner","owner_id":1`,
+			"delegate": fmt.Sprintf(`"voter_type":"delegate","delegation_id":%d`, delegation.ID),
+		} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if submitBallot(h, voter).Code == http.StatusCreated {
+					mu.Lock()
+					recorded[voterType]++
+					mu.Unlock()
+				}
+			}()
+		}
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, revokeErr = delegationService.Revoke(ctx, gathering, delegation.ID, nil, "Votes in person", time.Now())
+	}()
+	wg.Wait()
+
+	if recorded["owner"]+recorded["delegate"] > 1 {
+		t.Fatalf("recorded %d ballots by the owner and %d by the delegate, want one at most", recorded["owner"], recorded["delegate"])
+	}
+	if recorded["delegate"] == 1 && !errors.Is(revokeErr, services.ErrDelegationUsed) {
+		t.Errorf("Revoke() error = %v after the delegate voted, want %v", revokeErr, services.ErrDelegationUsed)
+	}
+	if recorded["owner"] == 1 && revokeErr != nil {
+		t.Errorf("the owner voted although Revoke() failed: %v", revokeErr)
+	}
+
+	var ballots int
+	cfg.Conn.QueryRow(`SELECT COUNT(*) FROM voting_ballots WHERE gathering_id = 1`).Scan(&ballots)
+	if ballots != recorded["owner"]+recorded["delegate"] {
+		t.Errorf("%d ballots stored, %d reported recorded", ballots, recorded["owner"]+recorded["delegate"])
+	}
+}
+
+// TestBallotCorrectionsAudited tests that a ballot is invalidated or replaced only together with
+// its audit entry
+func TestBallotCorrectionsAudited(t *testing.T) {
+	cfg, h := newActiveBallotTest(t)
+	if rw := submitBallot(h, `"voter_type":"owner","owner_id":1`); rw.Code != http.StatusCreated {
+		t.Fatalf("HandleSubmitBallot() status %d, body %s", rw.Code, rw.Body.String())
+	}
+
+	correct := func(handler http.HandlerFunc, body string) int {
+		t.Helper()
+		req := handlers.AddUserIdToContext(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)), "clerk")
+		req.SetPathValue(handlers.AssociationIdPathValue, "1")
+		req.SetPathValue(domain.GatheringIDPathValue, "1")
+		req.SetPathValue(domain.BallotIDPathValue, "1")
+		rw := httptest.NewRecorder()
+		handler(rw, req)
+		return rw.Code
+	}
+	count := func(query string) int {
+		t.Helper()
+		var n int
+		if err := cfg.Conn.QueryRow(query).Scan(&n); err != nil {
+			t.Fatalf("failed to count: %v", err)
+		}
+		return n
+	}
+
+	// The audit log refuses the entries, so the corrections are rolled back
+	mustExec(t, cfg.Conn, `CREATE TRIGGER refuse_corrections BEFORE INSERT ON voting_audit_log
+		WHEN NEW.action IN ('invalidated', 'replaced') BEGIN SELECT RAISE(ABORT, 'audit log unavailable'); END`)
+	replace := `{"reason":"Misread","ballot_content":{"1":{"matter_id":1,"values":["no"]}}}`
+	if got := correct(h.HandleReplaceBallot(), replace); got != http.StatusInternalServerError {
+		t.Errorf("HandleReplaceBallot() status %d without audit, want %d", got, http.StatusInternalServerError)
+	}
+	if got := correct(h.HandleInvalidateBallot(), `{"reason":"Spoiled"}`); got != http.StatusInternalServerError {
+		t.Errorf("HandleInvalidateBallot() status %d without audit, want %d", got, http.StatusInternalServerError)
+	}
+	if n := count(`SELECT COUNT(*) FROM voting_ballots WHERE is_valid`); n != 1 || count(`SELECT COUNT(*) FROM voting_ballots`) != 1 {
+		t.Fatalf("%d valid ballots after the failed corrections, want the original alone", n)
+	}
+
+	mustExec(t, cfg.Conn, `DROP TRIGGER refuse_corrections`)
+	if got := correct(h.HandleReplaceBallot(), replace); got != http.StatusCreated {
+		t.Fatalf("HandleReplaceBallot() status %d, want %d", got, http.StatusCreated)
+	}
+	if n := count(`SELECT COUNT(*) FROM voting_audit_log WHERE entity_id = 1 AND action = 'replaced' AND chain_hash IS NOT NULL`); n != 1 {
+		t.Errorf("%d sealed replacement entries, want 1", n)
+	}
+}
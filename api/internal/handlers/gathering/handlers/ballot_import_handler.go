@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/ballotimport"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"go.uber.org/zap"
+)
+
+// maxBallotImportSize bounds the size of an uploaded ballot sheet
+const maxBallotImportSize = 10 << 20
+
+// ballotImportFileField is the multipart form field holding the sheet
+const ballotImportFileField = "file"
+
+// errSlotTaken is returned when a unit slot is assigned between validating and importing a sheet
+var errSlotTaken = errors.New("unit slot already assigned")
+
+// BallotImportHandler imports paper ballots in bulk from a spreadsheet
+type BallotImportHandler struct {
+	cfg                  *handlers.ApiConfig
+	importService        *services.BallotImportService
+	statsService         *services.StatsService
+	tallyService         *services.TallyService
+	votingResultsService *services.VotingResultsService
+	liveResults          *services.LiveResults
+}
+
+// NewBallotImportHandler creates a new BallotImportHandler
+func NewBallotImportHandler(cfg *handlers.ApiConfig, liveResults *services.LiveResults) *BallotImportHandler {
+	tallyService := services.NewTallyService(cfg.Db)
+	quorumService := services.NewQuorumService(cfg.Db)
+	return &BallotImportHandler{
+		cfg:                  cfg,
+		importService:        services.NewBallotImportService(cfg.Db),
+		statsService:         services.NewStatsService(cfg.Db),
+		tallyService:         tallyService,
+		votingResultsService: services.NewVotingResultsService(cfg.Db, quorumService, tallyService),
+		liveResults:          liveResults,
+	}
+}
+
+// HandleDryRunImport validates a sheet of paper ballots and reports the problems found row by row,
+// without storing anything
+func (h *BallotImportHandler) HandleDryRunImport() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		report, _, ok := h.validateUpload(rw, req)
+		if !ok {
+			return
+		}
+		handlers.RespondWithJSON(rw, http.StatusOK, report)
+	}
+}
+
+// HandleImport validates a sheet of paper ballots and, when every row is valid, creates the
+// participants, assigns their unit slots and stores their ballots in a single transaction.
+// A sheet with errors is rejected with the validation report and nothing is stored.
+func (h *BallotImportHandler) HandleImport() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		report, ballots, ok := h.validateUpload(rw, req)
+		if !ok {
+			return
+		}
+		if report.ErrorCount > 0 {
+			handlers.RespondWithJSON(rw, http.StatusUnprocessableEntity, report)
+			return
+		}
+
+		tx, err := h.cfg.Conn.BeginTx(req.Context(), nil)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error starting transaction", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to import ballots")
+			return
+		}
+		defer tx.Rollback()
+		qtx := h.cfg.Db.WithTx(tx)
+
+		for i, ballot := range ballots {
+			participant, recorded, err := storeImportedBallot(req, h.cfg, qtx, int64(associationID), int64(gatheringID), ballot)
+			if err != nil {
+				if errors.Is(err, errSlotTaken) {
+					handlers.RespondWithError(rw, http.StatusConflict, fmt.Sprintf("Row %d: %v, validate the sheet again", ballot.Row, err))
+					return
+				}
+				logging.Logger.Log(zap.WarnLevel, "Error importing ballot",
+					zap.Int("gathering_id", gatheringID),
+					zap.Int("row", ballot.Row),
+					zap.Error(err))
+				handlers.RespondWithError(rw, http.StatusInternalServerError, fmt.Sprintf("Failed to import the ballot on row %d", ballot.Row))
+				return
+			}
+			report.Ballots[i].ParticipantID = &participant.ID
+			report.Ballots[i].BallotID = &recorded.ID
+
+			// Each ballot is audited like one submitted on its own, sealed with the ballots after commit
+			err = qtx.CreateAuditLog(req.Context(), database.CreateAuditLogParams{
+				GatheringID: int64(gatheringID),
+				EntityType:  "ballot",
+				EntityID:    recorded.ID,
+				Action:      "submitted",
+				PerformedBy: sql.NullString{String: handlers.GetUserIdFromContext(req), Valid: true},
+				IpAddress:   sql.NullString{String: req.RemoteAddr, Valid: true},
+				Details:     sql.NullString{String: fmt.Sprintf(`{"hash":"%s","voter_type":"%s","source":"import","row":%d}`, recorded.BallotHash, ballot.VoterType, ballot.Row), Valid: true},
+			})
+			if err != nil {
+				logging.Logger.Log(zap.WarnLevel, "Error auditing imported ballot",
+					zap.Int("gathering_id", gatheringID),
+					zap.Int("row", ballot.Row),
+					zap.Error(err))
+				handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to import ballots")
+				return
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error committing ballot import", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to import ballots")
+			return
+		}
+		report.Committed = true
+		sealBallots(req.Context(), h.cfg, int64(gatheringID))
+
+		// One tally and stats update for the whole sheet
+		go h.statsService.UpdateGatheringParticipationStats(int64(gatheringID), int64(associationID))
+		go func() {
+			h.tallyService.UpdateVoteTallies(int64(gatheringID), 0)
+			h.liveResults.Publish(int64(gatheringID))
+		}()
+		h.votingResultsService.InvalidateResults(req.Context(), int64(gatheringID))
+
+		details, _ := json.Marshal(map[string]interface{}{
+			"file_name": report.FileName,
+			"ballots":   len(ballots),
+			"units":     report.TotalUnits,
+		})
+		services.NewIntegrityService(h.cfg.Db).RecordAudit(req.Context(), database.CreateAuditLogParams{
+			GatheringID: int64(gatheringID),
+			EntityType:  "gathering",
+			EntityID:    int64(gatheringID),
+			Action:      "ballots_imported",
+			PerformedBy: sql.NullString{String: handlers.GetUserIdFromContext(req), Valid: true},
+			IpAddress:   sql.NullString{String: req.RemoteAddr, Valid: true},
+			Details:     sql.NullString{String: string(details), Valid: true},
+		})
+
+		handlers.RespondWithJSON(rw, http.StatusCreated, report)
+	}
+}
+
+// validateUpload reads the uploaded sheet and validates it against the gathering, responding
+// with the problem when the gathering or the file cannot be used
+func (h *BallotImportHandler) validateUpload(rw http.ResponseWriter, req *http.Request) (domain.BallotImportReport, []services.ImportedBallot, bool) {
+	associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+	gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+	gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+		ID:            int64(gatheringID),
+		AssociationID: int64(associationID),
+	})
+	if err != nil {
+		handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+		return domain.BallotImportReport{}, nil, false
+	}
+	if gathering.Status != services.GatheringStatusActive {
+		handlers.RespondWithError(rw, http.StatusBadRequest, "Gathering is not active")
+		return domain.BallotImportReport{}, nil, false
+	}
+
+	req.Body = http.MaxBytesReader(rw, req.Body, maxBallotImportSize)
+	file, header, err := req.FormFile(ballotImportFileField)
+	if err != nil {
+		handlers.RespondWithError(rw, http.StatusBadRequest, fmt.Sprintf("A CSV or XLSX file of at most %d MB is required in the %q field", maxBallotImportSize>>20, ballotImportFileField))
+		return domain.BallotImportReport{}, nil, false
+	}
+	defer file.Close()
+
+	records, err := ballotimport.ReadFile(header.Filename, file)
+	if err != nil {
+		handlers.RespondWithError(rw, http.StatusBadRequest, err.Error())
+		return domain.BallotImportReport{}, nil, false
+	}
+	sheet, err := ballotimport.Parse(records)
+	if err != nil {
+		handlers.RespondWithError(rw, http.StatusBadRequest, err.Error())
+		return domain.BallotImportReport{}, nil, false
+	}
+
+	report, ballots, err := h.importService.Validate(req.Context(), gathering, sheet)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error validating ballot import",
+			zap.Int("gathering_id", gatheringID),
+			zap.Error(err))
+		handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to validate ballots")
+		return domain.BallotImportReport{}, nil, false
+	}
+	report.FileName = header.Filename
+	return report, ballots, true
+}
+
+// storeImportedBallot creates the participant of an imported ballot, assigns its unit slots
+// and records the ballot with the given queries
+func storeImportedBallot(req *http.Request, cfg *handlers.ApiConfig, q *database.Queries, associationID, gatheringID int64, ballot services.ImportedBallot) (database.GatheringParticipant, recordedBallot, error) {
+	isDelegate := ballot.VoterType == "delegate"
+	name := ballot.OwnerName
+	if ballot.DelegateName != "" {
+		name = ballot.DelegateName
+	}
+	unitsJSON, _ := json.Marshal(ballot.UnitIDs)
+
+	participant, err := q.CreateGatheringParticipant(req.Context(), database.CreateGatheringParticipantParams{
+		GatheringID:               gatheringID,
+		ParticipantType:           ballot.VoterType,
+		ParticipantName:           name,
+		ParticipantIdentification: sql.NullString{String: ballot.Identification, Valid: true},
+		OwnerID:                   sql.NullInt64{Int64: ballot.OwnerID, Valid: !isDelegate},
+		DelegatingOwnerID:         sql.NullInt64{Int64: ballot.OwnerID, Valid: isDelegate},
+		DelegationDocumentRef:     sql.NullString{String: ballot.DelegationDocumentRef, Valid: isDelegate},
+		UnitsInfo:                 string(unitsJSON),
+		UnitsArea:                 ballot.UnitsArea,
+		UnitsPart:                 ballot.UnitsPart,
+	})
+	if err != nil {
+		return database.GatheringParticipant{}, recordedBallot{}, fmt.Errorf("failed to create participant: %w", err)
+	}
+
+	for i, unitID := range ballot.UnitIDs {
+		_, err := q.AssignUnitSlot(req.Context(), database.AssignUnitSlotParams{
+			ParticipantID: participant.ID,
+			GatheringID:   gatheringID,
+			UnitID:        unitID,
+			OwnerID:       ballot.OwnerID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.GatheringParticipant{}, recordedBallot{}, fmt.Errorf("unit %s: %w", ballot.UnitNumbers[i], errSlotTaken)
+		}
+		if err != nil {
+			return database.GatheringParticipant{}, recordedBallot{}, fmt.Errorf("failed to assign unit slot: %w", err)
+		}
+	}
+
+	recorded, err := recordBallot(req, cfg, q, associationID, participant, ballot.Content)
+	if err != nil {
+		return database.GatheringParticipant{}, recordedBallot{}, fmt.Errorf("failed to store ballot: %w", err)
+	}
+	return participant, recorded, nil
+}
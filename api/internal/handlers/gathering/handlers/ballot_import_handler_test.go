@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexmarian/apc/api/internal/handlers"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
+)
+
+// TestImportAuditsBallots tests that every imported ballot is audited as submitted
+func TestImportAuditsBallots(t *testing.T) {
+	cfg := newTestConfig(t)
+	mustExec(t, cfg.Conn,
+		`INSERT INTO associations (id, name, address, administrator) VALUES (1, 'Association', 'Street 1', 'Admin')`,
+		`INSERT INTO buildings (id, name, address, cadastral_number, total_area, association_id) VALUES (1, 'Block A', 'Street 1', 'B-1', 100, 1)`,
+		`INSERT INTO units (id, cadastral_number, building_id, unit_number, address, area, part, floor) VALUES
+			(1, 'U-1', 1, '1', 'Street 1', 50, 0.5, 1), (2, 'U-2', 1, '2', 'Street 1', 50, 0.5, 1)`,
+		`INSERT INTO owners (id, name, normalized_name, identification_number, association_id) VALUES
+			(1, 'First Owner', 'first owner', '1000000000001', 1), (2, 'Second Owner', 'second owner', '1000000000002', 1)`,
+		`INSERT INTO ownerships (unit_id, owner_id, association_id, registration_document, registration_date, is_voting) VALUES
+			(1, 1, 1, 'Deed', '2020-01-01', TRUE), (2, 2, 1, 'Deed', '2020-01-01', TRUE)`,
+		`INSERT INTO gatherings (id, association_id, title, description, intent, gathering_date, gathering_type)
+			VALUES (1, 1, 'Annual meeting', '', '', '2026-05-12 18:00:00', 'initial')`,
+		`INSERT INTO voting_matters (id, gathering_id, order_index, title, matter_type, voting_config)
+			VALUES (1, 1, 1, 'Budget', 'budget', '{"type":"yes_no"}')`,
+	)
+	lifecycle := services.NewLifecycleService(cfg.Db, cfg.Conn, nil, nil)
+	for _, status := range []string{services.GatheringStatusPublished, services.GatheringStatusActive} {
+		gathering, err := cfg.Db.GetGatheringByID(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("failed to get gathering: %v", err)
+		}
+		if _, err := lifecycle.Transition(context.Background(), gathering, status, services.TransitionActor{Trigger: "manual"}); err != nil {
+			t.Fatalf("Transition(%s) error = %v", status, err)
+		}
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, _ := form.CreateFormFile(ballotImportFileField, "ballots.csv")
+	file.Write([]byte("owner_identification,matter_1\n1000000000001,yes\n1000000000002,no\n"))
+	form.Close()
+
+	req := handlers.AddUserIdToContext(httptest.NewRequest(http.MethodPost, "/", &body), "clerk")
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.SetPathValue(handlers.AssociationIdPathValue, "1")
+	req.SetPathValue(domain.GatheringIDPathValue, "1")
+	rw := httptest.NewRecorder()
+	NewBallotImportHandler(cfg, services.NewLiveResults(cfg.Db, services.NewStatsService(cfg.Db))).HandleImport()(rw, req)
+	if rw.Code != http.StatusCreated {
+		t.Fatalf("HandleImport() status %d, body %s", rw.Code, rw.Body.String())
+	}
+
+	var audited, unsealed int
+	cfg.Conn.QueryRow(`SELECT COUNT(*) FROM voting_audit_log a JOIN voting_ballots b ON b.id = a.entity_id
+		WHERE a.gathering_id = 1 AND a.entity_type = 'ballot' AND a.action = 'submitted'`).Scan(&audited)
+	cfg.Conn.QueryRow(`SELECT COUNT(*) FROM voting_audit_log WHERE gathering_id = 1 AND chain_hash IS NULL`).Scan(&unsealed)
+	if audited != 2 || unsealed != 0 {
+		t.Errorf("%d ballots audited as submitted and %d entries unsealed, want 2 and 0", audited, unsealed)
+	}
+}
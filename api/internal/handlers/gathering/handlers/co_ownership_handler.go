@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"go.uber.org/zap"
+)
+
+// CoOwnershipHandler handles how units held by several owners vote in a gathering
+type CoOwnershipHandler struct {
+	cfg                *handlers.ApiConfig
+	coOwnershipService *services.CoOwnershipService
+}
+
+// NewCoOwnershipHandler creates a new CoOwnershipHandler
+func NewCoOwnershipHandler(cfg *handlers.ApiConfig) *CoOwnershipHandler {
+	return &CoOwnershipHandler{
+		cfg:                cfg,
+		coOwnershipService: services.NewCoOwnershipService(cfg.Db),
+	}
+}
+
+// HandleGetCoOwnership returns the co-ownership policy of a gathering
+func (h *CoOwnershipHandler) HandleGetCoOwnership() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			return
+		}
+
+		handlers.RespondWithJSON(rw, http.StatusOK, h.coOwnershipService.Settings(gathering))
+	}
+}
+
+// HandleSetCoOwnership sets how co-owned units vote in a gathering: by their designated voting
+// owner, split by share, or by agreement of all co-owners. It can change while the gathering is a draft.
+func (h *CoOwnershipHandler) HandleSetCoOwnership() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		var coOwnershipReq domain.CoOwnershipRequest
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&coOwnershipReq); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			return
+		}
+
+		settings, err := h.coOwnershipService.SetPolicy(req.Context(), gathering, coOwnershipReq.Policy)
+		switch {
+		case errors.Is(err, services.ErrInvalidCoOwnershipPolicy), errors.Is(err, services.ErrCoOwnershipByUnit):
+			handlers.RespondWithError(rw, http.StatusBadRequest, err.Error())
+			return
+		case errors.Is(err, services.ErrCoOwnershipPolicyFixed):
+			handlers.RespondWithError(rw, http.StatusConflict, "The co-ownership policy can only change while the gathering is a draft")
+			return
+		case err != nil:
+			logging.Logger.Log(zap.WarnLevel, "Error setting co-ownership policy",
+				zap.Int("gathering_id", gatheringID),
+				zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to set co-ownership policy")
+			return
+		}
+		handlers.RespondWithJSON(rw, http.StatusOK, settings)
+	}
+}
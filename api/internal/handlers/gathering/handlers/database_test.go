@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers"
+	"github.com/alexmarian/apc/api/internal/logging"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pressly/goose/v3"
+	"go.uber.org/zap"
+)
+
+// newTestConfig opens a migrated database of its own for a test
+func newTestConfig(t *testing.T) *handlers.ApiConfig {
+	t.Helper()
+	if logging.Logger == nil {
+		logging.Logger = zap.NewNop()
+	}
+
+	// Transactions take the write lock when they begin, so they wait for the tallies handlers run
+	// in the background instead of failing to upgrade their read lock
+	conn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "apc.db")+"?_busy_timeout=5000&_txlock=immediate")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	goose.SetLogger(goose.NopLogger())
+	if err := database.RunMigrations(conn, os.DirFS("../../../../sql/schema")); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	return &handlers.ApiConfig{Db: database.New(conn), Conn: conn, SigningKey: "test signing key"}
+}
+
+// mustExec runs fixture statements, failing the test on the first error
+func mustExec(t *testing.T, conn *sql.DB, statements ...string) {
+	t.Helper()
+	for _, statement := range statements {
+		if _, err := conn.Exec(statement); err != nil {
+			t.Fatalf("failed to run %q: %v", statement, err)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"go.uber.org/zap"
+)
+
+// maxDelegationDocumentSize bounds the size of an uploaded power of attorney
+const maxDelegationDocumentSize = 10 << 20
+
+// delegationDocumentFileField is the multipart form field holding the power of attorney
+const delegationDocumentFileField = "file"
+
+// DelegationHandler handles the registry of powers of attorney of a gathering
+type DelegationHandler struct {
+	cfg               *handlers.ApiConfig
+	delegationService *services.DelegationService
+}
+
+// NewDelegationHandler creates a new DelegationHandler
+func NewDelegationHandler(cfg *handlers.ApiConfig, delegationService *services.DelegationService) *DelegationHandler {
+	return &DelegationHandler{
+		cfg:               cfg,
+		delegationService: delegationService,
+	}
+}
+
+// HandleGetDelegations lists the delegations registered for a gathering
+func (h *DelegationHandler) HandleGetDelegations() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		gathering, ok := h.gathering(rw, req)
+		if !ok {
+			return
+		}
+
+		delegations, err := h.delegationService.List(req.Context(), gathering, time.Now())
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error getting delegations", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get delegations")
+			return
+		}
+		handlers.RespondWithJSON(rw, http.StatusOK, delegations)
+	}
+}
+
+// HandleGetDelegation returns one delegation of a gathering
+func (h *DelegationHandler) HandleGetDelegation() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		gathering, ok := h.gathering(rw, req)
+		if !ok {
+			return
+		}
+		delegationID, _ := strconv.Atoi(req.PathValue(domain.DelegationIDPathValue))
+
+		delegation, err := h.delegationService.Get(req.Context(), gathering, int64(delegationID), time.Now())
+		if err != nil {
+			respondWithDelegationError(rw, err, "Failed to get delegation")
+			return
+		}
+		handlers.RespondWithJSON(rw, http.StatusOK, delegation)
+	}
+}
+
+// HandleCreateDelegation registers a power of attorney for a gathering. The scanned document is
+// uploaded separately, and the delegate can take part only once it is.
+func (h *DelegationHandler) HandleCreateDelegation() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		var delegationReq domain.DelegationRequest
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&delegationReq); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+
+		gathering, ok := h.gathering(rw, req)
+		if !ok {
+			return
+		}
+
+		delegation, err := h.delegationService.Create(req.Context(), gathering, delegationReq, time.Now())
+		if err != nil {
+			respondWithDelegationError(rw, err, "Failed to create delegation")
+			return
+		}
+		handlers.RespondWithJSON(rw, http.StatusCreated, delegation)
+	}
+}
+
+// HandleRevokeDelegation records an owner revoking a delegation, or all of its owners when the
+// request names none
+func (h *DelegationHandler) HandleRevokeDelegation() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		var revocationReq domain.DelegationRevocationRequest
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&revocationReq); err != nil && !errors.Is(err, io.EOF) {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+
+		gathering, ok := h.gathering(rw, req)
+		if !ok {
+			return
+		}
+		delegationID, _ := strconv.Atoi(req.PathValue(domain.DelegationIDPathValue))
+
+		delegation, err := h.delegationService.Revoke(req.Context(), gathering, int64(delegationID), revocationReq.OwnerID, revocationReq.Note, time.Now())
+		if err != nil {
+			respondWithDelegationError(rw, err, "Failed to revoke delegation")
+			return
+		}
+		handlers.RespondWithJSON(rw, http.StatusOK, delegation)
+	}
+}
+
+// HandleUploadDelegationDocument stores the scanned power of attorney, a PDF, JPEG or PNG file
+// sent in the "file" field of a multipart form, replacing any earlier scan
+func (h *DelegationHandler) HandleUploadDelegationDocument() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		gathering, ok := h.gathering(rw, req)
+		if !ok {
+			return
+		}
+		delegationID, _ := strconv.Atoi(req.PathValue(domain.DelegationIDPathValue))
+
+		req.Body = http.MaxBytesReader(rw, req.Body, maxDelegationDocumentSize)
+		file, header, err := req.FormFile(delegationDocumentFileField)
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, fmt.Sprintf("A PDF, JPEG or PNG file of at most %d MB is required in the %q field", maxDelegationDocumentSize>>20, delegationDocumentFileField))
+			return
+		}
+		defer file.Close()
+
+		content, err := io.ReadAll(file)
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Failed to read the uploaded file")
+			return
+		}
+
+		name := strings.ReplaceAll(filepath.Base(header.Filename), `"`, "")
+		if name == "" || name == "." {
+			name = fmt.Sprintf("delegation-%d", delegationID)
+		}
+
+		delegation, err := h.delegationService.SaveDocument(req.Context(), gathering, int64(delegationID), name, content, time.Now())
+		if err != nil {
+			respondWithDelegationError(rw, err, "Failed to save delegation document")
+			return
+		}
+		handlers.RespondWithJSON(rw, http.StatusOK, delegation)
+	}
+}
+
+// HandleDownloadDelegationDocument downloads the scanned power of attorney of a delegation
+func (h *DelegationHandler) HandleDownloadDelegationDocument() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		gathering, ok := h.gathering(rw, req)
+		if !ok {
+			return
+		}
+		delegationID, _ := strconv.Atoi(req.PathValue(domain.DelegationIDPathValue))
+
+		document, err := h.delegationService.Document(req.Context(), gathering, int64(delegationID))
+		if errors.Is(err, services.ErrDelegationDocumentMissing) {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Delegation document not found")
+			return
+		}
+		if err != nil {
+			respondWithDelegationError(rw, err, "Failed to get delegation document")
+			return
+		}
+		respondWithFile(rw, document.FileName, document.ContentType, document.Content)
+	}
+}
+
+// gathering loads the gathering of the request, answering 404 when the association has no such gathering
+func (h *DelegationHandler) gathering(rw http.ResponseWriter, req *http.Request) (database.Gathering, bool) {
+	associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+	gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+	gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+		ID:            int64(gatheringID),
+		AssociationID: int64(associationID),
+	})
+	if err != nil {
+		handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+		return database.Gathering{}, false
+	}
+	return gathering, true
+}
+
+// respondWithDelegationError answers with the status a delegation registry error calls for,
+// logging unexpected ones
+func respondWithDelegationError(rw http.ResponseWriter, err error, failure string) {
+	switch {
+	case errors.Is(err, services.ErrDelegationNotFound):
+		handlers.RespondWithError(rw, http.StatusNotFound, "Delegation not found")
+	case errors.Is(err, services.ErrInvalidDelegation),
+		errors.Is(err, services.ErrInvalidDelegationDocument),
+		errors.Is(err, services.ErrDelegationNotValid),
+		errors.Is(err, services.ErrDelegationDocumentMissing):
+		handlers.RespondWithError(rw, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrDelegationConflict),
+		errors.Is(err, services.ErrProxyLimit),
+		errors.Is(err, services.ErrOwnerVotedDirectly),
+		errors.Is(err, services.ErrOwnerDelegated),
+		errors.Is(err, services.ErrDelegationUsed),
+		errors.Is(err, services.ErrDelegationsClosed):
+		handlers.RespondWithError(rw, http.StatusConflict, err.Error())
+	default:
+		logging.Logger.Log(zap.WarnLevel, failure, zap.Error(err))
+		handlers.RespondWithError(rw, http.StatusInternalServerError, failure)
+	}
+}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/alexmarian/apc/api/internal/database"
@@ -12,20 +15,37 @@ import (
 	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
 	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
 	"github.com/alexmarian/apc/api/internal/logging"
+	"github.com/alexmarian/apc/api/office"
 	"go.uber.org/zap"
 )
 
-// ExportHandler handles export operations (markdown reports, etc.)
+// defaultDocumentLanguage is used for printed documents when no language is requested
+const defaultDocumentLanguage = "ro"
+
+// ExportHandler handles export operations (markdown reports, printed PDF documents, minutes, etc.)
 type ExportHandler struct {
-	cfg           *handlers.ApiConfig
-	quorumService *services.QuorumService
+	cfg             *handlers.ApiConfig
+	quorumService   *services.QuorumService
+	i18nService     *services.I18nService
+	documentService *services.DocumentService
+	minutesService  *services.MinutesService
 }
 
 // NewExportHandler creates a new ExportHandler
 func NewExportHandler(cfg *handlers.ApiConfig) *ExportHandler {
+	quorumService := services.NewQuorumService(cfg.Db)
+	i18nService, err := services.NewI18nService()
+	if err != nil {
+		logging.Logger.Log(zap.ErrorLevel, "Error loading translations", zap.Error(err))
+	}
+	votingResultsService := services.NewVotingResultsService(cfg.Db, quorumService, services.NewTallyService(cfg.Db))
+	documentService := services.NewDocumentService(cfg.Db, i18nService, votingResultsService)
 	return &ExportHandler{
-		cfg:           cfg,
-		quorumService: services.NewQuorumService(cfg.Db),
+		cfg:             cfg,
+		quorumService:   quorumService,
+		i18nService:     i18nService,
+		documentService: documentService,
+		minutesService:  services.NewMinutesService(cfg.Db, i18nService, documentService),
 	}
 }
 
@@ -64,6 +84,14 @@ func (h *ExportHandler) HandleDownloadVotingResults() func(http.ResponseWriter,
 		// Get participants
 		participants, _ := h.cfg.Db.GetGatheringParticipants(req.Context(), int64(gatheringID))
 
+		// Stored tallies carry the ranking outcome and its round-by-round breakdown
+		storedTallies := make(map[int64]database.GetAllVoteTalliesRow)
+		if tallies, err := h.cfg.Db.GetAllVoteTallies(req.Context(), int64(gatheringID)); err == nil {
+			for _, t := range tallies {
+				storedTallies[t.VotingMatterID] = t
+			}
+		}
+
 		// Get voted units stats
 		votedStats, _ := h.cfg.Db.GetVotedUnitsStats(req.Context(), int64(gatheringID))
 		votedUnitsPart, _ := votedStats.VotedUnitsTotalPart.(float64)
@@ -73,6 +101,7 @@ func (h *ExportHandler) HandleDownloadVotingResults() func(http.ResponseWriter,
 		var md string
 		md += fmt.Sprintf("# Voting Results: %s\n\n", gathering.Title)
 		md += fmt.Sprintf("**Date:** %s\n\n", gathering.GatheringDate.Format("2006-01-02 15:04"))
+		md += h.repeatedFromMarkdown(req.Context(), gathering)
 		md += fmt.Sprintf("**Location:** %s\n\n", gathering.Location)
 		md += fmt.Sprintf("**Status:** %s\n\n", gathering.Status)
 		if gathering.Status == "closed" || gathering.Status == "tallied" {
@@ -106,6 +135,15 @@ func (h *ExportHandler) HandleDownloadVotingResults() func(http.ResponseWriter,
 		md += fmt.Sprintf("**Participation Rate:** %.2f%% (by weight)\n\n", participationRate)
 		md += fmt.Sprintf("**Voting Completion Rate:** %.2f%% (by weight)\n\n", votingRate)
 
+		// Who was present when each matter was voted, as participants came and went
+		attendance, err := h.quorumService.MattersAttendance(req.Context(), gathering, matters, time.Now())
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error getting attendance", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get attendance")
+			return
+		}
+		md += h.attendanceChangesMarkdown(req.Context(), gathering)
+
 		md += "## Voting Matters and Results\n\n"
 
 		// Process each voting matter
@@ -120,6 +158,8 @@ func (h *ExportHandler) HandleDownloadVotingResults() func(http.ResponseWriter,
 			md += fmt.Sprintf("**Type:** %s\n\n", matter.MatterType)
 			md += fmt.Sprintf("**Voting Method:** %s\n\n", votingConfig.Type)
 			md += fmt.Sprintf("**Required Majority:** %s\n\n", votingConfig.RequiredMajority)
+			matterAttendance := attendance[matter.ID]
+			md += attendanceMarkdown(matterAttendance)
 
 			// Calculate tally
 			tally := make(map[string]domain.TallyResult)
@@ -165,6 +205,11 @@ func (h *ExportHandler) HandleDownloadVotingResults() func(http.ResponseWriter,
 				// Find vote for this matter
 				matterIDStr := strconv.FormatInt(matter.ID, 10)
 				if vote, ok := ballotContent[matterIDStr]; ok {
+					// Anonymous choices are not on the ballot, only the participation is
+					if vote.Anonymous {
+						totalWeight += participantWeight
+						continue
+					}
 					for _, key := range vote.Values {
 						if _, exists := tally[key]; exists {
 							tally[key] = domain.TallyResult{
@@ -180,6 +225,24 @@ func (h *ExportHandler) HandleDownloadVotingResults() func(http.ResponseWriter,
 				}
 			}
 
+			// Ranking matters are counted by the configured ranking method, not option by option,
+			// and anonymous choices can only be read from the tally
+			var breakdown *domain.RankingBreakdown
+			if votingConfig.Type == "ranking" || votingConfig.IsAnonymous {
+				if stored, ok := storedTallies[matter.ID]; ok {
+					json.Unmarshal([]byte(stored.TallyData), &tally)
+					if stored.BreakdownData.Valid {
+						breakdown = &domain.RankingBreakdown{}
+						if err := json.Unmarshal([]byte(stored.BreakdownData.String), breakdown); err != nil {
+							breakdown = nil
+						}
+					}
+				}
+				if breakdown != nil {
+					totalWeight = breakdown.BallotWeight
+				}
+			}
+
 			// Display results
 			md += "**Results:**\n\n"
 			md += "| Option | Votes | % Votes | Weight | % Weight (of cast) | % Weight (of qualified) |\n"
@@ -207,7 +270,7 @@ func (h *ExportHandler) HandleDownloadVotingResults() func(http.ResponseWriter,
 				}
 
 				displayKey := key
-				if votingConfig.Type == "multiple_choice" || votingConfig.Type == "single_choice" {
+				if votingConfig.Type == "multiple_choice" || votingConfig.Type == "single_choice" || votingConfig.Type == "ranking" {
 					for _, opt := range votingConfig.Options {
 						if opt.ID == key {
 							displayKey = opt.Text
@@ -221,16 +284,23 @@ func (h *ExportHandler) HandleDownloadVotingResults() func(http.ResponseWriter,
 			}
 			md += "\n"
 
+			if breakdown != nil {
+				md += rankingBreakdownMarkdown(breakdown, votingConfig)
+			}
+
 			// Determine if passed
 			matterResult := domain.VoteMatterResult{
 				MatterID:       matter.ID,
 				MatterTitle:    matter.Title,
 				MatterType:     matter.MatterType,
 				VotingConfig:   votingConfig,
+				Breakdown:      breakdown,
 				Tally:          tally,
 				TotalVoted:     totalWeight,
 				TotalAbstained: tally["abstain"].Weight,
 			}
+			quorum := h.quorumService.CalculatePresentQuorum(domain.DBGatheringToResponse(gathering), matterAttendance.Attendance)
+			matterResult.QuorumInfo = &quorum
 			passed := h.quorumService.CalculateIfPassed(matterResult, votingConfig, gathering)
 
 			if matter.IsInformative != 0 {
@@ -244,6 +314,11 @@ func (h *ExportHandler) HandleDownloadVotingResults() func(http.ResponseWriter,
 			md += "---\n\n"
 		}
 
+		// The Merkle root published at close lets anyone check the ballot set was not altered since
+		if stored, err := h.cfg.Db.GetVotingResults(req.Context(), int64(gatheringID)); err == nil && stored.BallotMerkleRoot.Valid {
+			md += fmt.Sprintf("**Ballot Merkle root:** `%s`\n\n", stored.BallotMerkleRoot.String)
+		}
+
 		md += fmt.Sprintf("*Report generated at: %s*\n", time.Now().Format("2006-01-02 15:04:05"))
 
 		// Set headers for file download
@@ -299,6 +374,7 @@ func (h *ExportHandler) HandleDownloadVotingBallots() func(http.ResponseWriter,
 		var md string
 		md += fmt.Sprintf("# Voting Ballots: %s\n\n", gathering.Title)
 		md += fmt.Sprintf("**Date:** %s\n\n", gathering.GatheringDate.Format("2006-01-02 15:04"))
+		md += h.repeatedFromMarkdown(req.Context(), gathering)
 		md += fmt.Sprintf("**Total Ballots:** %d\n\n", len(ballots))
 
 		md += "---\n\n"
@@ -335,6 +411,11 @@ func (h *ExportHandler) HandleDownloadVotingBallots() func(http.ResponseWriter,
 
 					md += fmt.Sprintf("- **%s:** ", matter.Title)
 
+					if vote.Anonymous {
+						md += "*voted (anonymous matter)*\n"
+						continue
+					}
+
 					var config domain.VotingConfig
 					json.Unmarshal([]byte(matter.VotingConfig), &config)
 					optText := make(map[string]string, len(config.Options))
@@ -371,3 +452,381 @@ func (h *ExportHandler) HandleDownloadVotingBallots() func(http.ResponseWriter,
 		rw.Write([]byte(md))
 	}
 }
+
+// HandleDownloadPaperBallots generates the paper ballots of a gathering as a PDF, one per voting
+// owner pre-filled with the owner's units and weight, or only the ballot of the owner_id given.
+// The lang query parameter picks the language, Romanian by default.
+func (h *ExportHandler) HandleDownloadPaperBallots() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		gathering, lang, ok := h.documentRequest(rw, req)
+		if !ok {
+			return
+		}
+
+		var ownerID int64
+		if value := req.URL.Query().Get("owner_id"); value != "" {
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || id <= 0 {
+				handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid owner ID")
+				return
+			}
+			ownerID = id
+		}
+
+		document, err := h.documentService.RenderBallots(req.Context(), gathering, lang, ownerID)
+		if errors.Is(err, services.ErrOwnerNotOnRegister) {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Owner does not vote in this gathering")
+			return
+		}
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error rendering paper ballots",
+				zap.Int64("gathering_id", gathering.ID),
+				zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to generate ballots")
+			return
+		}
+
+		filename := fmt.Sprintf("ballots-%d-%s.pdf", gathering.ID, lang)
+		if ownerID != 0 {
+			filename = fmt.Sprintf("ballot-%d-owner-%d-%s.pdf", gathering.ID, ownerID, lang)
+		}
+		respondWithPDF(rw, filename, document)
+	}
+}
+
+// HandleDownloadAttendanceSheet generates the attendance sheet of a gathering as a PDF, listing
+// every voting owner with a line to sign on
+func (h *ExportHandler) HandleDownloadAttendanceSheet() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		gathering, lang, ok := h.documentRequest(rw, req)
+		if !ok {
+			return
+		}
+
+		document, err := h.documentService.RenderAttendanceSheet(req.Context(), gathering, lang)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error rendering attendance sheet",
+				zap.Int64("gathering_id", gathering.ID),
+				zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to generate attendance sheet")
+			return
+		}
+		respondWithPDF(rw, fmt.Sprintf("attendance-%d-%s.pdf", gathering.ID, lang), document)
+	}
+}
+
+// HandleDownloadResultsReport generates the results report of a gathering as a PDF, with the
+// participation, the quorum and the outcome of every matter
+func (h *ExportHandler) HandleDownloadResultsReport() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		gathering, lang, ok := h.documentRequest(rw, req)
+		if !ok {
+			return
+		}
+
+		document, err := h.documentService.RenderResultsReport(req.Context(), gathering, lang)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error rendering results report",
+				zap.Int64("gathering_id", gathering.ID),
+				zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to generate results report")
+			return
+		}
+		respondWithPDF(rw, fmt.Sprintf("results-%d-%s.pdf", gathering.ID, lang), document)
+	}
+}
+
+// HandleDownloadMinutes generates the minutes of a tallied gathering from the association's
+// minutes template, or from the default template in the format given by ?format=docx|odt
+func (h *ExportHandler) HandleDownloadMinutes() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		gathering, lang, ok := h.documentRequest(rw, req)
+		if !ok {
+			return
+		}
+		format, ok := minutesFormat(rw, req)
+		if !ok {
+			return
+		}
+		if gathering.Status != services.GatheringStatusTallied {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Minutes can only be generated for tallied gatherings")
+			return
+		}
+
+		document, format, err := h.minutesService.Render(req.Context(), gathering, format, lang)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error rendering minutes",
+				zap.Int64("gathering_id", gathering.ID),
+				zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to generate minutes")
+			return
+		}
+		respondWithFile(rw, fmt.Sprintf("minutes-%d-%s.%s", gathering.ID, lang, format), format.ContentType(), document)
+	}
+}
+
+// documentRequest reads the gathering and the language of a document request, responding with
+// the problem when either is invalid
+func (h *ExportHandler) documentRequest(rw http.ResponseWriter, req *http.Request) (database.Gathering, string, bool) {
+	associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+	gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+	lang := req.URL.Query().Get("lang")
+	if lang == "" {
+		lang = defaultDocumentLanguage
+	}
+	if !h.i18nService.Supports(lang) {
+		handlers.RespondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Unsupported language, expected one of %s", strings.Join(services.SupportedLanguages, ", ")))
+		return database.Gathering{}, "", false
+	}
+
+	gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+		ID:            int64(gatheringID),
+		AssociationID: int64(associationID),
+	})
+	if err != nil {
+		handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+		return database.Gathering{}, "", false
+	}
+	return gathering, lang, true
+}
+
+// minutesFormat reads the format of a minutes document, DOCX by default, responding with the
+// problem when it is invalid
+func minutesFormat(rw http.ResponseWriter, req *http.Request) (office.Format, bool) {
+	value := req.URL.Query().Get("format")
+	if value == "" {
+		return office.DOCX, true
+	}
+	format, ok := office.ParseFormat(value)
+	if !ok {
+		handlers.RespondWithError(rw, http.StatusBadRequest, "Unsupported format, expected docx or odt")
+	}
+	return format, ok
+}
+
+// respondWithPDF sends a PDF document as a file download
+func respondWithPDF(rw http.ResponseWriter, filename string, document []byte) {
+	respondWithFile(rw, filename, "application/pdf", document)
+}
+
+// respondWithFile sends a document as a file download
+func respondWithFile(rw http.ResponseWriter, filename, contentType string, document []byte) {
+	rw.Header().Set("Content-Type", contentType)
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	rw.Header().Set("Content-Length", strconv.Itoa(len(document)))
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(document)
+}
+
+// repeatedFromMarkdown references the gathering a repeated gathering was convened for, if any
+func (h *ExportHandler) repeatedFromMarkdown(ctx context.Context, gathering database.Gathering) string {
+	if !gathering.RepeatedFromID.Valid {
+		return ""
+	}
+	original, err := h.cfg.Db.GetGathering(ctx, database.GetGatheringParams{
+		ID:            gathering.RepeatedFromID.Int64,
+		AssociationID: gathering.AssociationID,
+	})
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error getting repeated gathering",
+			zap.Int64("gathering_id", gathering.RepeatedFromID.Int64),
+			zap.Error(err))
+		return fmt.Sprintf("**Repeats gathering:** #%d\n\n", gathering.RepeatedFromID.Int64)
+	}
+
+	md := fmt.Sprintf("**Repeats gathering:** #%d %s of %s",
+		original.ID, original.Title, original.GatheringDate.Format("2006-01-02"))
+	if stored, err := h.cfg.Db.GetVotingResults(ctx, original.ID); err == nil {
+		md += fmt.Sprintf(", which did not reach the required quorum of %.2f%%", stored.QuorumThresholdPercentage)
+	}
+	return md + "\n\n"
+}
+
+// attendanceMarkdown describes who was present when a matter was voted
+func attendanceMarkdown(attendance domain.MatterAttendance) string {
+	window := fmt.Sprintf("until %s", attendance.To.Format("15:04"))
+	if attendance.From != nil {
+		window = fmt.Sprintf("%s–%s", attendance.From.Format("15:04"), attendance.To.Format("15:04"))
+	}
+	return fmt.Sprintf("**Present When Voted (%s):** %d participants, %d units, weight %.4f\n\n",
+		window, attendance.Participants, attendance.Units, attendance.Weight)
+}
+
+// attendanceChangesMarkdown lists the participants arriving, leaving and coming back during the
+// meeting, with the attendance and quorum after each change
+func (h *ExportHandler) attendanceChangesMarkdown(ctx context.Context, gathering database.Gathering) string {
+	timeline, err := services.NewAttendanceService(h.cfg.Db, h.cfg.Conn).Timeline(ctx, gathering, time.Now())
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error getting attendance timeline",
+			zap.Int64("gathering_id", gathering.ID),
+			zap.Error(err))
+		return ""
+	}
+	if len(timeline.Events) == 0 {
+		return ""
+	}
+
+	md := "## Attendance Changes\n\n"
+	md += "| Time | Participant | Event | Present | Units | Weight | Quorum |\n"
+	md += "|------|-------------|-------|---------|-------|--------|--------|\n"
+	for _, event := range timeline.Events {
+		quorum := "not met"
+		if event.QuorumMet {
+			quorum = "met"
+		}
+		md += fmt.Sprintf("| %s | %s | %s | %d | %d | %.4f | %s |\n",
+			event.OccurredAt.Format("15:04:05"), event.ParticipantName, strings.ReplaceAll(event.EventType, "_", "-"),
+			event.Present.Participants, event.Present.Units, event.Present.Weight, quorum)
+	}
+	return md + "\n"
+}
+
+// rankingBreakdownMarkdown renders how the outcome of a ranking matter was reached
+func rankingBreakdownMarkdown(breakdown *domain.RankingBreakdown, config domain.VotingConfig) string {
+	label := func(optID string) string {
+		for _, opt := range config.Options {
+			if opt.ID == optID {
+				return opt.Text
+			}
+		}
+		return optID
+	}
+
+	var md string
+	switch breakdown.Method {
+	case services.RankingMethodIRV:
+		md += "**Ranking Method:** Instant-runoff\n\n"
+		md += "**Counting Rounds:**\n\n"
+		md += rankingRoundsMarkdown(breakdown.Rounds, config, label)
+	case services.RankingMethodSTV:
+		md += "**Ranking Method:** Single transferable vote\n\n"
+		md += fmt.Sprintf("**Seats:** %d\n\n", breakdown.Seats)
+		md += fmt.Sprintf("**Quota:** %.4f\n\n", breakdown.Quota)
+		md += "**Counting Rounds:**\n\n"
+		md += rankingRoundsMarkdown(breakdown.Rounds, config, label)
+		md += "**Transfers:**\n\n"
+		md += "| Round | From |"
+		for _, opt := range config.Options {
+			md += fmt.Sprintf(" %s |", opt.Text)
+		}
+		md += " Not transferable |\n"
+		md += "|-------|------|"
+		for range config.Options {
+			md += "------|"
+		}
+		md += "------------------|\n"
+		for _, round := range breakdown.Rounds {
+			if round.Transfers == nil {
+				continue
+			}
+			from := append(append([]string(nil), round.Elected...), round.Eliminated...)
+			md += fmt.Sprintf("| %d |", round.Round)
+			for _, optID := range from {
+				md += fmt.Sprintf(" %s", label(optID))
+			}
+			md += " |"
+			for _, opt := range config.Options {
+				if t, ok := round.Transfers[opt.ID]; ok {
+					md += fmt.Sprintf(" +%.4f (%d) |", t.Weight, t.Count)
+				} else {
+					md += " — |"
+				}
+			}
+			if round.TransferExhausted != nil {
+				md += fmt.Sprintf(" %.4f (%d) |\n", round.TransferExhausted.Weight, round.TransferExhausted.Count)
+			} else {
+				md += " — |\n"
+			}
+		}
+		md += "\n"
+		md += "**Elected:**\n\n"
+		for i, optID := range breakdown.Elected {
+			md += fmt.Sprintf("%d. %s\n", i+1, label(optID))
+		}
+		md += "\n"
+		return md
+	case services.RankingMethodSchulze:
+		md += "**Ranking Method:** Schulze\n\n"
+		md += "**Pairwise Preferences** (weight preferring the row option over the column option):\n\n"
+		md += schulzeMatrixMarkdown(breakdown.Pairwise, config)
+		md += "**Strongest Paths:**\n\n"
+		md += schulzeMatrixMarkdown(breakdown.StrongestPaths, config)
+	default:
+		md += "**Ranking Method:** Borda count\n\n"
+	}
+
+	md += "**Final Order:**\n\n"
+	for i, optID := range breakdown.Order {
+		md += fmt.Sprintf("%d. %s\n", i+1, label(optID))
+	}
+	md += "\n"
+	if breakdown.Winner != "" {
+		md += fmt.Sprintf("**Winner:** %s\n\n", label(breakdown.Winner))
+	} else {
+		md += "**Winner:** none (unresolved tie)\n\n"
+	}
+	return md
+}
+
+// rankingRoundsMarkdown renders the standing of each counting round as a markdown table
+func rankingRoundsMarkdown(rounds []domain.RankingRound, config domain.VotingConfig, label func(string) string) string {
+	md := "| Round |"
+	for _, opt := range config.Options {
+		md += fmt.Sprintf(" %s |", opt.Text)
+	}
+	md += " Exhausted | Outcome |\n"
+	md += "|-------|"
+	for range config.Options {
+		md += "------|"
+	}
+	md += "-----------|---------|\n"
+	for _, round := range rounds {
+		md += fmt.Sprintf("| %d |", round.Round)
+		for _, opt := range config.Options {
+			if t, ok := round.Tally[opt.ID]; ok {
+				md += fmt.Sprintf(" %.4f (%d) |", t.Weight, t.Count)
+			} else {
+				md += " — |"
+			}
+		}
+		md += fmt.Sprintf(" %.4f (%d) |", round.Exhausted.Weight, round.Exhausted.Count)
+		var outcome string
+		for _, optID := range round.Elected {
+			outcome += fmt.Sprintf("%s elected ", label(optID))
+		}
+		for _, optID := range round.Eliminated {
+			outcome += fmt.Sprintf("%s eliminated ", label(optID))
+		}
+		if round.TieBroken {
+			outcome += "(tie-break)"
+		}
+		md += fmt.Sprintf(" %s |\n", outcome)
+	}
+	return md + "\n"
+}
+
+// schulzeMatrixMarkdown renders an option-by-option matrix as a markdown table
+func schulzeMatrixMarkdown(matrix map[string]map[string]float64, config domain.VotingConfig) string {
+	md := "| |"
+	for _, opt := range config.Options {
+		md += fmt.Sprintf(" %s |", opt.Text)
+	}
+	md += "\n|---|"
+	for range config.Options {
+		md += "---|"
+	}
+	md += "\n"
+	for _, row := range config.Options {
+		md += fmt.Sprintf("| **%s** |", row.Text)
+		for _, col := range config.Options {
+			if row.ID == col.ID {
+				md += " — |"
+			} else {
+				md += fmt.Sprintf(" %.4f |", matrix[row.ID][col.ID])
+			}
+		}
+		md += "\n"
+	}
+	return md + "\n"
+}
@@ -3,14 +3,20 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/alexmarian/apc/api/internal/database"
 	"github.com/alexmarian/apc/api/internal/handlers"
 	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/qualification"
 	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
 	"github.com/alexmarian/apc/api/internal/logging"
+	"github.com/mattn/go-sqlite3"
 	"go.uber.org/zap"
 )
 
@@ -22,20 +28,20 @@ type GatheringHandler struct {
 	quorumService        *services.QuorumService
 	tallyService         *services.TallyService
 	votingResultsService *services.VotingResultsService
+	lifecycleService     *services.LifecycleService
 }
 
-// NewGatheringHandler creates a new GatheringHandler
-func NewGatheringHandler(cfg *handlers.ApiConfig) *GatheringHandler {
-	quorumService := services.NewQuorumService(cfg.Db)
-	tallyService := services.NewTallyService(cfg.Db)
-
+// NewGatheringHandler creates a new GatheringHandler. The lifecycle service is shared with the
+// scheduler opening and closing gatherings.
+func NewGatheringHandler(cfg *handlers.ApiConfig, votingResultsService *services.VotingResultsService, lifecycleService *services.LifecycleService) *GatheringHandler {
 	return &GatheringHandler{
 		cfg:                  cfg,
 		statsService:         services.NewStatsService(cfg.Db),
 		unitSlotService:      services.NewUnitSlotService(cfg.Db),
-		quorumService:        quorumService,
-		tallyService:         tallyService,
-		votingResultsService: services.NewVotingResultsService(cfg.Db, quorumService, tallyService),
+		quorumService:        services.NewQuorumService(cfg.Db),
+		tallyService:         services.NewTallyService(cfg.Db),
+		votingResultsService: votingResultsService,
+		lifecycleService:     lifecycleService,
 	}
 }
 
@@ -96,76 +102,470 @@ func (h *GatheringHandler) HandleCreateGathering() func(http.ResponseWriter, *ht
 			return
 		}
 
-		// Validate required fields
-		if createReq.Title == "" || createReq.GatheringType == "" {
-			handlers.RespondWithError(rw, http.StatusBadRequest, "Title and gathering type are required")
+		response, ok := h.createGathering(rw, req, int64(associationID), createReq, nil, nil)
+		if !ok {
 			return
 		}
+		handlers.RespondWithJSON(rw, http.StatusCreated, response)
+	}
+}
 
-		if createReq.Location == "" {
-			handlers.RespondWithError(rw, http.StatusBadRequest, "Location is required")
+// HandleUpdateGathering edits the setup of a draft gathering, including its scheduled opening and
+// closing, and qualifies its units again
+func (h *GatheringHandler) HandleUpdateGathering() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		var updateReq domain.UpdateGatheringRequest
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&updateReq); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
 			return
 		}
 
-		// Validate and default voting_mode
-		votingMode := createReq.VotingMode
-		if votingMode == "" {
-			votingMode = "by_weight" // Default for backward compatibility
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			} else {
+				logging.Logger.Log(zap.WarnLevel, "Error getting gathering", zap.Error(err))
+				handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get gathering")
+			}
+			return
+		}
+		if gathering.Status != services.GatheringStatusDraft {
+			handlers.RespondWithError(rw, http.StatusConflict, "Only draft gatherings can be edited")
+			return
 		}
-		if votingMode != "by_weight" && votingMode != "by_unit" {
-			handlers.RespondWithError(rw, http.StatusBadRequest, "voting_mode must be 'by_weight' or 'by_unit'")
+
+		setup := domain.CreateGatheringRequest(updateReq)
+		votingMode, rule, ok := validateGatheringSetup(rw, &setup)
+		if !ok {
 			return
 		}
+		if votingMode == "by_unit" && services.VotesShares(gathering.CoOwnershipPolicy) {
+			handlers.RespondWithError(rw, http.StatusBadRequest, services.ErrCoOwnershipByUnit.Error())
+			return
+		}
+
+		unitTypesJSON, _ := json.Marshal(setup.QualificationUnitTypes)
+		floorsJSON, _ := json.Marshal(setup.QualificationFloors)
+		entrancesJSON, _ := json.Marshal(setup.QualificationEntrances)
+
+		gathering, err = h.cfg.Db.UpdateGathering(req.Context(), database.UpdateGatheringParams{
+			Title:                       setup.Title,
+			Description:                 setup.Description,
+			Intent:                      setup.Intent,
+			Location:                    setup.Location,
+			GatheringDate:               setup.GatheringDate,
+			GatheringType:               setup.GatheringType,
+			VotingMode:                  votingMode,
+			OpensAt:                     timePtrToNullTime(setup.OpensAt),
+			ClosesAt:                    timePtrToNullTime(setup.ClosesAt),
+			QualificationUnitTypes:      sql.NullString{String: string(unitTypesJSON), Valid: len(unitTypesJSON) > 2},
+			QualificationFloors:         sql.NullString{String: string(floorsJSON), Valid: len(floorsJSON) > 2},
+			QualificationEntrances:      sql.NullString{String: string(entrancesJSON), Valid: len(entrancesJSON) > 2},
+			QualificationCustomRule:     sql.NullString{String: setup.QualificationCustomRule, Valid: setup.QualificationCustomRule != ""},
+			QualifiedUnitsCount:         gathering.QualifiedUnitsCount,
+			QualifiedUnitsTotalPart:     gathering.QualifiedUnitsTotalPart,
+			QualifiedUnitsTotalArea:     gathering.QualifiedUnitsTotalArea,
+			ParticipatingUnitsCount:     gathering.ParticipatingUnitsCount,
+			ParticipatingUnitsTotalPart: gathering.ParticipatingUnitsTotalPart,
+			ParticipatingUnitsTotalArea: gathering.ParticipatingUnitsTotalArea,
+			ID:                          gathering.ID,
+			AssociationID:               gathering.AssociationID,
+		})
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error updating gathering", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to update gathering")
+			return
+		}
+
+		// The qualification criteria may have changed, so the units are qualified from scratch
+		if err := h.cfg.Db.RemoveUnitSlot(req.Context(), gathering.ID); err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error removing units slots", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to sync units slots")
+			return
+		}
+		err = h.unitSlotService.SyncUnitsSlots(req.Context(), gathering.AssociationID, gathering.ID, setup.QualificationUnitTypes, setup.QualificationFloors, setup.QualificationEntrances, rule)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error syncing units slots", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to sync units slots")
+			return
+		}
+		qualifiedCount, qualifiedPart, qualifiedArea := h.statsService.UpdateGatheringStats(gathering.ID, gathering.AssociationID)
+
+		services.NewIntegrityService(h.cfg.Db).RecordAudit(req.Context(), database.CreateAuditLogParams{
+			GatheringID: gathering.ID,
+			EntityType:  "gathering",
+			EntityID:    gathering.ID,
+			Action:      "updated",
+			PerformedBy: sql.NullString{String: handlers.GetUserIdFromContext(req), Valid: true},
+			IpAddress:   sql.NullString{String: req.RemoteAddr, Valid: true},
+		})
+
+		response := domain.DBGatheringToResponse(gathering)
+		response.QualifiedUnitsCount = qualifiedCount
+		response.QualifiedUnitsTotalPart = qualifiedPart
+		response.QualifiedUnitsTotalArea = qualifiedArea
+		handlers.RespondWithJSON(rw, http.StatusOK, response)
+	}
+}
+
+// HandleCloneGathering creates a draft gathering with the setup and agenda of an earlier gathering
+func (h *GatheringHandler) HandleCloneGathering() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		var copyReq domain.CopyGatheringRequest
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&copyReq); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+		if copyReq.GatheringDate.IsZero() {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Gathering date is required")
+			return
+		}
+
+		source, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			} else {
+				logging.Logger.Log(zap.WarnLevel, "Error getting gathering", zap.Error(err))
+				handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get gathering")
+			}
+			return
+		}
+		dbMatters, err := h.cfg.Db.GetVotingMatters(req.Context(), source.ID)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error getting voting matters", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get voting matters")
+			return
+		}
+
+		original := domain.DBGatheringToResponse(source)
+		createReq := copyReq.Apply(domain.CreateGatheringRequest{
+			Title:                   original.Title,
+			Description:             original.Description,
+			Intent:                  original.Intent,
+			Location:                original.Location,
+			GatheringType:           original.GatheringType,
+			VotingMode:              original.VotingMode,
+			QualificationUnitTypes:  original.QualificationUnitTypes,
+			QualificationFloors:     original.QualificationFloors,
+			QualificationEntrances:  original.QualificationEntrances,
+			QualificationCustomRule: original.QualificationCustomRule,
+		})
+		matters := make([]domain.VotingMatter, len(dbMatters))
+		for i, m := range dbMatters {
+			matters[i] = domain.DBVotingMatterToResponse(m)
+		}
+
+		response, ok := h.createGathering(rw, req, int64(associationID), createReq, matters, map[string]int64{"cloned_from": source.ID})
+		if !ok {
+			return
+		}
+		handlers.RespondWithJSON(rw, http.StatusCreated, response)
+	}
+}
+
+// createGathering validates and stores a new draft gathering with its agenda, then qualifies its units.
+// It writes the error response itself and reports whether the gathering was created.
+func (h *GatheringHandler) createGathering(rw http.ResponseWriter, req *http.Request, associationID int64, createReq domain.CreateGatheringRequest, matters []domain.VotingMatter, origin map[string]int64) (domain.Gathering, bool) {
+	votingMode, rule, ok := validateGatheringSetup(rw, &createReq)
+	if !ok {
+		return domain.Gathering{}, false
+	}
+
+	// Agenda copied from a template or an earlier gathering is checked as if entered by hand
+	for _, matter := range matters {
+		if err := services.ValidateVotingConfig(matter.MatterType, matter.VotingConfig); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Voting matter %q: %s", matter.Title, err))
+			return domain.Gathering{}, false
+		}
+	}
+
+	// Convert arrays to JSON strings for storage
+	unitTypesJSON, _ := json.Marshal(createReq.QualificationUnitTypes)
+	floorsJSON, _ := json.Marshal(createReq.QualificationFloors)
+	entrancesJSON, _ := json.Marshal(createReq.QualificationEntrances)
+
+	tx, err := h.cfg.Conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error starting transaction", zap.Error(err))
+		handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to create gathering")
+		return domain.Gathering{}, false
+	}
+	defer tx.Rollback()
+	qtx := h.cfg.Db.WithTx(tx)
+
+	gathering, err := qtx.CreateGathering(req.Context(), database.CreateGatheringParams{
+		AssociationID:           associationID,
+		Title:                   createReq.Title,
+		Description:             createReq.Description,
+		Intent:                  createReq.Intent,
+		Location:                createReq.Location,
+		GatheringDate:           createReq.GatheringDate,
+		GatheringType:           createReq.GatheringType,
+		VotingMode:              votingMode,
+		Status:                  "draft",
+		QualificationUnitTypes:  sql.NullString{String: string(unitTypesJSON), Valid: len(unitTypesJSON) > 2},
+		QualificationFloors:     sql.NullString{String: string(floorsJSON), Valid: len(floorsJSON) > 2},
+		QualificationEntrances:  sql.NullString{String: string(entrancesJSON), Valid: len(entrancesJSON) > 2},
+		QualificationCustomRule: sql.NullString{String: createReq.QualificationCustomRule, Valid: createReq.QualificationCustomRule != ""},
+		OpensAt:                 timePtrToNullTime(createReq.OpensAt),
+		ClosesAt:                timePtrToNullTime(createReq.ClosesAt),
+	})
+
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error creating gathering", zap.Error(err))
+		handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to create gathering")
+		return domain.Gathering{}, false
+	}
+
+	for _, matter := range matters {
+		params, err := votingMatterParams(gathering.ID, matter)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error encoding voting config", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to create voting matter")
+			return domain.Gathering{}, false
+		}
+		if _, err := qtx.CreateVotingMatter(req.Context(), params); err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error creating voting matter", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to create voting matter")
+			return domain.Gathering{}, false
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error committing transaction", zap.Error(err))
+		handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to create gathering")
+		return domain.Gathering{}, false
+	}
+
+	// Calculate qualified units
+	qualifiedCount, qualifiedPart, qualifiedArea := h.statsService.UpdateGatheringStats(gathering.ID, associationID)
+
+	// Log audit
+	details := "{}"
+	if origin != nil {
+		data, _ := json.Marshal(origin)
+		details = string(data)
+	}
+	services.NewIntegrityService(h.cfg.Db).RecordAudit(req.Context(), database.CreateAuditLogParams{
+		GatheringID: gathering.ID,
+		EntityType:  "gathering",
+		EntityID:    gathering.ID,
+		Action:      "created",
+		PerformedBy: sql.NullString{String: req.Context().Value("userID").(string), Valid: true},
+		IpAddress:   sql.NullString{String: req.RemoteAddr, Valid: true},
+		Details:     sql.NullString{String: details, Valid: true},
+	})
+
+	err = h.unitSlotService.SyncUnitsSlots(req.Context(), associationID, gathering.ID, createReq.QualificationUnitTypes, createReq.QualificationFloors, createReq.QualificationEntrances, rule)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error syncing units slots", zap.Error(err))
+		handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to sync units slots")
+		return domain.Gathering{}, false
+	}
 
-		// Convert arrays to JSON strings for storage
-		unitTypesJSON, _ := json.Marshal(createReq.QualificationUnitTypes)
-		floorsJSON, _ := json.Marshal(createReq.QualificationFloors)
-		entrancesJSON, _ := json.Marshal(createReq.QualificationEntrances)
+	response := domain.DBGatheringToResponse(gathering)
+	response.QualifiedUnitsCount = qualifiedCount
+	response.QualifiedUnitsTotalPart = qualifiedPart
+	response.QualifiedUnitsTotalArea = qualifiedArea
+	return response, true
+}
+
+// validateGatheringSetup checks the setup of a gathering being created or edited, defaulting its
+// voting mode, and parses its custom qualification rule. It writes the error response itself and
+// reports whether the setup is valid.
+func validateGatheringSetup(rw http.ResponseWriter, setup *domain.CreateGatheringRequest) (string, *qualification.Rule, bool) {
+	// Validate required fields
+	if setup.Title == "" || setup.GatheringType == "" {
+		handlers.RespondWithError(rw, http.StatusBadRequest, "Title and gathering type are required")
+		return "", nil, false
+	}
 
-		gathering, err := h.cfg.Db.CreateGathering(req.Context(), database.CreateGatheringParams{
-			AssociationID:           int64(associationID),
-			Title:                   createReq.Title,
-			Description:             createReq.Description,
-			Intent:                  createReq.Intent,
-			Location:                createReq.Location,
-			GatheringDate:           createReq.GatheringDate,
-			GatheringType:           createReq.GatheringType,
-			VotingMode:              votingMode,
-			Status:                  "draft",
-			QualificationUnitTypes:  sql.NullString{String: string(unitTypesJSON), Valid: len(unitTypesJSON) > 2},
-			QualificationFloors:     sql.NullString{String: string(floorsJSON), Valid: len(floorsJSON) > 2},
-			QualificationEntrances:  sql.NullString{String: string(entrancesJSON), Valid: len(entrancesJSON) > 2},
-			QualificationCustomRule: sql.NullString{String: createReq.QualificationCustomRule, Valid: createReq.QualificationCustomRule != ""},
+	if setup.Location == "" {
+		handlers.RespondWithError(rw, http.StatusBadRequest, "Location is required")
+		return "", nil, false
+	}
+
+	// Validate and default voting_mode
+	votingMode := setup.VotingMode
+	if votingMode == "" {
+		votingMode = "by_weight" // Default for backward compatibility
+	}
+	if votingMode != "by_weight" && votingMode != "by_unit" {
+		handlers.RespondWithError(rw, http.StatusBadRequest, "voting_mode must be 'by_weight' or 'by_unit'")
+		return "", nil, false
+	}
+
+	// Scheduled opening and closing only applies to remote gatherings
+	if setup.OpensAt != nil || setup.ClosesAt != nil {
+		if setup.GatheringType != "remote" {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "opens_at and closes_at are only supported for remote gatherings")
+			return "", nil, false
+		}
+		if setup.OpensAt != nil && setup.ClosesAt != nil && !setup.ClosesAt.After(*setup.OpensAt) {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "closes_at must be after opens_at")
+			return "", nil, false
+		}
+	}
+
+	// The custom rule decides which units qualify, so it must parse before anything is stored
+	setup.QualificationCustomRule = strings.TrimSpace(setup.QualificationCustomRule)
+	rule, err := qualification.Parse(setup.QualificationCustomRule)
+	if err != nil {
+		handlers.RespondWithError(rw, http.StatusBadRequest, err.Error())
+		return "", nil, false
+	}
+	return votingMode, rule, true
+}
+
+// HandleRepeatGathering creates a repeated gathering from a closed gathering that missed quorum,
+// with the same agenda and qualification criteria
+func (h *GatheringHandler) HandleRepeatGathering() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		var repeatReq domain.RepeatGatheringRequest
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&repeatReq); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+		if repeatReq.GatheringDate.IsZero() {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Gathering date is required")
+			return
+		}
+
+		source, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
 		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			} else {
+				logging.Logger.Log(zap.WarnLevel, "Error getting gathering", zap.Error(err))
+				handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get gathering")
+			}
+			return
+		}
 
+		var storedResults *database.VotingResult
+		results, err := h.cfg.Db.GetVotingResults(req.Context(), source.ID)
+		if err == nil {
+			storedResults = &results
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			logging.Logger.Log(zap.WarnLevel, "Error getting voting results", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get voting results")
+			return
+		}
+		if err := services.CheckRepeatable(source, storedResults); err != nil {
+			handlers.RespondWithError(rw, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+
+		title := repeatReq.Title
+		if title == "" {
+			title = source.Title
+		}
+		location := repeatReq.Location
+		if location == "" {
+			location = source.Location
+		}
+
+		tx, err := h.cfg.Conn.BeginTx(req.Context(), nil)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error starting transaction", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to repeat gathering")
+			return
+		}
+		defer tx.Rollback()
+		qtx := h.cfg.Db.WithTx(tx)
+
+		existing, err := qtx.GetRepeatedGathering(req.Context(), sql.NullInt64{Int64: source.ID, Valid: true})
+		if err == nil {
+			handlers.RespondWithError(rw, http.StatusConflict, fmt.Sprintf("Gathering is already repeated by gathering %d", existing.ID))
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			logging.Logger.Log(zap.WarnLevel, "Error getting repeated gathering", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to repeat gathering")
+			return
+		}
+
+		gathering, err := qtx.CreateRepeatedGathering(req.Context(), database.CreateRepeatedGatheringParams{
+			Title:         title,
+			Location:      location,
+			GatheringDate: repeatReq.GatheringDate,
+			SourceID:      source.ID,
+			AssociationID: source.AssociationID,
+		})
+		// The unique index settles a repeat convened concurrently
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			handlers.RespondWithError(rw, http.StatusConflict, "Gathering is already repeated")
+			return
+		}
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error creating repeated gathering", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to repeat gathering")
+			return
+		}
+		copied, err := qtx.CopyVotingMatters(req.Context(), database.CopyVotingMattersParams{
+			ToGatheringID:   gathering.ID,
+			FromGatheringID: source.ID,
+		})
 		if err != nil {
-			logging.Logger.Log(zap.WarnLevel, "Error creating gathering", zap.Error(err))
-			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to create gathering")
+			logging.Logger.Log(zap.WarnLevel, "Error copying voting matters", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to repeat gathering")
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error committing transaction", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to repeat gathering")
 			return
 		}
 
-		// Calculate qualified units
+		// Qualified units are recalculated, ownership may have changed since the original gathering
 		qualifiedCount, qualifiedPart, qualifiedArea := h.statsService.UpdateGatheringStats(gathering.ID, int64(associationID))
 
-		// Log audit
-		h.cfg.Db.CreateAuditLog(req.Context(), database.CreateAuditLogParams{
+		details, _ := json.Marshal(map[string]int64{
+			"repeated_from":  source.ID,
+			"voting_matters": copied,
+		})
+		services.NewIntegrityService(h.cfg.Db).RecordAudit(req.Context(), database.CreateAuditLogParams{
 			GatheringID: gathering.ID,
 			EntityType:  "gathering",
 			EntityID:    gathering.ID,
 			Action:      "created",
-			PerformedBy: sql.NullString{String: req.Context().Value("userID").(string), Valid: true},
+			PerformedBy: sql.NullString{String: handlers.GetUserIdFromContext(req), Valid: true},
 			IpAddress:   sql.NullString{String: req.RemoteAddr, Valid: true},
-			Details:     sql.NullString{String: "{}", Valid: true},
+			Details:     sql.NullString{String: string(details), Valid: true},
 		})
 
-		err = h.unitSlotService.SyncUnitsSlots(req.Context(), int64(associationID), gathering.ID, createReq.QualificationUnitTypes, createReq.QualificationFloors, createReq.QualificationEntrances)
+		response := domain.DBGatheringToResponse(gathering)
+		err = h.unitSlotService.SyncUnitsSlots(req.Context(), int64(associationID), gathering.ID, response.QualificationUnitTypes, response.QualificationFloors, response.QualificationEntrances, services.GatheringQualificationRule(gathering))
 		if err != nil {
 			logging.Logger.Log(zap.WarnLevel, "Error syncing units slots", zap.Error(err))
 			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to sync units slots")
 			return
 		}
 
-		response := domain.DBGatheringToResponse(gathering)
 		response.QualifiedUnitsCount = qualifiedCount
 		response.QualifiedUnitsTotalPart = qualifiedPart
 		response.QualifiedUnitsTotalArea = qualifiedArea
@@ -188,52 +588,41 @@ func (h *GatheringHandler) HandleUpdateGatheringStatus() func(http.ResponseWrite
 			return
 		}
 
-		// Validate status transition
-		validStatuses := map[string]bool{
-			"draft": true, "published": true, "active": true, "closed": true, "tallied": true,
-		}
-		if !validStatuses[statusReq.Status] {
+		if !services.IsValidGatheringStatus(statusReq.Status) {
 			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid status")
 			return
 		}
 
-		gathering, err := h.cfg.Db.UpdateGatheringStatus(req.Context(), database.UpdateGatheringStatusParams{
-			Status:        statusReq.Status,
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
 			ID:            int64(gatheringID),
 			AssociationID: int64(associationID),
 		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			} else {
+				logging.Logger.Log(zap.WarnLevel, "Error getting gathering", zap.Error(err))
+				handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get gathering")
+			}
+			return
+		}
 
+		gathering, err = h.lifecycleService.Transition(req.Context(), gathering, statusReq.Status, services.TransitionActor{
+			PerformedBy: handlers.GetUserIdFromContext(req),
+			IPAddress:   req.RemoteAddr,
+			Trigger:     "manual",
+		})
 		if err != nil {
-			logging.Logger.Log(zap.WarnLevel, "Error updating gathering status", zap.Error(err))
-			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to update gathering status")
-			return
-		}
-
-		// If closing the gathering, calculate and cache final results
-		if statusReq.Status == "closed" {
-			go func() {
-				_, err := h.votingResultsService.ComputeAndStoreResults(req.Context(), int64(gatheringID), int64(associationID))
-				if err != nil {
-					logging.Logger.Log(zap.ErrorLevel, "Error computing final results",
-						zap.Int64("gathering_id", int64(gatheringID)),
-						zap.Error(err))
-				} else {
-					logging.Logger.Log(zap.InfoLevel, "Successfully computed and cached voting results",
-						zap.Int64("gathering_id", int64(gatheringID)))
-				}
-			}()
-		}
-
-		// If reopening gathering, invalidate cached results
-		if gathering.Status == "closed" && statusReq.Status != "closed" {
-			go func() {
-				err := h.votingResultsService.InvalidateResults(req.Context(), int64(gatheringID))
-				if err != nil {
-					logging.Logger.Log(zap.WarnLevel, "Error invalidating cached results",
-						zap.Int64("gathering_id", int64(gatheringID)),
-						zap.Error(err))
-				}
-			}()
+			switch {
+			case errors.Is(err, services.ErrInvalidTransition), errors.Is(err, services.ErrConcurrentTransition):
+				handlers.RespondWithError(rw, http.StatusConflict, err.Error())
+			case errors.Is(err, services.ErrTransitionPrecondition):
+				handlers.RespondWithError(rw, http.StatusUnprocessableEntity, err.Error())
+			default:
+				logging.Logger.Log(zap.WarnLevel, "Error updating gathering status", zap.Error(err))
+				handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to update gathering status")
+			}
+			return
 		}
 
 		handlers.RespondWithJSON(rw, http.StatusOK, domain.DBGatheringToResponse(gathering))
@@ -252,3 +641,11 @@ func (h *GatheringHandler) ValidateGatheringStateWithFetch(req *http.Request, ga
 	}
 	return h.quorumService.ValidateGatheringState(gathering, targetStatus)
 }
+
+// timePtrToNullTime converts an optional timestamp to a UTC sql.NullTime
+func timePtrToNullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/handlers"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
+)
+
+// TestRepeatGatheringOnce tests that an initial gathering that missed quorum is repeated once,
+// and that a repeated gathering is not repeated again
+func TestRepeatGatheringOnce(t *testing.T) {
+	cfg := newTestConfig(t)
+	mustExec(t, cfg.Conn,
+		`INSERT INTO associations (id, name, address, administrator) VALUES (1, 'Association', 'Street 1', 'Admin')`,
+		`INSERT INTO gatherings (id, association_id, title, description, intent, location, gathering_date, gathering_type, status)
+			VALUES (1, 1, 'Annual meeting', '', '', 'Hall', '2026-05-12 18:00:00', 'initial', 'closed')`,
+		`INSERT INTO voting_results (gathering_id, results_data, voting_mode, gathering_type, total_possible_votes_weight,
+				total_possible_votes_count, quorum_threshold_percentage, quorum_met)
+			VALUES (1, '{}', 'by_weight', 'initial', 1, 10, 50, FALSE)`,
+	)
+	h := NewGatheringHandler(cfg, nil, services.NewLifecycleService(cfg.Db, cfg.Conn, nil, nil))
+
+	repeat := func(gatheringID string) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"gathering_date":"2026-05-26T18:00:00Z"}`))
+		req = handlers.AddUserIdToContext(req, "admin")
+		req.SetPathValue(handlers.AssociationIdPathValue, "1")
+		req.SetPathValue(domain.GatheringIDPathValue, gatheringID)
+		rw := httptest.NewRecorder()
+		h.HandleRepeatGathering()(rw, req)
+		return rw.Code
+	}
+
+	if got := repeat("1"); got != http.StatusCreated {
+		t.Fatalf("first repeat status %d, want %d", got, http.StatusCreated)
+	}
+	if got := repeat("1"); got != http.StatusConflict {
+		t.Errorf("second repeat status %d, want %d", got, http.StatusConflict)
+	}
+	if _, err := cfg.Conn.Exec(`INSERT INTO gatherings (association_id, title, description, intent, gathering_date, gathering_type, repeated_from_id)
+		VALUES (1, 'Duplicate', '', '', '2026-05-26 18:00:00', 'repeated', 1)`); err == nil {
+		t.Error("a second gathering repeating gathering 1 was stored")
+	}
+
+	// The repeated gathering misses quorum too
+	mustExec(t, cfg.Conn,
+		`UPDATE gatherings SET status = 'closed' WHERE repeated_from_id = 1`,
+		`INSERT INTO voting_results (gathering_id, results_data, voting_mode, gathering_type, total_possible_votes_weight,
+				total_possible_votes_count, quorum_threshold_percentage, quorum_met)
+			SELECT id, '{}', 'by_weight', 'repeated', 1, 10, 30, FALSE FROM gatherings WHERE repeated_from_id = 1`,
+	)
+	var repeatedID string
+	cfg.Conn.QueryRow(`SELECT id FROM gatherings WHERE repeated_from_id = 1`).Scan(&repeatedID)
+	if got := repeat(repeatedID); got != http.StatusUnprocessableEntity {
+		t.Errorf("repeating a repeated gathering status %d, want %d", got, http.StatusUnprocessableEntity)
+	}
+}
+
+// TestUpdateGatheringSchedule tests that a draft remote gathering is rescheduled on update, and
+// that the schedule is checked as on create
+func TestUpdateGatheringSchedule(t *testing.T) {
+	cfg := newTestConfig(t)
+	mustExec(t, cfg.Conn,
+		`INSERT INTO associations (id, name, address, administrator) VALUES (1, 'Association', 'Street 1', 'Admin')`,
+		`INSERT INTO gatherings (id, association_id, title, description, intent, location, gathering_date, gathering_type, status)
+			VALUES (1, 1, 'Remote vote', '', '', 'Online', '2026-05-12 18:00:00', 'remote', 'draft')`,
+	)
+	h := NewGatheringHandler(cfg, nil, services.NewLifecycleService(cfg.Db, cfg.Conn, nil, nil))
+
+	update := func(body string) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPut, "/", bytes.NewBufferString(body))
+		req = handlers.AddUserIdToContext(req, "admin")
+		req.SetPathValue(handlers.AssociationIdPathValue, "1")
+		req.SetPathValue(domain.GatheringIDPathValue, "1")
+		rw := httptest.NewRecorder()
+		h.HandleUpdateGathering()(rw, req)
+		return rw.Code
+	}
+	setup := func(gatheringType, schedule string) string {
+		return `{"title":"Remote vote","location":"Online","gathering_date":"2026-05-12T18:00:00Z","gathering_type":"` + gatheringType + `"` + schedule + `}`
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{"closing before opening", setup("remote", `,"opens_at":"2026-05-12T18:00:00Z","closes_at":"2026-05-12T17:00:00Z"`), http.StatusBadRequest},
+		{"schedule of a gathering in person", setup("initial", `,"opens_at":"2026-05-12T18:00:00Z"`), http.StatusBadRequest},
+		{"schedule of a remote gathering", setup("remote", `,"opens_at":"2026-05-12T18:00:00Z","closes_at":"2026-05-14T18:00:00Z"`), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := update(tt.body); got != tt.expected {
+				t.Errorf("update status %d, want %d", got, tt.expected)
+			}
+		})
+	}
+
+	gathering, err := cfg.Db.GetGatheringByID(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetGatheringByID() error = %v", err)
+	}
+	closesAt := time.Date(2026, 5, 14, 18, 0, 0, 0, time.UTC)
+	if !gathering.OpensAt.Valid || !gathering.ClosesAt.Valid || !gathering.ClosesAt.Time.Equal(closesAt) {
+		t.Errorf("schedule = %v to %v, want 2026-05-12 18:00 to %v", gathering.OpensAt, gathering.ClosesAt, closesAt)
+	}
+
+	mustExec(t, cfg.Conn, `UPDATE gatherings SET status = 'published' WHERE id = 1`)
+	if got := update(setup("remote", "")); got != http.StatusConflict {
+		t.Errorf("updating a published gathering status %d, want %d", got, http.StatusConflict)
+	}
+}
+
+// TestScheduledClose tests that the scheduler closes a gathering with its audit entry sealed,
+// backs off after a failed close, and leaves alone a gathering reopened after its closing time
+func TestScheduledClose(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	mustExec(t, cfg.Conn,
+		`INSERT INTO associations (id, name, address, administrator) VALUES (1, 'Association', 'Street 1', 'Admin')`,
+		`INSERT INTO gatherings (id, association_id, title, description, intent, location, gathering_date, gathering_type, status, closes_at)
+			VALUES (1, 1, 'Remote vote', '', '', 'Online', '2026-05-12 18:00:00', 'remote', 'active', '2026-05-14 18:00:00')`,
+		`INSERT INTO buildings (id, name, address, cadastral_number, total_area, association_id) VALUES (1, 'Block A', 'Street 1', 'B-1', 100, 1)`,
+		`INSERT INTO units (id, cadastral_number, building_id, unit_number, address, area, part, floor) VALUES (1, 'U-1', 1, '1', 'Street 1', 50, 1, 1)`,
+		`INSERT INTO unit_slots (gathering_id, unit_id) VALUES (1, 1)`,
+		`CREATE TRIGGER refuse_status_changes BEFORE INSERT ON voting_audit_log
+			WHEN NEW.action = 'status_changed' BEGIN SELECT RAISE(ABORT, 'audit log unavailable'); END`,
+	)
+	lifecycle := services.NewLifecycleService(cfg.Db, cfg.Conn, nil, nil)
+	scheduler := services.NewGatheringScheduler(cfg.Db, lifecycle, time.Minute)
+	status := func() string {
+		t.Helper()
+		gathering, err := cfg.Db.GetGatheringByID(ctx, 1)
+		if err != nil {
+			t.Fatalf("GetGatheringByID() error = %v", err)
+		}
+		return gathering.Status
+	}
+	count := func(query string) int {
+		t.Helper()
+		var n int
+		if err := cfg.Conn.QueryRow(query).Scan(&n); err != nil {
+			t.Fatalf("failed to count: %v", err)
+		}
+		return n
+	}
+
+	// Without its audit entry the gathering is not closed
+	now := time.Date(2026, 5, 15, 9, 0, 0, 0, time.UTC)
+	scheduler.RunOnce(ctx, now)
+	if got := status(); got != services.GatheringStatusActive {
+		t.Fatalf("status %s after a close that could not be audited, want %s", got, services.GatheringStatusActive)
+	}
+	if n := count(`SELECT COUNT(*) FROM voting_audit_log WHERE action = 'scheduled_transition_failed'`); n != 1 {
+		t.Errorf("%d failures recorded, want 1", n)
+	}
+
+	// The close is retried after the backoff, not on the next tick
+	mustExec(t, cfg.Conn, `DROP TRIGGER refuse_status_changes`)
+	scheduler.RunOnce(ctx, now.Add(30*time.Second))
+	if got := status(); got != services.GatheringStatusActive {
+		t.Errorf("status %s while backing off, want %s", got, services.GatheringStatusActive)
+	}
+	scheduler.RunOnce(ctx, now.Add(time.Minute))
+	if got := status(); got != services.GatheringStatusClosed {
+		t.Fatalf("status %s after the backoff, want %s", got, services.GatheringStatusClosed)
+	}
+	if n := count(`SELECT COUNT(*) FROM voting_audit_log WHERE chain_hash IS NULL`); n != 0 {
+		t.Errorf("%d audit entries left unsealed by the close", n)
+	}
+	if report, err := services.NewIntegrityService(cfg.Db).Check(ctx, 1); err != nil || !report.Intact {
+		t.Errorf("Check() = %+v, %v after the close, want intact", report, err)
+	}
+
+	// Reopened by hand, it stays open past its closing time
+	gathering, _ := cfg.Db.GetGatheringByID(ctx, 1)
+	if _, err := lifecycle.Transition(ctx, gathering, services.GatheringStatusActive, services.TransitionActor{PerformedBy: "admin", Trigger: "manual"}); err != nil {
+		t.Fatalf("Transition(active) error = %v", err)
+	}
+	scheduler.RunOnce(ctx, now.Add(time.Hour))
+	if got := status(); got != services.GatheringStatusActive {
+		t.Errorf("status %s after reopening, want %s", got, services.GatheringStatusActive)
+	}
+}
@@ -1,31 +1,46 @@
 package handlers
 
 import (
-	"crypto/rand"
-	"crypto/sha256"
 	"database/sql"
-	"encoding/base64"
-	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/alexmarian/apc/api/internal/database"
 	"github.com/alexmarian/apc/api/internal/handlers"
 	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
 	"github.com/alexmarian/apc/api/internal/logging"
 	"go.uber.org/zap"
 )
 
-const defaultInvitationTTL = 365 * 24 * time.Hour
-
 type InvitationHandler struct {
-	cfg *handlers.ApiConfig
+	cfg                 *handlers.ApiConfig
+	i18nService         *services.I18nService
+	invitationService   *services.InvitationService
+	verificationService *services.MemberVerificationService
+	documentService     *services.DocumentService
 }
 
-func NewInvitationHandler(cfg *handlers.ApiConfig) *InvitationHandler {
-	return &InvitationHandler{cfg: cfg}
+func NewInvitationHandler(cfg *handlers.ApiConfig, verificationService *services.MemberVerificationService) *InvitationHandler {
+	i18nService, err := services.NewI18nService()
+	if err != nil {
+		logging.Logger.Log(zap.ErrorLevel, "Error loading translations", zap.Error(err))
+	}
+	votingResultsService := services.NewVotingResultsService(cfg.Db, services.NewQuorumService(cfg.Db), services.NewTallyService(cfg.Db))
+	return &InvitationHandler{
+		cfg:                 cfg,
+		i18nService:         i18nService,
+		invitationService:   services.NewInvitationService(cfg.Db, cfg.Conn, verificationService, cfg.MemberOrigin),
+		verificationService: verificationService,
+		documentService:     services.NewDocumentService(cfg.Db, i18nService, votingResultsService),
+	}
 }
 
 type invitationResponse struct {
@@ -34,12 +49,14 @@ type invitationResponse struct {
 	OwnerID     int64     `json:"owner_id"`
 	ExpiresAt   time.Time `json:"expires_at"`
 	Status      string    `json:"status"`
+	Locked      bool      `json:"locked"` // Too many failed verification attempts
 	CreatedAt   time.Time `json:"created_at"`
 }
 
 type createInvitationResponse struct {
 	invitationResponse
 	Token string `json:"token"`
+	PIN   string `json:"pin,omitempty"` // Handed out separately when the gathering requires a PIN
 }
 
 func invitationStatus(inv database.MemberInvitation) string {
@@ -63,8 +80,8 @@ func toInvitationResponse(inv database.MemberInvitation) invitationResponse {
 	}
 }
 
-// HandleCreateInvitation generates an opaque token for a member to access a gathering.
-// The plaintext token is returned once and never stored.
+// HandleCreateInvitation generates an opaque token for a member to access a gathering, with a PIN
+// when the gathering requires one. The plaintext token and PIN are returned once and never stored.
 func (h *InvitationHandler) HandleCreateInvitation() func(http.ResponseWriter, *http.Request) {
 	return func(rw http.ResponseWriter, req *http.Request) {
 		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
@@ -88,7 +105,6 @@ func (h *InvitationHandler) HandleCreateInvitation() func(http.ResponseWriter, *
 			handlers.RespondWithError(rw, http.StatusNotFound, "gathering not found")
 			return
 		}
-		_ = gathering
 
 		// Check for existing non-revoked invitation (active or expired)
 		existing, err := h.cfg.Db.GetActiveMemberInvitationByOwnerAndGathering(req.Context(),
@@ -102,23 +118,28 @@ func (h *InvitationHandler) HandleCreateInvitation() func(http.ResponseWriter, *
 			return
 		}
 
-		expiresAt := time.Now().Add(defaultInvitationTTL)
+		expiresAt := time.Now().Add(services.DefaultInvitationTTL)
 		if body.ExpiresAt != nil {
 			expiresAt = *body.ExpiresAt
 		}
 
-		// Generate 32 random bytes → base64url token
-		raw := make([]byte, 32)
-		if _, err := rand.Read(raw); err != nil {
+		token, tokenHash, err := services.NewMemberToken()
+		if err != nil {
 			logging.Logger.Log(zap.ErrorLevel, "failed to generate token", zap.Error(err))
 			handlers.RespondWithError(rw, http.StatusInternalServerError, "failed to generate token")
 			return
 		}
-		token := base64.RawURLEncoding.EncodeToString(raw)
-		hash := sha256.Sum256([]byte(token))
-		tokenHash := hex.EncodeToString(hash[:])
 
-		inv, err := h.cfg.Db.CreateMemberInvitation(req.Context(), database.CreateMemberInvitationParams{
+		tx, err := h.cfg.Conn.BeginTx(req.Context(), nil)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "failed to start transaction", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "failed to create invitation")
+			return
+		}
+		defer tx.Rollback()
+		qtx := h.cfg.Db.WithTx(tx)
+
+		inv, err := qtx.CreateMemberInvitation(req.Context(), database.CreateMemberInvitationParams{
 			GatheringID: int64(gatheringID),
 			OwnerID:     body.OwnerID,
 			TokenHash:   tokenHash,
@@ -129,10 +150,112 @@ func (h *InvitationHandler) HandleCreateInvitation() func(http.ResponseWriter, *
 			handlers.RespondWithError(rw, http.StatusInternalServerError, "failed to create invitation")
 			return
 		}
+		var pin string
+		if gathering.MemberVerification == services.MemberVerificationPIN {
+			if pin, err = h.verificationService.IssuePIN(req.Context(), qtx, inv); err != nil {
+				logging.Logger.Log(zap.WarnLevel, "failed to issue PIN", zap.Error(err))
+				handlers.RespondWithError(rw, http.StatusInternalServerError, "failed to create invitation")
+				return
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			logging.Logger.Log(zap.WarnLevel, "failed to commit invitation", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "failed to create invitation")
+			return
+		}
 
 		handlers.RespondWithJSON(rw, http.StatusCreated, createInvitationResponse{
 			invitationResponse: toInvitationResponse(inv),
 			Token:              token,
+			PIN:                pin,
+		})
+	}
+}
+
+// HandleCreateBulkInvitations issues an invitation to every voting owner of a gathering who has
+// no active one. The tokens are returned once: as JSON, or with ?format=pdf as printable letters
+// carrying a QR code of each owner's link, for owners who vote without email. When the gathering
+// requires a PIN, each invitation comes with one, printed on the letter below a cut line.
+func (h *InvitationHandler) HandleCreateBulkInvitations() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		format := req.URL.Query().Get("format")
+		if format != "" && format != "json" && format != "pdf" {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "format must be json or pdf")
+			return
+		}
+		lang := req.URL.Query().Get("lang")
+		if lang == "" {
+			lang = defaultDocumentLanguage
+		}
+		if !h.i18nService.Supports(lang) {
+			handlers.RespondWithError(rw, http.StatusBadRequest, fmt.Sprintf("unsupported language, expected one of %s", strings.Join(services.SupportedLanguages, ", ")))
+			return
+		}
+
+		var body struct {
+			ExpiresAt *time.Time `json:"expires_at,omitempty"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "invalid request format")
+			return
+		}
+		expiresAt := time.Now().Add(services.DefaultInvitationTTL)
+		if body.ExpiresAt != nil {
+			if !body.ExpiresAt.After(time.Now()) {
+				handlers.RespondWithError(rw, http.StatusBadRequest, "expires_at must be in the future")
+				return
+			}
+			expiresAt = *body.ExpiresAt
+		}
+
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusNotFound, "gathering not found")
+			return
+		}
+		// Invitations go to the owners of the register frozen at publication
+		if gathering.Status != services.GatheringStatusPublished && gathering.Status != services.GatheringStatusActive {
+			handlers.RespondWithError(rw, http.StatusConflict, "invitations are issued in bulk once the gathering is published and until voting closes")
+			return
+		}
+
+		var letters []byte
+		var handOut func([]domain.IssuedInvitation) error
+		if format == "pdf" {
+			handOut = func(issued []domain.IssuedInvitation) error {
+				letters, err = h.documentService.RenderInvitationLetters(req.Context(), gathering, lang, issued)
+				return err
+			}
+		}
+		issued, skipped, err := h.invitationService.IssueMissing(req.Context(), gathering, expiresAt, handOut)
+		if errors.Is(err, services.ErrMemberAppNotConfigured) {
+			handlers.RespondWithError(rw, http.StatusServiceUnavailable, "the member app address is not configured")
+			return
+		}
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "failed to issue invitations", zap.Error(err),
+				zap.Int("gathering_id", gatheringID))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "failed to issue invitations")
+			return
+		}
+
+		if format == "pdf" {
+			respondWithPDF(rw, fmt.Sprintf("invitation-letters-%d-%s.pdf", gathering.ID, lang), letters)
+			return
+		}
+		if issued == nil {
+			issued = []domain.IssuedInvitation{}
+		}
+		handlers.RespondWithJSON(rw, http.StatusCreated, domain.BulkInvitationResponse{
+			IssuedCount:  len(issued),
+			SkippedCount: skipped,
+			Invitations:  issued,
 		})
 	}
 }
@@ -159,9 +282,17 @@ func (h *InvitationHandler) HandleListInvitations() func(http.ResponseWriter, *h
 			return
 		}
 
+		locked, err := h.cfg.Db.GetLockedMemberInvitations(req.Context(), int64(gatheringID))
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "failed to get locked invitations", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "failed to list invitations")
+			return
+		}
+
 		response := make([]invitationResponse, len(invitations))
 		for i, inv := range invitations {
 			response[i] = toInvitationResponse(inv)
+			response[i].Locked = slices.Contains(locked, inv.ID)
 		}
 		handlers.RespondWithJSON(rw, http.StatusOK, response)
 	}
@@ -197,3 +328,39 @@ func (h *InvitationHandler) HandleRevokeInvitation() func(http.ResponseWriter, *
 		handlers.RespondWithJSON(rw, http.StatusOK, map[string]string{"status": "revoked"})
 	}
 }
+
+// HandleUnlockInvitation clears the failed verification attempts of a member invitation, lifting
+// the lock they put on it.
+func (h *InvitationHandler) HandleUnlockInvitation() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+		invitationID, _ := strconv.Atoi(req.PathValue(domain.InvitationIDPathValue))
+
+		_, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusNotFound, "gathering not found")
+			return
+		}
+
+		unlocked, err := h.cfg.Db.UnlockMemberVerification(req.Context(), database.UnlockMemberVerificationParams{
+			InvitationID: int64(invitationID),
+			GatheringID:  int64(gatheringID),
+		})
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "failed to unlock invitation", zap.Error(err),
+				zap.Int("invitation_id", invitationID))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "failed to unlock invitation")
+			return
+		}
+		if unlocked == 0 {
+			handlers.RespondWithError(rw, http.StatusNotFound, "invitation not found or without failed attempts")
+			return
+		}
+
+		handlers.RespondWithJSON(rw, http.StatusOK, map[string]string{"status": "unlocked"})
+	}
+}
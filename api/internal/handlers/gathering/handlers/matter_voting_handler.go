@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"go.uber.org/zap"
+)
+
+// MatterVotingHandler handles live meetings where the chair opens and closes voting on each matter
+type MatterVotingHandler struct {
+	cfg                  *handlers.ApiConfig
+	matterVotingService  *services.MatterVotingService
+	ballotValidator      *services.BallotValidator
+	tallyService         *services.TallyService
+	votingResultsService *services.VotingResultsService
+	liveResults          *services.LiveResults
+}
+
+// NewMatterVotingHandler creates a new MatterVotingHandler
+func NewMatterVotingHandler(cfg *handlers.ApiConfig, liveResults *services.LiveResults) *MatterVotingHandler {
+	tallyService := services.NewTallyService(cfg.Db)
+	return &MatterVotingHandler{
+		cfg:                  cfg,
+		matterVotingService:  services.NewMatterVotingService(cfg.Db),
+		ballotValidator:      services.NewBallotValidator(cfg.Db),
+		tallyService:         tallyService,
+		votingResultsService: services.NewVotingResultsService(cfg.Db, services.NewQuorumService(cfg.Db), tallyService),
+		liveResults:          liveResults,
+	}
+}
+
+// HandleGetMatterVoting returns the voting windows of a gathering and the voting state of its matters
+func (h *MatterVotingHandler) HandleGetMatterVoting() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		gathering, ok := h.getGathering(rw, req)
+		if !ok {
+			return
+		}
+
+		settings, err := h.matterVotingService.Settings(req.Context(), gathering)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error getting matter voting", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get matter voting")
+			return
+		}
+		handlers.RespondWithJSON(rw, http.StatusOK, settings)
+	}
+}
+
+// HandleSetMatterVoting sets whether every matter takes votes while the gathering is active, or the
+// chair opens and closes voting on each matter in turn. It can change until voting opens.
+func (h *MatterVotingHandler) HandleSetMatterVoting() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		var votingReq domain.MatterVotingRequest
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&votingReq); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+
+		gathering, ok := h.getGathering(rw, req)
+		if !ok {
+			return
+		}
+
+		settings, err := h.matterVotingService.SetVotingWindows(req.Context(), gathering, votingReq.VotingWindows)
+		switch {
+		case errors.Is(err, services.ErrInvalidVotingWindows):
+			handlers.RespondWithError(rw, http.StatusBadRequest, err.Error())
+			return
+		case errors.Is(err, services.ErrVotingWindowsFixed):
+			handlers.RespondWithError(rw, http.StatusConflict, "The voting windows can only change before voting opens")
+			return
+		case err != nil:
+			logging.Logger.Log(zap.WarnLevel, "Error setting voting windows", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to set voting windows")
+			return
+		}
+		handlers.RespondWithJSON(rw, http.StatusOK, settings)
+	}
+}
+
+// HandleOpenMatter opens voting on a matter of a live meeting
+func (h *MatterVotingHandler) HandleOpenMatter() func(http.ResponseWriter, *http.Request) {
+	return h.handleMatterWindow(h.matterVotingService.Open, "voting_opened")
+}
+
+// HandleCloseMatter closes voting on a matter of a live meeting. Participants who have not voted
+// on it by then no longer can.
+func (h *MatterVotingHandler) HandleCloseMatter() func(http.ResponseWriter, *http.Request) {
+	return h.handleMatterWindow(h.matterVotingService.Close, "voting_closed")
+}
+
+// handleMatterWindow opens or closes voting on a matter and records the chair's action
+func (h *MatterVotingHandler) handleMatterWindow(
+	change func(ctx context.Context, gathering database.Gathering, matterID int64) (database.VotingMatter, error),
+	action string,
+) func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		matterID, _ := strconv.Atoi(req.PathValue(domain.VotingMatterIDPathValue))
+
+		gathering, ok := h.getGathering(rw, req)
+		if !ok {
+			return
+		}
+
+		matter, err := change(req.Context(), gathering, int64(matterID))
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			handlers.RespondWithError(rw, http.StatusNotFound, "Voting matter not found")
+			return
+		case errors.Is(err, services.ErrNotPerMatter), errors.Is(err, services.ErrMatterVotingInactive), errors.Is(err, services.ErrMatterInformative):
+			handlers.RespondWithError(rw, http.StatusBadRequest, err.Error())
+			return
+		case errors.Is(err, services.ErrMatterNotPending), errors.Is(err, services.ErrMatterNotOpen):
+			handlers.RespondWithError(rw, http.StatusConflict, err.Error())
+			return
+		case err != nil:
+			logging.Logger.Log(zap.WarnLevel, "Error changing matter voting", zap.Error(err), zap.String("action", action))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to change voting on the matter")
+			return
+		}
+
+		services.NewIntegrityService(h.cfg.Db).RecordAudit(req.Context(), database.CreateAuditLogParams{
+			GatheringID: gathering.ID,
+			EntityType:  "matter",
+			EntityID:    matter.ID,
+			Action:      action,
+			PerformedBy: sql.NullString{String: handlers.GetUserIdFromContext(req), Valid: true},
+			IpAddress:   sql.NullString{String: req.RemoteAddr, Valid: true},
+			Details:     sql.NullString{String: fmt.Sprintf(`{"time":"%s"}`, time.Now().Format(time.RFC3339)), Valid: true},
+		})
+
+		h.votingResultsService.InvalidateResults(req.Context(), gathering.ID)
+		go h.liveResults.Publish(gathering.ID)
+
+		handlers.RespondWithJSON(rw, http.StatusOK, domain.DBVotingMatterToResponse(matter))
+	}
+}
+
+// HandleSubmitMatterVote records a checked-in participant's votes on open matters of a live
+// meeting. The votes join the participant's ballot; a matter already on it cannot be voted again.
+func (h *MatterVotingHandler) HandleSubmitMatterVote() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		matterID, _ := strconv.Atoi(req.PathValue(domain.VotingMatterIDPathValue))
+
+		var voteReq domain.MatterVoteRequest
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&voteReq); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid vote format")
+			return
+		}
+
+		gathering, ok := h.getGathering(rw, req)
+		if !ok {
+			return
+		}
+		if !services.PerMatterVoting(gathering) {
+			handlers.RespondWithError(rw, http.StatusBadRequest, services.ErrNotPerMatter.Error())
+			return
+		}
+		if gathering.Status != services.GatheringStatusActive {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Gathering is not active")
+			return
+		}
+
+		participant, err := h.cfg.Db.GetGatheringParticipant(req.Context(), database.GetGatheringParticipantParams{
+			ID:          voteReq.ParticipantID,
+			GatheringID: gathering.ID,
+		})
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Participant not found")
+			return
+		}
+		if !participant.CheckInTime.Valid {
+			handlers.RespondWithError(rw, http.StatusConflict, "Participant is not checked in")
+			return
+		}
+		if participant.CheckOutTime.Valid {
+			handlers.RespondWithError(rw, http.StatusConflict, services.ErrParticipantLeft.Error())
+			return
+		}
+
+		votes := map[string]domain.BallotVote{
+			strconv.Itoa(matterID): {MatterID: int64(matterID), Values: voteReq.Values},
+		}
+		if !validateBallotContent(rw, req, h.ballotValidator.Validate, gathering, votes) {
+			return
+		}
+
+		performedBy := fmt.Sprintf("participant_%d", participant.ID)
+		ballot, err := mergeMatterVotes(req, h.cfg, gathering.AssociationID, participant, votes, voteReq.Receipt, performedBy)
+		if err != nil {
+			respondWithMatterVoteError(rw, err)
+			return
+		}
+
+		go func() {
+			h.tallyService.UpdateVoteTallies(gathering.ID, int(participant.ID))
+			h.liveResults.Publish(gathering.ID)
+		}()
+		h.votingResultsService.InvalidateResults(req.Context(), gathering.ID)
+
+		services.NewIntegrityService(h.cfg.Db).RecordAudit(req.Context(), database.CreateAuditLogParams{
+			GatheringID: gathering.ID,
+			EntityType:  "ballot",
+			EntityID:    ballot.ID,
+			Action:      "submitted",
+			PerformedBy: sql.NullString{String: performedBy, Valid: true},
+			IpAddress:   sql.NullString{String: req.RemoteAddr, Valid: true},
+			Details:     sql.NullString{String: fmt.Sprintf(`{"hash":"%s","matter_id":%d}`, ballot.BallotHash, matterID), Valid: true},
+		})
+
+		// The receipt of anonymous votes is left out, as next to the participant it would reveal
+		// their choices
+		response := map[string]interface{}{
+			"status":         "vote_recorded",
+			"ballot_hash":    ballot.BallotHash,
+			"ballot_id":      ballot.ID,
+			"participant_id": participant.ID,
+			"signed_receipt": ballot.SignedReceipt,
+		}
+		handlers.RespondWithJSON(rw, http.StatusCreated, response)
+	}
+}
+
+// getGathering loads the gathering of the request's path and responds with 404 when it is not
+// found. It reports whether the gathering was loaded.
+func (h *MatterVotingHandler) getGathering(rw http.ResponseWriter, req *http.Request) (database.Gathering, bool) {
+	associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+	gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+	gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+		ID:            int64(gatheringID),
+		AssociationID: int64(associationID),
+	})
+	if err != nil {
+		handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+		return database.Gathering{}, false
+	}
+	return gathering, true
+}
+
+// respondWithMatterVoteError responds to a failure to add votes on matters to a ballot
+func respondWithMatterVoteError(rw http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrMatterAlreadyVoted):
+		handlers.RespondWithError(rw, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrReceiptRequired), errors.Is(err, services.ErrReceiptMismatch):
+		handlers.RespondWithError(rw, http.StatusUnprocessableEntity, err.Error())
+	default:
+		logging.Logger.Log(zap.WarnLevel, "Error recording matter votes", zap.Error(err))
+		handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to record votes")
+	}
+}
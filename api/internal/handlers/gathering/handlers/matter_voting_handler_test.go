@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
+	"github.com/alexmarian/apc/api/receipt"
+)
+
+// newMatterVotingTest sets up an active per_matter gathering with two open matters of the given
+// voting configs and a checked-in participant, and returns the handler of its votes
+func newMatterVotingTest(t *testing.T, firstConfig, secondConfig string) (*handlers.ApiConfig, *MatterVotingHandler) {
+	cfg := newTestConfig(t)
+	mustExec(t, cfg.Conn,
+		`INSERT INTO associations (id, name, address, administrator) VALUES (1, 'Association', 'Street 1', 'Admin')`,
+		`INSERT INTO gatherings (id, association_id, title, description, intent, gathering_date, gathering_type, status, voting_windows)
+			VALUES (1, 1, 'Annual meeting', '', '', '2026-05-12 18:00:00', 'initial', 'active', 'per_matter')`,
+		`INSERT INTO voting_matters (id, gathering_id, order_index, title, matter_type, voting_config, voting_state, voting_opened_at)
+			VALUES (1, 1, 1, 'Budget', 'budget', '`+firstConfig+`', 'open', CURRENT_TIMESTAMP),
+			       (2, 1, 2, 'Repairs', 'policy', '`+secondConfig+`', 'open', CURRENT_TIMESTAMP)`,
+		`INSERT INTO gathering_participants (id, gathering_id, participant_type, participant_name, units_info, units_area, units_part, check_in_time)
+			VALUES (1, 1, 'owner', 'Owner', '[1,2]', 50, 0.1, CURRENT_TIMESTAMP)`,
+	)
+	return cfg, NewMatterVotingHandler(cfg, services.NewLiveResults(cfg.Db, services.NewStatsService(cfg.Db)))
+}
+
+// submitMatterVote votes yes on a matter as participant 1 and returns the response body
+func submitMatterVote(t *testing.T, h *MatterVotingHandler, matterID string) map[string]interface{} {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"participant_id":1,"values":["yes"]}`))
+	req.SetPathValue(handlers.AssociationIdPathValue, "1")
+	req.SetPathValue(domain.GatheringIDPathValue, "1")
+	req.SetPathValue(domain.VotingMatterIDPathValue, matterID)
+	rw := httptest.NewRecorder()
+
+	h.HandleSubmitMatterVote()(rw, req)
+	if rw.Code != http.StatusCreated {
+		t.Fatalf("vote on matter %s: status %d, body %s", matterID, rw.Code, rw.Body.String())
+	}
+	var response map[string]interface{}
+	json.Unmarshal(rw.Body.Bytes(), &response)
+	return response
+}
+
+// TestMatterVotesKeepIntegrity tests that a participant voting on matters one by one leaves the
+// ballot chain and audit log consistent
+func TestMatterVotesKeepIntegrity(t *testing.T) {
+	yesNo := `{"type":"yes_no","allow_abstention":true}`
+	cfg, h := newMatterVotingTest(t, yesNo, yesNo)
+	submitMatterVote(t, h, "1")
+	submitMatterVote(t, h, "2")
+
+	report, err := services.NewIntegrityService(cfg.Db).Check(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if !report.Intact {
+		t.Errorf("Check() first break = %+v", *report.FirstBreak)
+	}
+	if report.Ballots.Entries != 2 {
+		t.Errorf("Check() ballots = %d, want 2", report.Ballots.Entries)
+	}
+}
+
+// TestAnonymousMatterVotes tests that votes recorded by the commission on anonymous matters keep
+// neither the weight nor the receipt next to the participant
+func TestAnonymousMatterVotes(t *testing.T) {
+	anonymous := `{"type":"yes_no","allow_abstention":true,"is_anonymous":true}`
+	cfg, h := newMatterVotingTest(t, anonymous, anonymous)
+	for _, matterID := range []string{"1", "2"} {
+		if response := submitMatterVote(t, h, matterID); response["receipt"] != nil {
+			t.Errorf("vote on matter %s: response holds the receipt", matterID)
+		}
+	}
+
+	var votes int
+	var part float64
+	var units int
+	err := cfg.Conn.QueryRow(`SELECT SUM(votes), SUM(units_part), SUM(units_count) FROM anonymous_vote_totals WHERE vote_values = '["yes"]'`).Scan(&votes, &part, &units)
+	if err != nil {
+		t.Fatalf("failed to read the totals: %v", err)
+	}
+	if votes != 2 || part != 0.2 || units != 4 {
+		t.Errorf("totals = %d votes, %v part, %d units, want 2, 0.2, 4", votes, part, units)
+	}
+}
+
+// TestSignedReceiptUnlinkedFromAnonymousVotes tests that the signing key is stored encrypted and
+// that a ballot's signature is made from the ballot row alone, so the row and the anonymous votes
+// cannot be joined through it
+func TestSignedReceiptUnlinkedFromAnonymousVotes(t *testing.T) {
+	anonymous := `{"type":"yes_no","allow_abstention":true,"is_anonymous":true}`
+	cfg, h := newMatterVotingTest(t, anonymous, anonymous)
+	submitMatterVote(t, h, "1")
+	signedReceipt, _ := submitMatterVote(t, h, "2")["signed_receipt"].(string)
+
+	var privateKey, publicKey string
+	if err := cfg.Conn.QueryRow(`SELECT private_key, public_key FROM association_signing_keys`).Scan(&privateKey, &publicKey); err != nil {
+		t.Fatalf("failed to read the signing key: %v", err)
+	}
+	if !strings.HasPrefix(privateKey, "aesgcm:") {
+		t.Errorf("signing key stored as %q, want it encrypted", privateKey)
+	}
+
+	body, _ := json.Marshal(domain.VerifyReceiptRequest{Receipt: signedReceipt})
+	rw := httptest.NewRecorder()
+	(&BallotHandler{cfg: cfg}).HandleVerifyReceipt()(rw, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body))))
+	var verified domain.VerifyReceiptResponse
+	json.Unmarshal(rw.Body.Bytes(), &verified)
+	if !verified.SignatureValid || !verified.Unchanged {
+		t.Fatalf("HandleVerifyReceipt() = %d %s", rw.Code, rw.Body.String())
+	}
+
+	// The payload rebuilt from the stored ballot row verifies, so nothing else went into it
+	var ballot database.VotingBallot
+	err := cfg.Conn.QueryRow(`SELECT id, gathering_id, ballot_hash, signature, signature_timestamp, signature_certificate
+		FROM voting_ballots WHERE is_valid ORDER BY id DESC LIMIT 1`).
+		Scan(&ballot.ID, &ballot.GatheringID, &ballot.BallotHash, &ballot.Signature, &ballot.SignatureTimestamp, &ballot.SignatureCertificate)
+	if err != nil {
+		t.Fatalf("failed to read the ballot: %v", err)
+	}
+	payload, _ := json.Marshal(receipt.Payload{
+		Version:       receipt.Version,
+		KeyID:         ballot.SignatureCertificate.String,
+		AssociationID: 1,
+		GatheringID:   ballot.GatheringID,
+		BallotID:      ballot.ID,
+		BallotHash:    ballot.BallotHash,
+		SignedAt:      ballot.SignatureTimestamp.Time.Unix(),
+	})
+	key, _ := receipt.DecodePublicKey(publicKey)
+	rebuilt := base64.RawURLEncoding.EncodeToString(payload) + "." + ballot.Signature.String
+	if _, err := receipt.Verify(key, rebuilt); err != nil {
+		t.Errorf("Verify() of the payload rebuilt from the ballot row error = %v", err)
+	}
+	if rebuilt != signedReceipt {
+		t.Errorf("signed receipt %q, want the one rebuilt from the ballot row %q", signedReceipt, rebuilt)
+	}
+
+	// No stored ballot column, nor the signed receipt, holds an anonymous receipt
+	var joined int
+	err = cfg.Conn.QueryRow(`SELECT COUNT(*) FROM voting_ballots b JOIN anonymous_votes a
+		ON instr(b.ballot_content, a.receipt) OR instr(COALESCE(b.signature, ''), a.receipt) OR instr(?, a.receipt)`, signedReceipt).Scan(&joined)
+	if err != nil {
+		t.Fatalf("failed to join the ballots and anonymous votes: %v", err)
+	}
+	if joined != 0 {
+		t.Errorf("%d ballots joined to anonymous votes", joined)
+	}
+
+	// Encrypted keys do not open with another secret
+	_, err = services.NewBallotSigner(cfg.Db, "other secret").Sign(context.Background(), 1, database.VotingBallot{ID: 1, GatheringID: 1})
+	if err == nil {
+		t.Error("Sign() with another secret succeeded")
+	}
+}
+
+// TestGatheringVotingWindow tests that the matters of a gathering voting on all of them at once
+// take votes from when it opens until it closes, and again once reopened
+func TestGatheringVotingWindow(t *testing.T) {
+	ctx := context.Background()
+	cfg, _ := newActiveBallotTest(t)
+	lifecycle := services.NewLifecycleService(cfg.Db, cfg.Conn, nil, nil)
+	window := func() database.VotingMatter {
+		t.Helper()
+		matter, err := cfg.Db.GetVotingMatter(ctx, database.GetVotingMatterParams{ID: 1, GatheringID: 1})
+		if err != nil {
+			t.Fatalf("GetVotingMatter() error = %v", err)
+		}
+		return matter
+	}
+	transition := func(to string) {
+		t.Helper()
+		gathering, _ := cfg.Db.GetGatheringByID(ctx, 1)
+		if _, err := lifecycle.Transition(ctx, gathering, to, services.TransitionActor{Trigger: "manual"}); err != nil {
+			t.Fatalf("Transition(%s) error = %v", to, err)
+		}
+	}
+
+	opened := window()
+	if !opened.VotingOpenedAt.Valid || opened.VotingClosedAt.Valid {
+		t.Fatalf("window %v to %v once active, want open", opened.VotingOpenedAt, opened.VotingClosedAt)
+	}
+	transition(services.GatheringStatusClosed)
+	if closed := window(); !closed.VotingClosedAt.Valid {
+		t.Errorf("window %v to %v once closed, want closed", closed.VotingOpenedAt, closed.VotingClosedAt)
+	}
+	transition(services.GatheringStatusActive)
+	if reopened := window(); reopened.VotingClosedAt.Valid || !reopened.VotingOpenedAt.Time.Equal(opened.VotingOpenedAt.Time) {
+		t.Errorf("window %v to %v once reopened, want open since %v", reopened.VotingOpenedAt, reopened.VotingClosedAt, opened.VotingOpenedAt)
+	}
+}
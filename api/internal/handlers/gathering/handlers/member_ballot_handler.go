@@ -1,10 +1,9 @@
 package handlers
 
 import (
-	"crypto/sha256"
 	"database/sql"
-	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -23,10 +22,13 @@ type MemberBallotHandler struct {
 	statsService         *services.StatsService
 	tallyService         *services.TallyService
 	votingResultsService *services.VotingResultsService
+	ballotValidator      *services.BallotValidator
+	liveResults          *services.LiveResults
+	delegationService    *services.DelegationService
 }
 
 // NewMemberBallotHandler creates a new MemberBallotHandler.
-func NewMemberBallotHandler(cfg *handlers.ApiConfig) *MemberBallotHandler {
+func NewMemberBallotHandler(cfg *handlers.ApiConfig, liveResults *services.LiveResults, delegationService *services.DelegationService) *MemberBallotHandler {
 	tallyService := services.NewTallyService(cfg.Db)
 	quorumService := services.NewQuorumService(cfg.Db)
 	return &MemberBallotHandler{
@@ -34,6 +36,9 @@ func NewMemberBallotHandler(cfg *handlers.ApiConfig) *MemberBallotHandler {
 		statsService:         services.NewStatsService(cfg.Db),
 		tallyService:         tallyService,
 		votingResultsService: services.NewVotingResultsService(cfg.Db, quorumService, tallyService),
+		ballotValidator:      services.NewBallotValidator(cfg.Db),
+		liveResults:          liveResults,
+		delegationService:    delegationService,
 	}
 }
 
@@ -68,129 +73,68 @@ func (h *MemberBallotHandler) HandleSubmitMemberBallot() http.HandlerFunc {
 			return
 		}
 
-		// 409 if a ballot already exists for this owner in this gathering
-		existingParticipant, err := h.cfg.Db.GetGatheringParticipantByOwner(r.Context(), database.GetGatheringParticipantByOwnerParams{
-			GatheringID: inv.GatheringID,
-			OwnerID:     sql.NullInt64{Int64: inv.OwnerID, Valid: true},
-		})
-		if err == nil {
-			_, err := h.cfg.Db.GetBallotByParticipant(r.Context(), database.GetBallotByParticipantParams{
-				GatheringID:   inv.GatheringID,
-				ParticipantID: existingParticipant.ID,
-			})
-			if err == nil {
-				handlers.RespondWithError(w, http.StatusConflict, "ballot already submitted")
-				return
-			}
-		}
-
 		var req struct {
 			BallotContent map[string]domain.BallotVote `json:"ballot_content"`
+			Receipt       string                       `json:"receipt,omitempty"` // Per-matter voting: the receipt of the owner's anonymous votes so far
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			handlers.RespondWithError(w, http.StatusBadRequest, "invalid request format")
 			return
 		}
 
-		owner, err := h.cfg.Db.GetOwnerById(r.Context(), inv.OwnerID)
-		if err != nil {
-			logging.Logger.Log(zap.WarnLevel, "failed to get owner", zap.Error(err))
-			handlers.RespondWithError(w, http.StatusInternalServerError, "failed to load owner")
+		if !validateBallotContent(w, r, h.ballotValidator.Validate, gathering, req.BallotContent) {
 			return
 		}
 
-		eligibleRows, err := h.cfg.Db.GetEligibleVotersWithUnits(r.Context(), database.GetEligibleVotersWithUnitsParams{
-			GatheringID:   inv.GatheringID,
-			AssociationID: gathering.AssociationID,
+		// 409 if a ballot already exists for this owner in this gathering, unless matters are voted
+		// one by one, when the votes are added to it
+		participant, err := h.cfg.Db.GetGatheringParticipantByOwner(r.Context(), database.GetGatheringParticipantByOwnerParams{
+			GatheringID: inv.GatheringID,
+			OwnerID:     sql.NullInt64{Int64: inv.OwnerID, Valid: true},
 		})
-		if err != nil {
-			logging.Logger.Log(zap.WarnLevel, "failed to get eligible units", zap.Error(err))
-			handlers.RespondWithError(w, http.StatusInternalServerError, "failed to load units")
-			return
-		}
-
-		var unitIDs []int64
-		totalArea := 0.0
-		totalWeight := 0.0
-		for _, row := range eligibleRows {
-			if row.OwnerID == inv.OwnerID && row.IsAvailable == 1 {
-				unitIDs = append(unitIDs, row.UnitID)
-				totalArea += row.Area
-				totalWeight += row.VotingWeight
-			}
+		hasBallot := false
+		if err == nil {
+			_, err := h.cfg.Db.GetBallotByParticipant(r.Context(), database.GetBallotByParticipantParams{
+				GatheringID:   inv.GatheringID,
+				ParticipantID: participant.ID,
+			})
+			hasBallot = err == nil
 		}
-		if len(unitIDs) == 0 {
-			handlers.RespondWithError(w, http.StatusBadRequest, "no available units for voting")
+		if hasBallot && !services.PerMatterVoting(gathering) {
+			handlers.RespondWithError(w, http.StatusConflict, "ballot already submitted")
 			return
 		}
 
-		unitsJSON, _ := json.Marshal(unitIDs)
-
-		participant, err := h.cfg.Db.CreateGatheringParticipant(r.Context(), database.CreateGatheringParticipantParams{
-			GatheringID:               inv.GatheringID,
-			ParticipantType:           "owner",
-			ParticipantName:           owner.Name,
-			ParticipantIdentification: sql.NullString{String: owner.IdentificationNumber, Valid: true},
-			OwnerID:                   sql.NullInt64{Int64: inv.OwnerID, Valid: true},
-			UnitsInfo:                 string(unitsJSON),
-			UnitsArea:                 totalArea,
-			UnitsPart:                 totalWeight,
-		})
-		if err != nil {
-			logging.Logger.Log(zap.WarnLevel, "failed to create participant", zap.Error(err))
-			handlers.RespondWithError(w, http.StatusInternalServerError, "failed to create participant")
-			return
-		}
-
-		// Auto check-in at submission time (no prior check-in step required)
-		if err := h.cfg.Db.CheckInParticipant(r.Context(), database.CheckInParticipantParams{
-			ID:          participant.ID,
-			GatheringID: inv.GatheringID,
-		}); err != nil {
-			logging.Logger.Log(zap.WarnLevel, "failed to check in participant", zap.Error(err))
-		}
-
-		for _, unitID := range unitIDs {
-			if _, err := h.cfg.Db.AssignUnitSlot(r.Context(), database.AssignUnitSlotParams{
-				ParticipantID: participant.ID,
-				GatheringID:   inv.GatheringID,
-				UnitID:        unitID,
-			}); err != nil {
-				// Slot already claimed by another owner — unit was voted concurrently
-				logging.Logger.Log(zap.WarnLevel, "unit slot already claimed", zap.Error(err), zap.Int64("unit_id", unitID))
-				handlers.RespondWithError(w, http.StatusConflict, "voting rights for this unit have already been exercised")
+		var ballot recordedBallot
+		if hasBallot {
+			if participant.CheckOutTime.Valid {
+				handlers.RespondWithError(w, http.StatusConflict, "you have left the meeting")
+				return
+			}
+			if !checkMemberDirectVote(w, r, h.delegationService, inv) {
+				return
+			}
+			ballot, err = mergeMatterVotes(r, h.cfg, gathering.AssociationID, participant, req.BallotContent, req.Receipt, fmt.Sprintf("member_owner_%d", inv.OwnerID))
+			if err != nil {
+				respondWithMatterVoteError(w, err)
+				return
+			}
+		} else {
+			participant, ballot, ok = h.recordFirstBallot(w, r, gathering, inv, req.BallotContent)
+			if !ok {
 				return
 			}
 		}
-
-		ballotJSON, err := json.Marshal(req.BallotContent)
-		if err != nil {
-			handlers.RespondWithError(w, http.StatusBadRequest, "invalid ballot content")
-			return
-		}
-
-		hash := sha256.Sum256(ballotJSON)
-		ballotHash := hex.EncodeToString(hash[:])
-
-		ballot, err := h.cfg.Db.CreateBallot(r.Context(), database.CreateBallotParams{
-			GatheringID:        inv.GatheringID,
-			ParticipantID:      participant.ID,
-			BallotContent:      string(ballotJSON),
-			BallotHash:         ballotHash,
-			SubmittedIp:        sql.NullString{String: r.RemoteAddr, Valid: true},
-			SubmittedUserAgent: sql.NullString{String: r.UserAgent(), Valid: true},
-		})
-		if err != nil {
-			logging.Logger.Log(zap.WarnLevel, "failed to create ballot", zap.Error(err))
-			handlers.RespondWithError(w, http.StatusInternalServerError, "failed to submit ballot")
-			return
-		}
+		ballotHash := ballot.BallotHash
 
 		go h.statsService.UpdateGatheringParticipationStats(inv.GatheringID, gathering.AssociationID)
-		go h.tallyService.UpdateVoteTallies(inv.GatheringID, int(participant.ID))
+		go func() {
+			h.tallyService.UpdateVoteTallies(inv.GatheringID, int(participant.ID))
+			h.liveResults.Publish(inv.GatheringID)
+		}()
 		h.votingResultsService.InvalidateResults(r.Context(), inv.GatheringID)
 
-		h.cfg.Db.CreateAuditLog(r.Context(), database.CreateAuditLogParams{
+		services.NewIntegrityService(h.cfg.Db).RecordAudit(r.Context(), database.CreateAuditLogParams{
 			GatheringID: inv.GatheringID,
 			EntityType:  "ballot",
 			EntityID:    ballot.ID,
@@ -205,10 +149,170 @@ func (h *MemberBallotHandler) HandleSubmitMemberBallot() http.HandlerFunc {
 			submittedAt = &ballot.SubmittedAt.Time
 		}
 
-		handlers.RespondWithJSON(w, http.StatusCreated, map[string]interface{}{
-			"ballot_id":    ballot.ID,
-			"ballot_hash":  ballotHash,
-			"submitted_at": submittedAt,
-		})
+		response := map[string]interface{}{
+			"ballot_id":      ballot.ID,
+			"ballot_hash":    ballotHash,
+			"submitted_at":   submittedAt,
+			"signed_receipt": ballot.SignedReceipt,
+		}
+		if ballot.Receipt != "" {
+			response["receipt"] = ballot.Receipt
+		}
+		handlers.RespondWithJSON(w, http.StatusCreated, response)
+	}
+}
+
+// recordFirstBallot registers the invitation's owner as a participant and records their ballot in
+// one transaction with the delegation check, so that a delegate cannot take part for them
+// meanwhile. The owner is then checked in. It responds with the problem when the ballot cannot be
+// recorded and reports whether it was.
+func (h *MemberBallotHandler) recordFirstBallot(w http.ResponseWriter, r *http.Request, gathering database.Gathering, inv database.MemberInvitation, content map[string]domain.BallotVote) (database.GatheringParticipant, recordedBallot, bool) {
+	tx, err := h.cfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "failed to start transaction", zap.Error(err))
+		handlers.RespondWithError(w, http.StatusInternalServerError, "failed to submit ballot")
+		return database.GatheringParticipant{}, recordedBallot{}, false
+	}
+	defer tx.Rollback()
+	qtx := h.cfg.Db.WithTx(tx)
+
+	if !checkMemberDirectVote(w, r, h.delegationService.WithTx(tx), inv) {
+		return database.GatheringParticipant{}, recordedBallot{}, false
+	}
+	participant, ok := h.createParticipant(w, r, qtx, gathering, inv)
+	if !ok {
+		return database.GatheringParticipant{}, recordedBallot{}, false
+	}
+	ballot, err := recordBallot(r, h.cfg, qtx, gathering.AssociationID, participant, content)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "failed to create ballot", zap.Error(err))
+		handlers.RespondWithError(w, http.StatusInternalServerError, "failed to submit ballot")
+		return database.GatheringParticipant{}, recordedBallot{}, false
+	}
+	if err := tx.Commit(); err != nil {
+		logging.Logger.Log(zap.WarnLevel, "failed to commit ballot", zap.Error(err))
+		handlers.RespondWithError(w, http.StatusInternalServerError, "failed to submit ballot")
+		return database.GatheringParticipant{}, recordedBallot{}, false
+	}
+	sealBallots(r.Context(), h.cfg, gathering.ID)
+
+	// Auto check-in at submission time (no prior check-in step required)
+	if _, err := services.NewAttendanceService(h.cfg.Db, h.cfg.Conn).CheckIn(r.Context(), gathering, participant.ID, fmt.Sprintf("member_owner_%d", inv.OwnerID)); err != nil {
+		logging.Logger.Log(zap.WarnLevel, "failed to check in participant", zap.Error(err))
+	}
+	return participant, ballot, true
+}
+
+// checkMemberDirectVote responds with the problem and reports false when the invitation's owner
+// is represented by a delegate. An owner represented by a delegate votes in person only once the
+// delegation is revoked.
+func checkMemberDirectVote(w http.ResponseWriter, r *http.Request, delegations *services.DelegationService, inv database.MemberInvitation) bool {
+	err := delegations.CheckDirectVote(r.Context(), inv.GatheringID, inv.OwnerID, time.Now())
+	if errors.Is(err, services.ErrOwnerDelegated) {
+		handlers.RespondWithError(w, http.StatusConflict, "your vote is delegated, ask your association to record its revocation first")
+		return false
+	}
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "failed to check delegations", zap.Error(err))
+		handlers.RespondWithError(w, http.StatusInternalServerError, "failed to check delegations")
+		return false
+	}
+	return true
+}
+
+// createParticipant registers the invitation's owner as a participant for their available units
+// with the given queries, and responds with the problem when it cannot. It reports whether the
+// owner may vote.
+func (h *MemberBallotHandler) createParticipant(w http.ResponseWriter, r *http.Request, q *database.Queries, gathering database.Gathering, inv database.MemberInvitation) (database.GatheringParticipant, bool) {
+	owner, err := q.GetOwnerById(r.Context(), inv.OwnerID)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "failed to get owner", zap.Error(err))
+		handlers.RespondWithError(w, http.StatusInternalServerError, "failed to load owner")
+		return database.GatheringParticipant{}, false
+	}
+
+	eligibleRows, err := services.NewVoterRegisterService(q).EligibleVoters(r.Context(), gathering)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "failed to get eligible units", zap.Error(err))
+		handlers.RespondWithError(w, http.StatusInternalServerError, "failed to load units")
+		return database.GatheringParticipant{}, false
+	}
+
+	var unitIDs []int64
+	totalArea := 0.0
+	totalWeight := 0.0
+	for _, row := range eligibleRows {
+		if row.OwnerID == inv.OwnerID && row.IsAvailable == 1 {
+			unitIDs = append(unitIDs, row.UnitID)
+			totalArea += row.Area * row.Share
+			totalWeight += row.VotingWeight
+		}
+	}
+	if len(unitIDs) == 0 {
+		handlers.RespondWithError(w, http.StatusBadRequest, "no available units for voting")
+		return database.GatheringParticipant{}, false
+	}
+
+	unitsJSON, _ := json.Marshal(unitIDs)
+
+	participant, err := q.CreateGatheringParticipant(r.Context(), database.CreateGatheringParticipantParams{
+		GatheringID:               inv.GatheringID,
+		ParticipantType:           "owner",
+		ParticipantName:           owner.Name,
+		ParticipantIdentification: sql.NullString{String: owner.IdentificationNumber, Valid: true},
+		OwnerID:                   sql.NullInt64{Int64: inv.OwnerID, Valid: true},
+		UnitsInfo:                 string(unitsJSON),
+		UnitsArea:                 totalArea,
+		UnitsPart:                 totalWeight,
+	})
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "failed to create participant", zap.Error(err))
+		handlers.RespondWithError(w, http.StatusInternalServerError, "failed to create participant")
+		return database.GatheringParticipant{}, false
+	}
+
+	for _, unitID := range unitIDs {
+		if _, err := q.AssignUnitSlot(r.Context(), database.AssignUnitSlotParams{
+			ParticipantID: participant.ID,
+			GatheringID:   inv.GatheringID,
+			UnitID:        unitID,
+			OwnerID:       inv.OwnerID,
+		}); err != nil {
+			// Slot already claimed by another owner — unit was voted concurrently
+			logging.Logger.Log(zap.WarnLevel, "unit slot already claimed", zap.Error(err), zap.Int64("unit_id", unitID))
+			handlers.RespondWithError(w, http.StatusConflict, "voting rights for this unit have already been exercised")
+			return database.GatheringParticipant{}, false
+		}
+	}
+
+	return participant, true
+}
+
+// HandleStreamMemberResults handles GET /v1/api/member/gatherings/{memberToken}/results/stream.
+// Streams the live results of the invitation's gathering as Server-Sent Events.
+func (h *MemberBallotHandler) HandleStreamMemberResults() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inv, ok := handlers.MemberInvitationFromContext(r.Context())
+		if !ok {
+			handlers.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if inv.RevokedAt != nil {
+			handlers.RespondWithError(w, http.StatusUnauthorized, "invitation has been revoked")
+			return
+		}
+		if time.Now().After(inv.ExpiresAt) {
+			handlers.RespondWithError(w, http.StatusUnauthorized, "invitation has expired")
+			return
+		}
+
+		gathering, err := h.cfg.Db.GetGatheringByID(r.Context(), inv.GatheringID)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "failed to get gathering", zap.Error(err))
+			handlers.RespondWithError(w, http.StatusInternalServerError, "failed to load gathering")
+			return
+		}
+
+		streamLiveResults(w, r, h.liveResults, gathering)
 	}
 }
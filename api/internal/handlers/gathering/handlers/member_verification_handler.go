@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"go.uber.org/zap"
+)
+
+// MemberVerificationHandler handles the second factor of owners voting with their invitation link
+type MemberVerificationHandler struct {
+	cfg                 *handlers.ApiConfig
+	verificationService *services.MemberVerificationService
+}
+
+// NewMemberVerificationHandler creates a new MemberVerificationHandler
+func NewMemberVerificationHandler(cfg *handlers.ApiConfig, verificationService *services.MemberVerificationService) *MemberVerificationHandler {
+	return &MemberVerificationHandler{
+		cfg:                 cfg,
+		verificationService: verificationService,
+	}
+}
+
+// HandleGetMemberVerification returns the second factor a gathering requires of owners voting
+// with their invitation link
+func (h *MemberVerificationHandler) HandleGetMemberVerification() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			return
+		}
+
+		settings, err := h.verificationService.Settings(req.Context(), gathering)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error getting member verification", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get member verification")
+			return
+		}
+		handlers.RespondWithJSON(rw, http.StatusOK, settings)
+	}
+}
+
+// HandleSetMemberVerification sets the second factor a gathering requires of owners voting with
+// their invitation link. It can change until voting opens.
+func (h *MemberVerificationHandler) HandleSetMemberVerification() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		var verificationReq domain.MemberVerificationRequest
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&verificationReq); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			return
+		}
+
+		settings, err := h.verificationService.SetMethod(req.Context(), gathering, verificationReq.Method)
+		switch {
+		case errors.Is(err, services.ErrInvalidVerificationMethod):
+			handlers.RespondWithError(rw, http.StatusBadRequest, err.Error())
+			return
+		case errors.Is(err, services.ErrVerificationMethodFixed):
+			handlers.RespondWithError(rw, http.StatusConflict, "The verification method can only change until voting opens")
+			return
+		case err != nil:
+			logging.Logger.Log(zap.WarnLevel, "Error setting member verification",
+				zap.Int("gathering_id", gatheringID),
+				zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to set member verification")
+			return
+		}
+		handlers.RespondWithJSON(rw, http.StatusOK, settings)
+	}
+}
+
+// HandleGetVerificationStatus tells the member app whether the owner has to verify before
+// submitting a ballot, and where a one-time code can be sent.
+// Route: GET /v1/api/member/gatherings/{memberToken}/verification
+func (h *MemberVerificationHandler) HandleGetVerificationStatus() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inv, ok := handlers.MemberInvitationFromContext(r.Context())
+		if !ok {
+			handlers.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		status, err := h.verificationService.Status(r.Context(), inv, r.Header.Get(handlers.MemberSessionHeader), time.Now())
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "failed to get verification status", zap.Error(err))
+			handlers.RespondWithError(w, http.StatusInternalServerError, "failed to get verification status")
+			return
+		}
+		handlers.RespondWithJSON(w, http.StatusOK, status)
+	}
+}
+
+// HandleSendVerificationCode sends the owner a one-time code by email or SMS.
+// Route: POST /v1/api/member/gatherings/{memberToken}/verification/code
+func (h *MemberVerificationHandler) HandleSendVerificationCode() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inv, ok := handlers.MemberInvitationFromContext(r.Context())
+		if !ok {
+			handlers.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		var codeReq domain.VerificationCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&codeReq); err != nil {
+			handlers.RespondWithError(w, http.StatusBadRequest, "invalid request format")
+			return
+		}
+		if codeReq.Channel != services.VerificationChannelEmail && codeReq.Channel != services.VerificationChannelSMS {
+			handlers.RespondWithError(w, http.StatusBadRequest, "channel must be email or sms")
+			return
+		}
+		if codeReq.Lang == "" {
+			codeReq.Lang = defaultDocumentLanguage
+		}
+		if !slices.Contains(services.SupportedLanguages, codeReq.Lang) {
+			handlers.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("unsupported language, expected one of %s", strings.Join(services.SupportedLanguages, ", ")))
+			return
+		}
+
+		sent, err := h.verificationService.SendCode(r.Context(), inv, codeReq, time.Now())
+		switch {
+		case errors.Is(err, services.ErrVerificationNotUsed):
+			handlers.RespondWithError(w, http.StatusBadRequest, "the gathering does not use one-time codes")
+			return
+		case errors.Is(err, services.ErrVotingNotOpen):
+			handlers.RespondWithError(w, http.StatusBadRequest, "gathering is not active")
+			return
+		case errors.Is(err, services.ErrVerificationChannel):
+			handlers.RespondWithError(w, http.StatusBadRequest, "no contact on record for this channel")
+			return
+		case errors.Is(err, services.ErrVerificationCodeTooSoon):
+			handlers.RespondWithError(w, http.StatusTooManyRequests, err.Error())
+			return
+		case errors.Is(err, services.ErrVerificationLocked):
+			handlers.RespondWithError(w, http.StatusForbidden, "too many failed attempts, contact your association")
+			return
+		case err != nil:
+			logging.Logger.Log(zap.WarnLevel, "failed to send verification code",
+				zap.Int64("invitation_id", inv.ID),
+				zap.Error(err))
+			handlers.RespondWithError(w, http.StatusBadGateway, "failed to send code")
+			return
+		}
+		handlers.RespondWithJSON(w, http.StatusOK, sent)
+	}
+}
+
+// HandleVerify checks the one-time code or PIN of the owner and opens a short-lived session to
+// submit the ballot with.
+// Route: POST /v1/api/member/gatherings/{memberToken}/verification
+func (h *MemberVerificationHandler) HandleVerify() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inv, ok := handlers.MemberInvitationFromContext(r.Context())
+		if !ok {
+			handlers.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		var verifyReq domain.MemberVerifyRequest
+		if err := json.NewDecoder(r.Body).Decode(&verifyReq); err != nil || verifyReq.Code == "" {
+			handlers.RespondWithError(w, http.StatusBadRequest, "code is required")
+			return
+		}
+
+		session, err := h.verificationService.Verify(r.Context(), inv, verifyReq.Code, time.Now())
+		switch {
+		case errors.Is(err, services.ErrVerificationNotUsed):
+			handlers.RespondWithError(w, http.StatusBadRequest, "the gathering does not require verification")
+			return
+		case errors.Is(err, services.ErrVerificationFailed):
+			handlers.RespondWithError(w, http.StatusUnauthorized, err.Error())
+			return
+		case errors.Is(err, services.ErrVerificationLocked):
+			handlers.RespondWithError(w, http.StatusForbidden, "too many failed attempts, contact your association")
+			return
+		case err != nil:
+			logging.Logger.Log(zap.WarnLevel, "failed to verify member",
+				zap.Int64("invitation_id", inv.ID),
+				zap.Error(err))
+			handlers.RespondWithError(w, http.StatusInternalServerError, "failed to verify")
+			return
+		}
+		handlers.RespondWithJSON(w, http.StatusOK, session)
+	}
+}
+
+// MiddlewareVerifiedMember lets a ballot through only from an owner who verified their second
+// factor, when the gathering requires one. It runs after MiddlewareMemberToken.
+func (h *MemberVerificationHandler) MiddlewareVerifiedMember(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inv, ok := handlers.MemberInvitationFromContext(r.Context())
+		if !ok {
+			handlers.RespondWithError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		err := h.verificationService.Authorize(r.Context(), inv, r.Header.Get(handlers.MemberSessionHeader), time.Now())
+		if errors.Is(err, services.ErrVerificationRequired) {
+			handlers.RespondWithError(w, http.StatusForbidden, "verification required")
+			return
+		}
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "failed to authorize member", zap.Error(err))
+			handlers.RespondWithError(w, http.StatusInternalServerError, "failed to authorize")
+			return
+		}
+		handler(w, r)
+	}
+}
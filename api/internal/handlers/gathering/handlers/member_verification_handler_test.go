@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
+)
+
+// TestConcurrentVerificationGuesses tests that guesses of a PIN made at once are counted each, so
+// they lock the invitation after MaxVerificationAttempts, and that the right PIN resets the count
+func TestConcurrentVerificationGuesses(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	mustExec(t, cfg.Conn,
+		`INSERT INTO associations (id, name, address, administrator) VALUES (1, 'Association', 'Street 1', 'Admin')`,
+		`INSERT INTO owners (id, name, normalized_name, identification_number, association_id) VALUES (1, 'Owner', 'owner', '1000000000001', 1)`,
+		`INSERT INTO gatherings (id, association_id, title, description, intent, gathering_date, gathering_type, member_verification)
+			VALUES (1, 1, 'Annual meeting', '', '', '2026-05-12 18:00:00', 'initial', 'pin')`,
+		`INSERT INTO member_invitations (id, gathering_id, owner_id, token_hash, expires_at) VALUES (1, 1, 1, 'token', '2099-01-01 00:00:00')`,
+	)
+	s := services.NewMemberVerificationService(cfg.Db, "secret", nil, nil)
+	inv, err := cfg.Db.GetMemberInvitationByTokenHash(ctx, "token")
+	if err != nil {
+		t.Fatalf("failed to get invitation: %v", err)
+	}
+	pin, err := s.IssuePIN(ctx, cfg.Db, inv)
+	if err != nil {
+		t.Fatalf("IssuePIN() error = %v", err)
+	}
+	wrong := "000000"
+	if pin == wrong {
+		wrong = "111111"
+	}
+	now := time.Now()
+
+	for range services.MaxVerificationAttempts - 1 {
+		if _, err := s.Verify(ctx, inv, wrong, now); !errors.Is(err, services.ErrVerificationFailed) {
+			t.Fatalf("Verify() with a wrong PIN error = %v, want %v", err, services.ErrVerificationFailed)
+		}
+	}
+	if _, err := s.Verify(ctx, inv, pin, now); err != nil {
+		t.Fatalf("Verify() with the right PIN error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	results := make(map[error]int)
+	for range 4 * services.MaxVerificationAttempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Verify(ctx, inv, wrong, now)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case errors.Is(err, services.ErrVerificationFailed):
+				results[services.ErrVerificationFailed]++
+			case errors.Is(err, services.ErrVerificationLocked):
+				results[services.ErrVerificationLocked]++
+			default:
+				t.Errorf("Verify() with a wrong PIN error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := results[services.ErrVerificationFailed]; got != services.MaxVerificationAttempts-1 {
+		t.Errorf("%d guesses rejected before the lock, want %d", got, services.MaxVerificationAttempts-1)
+	}
+	if _, err := s.Verify(ctx, inv, pin, now); !errors.Is(err, services.ErrVerificationLocked) {
+		t.Errorf("Verify() with the right PIN once locked error = %v, want %v", err, services.ErrVerificationLocked)
+	}
+	// Guesses past the limit are refused before they are compared
+	v, err := cfg.Db.GetMemberVerification(ctx, inv.ID)
+	if err != nil {
+		t.Fatalf("failed to get verification: %v", err)
+	}
+	if !v.LockedAt.Valid || v.FailedAttempts != services.MaxVerificationAttempts {
+		t.Errorf("%d attempts compared, locked %v, want %d and locked", v.FailedAttempts, v.LockedAt.Valid, services.MaxVerificationAttempts)
+	}
+}
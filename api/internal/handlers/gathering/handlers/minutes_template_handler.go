@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"github.com/alexmarian/apc/api/office"
+	"go.uber.org/zap"
+)
+
+// maxMinutesTemplateSize bounds the size of an uploaded minutes template
+const maxMinutesTemplateSize = 5 << 20
+
+// minutesTemplateFileField is the multipart form field holding the template
+const minutesTemplateFileField = "file"
+
+// MinutesTemplateHandler handles the template each association's minutes are generated from
+type MinutesTemplateHandler struct {
+	cfg            *handlers.ApiConfig
+	i18nService    *services.I18nService
+	minutesService *services.MinutesService
+}
+
+// NewMinutesTemplateHandler creates a new MinutesTemplateHandler
+func NewMinutesTemplateHandler(cfg *handlers.ApiConfig) *MinutesTemplateHandler {
+	i18nService, err := services.NewI18nService()
+	if err != nil {
+		logging.Logger.Log(zap.ErrorLevel, "Error loading translations", zap.Error(err))
+	}
+	quorumService := services.NewQuorumService(cfg.Db)
+	votingResultsService := services.NewVotingResultsService(cfg.Db, quorumService, services.NewTallyService(cfg.Db))
+	documentService := services.NewDocumentService(cfg.Db, i18nService, votingResultsService)
+	return &MinutesTemplateHandler{
+		cfg:            cfg,
+		i18nService:    i18nService,
+		minutesService: services.NewMinutesService(cfg.Db, i18nService, documentService),
+	}
+}
+
+// HandleGetMinutesTemplate describes the association's minutes template and the placeholders
+// a template can use
+func (h *MinutesTemplateHandler) HandleGetMinutesTemplate() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+
+		template, err := h.cfg.Db.GetMinutesTemplate(req.Context(), int64(associationID))
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			logging.Logger.Log(zap.WarnLevel, "Error getting minutes template", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get minutes template")
+			return
+		}
+
+		handlers.RespondWithJSON(rw, http.StatusOK, minutesTemplateResponse(template, err == nil))
+	}
+}
+
+// HandleDownloadMinutesTemplate downloads the association's minutes template or, when it has
+// none, the default template in the format and language given by ?format and ?lang
+func (h *MinutesTemplateHandler) HandleDownloadMinutesTemplate() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+
+		template, err := h.cfg.Db.GetMinutesTemplate(req.Context(), int64(associationID))
+		if err == nil {
+			format := office.Format(template.Format)
+			respondWithFile(rw, template.FileName, format.ContentType(), template.Content)
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			logging.Logger.Log(zap.WarnLevel, "Error getting minutes template", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get minutes template")
+			return
+		}
+
+		lang := req.URL.Query().Get("lang")
+		if lang == "" {
+			lang = defaultDocumentLanguage
+		}
+		if !h.i18nService.Supports(lang) {
+			handlers.RespondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Unsupported language, expected one of %s", strings.Join(services.SupportedLanguages, ", ")))
+			return
+		}
+		format, ok := minutesFormat(rw, req)
+		if !ok {
+			return
+		}
+
+		document, err := h.minutesService.DefaultTemplate(format, lang)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error laying out default minutes template", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to generate minutes template")
+			return
+		}
+		respondWithFile(rw, fmt.Sprintf("minutes-template-%s.%s", lang, format), format.ContentType(), document)
+	}
+}
+
+// HandleUploadMinutesTemplate stores a DOCX or ODT document, sent in the "file" field of a
+// multipart form, as the association's minutes template, replacing any earlier one
+func (h *MinutesTemplateHandler) HandleUploadMinutesTemplate() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+
+		req.Body = http.MaxBytesReader(rw, req.Body, maxMinutesTemplateSize)
+		file, header, err := req.FormFile(minutesTemplateFileField)
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, fmt.Sprintf("A DOCX or ODT file of at most %d MB is required in the %q field", maxMinutesTemplateSize>>20, minutesTemplateFileField))
+			return
+		}
+		defer file.Close()
+
+		content, err := io.ReadAll(file)
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Failed to read the uploaded file")
+			return
+		}
+		format, err := services.ValidateMinutesTemplate(content)
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// The file keeps its name but gets the extension of its actual format
+		name := strings.TrimSuffix(filepath.Base(header.Filename), filepath.Ext(header.Filename))
+		name = strings.ReplaceAll(name, `"`, "")
+		if name == "" || name == "." {
+			name = "minutes-template"
+		}
+
+		template, err := h.cfg.Db.UpsertMinutesTemplate(req.Context(), database.UpsertMinutesTemplateParams{
+			AssociationID: int64(associationID),
+			FileName:      name + "." + string(format),
+			Format:        string(format),
+			Content:       content,
+		})
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error saving minutes template", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to save minutes template")
+			return
+		}
+
+		handlers.RespondWithJSON(rw, http.StatusOK, minutesTemplateResponse(template, true))
+	}
+}
+
+// HandleDeleteMinutesTemplate deletes the association's minutes template, going back to the
+// default one
+func (h *MinutesTemplateHandler) HandleDeleteMinutesTemplate() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+
+		deleted, err := h.cfg.Db.DeleteMinutesTemplate(req.Context(), int64(associationID))
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error deleting minutes template", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to delete minutes template")
+			return
+		}
+		if deleted == 0 {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Minutes template not found")
+			return
+		}
+
+		handlers.RespondWithJSON(rw, http.StatusOK, map[string]string{"result": "success"})
+	}
+}
+
+// minutesTemplateResponse describes a stored minutes template, or the default one when custom
+// is false
+func minutesTemplateResponse(template database.MinutesTemplate, custom bool) domain.MinutesTemplate {
+	response := domain.MinutesTemplate{
+		Custom:            custom,
+		TextPlaceholders:  services.MinutesTextPlaceholders,
+		BlockPlaceholders: services.MinutesBlockPlaceholders,
+	}
+	if custom {
+		response.FileName = template.FileName
+		response.Format = template.Format
+		response.UpdatedAt = &template.UpdatedAt
+	}
+	return response
+}
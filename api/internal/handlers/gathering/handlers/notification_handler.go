@@ -1,68 +1,201 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/alexmarian/apc/api/internal/database"
 	"github.com/alexmarian/apc/api/internal/handlers"
 	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"go.uber.org/zap"
 )
 
 // NotificationHandler handles notification operations
 type NotificationHandler struct {
-	cfg *handlers.ApiConfig
+	cfg                 *handlers.ApiConfig
+	notificationService *services.NotificationService
+	reminderService     *services.ReminderService
 }
 
 // NewNotificationHandler creates a new NotificationHandler
-func NewNotificationHandler(cfg *handlers.ApiConfig) *NotificationHandler {
+func NewNotificationHandler(cfg *handlers.ApiConfig, notificationService *services.NotificationService, reminderService *services.ReminderService) *NotificationHandler {
 	return &NotificationHandler{
-		cfg: cfg,
+		cfg:                 cfg,
+		notificationService: notificationService,
+		reminderService:     reminderService,
 	}
 }
 
-// HandleSendNotification sends notifications to owners
+// HandleSendNotification emails an invitation, a reminder or the results to owners. The emails
+// are queued and delivered in the background; the response lists them as pending, or failed
+// for owners without an email address.
 func (h *NotificationHandler) HandleSendNotification() func(http.ResponseWriter, *http.Request) {
 	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
 		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
 
-		var notifyReq struct {
-			NotificationType string  `json:"notification_type"` // invitation, reminder, results
-			OwnerIDs         []int64 `json:"owner_ids,omitempty"`
-			SendVia          string  `json:"send_via"` // email, sms
-		}
-
+		var notifyReq domain.SendNotificationRequest
 		decoder := json.NewDecoder(req.Body)
 		if err := decoder.Decode(&notifyReq); err != nil {
 			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
 			return
 		}
+		if notifyReq.SendVia != "" && notifyReq.SendVia != "email" {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Only email notifications are supported")
+			return
+		}
+		if notifyReq.Lang == "" {
+			notifyReq.Lang = defaultDocumentLanguage
+		}
+		if !slices.Contains(services.SupportedLanguages, notifyReq.Lang) {
+			handlers.RespondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Unsupported language, expected one of %s", strings.Join(services.SupportedLanguages, ", ")))
+			return
+		}
 
-		// If no specific owners, could get non-participating owners here
-		// (implementation depends on specific notification requirements)
-
-		// Send notifications
-		var sent []database.VotingNotification
-		for _, ownerID := range notifyReq.OwnerIDs {
-			notification, err := h.cfg.Db.CreateNotification(req.Context(), database.CreateNotificationParams{
-				GatheringID:      int64(gatheringID),
-				OwnerID:          ownerID,
-				NotificationType: notifyReq.NotificationType,
-				SentVia:          sql.NullString{String: notifyReq.SendVia, Valid: true},
-			})
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			return
+		}
 
-			if err == nil {
-				sent = append(sent, notification)
-				// Here you would actually send the notification via email/SMS
-			}
+		deliveries, err := h.notificationService.Send(req.Context(), gathering, notifyReq)
+		switch {
+		case errors.Is(err, services.ErrUnknownNotificationType):
+			handlers.RespondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Invalid notification type, expected one of %s", strings.Join(services.NotificationTypes, ", ")))
+			return
+		case errors.Is(err, services.ErrNotificationNotDue):
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invitations and reminders are sent for published or active gatherings, results for tallied ones")
+			return
+		case errors.Is(err, services.ErrOwnerNotOnRegister):
+			handlers.RespondWithError(rw, http.StatusBadRequest, err.Error())
+			return
+		case err != nil:
+			logging.Logger.Log(zap.WarnLevel, "Error sending notifications",
+				zap.Int("gathering_id", gatheringID),
+				zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to send notifications")
+			return
 		}
 
 		handlers.RespondWithJSON(rw, http.StatusOK, map[string]interface{}{
-			"sent_count":    len(sent),
-			"notifications": sent,
+			"sent_count":    len(deliveries),
+			"notifications": deliveries,
+		})
+	}
+}
+
+// HandleGetNotifications lists the notification emails of a gathering, newest first, with how
+// their delivery went
+func (h *NotificationHandler) HandleGetNotifications() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		_, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			return
+		}
+
+		rows, err := h.cfg.Db.GetNotificationDeliveries(req.Context(), int64(gatheringID))
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error getting notification deliveries", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get notifications")
+			return
+		}
+
+		deliveries := make([]domain.NotificationDelivery, len(rows))
+		for i, row := range rows {
+			deliveries[i] = domain.DBNotificationDeliveryToResponse(row)
+		}
+		handlers.RespondWithJSON(rw, http.StatusOK, deliveries)
+	}
+}
+
+// HandleGetReminders returns the automatic reminders of a gathering and how their delivery went
+func (h *NotificationHandler) HandleGetReminders() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
 		})
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			return
+		}
+
+		campaign, err := h.reminderService.Campaign(req.Context(), gathering)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error getting reminder campaign", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get reminders")
+			return
+		}
+		handlers.RespondWithJSON(rw, http.StatusOK, campaign)
+	}
+}
+
+// HandleSetReminders schedules the reminders sent to owners who have not voted, replacing those
+// not sent yet. An empty list cancels them.
+func (h *NotificationHandler) HandleSetReminders() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		var scheduleReq domain.ReminderScheduleRequest
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&scheduleReq); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+		if scheduleReq.Lang == "" {
+			scheduleReq.Lang = defaultDocumentLanguage
+		}
+		if !slices.Contains(services.SupportedLanguages, scheduleReq.Lang) {
+			handlers.RespondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Unsupported language, expected one of %s", strings.Join(services.SupportedLanguages, ", ")))
+			return
+		}
+
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			return
+		}
+
+		campaign, err := h.reminderService.SetSchedules(req.Context(), gathering, scheduleReq)
+		switch {
+		case errors.Is(err, services.ErrInvalidReminderSchedule):
+			handlers.RespondWithError(rw, http.StatusBadRequest, err.Error())
+			return
+		case errors.Is(err, services.ErrRemindersClosed):
+			handlers.RespondWithError(rw, http.StatusConflict, "Reminders can only be scheduled until voting closes")
+			return
+		case err != nil:
+			logging.Logger.Log(zap.WarnLevel, "Error scheduling reminders",
+				zap.Int("gathering_id", gatheringID),
+				zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to schedule reminders")
+			return
+		}
+		handlers.RespondWithJSON(rw, http.StatusOK, campaign)
 	}
 }
 
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
@@ -18,17 +21,25 @@ import (
 
 // ParticipantHandler handles participant operations
 type ParticipantHandler struct {
-	cfg              *handlers.ApiConfig
-	gatheringHandler *GatheringHandler
-	statsService     *services.StatsService
+	cfg                  *handlers.ApiConfig
+	gatheringHandler     *GatheringHandler
+	statsService         *services.StatsService
+	voterRegisterService *services.VoterRegisterService
+	liveResults          *services.LiveResults
+	delegationService    *services.DelegationService
+	attendanceService    *services.AttendanceService
 }
 
 // NewParticipantHandler creates a new ParticipantHandler
-func NewParticipantHandler(cfg *handlers.ApiConfig, gatheringHandler *GatheringHandler) *ParticipantHandler {
+func NewParticipantHandler(cfg *handlers.ApiConfig, gatheringHandler *GatheringHandler, liveResults *services.LiveResults, delegationService *services.DelegationService) *ParticipantHandler {
 	return &ParticipantHandler{
-		cfg:              cfg,
-		gatheringHandler: gatheringHandler,
-		statsService:     services.NewStatsService(cfg.Db),
+		cfg:                  cfg,
+		gatheringHandler:     gatheringHandler,
+		statsService:         services.NewStatsService(cfg.Db),
+		voterRegisterService: services.NewVoterRegisterService(cfg.Db),
+		liveResults:          liveResults,
+		delegationService:    delegationService,
+		attendanceService:    services.NewAttendanceService(cfg.Db, cfg.Conn),
 	}
 }
 
@@ -64,7 +75,8 @@ func (h *ParticipantHandler) HandleGetParticipants() func(http.ResponseWriter, *
 	}
 }
 
-// HandleAddParticipant adds a participant to a gathering
+// HandleAddParticipant adds a participant to a gathering. A delegate takes part under a delegation
+// from the gathering's registry, for the units it covers.
 func (h *ParticipantHandler) HandleAddParticipant() func(http.ResponseWriter, *http.Request) {
 	return func(rw http.ResponseWriter, req *http.Request) {
 		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
@@ -77,11 +89,11 @@ func (h *ParticipantHandler) HandleAddParticipant() func(http.ResponseWriter, *h
 		}
 
 		var addReq struct {
-			ParticipantType       string  `json:"participant_type"`
-			OwnerID               int64   `json:"owner_id"`
-			UnitIDs               []int64 `json:"unit_ids"`
-			DelegatingOwnerID     *int64  `json:"delegating_owner_id,omitempty"`
-			DelegationDocumentRef string  `json:"delegation_document_ref,omitempty"`
+			ParticipantType   string  `json:"participant_type"`
+			OwnerID           int64   `json:"owner_id"`
+			UnitIDs           []int64 `json:"unit_ids"`
+			DelegationID      *int64  `json:"delegation_id,omitempty"`
+			DelegatingOwnerID *int64  `json:"delegating_owner_id,omitempty"` // Needed when the delegation represents several owners, alone it picks the owner's current delegation
 		}
 
 		decoder := json.NewDecoder(req.Body)
@@ -111,31 +123,13 @@ func (h *ParticipantHandler) HandleAddParticipant() func(http.ResponseWriter, *h
 
 		// Use the filtered unit IDs
 		addReq.UnitIDs = validUnitIDs
-		var effectiveOwnerID int64 = 0
-		var participantID string
-		var delegatingOwnerID int64 = 0
-
-		if addReq.ParticipantType == "owner" && addReq.OwnerID != 0 {
-			effectiveOwnerID = addReq.OwnerID
-		} else if addReq.ParticipantType == "delegate" && addReq.DelegatingOwnerID != nil {
-			effectiveOwnerID = *addReq.DelegatingOwnerID
-			delegatingOwnerID = *addReq.DelegatingOwnerID
-			participantID = addReq.DelegationDocumentRef
-		} else {
-			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid participant type or owner ID")
-			return
-		}
-
-		owner, err := h.cfg.Db.GetOwnerById(req.Context(), effectiveOwnerID)
-		if len(participantID) == 0 {
-			participantID = owner.IdentificationNumber
-		}
-		if err != nil {
-			handlers.RespondWithError(rw, http.StatusBadRequest, "Owner not found")
+		isOwner := addReq.ParticipantType == "owner" && addReq.OwnerID != 0
+		isDelegate := addReq.ParticipantType == "delegate" && (addReq.DelegationID != nil || addReq.DelegatingOwnerID != nil)
+		if !isOwner && !isDelegate {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid participant type, owner ID or delegation")
 			return
 		}
 
-		// Get gathering to check qualification rules
 		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
 			ID:            int64(gatheringID),
 			AssociationID: int64(associationID),
@@ -146,44 +140,60 @@ func (h *ParticipantHandler) HandleAddParticipant() func(http.ResponseWriter, *h
 			return
 		}
 
-		// Parse qualification rules
-		var unitTypes []string
-		var floors []int64
-		var entrances []int64
-
-		if gathering.QualificationUnitTypes.Valid {
-			json.Unmarshal([]byte(gathering.QualificationUnitTypes.String), &unitTypes)
+		// The delegations are checked in the transaction recording the participant, so that an
+		// owner and their delegate cannot both take part
+		tx, err := h.cfg.Conn.BeginTx(req.Context(), nil)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error starting transaction", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to create gathering participant")
+			return
 		}
-		if gathering.QualificationFloors.Valid {
-			json.Unmarshal([]byte(gathering.QualificationFloors.String), &floors)
+		defer tx.Rollback()
+		qtx := h.cfg.Db.WithTx(tx)
+		delegations := h.delegationService.WithTx(tx)
+
+		var effectiveOwnerID int64
+		var proxy services.Proxy
+		if isDelegate {
+			proxy, err = delegations.ResolveProxy(req.Context(), gathering, addReq.DelegationID, addReq.DelegatingOwnerID, time.Now())
+			if err != nil {
+				respondWithDelegationError(rw, err, "Failed to check delegation")
+				return
+			}
+			effectiveOwnerID = proxy.OwnerID
+		} else {
+			if err := delegations.CheckDirectVote(req.Context(), gathering.ID, addReq.OwnerID, time.Now()); err != nil {
+				respondWithDelegationError(rw, err, "Failed to check delegations")
+				return
+			}
+			effectiveOwnerID = addReq.OwnerID
 		}
-		if gathering.QualificationEntrances.Valid {
-			json.Unmarshal([]byte(gathering.QualificationEntrances.String), &entrances)
+
+		owner, err := qtx.GetOwnerById(req.Context(), effectiveOwnerID)
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Owner not found")
+			return
 		}
 
-		// Get owner's units that meet qualification criteria
-		ownerQualifiedUnits, err := h.cfg.Db.GetActiveOwnerUnitsForGathering(req.Context(), database.GetActiveOwnerUnitsForGatheringParams{
-			AssociationID: int64(associationID),
-			Column2:       len(unitTypes) > 0,
-			UnitTypes:     unitTypes,
-			Column4:       len(floors) > 0,
-			UnitFloors:    floors,
-			Column6:       len(entrances) > 0,
-			UnitEntrances: entrances,
-		})
+		// Units come from the register frozen at publication, ownership changes since then do not count
+		register, err := h.voterRegisterService.Register(req.Context(), gathering)
 		if err != nil {
-			logging.Logger.Log(zap.WarnLevel, "Error getting owner qualified units", zap.Error(err))
+			logging.Logger.Log(zap.WarnLevel, "Error getting voter register", zap.Error(err))
 			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get owner qualified units")
 			return
 		}
 
-		// Filter units by this specific owner
-		ownersUnits := make(map[int64]database.GetActiveOwnerUnitsForGatheringRow)
-		for _, unit := range ownerQualifiedUnits {
-			if unit.OwnerID == effectiveOwnerID {
+		// Filter units this specific owner votes for under the gathering's co-ownership policy
+		ownersUnits := make(map[int64]domain.RegisterUnit)
+		for _, unit := range register {
+			if isDelegate && !slices.Contains(proxy.UnitIDs, unit.UnitID) {
+				continue
+			}
+			if services.IsVotingOwner(gathering.CoOwnershipPolicy, unit, effectiveOwnerID) {
 				ownersUnits[unit.UnitID] = unit
 			}
 		}
+		strategy := services.ByWeightStrategy{CoOwnership: gathering.CoOwnershipPolicy}
 
 		participationUnits := make([]int64, 0)
 		totalPart := 0.0
@@ -191,23 +201,9 @@ func (h *ParticipantHandler) HandleAddParticipant() func(http.ResponseWriter, *h
 
 		for _, unitID := range addReq.UnitIDs {
 			if _, ok := ownersUnits[unitID]; ok {
-				slot, err := h.cfg.Db.AssignUnitSlot(req.Context(), database.AssignUnitSlotParams{
-					GatheringID:   int64(gatheringID),
-					UnitID:        unitID,
-					ParticipantID: effectiveOwnerID,
-				})
-				if err != nil {
-					logging.Logger.Log(zap.WarnLevel, "Error assigning unit slot", zap.Error(err))
-					continue
-				}
-				if slot.ParticipantID != effectiveOwnerID {
-					logging.Logger.Log(zap.WarnLevel, "Assigned unit slot to different participant",
-						zap.Any("slot_participant_id", slot.ParticipantID),
-						zap.Int64("effective_owner_id", effectiveOwnerID))
-					continue
-				}
-				totalArea += ownersUnits[unitID].Area
-				totalPart += ownersUnits[unitID].VotingWeight
+				share := services.OwnerShare(gathering.CoOwnershipPolicy, ownersUnits[unitID], effectiveOwnerID)
+				totalArea += ownersUnits[unitID].Area * share
+				totalPart += strategy.UnitWeight(ownersUnits[unitID].Part, share)
 				participationUnits = append(participationUnits, unitID)
 			}
 		}
@@ -225,14 +221,19 @@ func (h *ParticipantHandler) HandleAddParticipant() func(http.ResponseWriter, *h
 			return
 		}
 
-		participant, err := h.cfg.Db.CreateGatheringParticipant(req.Context(), database.CreateGatheringParticipantParams{
+		participantName, participantID := owner.Name, owner.IdentificationNumber
+		if isDelegate {
+			participantName, participantID = proxy.Delegation.DelegateName, proxy.Delegation.DelegateIdentification
+		}
+
+		participant, err := qtx.CreateGatheringParticipant(req.Context(), database.CreateGatheringParticipantParams{
 			GatheringID:               int64(gatheringID),
 			ParticipantType:           addReq.ParticipantType,
-			ParticipantName:           owner.Name,
+			ParticipantName:           participantName,
 			ParticipantIdentification: sql.NullString{String: participantID, Valid: true},
-			OwnerID:                   sql.NullInt64{Int64: addReq.OwnerID, Valid: true},
-			DelegatingOwnerID:         sql.NullInt64{Int64: delegatingOwnerID, Valid: addReq.DelegatingOwnerID != nil},
-			DelegationDocumentRef:     sql.NullString{String: addReq.DelegationDocumentRef, Valid: addReq.DelegationDocumentRef != ""},
+			OwnerID:                   sql.NullInt64{Int64: addReq.OwnerID, Valid: isOwner},
+			DelegatingOwnerID:         sql.NullInt64{Int64: effectiveOwnerID, Valid: isDelegate},
+			DelegationDocumentRef:     sql.NullString{String: proxy.Delegation.DocumentRef, Valid: isDelegate},
 			UnitsInfo:                 string(participationUnitsBStr),
 			UnitsPart:                 totalPart,
 			UnitsArea:                 totalArea,
@@ -243,41 +244,122 @@ func (h *ParticipantHandler) HandleAddParticipant() func(http.ResponseWriter, *h
 			return
 		}
 
+		if !assignUnitSlots(rw, req, qtx, participant, participationUnits, effectiveOwnerID) {
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error committing participant", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to create gathering participant")
+			return
+		}
+
 		// Update gathering statistics
 		go h.statsService.UpdateGatheringStats(int64(gatheringID), int64(associationID))
+		go h.liveResults.Publish(int64(gatheringID))
 
 		handlers.RespondWithJSON(rw, http.StatusCreated, domain.DBParticipantToResponse(participant))
 	}
 }
 
-// HandleCheckInParticipant checks in a participant
+// HandleCheckInParticipant checks in a participant arriving at the meeting, or coming back after
+// leaving it
 func (h *ParticipantHandler) HandleCheckInParticipant() func(http.ResponseWriter, *http.Request) {
+	return h.handleAttendance(h.attendanceService.CheckIn, "checked_in")
+}
+
+// HandleCheckOutParticipant records a checked-in participant leaving the meeting. They stop
+// counting toward the quorum, and cannot vote on matters opened while they are away.
+func (h *ParticipantHandler) HandleCheckOutParticipant() func(http.ResponseWriter, *http.Request) {
+	return h.handleAttendance(h.attendanceService.CheckOut, "checked_out")
+}
+
+// handleAttendance records a participant arriving, leaving or coming back
+func (h *ParticipantHandler) handleAttendance(
+	record func(ctx context.Context, gathering database.Gathering, participantID int64, recordedBy string) (database.AttendanceEvent, error),
+	action string,
+) func(http.ResponseWriter, *http.Request) {
 	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
 		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
 		participantID, _ := strconv.Atoi(req.PathValue(domain.ParticipantIDPathValue))
 
-		err := h.cfg.Db.CheckInParticipant(req.Context(), database.CheckInParticipantParams{
-			ID:          int64(participantID),
-			GatheringID: int64(gatheringID),
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
 		})
-
 		if err != nil {
-			logging.Logger.Log(zap.WarnLevel, "Error checking in participant", zap.Error(err))
-			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to check in participant")
+			handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			return
+		}
+
+		userID := handlers.GetUserIdFromContext(req)
+		event, err := record(req.Context(), gathering, int64(participantID), userID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			handlers.RespondWithError(rw, http.StatusNotFound, "Participant not found")
+			return
+		case errors.Is(err, services.ErrAlreadyPresent):
+			handlers.RespondWithError(rw, http.StatusConflict, "Participant is already present")
+			return
+		case errors.Is(err, services.ErrNotPresent):
+			handlers.RespondWithError(rw, http.StatusConflict, "Participant is not checked in or has already left")
+			return
+		case err != nil:
+			logging.Logger.Log(zap.WarnLevel, "Error recording attendance", zap.Error(err), zap.String("action", action))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to record attendance")
 			return
 		}
 
 		// Log audit
-		h.cfg.Db.CreateAuditLog(req.Context(), database.CreateAuditLogParams{
-			GatheringID: int64(gatheringID),
+		services.NewIntegrityService(h.cfg.Db).RecordAudit(req.Context(), database.CreateAuditLogParams{
+			GatheringID: gathering.ID,
 			EntityType:  "participant",
 			EntityID:    int64(participantID),
-			Action:      "checked_in",
-			PerformedBy: sql.NullString{String: req.Context().Value("userID").(string), Valid: true},
+			Action:      action,
+			PerformedBy: sql.NullString{String: userID, Valid: true},
 			IpAddress:   sql.NullString{String: req.RemoteAddr, Valid: true},
-			Details:     sql.NullString{String: fmt.Sprintf(`{"time":"%s"}`, time.Now().Format(time.RFC3339)), Valid: true},
+			Details:     sql.NullString{String: fmt.Sprintf(`{"time":"%s","event":"%s"}`, event.OccurredAt.Format(time.RFC3339), event.EventType), Valid: true},
+		})
+
+		go h.liveResults.Publish(gathering.ID)
+
+		handlers.RespondWithJSON(rw, http.StatusOK, map[string]string{"status": action, "event_type": event.EventType})
+	}
+}
+
+// HandleGetAttendance returns the attendance timeline of a gathering: every arrival, departure and
+// return with the quorum it left the meeting with, and the attendance and quorum at the moment
+// given by ?at= (RFC 3339), now by default
+func (h *ParticipantHandler) HandleGetAttendance() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		at := time.Now()
+		if value := req.URL.Query().Get("at"); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid time, expected RFC 3339")
+				return
+			}
+			at = parsed
+		}
+
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
 		})
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			return
+		}
 
-		handlers.RespondWithJSON(rw, http.StatusOK, map[string]string{"status": "checked_in"})
+		timeline, err := h.attendanceService.Timeline(req.Context(), gathering, at)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error getting attendance", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get attendance")
+			return
+		}
+		handlers.RespondWithJSON(rw, http.StatusOK, timeline)
 	}
 }
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/qualification"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"go.uber.org/zap"
+)
+
+// QualificationHandler handles qualification rules outside of a gathering
+type QualificationHandler struct {
+	cfg *handlers.ApiConfig
+}
+
+// NewQualificationHandler creates a new QualificationHandler
+func NewQualificationHandler(cfg *handlers.ApiConfig) *QualificationHandler {
+	return &QualificationHandler{cfg: cfg}
+}
+
+// HandleDryRun returns the units that qualification criteria select, without creating a gathering
+func (h *QualificationHandler) HandleDryRun() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+
+		var dryRunReq domain.QualificationDryRunRequest
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&dryRunReq); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+
+		rule, err := qualification.Parse(dryRunReq.Rule)
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		units, err := h.cfg.Db.GetQualifiedUnits(req.Context(), database.GetQualifiedUnitsParams{
+			AssociationID: int64(associationID),
+			Column2:       len(dryRunReq.UnitTypes) > 0,
+			UnitTypes:     dryRunReq.UnitTypes,
+			Column4:       len(dryRunReq.Floors) > 0,
+			UnitFloors:    dryRunReq.Floors,
+			Column6:       len(dryRunReq.Entrances) > 0,
+			UnitEntrances: dryRunReq.Entrances,
+		})
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error getting qualified units", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get qualified units")
+			return
+		}
+
+		result := domain.QualificationDryRunResult{
+			Units:      make([]domain.QualificationDryRunUnit, 0),
+			Attributes: qualification.Attributes(),
+		}
+		for _, u := range services.FilterQualifiedUnits(units, rule) {
+			result.Units = append(result.Units, domain.QualificationDryRunUnit{
+				ID:              u.ID,
+				UnitNumber:      u.UnitNumber,
+				CadastralNumber: u.CadastralNumber,
+				UnitType:        u.UnitType,
+				Floor:           u.Floor,
+				Entrance:        u.Entrance,
+				RoomCount:       u.RoomCount,
+				Area:            u.Area,
+				Part:            u.Part,
+				BuildingName:    u.BuildingName,
+			})
+			result.TotalPart += u.Part
+			result.TotalArea += u.Area
+		}
+		result.Count = len(result.Units)
+		result.TotalPart = services.RoundTo3Decimals(result.TotalPart)
+		result.TotalArea = services.RoundTo3Decimals(result.TotalArea)
+
+		handlers.RespondWithJSON(rw, http.StatusOK, result)
+	}
+}
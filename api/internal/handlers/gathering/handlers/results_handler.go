@@ -1,10 +1,16 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 
+	"github.com/alexmarian/apc/api/internal/auth"
 	"github.com/alexmarian/apc/api/internal/database"
 	"github.com/alexmarian/apc/api/internal/handlers"
 	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
@@ -19,10 +25,12 @@ type ResultsHandler struct {
 	quorumService        *services.QuorumService
 	tallyService         *services.TallyService
 	votingResultsService *services.VotingResultsService
+	liveResults          *services.LiveResults
+	voterRegisterService *services.VoterRegisterService
 }
 
 // NewResultsHandler creates a new ResultsHandler
-func NewResultsHandler(cfg *handlers.ApiConfig) *ResultsHandler {
+func NewResultsHandler(cfg *handlers.ApiConfig, liveResults *services.LiveResults) *ResultsHandler {
 	quorumService := services.NewQuorumService(cfg.Db)
 	tallyService := services.NewTallyService(cfg.Db)
 
@@ -31,6 +39,8 @@ func NewResultsHandler(cfg *handlers.ApiConfig) *ResultsHandler {
 		quorumService:        quorumService,
 		tallyService:         tallyService,
 		votingResultsService: services.NewVotingResultsService(cfg.Db, quorumService, tallyService),
+		liveResults:          liveResults,
+		voterRegisterService: services.NewVoterRegisterService(cfg.Db),
 	}
 }
 
@@ -54,6 +64,124 @@ func (h *ResultsHandler) HandleGetVoteResults() func(http.ResponseWriter, *http.
 	}
 }
 
+// HandleStreamResults streams participation and running tallies as Server-Sent Events
+func (h *ResultsHandler) HandleStreamResults() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+				return
+			}
+			logging.Logger.Log(zap.WarnLevel, "Error getting gathering", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get gathering")
+			return
+		}
+
+		streamLiveResults(rw, req, h.liveResults, gathering)
+	}
+}
+
+// streamTokenLifetime is how long a stream token can be used to open the live results stream.
+// Clients ask for a new one when the stream has to reconnect after that.
+const streamTokenLifetime = 2 * time.Minute
+
+// HandleCreateStreamToken issues a short-lived token that opens the gathering's live results
+// stream when passed as the token query parameter, for browsers whose EventSource cannot send
+// an Authorization header
+func (h *ResultsHandler) HandleCreateStreamToken() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		_, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+				return
+			}
+			logging.Logger.Log(zap.WarnLevel, "Error getting gathering", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get gathering")
+			return
+		}
+
+		token, expiresAt, err := auth.MakeStreamToken(handlers.GetUserIdFromContext(req), h.cfg.Secret, int64(gatheringID), streamTokenLifetime)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error creating stream token", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to create stream token")
+			return
+		}
+		handlers.RespondWithJSON(rw, http.StatusCreated, map[string]interface{}{
+			"token":      token,
+			"expires_at": expiresAt.UTC(),
+		})
+	}
+}
+
+// MiddlewareStreamToken authenticates a live results stream opened with the token query parameter
+// issued by HandleCreateStreamToken. Requests without one are authenticated by their bearer token
+// like any other association resource.
+func (h *ResultsHandler) MiddlewareStreamToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		token := req.URL.Query().Get("token")
+		if token == "" {
+			h.cfg.MiddlewareAssociationResource(next)(rw, req)
+			return
+		}
+
+		gatheringID, _ := strconv.ParseInt(req.PathValue(domain.GatheringIDPathValue), 10, 64)
+		userLogin, err := auth.ValidateStreamToken(token, h.cfg.Secret, gatheringID)
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusUnauthorized, "invalid or expired stream token")
+			return
+		}
+		next(rw, handlers.AddUserIdToContext(req, userLogin))
+	}
+}
+
+// HandleCheckIntegrity re-walks the gathering's ballot and audit log chains and reports the first
+// broken link, so the counting commission can certify integrity before tallying
+func (h *ResultsHandler) HandleCheckIntegrity() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		_, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+				return
+			}
+			logging.Logger.Log(zap.WarnLevel, "Error getting gathering", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get gathering")
+			return
+		}
+
+		report, err := services.NewIntegrityService(h.cfg.Db).Check(req.Context(), int64(gatheringID))
+		if err != nil {
+			logging.Logger.Log(zap.ErrorLevel, "Error checking gathering integrity",
+				zap.Int("gathering_id", gatheringID),
+				zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to check integrity")
+			return
+		}
+
+		handlers.RespondWithJSON(rw, http.StatusOK, report)
+	}
+}
+
 // HandleGetGatheringStats returns statistics for a gathering
 func (h *ResultsHandler) HandleGetGatheringStats() func(http.ResponseWriter, *http.Request) {
 	return func(rw http.ResponseWriter, req *http.Request) {
@@ -141,11 +269,16 @@ func (h *ResultsHandler) HandleGetEligibleVoters() func(http.ResponseWriter, *ht
 		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
 		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
 
-		rows, err := h.cfg.Db.GetEligibleVotersWithUnits(req.Context(), database.GetEligibleVotersWithUnitsParams{
-			GatheringID:   int64(gatheringID),
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
 			AssociationID: int64(associationID),
 		})
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			return
+		}
 
+		rows, err := h.voterRegisterService.EligibleVoters(req.Context(), gathering)
 		if err != nil {
 			logging.Logger.Log(zap.WarnLevel, "Error getting eligible voters", zap.Error(err))
 			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get eligible voters")
@@ -160,6 +293,7 @@ func (h *ResultsHandler) HandleGetEligibleVoters() func(http.ResponseWriter, *ht
 			Entrance        int64   `json:"entrance"`
 			Area            float64 `json:"area"`
 			VotingWeight    float64 `json:"voting_weight"`
+			Share           float64 `json:"share"` // Of the unit the owner votes for, 1 unless co-owners vote their shares
 			UnitType        string  `json:"unit_type"`
 			BuildingName    string  `json:"building_name"`
 			BuildingAddress string  `json:"building_address"`
@@ -204,6 +338,7 @@ func (h *ResultsHandler) HandleGetEligibleVoters() func(http.ResponseWriter, *ht
 				Entrance:        row.Entrance,
 				Area:            row.Area,
 				VotingWeight:    row.VotingWeight,
+				Share:           row.Share,
 				UnitType:        row.UnitType,
 				BuildingName:    row.BuildingName,
 				BuildingAddress: row.BuildingAddress,
@@ -212,11 +347,11 @@ func (h *ResultsHandler) HandleGetEligibleVoters() func(http.ResponseWriter, *ht
 
 			voter.Units = append(voter.Units, unit)
 			voter.TotalWeight += row.VotingWeight
-			voter.TotalArea += row.Area
+			voter.TotalArea += row.Area * row.Share
 
 			if isAvailable {
 				voter.TotalAvailableWeight += row.VotingWeight
-				voter.TotalAvailableArea += row.Area
+				voter.TotalAvailableArea += row.Area * row.Share
 				voter.HasAvailableUnits = true
 				voter.AvailableUnitsCount++
 			}
@@ -237,7 +372,6 @@ func (h *ResultsHandler) HandleGetQualifiedUnits() func(http.ResponseWriter, *ht
 		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
 		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
 
-		// Get gathering to check qualification rules
 		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
 			ID:            int64(gatheringID),
 			AssociationID: int64(associationID),
@@ -247,35 +381,8 @@ func (h *ResultsHandler) HandleGetQualifiedUnits() func(http.ResponseWriter, *ht
 			return
 		}
 
-		// Parse qualification rules
-		var unitTypes []string
-		var floors []int64
-		var entrances []int64
-
-		if gathering.QualificationUnitTypes.Valid {
-			json.Unmarshal([]byte(gathering.QualificationUnitTypes.String), &unitTypes)
-		}
-		if gathering.QualificationFloors.Valid {
-			json.Unmarshal([]byte(gathering.QualificationFloors.String), &floors)
-		}
-		if gathering.QualificationEntrances.Valid {
-			json.Unmarshal([]byte(gathering.QualificationEntrances.String), &entrances)
-		}
-
-		// Get qualified units with owner information
-		units, err := h.cfg.Db.GetActiveOwnerUnitsForGathering(req.Context(), database.GetActiveOwnerUnitsForGatheringParams{
-			AssociationID: int64(associationID),
-			Column2:       len(unitTypes) > 0,
-			UnitTypes:     unitTypes,
-			Column4:       len(floors) > 0,
-			UnitFloors:    floors,
-			Column6:       len(entrances) > 0,
-			UnitEntrances: entrances,
-		})
-
-		if err != nil {
-			logging.Logger.Log(zap.WarnLevel, "Error getting qualified units", zap.Error(err))
-			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get qualified units")
+		register, slots, ok := h.registerWithSlots(rw, req, gathering, "Failed to get qualified units")
+		if !ok {
 			return
 		}
 
@@ -295,34 +402,28 @@ func (h *ResultsHandler) HandleGetQualifiedUnits() func(http.ResponseWriter, *ht
 			OwnerName       string  `json:"owner_name"`
 		}
 
-		// Get current participants to mark which units are already participating
-		participants, _ := h.cfg.Db.GetGatheringParticipants(req.Context(), int64(gatheringID))
-		participatingUnits := make(map[int64]bool)
-		for _, p := range participants {
-			partUnits := make([]int64, 0)
-			json.Unmarshal([]byte(p.UnitsInfo), &partUnits)
-			for _, unitID := range partUnits {
-				participatingUnits[unitID] = true
+		response := make([]QualifiedUnit, 0, len(register))
+		for _, u := range register {
+			owners := services.VotingOwners(gathering.CoOwnershipPolicy, u)
+			if len(owners) == 0 {
+				continue
 			}
-		}
-
-		response := make([]QualifiedUnit, len(units))
-		for i, u := range units {
-			response[i] = QualifiedUnit{
+			owner := owners[0]
+			response = append(response, QualifiedUnit{
 				ID:              u.UnitID,
 				UnitNumber:      u.UnitNumber,
 				CadastralNumber: u.CadastralNumber,
 				Floor:           int(u.Floor),
 				Entrance:        int(u.Entrance),
 				Area:            u.Area,
-				Part:            u.VotingWeight,
+				Part:            u.Part,
 				UnitType:        u.UnitType,
 				BuildingName:    u.BuildingName,
 				BuildingAddress: u.BuildingAddress,
-				IsParticipating: participatingUnits[u.UnitID],
-				OwnerID:         u.OwnerID,
-				OwnerName:       u.OwnerName,
-			}
+				IsParticipating: slots.Participating(u.UnitID),
+				OwnerID:         owner.OwnerID,
+				OwnerName:       owner.Name,
+			})
 		}
 
 		handlers.RespondWithJSON(rw, http.StatusOK, response)
@@ -335,7 +436,6 @@ func (h *ResultsHandler) HandleGetNonParticipatingOwners() func(http.ResponseWri
 		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
 		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
 
-		// Get gathering qualification rules
 		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
 			ID:            int64(gatheringID),
 			AssociationID: int64(associationID),
@@ -345,36 +445,8 @@ func (h *ResultsHandler) HandleGetNonParticipatingOwners() func(http.ResponseWri
 			return
 		}
 
-		// Parse qualification rules
-		var unitTypes []string
-		var floors []int64
-		var entrances []int64
-
-		if gathering.QualificationUnitTypes.Valid {
-			json.Unmarshal([]byte(gathering.QualificationUnitTypes.String), &unitTypes)
-		}
-		if gathering.QualificationFloors.Valid {
-			json.Unmarshal([]byte(gathering.QualificationFloors.String), &floors)
-		}
-		if gathering.QualificationEntrances.Valid {
-			json.Unmarshal([]byte(gathering.QualificationEntrances.String), &entrances)
-		}
-
-		owners, err := h.cfg.Db.GetNonParticipatingOwners(req.Context(), database.GetNonParticipatingOwnersParams{
-			AssociationID:   int64(associationID),
-			AssociationID_2: int64(associationID),
-			Column3:         len(unitTypes) > 0,
-			UnitTypes:       unitTypes,
-			Column5:         len(floors) > 0,
-			UnitFloors:      floors,
-			Column7:         len(entrances) > 0,
-			UnitEntrances:   entrances,
-			GatheringID:     int64(gatheringID),
-		})
-
-		if err != nil {
-			logging.Logger.Log(zap.WarnLevel, "Error getting non-participating owners", zap.Error(err))
-			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get non-participating owners")
+		register, slots, ok := h.registerWithSlots(rw, req, gathering, "Failed to get non-participating owners")
+		if !ok {
 			return
 		}
 
@@ -387,18 +459,194 @@ func (h *ResultsHandler) HandleGetNonParticipatingOwners() func(http.ResponseWri
 			UnitsCount           int    `json:"units_count"`
 		}
 
-		response := make([]NonParticipatingOwner, len(owners))
-		for i, o := range owners {
-			response[i] = NonParticipatingOwner{
-				ID:                   o.ID,
-				Name:                 o.Name,
-				IdentificationNumber: o.IdentificationNumber,
-				ContactEmail:         o.ContactEmail,
-				ContactPhone:         o.ContactPhone,
-				UnitsCount:           int(o.UnitsCount),
+		// Every registered owner of a unit nobody has participated for yet is listed, or every
+		// co-owner who has not voted their share yet
+		byOwner := make(map[int64]*NonParticipatingOwner)
+		for _, u := range register {
+			for _, o := range u.Owners {
+				if slots.HasSlot(u.UnitID) && !slots.Available(u.UnitID, o.OwnerID) {
+					continue
+				}
+				owner, seen := byOwner[o.OwnerID]
+				if !seen {
+					owner = &NonParticipatingOwner{
+						ID:                   o.OwnerID,
+						Name:                 o.Name,
+						IdentificationNumber: o.Identification,
+						ContactEmail:         o.ContactEmail,
+						ContactPhone:         o.ContactPhone,
+					}
+					byOwner[o.OwnerID] = owner
+				}
+				owner.UnitsCount++
 			}
 		}
 
+		response := make([]NonParticipatingOwner, 0, len(byOwner))
+		for _, owner := range byOwner {
+			response = append(response, *owner)
+		}
+		sort.Slice(response, func(i, j int) bool {
+			if response[i].Name != response[j].Name {
+				return response[i].Name < response[j].Name
+			}
+			return response[i].ID < response[j].ID
+		})
+
 		handlers.RespondWithJSON(rw, http.StatusOK, response)
 	}
 }
+
+// HandleGetVoterRegister returns the gathering's voter register, frozen at publication
+func (h *ResultsHandler) HandleGetVoterRegister() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			return
+		}
+
+		units, err := h.voterRegisterService.Register(req.Context(), gathering)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error getting voter register",
+				zap.Int("gathering_id", gatheringID),
+				zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get voter register")
+			return
+		}
+
+		register := domain.VoterRegister{
+			GatheringID: gathering.ID,
+			Units:       units,
+		}
+		if services.IsRegisterFrozen(gathering) {
+			register.FrozenAt = &gathering.RegisterFrozenAt.Time
+		}
+		for _, u := range units {
+			register.TotalPart += u.Part
+			register.TotalArea += u.Area
+		}
+		register.TotalPart = services.RoundTo3Decimals(register.TotalPart)
+		register.TotalArea = services.RoundTo3Decimals(register.TotalArea)
+
+		handlers.RespondWithJSON(rw, http.StatusOK, register)
+	}
+}
+
+// HandleGetVoterRegisterDiff returns how the live register has drifted from the one frozen at publication
+func (h *ResultsHandler) HandleGetVoterRegisterDiff() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			return
+		}
+
+		diff, err := h.voterRegisterService.Diff(req.Context(), gathering)
+		if err != nil {
+			if errors.Is(err, services.ErrRegisterNotFrozen) {
+				handlers.RespondWithError(rw, http.StatusConflict, "Voter register is not frozen until the gathering is published")
+				return
+			}
+			logging.Logger.Log(zap.WarnLevel, "Error comparing voter register",
+				zap.Int("gathering_id", gatheringID),
+				zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to compare voter register")
+			return
+		}
+
+		handlers.RespondWithJSON(rw, http.StatusOK, diff)
+	}
+}
+
+// registerWithSlots loads the gathering's voter register and the availability of its unit slots,
+// responding with errorMessage if either cannot be read
+func (h *ResultsHandler) registerWithSlots(rw http.ResponseWriter, req *http.Request, gathering database.Gathering, errorMessage string) ([]domain.RegisterUnit, services.SlotAvailability, bool) {
+	register, err := h.voterRegisterService.Register(req.Context(), gathering)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error getting voter register",
+			zap.Int64("gathering_id", gathering.ID),
+			zap.Error(err))
+		handlers.RespondWithError(rw, http.StatusInternalServerError, errorMessage)
+		return nil, nil, false
+	}
+	slots, err := h.voterRegisterService.AvailableSlots(req.Context(), gathering.ID)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error getting unit slots",
+			zap.Int64("gathering_id", gathering.ID),
+			zap.Error(err))
+		handlers.RespondWithError(rw, http.StatusInternalServerError, errorMessage)
+		return nil, nil, false
+	}
+	return register, slots, true
+}
+
+// liveResultsKeepAlive is how often an idle stream sends a comment so proxies keep it open
+const liveResultsKeepAlive = 25 * time.Second
+
+// streamLiveResults sends the gathering's current results, then every update until the client
+// disconnects. Each update is a "results" event whose data is a domain.LiveResults.
+func streamLiveResults(rw http.ResponseWriter, req *http.Request, liveResults *services.LiveResults, gathering database.Gathering) {
+	updates, unsubscribe := liveResults.Subscribe(gathering.ID)
+	defer unsubscribe()
+
+	initial, err := liveResults.Snapshot(req.Context(), gathering)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error building live results", zap.Error(err))
+		handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get live results")
+		return
+	}
+
+	rc := http.NewResponseController(rw)
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.Header().Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+
+	send := func(update domain.LiveResults) error {
+		data, err := json.Marshal(update)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(rw, "event: results\ndata: %s\n\n", data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+	if err := send(initial); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(liveResultsKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case update := <-updates:
+			if err := send(update); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(rw, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
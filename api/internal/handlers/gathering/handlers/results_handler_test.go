@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/auth"
+	"github.com/alexmarian/apc/api/internal/handlers"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
+)
+
+// TestMiddlewareStreamToken tests which tokens open the live results stream of a gathering
+func TestMiddlewareStreamToken(t *testing.T) {
+	const secret = "test-secret"
+	h := &ResultsHandler{cfg: &handlers.ApiConfig{Secret: secret}}
+
+	streamToken := func(gatheringID int64, expiresIn time.Duration) string {
+		token, _, err := auth.MakeStreamToken("chair", secret, gatheringID, expiresIn)
+		if err != nil {
+			t.Fatalf("MakeStreamToken() error = %v", err)
+		}
+		return token
+	}
+	bearerToken, _, err := auth.MakeJWT("chair", secret, time.Hour, false)
+	if err != nil {
+		t.Fatalf("MakeJWT() error = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		header         string
+		expectedStatus int
+	}{
+		{"stream token", "?token=" + streamToken(7, time.Minute), "", http.StatusOK},
+		{"token of another gathering", "?token=" + streamToken(8, time.Minute), "", http.StatusUnauthorized},
+		{"expired token", "?token=" + streamToken(7, -time.Minute), "", http.StatusUnauthorized},
+		{"bearer token in the query", "?token=" + bearerToken, "", http.StatusUnauthorized},
+		{"bearer token in the header", "", "Bearer " + bearerToken, http.StatusOK},
+		{"no token", "", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/results/stream"+tt.query, nil)
+			req.SetPathValue(handlers.AssociationIdPathValue, "1")
+			req.SetPathValue(domain.GatheringIDPathValue, "7")
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rw := httptest.NewRecorder()
+
+			h.MiddlewareStreamToken(func(rw http.ResponseWriter, req *http.Request) {
+				if user := handlers.GetUserIdFromContext(req); user != "chair" {
+					t.Errorf("user = %q, want chair", user)
+				}
+				rw.WriteHeader(http.StatusOK)
+			})(rw, req)
+			if rw.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", rw.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+// TestEligibleVotersFollowRegister tests that a draft gathering lists the voters of the live
+// register, a published one those of its frozen register, and that moving back to draft drops it
+func TestEligibleVotersFollowRegister(t *testing.T) {
+	cfg := newTestConfig(t)
+	mustExec(t, cfg.Conn,
+		`INSERT INTO associations (id, name, address, administrator) VALUES (1, 'Association', 'Street 1', 'Admin')`,
+		`INSERT INTO buildings (id, name, address, cadastral_number, total_area, association_id) VALUES (1, 'Block A', 'Street 1', 'B-1', 100, 1)`,
+		`INSERT INTO units (id, cadastral_number, building_id, unit_number, address, area, part, floor) VALUES (1, 'U-1', 1, '1', 'Street 1', 50, 0.5, 1)`,
+		`INSERT INTO owners (id, name, normalized_name, association_id) VALUES (1, 'Owner', 'owner', 1)`,
+		`INSERT INTO ownerships (unit_id, owner_id, association_id, registration_document, registration_date, is_voting)
+			VALUES (1, 1, 1, 'Deed', '2020-01-01', TRUE)`,
+		`INSERT INTO gatherings (id, association_id, title, description, intent, gathering_date, gathering_type)
+			VALUES (1, 1, 'Annual meeting', '', '', '2026-05-12 18:00:00', 'initial')`,
+		`INSERT INTO voting_matters (id, gathering_id, order_index, title, matter_type, voting_config)
+			VALUES (1, 1, 1, 'Budget', 'budget', '{"type":"yes_no"}')`,
+	)
+	h := NewResultsHandler(cfg, services.NewLiveResults(cfg.Db, services.NewStatsService(cfg.Db)))
+	lifecycle := services.NewLifecycleService(cfg.Db, cfg.Conn, nil, nil)
+
+	eligibleVoters := func() int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.SetPathValue(handlers.AssociationIdPathValue, "1")
+		req.SetPathValue(domain.GatheringIDPathValue, "1")
+		rw := httptest.NewRecorder()
+		h.HandleGetEligibleVoters()(rw, req)
+		if rw.Code != http.StatusOK {
+			t.Fatalf("HandleGetEligibleVoters() status %d, body %s", rw.Code, rw.Body.String())
+		}
+		var voters []json.RawMessage
+		json.Unmarshal(rw.Body.Bytes(), &voters)
+		return len(voters)
+	}
+	transition := func(to string) {
+		t.Helper()
+		gathering, err := cfg.Db.GetGatheringByID(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("failed to get gathering: %v", err)
+		}
+		if _, err := lifecycle.Transition(context.Background(), gathering, to, services.TransitionActor{Trigger: "manual"}); err != nil {
+			t.Fatalf("Transition(%s) error = %v", to, err)
+		}
+	}
+	registeredUnits := func() int {
+		var count int
+		cfg.Conn.QueryRow(`SELECT COUNT(*) FROM voter_register_units WHERE gathering_id = 1`).Scan(&count)
+		return count
+	}
+
+	if got := eligibleVoters(); got != 1 {
+		t.Errorf("draft: %d eligible voters, want 1", got)
+	}
+	transition(services.GatheringStatusPublished)
+	if got := eligibleVoters(); got != 1 || registeredUnits() != 1 {
+		t.Errorf("published: %d eligible voters and %d registered units, want 1 and 1", got, registeredUnits())
+	}
+	transition(services.GatheringStatusDraft)
+	if got := eligibleVoters(); got != 1 || registeredUnits() != 0 {
+		t.Errorf("back to draft: %d eligible voters and %d registered units, want 1 and 0", got, registeredUnits())
+	}
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"go.uber.org/zap"
+)
+
+// TemplateHandler handles gathering templates
+type TemplateHandler struct {
+	cfg              *handlers.ApiConfig
+	gatheringHandler *GatheringHandler
+}
+
+// NewTemplateHandler creates a new TemplateHandler
+func NewTemplateHandler(cfg *handlers.ApiConfig, gatheringHandler *GatheringHandler) *TemplateHandler {
+	return &TemplateHandler{
+		cfg:              cfg,
+		gatheringHandler: gatheringHandler,
+	}
+}
+
+// HandleGetTemplates returns the association's gathering templates, without their agendas
+func (h *TemplateHandler) HandleGetTemplates() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+
+		templates, err := h.cfg.Db.GetGatheringTemplates(req.Context(), int64(associationID))
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error getting gathering templates", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get gathering templates")
+			return
+		}
+
+		response := make([]domain.GatheringTemplate, len(templates))
+		for i, t := range templates {
+			response[i] = domain.DBGatheringTemplateToResponse(t, nil)
+		}
+
+		handlers.RespondWithJSON(rw, http.StatusOK, response)
+	}
+}
+
+// HandleGetTemplate returns a gathering template with its agenda
+func (h *TemplateHandler) HandleGetTemplate() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		template, matters, ok := h.getTemplate(rw, req)
+		if !ok {
+			return
+		}
+
+		handlers.RespondWithJSON(rw, http.StatusOK, domain.DBGatheringTemplateToResponse(template, matters))
+	}
+}
+
+// HandleSaveTemplate saves a gathering's setup and agenda as a new template
+func (h *TemplateHandler) HandleSaveTemplate() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		gatheringID, _ := strconv.Atoi(req.PathValue(domain.GatheringIDPathValue))
+
+		var saveReq domain.SaveTemplateRequest
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&saveReq); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+		saveReq.Name = strings.TrimSpace(saveReq.Name)
+		if saveReq.Name == "" {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Template name is required")
+			return
+		}
+
+		gathering, err := h.cfg.Db.GetGathering(req.Context(), database.GetGatheringParams{
+			ID:            int64(gatheringID),
+			AssociationID: int64(associationID),
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				handlers.RespondWithError(rw, http.StatusNotFound, "Gathering not found")
+			} else {
+				logging.Logger.Log(zap.WarnLevel, "Error getting gathering", zap.Error(err))
+				handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get gathering")
+			}
+			return
+		}
+
+		tx, err := h.cfg.Conn.BeginTx(req.Context(), nil)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error starting transaction", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to save template")
+			return
+		}
+		defer tx.Rollback()
+		qtx := h.cfg.Db.WithTx(tx)
+
+		template, err := qtx.CreateGatheringTemplate(req.Context(), database.CreateGatheringTemplateParams{
+			AssociationID:           gathering.AssociationID,
+			Name:                    saveReq.Name,
+			Title:                   gathering.Title,
+			Description:             gathering.Description,
+			Intent:                  gathering.Intent,
+			Location:                gathering.Location,
+			GatheringType:           gathering.GatheringType,
+			VotingMode:              gathering.VotingMode,
+			QualificationUnitTypes:  gathering.QualificationUnitTypes,
+			QualificationFloors:     gathering.QualificationFloors,
+			QualificationEntrances:  gathering.QualificationEntrances,
+			QualificationCustomRule: gathering.QualificationCustomRule,
+		})
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error creating gathering template", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to save template")
+			return
+		}
+		_, err = qtx.CopyVotingMattersToTemplate(req.Context(), database.CopyVotingMattersToTemplateParams{
+			TemplateID:  template.ID,
+			GatheringID: gathering.ID,
+		})
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error copying voting matters to template", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to save template")
+			return
+		}
+		matters, err := qtx.GetGatheringTemplateMatters(req.Context(), template.ID)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error getting template matters", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to save template")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error committing transaction", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to save template")
+			return
+		}
+
+		handlers.RespondWithJSON(rw, http.StatusCreated, domain.DBGatheringTemplateToResponse(template, matters))
+	}
+}
+
+// HandleDeleteTemplate deletes a gathering template. Gatherings created from it are not affected.
+func (h *TemplateHandler) HandleDeleteTemplate() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+		templateID, _ := strconv.Atoi(req.PathValue(domain.TemplateIDPathValue))
+
+		params := database.DeleteGatheringTemplateParams{
+			ID:            int64(templateID),
+			AssociationID: int64(associationID),
+		}
+
+		tx, err := h.cfg.Conn.BeginTx(req.Context(), nil)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error starting transaction", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to delete template")
+			return
+		}
+		defer tx.Rollback()
+		qtx := h.cfg.Db.WithTx(tx)
+
+		err = qtx.DeleteGatheringTemplateMatters(req.Context(), database.DeleteGatheringTemplateMattersParams{
+			ID:            params.ID,
+			AssociationID: params.AssociationID,
+		})
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error deleting template matters", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to delete template")
+			return
+		}
+		deleted, err := qtx.DeleteGatheringTemplate(req.Context(), params)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error deleting gathering template", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to delete template")
+			return
+		}
+		if deleted == 0 {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Template not found")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error committing transaction", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to delete template")
+			return
+		}
+
+		handlers.RespondWithJSON(rw, http.StatusOK, map[string]string{"result": "success"})
+	}
+}
+
+// HandleCreateGatheringFromTemplate creates a draft gathering with a template's setup and agenda
+func (h *TemplateHandler) HandleCreateGatheringFromTemplate() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+
+		var copyReq domain.CopyGatheringRequest
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&copyReq); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+		if copyReq.GatheringDate.IsZero() {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Gathering date is required")
+			return
+		}
+
+		dbTemplate, dbMatters, ok := h.getTemplate(rw, req)
+		if !ok {
+			return
+		}
+
+		template := domain.DBGatheringTemplateToResponse(dbTemplate, dbMatters)
+		createReq := copyReq.Apply(domain.CreateGatheringRequest{
+			Title:                   template.Title,
+			Description:             template.Description,
+			Intent:                  template.Intent,
+			Location:                template.Location,
+			GatheringType:           template.GatheringType,
+			VotingMode:              template.VotingMode,
+			QualificationUnitTypes:  template.QualificationUnitTypes,
+			QualificationFloors:     template.QualificationFloors,
+			QualificationEntrances:  template.QualificationEntrances,
+			QualificationCustomRule: template.QualificationCustomRule,
+		})
+
+		response, ok := h.gatheringHandler.createGathering(rw, req, int64(associationID), createReq, template.Matters, map[string]int64{"template_id": template.ID})
+		if !ok {
+			return
+		}
+		handlers.RespondWithJSON(rw, http.StatusCreated, response)
+	}
+}
+
+// getTemplate fetches the template named in the request path with its agenda.
+// It writes the error response itself and reports whether the template was found.
+func (h *TemplateHandler) getTemplate(rw http.ResponseWriter, req *http.Request) (database.GatheringTemplate, []database.GatheringTemplateMatter, bool) {
+	associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+	templateID, _ := strconv.Atoi(req.PathValue(domain.TemplateIDPathValue))
+
+	template, err := h.cfg.Db.GetGatheringTemplate(req.Context(), database.GetGatheringTemplateParams{
+		ID:            int64(templateID),
+		AssociationID: int64(associationID),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			handlers.RespondWithError(rw, http.StatusNotFound, "Template not found")
+		} else {
+			logging.Logger.Log(zap.WarnLevel, "Error getting gathering template", zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get template")
+		}
+		return database.GatheringTemplate{}, nil, false
+	}
+
+	matters, err := h.cfg.Db.GetGatheringTemplateMatters(req.Context(), template.ID)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error getting template matters", zap.Error(err))
+		handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get template")
+		return database.GatheringTemplate{}, nil, false
+	}
+	return template, matters, true
+}
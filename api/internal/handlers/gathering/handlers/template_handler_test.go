@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/alexmarian/apc/api/internal/handlers"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
+)
+
+// TestTemplatesCopyGatherings tests that saving a template, creating a gathering from it and
+// cloning a gathering copy the agenda and qualification rules onto new records
+func TestTemplatesCopyGatherings(t *testing.T) {
+	cfg := newTestConfig(t)
+	mustExec(t, cfg.Conn,
+		`INSERT INTO associations (id, name, address, administrator) VALUES (1, 'Association', 'Street 1', 'Admin')`,
+		`INSERT INTO gatherings (id, association_id, title, description, intent, location, gathering_date, gathering_type, voting_mode,
+				qualification_unit_types, qualification_floors, qualification_entrances, qualification_custom_rule)
+			VALUES (1, 1, 'Annual meeting', 'Yearly', 'Approve the budget', 'Hall', '2025-05-12 18:00:00', 'initial', 'by_unit',
+				'["apartment"]', '[1,2]', '[3]', 'unit.area > 40')`,
+		`INSERT INTO voting_matters (id, gathering_id, order_index, title, matter_type, voting_config) VALUES
+			(1, 1, 1, 'Budget', 'budget', '{"type":"yes_no","required_majority":"simple","allow_abstention":true}'),
+			(2, 1, 2, 'Administrator', 'election', '{"type":"ranking","options":[{"id":"a","text":"Ana"},{"id":"b","text":"Ion"}],"ranking_method":"irv"}')`,
+	)
+	gatheringHandler := NewGatheringHandler(cfg, nil, services.NewLifecycleService(cfg.Db, cfg.Conn, nil, nil))
+	h := NewTemplateHandler(cfg, gatheringHandler)
+
+	call := func(handler http.HandlerFunc, body string, pathValues map[string]string, expectedStatus int, response interface{}) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		req = handlers.AddUserIdToContext(req, "admin")
+		req.SetPathValue(handlers.AssociationIdPathValue, "1")
+		for name, value := range pathValues {
+			req.SetPathValue(name, value)
+		}
+		rw := httptest.NewRecorder()
+		handler(rw, req)
+		if rw.Code != expectedStatus {
+			t.Fatalf("status %d, want %d, body %s", rw.Code, expectedStatus, rw.Body.String())
+		}
+		if response != nil {
+			json.Unmarshal(rw.Body.Bytes(), response)
+		}
+	}
+
+	original, _ := cfg.Db.GetGatheringByID(context.Background(), 1)
+	originalMatters, _ := cfg.Db.GetVotingMatters(context.Background(), 1)
+	checkCopy := func(name string, gatheringID int64) {
+		t.Helper()
+		copied, err := cfg.Db.GetGatheringByID(context.Background(), gatheringID)
+		if err != nil {
+			t.Fatalf("%s: failed to get gathering %d: %v", name, gatheringID, err)
+		}
+		if copied.ID == original.ID || copied.VotingMode != original.VotingMode ||
+			copied.QualificationUnitTypes != original.QualificationUnitTypes || copied.QualificationFloors != original.QualificationFloors ||
+			copied.QualificationEntrances != original.QualificationEntrances || copied.QualificationCustomRule != original.QualificationCustomRule {
+			t.Errorf("%s: gathering = %+v, want the setup of %+v", name, copied, original)
+		}
+		matters, _ := cfg.Db.GetVotingMatters(context.Background(), gatheringID)
+		if len(matters) != len(originalMatters) {
+			t.Fatalf("%s: %d matters, want %d", name, len(matters), len(originalMatters))
+		}
+		for i, matter := range matters {
+			want := domain.DBVotingMatterToResponse(originalMatters[i])
+			got := domain.DBVotingMatterToResponse(matter)
+			if got.ID == want.ID || got.GatheringID != gatheringID || got.Title != want.Title || !reflect.DeepEqual(got.VotingConfig, want.VotingConfig) {
+				t.Errorf("%s: matter = %+v, want a copy of %+v", name, got, want)
+			}
+		}
+	}
+
+	var template domain.GatheringTemplate
+	call(h.HandleSaveTemplate(), `{"name":"AGM"}`, map[string]string{domain.GatheringIDPathValue: "1"}, http.StatusCreated, &template)
+	if len(template.Matters) != 2 || template.QualificationCustomRule != "unit.area > 40" || !reflect.DeepEqual(template.QualificationFloors, []int64{1, 2}) {
+		t.Errorf("template = %+v, want the gathering's agenda and qualification rules", template)
+	}
+	for i, matter := range template.Matters {
+		if matter.GatheringID != 0 || matter.Title != originalMatters[i].Title || !reflect.DeepEqual(matter.VotingConfig, domain.DBVotingMatterToResponse(originalMatters[i]).VotingConfig) {
+			t.Errorf("template matter = %+v, want a copy of matter %d", matter, originalMatters[i].ID)
+		}
+	}
+
+	templatePath := map[string]string{domain.TemplateIDPathValue: strconv.FormatInt(template.ID, 10)}
+	call(h.HandleCreateGatheringFromTemplate(), `{"title":"Annual meeting 2026"}`, templatePath, http.StatusBadRequest, nil)
+	var fromTemplate domain.Gathering
+	call(h.HandleCreateGatheringFromTemplate(), `{"title":"Annual meeting 2026","gathering_date":"2026-05-12T18:00:00Z"}`, templatePath, http.StatusCreated, &fromTemplate)
+	checkCopy("from template", fromTemplate.ID)
+
+	clonePath := map[string]string{domain.GatheringIDPathValue: "1"}
+	call(gatheringHandler.HandleCloneGathering(), `{}`, clonePath, http.StatusBadRequest, nil)
+	var clone domain.Gathering
+	call(gatheringHandler.HandleCloneGathering(), `{"gathering_date":"2026-05-12T18:00:00Z"}`, clonePath, http.StatusCreated, &clone)
+	checkCopy("clone", clone.ID)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/alexmarian/apc/api/internal/database"
 	"github.com/alexmarian/apc/api/internal/handlers"
 	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
 	"github.com/alexmarian/apc/api/internal/logging"
 	"go.uber.org/zap"
 )
@@ -67,28 +68,17 @@ func (h *VotingMatterHandler) HandleCreateVotingMatter() func(http.ResponseWrite
 			return
 		}
 
-		// Convert voting config to JSON
-		configJSON, err := json.Marshal(createReq.VotingConfig)
-		if err != nil {
-			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid voting configuration")
+		if err := services.ValidateVotingConfig(createReq.MatterType, createReq.VotingConfig); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		isInformative := int64(0)
-		if createReq.IsInformative {
-			isInformative = 1
+		params, err := votingMatterParams(int64(gatheringID), createReq)
+		if err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid voting configuration")
+			return
 		}
-		matter, err := h.cfg.Db.CreateVotingMatter(req.Context(), database.CreateVotingMatterParams{
-			GatheringID:   int64(gatheringID),
-			OrderIndex:    int64(createReq.OrderIndex),
-			Title:         createReq.Title,
-			TitleRu:       createReq.TitleRu,
-			Description:   sql.NullString{String: createReq.Description, Valid: createReq.Description != ""},
-			DescriptionRu: sql.NullString{String: createReq.DescriptionRu, Valid: createReq.DescriptionRu != ""},
-			MatterType:    createReq.MatterType,
-			VotingConfig:  string(configJSON),
-			IsInformative: isInformative,
-		})
+		matter, err := h.cfg.Db.CreateVotingMatter(req.Context(), params)
 
 		if err != nil {
 			logging.Logger.Log(zap.WarnLevel, "Error creating voting matter", zap.Error(err))
@@ -120,6 +110,11 @@ func (h *VotingMatterHandler) HandleUpdateVotingMatter() func(http.ResponseWrite
 			return
 		}
 
+		if err := services.ValidateVotingConfig(createReq.MatterType, createReq.VotingConfig); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, err.Error())
+			return
+		}
+
 		// Convert voting config to JSON
 		configJSON, err := json.Marshal(createReq.VotingConfig)
 		if err != nil {
@@ -181,3 +176,27 @@ func (h *VotingMatterHandler) HandleDeleteVotingMatter() func(http.ResponseWrite
 		handlers.RespondWithJSON(rw, http.StatusOK, map[string]string{"result": "success"})
 	}
 }
+
+// votingMatterParams builds the insert parameters for a matter on a gathering's agenda
+func votingMatterParams(gatheringID int64, matter domain.VotingMatter) (database.CreateVotingMatterParams, error) {
+	configJSON, err := json.Marshal(matter.VotingConfig)
+	if err != nil {
+		return database.CreateVotingMatterParams{}, err
+	}
+
+	isInformative := int64(0)
+	if matter.IsInformative {
+		isInformative = 1
+	}
+	return database.CreateVotingMatterParams{
+		GatheringID:   gatheringID,
+		OrderIndex:    int64(matter.OrderIndex),
+		Title:         matter.Title,
+		TitleRu:       matter.TitleRu,
+		Description:   sql.NullString{String: matter.Description, Valid: matter.Description != ""},
+		DescriptionRu: sql.NullString{String: matter.DescriptionRu, Valid: matter.DescriptionRu != ""},
+		MatterType:    matter.MatterType,
+		VotingConfig:  string(configJSON),
+		IsInformative: isInformative,
+	}, nil
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/alexmarian/apc/api/internal/handlers"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"go.uber.org/zap"
+)
+
+// VotingRulesHandler handles an association's quorum and majority rules
+type VotingRulesHandler struct {
+	cfg                *handlers.ApiConfig
+	votingRulesService *services.VotingRulesService
+}
+
+// NewVotingRulesHandler creates a new VotingRulesHandler
+func NewVotingRulesHandler(cfg *handlers.ApiConfig) *VotingRulesHandler {
+	return &VotingRulesHandler{
+		cfg:                cfg,
+		votingRulesService: services.NewVotingRulesService(cfg.Db),
+	}
+}
+
+// HandleGetVotingRules returns the association's voting rules, or the default rules if none are stored
+func (h *VotingRulesHandler) HandleGetVotingRules() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+
+		profile, err := h.votingRulesService.GetProfile(req.Context(), int64(associationID))
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error getting voting rules",
+				zap.Int("association_id", associationID),
+				zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to get voting rules")
+			return
+		}
+
+		handlers.RespondWithJSON(rw, http.StatusOK, profile)
+	}
+}
+
+// HandleUpdateVotingRules stores the association's voting rules. Gatherings published
+// earlier keep the rules they were published with.
+func (h *VotingRulesHandler) HandleUpdateVotingRules() func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationID, _ := strconv.Atoi(req.PathValue(handlers.AssociationIdPathValue))
+
+		var rules domain.VotingRules
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&rules); err != nil {
+			handlers.RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+
+		profile, err := h.votingRulesService.SaveProfile(req.Context(), int64(associationID), rules)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidVotingRules) {
+				handlers.RespondWithError(rw, http.StatusBadRequest, err.Error())
+				return
+			}
+			logging.Logger.Log(zap.WarnLevel, "Error saving voting rules",
+				zap.Int("association_id", associationID),
+				zap.Error(err))
+			handlers.RespondWithError(rw, http.StatusInternalServerError, "Failed to save voting rules")
+			return
+		}
+
+		handlers.RespondWithJSON(rw, http.StatusOK, profile)
+	}
+}
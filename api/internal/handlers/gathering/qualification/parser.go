@@ -0,0 +1,303 @@
+package qualification
+
+import "fmt"
+
+// value is the result of evaluating an expression; which field is set depends on its kind
+type value struct {
+	num float64
+	str string
+	b   bool
+}
+
+type node interface {
+	kind() valueKind
+	eval(u Unit) value
+}
+
+type literalNode struct {
+	k valueKind
+	v value
+}
+
+func (n literalNode) kind() valueKind { return n.k }
+func (n literalNode) eval(Unit) value { return n.v }
+
+type attributeNode struct {
+	attr attribute
+}
+
+func (n attributeNode) kind() valueKind   { return n.attr.kind }
+func (n attributeNode) eval(u Unit) value { return n.attr.get(u) }
+
+type notNode struct {
+	x node
+}
+
+func (n notNode) kind() valueKind   { return kindBool }
+func (n notNode) eval(u Unit) value { return value{b: !n.x.eval(u).b} }
+
+type logicNode struct {
+	op   string
+	l, r node
+}
+
+func (n logicNode) kind() valueKind { return kindBool }
+func (n logicNode) eval(u Unit) value {
+	if n.op == "&&" {
+		return value{b: n.l.eval(u).b && n.r.eval(u).b}
+	}
+	return value{b: n.l.eval(u).b || n.r.eval(u).b}
+}
+
+type compareNode struct {
+	op   string
+	k    valueKind // Kind of both operands
+	l, r node
+}
+
+func (n compareNode) kind() valueKind { return kindBool }
+func (n compareNode) eval(u Unit) value {
+	l, r := n.l.eval(u), n.r.eval(u)
+	switch n.k {
+	case kindString:
+		if n.op == "==" {
+			return value{b: l.str == r.str}
+		}
+		return value{b: l.str != r.str}
+	case kindBool:
+		if n.op == "==" {
+			return value{b: l.b == r.b}
+		}
+		return value{b: l.b != r.b}
+	}
+	switch n.op {
+	case "==":
+		return value{b: l.num == r.num}
+	case "!=":
+		return value{b: l.num != r.num}
+	case "<":
+		return value{b: l.num < r.num}
+	case "<=":
+		return value{b: l.num <= r.num}
+	case ">":
+		return value{b: l.num > r.num}
+	default:
+		return value{b: l.num >= r.num}
+	}
+}
+
+type inNode struct {
+	x    node
+	list []value
+}
+
+func (n inNode) kind() valueKind { return kindBool }
+func (n inNode) eval(u Unit) value {
+	x := n.x.eval(u)
+	for _, item := range n.list {
+		if item == x {
+			return value{b: true}
+		}
+	}
+	return value{b: false}
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isOperator(op string) bool {
+	tok := p.peek()
+	return tok.kind == tokenOperator && tok.text == op
+}
+
+// parseOr parses a || b || ...
+func (p *parser) parseOr(depth int) (node, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("||") {
+		op := p.next()
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		if err := expectKind(op, kindBool, left, right); err != nil {
+			return nil, err
+		}
+		left = logicNode{op: op.text, l: left, r: right}
+	}
+	return left, nil
+}
+
+// parseAnd parses a && b && ...
+func (p *parser) parseAnd(depth int) (node, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("&&") {
+		op := p.next()
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		if err := expectKind(op, kindBool, left, right); err != nil {
+			return nil, err
+		}
+		left = logicNode{op: op.text, l: left, r: right}
+	}
+	return left, nil
+}
+
+// parseUnary parses !a or a comparison
+func (p *parser) parseUnary(depth int) (node, error) {
+	if !p.isOperator("!") {
+		return p.parseComparison(depth)
+	}
+	op := p.next()
+	if depth+1 > maxDepth {
+		return nil, &SyntaxError{Pos: op.pos, Msg: "rule is nested too deeply"}
+	}
+	x, err := p.parseUnary(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectKind(op, kindBool, x); err != nil {
+		return nil, err
+	}
+	return notNode{x: x}, nil
+}
+
+// parseComparison parses a, a <op> b or a in [...]
+func (p *parser) parseComparison(depth int) (node, error) {
+	left, err := p.parseOperand(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	if tok.kind == tokenIdent && tok.text == "in" {
+		p.next()
+		return p.parseIn(tok, left)
+	}
+	if tok.kind != tokenOperator {
+		return left, nil
+	}
+	switch tok.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return left, nil
+	}
+	op := p.next()
+	right, err := p.parseOperand(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	if left.kind() != right.kind() {
+		return nil, &SyntaxError{Pos: op.pos, Msg: fmt.Sprintf("cannot compare %s with %s", left.kind(), right.kind())}
+	}
+	if op.text != "==" && op.text != "!=" && left.kind() != kindNumber {
+		return nil, &SyntaxError{Pos: op.pos, Msg: fmt.Sprintf("%s only compares numbers", op.text)}
+	}
+	return compareNode{op: op.text, k: left.kind(), l: left, r: right}, nil
+}
+
+// parseIn parses the list after "in"
+func (p *parser) parseIn(op token, x node) (node, error) {
+	if x.kind() != kindNumber && x.kind() != kindString {
+		return nil, &SyntaxError{Pos: op.pos, Msg: fmt.Sprintf("in needs a number or string, not a %s", x.kind())}
+	}
+	if tok := p.next(); tok.kind != tokenLBracket {
+		return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("expected [ after in, found %s", tok)}
+	}
+
+	var list []value
+	for {
+		tok := p.next()
+		item, ok := literal(tok)
+		if !ok {
+			return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("expected a value, found %s", tok)}
+		}
+		if item.k != x.kind() {
+			return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("list holds a %s, expected a %s", item.k, x.kind())}
+		}
+		list = append(list, item.v)
+
+		tok = p.next()
+		if tok.kind == tokenRBracket {
+			break
+		}
+		if tok.kind != tokenComma {
+			return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("expected , or ], found %s", tok)}
+		}
+	}
+	return inNode{x: x, list: list}, nil
+}
+
+// parseOperand parses a parenthesized expression, an attribute or a literal
+func (p *parser) parseOperand(depth int) (node, error) {
+	tok := p.next()
+	if tok.kind == tokenLParen {
+		if depth+1 > maxDepth {
+			return nil, &SyntaxError{Pos: tok.pos, Msg: "rule is nested too deeply"}
+		}
+		x, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &SyntaxError{Pos: closing.pos, Msg: fmt.Sprintf("expected ), found %s", closing)}
+		}
+		return x, nil
+	}
+	if lit, ok := literal(tok); ok {
+		return lit, nil
+	}
+	if tok.kind == tokenIdent {
+		attr, ok := attributes[tok.text]
+		if !ok {
+			return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("unknown attribute %q", tok.text)}
+		}
+		return attributeNode{attr: attr}, nil
+	}
+	return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("expected a value, found %s", tok)}
+}
+
+// literal converts a number, string or boolean token
+func literal(tok token) (literalNode, bool) {
+	switch {
+	case tok.kind == tokenNumber:
+		return literalNode{k: kindNumber, v: value{num: tok.num}}, true
+	case tok.kind == tokenString:
+		return literalNode{k: kindString, v: value{str: tok.text}}, true
+	case tok.kind == tokenIdent && (tok.text == "true" || tok.text == "false"):
+		return literalNode{k: kindBool, v: value{b: tok.text == "true"}}, true
+	}
+	return literalNode{}, false
+}
+
+// expectKind checks the operands of a logical operator
+func expectKind(op token, k valueKind, operands ...node) error {
+	for _, x := range operands {
+		if x.kind() != k {
+			return &SyntaxError{Pos: op.pos, Msg: fmt.Sprintf("%s needs a %s, not a %s", op.text, k, x.kind())}
+		}
+	}
+	return nil
+}
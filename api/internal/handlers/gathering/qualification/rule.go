@@ -0,0 +1,144 @@
+// Package qualification implements the expression language of custom gathering
+// qualification rules, e.g.
+//
+//	building.name == "Bloc A" && unit.area > 40 && unit.room_count >= 2
+//
+// A rule is a boolean expression over unit and building attributes built from
+// comparisons (==, !=, <, <=, >, >=), membership (unit.type in ["apartment", "commercial"]),
+// !, && and || and parentheses. Rules are type checked when parsed and cannot call
+// functions or loop, so evaluating one is always cheap.
+package qualification
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxRuleLength bounds the size of a rule's source text
+const MaxRuleLength = 1000
+
+// maxDepth bounds expression nesting
+const maxDepth = 32
+
+// Unit holds the attributes a rule can refer to
+type Unit struct {
+	Number          string
+	CadastralNumber string
+	Type            string
+	Floor           int64
+	Entrance        int64
+	Area            float64
+	Part            float64
+	RoomCount       int64 // -1 when unknown
+	BuildingName    string
+	BuildingAddress string
+}
+
+// valueKind is the static type of an expression
+type valueKind int
+
+const (
+	kindNumber valueKind = iota
+	kindString
+	kindBool
+)
+
+func (k valueKind) String() string {
+	switch k {
+	case kindNumber:
+		return "number"
+	case kindString:
+		return "string"
+	default:
+		return "boolean"
+	}
+}
+
+// attribute describes a unit or building attribute available to rules
+type attribute struct {
+	kind valueKind
+	get  func(Unit) value
+}
+
+var attributes = map[string]attribute{
+	"unit.number":           {kindString, func(u Unit) value { return value{str: u.Number} }},
+	"unit.cadastral_number": {kindString, func(u Unit) value { return value{str: u.CadastralNumber} }},
+	"unit.type":             {kindString, func(u Unit) value { return value{str: u.Type} }},
+	"unit.floor":            {kindNumber, func(u Unit) value { return value{num: float64(u.Floor)} }},
+	"unit.entrance":         {kindNumber, func(u Unit) value { return value{num: float64(u.Entrance)} }},
+	"unit.area":             {kindNumber, func(u Unit) value { return value{num: u.Area} }},
+	"unit.part":             {kindNumber, func(u Unit) value { return value{num: u.Part} }},
+	"unit.room_count":       {kindNumber, func(u Unit) value { return value{num: float64(u.RoomCount)} }},
+	"building.name":         {kindString, func(u Unit) value { return value{str: u.BuildingName} }},
+	"building.address":      {kindString, func(u Unit) value { return value{str: u.BuildingAddress} }},
+}
+
+// Attributes lists the attribute names rules can refer to
+func Attributes() []string {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SyntaxError reports an invalid rule and where the problem is
+type SyntaxError struct {
+	Pos int // 1-based character position in the rule
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("qualification rule: %s at position %d", e.Msg, e.Pos)
+}
+
+// Rule is a parsed, type checked qualification rule
+type Rule struct {
+	source string
+	root   node
+}
+
+// Parse parses and type checks a rule. A blank rule parses to nil, which matches every unit.
+func Parse(source string) (*Rule, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, nil
+	}
+	if len(source) > MaxRuleLength {
+		return nil, &SyntaxError{Pos: MaxRuleLength + 1, Msg: fmt.Sprintf("rule is longer than %d characters", MaxRuleLength)}
+	}
+
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", tok)}
+	}
+	if root.kind() != kindBool {
+		return nil, &SyntaxError{Pos: 1, Msg: fmt.Sprintf("rule must be a condition, not a %s", root.kind())}
+	}
+	return &Rule{source: source, root: root}, nil
+}
+
+// Matches reports whether the unit satisfies the rule. A nil rule matches every unit.
+func (r *Rule) Matches(u Unit) bool {
+	if r == nil {
+		return true
+	}
+	return r.root.eval(u).b
+}
+
+// String returns the rule's source text
+func (r *Rule) String() string {
+	if r == nil {
+		return ""
+	}
+	return r.source
+}
@@ -0,0 +1,107 @@
+package qualification
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestRuleMatches tests rule evaluation against a unit
+func TestRuleMatches(t *testing.T) {
+	unit := Unit{
+		Number:       "12",
+		Type:         "apartment",
+		Floor:        3,
+		Entrance:     1,
+		Area:         54.5,
+		Part:         0.0125,
+		RoomCount:    2,
+		BuildingName: "Bloc A",
+	}
+
+	tests := []struct {
+		name    string
+		rule    string
+		matches bool
+	}{
+		{"blank rule", "  ", true},
+		{"request example", `building.name == "Bloc A" && unit.area > 40 && unit.room_count >= 2`, true},
+		{"other building", `building.name == "Bloc B" && unit.area > 40`, false},
+		{"or", `building.name == "Bloc B" || unit.floor == 3`, true},
+		{"not", `!(unit.type == "commercial")`, true},
+		{"not equal", `unit.type != "apartment"`, false},
+		{"in strings", `unit.type in ["commercial", "apartment"]`, true},
+		{"in numbers", `unit.floor in [0, 1, 2]`, false},
+		{"decimal", `unit.part <= 0.0125`, true},
+		{"negative number", `unit.floor > -1`, true},
+		{"precedence", `unit.floor == 0 && unit.area > 40 || unit.entrance == 1`, true},
+		{"parentheses", `unit.floor == 0 && (unit.area > 40 || unit.entrance == 1)`, false},
+		{"escaped quote", `unit.number != "a\"b"`, true},
+		{"boolean literal", `true && unit.room_count < 3`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned error: %v", tt.rule, err)
+			}
+			if got := rule.Matches(unit); got != tt.matches {
+				t.Errorf("Matches() = %v, expected %v", got, tt.matches)
+			}
+		})
+	}
+}
+
+// TestParseErrors tests that invalid rules are rejected with a position
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+		pos  int
+	}{
+		{"unknown attribute", `unit.color == "red"`, 1},
+		{"type mismatch", `unit.area == "large"`, 11},
+		{"ordering strings", `building.name > "A"`, 15},
+		{"not a condition", `unit.area`, 1},
+		{"and on numbers", `unit.area && unit.floor == 1`, 11},
+		{"mixed list", `unit.floor in [1, "2"]`, 19},
+		{"missing bracket", `unit.floor in 1`, 15},
+		{"unclosed parenthesis", `(unit.floor == 1`, 17},
+		{"trailing token", `unit.floor == 1 unit.area`, 17},
+		{"unterminated string", `unit.type == "apartment`, 14},
+		{"unexpected character", `unit.floor = 1`, 12},
+		{"free text", `Owners of the ground floor`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.rule)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse(%q) = %v, expected a SyntaxError", tt.rule, err)
+			}
+			if syntaxErr.Pos != tt.pos {
+				t.Errorf("Parse(%q) error at position %d, expected %d (%v)", tt.rule, syntaxErr.Pos, tt.pos, err)
+			}
+		})
+	}
+}
+
+// TestParseLimits tests the length and nesting limits
+func TestParseLimits(t *testing.T) {
+	long := strings.Repeat(" ", MaxRuleLength) + "true"
+	if _, err := Parse(long); err == nil {
+		t.Error("Parse() accepted a rule longer than MaxRuleLength")
+	}
+
+	nested := strings.Repeat("(", maxDepth+1) + "true" + strings.Repeat(")", maxDepth+1)
+	if _, err := Parse(nested); err == nil {
+		t.Error("Parse() accepted a rule nested deeper than maxDepth")
+	}
+
+	negated := strings.Repeat("!", maxDepth) + "true"
+	if _, err := Parse(negated); err != nil {
+		t.Errorf("Parse() rejected a rule at the nesting limit: %v", err)
+	}
+}
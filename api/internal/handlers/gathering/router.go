@@ -3,35 +3,98 @@ package gathering
 import (
 	"github.com/alexmarian/apc/api/internal/handlers"
 	gatheringHandlers "github.com/alexmarian/apc/api/internal/handlers/gathering/handlers"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"github.com/alexmarian/apc/api/notify"
+	"go.uber.org/zap"
 )
 
 // GatheringRouter provides all gathering-related HTTP handlers
 type GatheringRouter struct {
-	Gathering    *gatheringHandlers.GatheringHandler
-	VotingMatter *gatheringHandlers.VotingMatterHandler
-	Participant  *gatheringHandlers.ParticipantHandler
-	Ballot       *gatheringHandlers.BallotHandler
-	MemberBallot *gatheringHandlers.MemberBallotHandler
-	Results      *gatheringHandlers.ResultsHandler
-	Export       *gatheringHandlers.ExportHandler
-	Notification *gatheringHandlers.NotificationHandler
-	Invitation   *gatheringHandlers.InvitationHandler
+	Gathering          *gatheringHandlers.GatheringHandler
+	VotingMatter       *gatheringHandlers.VotingMatterHandler
+	MatterVoting       *gatheringHandlers.MatterVotingHandler
+	Participant        *gatheringHandlers.ParticipantHandler
+	Ballot             *gatheringHandlers.BallotHandler
+	BallotImport       *gatheringHandlers.BallotImportHandler
+	MemberBallot       *gatheringHandlers.MemberBallotHandler
+	MemberVerification *gatheringHandlers.MemberVerificationHandler
+	CoOwnership        *gatheringHandlers.CoOwnershipHandler
+	Results            *gatheringHandlers.ResultsHandler
+	Export             *gatheringHandlers.ExportHandler
+	Minutes            *gatheringHandlers.MinutesTemplateHandler
+	Notification       *gatheringHandlers.NotificationHandler
+	Invitation         *gatheringHandlers.InvitationHandler
+	Delegation         *gatheringHandlers.DelegationHandler
+	VotingRules        *gatheringHandlers.VotingRulesHandler
+	Template           *gatheringHandlers.TemplateHandler
+	Qualification      *gatheringHandlers.QualificationHandler
+	Scheduler          *services.GatheringScheduler
+	Dispatcher         *services.NotificationDispatcher
+	Reminders          *services.ReminderScheduler
 }
 
 // NewGatheringRouter creates and initializes all gathering handlers
 func NewGatheringRouter(cfg *handlers.ApiConfig) *GatheringRouter {
+	// Live results subscribers are shared by every handler that records ballots or check-ins
+	liveResults := services.NewLiveResults(cfg.Db, services.NewStatsService(cfg.Db))
+
+	// Status changes go through one lifecycle service, whether made by hand or by the scheduler
+	quorumService := services.NewQuorumService(cfg.Db)
+	tallyService := services.NewTallyService(cfg.Db)
+	votingResultsService := services.NewVotingResultsService(cfg.Db, quorumService, tallyService)
+	lifecycleService := services.NewLifecycleService(cfg.Db, cfg.Conn, votingResultsService, liveResults)
+
 	// Create gathering handler first as others depend on it
-	gatheringHandler := gatheringHandlers.NewGatheringHandler(cfg)
+	gatheringHandler := gatheringHandlers.NewGatheringHandler(cfg, votingResultsService, lifecycleService)
+
+	var mailer notify.Transport = notify.Log{}
+	if cfg.Mailer != nil {
+		mailer = cfg.Mailer
+	}
+	dispatcher := services.NewNotificationDispatcher(cfg.Db, mailer, services.DefaultDispatchInterval)
+	notificationService, err := services.NewNotificationService(cfg.Db, cfg.Conn, votingResultsService, dispatcher, cfg.MemberOrigin)
+	if err != nil {
+		logging.Logger.Log(zap.ErrorLevel, "Error loading email templates", zap.Error(err))
+	}
+	reminderService := services.NewReminderService(cfg.Db, cfg.Conn, notificationService)
+
+	var sms notify.Transport = notify.Log{}
+	if cfg.SMS != nil {
+		sms = cfg.SMS
+	}
+	i18nService, err := services.NewI18nService()
+	if err != nil {
+		logging.Logger.Log(zap.ErrorLevel, "Error loading translations", zap.Error(err))
+	}
+	verificationService := services.NewMemberVerificationService(cfg.Db, cfg.Secret, i18nService, map[string]notify.Transport{
+		services.VerificationChannelEmail: mailer,
+		services.VerificationChannelSMS:   sms,
+	})
+
+	delegationService := services.NewDelegationService(cfg.Db, cfg.Conn)
 
 	return &GatheringRouter{
-		Gathering:    gatheringHandler,
-		VotingMatter: gatheringHandlers.NewVotingMatterHandler(cfg, gatheringHandler),
-		Participant:  gatheringHandlers.NewParticipantHandler(cfg, gatheringHandler),
-		Ballot:       gatheringHandlers.NewBallotHandler(cfg, gatheringHandler),
-		MemberBallot: gatheringHandlers.NewMemberBallotHandler(cfg),
-		Results:      gatheringHandlers.NewResultsHandler(cfg),
-		Export:       gatheringHandlers.NewExportHandler(cfg),
-		Notification: gatheringHandlers.NewNotificationHandler(cfg),
-		Invitation:   gatheringHandlers.NewInvitationHandler(cfg),
+		Gathering:          gatheringHandler,
+		VotingMatter:       gatheringHandlers.NewVotingMatterHandler(cfg, gatheringHandler),
+		MatterVoting:       gatheringHandlers.NewMatterVotingHandler(cfg, liveResults),
+		Participant:        gatheringHandlers.NewParticipantHandler(cfg, gatheringHandler, liveResults, delegationService),
+		Ballot:             gatheringHandlers.NewBallotHandler(cfg, gatheringHandler, liveResults, delegationService),
+		BallotImport:       gatheringHandlers.NewBallotImportHandler(cfg, liveResults),
+		MemberBallot:       gatheringHandlers.NewMemberBallotHandler(cfg, liveResults, delegationService),
+		MemberVerification: gatheringHandlers.NewMemberVerificationHandler(cfg, verificationService),
+		CoOwnership:        gatheringHandlers.NewCoOwnershipHandler(cfg),
+		Results:            gatheringHandlers.NewResultsHandler(cfg, liveResults),
+		Export:             gatheringHandlers.NewExportHandler(cfg),
+		Minutes:            gatheringHandlers.NewMinutesTemplateHandler(cfg),
+		Notification:       gatheringHandlers.NewNotificationHandler(cfg, notificationService, reminderService),
+		Invitation:         gatheringHandlers.NewInvitationHandler(cfg, verificationService),
+		Delegation:         gatheringHandlers.NewDelegationHandler(cfg, delegationService),
+		VotingRules:        gatheringHandlers.NewVotingRulesHandler(cfg),
+		Template:           gatheringHandlers.NewTemplateHandler(cfg, gatheringHandler),
+		Qualification:      gatheringHandlers.NewQualificationHandler(cfg),
+		Scheduler:          services.NewGatheringScheduler(cfg.Db, lifecycleService, services.DefaultSchedulerInterval),
+		Dispatcher:         dispatcher,
+		Reminders:          services.NewReminderScheduler(reminderService, services.DefaultReminderInterval),
 	}
 }
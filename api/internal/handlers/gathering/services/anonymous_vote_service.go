@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+var (
+	// ErrReceiptRequired is returned when a ballot with anonymous votes is corrected or added to without the voter's receipt
+	ErrReceiptRequired = errors.New("the voter's receipt is required for the anonymous votes of this ballot")
+	// ErrReceiptMismatch is returned when the receipt does not hold the anonymous votes of the ballot
+	ErrReceiptMismatch = errors.New("the receipt does not match the anonymous votes of this ballot")
+)
+
+// AnonymousVoteService keeps the choices made on anonymous matters apart from the ballot,
+// in a store keyed only by the receipt handed to the voter. The weight of the votes, which would
+// point back to the voter, is only kept in totals over every voter who chose alike.
+type AnonymousVoteService struct {
+	db *database.Queries
+}
+
+// NewAnonymousVoteService creates a new AnonymousVoteService.
+// Pass a transaction-bound Queries to store votes together with their ballot.
+func NewAnonymousVoteService(db *database.Queries) *AnonymousVoteService {
+	return &AnonymousVoteService{db: db}
+}
+
+// Seal moves the choices on anonymous matters out of a participant's ballot content and stores
+// them under a new receipt. It returns the content to store on the ballot, where each anonymous
+// vote is reduced to a participation marker, and the receipt (empty when nothing was sealed).
+func (s *AnonymousVoteService) Seal(ctx context.Context, participant database.GatheringParticipant, content map[string]domain.BallotVote) (map[string]domain.BallotVote, string, error) {
+	matters, err := s.db.GetVotingMatters(ctx, participant.GatheringID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get voting matters: %w", err)
+	}
+
+	stored, sealed := SplitAnonymousVotes(matters, content)
+	if len(sealed) == 0 {
+		return stored, "", nil
+	}
+
+	receipt, err := newBallotReceipt()
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.store(ctx, participant, receipt, sealed); err != nil {
+		return nil, "", err
+	}
+	return stored, receipt, nil
+}
+
+// SealAdded seals the choices on anonymous matters of votes added to a participant's ballot.
+// When the voter hands back the receipt of the ballot's anonymous votes the new ones join it, so
+// that the ballot keeps a single receipt; otherwise they are stored under a new one, and the
+// ballot's anonymous votes can no longer be corrected.
+func (s *AnonymousVoteService) SealAdded(ctx context.Context, participant database.GatheringParticipant, ballot database.VotingBallot, votes map[string]domain.BallotVote, receipt string) (map[string]domain.BallotVote, string, error) {
+	matters, err := s.db.GetVotingMatters(ctx, participant.GatheringID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get voting matters: %w", err)
+	}
+
+	stored, sealed := SplitAnonymousVotes(matters, votes)
+	if len(sealed) == 0 {
+		return stored, "", nil
+	}
+
+	marked, err := markedMatters(ballot)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(marked) > 0 && receipt != "" {
+		if _, err := s.checkReceipt(ctx, ballot.GatheringID, marked, receipt); err != nil {
+			return nil, "", err
+		}
+	} else if receipt, err = newBallotReceipt(); err != nil {
+		return nil, "", err
+	}
+
+	if err := s.store(ctx, participant, receipt, sealed); err != nil {
+		return nil, "", err
+	}
+	return stored, receipt, nil
+}
+
+// store records a participant's anonymous choices, by matter, under the receipt and adds their
+// weight to the totals of the choices
+func (s *AnonymousVoteService) store(ctx context.Context, participant database.GatheringParticipant, receipt string, sealed map[int64][]string) error {
+	for matterID, values := range sealed {
+		valuesJSON, err := json.Marshal(values)
+		if err != nil {
+			return fmt.Errorf("failed to encode anonymous vote: %w", err)
+		}
+		err = s.db.CreateAnonymousVote(ctx, database.CreateAnonymousVoteParams{
+			Receipt:        receipt,
+			GatheringID:    participant.GatheringID,
+			VotingMatterID: matterID,
+			VoteValues:     string(valuesJSON),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to store anonymous vote: %w", err)
+		}
+		if err := s.addTotals(ctx, participant, matterID, string(valuesJSON), 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addTotals adds a participant's vote to the totals of a choice, or takes it back out with a
+// sign of -1
+func (s *AnonymousVoteService) addTotals(ctx context.Context, participant database.GatheringParticipant, matterID int64, valuesJSON string, sign int64) error {
+	var unitIDs []int64
+	json.Unmarshal([]byte(participant.UnitsInfo), &unitIDs)
+
+	err := s.db.AddAnonymousVoteTotals(ctx, database.AddAnonymousVoteTotalsParams{
+		GatheringID:    participant.GatheringID,
+		VotingMatterID: matterID,
+		VoteValues:     valuesJSON,
+		Votes:          sign,
+		UnitsPart:      float64(sign) * participant.UnitsPart,
+		UnitsCount:     sign * int64(len(unitIDs)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update anonymous vote totals: %w", err)
+	}
+	return nil
+}
+
+// Withdraw invalidates the anonymous votes cast with a ballot. Since the store cannot be linked
+// to the ballot, the voter's receipt is required; it must hold a vote on exactly the anonymous
+// matters the ballot records participation in. Ballots without anonymous votes need no receipt.
+func (s *AnonymousVoteService) Withdraw(ctx context.Context, ballot database.VotingBallot, receipt string) error {
+	marked, err := markedMatters(ballot)
+	if err != nil {
+		return err
+	}
+	if len(marked) == 0 {
+		return nil
+	}
+	votes, err := s.checkReceipt(ctx, ballot.GatheringID, marked, receipt)
+	if err != nil {
+		return err
+	}
+
+	participant, err := s.db.GetGatheringParticipant(ctx, database.GetGatheringParticipantParams{
+		ID:          ballot.ParticipantID,
+		GatheringID: ballot.GatheringID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get participant: %w", err)
+	}
+	for _, vote := range votes {
+		if err := s.addTotals(ctx, participant, vote.VotingMatterID, vote.VoteValues, -1); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.db.InvalidateAnonymousVotes(ctx, database.InvalidateAnonymousVotesParams{
+		GatheringID: ballot.GatheringID,
+		Receipt:     receipt,
+	}); err != nil {
+		return fmt.Errorf("failed to invalidate anonymous votes: %w", err)
+	}
+	return nil
+}
+
+// markedMatters returns the anonymous matters a ballot records participation in, by matter ID
+func markedMatters(ballot database.VotingBallot) ([]int64, error) {
+	var content map[string]domain.BallotVote
+	if err := json.Unmarshal([]byte(ballot.BallotContent), &content); err != nil {
+		return nil, fmt.Errorf("failed to read ballot content: %w", err)
+	}
+
+	var marked []int64
+	for _, vote := range content {
+		if vote.Anonymous {
+			marked = append(marked, vote.MatterID)
+		}
+	}
+	sort.Slice(marked, func(i, j int) bool { return marked[i] < marked[j] })
+	return marked, nil
+}
+
+// checkReceipt checks the receipt holds a vote on exactly the anonymous matters marked on a
+// ballot and returns those votes
+func (s *AnonymousVoteService) checkReceipt(ctx context.Context, gatheringID int64, marked []int64, receipt string) ([]database.AnonymousVote, error) {
+	if receipt == "" {
+		return nil, ErrReceiptRequired
+	}
+
+	votes, err := s.db.GetAnonymousVotesByReceipt(ctx, database.GetAnonymousVotesByReceiptParams{
+		GatheringID: gatheringID,
+		Receipt:     receipt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get anonymous votes: %w", err)
+	}
+	if len(votes) != len(marked) {
+		return nil, ErrReceiptMismatch
+	}
+	for i, vote := range votes {
+		if vote.VotingMatterID != marked[i] {
+			return nil, ErrReceiptMismatch
+		}
+	}
+	return votes, nil
+}
+
+// SplitAnonymousVotes separates the votes cast on anonymous matters from a ballot's content.
+// The returned content keeps every other vote as is and marks participation in anonymous
+// matters without their values; the separated values are keyed by matter ID.
+// Anonymous matters left without a vote are omitted, like any other unanswered matter.
+func SplitAnonymousVotes(matters []database.VotingMatter, content map[string]domain.BallotVote) (map[string]domain.BallotVote, map[int64][]string) {
+	anonymous := make(map[string]int64)
+	for _, matter := range matters {
+		var config domain.VotingConfig
+		if err := json.Unmarshal([]byte(matter.VotingConfig), &config); err == nil && config.IsAnonymous {
+			anonymous[strconv.FormatInt(matter.ID, 10)] = matter.ID
+		}
+	}
+
+	stored := make(map[string]domain.BallotVote, len(content))
+	sealed := make(map[int64][]string)
+	for key, vote := range content {
+		matterID, isAnonymous := anonymous[key]
+		switch {
+		case !isAnonymous:
+			vote.Anonymous = false
+			stored[key] = vote
+		case len(vote.Values) > 0:
+			sealed[matterID] = vote.Values
+			stored[key] = domain.BallotVote{MatterID: matterID, Anonymous: true}
+		}
+	}
+	return stored, sealed
+}
+
+// newBallotReceipt generates the random token under which a ballot's anonymous votes are stored
+func newBallotReceipt() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate receipt: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
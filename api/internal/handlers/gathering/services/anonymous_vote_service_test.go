@@ -0,0 +1,101 @@
+package services
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// TestSplitAnonymousVotes tests that choices on anonymous matters are taken off the ballot
+func TestSplitAnonymousVotes(t *testing.T) {
+	matters := []database.VotingMatter{
+		{ID: 1, VotingConfig: `{"type":"yes_no"}`},
+		{ID: 2, VotingConfig: `{"type":"yes_no","is_anonymous":true}`},
+		{ID: 3, VotingConfig: `{"type":"ranking","is_anonymous":true,"options":[{"id":"x"},{"id":"y"}]}`},
+	}
+
+	tests := []struct {
+		name           string
+		content        map[string]domain.BallotVote
+		expectedStored map[string]domain.BallotVote
+		expectedSealed map[int64][]string
+	}{
+		{
+			name: "mixed ballot",
+			content: map[string]domain.BallotVote{
+				"1": {MatterID: 1, Values: []string{"yes"}},
+				"2": {MatterID: 2, Values: []string{"no"}},
+				"3": {Values: []string{"y", "x"}},
+			},
+			expectedStored: map[string]domain.BallotVote{
+				"1": {MatterID: 1, Values: []string{"yes"}},
+				"2": {MatterID: 2, Anonymous: true},
+				"3": {MatterID: 3, Anonymous: true},
+			},
+			expectedSealed: map[int64][]string{2: {"no"}, 3: {"y", "x"}},
+		},
+		{
+			name: "unanswered anonymous matter leaves no marker",
+			content: map[string]domain.BallotVote{
+				"1": {MatterID: 1, Values: []string{"no"}},
+				"2": {MatterID: 2},
+			},
+			expectedStored: map[string]domain.BallotVote{
+				"1": {MatterID: 1, Values: []string{"no"}},
+			},
+			expectedSealed: map[int64][]string{},
+		},
+		{
+			name: "client supplied marker on an open matter is ignored",
+			content: map[string]domain.BallotVote{
+				"1": {MatterID: 1, Values: []string{"yes"}, Anonymous: true},
+			},
+			expectedStored: map[string]domain.BallotVote{
+				"1": {MatterID: 1, Values: []string{"yes"}},
+			},
+			expectedSealed: map[int64][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored, sealed := SplitAnonymousVotes(matters, tt.content)
+			if !reflect.DeepEqual(stored, tt.expectedStored) {
+				t.Errorf("stored = %+v, expected %+v", stored, tt.expectedStored)
+			}
+			if !reflect.DeepEqual(sealed, tt.expectedSealed) {
+				t.Errorf("sealed = %+v, expected %+v", sealed, tt.expectedSealed)
+			}
+		})
+	}
+}
+
+// TestAnonymousVotes tests that the totals of an anonymous choice count as the votes cast
+func TestAnonymousVotes(t *testing.T) {
+	tests := []struct {
+		name           string
+		total          database.AnonymousVoteTotal
+		expectedVotes  int
+		expectedWeight float64
+		expectedUnits  float64
+	}{
+		{"shared evenly", database.AnonymousVoteTotal{VoteValues: `["y","x"]`, Votes: 4, UnitsPart: 0.2, UnitsCount: 6}, 4, 0.05, 1.5},
+		{"every vote withdrawn", database.AnonymousVoteTotal{VoteValues: `["y","x"]`}, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			votes := anonymousVotes(tt.total)
+			if len(votes) != tt.expectedVotes {
+				t.Fatalf("anonymousVotes() = %d votes, want %d", len(votes), tt.expectedVotes)
+			}
+			for _, vote := range votes {
+				if !reflect.DeepEqual(vote.Values, []string{"y", "x"}) || math.Abs(vote.Weight-tt.expectedWeight) > 1e-9 || math.Abs(vote.Units-tt.expectedUnits) > 1e-9 {
+					t.Errorf("anonymousVotes() vote = %+v, want weight %v and units %v", vote, tt.expectedWeight, tt.expectedUnits)
+				}
+			}
+		})
+	}
+}
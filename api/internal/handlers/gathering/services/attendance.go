@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// Attendance events of a participant during a gathering
+const (
+	AttendanceCheckIn  = "check_in"  // First arrival
+	AttendanceCheckOut = "check_out" // Leaving the meeting
+	AttendanceReEntry  = "re_entry"  // Coming back after leaving
+)
+
+var (
+	// ErrAlreadyPresent is returned when a participant who is present checks in again
+	ErrAlreadyPresent = errors.New("the participant is already present")
+	// ErrNotPresent is returned when a participant who is not present checks out
+	ErrNotPresent = errors.New("the participant is not checked in or has already left")
+)
+
+// AttendanceSpan is a stretch of the meeting a participant was present for. To is zero while
+// they still are.
+type AttendanceSpan struct {
+	From time.Time
+	To   time.Time
+}
+
+// AttendanceSpans works out when each participant was present from their attendance events, in
+// the order they occurred. Participants without events are taken from their check-in and
+// check-out times.
+func AttendanceSpans(participants []database.GetGatheringParticipantsRow, events []database.GetAttendanceEventsRow) map[int64][]AttendanceSpan {
+	spans := make(map[int64][]AttendanceSpan)
+	for _, event := range events {
+		list := spans[event.ParticipantID]
+		present := len(list) > 0 && list[len(list)-1].To.IsZero()
+		switch event.EventType {
+		case AttendanceCheckIn, AttendanceReEntry:
+			if !present {
+				list = append(list, AttendanceSpan{From: event.OccurredAt})
+			}
+		case AttendanceCheckOut:
+			if present {
+				list[len(list)-1].To = event.OccurredAt
+			}
+		}
+		spans[event.ParticipantID] = list
+	}
+
+	for _, p := range participants {
+		if _, ok := spans[p.ID]; ok || !p.CheckInTime.Valid {
+			continue
+		}
+		span := AttendanceSpan{From: p.CheckInTime.Time}
+		if p.CheckOutTime.Valid {
+			span.To = p.CheckOutTime.Time
+		}
+		spans[p.ID] = []AttendanceSpan{span}
+	}
+	return spans
+}
+
+// PresentDuring reports whether the spans cover some moment from from to to
+func PresentDuring(spans []AttendanceSpan, from, to time.Time) bool {
+	for _, span := range spans {
+		if !span.From.After(to) && (span.To.IsZero() || span.To.After(from)) {
+			return true
+		}
+	}
+	return false
+}
+
+// AttendanceAt counts the participants present at a moment of the meeting
+func AttendanceAt(participants []database.GetGatheringParticipantsRow, spans map[int64][]AttendanceSpan, at time.Time) domain.Attendance {
+	return countAttendance(participants, func(p database.GetGatheringParticipantsRow) bool {
+		return PresentDuring(spans[p.ID], at, at)
+	})
+}
+
+// AttendanceForMatter returns the attendance a matter was voted with: participants present at
+// some point while it took votes, and those who voted on it. A matter takes votes while the chair
+// has it open in a per_matter gathering, and while the gathering is active otherwise; one that
+// has not opened yet counts those present now.
+func AttendanceForMatter(matter database.VotingMatter, participants []database.GetGatheringParticipantsRow, spans map[int64][]AttendanceSpan, voters map[int64]bool, now time.Time) domain.MatterAttendance {
+	from, to := now, now
+	if matter.VotingOpenedAt.Valid {
+		from = matter.VotingOpenedAt.Time
+	}
+	if matter.VotingClosedAt.Valid {
+		to = matter.VotingClosedAt.Time
+	}
+
+	attendance := domain.MatterAttendance{
+		Attendance: countAttendance(participants, func(p database.GetGatheringParticipantsRow) bool {
+			return voters[p.ID] || PresentDuring(spans[p.ID], from, to)
+		}),
+		To: to,
+	}
+	if matter.VotingOpenedAt.Valid {
+		attendance.From = &from
+	}
+	return attendance
+}
+
+// countAttendance adds up the participants that count and the units and weight they hold
+func countAttendance(participants []database.GetGatheringParticipantsRow, counts func(database.GetGatheringParticipantsRow) bool) domain.Attendance {
+	var attendance domain.Attendance
+	for _, p := range participants {
+		if !counts(p) {
+			continue
+		}
+		var unitIDs []int64
+		json.Unmarshal([]byte(p.UnitsInfo), &unitIDs)
+		attendance.Participants++
+		attendance.Units += len(unitIDs)
+		attendance.Weight += p.UnitsPart
+	}
+	return attendance
+}
+
+// AttendanceService records participants arriving, leaving and coming back during a gathering
+type AttendanceService struct {
+	db            *database.Queries
+	conn          *sql.DB
+	quorumService *QuorumService
+}
+
+// NewAttendanceService creates a new AttendanceService
+func NewAttendanceService(db *database.Queries, conn *sql.DB) *AttendanceService {
+	return &AttendanceService{
+		db:            db,
+		conn:          conn,
+		quorumService: NewQuorumService(db),
+	}
+}
+
+// CheckIn records a participant arriving at the meeting, or coming back after leaving it
+func (s *AttendanceService) CheckIn(ctx context.Context, gathering database.Gathering, participantID int64, recordedBy string) (database.AttendanceEvent, error) {
+	return s.record(ctx, gathering, participantID, recordedBy, func(q *database.Queries, participant database.GatheringParticipant) (string, error) {
+		switch {
+		case !participant.CheckInTime.Valid:
+			err := q.CheckInParticipant(ctx, database.CheckInParticipantParams{
+				ID:          participant.ID,
+				GatheringID: gathering.ID,
+			})
+			return AttendanceCheckIn, err
+		case participant.CheckOutTime.Valid:
+			affected, err := q.ReEnterParticipant(ctx, database.ReEnterParticipantParams{
+				ID:          participant.ID,
+				GatheringID: gathering.ID,
+			})
+			if err == nil && affected == 0 {
+				err = ErrAlreadyPresent
+			}
+			return AttendanceReEntry, err
+		default:
+			return "", ErrAlreadyPresent
+		}
+	})
+}
+
+// CheckOut records a participant leaving the meeting
+func (s *AttendanceService) CheckOut(ctx context.Context, gathering database.Gathering, participantID int64, recordedBy string) (database.AttendanceEvent, error) {
+	return s.record(ctx, gathering, participantID, recordedBy, func(q *database.Queries, participant database.GatheringParticipant) (string, error) {
+		affected, err := q.CheckOutParticipant(ctx, database.CheckOutParticipantParams{
+			ID:          participant.ID,
+			GatheringID: gathering.ID,
+		})
+		if err == nil && affected == 0 {
+			err = ErrNotPresent
+		}
+		return AttendanceCheckOut, err
+	})
+}
+
+// record updates a participant's attendance and records the event in one transaction
+func (s *AttendanceService) record(ctx context.Context, gathering database.Gathering, participantID int64, recordedBy string,
+	update func(q *database.Queries, participant database.GatheringParticipant) (string, error)) (database.AttendanceEvent, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return database.AttendanceEvent{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	q := s.db.WithTx(tx)
+
+	participant, err := q.GetGatheringParticipant(ctx, database.GetGatheringParticipantParams{
+		ID:          participantID,
+		GatheringID: gathering.ID,
+	})
+	if err != nil {
+		return database.AttendanceEvent{}, fmt.Errorf("failed to get participant: %w", err)
+	}
+
+	eventType, err := update(q, participant)
+	if err != nil {
+		return database.AttendanceEvent{}, err
+	}
+	event, err := q.CreateAttendanceEvent(ctx, database.CreateAttendanceEventParams{
+		GatheringID:   gathering.ID,
+		ParticipantID: participant.ID,
+		EventType:     eventType,
+		RecordedBy:    sql.NullString{String: recordedBy, Valid: recordedBy != ""},
+	})
+	if err != nil {
+		return database.AttendanceEvent{}, fmt.Errorf("failed to record attendance: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.AttendanceEvent{}, fmt.Errorf("failed to commit attendance: %w", err)
+	}
+	return event, nil
+}
+
+// Timeline returns the attendance events of a gathering, each with the attendance and quorum it
+// left the meeting with, and the attendance and quorum at the given moment
+func (s *AttendanceService) Timeline(ctx context.Context, gathering database.Gathering, at time.Time) (domain.AttendanceTimeline, error) {
+	participants, err := s.db.GetGatheringParticipants(ctx, gathering.ID)
+	if err != nil {
+		return domain.AttendanceTimeline{}, fmt.Errorf("failed to get participants: %w", err)
+	}
+	events, err := s.db.GetAttendanceEvents(ctx, gathering.ID)
+	if err != nil {
+		return domain.AttendanceTimeline{}, fmt.Errorf("failed to get attendance events: %w", err)
+	}
+	spans := AttendanceSpans(participants, events)
+	g := domain.DBGatheringToResponse(gathering)
+
+	timeline := domain.AttendanceTimeline{
+		GatheringID: gathering.ID,
+		Events:      make([]domain.AttendanceEvent, len(events)),
+		At:          at,
+		Present:     AttendanceAt(participants, spans, at),
+	}
+	timeline.Quorum = s.quorumService.CalculatePresentQuorum(g, timeline.Present)
+
+	// Replay the events, as several can share the same moment
+	presentIDs := make(map[int64]bool)
+	for i, event := range events {
+		presentIDs[event.ParticipantID] = event.EventType != AttendanceCheckOut
+		present := countAttendance(participants, func(p database.GetGatheringParticipantsRow) bool {
+			return presentIDs[p.ID]
+		})
+		timeline.Events[i] = domain.AttendanceEvent{
+			ID:              event.ID,
+			ParticipantID:   event.ParticipantID,
+			ParticipantName: event.ParticipantName,
+			EventType:       event.EventType,
+			OccurredAt:      event.OccurredAt,
+			RecordedBy:      event.RecordedBy.String,
+			Present:         present,
+			QuorumMet:       s.quorumService.CalculatePresentQuorum(g, present).Met,
+		}
+	}
+	return timeline, nil
+}
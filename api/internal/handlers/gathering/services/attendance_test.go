@@ -0,0 +1,178 @@
+package services
+
+import (
+	"database/sql"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// meetingStart is when the meetings of these tests open
+var meetingStart = time.Date(2026, 5, 12, 18, 0, 0, 0, time.UTC)
+
+// minutesIn returns the moment some minutes into the meeting
+func minutesIn(minutes int) time.Time {
+	return meetingStart.Add(time.Duration(minutes) * time.Minute)
+}
+
+// TestAttendanceSpans tests working out when participants were present from their events
+func TestAttendanceSpans(t *testing.T) {
+	event := func(participantID int64, eventType string, minutes int) database.GetAttendanceEventsRow {
+		return database.GetAttendanceEventsRow{ParticipantID: participantID, EventType: eventType, OccurredAt: minutesIn(minutes)}
+	}
+	participants := []database.GetGatheringParticipantsRow{
+		{ID: 1}, {ID: 2}, {ID: 3},
+		{ID: 4, CheckInTime: sql.NullTime{Time: minutesIn(5), Valid: true}}, // Checked in before events were recorded
+		{ID: 5},
+	}
+	events := []database.GetAttendanceEventsRow{
+		event(1, AttendanceCheckIn, 0),
+		event(2, AttendanceCheckIn, 0),
+		event(2, AttendanceCheckOut, 20),
+		event(2, AttendanceReEntry, 45),
+		event(3, AttendanceCheckIn, 10),
+		event(3, AttendanceCheckIn, 15), // Repeated check-in while present
+		event(3, AttendanceCheckOut, 30),
+		event(3, AttendanceCheckOut, 35), // Repeated check-out while away
+	}
+
+	spans := AttendanceSpans(participants, events)
+	expected := map[int64][]AttendanceSpan{
+		1: {{From: minutesIn(0)}},
+		2: {{From: minutesIn(0), To: minutesIn(20)}, {From: minutesIn(45)}},
+		3: {{From: minutesIn(10), To: minutesIn(30)}},
+		4: {{From: minutesIn(5)}},
+	}
+	if !reflect.DeepEqual(spans, expected) {
+		t.Errorf("AttendanceSpans() = %v, want %v", spans, expected)
+	}
+
+	tests := []struct {
+		name     string
+		spans    []AttendanceSpan
+		from, to int
+		expected bool
+	}{
+		{"present throughout", spans[1], 10, 10, true},
+		{"away at the moment", spans[2], 30, 30, false},
+		{"back at the moment", spans[2], 45, 45, true},
+		{"left when the window opened", spans[2], 20, 25, false},
+		{"left during the window", spans[2], 15, 25, true},
+		{"arrived when the window closed", spans[3], 0, 10, true},
+		{"arrived after the window", spans[3], 0, 5, false},
+		{"never present", spans[5], 0, 60, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PresentDuring(tt.spans, minutesIn(tt.from), minutesIn(tt.to)); got != tt.expected {
+				t.Errorf("PresentDuring() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestAttendanceAt tests the attendance and quorum at moments of a meeting people leave and return to
+func TestAttendanceAt(t *testing.T) {
+	participants := []database.GetGatheringParticipantsRow{
+		{ID: 1, UnitsInfo: "[1]", UnitsPart: 0.3},
+		{ID: 2, UnitsInfo: "[2,3]", UnitsPart: 0.4},
+	}
+	spans := map[int64][]AttendanceSpan{
+		1: {{From: minutesIn(0)}},
+		2: {{From: minutesIn(0), To: minutesIn(20)}, {From: minutesIn(45)}},
+	}
+	gathering := domain.Gathering{GatheringType: "initial", VotingMode: "by_weight", QualifiedUnitsTotalPart: 1}
+	quorumService := NewQuorumService(nil)
+
+	tests := []struct {
+		minutes   int
+		expected  domain.Attendance
+		quorumMet bool
+	}{
+		{10, domain.Attendance{Participants: 2, Units: 3, Weight: 0.7}, true},
+		{30, domain.Attendance{Participants: 1, Units: 1, Weight: 0.3}, false},
+		{50, domain.Attendance{Participants: 2, Units: 3, Weight: 0.7}, true},
+	}
+	for _, tt := range tests {
+		present := AttendanceAt(participants, spans, minutesIn(tt.minutes))
+		if present.Participants != tt.expected.Participants || present.Units != tt.expected.Units || math.Abs(present.Weight-tt.expected.Weight) > 1e-9 {
+			t.Errorf("AttendanceAt(%d) = %+v, want %+v", tt.minutes, present, tt.expected)
+		}
+		if got := quorumService.CalculatePresentQuorum(gathering, present).Met; got != tt.quorumMet {
+			t.Errorf("CalculatePresentQuorum(%d).Met = %v, want %v", tt.minutes, got, tt.quorumMet)
+		}
+	}
+}
+
+// TestAttendanceForMatter tests that the matters of a gathering voting on all of them at once
+// count those present while it was open, as they left and came back
+func TestAttendanceForMatter(t *testing.T) {
+	at := func(minutes int) sql.NullTime {
+		return sql.NullTime{Time: minutesIn(minutes), Valid: true}
+	}
+	event := func(participantID int64, eventType string, minutes int) database.GetAttendanceEventsRow {
+		return database.GetAttendanceEventsRow{ParticipantID: participantID, EventType: eventType, OccurredAt: minutesIn(minutes)}
+	}
+
+	participants := []database.GetGatheringParticipantsRow{
+		{ID: 1, UnitsInfo: "[1]", UnitsPart: 0.1},   // Stays throughout
+		{ID: 2, UnitsInfo: "[2,3]", UnitsPart: 0.2}, // Leaves for a while
+		{ID: 3, UnitsInfo: "[4]", UnitsPart: 0.3},   // Arrives once voting has closed
+		{ID: 4, UnitsInfo: "[5]", UnitsPart: 0.05},  // Votes without attending
+	}
+	spans := AttendanceSpans(participants, []database.GetAttendanceEventsRow{
+		event(1, AttendanceCheckIn, 0),
+		event(2, AttendanceCheckIn, 0),
+		event(2, AttendanceCheckOut, 20),
+		event(2, AttendanceReEntry, 45),
+		event(3, AttendanceCheckIn, 70),
+	})
+
+	tests := []struct {
+		name           string
+		matter         database.VotingMatter
+		voters         map[int64]bool
+		expectedWeight float64
+		expectedUnits  int
+		expectedFrom   bool
+	}{
+		{
+			name:           "matter voted while the gathering was open",
+			matter:         database.VotingMatter{VotingOpenedAt: at(0), VotingClosedAt: at(60)},
+			voters:         map[int64]bool{4: true},
+			expectedWeight: 0.35,
+			expectedUnits:  4,
+			expectedFrom:   true,
+		},
+		{
+			name:           "matter voted while a participant was away",
+			matter:         database.VotingMatter{VotingOpenedAt: at(25), VotingClosedAt: at(40)},
+			expectedWeight: 0.1,
+			expectedUnits:  1,
+			expectedFrom:   true,
+		},
+		{
+			name:           "matter not opened yet counts those present now",
+			matter:         database.VotingMatter{},
+			expectedWeight: 0.6,
+			expectedUnits:  4,
+		},
+	}
+
+	now := minutesIn(80)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attendance := AttendanceForMatter(tt.matter, participants, spans, tt.voters, now)
+			if math.Abs(attendance.Weight-tt.expectedWeight) > 1e-9 || attendance.Units != tt.expectedUnits {
+				t.Errorf("AttendanceForMatter() = %v, %d, want %v, %d", attendance.Weight, attendance.Units, tt.expectedWeight, tt.expectedUnits)
+			}
+			if (attendance.From != nil) != tt.expectedFrom {
+				t.Errorf("AttendanceForMatter() from = %v, want set %v", attendance.From, tt.expectedFrom)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/ballotimport"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// BallotImportService validates sheets of paper ballots against a gathering's voter register
+type BallotImportService struct {
+	db *database.Queries
+}
+
+// NewBallotImportService creates a new BallotImportService
+func NewBallotImportService(db *database.Queries) *BallotImportService {
+	return &BallotImportService{db: db}
+}
+
+// ImportedBallot is a valid row of an import sheet, ready to be stored
+type ImportedBallot struct {
+	domain.BallotImportBallot
+	DelegationDocumentRef string
+	Identification        string // Recorded on the participant: the owner's, or the delegation document for a delegate
+	Content               map[string]domain.BallotVote
+}
+
+// Validate checks every row of the sheet against the gathering's matters, its voter register
+// and the unit slots still free. It returns the report and the ballots of the rows without
+// errors; a sheet should only be imported when the report has no errors.
+func (s *BallotImportService) Validate(ctx context.Context, gathering database.Gathering, sheet ballotimport.Sheet) (domain.BallotImportReport, []ImportedBallot, error) {
+	report := domain.BallotImportReport{
+		RowCount: len(sheet.Rows),
+		Errors:   make([]domain.BallotImportError, 0),
+		Ballots:  make([]domain.BallotImportBallot, 0),
+	}
+
+	matters, err := s.db.GetVotingMatters(ctx, gathering.ID)
+	if err != nil {
+		return report, nil, fmt.Errorf("failed to get voting matters: %w", err)
+	}
+	register, err := NewVoterRegisterService(s.db).Register(ctx, gathering)
+	if err != nil {
+		return report, nil, err
+	}
+	slots, err := NewVoterRegisterService(s.db).AvailableSlots(ctx, gathering.ID)
+	if err != nil {
+		return report, nil, err
+	}
+
+	// Every matter voted on must have a column and every column must be a matter of the gathering
+	columns := make(map[int64]bool, len(sheet.MatterIDs))
+	for _, matterID := range sheet.MatterIDs {
+		columns[matterID] = true
+	}
+	known := make(map[int64]bool, len(matters))
+	for _, matter := range matters {
+		known[matter.ID] = true
+		if matter.IsInformative == 0 && !columns[matter.ID] {
+			report.Errors = append(report.Errors, domain.BallotImportError{
+				Row:     1,
+				Message: fmt.Sprintf("missing column %s for matter %q", ballotimport.MatterColumn(matter.ID), matter.Title),
+			})
+		}
+	}
+	for _, matterID := range sheet.MatterIDs {
+		if !known[matterID] {
+			report.Errors = append(report.Errors, domain.BallotImportError{
+				Row:     1,
+				Column:  ballotimport.MatterColumn(matterID),
+				Message: "matter does not belong to this gathering",
+			})
+		}
+	}
+
+	// Owners are identified by identification number and vote for the units the co-ownership
+	// policy lets them vote for
+	ownersByIdentification := make(map[string][]domain.RegisterOwner)
+	ownerUnits := make(map[int64][]domain.RegisterUnit)
+	for _, unit := range register {
+		for _, owner := range VotingOwners(gathering.CoOwnershipPolicy, unit) {
+			if len(ownerUnits[owner.OwnerID]) == 0 {
+				ownersByIdentification[owner.Identification] = append(ownersByIdentification[owner.Identification], owner)
+			}
+			ownerUnits[owner.OwnerID] = append(ownerUnits[owner.OwnerID], unit)
+		}
+	}
+
+	claimedBy := make(map[unitOwner]int) // Unit slot to the row that votes for it
+	var ballots []ImportedBallot
+	for _, row := range sheet.Rows {
+		var rowErrors []domain.BallotImportError
+		addErr := func(column string, format string, args ...interface{}) {
+			rowErrors = append(rowErrors, domain.BallotImportError{Row: row.Line, Column: column, Message: fmt.Sprintf(format, args...)})
+		}
+
+		var owner domain.RegisterOwner
+		switch owners := ownersByIdentification[row.OwnerIdentification]; {
+		case row.OwnerIdentification == "":
+			addErr(ballotimport.ColumnOwnerIdentification, "owner identification is required")
+		case len(owners) == 0:
+			addErr(ballotimport.ColumnOwnerIdentification, "no owner voting in this gathering has identification %q", row.OwnerIdentification)
+		case len(owners) > 1:
+			addErr(ballotimport.ColumnOwnerIdentification, "identification %q matches several owners", row.OwnerIdentification)
+		default:
+			owner = owners[0]
+		}
+		if row.IsDelegate() && row.DelegationDocumentRef == "" {
+			addErr(ballotimport.ColumnDelegationDocumentRef, "the delegation document is required for a delegate")
+		}
+
+		// Units, all of the owner's free ones unless listed
+		var units []domain.RegisterUnit
+		if owner.OwnerID != 0 {
+			if len(row.UnitNumbers) == 0 {
+				for _, unit := range ownerUnits[owner.OwnerID] {
+					if slots.Available(unit.UnitID, owner.OwnerID) && claimedBy[unitOwner{unit.UnitID, owner.OwnerID}] == 0 {
+						units = append(units, unit)
+					}
+				}
+				if len(units) == 0 {
+					addErr(ballotimport.ColumnUnitNumbers, "the owner has no units left to vote for")
+				}
+			}
+			for _, number := range row.UnitNumbers {
+				var matches []domain.RegisterUnit
+				for _, unit := range ownerUnits[owner.OwnerID] {
+					if unit.UnitNumber == number {
+						matches = append(matches, unit)
+					}
+				}
+				switch {
+				case len(matches) == 0:
+					addErr(ballotimport.ColumnUnitNumbers, "unit %s is not qualified or the owner does not vote for it", number)
+				case len(matches) > 1:
+					addErr(ballotimport.ColumnUnitNumbers, "unit number %s matches several of the owner's units", number)
+				case !slots.Available(matches[0].UnitID, owner.OwnerID):
+					addErr(ballotimport.ColumnUnitNumbers, "unit %s is not available (already assigned)", number)
+				case claimedBy[unitOwner{matches[0].UnitID, owner.OwnerID}] != 0:
+					addErr(ballotimport.ColumnUnitNumbers, "unit %s is already voted for on row %d", number, claimedBy[unitOwner{matches[0].UnitID, owner.OwnerID}])
+				default:
+					units = append(units, matches[0])
+				}
+			}
+		}
+
+		content := make(map[string]domain.BallotVote, len(row.Votes))
+		for matterID, values := range row.Votes {
+			if known[matterID] && len(values) > 0 {
+				content[strconv.FormatInt(matterID, 10)] = domain.BallotVote{MatterID: matterID, Values: values}
+			}
+		}
+		for _, matterErr := range ValidateVotingWindows(gathering, matters, content) {
+			matterID, _ := strconv.ParseInt(matterErr.MatterID, 10, 64)
+			addErr(ballotimport.MatterColumn(matterID), "%s", matterErr.Message)
+		}
+
+		if len(rowErrors) > 0 {
+			report.Errors = append(report.Errors, rowErrors...)
+			continue
+		}
+
+		ballot := ImportedBallot{
+			BallotImportBallot: domain.BallotImportBallot{
+				Row:         row.Line,
+				VoterType:   "owner",
+				OwnerID:     owner.OwnerID,
+				OwnerName:   owner.Name,
+				UnitIDs:     make([]int64, 0, len(units)),
+				UnitNumbers: make([]string, 0, len(units)),
+			},
+			Identification: owner.Identification,
+			Content:        content,
+		}
+		if row.IsDelegate() {
+			ballot.VoterType = "delegate"
+			ballot.DelegateName = row.DelegateName
+			ballot.DelegationDocumentRef = row.DelegationDocumentRef
+			ballot.Identification = row.DelegationDocumentRef
+		}
+		for _, unit := range units {
+			share := OwnerShare(gathering.CoOwnershipPolicy, unit, owner.OwnerID)
+			claimedBy[unitOwner{unit.UnitID, owner.OwnerID}] = row.Line
+			ballot.UnitIDs = append(ballot.UnitIDs, unit.UnitID)
+			ballot.UnitNumbers = append(ballot.UnitNumbers, unit.UnitNumber)
+			ballot.UnitsPart += unit.Part * share
+			ballot.UnitsArea += unit.Area * share
+		}
+		ballots = append(ballots, ballot)
+
+		report.TotalUnits += len(units)
+		report.TotalPart += ballot.UnitsPart
+		report.TotalArea += ballot.UnitsArea
+	}
+
+	for _, ballot := range ballots {
+		report.Ballots = append(report.Ballots, ballot.BallotImportBallot)
+	}
+	report.ErrorCount = len(report.Errors)
+	report.TotalPart = RoundTo3Decimals(report.TotalPart)
+	report.TotalArea = RoundTo3Decimals(report.TotalArea)
+	return report, ballots, nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/receipt"
+)
+
+// encryptedKeyPrefix marks a stored seed encrypted with the key secret
+const encryptedKeyPrefix = "aesgcm:"
+
+// BallotSigner signs recorded ballots with the association's Ed25519 key and issues the
+// receipt handed to the voter. Private keys are stored encrypted with a key derived from the
+// key secret, which is kept outside the database.
+type BallotSigner struct {
+	db      *database.Queries
+	keyAEAD cipher.AEAD
+}
+
+// NewBallotSigner creates a new BallotSigner.
+// Pass a transaction-bound Queries to sign a ballot together with its insertion.
+func NewBallotSigner(db *database.Queries, keySecret string) *BallotSigner {
+	key := sha256.Sum256([]byte(keySecret))
+	block, _ := aes.NewCipher(key[:])
+	keyAEAD, _ := cipher.NewGCM(block)
+	return &BallotSigner{db: db, keyAEAD: keyAEAD}
+}
+
+// Sign signs the ballot's hash, gathering and signing time, stores the signature on the
+// ballot and returns the signed receipt. The receipt commits to nothing of the anonymous votes:
+// the signature is stored with the ballot, so it could otherwise be matched to their receipts.
+func (s *BallotSigner) Sign(ctx context.Context, associationID int64, ballot database.VotingBallot) (string, error) {
+	key, err := s.SigningKey(ctx, associationID)
+	if err != nil {
+		return "", err
+	}
+	privateKey, err := s.decodePrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	signedAt := time.Now().UTC().Truncate(time.Second)
+	signed, err := receipt.Sign(privateKey, receipt.Payload{
+		KeyID:         key.KeyID,
+		AssociationID: associationID,
+		GatheringID:   ballot.GatheringID,
+		BallotID:      ballot.ID,
+		BallotHash:    ballot.BallotHash,
+		SignedAt:      signedAt.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	err = s.db.SignBallot(ctx, database.SignBallotParams{
+		Signature:            sql.NullString{String: receipt.Signature(signed), Valid: true},
+		SignatureTimestamp:   sql.NullTime{Time: signedAt, Valid: true},
+		SignatureCertificate: sql.NullString{String: key.KeyID, Valid: true},
+		ID:                   ballot.ID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to store ballot signature: %w", err)
+	}
+	return signed, nil
+}
+
+// SigningKey returns the association's signing key, generating it on first use
+func (s *BallotSigner) SigningKey(ctx context.Context, associationID int64) (database.AssociationSigningKey, error) {
+	key, err := s.db.GetAssociationSigningKey(ctx, associationID)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return key, fmt.Errorf("failed to get signing key: %w", err)
+	}
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return key, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	keyID := receipt.KeyID(publicKey)
+	key, err = s.db.CreateAssociationSigningKey(ctx, database.CreateAssociationSigningKeyParams{
+		AssociationID: associationID,
+		KeyID:         keyID,
+		PublicKey:     receipt.EncodePublicKey(publicKey),
+		PrivateKey:    s.encryptSeed(keyID, privateKey.Seed()),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Another request created the key first
+		return s.db.GetAssociationSigningKey(ctx, associationID)
+	}
+	if err != nil {
+		return key, fmt.Errorf("failed to store signing key: %w", err)
+	}
+	return key, nil
+}
+
+// EncryptStoredKeys encrypts the private keys stored before they were encrypted
+func (s *BallotSigner) EncryptStoredKeys(ctx context.Context) (int, error) {
+	keys, err := s.db.GetUnencryptedSigningKeys(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get signing keys: %w", err)
+	}
+	for _, key := range keys {
+		seed, err := base64.StdEncoding.DecodeString(key.PrivateKey)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return 0, fmt.Errorf("invalid stored signing key %s", key.KeyID)
+		}
+		err = s.db.SetSigningKeyPrivateKey(ctx, database.SetSigningKeyPrivateKeyParams{
+			PrivateKey: s.encryptSeed(key.KeyID, seed),
+			ID:         key.ID,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to store signing key %s: %w", key.KeyID, err)
+		}
+	}
+	return len(keys), nil
+}
+
+// encryptSeed encrypts a private key's seed, bound to its key ID, for storage
+func (s *BallotSigner) encryptSeed(keyID string, seed []byte) string {
+	nonce := make([]byte, s.keyAEAD.NonceSize())
+	rand.Read(nonce)
+	sealed := s.keyAEAD.Seal(nonce, nonce, seed, []byte(keyID))
+	return encryptedKeyPrefix + base64.StdEncoding.EncodeToString(sealed)
+}
+
+// decodePrivateKey rebuilds the private key from its stored, encrypted seed
+func (s *BallotSigner) decodePrivateKey(key database.AssociationSigningKey) (ed25519.PrivateKey, error) {
+	encoded, ok := strings.CutPrefix(key.PrivateKey, encryptedKeyPrefix)
+	if !ok {
+		return nil, errors.New("stored signing key is not encrypted")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < s.keyAEAD.NonceSize() {
+		return nil, errors.New("invalid stored signing key")
+	}
+	nonceSize := s.keyAEAD.NonceSize()
+	seed, err := s.keyAEAD.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(key.KeyID))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, errors.New("cannot decrypt stored signing key, check the signing key secret")
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
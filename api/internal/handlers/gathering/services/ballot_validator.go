@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// abstainValue is the vote value used to abstain on any matter type
+const abstainValue = "abstain"
+
+// BallotValidator checks ballot content against the voting matters of a gathering
+type BallotValidator struct {
+	db *database.Queries
+}
+
+// NewBallotValidator creates a new BallotValidator
+func NewBallotValidator(db *database.Queries) *BallotValidator {
+	return &BallotValidator{db: db}
+}
+
+// Validate loads the gathering's voting matters and checks the ballot against the matters
+// taking votes. It returns the per-matter problems found; an empty result means the ballot is valid.
+func (v *BallotValidator) Validate(ctx context.Context, gathering database.Gathering, content map[string]domain.BallotVote) ([]domain.BallotMatterError, error) {
+	matters, err := v.db.GetVotingMatters(ctx, gathering.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voting matters: %w", err)
+	}
+	return ValidateVotingWindows(gathering, matters, content), nil
+}
+
+// ValidateCorrection checks a ballot corrected by the counting commission like Validate, against
+// every matter voting was opened on
+func (v *BallotValidator) ValidateCorrection(ctx context.Context, gathering database.Gathering, content map[string]domain.BallotVote) ([]domain.BallotMatterError, error) {
+	matters, err := v.db.GetVotingMatters(ctx, gathering.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voting matters: %w", err)
+	}
+	return ValidateCorrectionWindows(gathering, matters, content), nil
+}
+
+// ValidateBallotContent checks that every vote targets a matter of the gathering and
+// is well formed for that matter's VotingConfig
+func ValidateBallotContent(matters []database.VotingMatter, content map[string]domain.BallotVote) []domain.BallotMatterError {
+	var errs []domain.BallotMatterError
+	addErr := func(matterID string, format string, args ...interface{}) {
+		errs = append(errs, domain.BallotMatterError{MatterID: matterID, Message: fmt.Sprintf(format, args...)})
+	}
+
+	known := make(map[string]bool, len(matters))
+	for _, matter := range matters {
+		matterID := strconv.FormatInt(matter.ID, 10)
+		known[matterID] = true
+
+		var config domain.VotingConfig
+		if err := json.Unmarshal([]byte(matter.VotingConfig), &config); err != nil {
+			addErr(matterID, "matter has an invalid voting configuration")
+			continue
+		}
+
+		vote, voted := content[matterID]
+		if voted && vote.MatterID != 0 && vote.MatterID != matter.ID {
+			addErr(matterID, "vote is keyed by matter %s but refers to matter %d", matterID, vote.MatterID)
+			continue
+		}
+
+		if matter.IsInformative == 1 {
+			if voted && len(vote.Values) > 0 {
+				addErr(matterID, "informative matters do not accept votes")
+			}
+			continue
+		}
+
+		if !voted || len(vote.Values) == 0 {
+			if !config.AllowAbstention {
+				addErr(matterID, "a vote is required because abstention is not allowed")
+			}
+			continue
+		}
+
+		for _, msg := range validateVoteValues(config, vote.Values) {
+			addErr(matterID, "%s", msg)
+		}
+	}
+
+	var unknown []string
+	for matterID := range content {
+		if !known[matterID] {
+			unknown = append(unknown, matterID)
+		}
+	}
+	sort.Strings(unknown)
+	for _, matterID := range unknown {
+		addErr(matterID, "matter does not belong to this gathering")
+	}
+
+	return errs
+}
+
+// validateVoteValues checks the selected values against the matter's type and options
+func validateVoteValues(config domain.VotingConfig, values []string) []string {
+	var problems []string
+
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		if seen[value] {
+			problems = append(problems, fmt.Sprintf("option %q is selected more than once", value))
+		}
+		seen[value] = true
+	}
+
+	if seen[abstainValue] {
+		if !config.AllowAbstention {
+			problems = append(problems, "abstention is not allowed")
+		} else if len(values) > 1 {
+			problems = append(problems, "abstention cannot be combined with other choices")
+		}
+		return problems
+	}
+
+	switch config.Type {
+	case "yes_no":
+		if len(values) != 1 {
+			problems = append(problems, "exactly one value is required")
+		} else if values[0] != "yes" && values[0] != "no" {
+			problems = append(problems, fmt.Sprintf("%q is not a valid yes/no answer", values[0]))
+		}
+	case "single_choice", "multiple_choice", "ranking":
+		if config.Type == "single_choice" && len(values) != 1 {
+			problems = append(problems, "exactly one option must be selected")
+		}
+		options := make(map[string]bool, len(config.Options))
+		for _, opt := range config.Options {
+			options[opt.ID] = true
+		}
+		for _, value := range values {
+			if !options[value] {
+				problems = append(problems, fmt.Sprintf("%q is not an option of this matter", value))
+			}
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("unsupported voting type %q", config.Type))
+	}
+
+	return problems
+}
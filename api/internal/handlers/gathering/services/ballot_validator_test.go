@@ -0,0 +1,120 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// TestValidateBallotContent tests ballot validation against voting matter configurations
+func TestValidateBallotContent(t *testing.T) {
+	matters := []database.VotingMatter{
+		{ID: 1, VotingConfig: `{"type":"yes_no","allow_abstention":false}`},
+		{ID: 2, VotingConfig: `{"type":"single_choice","allow_abstention":true,"options":[{"id":"a","text":"A"},{"id":"b","text":"B"}]}`},
+		{ID: 3, VotingConfig: `{"type":"ranking","allow_abstention":true,"options":[{"id":"x","text":"X"},{"id":"y","text":"Y"},{"id":"z","text":"Z"}]}`},
+		{ID: 4, VotingConfig: `{"type":"yes_no"}`, IsInformative: 1},
+	}
+
+	tests := []struct {
+		name           string
+		content        map[string]domain.BallotVote
+		expectedErrors map[string]int // matter ID -> number of errors
+	}{
+		{
+			name: "valid ballot",
+			content: map[string]domain.BallotVote{
+				"1": {MatterID: 1, Values: []string{"yes"}},
+				"2": {MatterID: 2, Values: []string{"b"}},
+				"3": {MatterID: 3, Values: []string{"z", "x", "y"}},
+			},
+			expectedErrors: map[string]int{},
+		},
+		{
+			name: "omitted matters are abstentions where allowed",
+			content: map[string]domain.BallotVote{
+				"1": {Values: []string{"no"}},
+			},
+			expectedErrors: map[string]int{},
+		},
+		{
+			name: "abstention not allowed",
+			content: map[string]domain.BallotVote{
+				"1": {Values: []string{"abstain"}},
+			},
+			expectedErrors: map[string]int{"1": 1},
+		},
+		{
+			name:           "missing vote when abstention not allowed",
+			content:        map[string]domain.BallotVote{},
+			expectedErrors: map[string]int{"1": 1},
+		},
+		{
+			name: "unknown option and matter",
+			content: map[string]domain.BallotVote{
+				"1":  {Values: []string{"maybe"}},
+				"2":  {Values: []string{"c"}},
+				"99": {Values: []string{"yes"}},
+			},
+			expectedErrors: map[string]int{"1": 1, "2": 1, "99": 1},
+		},
+		{
+			name: "single choice with two values",
+			content: map[string]domain.BallotVote{
+				"1": {Values: []string{"yes"}},
+				"2": {Values: []string{"a", "b"}},
+			},
+			expectedErrors: map[string]int{"2": 1},
+		},
+		{
+			name: "duplicate ranking entries",
+			content: map[string]domain.BallotVote{
+				"1": {Values: []string{"yes"}},
+				"3": {Values: []string{"x", "x", "y"}},
+			},
+			expectedErrors: map[string]int{"3": 1},
+		},
+		{
+			name: "abstention combined with a choice",
+			content: map[string]domain.BallotVote{
+				"1": {Values: []string{"yes"}},
+				"2": {Values: []string{"abstain", "a"}},
+			},
+			expectedErrors: map[string]int{"2": 1},
+		},
+		{
+			name: "vote on informative matter",
+			content: map[string]domain.BallotVote{
+				"1": {Values: []string{"yes"}},
+				"4": {Values: []string{"yes"}},
+			},
+			expectedErrors: map[string]int{"4": 1},
+		},
+		{
+			name: "matter id mismatch",
+			content: map[string]domain.BallotVote{
+				"1": {MatterID: 2, Values: []string{"yes"}},
+			},
+			expectedErrors: map[string]int{"1": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateBallotContent(matters, tt.content)
+
+			got := make(map[string]int)
+			for _, e := range errs {
+				got[e.MatterID]++
+			}
+			if len(got) != len(tt.expectedErrors) {
+				t.Fatalf("ValidateBallotContent() errors = %+v, expected per-matter counts %v", errs, tt.expectedErrors)
+			}
+			for matterID, count := range tt.expectedErrors {
+				if got[matterID] != count {
+					t.Errorf("matter %s: got %d errors, expected %d (%+v)", matterID, got[matterID], count, errs)
+				}
+			}
+		})
+	}
+}
@@ -6,13 +6,26 @@ import (
 )
 
 // ByWeightStrategy implements voting by weight (combined unit weights per owner)
-type ByWeightStrategy struct{}
+type ByWeightStrategy struct {
+	// CoOwnership is the gathering's co-ownership policy, co-owners voting their shares carry
+	// their share of a unit's weight
+	CoOwnership string
+}
+
+// UnitWeight returns the weight an owner holding the given share of a unit votes with: the whole
+// part for the designated voting owner, the owner's share of it when co-owners vote their shares
+func (s *ByWeightStrategy) UnitWeight(part, share float64) float64 {
+	if !VotesShares(s.CoOwnership) {
+		return part
+	}
+	return part * share
+}
 
 // CalculateVoteWeight returns the sum of all unit weights for a participant
 // In by-weight mode, a participant's vote counts as their total combined weight
 func (s *ByWeightStrategy) CalculateVoteWeight(participant domain.GatheringParticipant, units []handlers.Unit) float64 {
 	// For by-weight voting, the participant's UnitsPart already contains
-	// the sum of all their units' weights
+	// the sum of all their units' weights, co-owners' shares applied
 	return participant.UnitsPart
 }
 
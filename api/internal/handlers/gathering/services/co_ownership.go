@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// Co-ownership policies, how a unit held by several owners votes in a gathering
+const (
+	CoOwnershipDesignated = "designated" // The designated voting owner carries the whole part
+	CoOwnershipSplit      = "split"      // Each co-owner votes their share of the part
+	CoOwnershipAgreement  = "agreement"  // The unit counts only when all co-owners vote alike
+)
+
+// CoOwnershipPolicies lists the co-ownership policies a gathering can use
+var CoOwnershipPolicies = []string{CoOwnershipDesignated, CoOwnershipSplit, CoOwnershipAgreement}
+
+// shareTolerance absorbs the rounding of shares such as thirds
+const shareTolerance = 1e-6
+
+var (
+	// ErrInvalidCoOwnershipPolicy is returned for a policy other than designated, split or agreement
+	ErrInvalidCoOwnershipPolicy = errors.New("invalid co-ownership policy")
+	// ErrCoOwnershipPolicyFixed is returned when the policy is changed once the gathering is published
+	ErrCoOwnershipPolicyFixed = errors.New("co-ownership policy is fixed once the gathering is published")
+	// ErrCoOwnershipByUnit is returned when shares are to be voted in a gathering voting by unit
+	ErrCoOwnershipByUnit = errors.New("co-owners vote their shares only in gatherings voting by weight")
+)
+
+// VotesShares reports whether co-owners vote on their own under the policy, each holding a slot
+// for their share, rather than the designated voting owner voting for the whole unit
+func VotesShares(policy string) bool {
+	return policy == CoOwnershipSplit || policy == CoOwnershipAgreement
+}
+
+// VotingOwners returns the owners who vote for a unit under the policy: the designated voting
+// owner, or every co-owner when they vote their shares
+func VotingOwners(policy string, unit domain.RegisterUnit) []domain.RegisterOwner {
+	if VotesShares(policy) {
+		return unit.Owners
+	}
+	if owner := unit.VotingOwner(); owner != nil {
+		return []domain.RegisterOwner{*owner}
+	}
+	return nil
+}
+
+// IsVotingOwner reports whether the owner votes for the unit under the policy
+func IsVotingOwner(policy string, unit domain.RegisterUnit, ownerID int64) bool {
+	return slices.ContainsFunc(VotingOwners(policy, unit), func(o domain.RegisterOwner) bool {
+		return o.OwnerID == ownerID
+	})
+}
+
+// OwnerShare returns the share of the unit the owner votes under the policy: the whole unit for the
+// designated voting owner, the owner's share otherwise
+func OwnerShare(policy string, unit domain.RegisterUnit, ownerID int64) float64 {
+	if !VotesShares(policy) {
+		return 1
+	}
+	for _, o := range unit.Owners {
+		if o.OwnerID == ownerID {
+			return o.Share
+		}
+	}
+	return 0
+}
+
+// CoOwnershipService handles the co-ownership policy of gatherings
+type CoOwnershipService struct {
+	db *database.Queries
+}
+
+// NewCoOwnershipService creates a new CoOwnershipService
+func NewCoOwnershipService(db *database.Queries) *CoOwnershipService {
+	return &CoOwnershipService{db: db}
+}
+
+// Settings returns how co-owned units vote in the gathering
+func (s *CoOwnershipService) Settings(gathering database.Gathering) domain.CoOwnershipSettings {
+	return domain.CoOwnershipSettings{
+		GatheringID: gathering.ID,
+		Policy:      gathering.CoOwnershipPolicy,
+		VotingMode:  gathering.VotingMode,
+		Editable:    gathering.Status == GatheringStatusDraft,
+	}
+}
+
+// SetPolicy sets how co-owned units vote. Unit slots are laid out by the policy when the register
+// is frozen, so it can change only while the gathering is a draft.
+func (s *CoOwnershipService) SetPolicy(ctx context.Context, gathering database.Gathering, policy string) (domain.CoOwnershipSettings, error) {
+	if !slices.Contains(CoOwnershipPolicies, policy) {
+		return domain.CoOwnershipSettings{}, fmt.Errorf("%w: expected one of %s", ErrInvalidCoOwnershipPolicy, strings.Join(CoOwnershipPolicies, ", "))
+	}
+	if gathering.Status != GatheringStatusDraft {
+		return domain.CoOwnershipSettings{}, ErrCoOwnershipPolicyFixed
+	}
+	if VotesShares(policy) && gathering.VotingMode == "by_unit" {
+		return domain.CoOwnershipSettings{}, ErrCoOwnershipByUnit
+	}
+	err := s.db.SetGatheringCoOwnershipPolicy(ctx, database.SetGatheringCoOwnershipPolicyParams{
+		CoOwnershipPolicy: policy,
+		ID:                gathering.ID,
+	})
+	if err != nil {
+		return domain.CoOwnershipSettings{}, fmt.Errorf("failed to set co-ownership policy: %w", err)
+	}
+	gathering.CoOwnershipPolicy = policy
+	return s.Settings(gathering), nil
+}
+
+// coOwnedUnit is a unit whose co-owners each hold a slot for their share
+type coOwnedUnit struct {
+	Part  float64
+	Slots []database.GetCoOwnerUnitSlotsRow
+}
+
+// AgreedVotes applies the agreement policy to the ballots of a gathering. Co-owners' shares are
+// taken out of their participants' weight and a co-owned unit votes with its whole part, on each
+// matter, only when all its co-owners took part and chose alike. Returns the weight to take out
+// of each participant and the votes of the agreeing units by matter.
+func AgreedVotes(slots []database.GetCoOwnerUnitSlotsRow, ballots map[int64]map[string]domain.BallotVote) (map[int64]float64, map[string][]weightedVote) {
+	units := make(map[int64]*coOwnedUnit)
+	var unitIDs []int64
+	for _, slot := range slots {
+		u, ok := units[slot.UnitID]
+		if !ok {
+			u = &coOwnedUnit{Part: slot.Part}
+			units[slot.UnitID] = u
+			unitIDs = append(unitIDs, slot.UnitID)
+		}
+		u.Slots = append(u.Slots, slot)
+	}
+
+	withheld := make(map[int64]float64)
+	agreed := make(map[string][]weightedVote)
+	for _, unitID := range unitIDs {
+		u := units[unitID]
+		var contents []map[string]domain.BallotVote
+		for _, slot := range u.Slots {
+			participantID, ok := slotParticipant(slot.ParticipantID)
+			if !ok {
+				continue
+			}
+			withheld[participantID] += slot.Part * slot.Share
+			if content, ok := ballots[participantID]; ok {
+				contents = append(contents, content)
+			}
+		}
+		// Every co-owner has to have voted for the unit to count
+		if len(contents) != len(u.Slots) {
+			continue
+		}
+		for matterID, vote := range contents[0] {
+			if len(vote.Values) == 0 {
+				continue
+			}
+			alike := true
+			for _, other := range contents[1:] {
+				if !slices.Equal(other[matterID].Values, vote.Values) {
+					alike = false
+					break
+				}
+			}
+			if alike {
+				agreed[matterID] = append(agreed[matterID], weightedVote{Values: vote.Values, Weight: u.Part})
+			}
+		}
+	}
+	return withheld, agreed
+}
+
+// slotParticipant returns the participant holding a unit slot, if it is claimed
+func slotParticipant(participantID interface{}) (int64, bool) {
+	id, ok := participantID.(int64)
+	return id, ok && id != 0
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// TestVotingOwners tests who votes for a co-owned unit under each policy
+func TestVotingOwners(t *testing.T) {
+	unit := domain.RegisterUnit{UnitID: 1, Part: 0.4, Owners: []domain.RegisterOwner{
+		{OwnerID: 1, IsVoting: true, Share: 0.75},
+		{OwnerID: 2, Share: 0.25},
+	}}
+
+	tests := []struct {
+		policy         string
+		expectedOwners []int64
+		expectedShares []float64
+	}{
+		{CoOwnershipDesignated, []int64{1}, []float64{1}},
+		{CoOwnershipSplit, []int64{1, 2}, []float64{0.75, 0.25}},
+		{CoOwnershipAgreement, []int64{1, 2}, []float64{0.75, 0.25}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			var owners []int64
+			var shares []float64
+			for _, o := range VotingOwners(tt.policy, unit) {
+				owners = append(owners, o.OwnerID)
+				shares = append(shares, OwnerShare(tt.policy, unit, o.OwnerID))
+			}
+			if !reflect.DeepEqual(owners, tt.expectedOwners) {
+				t.Errorf("VotingOwners() = %v, want %v", owners, tt.expectedOwners)
+			}
+			if !reflect.DeepEqual(shares, tt.expectedShares) {
+				t.Errorf("OwnerShare() = %v, want %v", shares, tt.expectedShares)
+			}
+			if got := IsVotingOwner(tt.policy, unit, 2); got != (tt.policy != CoOwnershipDesignated) {
+				t.Errorf("IsVotingOwner(co-owner) = %v", got)
+			}
+		})
+	}
+}
+
+// TestUnitSlotsFor tests that co-owners voting their shares each get a slot
+func TestUnitSlotsFor(t *testing.T) {
+	coOwned := domain.RegisterUnit{UnitID: 7, Owners: []domain.RegisterOwner{
+		{OwnerID: 1, IsVoting: true, Share: 0.5},
+		{OwnerID: 2, Share: 0.5},
+	}}
+	unowned := domain.RegisterUnit{UnitID: 8, Owners: []domain.RegisterOwner{}}
+
+	tests := []struct {
+		name     string
+		policy   string
+		unit     domain.RegisterUnit
+		expected []database.CreateUnitSlotParams
+	}{
+		{"designated", CoOwnershipDesignated, coOwned, []database.CreateUnitSlotParams{{UnitID: 7, Share: 1}}},
+		{"split", CoOwnershipSplit, coOwned, []database.CreateUnitSlotParams{
+			{UnitID: 7, OwnerID: 1, Share: 0.5},
+			{UnitID: 7, OwnerID: 2, Share: 0.5},
+		}},
+		{"split without owners", CoOwnershipSplit, unowned, []database.CreateUnitSlotParams{{UnitID: 8, Share: 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnitSlotsFor(tt.policy, tt.unit); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("UnitSlotsFor() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestByWeightStrategyUnitWeight tests that co-owners voting their shares carry their share of the weight
+func TestByWeightStrategyUnitWeight(t *testing.T) {
+	designated := ByWeightStrategy{CoOwnership: CoOwnershipDesignated}
+	if got := designated.UnitWeight(0.4, 0.25); got != 0.4 {
+		t.Errorf("designated UnitWeight() = %v, want 0.4", got)
+	}
+	split := ByWeightStrategy{CoOwnership: CoOwnershipSplit}
+	if got := split.UnitWeight(0.4, 0.25); math.Abs(got-0.1) > 1e-9 {
+		t.Errorf("split UnitWeight() = %v, want 0.1", got)
+	}
+}
+
+// TestAgreedVotes tests that a co-owned unit votes whole only when all its co-owners chose alike
+func TestAgreedVotes(t *testing.T) {
+	slots := []database.GetCoOwnerUnitSlotsRow{
+		{UnitID: 1, OwnerID: 1, ParticipantID: int64(10), Share: 0.5, Part: 0.2},
+		{UnitID: 1, OwnerID: 2, ParticipantID: int64(11), Share: 0.5, Part: 0.2},
+		{UnitID: 2, OwnerID: 3, ParticipantID: int64(12), Share: 0.6, Part: 0.1},
+		{UnitID: 2, OwnerID: 4, ParticipantID: nil, Share: 0.4, Part: 0.1},
+	}
+	vote := func(values ...string) domain.BallotVote { return domain.BallotVote{Values: values} }
+	ballots := map[int64]map[string]domain.BallotVote{
+		10: {"100": vote("yes"), "101": vote("no")},
+		11: {"100": vote("yes"), "101": vote("yes")},
+		12: {"100": vote("yes"), "101": vote("yes")},
+	}
+
+	withheld, agreed := AgreedVotes(slots, ballots)
+
+	expectedWithheld := map[int64]float64{10: 0.1, 11: 0.1, 12: 0.06}
+	for participantID, want := range expectedWithheld {
+		if got := withheld[participantID]; math.Abs(got-want) > 1e-9 {
+			t.Errorf("withheld[%d] = %v, want %v", participantID, got, want)
+		}
+	}
+	if len(agreed["100"]) != 1 || agreed["100"][0].Weight != 0.2 || agreed["100"][0].Values[0] != "yes" {
+		t.Errorf("agreed[100] = %+v, want unit 1 voting yes with its whole part", agreed["100"])
+	}
+	// The co-owners of unit 1 disagree on matter 101 and unit 2 is missing a co-owner
+	if len(agreed["101"]) != 0 {
+		t.Errorf("agreed[101] = %+v, want none", agreed["101"])
+	}
+}
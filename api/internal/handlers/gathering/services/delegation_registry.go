@@ -0,0 +1,593 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// Statuses of a registered delegation
+const (
+	DelegationPending = "pending" // Its validity period has not started
+	DelegationActive  = "active"
+	DelegationExpired = "expired"
+	DelegationRevoked = "revoked" // Every owner it represented revoked it
+)
+
+// DelegationDocumentTypes are the content types a scanned power of attorney may have
+var DelegationDocumentTypes = []string{"application/pdf", "image/jpeg", "image/png"}
+
+var (
+	// ErrDelegationNotFound is returned when the gathering has no such delegation
+	ErrDelegationNotFound = errors.New("delegation not found")
+	// ErrInvalidDelegation is returned for a delegation missing its delegate, period or owners,
+	// or naming owners and units it cannot cover
+	ErrInvalidDelegation = errors.New("invalid delegation")
+	// ErrDelegationConflict is returned when an owner would be represented by two delegations at once
+	ErrDelegationConflict = errors.New("owner is already represented by a delegate")
+	// ErrProxyLimit is returned when a delegate would represent more owners than the association allows
+	ErrProxyLimit = errors.New("delegate would exceed the proxy limit")
+	// ErrOwnerVotedDirectly is returned when an owner who took part in person is to be represented
+	ErrOwnerVotedDirectly = errors.New("owner has already taken part in person")
+	// ErrOwnerDelegated is returned when an owner represented by a valid delegation takes part in person
+	ErrOwnerDelegated = errors.New("owner is represented by a delegate")
+	// ErrDelegationNotValid is returned when a delegation is used outside its period or after revocation
+	ErrDelegationNotValid = errors.New("delegation is not valid")
+	// ErrDelegationUsed is returned when revoking a delegation the delegate has already acted on
+	ErrDelegationUsed = errors.New("delegate has already taken part on the owner's behalf")
+	// ErrDelegationDocumentMissing is returned when no scanned power of attorney was uploaded
+	ErrDelegationDocumentMissing = errors.New("the scanned power of attorney has not been uploaded")
+	// ErrInvalidDelegationDocument is returned for an upload that is not a PDF or image
+	ErrInvalidDelegationDocument = errors.New("the power of attorney must be a PDF, JPEG or PNG file")
+	// ErrDelegationsClosed is returned when the registry of a closed gathering is changed
+	ErrDelegationsClosed = errors.New("delegations cannot change once the gathering is closed")
+)
+
+// Proxy is what a delegation lets its delegate do for one owner
+type Proxy struct {
+	Delegation database.Delegation
+	OwnerID    int64
+	UnitIDs    []int64
+}
+
+// DelegationService keeps the registry of powers of attorney for each gathering and checks
+// delegates and owners against it
+type DelegationService struct {
+	db                   *database.Queries
+	conn                 *sql.DB
+	voterRegisterService *VoterRegisterService
+	votingRulesService   *VotingRulesService
+}
+
+// NewDelegationService creates a new DelegationService
+func NewDelegationService(db *database.Queries, conn *sql.DB) *DelegationService {
+	return &DelegationService{
+		db:                   db,
+		conn:                 conn,
+		voterRegisterService: NewVoterRegisterService(db),
+		votingRulesService:   NewVotingRulesService(db),
+	}
+}
+
+// WithTx returns a DelegationService reading and writing within the transaction, so that
+// delegates and owners are checked together with recording their participation
+func (s *DelegationService) WithTx(tx *sql.Tx) *DelegationService {
+	qtx := s.db.WithTx(tx)
+	return &DelegationService{
+		db:                   qtx,
+		conn:                 s.conn,
+		voterRegisterService: NewVoterRegisterService(qtx),
+		votingRulesService:   NewVotingRulesService(qtx),
+	}
+}
+
+// List returns the delegations registered for the gathering
+func (s *DelegationService) List(ctx context.Context, gathering database.Gathering, now time.Time) ([]domain.Delegation, error) {
+	delegations, err := s.db.GetDelegationsForGathering(ctx, gathering.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delegations: %w", err)
+	}
+	units, err := s.db.GetDelegationUnitsForGathering(ctx, gathering.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delegated units: %w", err)
+	}
+	documents, err := s.db.GetDelegationDocumentsForGathering(ctx, gathering.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delegation documents: %w", err)
+	}
+	participants, err := s.db.GetGatheringParticipants(ctx, gathering.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get participants: %w", err)
+	}
+	represented := make(map[int64]bool)
+	for _, p := range participants {
+		if p.ParticipantType == "delegate" && p.DelegatingOwnerID.Valid {
+			represented[p.DelegatingOwnerID.Int64] = true
+		}
+	}
+	return describeDelegations(delegations, units, documents, represented, now), nil
+}
+
+// Get returns one delegation registered for the gathering
+func (s *DelegationService) Get(ctx context.Context, gathering database.Gathering, delegationID int64, now time.Time) (domain.Delegation, error) {
+	delegations, err := s.List(ctx, gathering, now)
+	if err != nil {
+		return domain.Delegation{}, err
+	}
+	for _, d := range delegations {
+		if d.ID == delegationID {
+			return d, nil
+		}
+	}
+	return domain.Delegation{}, ErrDelegationNotFound
+}
+
+// Create registers a delegation for the gathering. Each owner is represented for the units they
+// vote for in the gathering's register, all of them unless the request lists some.
+func (s *DelegationService) Create(ctx context.Context, gathering database.Gathering, req domain.DelegationRequest, now time.Time) (domain.Delegation, error) {
+	if isClosed(gathering) {
+		return domain.Delegation{}, ErrDelegationsClosed
+	}
+	req.DelegateName = strings.TrimSpace(req.DelegateName)
+	req.DelegateIdentification = strings.TrimSpace(req.DelegateIdentification)
+	if req.DelegateName == "" || req.DelegateIdentification == "" {
+		return domain.Delegation{}, fmt.Errorf("%w: the delegate's name and identification are required", ErrInvalidDelegation)
+	}
+	validFrom := now
+	if req.ValidFrom != nil {
+		validFrom = *req.ValidFrom
+	}
+	if !req.ValidUntil.After(validFrom) {
+		return domain.Delegation{}, fmt.Errorf("%w: valid_until must be after valid_from", ErrInvalidDelegation)
+	}
+	if len(req.Owners) == 0 {
+		return domain.Delegation{}, fmt.Errorf("%w: at least one owner is required", ErrInvalidDelegation)
+	}
+
+	register, err := s.voterRegisterService.Register(ctx, gathering)
+	if err != nil {
+		return domain.Delegation{}, fmt.Errorf("failed to get voter register: %w", err)
+	}
+	votingUnits := make(map[int64][]int64)
+	owners := make(map[int64]domain.RegisterOwner)
+	for _, unit := range register {
+		for _, voting := range VotingOwners(gathering.CoOwnershipPolicy, unit) {
+			votingUnits[voting.OwnerID] = append(votingUnits[voting.OwnerID], unit.UnitID)
+			owners[voting.OwnerID] = voting
+		}
+	}
+
+	ownerIDs := make([]int64, 0, len(req.Owners))
+	units := make(map[int64][]int64)
+	for _, o := range req.Owners {
+		if _, ok := units[o.OwnerID]; ok {
+			return domain.Delegation{}, fmt.Errorf("%w: owner %d is listed twice", ErrInvalidDelegation, o.OwnerID)
+		}
+		owned := votingUnits[o.OwnerID]
+		if len(owned) == 0 {
+			return domain.Delegation{}, fmt.Errorf("%w: owner %d votes for no unit in this gathering", ErrInvalidDelegation, o.OwnerID)
+		}
+		if sameIdentification(owners[o.OwnerID].Identification, req.DelegateIdentification) {
+			return domain.Delegation{}, fmt.Errorf("%w: owner %d cannot be their own delegate", ErrInvalidDelegation, o.OwnerID)
+		}
+		if len(o.UnitIDs) == 0 {
+			units[o.OwnerID] = owned
+		} else {
+			for _, unitID := range o.UnitIDs {
+				if !slices.Contains(owned, unitID) {
+					return domain.Delegation{}, fmt.Errorf("%w: owner %d does not vote for unit %d", ErrInvalidDelegation, o.OwnerID, unitID)
+				}
+			}
+			units[o.OwnerID] = o.UnitIDs
+		}
+		ownerIDs = append(ownerIDs, o.OwnerID)
+	}
+
+	maxProxies, err := s.maxProxies(ctx, gathering)
+	if err != nil {
+		return domain.Delegation{}, err
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Delegation{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+
+	// Checked in the transaction, so that an owner cannot take part while being represented, and
+	// concurrent delegations cannot together represent an owner twice or exceed the proxy limit
+	for _, ownerID := range ownerIDs {
+		participations, err := qtx.GetOwnerParticipations(ctx, database.GetOwnerParticipationsParams{
+			GatheringID:       gathering.ID,
+			OwnerID:           sql.NullInt64{Int64: ownerID, Valid: true},
+			DelegatingOwnerID: sql.NullInt64{Int64: ownerID, Valid: true},
+		})
+		if err != nil {
+			return domain.Delegation{}, fmt.Errorf("failed to get owner participations: %w", err)
+		}
+		for _, p := range participations {
+			if p.ParticipantType == "delegate" {
+				return domain.Delegation{}, fmt.Errorf("%w: %s was already represented by %s", ErrDelegationConflict, owners[ownerID].Name, p.ParticipantName)
+			}
+			return domain.Delegation{}, fmt.Errorf("%w: %s", ErrOwnerVotedDirectly, owners[ownerID].Name)
+		}
+	}
+	existing, err := qtx.GetDelegationUnitsForGathering(ctx, gathering.ID)
+	if err != nil {
+		return domain.Delegation{}, fmt.Errorf("failed to get delegated units: %w", err)
+	}
+	if err := checkDelegation(existing, req.DelegateIdentification, ownerIDs, validFrom, req.ValidUntil, maxProxies); err != nil {
+		return domain.Delegation{}, err
+	}
+
+	delegation, err := qtx.CreateDelegation(ctx, database.CreateDelegationParams{
+		GatheringID:            gathering.ID,
+		DelegateName:           req.DelegateName,
+		DelegateIdentification: req.DelegateIdentification,
+		DocumentRef:            strings.TrimSpace(req.DocumentRef),
+		ValidFrom:              validFrom,
+		ValidUntil:             req.ValidUntil,
+	})
+	if err != nil {
+		return domain.Delegation{}, fmt.Errorf("failed to create delegation: %w", err)
+	}
+	for _, ownerID := range ownerIDs {
+		for _, unitID := range units[ownerID] {
+			err := qtx.CreateDelegationUnit(ctx, database.CreateDelegationUnitParams{
+				DelegationID: delegation.ID,
+				OwnerID:      ownerID,
+				UnitID:       unitID,
+			})
+			if err != nil {
+				return domain.Delegation{}, fmt.Errorf("failed to record delegated unit: %w", err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return domain.Delegation{}, fmt.Errorf("failed to commit delegation: %w", err)
+	}
+
+	return s.Get(ctx, gathering, delegation.ID, now)
+}
+
+// Revoke records an owner revoking the delegation, or every owner it represents when ownerID is
+// nil. A delegate who has already taken part for an owner keeps the vote they cast.
+func (s *DelegationService) Revoke(ctx context.Context, gathering database.Gathering, delegationID int64, ownerID *int64, note string, now time.Time) (domain.Delegation, error) {
+	if isClosed(gathering) {
+		return domain.Delegation{}, ErrDelegationsClosed
+	}
+
+	// Whether the delegate has taken part is checked with the revocation, so that they cannot
+	// take part in between
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Delegation{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	stx := s.WithTx(tx)
+
+	delegation, err := stx.Get(ctx, gathering, delegationID, now)
+	if err != nil {
+		return domain.Delegation{}, err
+	}
+
+	if ownerID != nil && !slices.ContainsFunc(delegation.Owners, func(o domain.DelegatedOwner) bool { return o.OwnerID == *ownerID }) {
+		return domain.Delegation{}, fmt.Errorf("%w: the delegation does not represent owner %d", ErrInvalidDelegation, *ownerID)
+	}
+	var revoking []domain.DelegatedOwner
+	for _, owner := range delegation.Owners {
+		if owner.RevokedAt == nil && (ownerID == nil || owner.OwnerID == *ownerID) {
+			revoking = append(revoking, owner)
+		}
+	}
+	for _, owner := range revoking {
+		if owner.Used {
+			return domain.Delegation{}, fmt.Errorf("%w: %s", ErrDelegationUsed, owner.OwnerName)
+		}
+	}
+
+	for _, owner := range revoking {
+		_, err := stx.db.RevokeDelegationUnits(ctx, database.RevokeDelegationUnitsParams{
+			RevokedAt:      sql.NullTime{Time: now, Valid: true},
+			RevocationNote: strings.TrimSpace(note),
+			DelegationID:   delegationID,
+			OwnerID:        owner.OwnerID,
+		})
+		if err != nil {
+			return domain.Delegation{}, fmt.Errorf("failed to revoke delegation: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return domain.Delegation{}, fmt.Errorf("failed to commit revocation: %w", err)
+	}
+	return s.Get(ctx, gathering, delegationID, now)
+}
+
+// SaveDocument stores the scanned power of attorney of a delegation, replacing any earlier scan
+func (s *DelegationService) SaveDocument(ctx context.Context, gathering database.Gathering, delegationID int64, fileName string, content []byte, now time.Time) (domain.Delegation, error) {
+	if _, err := s.delegation(ctx, gathering.ID, delegationID); err != nil {
+		return domain.Delegation{}, err
+	}
+	contentType := http.DetectContentType(content)
+	if !slices.Contains(DelegationDocumentTypes, contentType) {
+		return domain.Delegation{}, ErrInvalidDelegationDocument
+	}
+	err := s.db.UpsertDelegationDocument(ctx, database.UpsertDelegationDocumentParams{
+		DelegationID: delegationID,
+		FileName:     fileName,
+		ContentType:  contentType,
+		Content:      content,
+	})
+	if err != nil {
+		return domain.Delegation{}, fmt.Errorf("failed to save delegation document: %w", err)
+	}
+	return s.Get(ctx, gathering, delegationID, now)
+}
+
+// Document returns the scanned power of attorney of a delegation
+func (s *DelegationService) Document(ctx context.Context, gathering database.Gathering, delegationID int64) (database.DelegationDocument, error) {
+	if _, err := s.delegation(ctx, gathering.ID, delegationID); err != nil {
+		return database.DelegationDocument{}, err
+	}
+	document, err := s.db.GetDelegationDocument(ctx, delegationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.DelegationDocument{}, ErrDelegationDocumentMissing
+	}
+	if err != nil {
+		return database.DelegationDocument{}, fmt.Errorf("failed to get delegation document: %w", err)
+	}
+	return document, nil
+}
+
+// ResolveProxy checks that the delegation lets its delegate take part for the owner now and
+// returns the units they may vote for. The owner may be omitted when the delegation still
+// represents a single one, and the delegation when a request names only the owner, as clients
+// did before the registry: the delegation representing the owner now is used.
+// Call it on a service bound to the transaction recording the delegate's participation.
+func (s *DelegationService) ResolveProxy(ctx context.Context, gathering database.Gathering, delegationID, ownerID *int64, now time.Time) (Proxy, error) {
+	rows, err := s.db.GetDelegationUnitsForGathering(ctx, gathering.ID)
+	if err != nil {
+		return Proxy{}, fmt.Errorf("failed to get delegated units: %w", err)
+	}
+	if delegationID == nil {
+		if ownerID == nil {
+			return Proxy{}, fmt.Errorf("%w: name the delegation or the owner it represents", ErrInvalidDelegation)
+		}
+		delegationID = currentDelegation(rows, *ownerID, now)
+		if delegationID == nil {
+			return Proxy{}, fmt.Errorf("%w: no delegation in the registry represents owner %d now", ErrDelegationNotFound, *ownerID)
+		}
+	}
+	delegation, err := s.delegation(ctx, gathering.ID, *delegationID)
+	if err != nil {
+		return Proxy{}, err
+	}
+
+	proxy := Proxy{Delegation: delegation}
+	if ownerID != nil {
+		proxy.OwnerID = *ownerID
+	} else {
+		// Owners who revoked it are left out, unless none is left to report the revocation for
+		var represented, unrevoked []int64
+		for _, row := range rows {
+			if row.DelegationID != *delegationID || slices.Contains(represented, row.OwnerID) {
+				continue
+			}
+			represented = append(represented, row.OwnerID)
+			if !row.RevokedAt.Valid {
+				unrevoked = append(unrevoked, row.OwnerID)
+			}
+		}
+		if len(unrevoked) == 0 {
+			unrevoked = represented
+		}
+		if len(unrevoked) != 1 {
+			return Proxy{}, fmt.Errorf("%w: the delegation represents %d owners, name the owner", ErrInvalidDelegation, len(unrevoked))
+		}
+		proxy.OwnerID = unrevoked[0]
+	}
+
+	revoked := false
+	for _, row := range rows {
+		if row.DelegationID == *delegationID && row.OwnerID == proxy.OwnerID {
+			proxy.UnitIDs = append(proxy.UnitIDs, row.UnitID)
+			revoked = row.RevokedAt.Valid
+		}
+	}
+	if len(proxy.UnitIDs) == 0 {
+		return Proxy{}, fmt.Errorf("%w: the delegation does not represent owner %d", ErrInvalidDelegation, proxy.OwnerID)
+	}
+	if revoked {
+		return Proxy{}, fmt.Errorf("%w: revoked by the owner", ErrDelegationNotValid)
+	}
+	if now.Before(delegation.ValidFrom) || !now.Before(delegation.ValidUntil) {
+		return Proxy{}, fmt.Errorf("%w: valid from %s until %s", ErrDelegationNotValid,
+			delegation.ValidFrom.Format(time.RFC3339), delegation.ValidUntil.Format(time.RFC3339))
+	}
+	if _, err := s.db.GetDelegationDocument(ctx, *delegationID); errors.Is(err, sql.ErrNoRows) {
+		return Proxy{}, ErrDelegationDocumentMissing
+	} else if err != nil {
+		return Proxy{}, fmt.Errorf("failed to get delegation document: %w", err)
+	}
+
+	participations, err := s.db.GetOwnerParticipations(ctx, database.GetOwnerParticipationsParams{
+		GatheringID: gathering.ID,
+		OwnerID:     sql.NullInt64{Int64: proxy.OwnerID, Valid: true},
+	})
+	if err != nil {
+		return Proxy{}, fmt.Errorf("failed to get owner participations: %w", err)
+	}
+	if len(participations) > 0 {
+		return Proxy{}, ErrOwnerVotedDirectly
+	}
+	return proxy, nil
+}
+
+// CheckDirectVote returns ErrOwnerDelegated when a delegation valid now represents the owner,
+// who then has to revoke it before taking part in person. Call it on a service bound to the
+// transaction recording the owner's participation.
+func (s *DelegationService) CheckDirectVote(ctx context.Context, gatheringID, ownerID int64, now time.Time) error {
+	rows, err := s.db.GetDelegationUnitsForGathering(ctx, gatheringID)
+	if err != nil {
+		return fmt.Errorf("failed to get delegated units: %w", err)
+	}
+	for _, row := range rows {
+		if row.OwnerID == ownerID && !row.RevokedAt.Valid && !now.Before(row.ValidFrom) && now.Before(row.ValidUntil) {
+			return fmt.Errorf("%w: %s", ErrOwnerDelegated, row.DelegateName)
+		}
+	}
+	return nil
+}
+
+// currentDelegation returns the unrevoked delegation representing the owner now, if any
+func currentDelegation(rows []database.GetDelegationUnitsForGatheringRow, ownerID int64, now time.Time) *int64 {
+	for _, row := range rows {
+		if row.OwnerID == ownerID && !row.RevokedAt.Valid && !now.Before(row.ValidFrom) && now.Before(row.ValidUntil) {
+			return &row.DelegationID
+		}
+	}
+	return nil
+}
+
+// delegation returns a delegation of the gathering
+func (s *DelegationService) delegation(ctx context.Context, gatheringID, delegationID int64) (database.Delegation, error) {
+	delegation, err := s.db.GetDelegation(ctx, database.GetDelegationParams{ID: delegationID, GatheringID: gatheringID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Delegation{}, ErrDelegationNotFound
+	}
+	if err != nil {
+		return database.Delegation{}, fmt.Errorf("failed to get delegation: %w", err)
+	}
+	return delegation, nil
+}
+
+// maxProxies returns the proxy limit of the rules the gathering was published with, or of the
+// association's current rules while it is a draft
+func (s *DelegationService) maxProxies(ctx context.Context, gathering database.Gathering) (int64, error) {
+	if gathering.VotingRules.Valid {
+		return GatheringVotingRules(gathering).MaxProxiesPerDelegate, nil
+	}
+	profile, err := s.votingRulesService.GetProfile(ctx, gathering.AssociationID)
+	if err != nil {
+		return 0, err
+	}
+	return profile.Rules.MaxProxiesPerDelegate, nil
+}
+
+// checkDelegation checks a new delegation against the unrevoked ones whose period overlaps it:
+// none may already represent one of its owners, and the delegate may not represent more than
+// maxProxies owners over all of them. A maxProxies of 0 means no limit.
+func checkDelegation(existing []database.GetDelegationUnitsForGatheringRow, delegateIdentification string, ownerIDs []int64, validFrom, validUntil time.Time, maxProxies int64) error {
+	represented := make(map[int64]bool)
+	for _, row := range existing {
+		if row.RevokedAt.Valid || !row.ValidFrom.Before(validUntil) || !validFrom.Before(row.ValidUntil) {
+			continue
+		}
+		if slices.Contains(ownerIDs, row.OwnerID) {
+			return fmt.Errorf("%w: %s is already represented by %s", ErrDelegationConflict, row.OwnerName, row.DelegateName)
+		}
+		if sameIdentification(row.DelegateIdentification, delegateIdentification) {
+			represented[row.OwnerID] = true
+		}
+	}
+	total := int64(len(represented) + len(ownerIDs))
+	if maxProxies > 0 && total > maxProxies {
+		return fmt.Errorf("%w: the delegate would represent %d owners, at most %d are allowed", ErrProxyLimit, total, maxProxies)
+	}
+	return nil
+}
+
+// describeDelegations builds the registry as shown to administrators. represented holds the owners
+// a delegate has taken part for.
+func describeDelegations(delegations []database.Delegation, units []database.GetDelegationUnitsForGatheringRow, documents []database.GetDelegationDocumentsForGatheringRow, represented map[int64]bool, now time.Time) []domain.Delegation {
+	response := make([]domain.Delegation, 0, len(delegations))
+	for _, d := range delegations {
+		delegation := domain.Delegation{
+			ID:                     d.ID,
+			GatheringID:            d.GatheringID,
+			DelegateName:           d.DelegateName,
+			DelegateIdentification: d.DelegateIdentification,
+			DocumentRef:            d.DocumentRef,
+			ValidFrom:              d.ValidFrom,
+			ValidUntil:             d.ValidUntil,
+			Owners:                 []domain.DelegatedOwner{},
+			CreatedAt:              d.CreatedAt,
+		}
+		for _, row := range units {
+			if row.DelegationID != d.ID {
+				continue
+			}
+			last := len(delegation.Owners) - 1
+			if last < 0 || delegation.Owners[last].OwnerID != row.OwnerID {
+				owner := domain.DelegatedOwner{
+					OwnerID:        row.OwnerID,
+					OwnerName:      row.OwnerName,
+					UnitIDs:        []int64{},
+					RevocationNote: row.RevocationNote,
+					Used:           represented[row.OwnerID],
+				}
+				if row.RevokedAt.Valid {
+					owner.RevokedAt = &row.RevokedAt.Time
+				}
+				delegation.Owners = append(delegation.Owners, owner)
+				last++
+			}
+			delegation.Owners[last].UnitIDs = append(delegation.Owners[last].UnitIDs, row.UnitID)
+		}
+		for _, doc := range documents {
+			if doc.DelegationID == d.ID {
+				delegation.Document = &domain.DelegationDocument{
+					FileName:    doc.FileName,
+					ContentType: doc.ContentType,
+					Size:        doc.Size,
+					UploadedAt:  doc.UploadedAt,
+				}
+			}
+		}
+		delegation.Status = delegationStatus(delegation, now)
+		response = append(response, delegation)
+	}
+	return response
+}
+
+// delegationStatus tells whether a delegation can be used now
+func delegationStatus(delegation domain.Delegation, now time.Time) string {
+	revoked := len(delegation.Owners) > 0
+	for _, owner := range delegation.Owners {
+		if owner.RevokedAt == nil {
+			revoked = false
+		}
+	}
+	switch {
+	case revoked:
+		return DelegationRevoked
+	case now.Before(delegation.ValidFrom):
+		return DelegationPending
+	case !now.Before(delegation.ValidUntil):
+		return DelegationExpired
+	default:
+		return DelegationActive
+	}
+}
+
+// sameIdentification compares identification numbers as typed, ignoring case and spaces
+func sameIdentification(a, b string) bool {
+	normalize := func(s string) string {
+		return strings.ToUpper(strings.Join(strings.Fields(s), ""))
+	}
+	return normalize(a) != "" && normalize(a) == normalize(b)
+}
+
+// isClosed reports whether the gathering's voting is over
+func isClosed(gathering database.Gathering) bool {
+	return gathering.Status == "closed" || gathering.Status == "tallied"
+}
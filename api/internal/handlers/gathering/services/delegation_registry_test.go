@@ -0,0 +1,125 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// TestCheckDelegation tests the conflicts and proxy limit a new delegation is checked for
+func TestCheckDelegation(t *testing.T) {
+	day := time.Date(2026, 11, 5, 0, 0, 0, 0, time.UTC)
+	row := func(delegationID, ownerID int64, identification string, from, until time.Time, revoked bool) database.GetDelegationUnitsForGatheringRow {
+		return database.GetDelegationUnitsForGatheringRow{
+			DelegationID:           delegationID,
+			OwnerID:                ownerID,
+			UnitID:                 ownerID * 10,
+			RevokedAt:              sql.NullTime{Time: day, Valid: revoked},
+			OwnerName:              "Owner",
+			DelegateName:           "Delegate",
+			DelegateIdentification: identification,
+			ValidFrom:              from,
+			ValidUntil:             until,
+		}
+	}
+	meeting := [2]time.Time{day, day.Add(24 * time.Hour)}
+	existing := []database.GetDelegationUnitsForGatheringRow{
+		row(1, 1, "2001000000001", meeting[0], meeting[1], false),
+		row(1, 2, "2001000000001", meeting[0], meeting[1], false),
+		row(2, 3, "2001000000002", meeting[0], meeting[1], true),
+		row(3, 4, "2001000000001", day.Add(-48*time.Hour), day.Add(-24*time.Hour), false),
+	}
+
+	tests := []struct {
+		name           string
+		identification string
+		ownerIDs       []int64
+		from, until    time.Time
+		maxProxies     int64
+		expected       error
+	}{
+		{"new owner within limit", "2001000000001", []int64{5}, meeting[0], meeting[1], 3, nil},
+		{"owner already represented", "2001000000009", []int64{2}, meeting[0], meeting[1], 0, ErrDelegationConflict},
+		{"owner represented by the same delegate", "2001000000001", []int64{1}, meeting[0], meeting[1], 0, ErrDelegationConflict},
+		{"owner whose delegation was revoked", "2001000000009", []int64{3}, meeting[0], meeting[1], 0, nil},
+		{"owner represented in another period", "2001000000009", []int64{4}, meeting[0], meeting[1], 0, nil},
+		{"over the limit", "2001000000001", []int64{5, 6}, meeting[0], meeting[1], 3, ErrProxyLimit},
+		{"identification typed with spaces", " 2001 0000 00001 ", []int64{5, 6}, meeting[0], meeting[1], 3, ErrProxyLimit},
+		{"no limit", "2001000000001", []int64{5, 6, 7}, meeting[0], meeting[1], 0, nil},
+		{"expired delegations do not count", "2001000000001", []int64{5}, day.Add(48 * time.Hour), day.Add(72 * time.Hour), 1, nil},
+		{"new delegate", "2001000000003", []int64{5, 6}, meeting[0], meeting[1], 2, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDelegation(existing, tt.identification, tt.ownerIDs, tt.from, tt.until, tt.maxProxies)
+			if !errors.Is(err, tt.expected) || (err == nil) != (tt.expected == nil) {
+				t.Errorf("checkDelegation() = %v, expected %v", err, tt.expected)
+			}
+		})
+	}
+}
+
+// TestDelegationStatus tests that a delegation is active within its period until every owner revokes it
+func TestDelegationStatus(t *testing.T) {
+	from := time.Date(2026, 11, 5, 8, 0, 0, 0, time.UTC)
+	until := from.Add(12 * time.Hour)
+	revokedAt := from.Add(time.Hour)
+	owner := domain.DelegatedOwner{OwnerID: 1}
+	revoked := domain.DelegatedOwner{OwnerID: 2, RevokedAt: &revokedAt}
+
+	tests := []struct {
+		name     string
+		owners   []domain.DelegatedOwner
+		now      time.Time
+		expected string
+	}{
+		{"before the period", []domain.DelegatedOwner{owner}, from.Add(-time.Minute), DelegationPending},
+		{"at the start", []domain.DelegatedOwner{owner}, from, DelegationActive},
+		{"one owner revoked", []domain.DelegatedOwner{owner, revoked}, from.Add(2 * time.Hour), DelegationActive},
+		{"every owner revoked", []domain.DelegatedOwner{revoked}, from.Add(2 * time.Hour), DelegationRevoked},
+		{"at the end", []domain.DelegatedOwner{owner}, until, DelegationExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delegation := domain.Delegation{ValidFrom: from, ValidUntil: until, Owners: tt.owners}
+			if got := delegationStatus(delegation, tt.now); got != tt.expected {
+				t.Errorf("delegationStatus() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestCurrentDelegation tests picking the delegation representing an owner when a client names only the owner
+func TestCurrentDelegation(t *testing.T) {
+	day := time.Date(2026, 11, 5, 0, 0, 0, 0, time.UTC)
+	rows := []database.GetDelegationUnitsForGatheringRow{
+		{DelegationID: 1, OwnerID: 1, ValidFrom: day.Add(-48 * time.Hour), ValidUntil: day.Add(-24 * time.Hour)},
+		{DelegationID: 2, OwnerID: 1, ValidFrom: day, ValidUntil: day.Add(24 * time.Hour)},
+		{DelegationID: 3, OwnerID: 2, ValidFrom: day, ValidUntil: day.Add(24 * time.Hour), RevokedAt: sql.NullTime{Time: day, Valid: true}},
+	}
+
+	tests := []struct {
+		name     string
+		ownerID  int64
+		expected int64 // 0 when no delegation represents the owner
+	}{
+		{"current delegation over an expired one", 1, 2},
+		{"revoked delegation", 2, 0},
+		{"owner without delegation", 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := currentDelegation(rows, tt.ownerID, day.Add(time.Hour))
+			if (got == nil) != (tt.expected == 0) || (got != nil && *got != tt.expected) {
+				t.Errorf("currentDelegation() = %v, expected %d", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,660 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/pdf"
+)
+
+// ErrOwnerNotOnRegister is returned when a ballot is requested for an owner who does not vote in the gathering
+var ErrOwnerNotOnRegister = errors.New("owner does not vote in this gathering")
+
+// documentDateFormat is how dates are printed in every language
+const documentDateFormat = "02.01.2006 15:04"
+
+// DocumentService renders the printed documents of a gathering as PDF
+type DocumentService struct {
+	db                   *database.Queries
+	i18n                 *I18nService
+	voterRegisterService *VoterRegisterService
+	votingResultsService *VotingResultsService
+}
+
+// NewDocumentService creates a new DocumentService
+func NewDocumentService(db *database.Queries, i18n *I18nService, votingResultsService *VotingResultsService) *DocumentService {
+	return &DocumentService{
+		db:                   db,
+		i18n:                 i18n,
+		voterRegisterService: NewVoterRegisterService(db),
+		votingResultsService: votingResultsService,
+	}
+}
+
+// registerVoter is a voting owner with the registered units they vote for. When co-owners vote
+// their shares, a unit's part and area are the owner's share of them.
+type registerVoter struct {
+	owner domain.RegisterOwner
+	units []domain.RegisterUnit
+}
+
+// voters groups the register by voting owner under the co-ownership policy, sorted by name
+func voters(policy string, register []domain.RegisterUnit) []registerVoter {
+	var result []registerVoter
+	index := make(map[int64]int)
+	for _, unit := range register {
+		for _, owner := range VotingOwners(policy, unit) {
+			i, ok := index[owner.OwnerID]
+			if !ok {
+				i = len(result)
+				index[owner.OwnerID] = i
+				result = append(result, registerVoter{owner: owner})
+			}
+			share := OwnerShare(policy, unit, owner.OwnerID)
+			voted := unit
+			voted.Part *= share
+			voted.Area *= share
+			result[i].units = append(result[i].units, voted)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].owner.Name != result[j].owner.Name {
+			return result[i].owner.Name < result[j].owner.Name
+		}
+		return result[i].owner.OwnerID < result[j].owner.OwnerID
+	})
+	return result
+}
+
+// RenderBallots renders a paper ballot for each voting owner on the register, pre-filled with
+// the owner, the units they vote for and the matters of the gathering. When ownerID is not
+// zero only that owner's ballot is rendered.
+func (s *DocumentService) RenderBallots(ctx context.Context, gathering database.Gathering, lang string, ownerID int64) ([]byte, error) {
+	register, err := s.voterRegisterService.Register(ctx, gathering)
+	if err != nil {
+		return nil, err
+	}
+	matters, err := s.db.GetVotingMatters(ctx, gathering.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voting matters: %w", err)
+	}
+
+	owners := voters(gathering.CoOwnershipPolicy, register)
+	if ownerID != 0 {
+		owners = slices.DeleteFunc(owners, func(v registerVoter) bool { return v.owner.OwnerID != ownerID })
+		if len(owners) == 0 {
+			return nil, ErrOwnerNotOnRegister
+		}
+	}
+
+	d, err := s.newDocument(gathering, s.i18n.Translate(KeyBallotTitle, lang), lang)
+	if err != nil {
+		return nil, err
+	}
+	t := func(key string) string { return s.i18n.Translate(key, lang) }
+	header := s.newHeader(ctx, gathering, lang)
+
+	for _, voter := range owners {
+		d.AddPage()
+		s.header(d, header, t(KeyBallotTitle))
+
+		d.Text(fmt.Sprintf("%s: %s", t(KeyBallotOwner), voter.owner.Name), pdf.Style{Bold: true})
+		if voter.owner.Identification != "" {
+			d.Text(fmt.Sprintf("%s: %s", t(KeyBallotIdentification), voter.owner.Identification), pdf.Style{})
+		}
+		d.Space(4)
+
+		var totalPart, totalArea float64
+		rows := make([][]string, 0, len(voter.units))
+		for _, unit := range voter.units {
+			rows = append(rows, []string{
+				unit.UnitNumber,
+				unit.BuildingName,
+				fmt.Sprintf("%.2f", unit.Area),
+				fmt.Sprintf("%.4f", unit.Part),
+			})
+			totalPart += unit.Part
+			totalArea += unit.Area
+		}
+		d.Table(pdf.Table{
+			Columns: []pdf.Column{
+				{Header: t(KeyUnitNumber), Width: 2},
+				{Header: t(KeyUnitBuilding), Width: 4},
+				{Header: t(KeyStatisticsArea), Width: 2, Align: pdf.AlignRight},
+				{Header: t(KeyStatisticsWeight), Width: 2, Align: pdf.AlignRight},
+			},
+			Rows:  rows,
+			Total: []string{t(KeyTotal), "", fmt.Sprintf("%.2f", totalArea), fmt.Sprintf("%.4f", totalPart)},
+			Size:  9,
+		})
+		d.Space(8)
+		d.Text(t(KeyBallotInstructions), pdf.Style{Size: 9})
+		d.Space(6)
+
+		for i, matter := range matters {
+			s.ballotMatter(d, i+1, matter, lang)
+		}
+
+		d.Space(12)
+		d.SignatureLines([]string{t(KeyBallotSignature), t(KeyBallotDate)})
+	}
+	if len(owners) == 0 {
+		d.AddPage()
+		s.header(d, header, t(KeyBallotTitle))
+	}
+	return d.Bytes(), nil
+}
+
+// ballotMatter renders a matter of a ballot with the boxes or lines to mark the vote on
+func (s *DocumentService) ballotMatter(d *pdf.Document, number int, matter database.VotingMatter, lang string) {
+	t := func(key string) string { return s.i18n.Translate(key, lang) }
+	var config domain.VotingConfig
+	json.Unmarshal([]byte(matter.VotingConfig), &config)
+
+	title := fmt.Sprintf("%d. %s", number, matterTitle(matter, lang))
+	description := matterDescription(matter, lang)
+
+	// Keep the title with its description and the first line to vote on
+	height := d.TextHeight(title, pdf.Style{Size: 11, Bold: true}) + 20
+	if description != "" {
+		height += d.TextHeight(description, pdf.Style{Size: 9, Indent: 12})
+	}
+	d.EnsureSpace(height)
+	d.Text(title, pdf.Style{Size: 11, Bold: true})
+	if description != "" {
+		d.Text(description, pdf.Style{Size: 9, Indent: 12})
+	}
+	d.Space(3)
+
+	style := pdf.Style{Indent: 12}
+	switch {
+	case matter.IsInformative != 0:
+		d.Text(t(KeyBallotInformative), pdf.Style{Size: 9, Indent: 12})
+	case config.Type == "yes_no":
+		labels := []string{strings.ToUpper(t(KeyVoteYes)), strings.ToUpper(t(KeyVoteNo))}
+		if config.AllowAbstention {
+			labels = append(labels, strings.ToUpper(t(KeyVoteAbstain)))
+		}
+		d.Checkboxes(labels, style)
+	case config.Type == "single_choice" || config.Type == "multiple_choice":
+		if config.Type == "multiple_choice" {
+			d.Text(t(KeyBallotMultipleChoice), pdf.Style{Size: 9, Indent: 12})
+		}
+		labels := make([]string, 0, len(config.Options)+1)
+		for _, option := range config.Options {
+			labels = append(labels, option.Text)
+		}
+		if config.AllowAbstention {
+			labels = append(labels, strings.ToUpper(t(KeyVoteAbstain)))
+		}
+		d.Checkboxes(labels, style)
+	case config.Type == "ranking":
+		d.Text(t(KeyBallotRankInstructions), pdf.Style{Size: 9, Indent: 12})
+		for _, option := range config.Options {
+			d.FillIn(option.Text, style)
+		}
+	}
+	d.Space(10)
+}
+
+// RenderAttendanceSheet renders the registration sheet of the gathering: every voting owner
+// on the register with their units and weight, and room to sign on arrival
+func (s *DocumentService) RenderAttendanceSheet(ctx context.Context, gathering database.Gathering, lang string) ([]byte, error) {
+	register, err := s.voterRegisterService.Register(ctx, gathering)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := s.newDocument(gathering, s.i18n.Translate(KeyAttendanceTitle, lang), lang)
+	if err != nil {
+		return nil, err
+	}
+	t := func(key string) string { return s.i18n.Translate(key, lang) }
+	header := s.newHeader(ctx, gathering, lang)
+
+	d.AddPage()
+	s.header(d, header, t(KeyAttendanceTitle))
+
+	owners := voters(gathering.CoOwnershipPolicy, register)
+	var totalUnits int
+	var totalPart float64
+	rows := make([][]string, 0, len(owners))
+	for i, voter := range owners {
+		numbers := make([]string, len(voter.units))
+		var part float64
+		for j, unit := range voter.units {
+			numbers[j] = unit.UnitNumber
+			part += unit.Part
+		}
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", i+1),
+			voter.owner.Name,
+			strings.Join(numbers, ", "),
+			fmt.Sprintf("%.4f", part),
+			"",
+			"",
+		})
+		totalUnits += len(voter.units)
+		totalPart += part
+	}
+	d.Table(pdf.Table{
+		Columns: []pdf.Column{
+			{Header: t(KeyAttendanceNumber), Width: 0.7, Align: pdf.AlignRight},
+			{Header: t(KeyAttendanceOwner), Width: 3.2},
+			{Header: t(KeyAttendanceUnits), Width: 1.8},
+			{Header: t(KeyStatisticsWeight), Width: 1.8, Align: pdf.AlignRight},
+			{Header: t(KeyAttendanceRepresentative), Width: 2.5},
+			{Header: t(KeyAttendanceSignature), Width: 2},
+		},
+		Rows:      rows,
+		Total:     []string{"", t(KeyTotal), fmt.Sprintf("%d", totalUnits), fmt.Sprintf("%.4f", totalPart), "", ""},
+		Size:      9,
+		RowHeight: 26,
+	})
+
+	d.Space(24)
+	d.SignatureLines([]string{t(KeySignatureChair), t(KeySignatureSecretary)})
+	return d.Bytes(), nil
+}
+
+// qrCodeWidth is the printed width of the QR code of an invitation letter, in points
+const qrCodeWidth = 150
+
+// RenderInvitationLetters renders a letter for each invitation, to be handed to owners who vote
+// without email: their units, until when they can vote and a QR code linking to the member app
+func (s *DocumentService) RenderInvitationLetters(ctx context.Context, gathering database.Gathering, lang string, invitations []domain.IssuedInvitation) ([]byte, error) {
+	d, err := s.newDocument(gathering, s.i18n.Translate(KeyLetterTitle, lang), lang)
+	if err != nil {
+		return nil, err
+	}
+	t := func(key string) string { return s.i18n.Translate(key, lang) }
+	header := s.newHeader(ctx, gathering, lang)
+
+	for _, invitation := range invitations {
+		modules, err := qrModules(invitation.Link)
+		if err != nil {
+			return nil, err
+		}
+
+		d.AddPage()
+		s.header(d, header, t(KeyLetterTitle))
+		d.Text(fmt.Sprintf(t(KeyLetterGreeting), invitation.OwnerName), pdf.Style{Size: 11, Bold: true})
+		d.Space(4)
+		d.Text(t(KeyLetterIntro), pdf.Style{})
+		d.Space(6)
+		d.Text(t(KeyLetterUnits)+": "+strings.Join(invitation.Units, ", "), pdf.Style{})
+		d.Text(fmt.Sprintf(t(KeyLetterDeadline), VotingClosesAt(gathering).Format(documentDateFormat)), pdf.Style{Bold: true})
+		d.Space(12)
+
+		// Scanners need a light margin of four modules around the code
+		quietZone := 4 * qrCodeWidth / float64(len(modules))
+		d.Text(t(KeyLetterScan), pdf.Style{Align: pdf.AlignCenter})
+		d.Space(quietZone)
+		d.Matrix(modules, qrCodeWidth, pdf.AlignCenter)
+		d.Space(quietZone)
+		d.Text(invitation.Link, pdf.Style{Size: 9, Align: pdf.AlignCenter})
+		d.Space(12)
+
+		d.Text(t(KeyLetterPersonal), pdf.Style{Size: 9})
+		d.Text(fmt.Sprintf(t(KeyLetterExpires), invitation.ExpiresAt.Format(documentDateFormat)), pdf.Style{Size: 9})
+
+		// The PIN is cut off along the rule, to be kept apart from the link
+		if invitation.PIN != "" {
+			d.Space(18)
+			d.Rule()
+			d.Space(12)
+			d.Text(fmt.Sprintf(t(KeyLetterPIN), invitation.PIN), pdf.Style{Size: 14, Bold: true, Align: pdf.AlignCenter})
+			d.Space(4)
+			d.Text(t(KeyLetterPINNote), pdf.Style{Size: 9, Align: pdf.AlignCenter})
+		}
+	}
+	if len(invitations) == 0 {
+		d.AddPage()
+		s.header(d, header, t(KeyLetterTitle))
+	}
+	return d.Bytes(), nil
+}
+
+// RenderResultsReport renders the results of the gathering: participation, quorum and the
+// outcome of every matter
+func (s *DocumentService) RenderResultsReport(ctx context.Context, gathering database.Gathering, lang string) ([]byte, error) {
+	results, err := s.votingResultsService.GetCachedResults(ctx, gathering.ID, gathering.AssociationID)
+	if err != nil {
+		return nil, err
+	}
+	matters, err := s.db.GetVotingMatters(ctx, gathering.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voting matters: %w", err)
+	}
+	matterResults, quorum := resultsByMatter(results)
+
+	d, err := s.newDocument(gathering, s.i18n.Translate(KeyResultsTitle, lang), lang)
+	if err != nil {
+		return nil, err
+	}
+	t := func(key string) string { return s.i18n.Translate(key, lang) }
+	header := s.newHeader(ctx, gathering, lang)
+
+	d.AddPage()
+	s.header(d, header, t(KeyResultsTitle))
+	d.Text(fmt.Sprintf("%s: %s    %s: %s",
+		t(KeyGatheringType), s.i18n.FormatGatheringType(gathering.GatheringType, lang),
+		t(KeyVotingMode), s.i18n.FormatVotingMode(gathering.VotingMode, lang)), pdf.Style{Size: 9})
+	d.Space(8)
+
+	summary := results.Summary
+	d.Text(t(KeyStatisticsTitle), pdf.Style{Size: 12, Bold: true})
+	d.Table(pdf.Table{
+		Columns: []pdf.Column{
+			{Header: "", Width: 4},
+			{Header: t(KeyStatisticsUnits), Width: 2, Align: pdf.AlignRight},
+			{Header: t(KeyStatisticsWeight), Width: 2, Align: pdf.AlignRight},
+			{Header: t(KeyStatisticsArea), Width: 2, Align: pdf.AlignRight},
+		},
+		Rows: [][]string{
+			{t(KeyStatisticsQualifiedUnits), fmt.Sprintf("%d", summary.QualifiedUnits), fmt.Sprintf("%.4f", summary.QualifiedWeight), fmt.Sprintf("%.2f", summary.QualifiedArea)},
+			{t(KeyStatisticsParticipating), fmt.Sprintf("%d", summary.ParticipatingUnits), fmt.Sprintf("%.4f", summary.ParticipatingWeight), fmt.Sprintf("%.2f", summary.ParticipatingArea)},
+			{t(KeyStatisticsVoted), fmt.Sprintf("%d", summary.VotedUnits), fmt.Sprintf("%.4f", summary.VotedWeight), fmt.Sprintf("%.2f", summary.VotedArea)},
+		},
+		Size: 9,
+	})
+	if summary.QualifiedWeight > 0 {
+		d.Space(3)
+		d.Text(fmt.Sprintf("%s: %.2f%%", t(KeyStatisticsParticipation), summary.ParticipatingWeight/summary.QualifiedWeight*100), pdf.Style{Size: 9})
+	}
+	d.Space(8)
+
+	if quorum != nil {
+		d.Text(t(KeyQuorumInfo), pdf.Style{Size: 12, Bold: true})
+		d.Text(s.quorumDetails(quorum, lang), pdf.Style{Size: 9})
+		d.Text(s.quorumStatus(quorum, lang), pdf.Style{Bold: true})
+		d.Space(8)
+	}
+
+	for i, matter := range matters {
+		s.resultsMatter(d, i+1, matter, matterResults[matter.ID], lang)
+	}
+
+	if results.BallotMerkleRoot != "" {
+		d.Text(fmt.Sprintf("%s: %s", t(KeyResultsMerkleRoot), results.BallotMerkleRoot), pdf.Style{Size: 8})
+	}
+	if generatedAt, err := time.Parse(time.RFC3339, results.GeneratedAt); err == nil {
+		d.Text(fmt.Sprintf("%s: %s", t(KeyGeneratedAt), generatedAt.Format(documentDateFormat)), pdf.Style{Size: 8})
+	}
+	d.Space(24)
+	d.SignatureLines([]string{t(KeySignatureChair), t(KeySignatureSecretary)})
+	return d.Bytes(), nil
+}
+
+// resultsByMatter indexes the results of a gathering by matter, with the quorum they were decided with
+func resultsByMatter(results *domain.VoteResults) (map[int64]domain.VoteMatterResult, *domain.QuorumInfo) {
+	matterResults := make(map[int64]domain.VoteMatterResult, len(results.Results))
+	var quorum *domain.QuorumInfo
+	for _, result := range results.Results {
+		matterResults[result.MatterID] = result
+		if quorum == nil {
+			quorum = result.QuorumInfo
+		}
+	}
+	return matterResults, quorum
+}
+
+// quorumDetails describes the quorum threshold and the participation achieved
+func (s *DocumentService) quorumDetails(quorum *domain.QuorumInfo, lang string) string {
+	t := func(key string) string { return s.i18n.Translate(key, lang) }
+	format := "%.4f"
+	if quorum.VotingMode == "by_unit" {
+		format = "%.0f"
+	}
+	return fmt.Sprintf("%s: %.2f%%    %s: "+format+"    %s: "+format+" (%.2f%%)",
+		t(KeyQuorumThreshold), quorum.RequiredPercentage,
+		t(KeyQuorumRequired), quorum.Required,
+		t(KeyQuorumAchieved), quorum.Achieved, quorum.AchievedPercentage)
+}
+
+// quorumStatus states whether the quorum is met
+func (s *DocumentService) quorumStatus(quorum *domain.QuorumInfo, lang string) string {
+	if quorum.Met {
+		return s.i18n.Translate(KeyQuorumMet, lang)
+	}
+	return s.i18n.Translate(KeyQuorumNotMet, lang)
+}
+
+// resultsMatter renders the tally and outcome of a matter
+func (s *DocumentService) resultsMatter(d *pdf.Document, number int, matter database.VotingMatter, result domain.VoteMatterResult, lang string) {
+	t := func(key string) string { return s.i18n.Translate(key, lang) }
+
+	title := fmt.Sprintf("%d. %s", number, matterTitle(matter, lang))
+	d.EnsureSpace(d.TextHeight(title, pdf.Style{Size: 11, Bold: true}) + 60)
+	d.Text(title, pdf.Style{Size: 11, Bold: true})
+
+	if matter.IsInformative != 0 {
+		d.Text(t(KeyResultsInformative), pdf.Style{Size: 9})
+		d.Space(10)
+		return
+	}
+
+	outcome := s.matterOutcome(matter, result, lang)
+	d.Text(outcome.details, pdf.Style{Size: 9})
+	if outcome.attendance != "" {
+		d.Text(outcome.attendance, pdf.Style{Size: 9})
+	}
+	d.Space(3)
+	if outcome.ranking {
+		d.Text(outcome.orderHeading+":", pdf.Style{Size: 9})
+		for _, line := range outcome.order {
+			d.Text(line, pdf.Style{Size: 9, Indent: 12})
+		}
+		if outcome.winner != "" {
+			d.Text(outcome.winner, pdf.Style{Size: 9})
+		}
+	} else {
+		d.Table(pdf.Table{
+			Columns: []pdf.Column{
+				{Header: t(KeyResultsOption), Width: 5},
+				{Header: t(KeyResultsVotes), Width: 1.5, Align: pdf.AlignRight},
+				{Header: t(KeyStatisticsWeight), Width: 2, Align: pdf.AlignRight},
+				{Header: t(KeyResultsWeightPercentage), Width: 2, Align: pdf.AlignRight},
+			},
+			Rows: outcome.rows,
+			Size: 9,
+		})
+	}
+
+	d.Space(3)
+	d.Text(outcome.result, pdf.Style{Bold: true})
+	d.Space(10)
+}
+
+// matterOutcome is what documents show of the outcome of a matter put to the vote
+type matterOutcome struct {
+	details      string     // Required majority, and whether the vote was secret
+	attendance   string     // Who was present when the matter was voted, empty when not recorded
+	ranking      bool       // Ranking outcomes are an order rather than a tally
+	orderHeading string     // What the order lists, the final order or the elected options
+	order        []string   // Numbered lines of the order
+	winner       string     // Winner line, empty when the method elects several options
+	rows         [][]string // Tally: option, votes, weight and share of the weight cast
+	result       string     // Result line, passed or failed
+}
+
+// matterOutcome describes the outcome of a matter in the language
+func (s *DocumentService) matterOutcome(matter database.VotingMatter, result domain.VoteMatterResult, lang string) matterOutcome {
+	t := func(key string) string { return s.i18n.Translate(key, lang) }
+	var config domain.VotingConfig
+	json.Unmarshal([]byte(matter.VotingConfig), &config)
+
+	var outcome matterOutcome
+	outcome.details = fmt.Sprintf("%s: %s", t(KeyMajorityRequired), s.i18n.FormatMajority(config.RequiredMajority, lang))
+	if result.Attendance != nil {
+		outcome.attendance = fmt.Sprintf(t(KeyResultsAttendance), result.Attendance.Participants, result.Attendance.Units, result.Attendance.Weight)
+	}
+	if config.IsAnonymous {
+		outcome.details += "    " + t(KeyResultsAnonymous)
+	}
+
+	label := func(choice string) string {
+		switch choice {
+		case "yes":
+			return t(KeyVoteYes)
+		case "no":
+			return t(KeyVoteNo)
+		case "abstain":
+			return t(KeyVoteAbstain)
+		}
+		for _, option := range config.Options {
+			if option.ID == choice {
+				return option.Text
+			}
+		}
+		return choice
+	}
+
+	// Ranking tallies hold points or wins rather than votes, the order is what counts
+	if config.Type == "ranking" && result.Breakdown != nil {
+		breakdown := result.Breakdown
+		outcome.ranking = true
+		order := breakdown.Order
+		outcome.orderHeading = t(KeyResultsFinalOrder)
+		if len(breakdown.Elected) > 0 {
+			order = breakdown.Elected
+			outcome.orderHeading = t(KeyResultsElected)
+		}
+		for i, optionID := range order {
+			outcome.order = append(outcome.order, fmt.Sprintf("%d. %s", i+1, label(optionID)))
+		}
+		if breakdown.Method != RankingMethodSTV {
+			winner := t(KeyResultsNoWinner)
+			if breakdown.Winner != "" {
+				winner = label(breakdown.Winner)
+			}
+			outcome.winner = fmt.Sprintf("%s: %s", t(KeyResultsWinner), winner)
+		}
+	} else {
+		votes := make(map[string]domain.VoteResult, len(result.Votes))
+		for _, vote := range result.Votes {
+			votes[vote.Choice] = vote
+		}
+		var choices []string
+		if config.Type == "yes_no" {
+			choices = []string{"yes", "no"}
+		}
+		for _, option := range config.Options {
+			choices = append(choices, option.ID)
+		}
+		if config.AllowAbstention {
+			choices = append(choices, "abstain")
+		}
+		// Anything tallied that the configuration no longer lists is still shown
+		var extra []string
+		for choice := range votes {
+			if !slices.Contains(choices, choice) {
+				extra = append(extra, choice)
+			}
+		}
+		sort.Strings(extra)
+		choices = append(choices, extra...)
+
+		for _, choice := range choices {
+			vote := votes[choice]
+			outcome.rows = append(outcome.rows, []string{
+				label(choice),
+				fmt.Sprintf("%d", vote.VoteCount),
+				fmt.Sprintf("%.4f", vote.WeightSum),
+				fmt.Sprintf("%.2f%%", vote.WeightPercentage),
+			})
+		}
+	}
+
+	passed := t(KeyResultsFailed)
+	if result.IsPassed {
+		passed = t(KeyResultsPassed)
+	}
+	outcome.result = fmt.Sprintf("%s: %s", t(KeyMatterResult), strings.ToUpper(passed))
+	return outcome
+}
+
+// newDocument creates a document whose pages are numbered in the footer
+func (s *DocumentService) newDocument(gathering database.Gathering, title, lang string) (*pdf.Document, error) {
+	d, err := pdf.New(fmt.Sprintf("%s - %s", title, gathering.Title))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create document: %w", err)
+	}
+	d.Footer = func(page, pages int) string {
+		return fmt.Sprintf("%s  ·  %s", gathering.Title, fmt.Sprintf(s.i18n.Translate(KeyPage, lang), page, pages))
+	}
+	return d, nil
+}
+
+// documentHeader holds what the title block of a document shows
+type documentHeader struct {
+	gathering   database.Gathering
+	lang        string
+	association string
+	repeats     *database.Gathering // The gathering a repeated gathering was convened for
+}
+
+// newHeader looks up the association and, for a repeated gathering, the original gathering
+func (s *DocumentService) newHeader(ctx context.Context, gathering database.Gathering, lang string) documentHeader {
+	header := documentHeader{gathering: gathering, lang: lang}
+	if association, err := s.db.GetAssociations(ctx, gathering.AssociationID); err == nil {
+		header.association = association.Name
+	}
+	if gathering.RepeatedFromID.Valid {
+		original, err := s.db.GetGathering(ctx, database.GetGatheringParams{
+			ID:            gathering.RepeatedFromID.Int64,
+			AssociationID: gathering.AssociationID,
+		})
+		if err == nil {
+			header.repeats = &original
+		}
+	}
+	return header
+}
+
+// header renders the association, the document title and when and where the gathering takes place
+func (s *DocumentService) header(d *pdf.Document, header documentHeader, title string) {
+	if header.association != "" {
+		d.Text(header.association, pdf.Style{Size: 9, Align: pdf.AlignCenter})
+	}
+	gathering := header.gathering
+	lang := header.lang
+	d.Text(strings.ToUpper(title), pdf.Style{Size: 15, Bold: true, Align: pdf.AlignCenter})
+	d.Text(gathering.Title, pdf.Style{Size: 12, Bold: true, Align: pdf.AlignCenter})
+
+	line := fmt.Sprintf("%s: %s", s.i18n.Translate(KeyGatheringDate, lang), gathering.GatheringDate.Format(documentDateFormat))
+	if gathering.Location != "" {
+		line += fmt.Sprintf("    %s: %s", s.i18n.Translate(KeyGatheringLocation, lang), gathering.Location)
+	}
+	d.Text(line, pdf.Style{Size: 9, Align: pdf.AlignCenter})
+	if header.repeats != nil {
+		d.Text(fmt.Sprintf(s.i18n.Translate(KeyGatheringRepeats, lang), header.repeats.GatheringDate.Format(documentDateFormat)),
+			pdf.Style{Size: 9, Align: pdf.AlignCenter})
+	}
+	d.Rule()
+	d.Space(6)
+}
+
+// matterTitle returns the title of a matter in the language, falling back to the default title
+func matterTitle(matter database.VotingMatter, lang string) string {
+	if lang == "ru" && matter.TitleRu != "" {
+		return matter.TitleRu
+	}
+	return matter.Title
+}
+
+// matterDescription returns the description of a matter in the language, falling back to the default
+func matterDescription(matter database.VotingMatter, lang string) string {
+	if lang == "ru" && matter.DescriptionRu.Valid && matter.DescriptionRu.String != "" {
+		return matter.DescriptionRu.String
+	}
+	return matter.Description.String
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultSchedulerInterval is how often the scheduler looks for gatherings to open or close
+	DefaultSchedulerInterval = time.Minute
+	// maxSchedulerBackoff caps how long a failing transition waits before it is retried
+	maxSchedulerBackoff = time.Hour
+)
+
+// GatheringScheduler opens and closes remote gatherings at their scheduled times
+type GatheringScheduler struct {
+	db               *database.Queries
+	lifecycleService *LifecycleService
+	interval         time.Duration
+	failures         map[scheduledTransition]scheduleFailure
+}
+
+// scheduledTransition identifies a transition the scheduler applies
+type scheduledTransition struct {
+	gatheringID int64
+	to          string
+}
+
+// scheduleFailure records a transition that keeps failing, and when it is retried next
+type scheduleFailure struct {
+	attempts int
+	retryAt  time.Time
+}
+
+// NewGatheringScheduler creates a new GatheringScheduler
+func NewGatheringScheduler(db *database.Queries, lifecycleService *LifecycleService, interval time.Duration) *GatheringScheduler {
+	if interval <= 0 {
+		interval = DefaultSchedulerInterval
+	}
+	return &GatheringScheduler{
+		db:               db,
+		lifecycleService: lifecycleService,
+		interval:         interval,
+		failures:         make(map[scheduledTransition]scheduleFailure),
+	}
+}
+
+// Start runs the scheduler in the background until ctx is cancelled
+func (s *GatheringScheduler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.RunOnce(ctx, time.Now())
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.RunOnce(ctx, now)
+			}
+		}
+	}()
+}
+
+// RunOnce applies every transition that is due at the given time. A transition that failed is
+// retried after a backoff doubling with each failure, up to maxSchedulerBackoff. It is not safe
+// for concurrent use; Start runs it from a single goroutine.
+func (s *GatheringScheduler) RunOnce(ctx context.Context, now time.Time) {
+	due := sql.NullTime{Time: now.UTC(), Valid: true}
+	actor := TransitionActor{PerformedBy: "scheduler", Trigger: "scheduled"}
+
+	toOpen, err := s.db.GetGatheringsDueToOpen(ctx, due)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error getting gatherings due to open", zap.Error(err))
+	}
+	for _, g := range toOpen {
+		s.transition(ctx, g, GatheringStatusActive, actor, now)
+	}
+
+	toClose, err := s.db.GetGatheringsDueToClose(ctx, due)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error getting gatherings due to close", zap.Error(err))
+	}
+	for _, g := range toClose {
+		s.transition(ctx, g, GatheringStatusClosed, actor, now)
+	}
+}
+
+// transition applies a due transition unless it is backing off after failing, and records the
+// outcome: the first failure goes to the gathering's audit log, the failures after it are
+// counted to back off further
+func (s *GatheringScheduler) transition(ctx context.Context, gathering database.Gathering, to string, actor TransitionActor, now time.Time) {
+	key := scheduledTransition{gatheringID: gathering.ID, to: to}
+	failure, failed := s.failures[key]
+	if failed && now.Before(failure.retryAt) {
+		return
+	}
+
+	if _, err := s.lifecycleService.Transition(ctx, gathering, to, actor); err != nil {
+		failure.attempts++
+		failure.retryAt = now.Add(schedulerBackoff(s.interval, failure.attempts))
+		s.failures[key] = failure
+		logging.Logger.Log(zap.WarnLevel, "Scheduled gathering transition failed",
+			zap.Int64("gathering_id", gathering.ID),
+			zap.String("to", to),
+			zap.Int("attempts", failure.attempts),
+			zap.Time("retry_at", failure.retryAt),
+			zap.Error(err))
+		if failure.attempts == 1 {
+			s.recordFailure(ctx, gathering, to, actor, err)
+		}
+		return
+	}
+	delete(s.failures, key)
+	logging.Logger.Log(zap.InfoLevel, "Scheduled gathering transition applied",
+		zap.Int64("gathering_id", gathering.ID),
+		zap.String("to", to))
+}
+
+// recordFailure writes a failed scheduled transition to the gathering's audit log, so that
+// administrators see why it did not open or close on time
+func (s *GatheringScheduler) recordFailure(ctx context.Context, gathering database.Gathering, to string, actor TransitionActor, cause error) {
+	details, _ := json.Marshal(map[string]string{
+		"from":    gathering.Status,
+		"to":      to,
+		"trigger": actor.Trigger,
+		"error":   cause.Error(),
+	})
+	err := NewIntegrityService(s.db).RecordAudit(ctx, database.CreateAuditLogParams{
+		GatheringID: gathering.ID,
+		EntityType:  "gathering",
+		EntityID:    gathering.ID,
+		Action:      "scheduled_transition_failed",
+		PerformedBy: sql.NullString{String: actor.PerformedBy, Valid: actor.PerformedBy != ""},
+		Details:     sql.NullString{String: string(details), Valid: true},
+	})
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error logging failed scheduled transition",
+			zap.Int64("gathering_id", gathering.ID),
+			zap.Error(err))
+	}
+}
+
+// schedulerBackoff returns how long to wait after the given number of failed attempts
+func schedulerBackoff(interval time.Duration, attempts int) time.Duration {
+	backoff := interval
+	for i := 1; i < attempts && backoff < maxSchedulerBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxSchedulerBackoff)
+}
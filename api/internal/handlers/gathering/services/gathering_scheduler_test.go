@@ -0,0 +1,27 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSchedulerBackoff tests that a failing transition waits twice as long after each failure,
+// up to an hour
+func TestSchedulerBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{7, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := schedulerBackoff(time.Minute, tt.attempts); got != tt.expected {
+			t.Errorf("schedulerBackoff(1m, %d) = %v, expected %v", tt.attempts, got, tt.expected)
+		}
+	}
+}
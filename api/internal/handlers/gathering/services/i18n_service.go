@@ -1,13 +1,22 @@
 package services
 
 import (
+	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"slices"
 )
 
-// I18nService handles internationalization for voting results
+// locales holds a <lang>.json file of translations per supported language
+//
+//go:embed locales/*.json
+var locales embed.FS
+
+// SupportedLanguages are the languages documents can be produced in
+var SupportedLanguages = []string{"ro", "ru", "en"}
+
+// I18nService handles internationalization for voting results and printed documents
 type I18nService struct {
 	translations map[string]map[string]string
 	defaultLang  string
@@ -43,22 +52,104 @@ const (
 	KeyMatterType               = "matter.type"
 	KeyMatterResult             = "matter.result"
 	KeyGeneratedAt              = "generated_at"
+
+	// Printed documents
+	KeyGatheringDate             = "gathering.date"
+	KeyGatheringLocation         = "gathering.location"
+	KeyGatheringRepeats          = "gathering.repeats"
+	KeyBallotTitle               = "ballot.title"
+	KeyBallotOwner               = "ballot.owner"
+	KeyBallotIdentification      = "ballot.identification"
+	KeyBallotInstructions        = "ballot.instructions"
+	KeyBallotRankInstructions    = "ballot.rank_instructions"
+	KeyBallotMultipleChoice      = "ballot.multiple_choice"
+	KeyBallotInformative         = "ballot.informative"
+	KeyBallotSignature           = "ballot.signature"
+	KeyBallotDate                = "ballot.date"
+	KeyUnitNumber                = "unit.number"
+	KeyUnitBuilding              = "unit.building"
+	KeyAttendanceTitle           = "attendance.title"
+	KeyAttendanceNumber          = "attendance.number"
+	KeyAttendanceOwner           = "attendance.owner"
+	KeyAttendanceUnits           = "attendance.units"
+	KeyAttendanceRepresentative  = "attendance.representative"
+	KeyAttendanceSignature       = "attendance.signature"
+	KeyTotal                     = "total"
+	KeySignatureChair            = "signature.chair"
+	KeySignatureSecretary        = "signature.secretary"
+	KeyResultsTitle              = "results.title"
+	KeyResultsInformative        = "results.informative"
+	KeyResultsOption             = "results.option"
+	KeyResultsVotes              = "results.votes"
+	KeyResultsWeightPercentage   = "results.weight_percentage"
+	KeyResultsFinalOrder         = "results.final_order"
+	KeyResultsWinner             = "results.winner"
+	KeyResultsNoWinner           = "results.no_winner"
+	KeyResultsElected            = "results.elected"
+	KeyResultsAnonymous          = "results.anonymous"
+	KeyResultsMerkleRoot         = "results.merkle_root"
+	KeyResultsAttendance         = "results.attendance" // Present when voted: %d participants, %d units, weight %.4f
+	KeyStatisticsTitle           = "statistics.title"
+	KeyStatisticsUnits           = "statistics.units"
+	KeyStatisticsParticipation   = "statistics.participation_rate"
+	KeyMajorityRequired          = "majority.required"
+	KeyMajoritySimple            = "majority.simple"
+	KeyMajorityAbsolute          = "majority.absolute"
+	KeyMajorityAbsoluteTwoThirds = "majority.absolute_two_thirds"
+	KeyMajorityQualified         = "majority.qualified"
+	KeyMajorityUnanimous         = "majority.unanimous"
+	KeyPage                      = "page" // Page %d of %d
+
+	// Minutes, whose texts carry the placeholders of the default minutes template
+	KeyAttendanceParticipant = "attendance.participant"
+	KeyAttendanceRepresents  = "attendance.represents"
+	KeyMinutesTitle          = "minutes.title"
+	KeyMinutesSubtitle       = "minutes.subtitle"
+	KeyMinutesOpening        = "minutes.opening"
+	KeyMinutesAttendance     = "minutes.attendance"
+	KeyMinutesAttendanceText = "minutes.attendance_summary"
+	KeyMinutesAgenda         = "minutes.agenda"
+	KeyMinutesResults        = "minutes.results"
+	KeyMinutesClosing        = "minutes.closing"
+
+	// Invitation letters
+	KeyLetterTitle    = "letter.title"
+	KeyLetterGreeting = "letter.greeting" // Dear %s,
+	KeyLetterIntro    = "letter.intro"
+	KeyLetterUnits    = "letter.units"
+	KeyLetterDeadline = "letter.deadline" // Votes can be cast until %s.
+	KeyLetterScan     = "letter.scan"
+	KeyLetterPersonal = "letter.personal"
+	KeyLetterExpires  = "letter.expires" // The link is valid until %s.
+	KeyLetterPIN      = "letter.pin"     // Your PIN: %s
+	KeyLetterPINNote  = "letter.pin_note"
+
+	// One-time codes for member verification
+	KeyVerificationSubject = "verification.subject"
+	KeyVerificationBody    = "verification.body" // Your code to vote in %s is %s, valid for %d minutes
 )
 
-// NewI18nService creates a new I18nService
-func NewI18nService(localesDir string) (*I18nService, error) {
+// NewI18nService creates a new I18nService with the translations of every supported language.
+// The service is usable even when a language fails to load: its keys are then returned as is.
+func NewI18nService() (*I18nService, error) {
 	service := &I18nService{
 		translations: make(map[string]map[string]string),
 		defaultLang:  "en",
 	}
 
-	// Load English translations (default)
-	if err := service.loadTranslations("en", localesDir); err != nil {
-		// If loading from file fails, use built-in English translations
-		service.translations["en"] = getBuiltInEnglishTranslations()
+	var errs []error
+	for _, lang := range SupportedLanguages {
+		if err := service.loadTranslations(lang); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", lang, err))
+		}
 	}
 
-	return service, nil
+	return service, errors.Join(errs...)
+}
+
+// Supports reports whether documents can be produced in the language
+func (s *I18nService) Supports(lang string) bool {
+	return slices.Contains(SupportedLanguages, lang)
 }
 
 // Translate returns the translation for a key in the specified language
@@ -81,11 +172,9 @@ func (s *I18nService) Translate(key string, lang string) string {
 	return key
 }
 
-// loadTranslations loads translations from a JSON file
-func (s *I18nService) loadTranslations(lang string, localesDir string) error {
-	filePath := filepath.Join(localesDir, fmt.Sprintf("%s.json", lang))
-
-	data, err := os.ReadFile(filePath)
+// loadTranslations loads the embedded translations of a language
+func (s *I18nService) loadTranslations(lang string) error {
+	data, err := locales.ReadFile(fmt.Sprintf("locales/%s.json", lang))
 	if err != nil {
 		return fmt.Errorf("failed to read translation file: %w", err)
 	}
@@ -99,40 +188,6 @@ func (s *I18nService) loadTranslations(lang string, localesDir string) error {
 	return nil
 }
 
-// getBuiltInEnglishTranslations returns built-in English translations
-func getBuiltInEnglishTranslations() map[string]string {
-	return map[string]string{
-		KeyGatheringTitle:           "Gathering",
-		KeyGatheringType:            "Type",
-		KeyGatheringTypeInitial:     "Initial Gathering",
-		KeyGatheringTypeRepeated:    "Repeated Gathering",
-		KeyGatheringTypeRemote:      "Remote Gathering",
-		KeyVotingMode:               "Voting Mode",
-		KeyVotingModeByWeight:       "By Weight",
-		KeyVotingModeByUnit:         "By Unit",
-		KeyQuorumInfo:               "Quorum Information",
-		KeyQuorumMet:                "Quorum Met",
-		KeyQuorumNotMet:             "Quorum Not Met",
-		KeyQuorumRequired:           "Required",
-		KeyQuorumAchieved:           "Achieved",
-		KeyQuorumThreshold:          "Threshold",
-		KeyResultsPassed:            "Passed",
-		KeyResultsFailed:            "Failed",
-		KeyStatisticsQualifiedUnits: "Qualified Units",
-		KeyStatisticsParticipating:  "Participating Units",
-		KeyStatisticsVoted:          "Voted Units",
-		KeyStatisticsWeight:         "Weight",
-		KeyStatisticsArea:           "Area",
-		KeyVoteYes:                  "Yes",
-		KeyVoteNo:                   "No",
-		KeyVoteAbstain:              "Abstain",
-		KeyMatterTitle:              "Matter",
-		KeyMatterType:               "Type",
-		KeyMatterResult:             "Result",
-		KeyGeneratedAt:              "Generated At",
-	}
-}
-
 // FormatGatheringType returns the translated gathering type
 func (s *I18nService) FormatGatheringType(gatheringType string, lang string) string {
 	switch gatheringType {
@@ -158,3 +213,21 @@ func (s *I18nService) FormatVotingMode(votingMode string, lang string) string {
 		return votingMode
 	}
 }
+
+// FormatMajority returns the translated required majority
+func (s *I18nService) FormatMajority(majority string, lang string) string {
+	switch majority {
+	case "simple":
+		return s.Translate(KeyMajoritySimple, lang)
+	case "absolute":
+		return s.Translate(KeyMajorityAbsolute, lang)
+	case "absolute_two_thirds":
+		return s.Translate(KeyMajorityAbsoluteTwoThirds, lang)
+	case "qualified":
+		return s.Translate(KeyMajorityQualified, lang)
+	case "unanimous":
+		return s.Translate(KeyMajorityUnanimous, lang)
+	default:
+		return majority
+	}
+}
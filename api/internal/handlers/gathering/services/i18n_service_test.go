@@ -0,0 +1,41 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestTranslations tests that every language translates the same keys with the same format verbs
+func TestTranslations(t *testing.T) {
+	s, err := NewI18nService()
+	if err != nil {
+		t.Fatalf("NewI18nService() unexpected error: %v", err)
+	}
+
+	reference := s.translations[s.defaultLang]
+	for _, lang := range SupportedLanguages {
+		t.Run(lang, func(t *testing.T) {
+			translations := s.translations[lang]
+			if len(translations) != len(reference) {
+				t.Errorf("%d keys, want %d", len(translations), len(reference))
+			}
+			for key, text := range reference {
+				translated, ok := translations[key]
+				if !ok {
+					t.Errorf("missing %q", key)
+					continue
+				}
+				if strings.Count(translated, "%") != strings.Count(text, "%") {
+					t.Errorf("%q = %q does not take the arguments of %q", key, translated, text)
+				}
+			}
+		})
+	}
+
+	if got := s.Translate(KeyBallotTitle, "ro"); got != "Buletin de vot" {
+		t.Errorf("Translate(ro) = %q", got)
+	}
+	if got := s.Translate(KeyBallotTitle, "fr"); got != "Ballot" {
+		t.Errorf("Translate(fr) = %q, want the English fallback", got)
+	}
+}
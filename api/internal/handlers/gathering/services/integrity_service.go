@@ -0,0 +1,374 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// Chains and checks reported by the integrity check
+const (
+	ChainBallots        = "ballots"
+	ChainAuditLog       = "audit_log"
+	ChainCrossReference = "cross_reference"
+	ChainMerkleRoot     = "merkle_root"
+)
+
+// chainMu serializes sealing so two writers never link new entries to the same head
+var chainMu sync.Mutex
+
+// IntegrityService keeps a gathering's ballots and audit log entries in tamper-evident hash chains.
+// Every entry is sealed right after it is written: its chain hash covers the recorded fields and
+// the chain hash of the previous entry of the same gathering, so editing or deleting a row breaks
+// every link after it.
+type IntegrityService struct {
+	db *database.Queries
+}
+
+// NewIntegrityService creates a new IntegrityService
+func NewIntegrityService(db *database.Queries) *IntegrityService {
+	return &IntegrityService{db: db}
+}
+
+// RecordAudit writes an audit log entry and seals it into the gathering's audit chain
+func (s *IntegrityService) RecordAudit(ctx context.Context, entry database.CreateAuditLogParams) error {
+	if err := s.db.CreateAuditLog(ctx, entry); err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return s.SealAuditLog(ctx, entry.GatheringID)
+}
+
+// Seal links every unsealed ballot and audit log entry of a gathering into its chain
+func (s *IntegrityService) Seal(ctx context.Context, gatheringID int64) error {
+	if err := s.SealBallots(ctx, gatheringID); err != nil {
+		return err
+	}
+	return s.SealAuditLog(ctx, gatheringID)
+}
+
+// SealBallots links the ballots recorded after the chain head into the ballot chain.
+// Call it once the transaction that recorded the ballots has committed.
+func (s *IntegrityService) SealBallots(ctx context.Context, gatheringID int64) error {
+	chainMu.Lock()
+	defer chainMu.Unlock()
+	return s.sealBallots(ctx, gatheringID)
+}
+
+// SealAuditLog links the audit log entries written after the chain head into the audit chain
+func (s *IntegrityService) SealAuditLog(ctx context.Context, gatheringID int64) error {
+	chainMu.Lock()
+	defer chainMu.Unlock()
+	return s.sealAuditLog(ctx, gatheringID)
+}
+
+// sealLocked seals both chains of a gathering. The caller holds chainMu, which it takes before
+// beginning the transaction it seals in, so that it never waits for a sealer waiting on it.
+func (s *IntegrityService) sealLocked(ctx context.Context, gatheringID int64) error {
+	if err := s.sealBallots(ctx, gatheringID); err != nil {
+		return err
+	}
+	return s.sealAuditLog(ctx, gatheringID)
+}
+
+// sealBallots links the pending ballots into the ballot chain, with chainMu held
+func (s *IntegrityService) sealBallots(ctx context.Context, gatheringID int64) error {
+	head, err := s.db.GetBallotChainHead(ctx, gatheringID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get ballot chain head: %w", err)
+	}
+	pending, err := s.db.GetUnchainedBallots(ctx, database.GetUnchainedBallotsParams{
+		GatheringID: gatheringID,
+		ID:          head.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get unsealed ballots: %w", err)
+	}
+
+	prev := head.ChainHash.String
+	for _, ballot := range pending {
+		hash := ballotChainHash(prev, ballot)
+		_, err := s.db.SetBallotChainHash(ctx, database.SetBallotChainHashParams{
+			PrevHash:  sql.NullString{String: prev, Valid: true},
+			ChainHash: sql.NullString{String: hash, Valid: true},
+			ID:        ballot.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to seal ballot %d: %w", ballot.ID, err)
+		}
+		prev = hash
+	}
+	return nil
+}
+
+// sealAuditLog links the pending audit log entries into the audit chain, with chainMu held
+func (s *IntegrityService) sealAuditLog(ctx context.Context, gatheringID int64) error {
+	head, err := s.db.GetAuditLogChainHead(ctx, gatheringID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get audit chain head: %w", err)
+	}
+	pending, err := s.db.GetUnchainedAuditLogs(ctx, database.GetUnchainedAuditLogsParams{
+		GatheringID: gatheringID,
+		ID:          head.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get unsealed audit entries: %w", err)
+	}
+
+	prev := head.ChainHash.String
+	for _, entry := range pending {
+		hash := auditChainHash(prev, entry)
+		_, err := s.db.SetAuditLogChainHash(ctx, database.SetAuditLogChainHashParams{
+			PrevHash:  sql.NullString{String: prev, Valid: true},
+			ChainHash: sql.NullString{String: hash, Valid: true},
+			ID:        entry.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to seal audit entry %d: %w", entry.ID, err)
+		}
+		prev = hash
+	}
+	return nil
+}
+
+// Check re-walks the gathering's chains and reports the first broken link of each. It also
+// cross-checks ballots against the audit log, which catches deleted trailing ballots and
+// validity flags changed outside the invalidation endpoints, and compares the Merkle root
+// published with the results against the ballots as they are now.
+func (s *IntegrityService) Check(ctx context.Context, gatheringID int64) (domain.IntegrityReport, error) {
+	report := domain.IntegrityReport{
+		GatheringID: gatheringID,
+		CheckedAt:   time.Now().UTC(),
+	}
+
+	ballots, err := s.db.GetBallotChain(ctx, gatheringID)
+	if err != nil {
+		return report, fmt.Errorf("failed to get ballots: %w", err)
+	}
+	entries, err := s.db.GetAuditLogChain(ctx, gatheringID)
+	if err != nil {
+		return report, fmt.Errorf("failed to get audit log: %w", err)
+	}
+
+	report.Ballots = VerifyBallotChain(ballots)
+	report.AuditLog = VerifyAuditChain(entries)
+
+	hashes := make([]string, len(ballots))
+	for i, ballot := range ballots {
+		hashes[i] = ballot.BallotHash
+	}
+	report.BallotMerkleRoot = MerkleRoot(hashes)
+
+	breaks := []*domain.IntegrityBreak{report.Ballots.FirstBreak, report.AuditLog.FirstBreak, crossCheckBallots(ballots, entries)}
+
+	stored, err := s.db.GetVotingResults(ctx, gatheringID)
+	switch {
+	case err == nil && stored.BallotMerkleRoot.Valid:
+		report.PublishedMerkleRoot = stored.BallotMerkleRoot.String
+		if report.PublishedMerkleRoot != report.BallotMerkleRoot {
+			breaks = append(breaks, &domain.IntegrityBreak{
+				Chain:  ChainMerkleRoot,
+				Reason: "the ballots no longer match the Merkle root published with the results",
+			})
+		}
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		return report, fmt.Errorf("failed to get voting results: %w", err)
+	}
+
+	for _, b := range breaks {
+		if b != nil {
+			report.FirstBreak = b
+			break
+		}
+	}
+	report.Intact = report.FirstBreak == nil
+	return report, nil
+}
+
+// VerifyBallotChain walks ballots in ID order and reports the first one that is unsealed, does
+// not link to its predecessor, or no longer matches its chain hash or content hash
+func VerifyBallotChain(ballots []database.VotingBallot) domain.ChainReport {
+	report := domain.ChainReport{Entries: len(ballots)}
+	prev := ""
+	for i, ballot := range ballots {
+		reason := ""
+		content := sha256.Sum256([]byte(ballot.BallotContent))
+		switch {
+		case !ballot.ChainHash.Valid:
+			reason = "ballot is not sealed into the chain"
+		case ballot.PrevHash.String != prev:
+			reason = "ballot does not link to the preceding ballot; a ballot was removed or the chain was rewritten"
+		case ballotChainHash(prev, ballot) != ballot.ChainHash.String:
+			reason = "ballot was modified after it was recorded"
+		case hex.EncodeToString(content[:]) != ballot.BallotHash:
+			reason = "ballot content does not match its hash"
+		}
+		if reason != "" {
+			report.FirstBreak = &domain.IntegrityBreak{Chain: ChainBallots, EntryID: ballot.ID, Position: i + 1, Reason: reason}
+			return report
+		}
+		prev = ballot.ChainHash.String
+	}
+	report.Head = prev
+	return report
+}
+
+// VerifyAuditChain walks audit log entries in ID order and reports the first one that is
+// unsealed, does not link to its predecessor or no longer matches its chain hash
+func VerifyAuditChain(entries []database.VotingAuditLog) domain.ChainReport {
+	report := domain.ChainReport{Entries: len(entries)}
+	prev := ""
+	for i, entry := range entries {
+		reason := ""
+		switch {
+		case !entry.ChainHash.Valid:
+			reason = "audit entry is not sealed into the chain"
+		case entry.PrevHash.String != prev:
+			reason = "audit entry does not link to the preceding entry; an entry was removed or the chain was rewritten"
+		case auditChainHash(prev, entry) != entry.ChainHash.String:
+			reason = "audit entry was modified after it was recorded"
+		}
+		if reason != "" {
+			report.FirstBreak = &domain.IntegrityBreak{Chain: ChainAuditLog, EntryID: entry.ID, Position: i + 1, Reason: reason}
+			return report
+		}
+		prev = entry.ChainHash.String
+	}
+	report.Head = prev
+	return report
+}
+
+// crossCheckBallots reports the first ballot whose recorded existence or validity disagrees with
+// the audit log. The validity flag is not covered by the ballot chain since invalidation changes
+// it; the invalidation itself is chained in the audit log.
+func crossCheckBallots(ballots []database.VotingBallot, entries []database.VotingAuditLog) *domain.IntegrityBreak {
+	byID := make(map[int64]database.VotingBallot, len(ballots))
+	for _, ballot := range ballots {
+		byID[ballot.ID] = ballot
+	}
+
+	voided := make(map[int64]bool)
+	for _, entry := range entries {
+		if entry.EntityType != "ballot" {
+			continue
+		}
+		if _, exists := byID[entry.EntityID]; !exists {
+			return &domain.IntegrityBreak{
+				Chain:   ChainCrossReference,
+				EntryID: entry.EntityID,
+				Reason:  fmt.Sprintf("ballot %d is in the audit log but missing from the ballots", entry.EntityID),
+			}
+		}
+		if entry.Action == "invalidated" || entry.Action == "replaced" {
+			voided[entry.EntityID] = true
+		}
+	}
+
+	for _, ballot := range ballots {
+		if ballot.IsValid.Bool == voided[ballot.ID] {
+			reason := "ballot is marked invalid without an invalidation in the audit log"
+			if ballot.IsValid.Bool {
+				reason = "ballot was invalidated according to the audit log but is marked valid"
+			}
+			return &domain.IntegrityBreak{Chain: ChainCrossReference, EntryID: ballot.ID, Reason: reason}
+		}
+	}
+	return nil
+}
+
+// MerkleRoot computes the RFC 6962 Merkle tree hash over ballot hashes in the given order.
+// Leaves are SHA256(0x00 || ballot hash as hex text), inner nodes SHA256(0x01 || left || right).
+func MerkleRoot(ballotHashes []string) string {
+	root := merkleTreeHash(ballotHashes)
+	return hex.EncodeToString(root)
+}
+
+func merkleTreeHash(leaves []string) []byte {
+	switch len(leaves) {
+	case 0:
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	case 1:
+		sum := sha256.Sum256(append([]byte{0x00}, leaves[0]...))
+		return sum[:]
+	}
+
+	// Split at the largest power of two smaller than the number of leaves
+	k := 1
+	for k*2 < len(leaves) {
+		k *= 2
+	}
+	node := append([]byte{0x01}, merkleTreeHash(leaves[:k])...)
+	node = append(node, merkleTreeHash(leaves[k:])...)
+	sum := sha256.Sum256(node)
+	return sum[:]
+}
+
+// ballotChainHash links a ballot's immutable fields to the previous chain hash
+func ballotChainHash(prev string, ballot database.VotingBallot) string {
+	return chainHash(prev,
+		strconv.FormatInt(ballot.ID, 10),
+		strconv.FormatInt(ballot.GatheringID, 10),
+		strconv.FormatInt(ballot.ParticipantID, 10),
+		ballot.BallotHash,
+		chainTime(ballot.SubmittedAt),
+		chainString(ballot.SubmittedIp),
+		chainString(ballot.SubmittedUserAgent),
+		chainInt(ballot.ReplacesBallotID),
+	)
+}
+
+// auditChainHash links an audit entry's fields to the previous chain hash
+func auditChainHash(prev string, entry database.VotingAuditLog) string {
+	return chainHash(prev,
+		strconv.FormatInt(entry.ID, 10),
+		strconv.FormatInt(entry.GatheringID, 10),
+		entry.EntityType,
+		strconv.FormatInt(entry.EntityID, 10),
+		entry.Action,
+		chainString(entry.PerformedBy),
+		chainTime(entry.PerformedAt),
+		chainString(entry.IpAddress),
+		chainString(entry.Details),
+	)
+}
+
+// chainHash hashes the previous chain hash followed by the fields, separated by the unit separator
+func chainHash(prev string, fields ...string) string {
+	h := sha256.New()
+	h.Write([]byte(prev))
+	for _, field := range fields {
+		h.Write([]byte{0x1f})
+		h.Write([]byte(field))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func chainString(s sql.NullString) string {
+	if !s.Valid {
+		return "\x00"
+	}
+	return s.String
+}
+
+func chainInt(i sql.NullInt64) string {
+	if !i.Valid {
+		return "\x00"
+	}
+	return strconv.FormatInt(i.Int64, 10)
+}
+
+func chainTime(t sql.NullTime) string {
+	if !t.Valid {
+		return "\x00"
+	}
+	return t.Time.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+)
+
+// sealedBallots builds a correctly chained list of ballots
+func sealedBallots(contents ...string) []database.VotingBallot {
+	var ballots []database.VotingBallot
+	prev := ""
+	for i, content := range contents {
+		sum := sha256.Sum256([]byte(content))
+		ballot := database.VotingBallot{
+			ID:            int64(i + 1),
+			GatheringID:   7,
+			ParticipantID: int64(100 + i),
+			BallotContent: content,
+			BallotHash:    hex.EncodeToString(sum[:]),
+			SubmittedAt:   sql.NullTime{Time: time.Date(2025, 3, 1, 10, i, 0, 0, time.UTC), Valid: true},
+			IsValid:       sql.NullBool{Bool: true, Valid: true},
+		}
+		hash := ballotChainHash(prev, ballot)
+		ballot.PrevHash = sql.NullString{String: prev, Valid: true}
+		ballot.ChainHash = sql.NullString{String: hash, Valid: true}
+		ballots = append(ballots, ballot)
+		prev = hash
+	}
+	return ballots
+}
+
+// TestVerifyBallotChain tests that edits, deletions and unsealed rows are reported at the right position
+func TestVerifyBallotChain(t *testing.T) {
+	tests := []struct {
+		name             string
+		tamper           func([]database.VotingBallot) []database.VotingBallot
+		expectedPosition int // 0 when the chain is intact
+	}{
+		{"intact", func(b []database.VotingBallot) []database.VotingBallot { return b }, 0},
+		{"content edited", func(b []database.VotingBallot) []database.VotingBallot {
+			b[1].BallotContent = `{"1":{"values":["no"]}}`
+			return b
+		}, 2},
+		{"content and hash edited", func(b []database.VotingBallot) []database.VotingBallot {
+			sum := sha256.Sum256([]byte("forged"))
+			b[1].BallotContent = "forged"
+			b[1].BallotHash = hex.EncodeToString(sum[:])
+			return b
+		}, 2},
+		{"participant reassigned", func(b []database.VotingBallot) []database.VotingBallot {
+			b[2].ParticipantID = 999
+			return b
+		}, 3},
+		{"ballot deleted", func(b []database.VotingBallot) []database.VotingBallot {
+			return append(b[:1], b[2:]...)
+		}, 2},
+		{"hash removed", func(b []database.VotingBallot) []database.VotingBallot {
+			b[2].ChainHash = sql.NullString{}
+			return b
+		}, 3},
+		{"invalidated", func(b []database.VotingBallot) []database.VotingBallot {
+			b[0].IsValid = sql.NullBool{Bool: false, Valid: true}
+			b[0].InvalidationReason = sql.NullString{String: "spoiled", Valid: true}
+			return b
+		}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ballots := tt.tamper(sealedBallots(`{"1":{"values":["yes"]}}`, `{"1":{"values":["yes"]}}`, `{"1":{"values":["abstain"]}}`))
+			report := VerifyBallotChain(ballots)
+
+			position := 0
+			if report.FirstBreak != nil {
+				position = report.FirstBreak.Position
+			}
+			if position != tt.expectedPosition {
+				t.Errorf("VerifyBallotChain() break at %d (%+v), expected %d", position, report.FirstBreak, tt.expectedPosition)
+			}
+			if tt.expectedPosition == 0 && report.Head != ballots[len(ballots)-1].ChainHash.String {
+				t.Errorf("VerifyBallotChain() head = %s, expected the last chain hash", report.Head)
+			}
+		})
+	}
+}
+
+// TestCrossCheckBallots tests that validity flags must agree with the audit log
+func TestCrossCheckBallots(t *testing.T) {
+	ballots := sealedBallots("a", "b")
+	submitted := []database.VotingAuditLog{
+		{ID: 1, EntityType: "ballot", EntityID: 1, Action: "submitted"},
+		{ID: 2, EntityType: "ballot", EntityID: 2, Action: "submitted"},
+	}
+	invalidated := append(submitted, database.VotingAuditLog{ID: 3, EntityType: "ballot", EntityID: 2, Action: "invalidated"})
+
+	tests := []struct {
+		name       string
+		valid      bool // validity of the second ballot
+		entries    []database.VotingAuditLog
+		expectedID int64 // 0 when consistent
+	}{
+		{"consistent", true, submitted, 0},
+		{"invalidated through the endpoint", false, invalidated, 0},
+		{"flag cleared directly", false, submitted, 2},
+		{"flag restored directly", true, invalidated, 2},
+		{"ballot deleted", true, append(submitted, database.VotingAuditLog{ID: 3, EntityType: "ballot", EntityID: 3, Action: "submitted"}), 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ballots[1].IsValid = sql.NullBool{Bool: tt.valid, Valid: true}
+			got := crossCheckBallots(ballots, tt.entries)
+
+			var id int64
+			if got != nil {
+				id = got.EntryID
+			}
+			if id != tt.expectedID {
+				t.Errorf("crossCheckBallots() = %+v, expected a break on ballot %d", got, tt.expectedID)
+			}
+		})
+	}
+}
+
+// TestMerkleRoot tests the RFC 6962 tree shape
+func TestMerkleRoot(t *testing.T) {
+	leaf := func(s string) []byte {
+		sum := sha256.Sum256(append([]byte{0x00}, s...))
+		return sum[:]
+	}
+	node := func(l, r []byte) []byte {
+		sum := sha256.Sum256(append(append([]byte{0x01}, l...), r...))
+		return sum[:]
+	}
+	empty := sha256.Sum256(nil)
+
+	tests := []struct {
+		name     string
+		hashes   []string
+		expected []byte
+	}{
+		{"no ballots", nil, empty[:]},
+		{"one ballot", []string{"a"}, leaf("a")},
+		{"two ballots", []string{"a", "b"}, node(leaf("a"), leaf("b"))},
+		{"three ballots", []string{"a", "b", "c"}, node(node(leaf("a"), leaf("b")), leaf("c"))},
+		{"five ballots", []string{"a", "b", "c", "d", "e"},
+			node(node(node(leaf("a"), leaf("b")), node(leaf("c"), leaf("d"))), leaf("e"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MerkleRoot(tt.hashes); got != hex.EncodeToString(tt.expected) {
+				t.Errorf("MerkleRoot() = %s, expected %x", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"go.uber.org/zap"
+)
+
+// Gathering lifecycle statuses
+const (
+	GatheringStatusDraft     = "draft"
+	GatheringStatusPublished = "published"
+	GatheringStatusActive    = "active"
+	GatheringStatusClosed    = "closed"
+	GatheringStatusTallied   = "tallied"
+)
+
+var (
+	// ErrInvalidTransition is returned when the requested status cannot be reached from the current one
+	ErrInvalidTransition = errors.New("invalid gathering status transition")
+	// ErrTransitionPrecondition is returned when a legal transition is blocked by the gathering's state
+	ErrTransitionPrecondition = errors.New("gathering transition precondition not met")
+	// ErrConcurrentTransition is returned when the gathering changed status while a transition was in progress
+	ErrConcurrentTransition = errors.New("gathering status changed concurrently")
+)
+
+// gatheringTransitions lists the statuses reachable from each status.
+// Tallied is terminal: once results are final the gathering cannot be reopened.
+var gatheringTransitions = map[string][]string{
+	GatheringStatusDraft:     {GatheringStatusPublished},
+	GatheringStatusPublished: {GatheringStatusDraft, GatheringStatusActive},
+	GatheringStatusActive:    {GatheringStatusClosed},
+	GatheringStatusClosed:    {GatheringStatusActive, GatheringStatusTallied},
+	GatheringStatusTallied:   {},
+}
+
+// IsValidGatheringStatus reports whether status is a known lifecycle status
+func IsValidGatheringStatus(status string) bool {
+	_, ok := gatheringTransitions[status]
+	return ok
+}
+
+// AllowedTransitions returns the statuses reachable from the given status
+func AllowedTransitions(from string) []string {
+	return gatheringTransitions[from]
+}
+
+// CanTransition reports whether a gathering may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, allowed := range gatheringTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionActor identifies who or what triggered a status transition
+type TransitionActor struct {
+	PerformedBy string // Username, or "scheduler" for automatic transitions
+	IPAddress   string
+	Trigger     string // manual or scheduled
+}
+
+// LifecycleService enforces the gathering status state machine
+type LifecycleService struct {
+	db                   *database.Queries
+	conn                 *sql.DB
+	votingResultsService *VotingResultsService
+	liveResults          *LiveResults
+}
+
+// NewLifecycleService creates a new LifecycleService
+func NewLifecycleService(db *database.Queries, conn *sql.DB, votingResultsService *VotingResultsService, liveResults *LiveResults) *LifecycleService {
+	return &LifecycleService{
+		db:                   db,
+		conn:                 conn,
+		votingResultsService: votingResultsService,
+		liveResults:          liveResults,
+	}
+}
+
+// Transition moves a gathering to the target status if the transition is legal and its
+// preconditions hold, records it in the audit log and triggers any follow-up work. The
+// preconditions, the status change and its audit entry are one transaction; closing also seals
+// the gathering's chains in it, so that they are complete before it can be tallied.
+func (s *LifecycleService) Transition(ctx context.Context, gathering database.Gathering, to string, actor TransitionActor) (database.Gathering, error) {
+	from := gathering.Status
+	if !CanTransition(from, to) {
+		return gathering, fmt.Errorf("%w: cannot move from %s to %s", ErrInvalidTransition, from, to)
+	}
+
+	// Taken before the transaction, which seals the audit entry in it
+	chainMu.Lock()
+	defer chainMu.Unlock()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return gathering, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	q := s.db.WithTx(tx)
+
+	if err := s.checkPreconditions(ctx, q, gathering, to); err != nil {
+		return gathering, err
+	}
+
+	// Freeze the association's voting rules and voter register so later edits do not change
+	// this gathering's results. Moving back to draft drops the register, publishing again
+	// takes a fresh snapshot.
+	switch to {
+	case GatheringStatusPublished:
+		if err := NewVotingRulesService(q).Snapshot(ctx, gathering); err != nil {
+			return gathering, err
+		}
+		if err := NewVoterRegisterService(q).Freeze(ctx, gathering); err != nil {
+			return gathering, err
+		}
+	case GatheringStatusDraft:
+		if err := NewVoterRegisterService(q).Unfreeze(ctx, gathering); err != nil {
+			return gathering, err
+		}
+	case GatheringStatusActive:
+		// A gathering reopened after its scheduled close stays open until closed by hand
+		if from == GatheringStatusClosed {
+			err := q.ClearPassedGatheringClose(ctx, database.ClearPassedGatheringCloseParams{
+				ID:  gathering.ID,
+				Now: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+			})
+			if err != nil {
+				return gathering, fmt.Errorf("failed to clear scheduled close: %w", err)
+			}
+		}
+	}
+
+	updated, err := q.TransitionGatheringStatus(ctx, database.TransitionGatheringStatusParams{
+		ToStatus:      to,
+		ID:            gathering.ID,
+		AssociationID: gathering.AssociationID,
+		FromStatus:    from,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return gathering, fmt.Errorf("%w: gathering %d is no longer %s", ErrConcurrentTransition, gathering.ID, from)
+		}
+		return gathering, fmt.Errorf("failed to update gathering status: %w", err)
+	}
+
+	// Voting on matters starts and ends with the gathering, unless the chair opens and closes
+	// each of them
+	switch to {
+	case GatheringStatusActive:
+		if err := NewMatterVotingService(q).OpenAll(ctx, updated); err != nil {
+			return gathering, err
+		}
+	case GatheringStatusClosed:
+		if err := NewMatterVotingService(q).CloseAll(ctx, updated); err != nil {
+			return gathering, err
+		}
+	}
+
+	details, err := json.Marshal(map[string]string{
+		"from":    from,
+		"to":      to,
+		"trigger": actor.Trigger,
+	})
+	if err != nil {
+		return gathering, fmt.Errorf("failed to encode audit details: %w", err)
+	}
+	err = q.CreateAuditLog(ctx, database.CreateAuditLogParams{
+		GatheringID: updated.ID,
+		EntityType:  "gathering",
+		EntityID:    updated.ID,
+		Action:      "status_changed",
+		PerformedBy: sql.NullString{String: actor.PerformedBy, Valid: actor.PerformedBy != ""},
+		IpAddress:   sql.NullString{String: actor.IPAddress, Valid: actor.IPAddress != ""},
+		Details:     sql.NullString{String: string(details), Valid: true},
+	})
+	if err != nil {
+		return gathering, fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	// Seal anything left unsealed so the published Merkle root covers a complete chain
+	integrity := NewIntegrityService(q)
+	if to == GatheringStatusClosed {
+		err = integrity.sealLocked(ctx, updated.ID)
+	} else {
+		err = integrity.sealAuditLog(ctx, updated.ID)
+	}
+	if err != nil {
+		return gathering, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return gathering, fmt.Errorf("failed to commit gathering status: %w", err)
+	}
+
+	s.afterTransition(updated, from, to)
+
+	return updated, nil
+}
+
+// checkPreconditions verifies the gathering is ready for the target status, reading through the
+// transition's transaction
+func (s *LifecycleService) checkPreconditions(ctx context.Context, q *database.Queries, gathering database.Gathering, to string) error {
+	switch to {
+	case GatheringStatusPublished:
+		matters, err := q.CountVotingMatters(ctx, gathering.ID)
+		if err != nil {
+			return fmt.Errorf("failed to count voting matters: %w", err)
+		}
+		if matters == 0 {
+			return fmt.Errorf("%w: at least one voting matter is required before publishing", ErrTransitionPrecondition)
+		}
+	case GatheringStatusActive:
+		slots, err := q.CountGatheringUnitSlots(ctx, gathering.ID)
+		if err != nil {
+			return fmt.Errorf("failed to count unit slots: %w", err)
+		}
+		if slots == 0 || slots < gathering.QualifiedUnitsCount.Int64 {
+			return fmt.Errorf("%w: unit slots are not synced with the qualified units (%d of %d)",
+				ErrTransitionPrecondition, slots, gathering.QualifiedUnitsCount.Int64)
+		}
+	case GatheringStatusTallied:
+		report, err := NewIntegrityService(q).Check(ctx, gathering.ID)
+		if err != nil {
+			return fmt.Errorf("failed to check integrity: %w", err)
+		}
+		if !report.Intact {
+			return fmt.Errorf("%w: integrity check failed on %s: %s",
+				ErrTransitionPrecondition, report.FirstBreak.Chain, report.FirstBreak.Reason)
+		}
+	}
+	return nil
+}
+
+// afterTransition runs the background work associated with entering a status.
+// It uses its own context so the work outlives the request that triggered it.
+func (s *LifecycleService) afterTransition(gathering database.Gathering, from, to string) {
+	if s.liveResults != nil {
+		go s.liveResults.Publish(gathering.ID)
+	}
+	if s.votingResultsService == nil {
+		return
+	}
+
+	switch {
+	case to == GatheringStatusClosed:
+		go func() {
+			_, err := s.votingResultsService.ComputeAndStoreResults(context.Background(), gathering.ID, gathering.AssociationID)
+			if err != nil {
+				logging.Logger.Log(zap.ErrorLevel, "Error computing final results",
+					zap.Int64("gathering_id", gathering.ID),
+					zap.Error(err))
+			} else {
+				logging.Logger.Log(zap.InfoLevel, "Successfully computed and cached voting results",
+					zap.Int64("gathering_id", gathering.ID))
+			}
+		}()
+	case from == GatheringStatusClosed && to == GatheringStatusActive:
+		go func() {
+			err := s.votingResultsService.InvalidateResults(context.Background(), gathering.ID)
+			if err != nil {
+				logging.Logger.Log(zap.WarnLevel, "Error invalidating cached results",
+					zap.Int64("gathering_id", gathering.ID),
+					zap.Error(err))
+			}
+		}()
+	}
+}
@@ -0,0 +1,50 @@
+package services
+
+import "testing"
+
+// TestCanTransition tests the allowed gathering status transitions
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected bool
+	}{
+		{name: "draft to published", from: "draft", to: "published", expected: true},
+		{name: "published back to draft", from: "published", to: "draft", expected: true},
+		{name: "published to active", from: "published", to: "active", expected: true},
+		{name: "active to closed", from: "active", to: "closed", expected: true},
+		{name: "closed reopened to active", from: "closed", to: "active", expected: true},
+		{name: "closed to tallied", from: "closed", to: "tallied", expected: true},
+		{name: "draft straight to tallied", from: "draft", to: "tallied", expected: false},
+		{name: "draft straight to active", from: "draft", to: "active", expected: false},
+		{name: "active back to draft", from: "active", to: "draft", expected: false},
+		{name: "active straight to tallied", from: "active", to: "tallied", expected: false},
+		{name: "tallied reopened", from: "tallied", to: "active", expected: false},
+		{name: "tallied to closed", from: "tallied", to: "closed", expected: false},
+		{name: "same status", from: "active", to: "active", expected: false},
+		{name: "unknown source status", from: "archived", to: "draft", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanTransition(tt.from, tt.to); got != tt.expected {
+				t.Errorf("CanTransition(%q, %q) = %v, expected %v", tt.from, tt.to, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestIsValidGatheringStatus tests recognition of lifecycle statuses
+func TestIsValidGatheringStatus(t *testing.T) {
+	for _, status := range []string{"draft", "published", "active", "closed", "tallied"} {
+		if !IsValidGatheringStatus(status) {
+			t.Errorf("IsValidGatheringStatus(%q) = false, expected true", status)
+		}
+	}
+	for _, status := range []string{"", "archived", "ACTIVE"} {
+		if IsValidGatheringStatus(status) {
+			t.Errorf("IsValidGatheringStatus(%q) = true, expected false", status)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"go.uber.org/zap"
+)
+
+// LiveResults pushes participation and running tallies to the subscribers of a gathering,
+// e.g. the results screen at an in-person meeting. Publishers call Publish once the change is
+// stored; each subscriber only ever holds the latest update, so slow clients skip intermediate ones.
+type LiveResults struct {
+	db           *database.Queries
+	statsService *StatsService
+
+	mu          sync.Mutex
+	subscribers map[int64]map[chan domain.LiveResults]struct{}
+	publishMu   sync.Mutex // Keeps concurrent publishes from delivering an older snapshot last
+}
+
+// NewLiveResults creates a new LiveResults
+func NewLiveResults(db *database.Queries, statsService *StatsService) *LiveResults {
+	return &LiveResults{
+		db:           db,
+		statsService: statsService,
+		subscribers:  make(map[int64]map[chan domain.LiveResults]struct{}),
+	}
+}
+
+// Subscribe registers a subscriber for a gathering's updates. Call the returned function to unsubscribe.
+func (l *LiveResults) Subscribe(gatheringID int64) (<-chan domain.LiveResults, func()) {
+	ch := make(chan domain.LiveResults, 1)
+
+	l.mu.Lock()
+	if l.subscribers[gatheringID] == nil {
+		l.subscribers[gatheringID] = make(map[chan domain.LiveResults]struct{})
+	}
+	l.subscribers[gatheringID][ch] = struct{}{}
+	l.mu.Unlock()
+
+	return ch, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.subscribers[gatheringID], ch)
+		if len(l.subscribers[gatheringID]) == 0 {
+			delete(l.subscribers, gatheringID)
+		}
+	}
+}
+
+// Publish builds the gathering's current results and sends them to its subscribers.
+// It does nothing when nobody is subscribed.
+func (l *LiveResults) Publish(gatheringID int64) {
+	l.mu.Lock()
+	subscribed := len(l.subscribers[gatheringID]) > 0
+	l.mu.Unlock()
+	if !subscribed {
+		return
+	}
+
+	l.publishMu.Lock()
+	defer l.publishMu.Unlock()
+
+	ctx := context.Background()
+	gathering, err := l.db.GetGatheringByID(ctx, gatheringID)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error getting gathering for live results",
+			zap.Int64("gathering_id", gatheringID),
+			zap.Error(err))
+		return
+	}
+	update, err := l.Snapshot(ctx, gathering)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error building live results",
+			zap.Int64("gathering_id", gatheringID),
+			zap.Error(err))
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ch := range l.subscribers[gatheringID] {
+		// Replace an update the subscriber has not picked up yet
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- update
+	}
+}
+
+// Snapshot builds the current live results of a gathering
+func (l *LiveResults) Snapshot(ctx context.Context, gathering database.Gathering) (domain.LiveResults, error) {
+	participation, err := l.statsService.LiveParticipation(ctx, gathering)
+	if err != nil {
+		return domain.LiveResults{}, err
+	}
+	matters, err := l.db.GetVotingMatters(ctx, gathering.ID)
+	if err != nil {
+		return domain.LiveResults{}, fmt.Errorf("failed to get voting matters: %w", err)
+	}
+	tallies, err := l.db.GetAllVoteTallies(ctx, gathering.ID)
+	if err != nil {
+		return domain.LiveResults{}, fmt.Errorf("failed to get tallies: %w", err)
+	}
+
+	return domain.LiveResults{
+		GatheringID:   gathering.ID,
+		Status:        gathering.Status,
+		Participation: participation,
+		Matters:       liveMatterResults(gathering.Status, matters, tallies),
+		UpdatedAt:     time.Now().UTC(),
+	}, nil
+}
+
+// liveMatterResults lists every matter in order, with its tally only where results may be shown:
+// matters configured to show results during voting, and every matter once voting has ended.
+// Anonymous matters wait until voting on them ends even when configured to show results, as
+// comparing the tally from one vote to the next would show how each voter chose.
+func liveMatterResults(status string, matters []database.VotingMatter, tallies []database.GetAllVoteTalliesRow) []domain.LiveMatterResult {
+	talliesByMatter := make(map[int64]string, len(tallies))
+	for _, tally := range tallies {
+		talliesByMatter[tally.VotingMatterID] = tally.TallyData
+	}
+	votingEnded := status == GatheringStatusClosed || status == GatheringStatusTallied
+
+	results := make([]domain.LiveMatterResult, 0, len(matters))
+	for _, matter := range matters {
+		var config domain.VotingConfig
+		json.Unmarshal([]byte(matter.VotingConfig), &config)
+
+		visible := votingEnded
+		if config.ShowResultsDuringVoting {
+			visible = visible || !config.IsAnonymous || matter.VotingState == MatterVotingClosed
+		}
+		result := domain.LiveMatterResult{
+			MatterID:       matter.ID,
+			Title:          matter.Title,
+			ResultsVisible: visible,
+		}
+		if result.ResultsVisible {
+			result.Tally = make(map[string]domain.TallyResult)
+			if data, ok := talliesByMatter[matter.ID]; ok {
+				json.Unmarshal([]byte(data), &result.Tally)
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
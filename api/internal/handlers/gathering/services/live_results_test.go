@@ -0,0 +1,55 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/alexmarian/apc/api/internal/database"
+)
+
+// TestLiveMatterResults tests that tallies are only streamed for matters allowed to show them
+func TestLiveMatterResults(t *testing.T) {
+	matters := []database.VotingMatter{
+		{ID: 1, Title: "Budget", VotingConfig: `{"type":"yes_no","show_results_during_voting":true}`},
+		{ID: 2, Title: "Board", VotingConfig: `{"type":"multiple_choice","show_results_during_voting":false}`},
+		{ID: 3, Title: "Roof", VotingConfig: `{"type":"yes_no","show_results_during_voting":true}`},
+		{ID: 4, Title: "Auditor", VotingConfig: `{"type":"yes_no","show_results_during_voting":true,"is_anonymous":true}`},
+		{ID: 5, Title: "Chair", VotingConfig: `{"type":"yes_no","show_results_during_voting":true,"is_anonymous":true}`, VotingState: MatterVotingClosed},
+	}
+	tallies := []database.GetAllVoteTalliesRow{
+		{VotingMatterID: 1, TallyData: `{"yes":{"count":3,"weight":0.3}}`},
+		{VotingMatterID: 2, TallyData: `{"anna":{"count":2,"weight":0.2}}`},
+	}
+
+	tests := []struct {
+		name            string
+		status          string
+		expectedVisible []bool
+	}{
+		{"during voting", GatheringStatusActive, []bool{true, false, true, false, true}},
+		{"after closing", GatheringStatusClosed, []bool{true, true, true, true, true}},
+		{"after tallying", GatheringStatusTallied, []bool{true, true, true, true, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := liveMatterResults(tt.status, matters, tallies)
+			if len(results) != len(matters) {
+				t.Fatalf("liveMatterResults() returned %d matters, expected %d", len(results), len(matters))
+			}
+			for i, result := range results {
+				if result.ResultsVisible != tt.expectedVisible[i] {
+					t.Errorf("matter %d visible = %v, expected %v", result.MatterID, result.ResultsVisible, tt.expectedVisible[i])
+				}
+				if !result.ResultsVisible && result.Tally != nil {
+					t.Errorf("matter %d leaked its tally: %v", result.MatterID, result.Tally)
+				}
+			}
+			if results[0].Tally["yes"].Count != 3 {
+				t.Errorf("matter 1 tally = %v, expected 3 yes votes", results[0].Tally)
+			}
+			if results[2].Tally == nil || len(results[2].Tally) != 0 {
+				t.Errorf("matter 3 tally = %v, expected an empty tally before any vote", results[2].Tally)
+			}
+		})
+	}
+}
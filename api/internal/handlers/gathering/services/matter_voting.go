@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// Voting windows, when the matters of a gathering take votes
+const (
+	VotingWindowsGathering = "gathering"  // Every matter takes votes while the gathering is active
+	VotingWindowsPerMatter = "per_matter" // The chair opens and closes voting on each matter in turn
+)
+
+// VotingWindowsModes lists the voting windows a gathering can use
+var VotingWindowsModes = []string{VotingWindowsGathering, VotingWindowsPerMatter}
+
+// Voting states of a matter in a per_matter gathering
+const (
+	MatterVotingPending = "pending"
+	MatterVotingOpen    = "open"
+	MatterVotingClosed  = "closed"
+)
+
+var (
+	// ErrInvalidVotingWindows is returned for voting windows other than gathering or per_matter
+	ErrInvalidVotingWindows = errors.New("invalid voting windows")
+	// ErrVotingWindowsFixed is returned when the voting windows are changed once voting has opened
+	ErrVotingWindowsFixed = errors.New("voting windows are fixed once voting opens")
+	// ErrNotPerMatter is returned when a matter is opened or closed in a gathering voting on all matters at once
+	ErrNotPerMatter = errors.New("the gathering does not vote matter by matter")
+	// ErrMatterVotingInactive is returned when a matter is opened or closed while the gathering is not active
+	ErrMatterVotingInactive = errors.New("voting on matters is opened and closed while the gathering is active")
+	// ErrMatterInformative is returned when voting is opened on an informative matter
+	ErrMatterInformative = errors.New("informative matters take no votes")
+	// ErrMatterNotPending is returned when voting on a matter is opened a second time
+	ErrMatterNotPending = errors.New("voting on the matter has already been opened")
+	// ErrMatterNotOpen is returned when voting on a matter that is not open is closed
+	ErrMatterNotOpen = errors.New("voting on the matter is not open")
+	// ErrMatterAlreadyVoted is returned when a participant votes again on a matter of their ballot
+	ErrMatterAlreadyVoted = errors.New("the participant has already voted on the matter")
+	// ErrParticipantLeft is returned when a participant who left the meeting votes
+	ErrParticipantLeft = errors.New("the participant has left the meeting")
+)
+
+// PerMatterVoting reports whether the chair opens and closes voting on each matter of the gathering
+func PerMatterVoting(gathering database.Gathering) bool {
+	return gathering.VotingWindows == VotingWindowsPerMatter
+}
+
+// ValidateVotingWindows checks a ballot submitted to the gathering. Every matter takes votes
+// unless the gathering votes matter by matter, where votes are taken only on open matters and
+// a ballot holds the matters it votes on rather than all of them.
+func ValidateVotingWindows(gathering database.Gathering, matters []database.VotingMatter, content map[string]domain.BallotVote) []domain.BallotMatterError {
+	return validateWindows(gathering, matters, content, MatterVotingOpen)
+}
+
+// ValidateCorrectionWindows checks a ballot corrected by the counting commission, which may hold
+// votes on any matter voting was opened on
+func ValidateCorrectionWindows(gathering database.Gathering, matters []database.VotingMatter, content map[string]domain.BallotVote) []domain.BallotMatterError {
+	return validateWindows(gathering, matters, content, MatterVotingOpen, MatterVotingClosed)
+}
+
+// validateWindows checks a ballot against the matters in one of the given voting states
+func validateWindows(gathering database.Gathering, matters []database.VotingMatter, content map[string]domain.BallotVote, states ...string) []domain.BallotMatterError {
+	if !PerMatterVoting(gathering) {
+		return ValidateBallotContent(matters, content)
+	}
+
+	var errs []domain.BallotMatterError
+	var voted []database.VotingMatter
+	for _, matter := range matters {
+		matterID := strconv.FormatInt(matter.ID, 10)
+		if _, ok := content[matterID]; !ok {
+			continue
+		}
+		if !slices.Contains(states, matter.VotingState) {
+			errs = append(errs, domain.BallotMatterError{
+				MatterID: matterID,
+				Message:  fmt.Sprintf("voting on this matter is %s", matter.VotingState),
+			})
+			continue
+		}
+		voted = append(voted, matter)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return ValidateBallotContent(voted, content)
+}
+
+// MergeMatterVotes adds votes on matters to a participant's ballot content. A matter already on
+// the ballot cannot be voted again; corrections go through the counting commission.
+func MergeMatterVotes(current, votes map[string]domain.BallotVote) (map[string]domain.BallotVote, error) {
+	merged := make(map[string]domain.BallotVote, len(current)+len(votes))
+	for matterID, vote := range current {
+		merged[matterID] = vote
+	}
+	for matterID, vote := range votes {
+		if _, ok := merged[matterID]; ok {
+			return nil, fmt.Errorf("%w: matter %s", ErrMatterAlreadyVoted, matterID)
+		}
+		merged[matterID] = vote
+	}
+	return merged, nil
+}
+
+// MatterVotingService handles the voting windows of gatherings and the chair opening and closing
+// voting on each matter
+type MatterVotingService struct {
+	db *database.Queries
+}
+
+// NewMatterVotingService creates a new MatterVotingService
+func NewMatterVotingService(db *database.Queries) *MatterVotingService {
+	return &MatterVotingService{db: db}
+}
+
+// Settings returns the voting windows of a gathering and the voting state of its matters
+func (s *MatterVotingService) Settings(ctx context.Context, gathering database.Gathering) (domain.MatterVotingSettings, error) {
+	matters, err := s.db.GetVotingMatters(ctx, gathering.ID)
+	if err != nil {
+		return domain.MatterVotingSettings{}, fmt.Errorf("failed to get voting matters: %w", err)
+	}
+	settings := domain.MatterVotingSettings{
+		GatheringID:   gathering.ID,
+		VotingWindows: gathering.VotingWindows,
+		Editable:      gathering.Status == GatheringStatusDraft || gathering.Status == GatheringStatusPublished,
+		Matters:       make([]domain.MatterVotingState, len(matters)),
+	}
+	for i, matter := range matters {
+		settings.Matters[i] = domain.MatterVotingState{
+			MatterID:       matter.ID,
+			OrderIndex:     int(matter.OrderIndex),
+			Title:          matter.Title,
+			IsInformative:  matter.IsInformative != 0,
+			VotingState:    matter.VotingState,
+			VotingOpenedAt: domain.NullTimeToPtr(matter.VotingOpenedAt),
+			VotingClosedAt: domain.NullTimeToPtr(matter.VotingClosedAt),
+		}
+	}
+	return settings, nil
+}
+
+// SetVotingWindows sets when the matters of a gathering take votes. It can change until voting opens.
+func (s *MatterVotingService) SetVotingWindows(ctx context.Context, gathering database.Gathering, windows string) (domain.MatterVotingSettings, error) {
+	if !slices.Contains(VotingWindowsModes, windows) {
+		return domain.MatterVotingSettings{}, fmt.Errorf("%w: expected one of %s", ErrInvalidVotingWindows, strings.Join(VotingWindowsModes, ", "))
+	}
+	if gathering.Status != GatheringStatusDraft && gathering.Status != GatheringStatusPublished {
+		return domain.MatterVotingSettings{}, ErrVotingWindowsFixed
+	}
+	err := s.db.SetGatheringVotingWindows(ctx, database.SetGatheringVotingWindowsParams{
+		VotingWindows: windows,
+		ID:            gathering.ID,
+	})
+	if err != nil {
+		return domain.MatterVotingSettings{}, fmt.Errorf("failed to set voting windows: %w", err)
+	}
+	gathering.VotingWindows = windows
+	return s.Settings(ctx, gathering)
+}
+
+// Open opens voting on a pending matter of an active per_matter gathering
+func (s *MatterVotingService) Open(ctx context.Context, gathering database.Gathering, matterID int64) (database.VotingMatter, error) {
+	if err := s.checkChairCanAct(gathering); err != nil {
+		return database.VotingMatter{}, err
+	}
+	matter, err := s.db.GetVotingMatter(ctx, database.GetVotingMatterParams{
+		ID:          matterID,
+		GatheringID: gathering.ID,
+	})
+	if err != nil {
+		return database.VotingMatter{}, fmt.Errorf("failed to get voting matter: %w", err)
+	}
+	if matter.IsInformative != 0 {
+		return database.VotingMatter{}, ErrMatterInformative
+	}
+
+	opened, err := s.db.OpenVotingMatter(ctx, database.OpenVotingMatterParams{
+		ID:          matterID,
+		GatheringID: gathering.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return matter, ErrMatterNotPending
+	}
+	if err != nil {
+		return database.VotingMatter{}, fmt.Errorf("failed to open voting on matter: %w", err)
+	}
+	return opened, nil
+}
+
+// Close closes voting on an open matter of an active per_matter gathering
+func (s *MatterVotingService) Close(ctx context.Context, gathering database.Gathering, matterID int64) (database.VotingMatter, error) {
+	if err := s.checkChairCanAct(gathering); err != nil {
+		return database.VotingMatter{}, err
+	}
+	matter, err := s.db.GetVotingMatter(ctx, database.GetVotingMatterParams{
+		ID:          matterID,
+		GatheringID: gathering.ID,
+	})
+	if err != nil {
+		return database.VotingMatter{}, fmt.Errorf("failed to get voting matter: %w", err)
+	}
+
+	closed, err := s.db.CloseVotingMatter(ctx, database.CloseVotingMatterParams{
+		ID:          matterID,
+		GatheringID: gathering.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return matter, ErrMatterNotOpen
+	}
+	if err != nil {
+		return database.VotingMatter{}, fmt.Errorf("failed to close voting on matter: %w", err)
+	}
+	return closed, nil
+}
+
+// OpenAll starts the voting window of every matter when a gathering voting on all of them at
+// once opens, or reopens. The matters of a per_matter gathering open one by one instead.
+func (s *MatterVotingService) OpenAll(ctx context.Context, gathering database.Gathering) error {
+	if PerMatterVoting(gathering) {
+		return nil
+	}
+	if err := s.db.OpenVotingWindow(ctx, gathering.ID); err != nil {
+		return fmt.Errorf("failed to open voting on matters: %w", err)
+	}
+	return nil
+}
+
+// CloseAll closes voting on the matters left open when the gathering closes, and ends the voting
+// window of every matter of a gathering voting on all of them at once
+func (s *MatterVotingService) CloseAll(ctx context.Context, gathering database.Gathering) error {
+	if !PerMatterVoting(gathering) {
+		if err := s.db.CloseVotingWindow(ctx, gathering.ID); err != nil {
+			return fmt.Errorf("failed to close voting on matters: %w", err)
+		}
+		return nil
+	}
+	if _, err := s.db.CloseOpenVotingMatters(ctx, gathering.ID); err != nil {
+		return fmt.Errorf("failed to close voting on matters: %w", err)
+	}
+	return nil
+}
+
+// checkChairCanAct checks the chair may open or close voting on the gathering's matters
+func (s *MatterVotingService) checkChairCanAct(gathering database.Gathering) error {
+	if !PerMatterVoting(gathering) {
+		return ErrNotPerMatter
+	}
+	if gathering.Status != GatheringStatusActive {
+		return ErrMatterVotingInactive
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// TestValidateVotingWindows tests that a per_matter gathering takes votes only on open matters
+func TestValidateVotingWindows(t *testing.T) {
+	matters := []database.VotingMatter{
+		{ID: 1, VotingConfig: `{"type":"yes_no","allow_abstention":false}`, VotingState: MatterVotingClosed},
+		{ID: 2, VotingConfig: `{"type":"yes_no","allow_abstention":false}`, VotingState: MatterVotingOpen},
+		{ID: 3, VotingConfig: `{"type":"yes_no","allow_abstention":false}`, VotingState: MatterVotingPending},
+	}
+	perMatter := database.Gathering{VotingWindows: VotingWindowsPerMatter}
+
+	tests := []struct {
+		name               string
+		gathering          database.Gathering
+		content            map[string]domain.BallotVote
+		expectedErrors     []string // matter IDs with errors
+		expectedCorrection []string
+	}{
+		{
+			name:      "open matter alone",
+			gathering: perMatter,
+			content: map[string]domain.BallotVote{
+				"2": {MatterID: 2, Values: []string{"yes"}},
+			},
+		},
+		{
+			name:      "closed matter only by correction",
+			gathering: perMatter,
+			content: map[string]domain.BallotVote{
+				"1": {MatterID: 1, Values: []string{"no"}},
+				"2": {MatterID: 2, Values: []string{"yes"}},
+			},
+			expectedErrors: []string{"1"},
+		},
+		{
+			name:      "pending matter",
+			gathering: perMatter,
+			content: map[string]domain.BallotVote{
+				"3": {MatterID: 3, Values: []string{"yes"}},
+			},
+			expectedErrors:     []string{"3"},
+			expectedCorrection: []string{"3"},
+		},
+		{
+			name:      "invalid vote on an open matter",
+			gathering: perMatter,
+			content: map[string]domain.BallotVote{
+				"2": {MatterID: 2, Values: []string{"maybe"}},
+			},
+			expectedErrors:     []string{"2"},
+			expectedCorrection: []string{"2"},
+		},
+		{
+			name:      "gathering windows need every matter",
+			gathering: database.Gathering{VotingWindows: VotingWindowsGathering},
+			content: map[string]domain.BallotVote{
+				"2": {MatterID: 2, Values: []string{"yes"}},
+			},
+			expectedErrors:     []string{"1", "3"},
+			expectedCorrection: []string{"1", "3"},
+		},
+	}
+
+	matterIDs := func(errs []domain.BallotMatterError) []string {
+		var ids []string
+		for _, e := range errs {
+			ids = append(ids, e.MatterID)
+		}
+		return ids
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matterIDs(ValidateVotingWindows(tt.gathering, matters, tt.content))
+			if !reflect.DeepEqual(got, tt.expectedErrors) {
+				t.Errorf("ValidateVotingWindows() errors on %v, want %v", got, tt.expectedErrors)
+			}
+			got = matterIDs(ValidateCorrectionWindows(tt.gathering, matters, tt.content))
+			if !reflect.DeepEqual(got, tt.expectedCorrection) {
+				t.Errorf("ValidateCorrectionWindows() errors on %v, want %v", got, tt.expectedCorrection)
+			}
+		})
+	}
+}
+
+// TestMergeMatterVotes tests adding votes to a ballot without voting a matter twice
+func TestMergeMatterVotes(t *testing.T) {
+	current := map[string]domain.BallotVote{"1": {MatterID: 1, Values: []string{"yes"}}}
+
+	merged, err := MergeMatterVotes(current, map[string]domain.BallotVote{"2": {MatterID: 2, Values: []string{"no"}}})
+	if err != nil {
+		t.Fatalf("MergeMatterVotes() error = %v", err)
+	}
+	if len(merged) != 2 || merged["1"].Values[0] != "yes" || merged["2"].Values[0] != "no" {
+		t.Errorf("MergeMatterVotes() = %v", merged)
+	}
+	if len(current) != 1 {
+		t.Errorf("MergeMatterVotes() changed the current ballot: %v", current)
+	}
+
+	_, err = MergeMatterVotes(current, map[string]domain.BallotVote{"1": {MatterID: 1, Values: []string{"no"}}})
+	if !errors.Is(err, ErrMatterAlreadyVoted) {
+		t.Errorf("MergeMatterVotes() error = %v, want %v", err, ErrMatterAlreadyVoted)
+	}
+}
+
+// TestMatterAttendance tests who counts toward the quorum of a matter voted on its own
+func TestMatterAttendance(t *testing.T) {
+	base := time.Date(2026, 5, 12, 18, 0, 0, 0, time.UTC)
+	at := func(minutes int) sql.NullTime {
+		return sql.NullTime{Time: base.Add(time.Duration(minutes) * time.Minute), Valid: true}
+	}
+
+	participants := []database.GetGatheringParticipantsRow{
+		{ID: 1, UnitsInfo: "[1]", UnitsPart: 0.1, CheckInTime: at(0)},                         // Stays throughout
+		{ID: 2, UnitsInfo: "[2,3]", UnitsPart: 0.2, CheckInTime: at(0), CheckOutTime: at(20)}, // Leaves after the first matter
+		{ID: 3, UnitsInfo: "[4]", UnitsPart: 0.3, CheckInTime: at(25)},                        // Arrives for the second matter
+		{ID: 4, UnitsInfo: "[5]", UnitsPart: 0.05},                                            // Never checked in
+	}
+	spans := AttendanceSpans(participants, nil)
+
+	tests := []struct {
+		name           string
+		matter         database.VotingMatter
+		voters         map[int64]bool
+		expectedWeight float64
+		expectedCount  int
+	}{
+		{
+			name:           "first matter",
+			matter:         database.VotingMatter{VotingState: MatterVotingClosed, VotingOpenedAt: at(5), VotingClosedAt: at(15)},
+			expectedWeight: 0.3,
+			expectedCount:  3,
+		},
+		{
+			name:           "second matter",
+			matter:         database.VotingMatter{VotingState: MatterVotingClosed, VotingOpenedAt: at(30), VotingClosedAt: at(40)},
+			expectedWeight: 0.4,
+			expectedCount:  2,
+		},
+		{
+			name:           "open matter counts arrivals so far",
+			matter:         database.VotingMatter{VotingState: MatterVotingOpen, VotingOpenedAt: at(10)},
+			expectedWeight: 0.6,
+			expectedCount:  4,
+		},
+		{
+			name:           "voters count without checking in",
+			matter:         database.VotingMatter{VotingState: MatterVotingClosed, VotingOpenedAt: at(30), VotingClosedAt: at(40)},
+			voters:         map[int64]bool{4: true},
+			expectedWeight: 0.45,
+			expectedCount:  3,
+		},
+		{
+			name:           "pending matter counts those present now",
+			matter:         database.VotingMatter{VotingState: MatterVotingPending},
+			expectedWeight: 0.4,
+			expectedCount:  2,
+		},
+	}
+
+	now := base.Add(time.Hour)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attendance := AttendanceForMatter(tt.matter, participants, spans, tt.voters, now)
+			if math.Abs(attendance.Weight-tt.expectedWeight) > 1e-9 || attendance.Units != tt.expectedCount {
+				t.Errorf("AttendanceForMatter() = %v, %d, want %v, %d", attendance.Weight, attendance.Units, tt.expectedWeight, tt.expectedCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/boombuler/barcode/qr"
+)
+
+// DefaultInvitationTTL is how long a member invitation stays valid unless told otherwise
+const DefaultInvitationTTL = 365 * 24 * time.Hour
+
+// ErrMemberAppNotConfigured is returned when links to the member app are needed but where it is
+// served is not configured
+var ErrMemberAppNotConfigured = errors.New("member app address is not configured")
+
+// NewMemberToken generates the opaque token of a member invitation and the hash it is stored as.
+// The plaintext token is handed out once and never stored.
+func NewMemberToken() (token, tokenHash string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	hash := sha256.Sum256([]byte(token))
+	return token, hex.EncodeToString(hash[:]), nil
+}
+
+// InvitationService issues member invitations in bulk
+type InvitationService struct {
+	db                   *database.Queries
+	conn                 *sql.DB
+	voterRegisterService *VoterRegisterService
+	verificationService  *MemberVerificationService
+	memberOrigin         string // Where the member app is served, without a trailing slash
+}
+
+// NewInvitationService creates a new InvitationService
+func NewInvitationService(db *database.Queries, conn *sql.DB, verificationService *MemberVerificationService, memberOrigin string) *InvitationService {
+	return &InvitationService{
+		db:                   db,
+		conn:                 conn,
+		voterRegisterService: NewVoterRegisterService(db),
+		verificationService:  verificationService,
+		memberOrigin:         strings.TrimSuffix(memberOrigin, "/"),
+	}
+}
+
+// IssueMissing issues an invitation to every voting owner on the gathering's register who has
+// no active one, replacing those that expired. It returns the invitations issued, with their
+// tokens, and how many owners already had an active invitation. The tokens cannot be recovered
+// later, so when handOut is given the invitations are only kept if it succeeds with them. When
+// the gathering requires a PIN, each invitation comes with one.
+func (s *InvitationService) IssueMissing(ctx context.Context, gathering database.Gathering, expiresAt time.Time, handOut func([]domain.IssuedInvitation) error) ([]domain.IssuedInvitation, int, error) {
+	if s.memberOrigin == "" {
+		return nil, 0, ErrMemberAppNotConfigured
+	}
+	register, err := s.voterRegisterService.Register(ctx, gathering)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get voter register: %w", err)
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+
+	now := time.Now()
+	var issued []domain.IssuedInvitation
+	skipped := 0
+	for _, voter := range voters(gathering.CoOwnershipPolicy, register) {
+		existing, err := qtx.GetActiveMemberInvitationByOwnerAndGathering(ctx, database.GetActiveMemberInvitationByOwnerAndGatheringParams{
+			GatheringID: gathering.ID,
+			OwnerID:     voter.owner.OwnerID,
+		})
+		switch {
+		case err == nil && existing.ExpiresAt.After(now):
+			skipped++
+			continue
+		case err == nil:
+			if err := qtx.RevokeMemberInvitation(ctx, existing.ID); err != nil {
+				return nil, 0, fmt.Errorf("failed to revoke expired invitation: %w", err)
+			}
+		case !errors.Is(err, sql.ErrNoRows):
+			return nil, 0, fmt.Errorf("failed to get invitation: %w", err)
+		}
+
+		token, tokenHash, err := NewMemberToken()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to generate token: %w", err)
+		}
+		inv, err := qtx.CreateMemberInvitation(ctx, database.CreateMemberInvitationParams{
+			GatheringID: gathering.ID,
+			OwnerID:     voter.owner.OwnerID,
+			TokenHash:   tokenHash,
+			ExpiresAt:   expiresAt,
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to create invitation: %w", err)
+		}
+		var pin string
+		if gathering.MemberVerification == MemberVerificationPIN {
+			if pin, err = s.verificationService.IssuePIN(ctx, qtx, inv); err != nil {
+				return nil, 0, err
+			}
+		}
+
+		units := make([]string, len(voter.units))
+		for i, unit := range voter.units {
+			units[i] = fmt.Sprintf("%s (%s)", unit.UnitNumber, unit.BuildingName)
+		}
+		issued = append(issued, domain.IssuedInvitation{
+			InvitationID: inv.ID,
+			OwnerID:      voter.owner.OwnerID,
+			OwnerName:    voter.owner.Name,
+			Units:        units,
+			Token:        token,
+			Link:         s.memberOrigin + "/" + token,
+			PIN:          pin,
+			ExpiresAt:    inv.ExpiresAt,
+		})
+	}
+
+	if handOut != nil {
+		if err := handOut(issued); err != nil {
+			return nil, 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit invitations: %w", err)
+	}
+	return issued, skipped, nil
+}
+
+// qrModules encodes text as a QR code, returned as rows of dark modules
+func qrModules(text string) ([][]bool, error) {
+	code, err := qr.Encode(text, qr.M, qr.Auto)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	bounds := code.Bounds()
+	modules := make([][]bool, bounds.Dy())
+	for y := range modules {
+		modules[y] = make([]bool, bounds.Dx())
+		for x := range modules[y] {
+			modules[y][x] = code.At(bounds.Min.X+x, bounds.Min.Y+y) == color.Black
+		}
+	}
+	return modules, nil
+}
@@ -0,0 +1,39 @@
+package services
+
+import "testing"
+
+// TestQRModules tests that links are encoded as square QR codes with their finder patterns
+func TestQRModules(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"short link", "https://vote.example.com/abc"},
+		{"member link", "https://vote.example.com/sWegNJ6KuV3vUxodYZF0VLbULKnp28WDuFBL7NI1ufI"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modules, err := qrModules(tt.text)
+			if err != nil {
+				t.Fatalf("qrModules() unexpected error: %v", err)
+			}
+			size := len(modules)
+			if size < 21 || (size-21)%4 != 0 {
+				t.Fatalf("size = %d, not that of a QR code version", size)
+			}
+			for _, row := range modules {
+				if len(row) != size {
+					t.Fatalf("row of %d modules in a %d wide code", len(row), size)
+				}
+			}
+			// Each finder pattern has a dark ring around a light ring around a dark 3x3 center
+			for _, corner := range [][2]int{{0, 0}, {size - 7, 0}, {0, size - 7}} {
+				x, y := corner[0], corner[1]
+				if !modules[y][x] || !modules[y+6][x+6] || modules[y+1][x+1] || !modules[y+3][x+3] {
+					t.Errorf("no finder pattern at %v", corner)
+				}
+			}
+		})
+	}
+}
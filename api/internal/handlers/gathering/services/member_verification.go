@@ -0,0 +1,452 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"github.com/alexmarian/apc/api/notify"
+	"go.uber.org/zap"
+)
+
+// Member verification methods, the second factor owners give before submitting a ballot with
+// their invitation link
+const (
+	MemberVerificationNone = "none"
+	MemberVerificationCode = "code" // A one-time code sent by email or SMS
+	MemberVerificationPIN  = "pin"  // A PIN printed on the invitation letter
+)
+
+// MemberVerificationMethods lists the verification methods a gathering can require
+var MemberVerificationMethods = []string{MemberVerificationNone, MemberVerificationCode, MemberVerificationPIN}
+
+// Channels one-time codes are sent over
+const (
+	VerificationChannelEmail = "email"
+	VerificationChannelSMS   = "sms"
+)
+
+const (
+	// MemberSessionTTL is how long a verified session lasts
+	MemberSessionTTL = 15 * time.Minute
+	// VerificationCodeTTL is how long a one-time code is valid
+	VerificationCodeTTL = 10 * time.Minute
+	// MaxVerificationAttempts is how many wrong codes or PINs lock an invitation
+	MaxVerificationAttempts = 5
+	// verificationCodeInterval is how long an owner waits before another code is sent
+	verificationCodeInterval = time.Minute
+	// verificationDigits is the length of codes and PINs
+	verificationDigits = 6
+)
+
+var (
+	// ErrInvalidVerificationMethod is returned for a method other than none, code or pin
+	ErrInvalidVerificationMethod = errors.New("invalid verification method")
+	// ErrVerificationMethodFixed is returned when the method is changed once voting has opened
+	ErrVerificationMethodFixed = errors.New("verification method is fixed once voting opens")
+	// ErrVerificationNotUsed is returned when an owner verifies in a way the gathering does not use
+	ErrVerificationNotUsed = errors.New("the gathering does not use this verification")
+	// ErrVerificationRequired is returned when a ballot is submitted without a verified session
+	ErrVerificationRequired = errors.New("verification required")
+	// ErrVerificationFailed is returned for a wrong or expired code or PIN
+	ErrVerificationFailed = errors.New("wrong or expired code")
+	// ErrVerificationLocked is returned once an invitation had too many failed attempts
+	ErrVerificationLocked = errors.New("too many failed attempts")
+	// ErrVerificationCodeTooSoon is returned when another code is asked for right after one was sent
+	ErrVerificationCodeTooSoon = errors.New("a code was sent less than a minute ago")
+	// ErrVerificationChannel is returned for a channel the owner has no contact for
+	ErrVerificationChannel = errors.New("owner has no contact for this channel")
+	// ErrVotingNotOpen is returned when a code is asked for while the gathering takes no votes
+	ErrVotingNotOpen = errors.New("voting is not open")
+)
+
+// MemberVerificationService handles the second factor of owners voting with their invitation
+// link: a one-time code sent through one of the senders, or a PIN handed out with the invitation.
+// Codes and PINs are stored as hashes keyed with the API secret; a verified code or PIN opens a
+// short-lived session, which the ballot is submitted with.
+type MemberVerificationService struct {
+	db      *database.Queries
+	secret  []byte
+	i18n    *I18nService
+	senders map[string]notify.Transport // By channel: email, sms
+}
+
+// NewMemberVerificationService creates a new MemberVerificationService sending codes through
+// the given senders, by channel
+func NewMemberVerificationService(db *database.Queries, secret string, i18n *I18nService, senders map[string]notify.Transport) *MemberVerificationService {
+	return &MemberVerificationService{
+		db:      db,
+		secret:  []byte(secret),
+		i18n:    i18n,
+		senders: senders,
+	}
+}
+
+// Settings returns the verification a gathering requires
+func (s *MemberVerificationService) Settings(ctx context.Context, gathering database.Gathering) (domain.MemberVerificationSettings, error) {
+	withoutPIN, err := s.db.CountInvitationsWithoutPIN(ctx, gathering.ID)
+	if err != nil {
+		return domain.MemberVerificationSettings{}, fmt.Errorf("failed to count invitations without PIN: %w", err)
+	}
+	return domain.MemberVerificationSettings{
+		GatheringID:           gathering.ID,
+		Method:                gathering.MemberVerification,
+		InvitationsWithoutPIN: withoutPIN,
+	}, nil
+}
+
+// SetMethod sets the verification a gathering requires. It can change until voting opens;
+// invitations issued before a PIN is required have none and are to be reissued.
+func (s *MemberVerificationService) SetMethod(ctx context.Context, gathering database.Gathering, method string) (domain.MemberVerificationSettings, error) {
+	if !slices.Contains(MemberVerificationMethods, method) {
+		return domain.MemberVerificationSettings{}, fmt.Errorf("%w: expected one of %s", ErrInvalidVerificationMethod, strings.Join(MemberVerificationMethods, ", "))
+	}
+	if gathering.Status != GatheringStatusDraft && gathering.Status != GatheringStatusPublished {
+		return domain.MemberVerificationSettings{}, ErrVerificationMethodFixed
+	}
+	err := s.db.SetGatheringMemberVerification(ctx, database.SetGatheringMemberVerificationParams{
+		MemberVerification: method,
+		ID:                 gathering.ID,
+	})
+	if err != nil {
+		return domain.MemberVerificationSettings{}, fmt.Errorf("failed to set verification method: %w", err)
+	}
+	gathering.MemberVerification = method
+	return s.Settings(ctx, gathering)
+}
+
+// IssuePIN generates the PIN of a new invitation, to hand out with it. The plaintext PIN is
+// returned once and never stored.
+func (s *MemberVerificationService) IssuePIN(ctx context.Context, qtx *database.Queries, inv database.MemberInvitation) (string, error) {
+	pin, err := newVerificationSecret()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate PIN: %w", err)
+	}
+	err = qtx.SetMemberPIN(ctx, database.SetMemberPINParams{
+		InvitationID: inv.ID,
+		PinHash:      sql.NullString{String: s.hash(inv, MemberVerificationPIN, pin), Valid: true},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to store PIN: %w", err)
+	}
+	return pin, nil
+}
+
+// Status reports the verification the owner of an invitation has to give, and whether the
+// session sent along is still open
+func (s *MemberVerificationService) Status(ctx context.Context, inv database.MemberInvitation, sessionToken string, now time.Time) (domain.MemberVerificationStatus, error) {
+	gathering, err := s.db.GetGatheringByID(ctx, inv.GatheringID)
+	if err != nil {
+		return domain.MemberVerificationStatus{}, fmt.Errorf("failed to get gathering: %w", err)
+	}
+	status := domain.MemberVerificationStatus{Method: gathering.MemberVerification, AttemptsLeft: MaxVerificationAttempts}
+	if gathering.MemberVerification == MemberVerificationNone {
+		status.Verified = true
+		return status, nil
+	}
+
+	v, err := s.verification(ctx, inv)
+	if err != nil {
+		return domain.MemberVerificationStatus{}, err
+	}
+	status.Locked = v.LockedAt.Valid
+	status.AttemptsLeft = max(MaxVerificationAttempts-v.FailedAttempts, 0)
+	if err := s.openSession(ctx, inv, sessionToken, now); err == nil {
+		status.Verified = true
+	} else if !errors.Is(err, ErrVerificationRequired) {
+		return domain.MemberVerificationStatus{}, err
+	}
+
+	if gathering.MemberVerification == MemberVerificationCode {
+		owner, err := s.db.GetOwnerById(ctx, inv.OwnerID)
+		if err != nil {
+			return domain.MemberVerificationStatus{}, fmt.Errorf("failed to get owner: %w", err)
+		}
+		status.Channels = verificationChannels(owner)
+	}
+	return status, nil
+}
+
+// SendCode sends the owner of an invitation a one-time code, replacing any sent before
+func (s *MemberVerificationService) SendCode(ctx context.Context, inv database.MemberInvitation, req domain.VerificationCodeRequest, now time.Time) (domain.VerificationCodeSent, error) {
+	gathering, err := s.db.GetGatheringByID(ctx, inv.GatheringID)
+	if err != nil {
+		return domain.VerificationCodeSent{}, fmt.Errorf("failed to get gathering: %w", err)
+	}
+	if gathering.MemberVerification != MemberVerificationCode {
+		return domain.VerificationCodeSent{}, ErrVerificationNotUsed
+	}
+	if gathering.Status != GatheringStatusActive {
+		return domain.VerificationCodeSent{}, ErrVotingNotOpen
+	}
+
+	v, err := s.verification(ctx, inv)
+	if err != nil {
+		return domain.VerificationCodeSent{}, err
+	}
+	if v.LockedAt.Valid {
+		return domain.VerificationCodeSent{}, ErrVerificationLocked
+	}
+	if v.CodeSentAt.Valid && now.Sub(v.CodeSentAt.Time) < verificationCodeInterval {
+		return domain.VerificationCodeSent{}, ErrVerificationCodeTooSoon
+	}
+
+	owner, err := s.db.GetOwnerById(ctx, inv.OwnerID)
+	if err != nil {
+		return domain.VerificationCodeSent{}, fmt.Errorf("failed to get owner: %w", err)
+	}
+	to, sender := contact(owner, req.Channel), s.senders[req.Channel]
+	if to == "" || sender == nil {
+		return domain.VerificationCodeSent{}, ErrVerificationChannel
+	}
+
+	code, err := newVerificationSecret()
+	if err != nil {
+		return domain.VerificationCodeSent{}, fmt.Errorf("failed to generate code: %w", err)
+	}
+	expiresAt := now.Add(VerificationCodeTTL).UTC()
+	err = s.db.SetMemberCode(ctx, database.SetMemberCodeParams{
+		InvitationID:  inv.ID,
+		CodeHash:      sql.NullString{String: s.hash(inv, MemberVerificationCode, code), Valid: true},
+		CodeChannel:   sql.NullString{String: req.Channel, Valid: true},
+		CodeExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+		CodeSentAt:    sql.NullTime{Time: now.UTC(), Valid: true},
+	})
+	if err != nil {
+		return domain.VerificationCodeSent{}, fmt.Errorf("failed to store code: %w", err)
+	}
+
+	err = sender.Send(ctx, notify.Message{
+		To:      to,
+		Subject: s.i18n.Translate(KeyVerificationSubject, req.Lang),
+		Body:    fmt.Sprintf(s.i18n.Translate(KeyVerificationBody, req.Lang), gathering.Title, code, int(VerificationCodeTTL/time.Minute)),
+	})
+	if err != nil {
+		return domain.VerificationCodeSent{}, fmt.Errorf("failed to send code: %w", err)
+	}
+	return domain.VerificationCodeSent{
+		VerificationChannel: domain.VerificationChannel{Channel: req.Channel, Destination: maskContact(req.Channel, to)},
+		ExpiresAt:           expiresAt,
+	}, nil
+}
+
+// Verify checks the code or PIN of an invitation and opens a session for its owner, closing
+// any opened before. Every attempt is reserved before the comparison, so that concurrent guesses
+// cannot go past MaxVerificationAttempts; the invitation is locked once they reach it, and a
+// successful attempt resets the count.
+func (s *MemberVerificationService) Verify(ctx context.Context, inv database.MemberInvitation, code string, now time.Time) (domain.MemberSession, error) {
+	gathering, err := s.db.GetGatheringByID(ctx, inv.GatheringID)
+	if err != nil {
+		return domain.MemberSession{}, fmt.Errorf("failed to get gathering: %w", err)
+	}
+	method := gathering.MemberVerification
+	if method == MemberVerificationNone {
+		return domain.MemberSession{}, ErrVerificationNotUsed
+	}
+
+	// No attempt is left to reserve once the invitation is locked or out of attempts
+	v, err := s.db.ReserveVerificationAttempt(ctx, database.ReserveVerificationAttemptParams{
+		InvitationID: inv.ID,
+		MaxAttempts:  MaxVerificationAttempts,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.MemberSession{}, ErrVerificationLocked
+	}
+	if err != nil {
+		return domain.MemberSession{}, fmt.Errorf("failed to record attempt: %w", err)
+	}
+
+	if !matchSecret(v, method, s.hash(inv, method, normalizeSecret(code)), now) {
+		if v.FailedAttempts < MaxVerificationAttempts {
+			return domain.MemberSession{}, fmt.Errorf("%w, attempts left: %d", ErrVerificationFailed, MaxVerificationAttempts-v.FailedAttempts)
+		}
+		if err := s.lock(ctx, inv, now); err != nil {
+			return domain.MemberSession{}, err
+		}
+		return domain.MemberSession{}, ErrVerificationLocked
+	}
+
+	// Guesses made alongside may have locked the invitation meanwhile
+	completed, err := s.db.CompleteMemberVerification(ctx, inv.ID)
+	if err != nil {
+		return domain.MemberSession{}, fmt.Errorf("failed to complete verification: %w", err)
+	}
+	if completed == 0 {
+		return domain.MemberSession{}, ErrVerificationLocked
+	}
+	token, tokenHash, err := NewMemberToken()
+	if err != nil {
+		return domain.MemberSession{}, fmt.Errorf("failed to generate session: %w", err)
+	}
+	if err := s.db.DeleteMemberSessions(ctx, inv.ID); err != nil {
+		return domain.MemberSession{}, fmt.Errorf("failed to close sessions: %w", err)
+	}
+	expiresAt := now.Add(MemberSessionTTL).UTC()
+	err = s.db.CreateMemberSession(ctx, database.CreateMemberSessionParams{
+		InvitationID: inv.ID,
+		TokenHash:    tokenHash,
+		ExpiresAt:    expiresAt,
+	})
+	if err != nil {
+		return domain.MemberSession{}, fmt.Errorf("failed to open session: %w", err)
+	}
+	return domain.MemberSession{SessionToken: token, ExpiresAt: expiresAt}, nil
+}
+
+// Authorize checks that the owner of an invitation may submit a ballot: the gathering requires
+// no verification, or the session sent along is open
+func (s *MemberVerificationService) Authorize(ctx context.Context, inv database.MemberInvitation, sessionToken string, now time.Time) error {
+	gathering, err := s.db.GetGatheringByID(ctx, inv.GatheringID)
+	if err != nil {
+		return fmt.Errorf("failed to get gathering: %w", err)
+	}
+	if gathering.MemberVerification == MemberVerificationNone {
+		return nil
+	}
+	return s.openSession(ctx, inv, sessionToken, now)
+}
+
+// openSession checks that a session of the invitation is open
+func (s *MemberVerificationService) openSession(ctx context.Context, inv database.MemberInvitation, sessionToken string, now time.Time) error {
+	if sessionToken == "" {
+		return ErrVerificationRequired
+	}
+	hash := sha256.Sum256([]byte(sessionToken))
+	session, err := s.db.GetMemberSession(ctx, database.GetMemberSessionParams{
+		TokenHash:    hex.EncodeToString(hash[:]),
+		InvitationID: inv.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrVerificationRequired
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	if !now.Before(session.ExpiresAt) {
+		return ErrVerificationRequired
+	}
+	return nil
+}
+
+// lock locks an invitation and closes its sessions
+func (s *MemberVerificationService) lock(ctx context.Context, inv database.MemberInvitation, now time.Time) error {
+	err := s.db.LockMemberVerification(ctx, database.LockMemberVerificationParams{
+		LockedAt:     sql.NullTime{Time: now.UTC(), Valid: true},
+		InvitationID: inv.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to lock invitation: %w", err)
+	}
+	if err := s.db.DeleteMemberSessions(ctx, inv.ID); err != nil {
+		return fmt.Errorf("failed to close sessions: %w", err)
+	}
+	logging.Logger.Log(zap.WarnLevel, "Member invitation locked after failed verification attempts",
+		zap.Int64("invitation_id", inv.ID),
+		zap.Int64("gathering_id", inv.GatheringID))
+	return nil
+}
+
+// verification returns the verification state of an invitation, empty when it has none yet
+func (s *MemberVerificationService) verification(ctx context.Context, inv database.MemberInvitation) (database.MemberVerification, error) {
+	v, err := s.db.GetMemberVerification(ctx, inv.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.MemberVerification{InvitationID: inv.ID}, nil
+	}
+	if err != nil {
+		return database.MemberVerification{}, fmt.Errorf("failed to get verification: %w", err)
+	}
+	return v, nil
+}
+
+// hash keys a code or PIN with the API secret, so the short secrets cannot be guessed from the
+// database alone. PINs are bound to the owner and gathering, so they carry over to the links
+// reissued to the owner.
+func (s *MemberVerificationService) hash(inv database.MemberInvitation, method, secret string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s:%d:%d:%s", method, inv.GatheringID, inv.OwnerID, secret)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// matchSecret reports whether a hashed secret matches the invitation's PIN, or its one-time
+// code while that is valid
+func matchSecret(v database.MemberVerification, method, hashed string, now time.Time) bool {
+	switch method {
+	case MemberVerificationPIN:
+		return v.PinHash.Valid && hmac.Equal([]byte(v.PinHash.String), []byte(hashed))
+	case MemberVerificationCode:
+		return v.CodeHash.Valid && v.CodeExpiresAt.Valid && now.Before(v.CodeExpiresAt.Time) &&
+			hmac.Equal([]byte(v.CodeHash.String), []byte(hashed))
+	}
+	return false
+}
+
+// newVerificationSecret generates a random numeric code or PIN
+func newVerificationSecret() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", verificationDigits, n.Int64()), nil
+}
+
+// normalizeSecret drops the spaces and dashes owners may type a code or PIN with
+func normalizeSecret(code string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code))
+}
+
+// contact returns the owner's address for a channel, empty if there is none
+func contact(owner database.Owner, channel string) string {
+	switch channel {
+	case VerificationChannelEmail:
+		return strings.TrimSpace(owner.ContactEmail)
+	case VerificationChannelSMS:
+		return strings.TrimSpace(owner.ContactPhone)
+	}
+	return ""
+}
+
+// verificationChannels lists the channels a code can be sent to the owner over
+func verificationChannels(owner database.Owner) []domain.VerificationChannel {
+	var channels []domain.VerificationChannel
+	for _, channel := range []string{VerificationChannelEmail, VerificationChannelSMS} {
+		if to := contact(owner, channel); to != "" {
+			channels = append(channels, domain.VerificationChannel{Channel: channel, Destination: maskContact(channel, to)})
+		}
+	}
+	return channels
+}
+
+// maskContact hides most of an address, enough for the owner to recognize it: the first letter
+// of an email's local part, the last three digits of a phone number
+func maskContact(channel, to string) string {
+	if channel == VerificationChannelEmail {
+		local, domainPart, ok := strings.Cut(to, "@")
+		if !ok || local == "" {
+			return "***"
+		}
+		first := []rune(local)[0]
+		return string(first) + "***@" + domainPart
+	}
+	digits := []rune(strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, to))
+	if len(digits) <= 3 {
+		return "***"
+	}
+	return strings.Repeat("*", len(digits)-3) + string(digits[len(digits)-3:])
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+)
+
+// TestMatchSecret tests that PINs match while set and codes only until they expire, each hashed
+// for its own gathering and owner
+func TestMatchSecret(t *testing.T) {
+	s := NewMemberVerificationService(nil, "secret", nil, nil)
+	inv := database.MemberInvitation{ID: 7, GatheringID: 3, OwnerID: 11}
+	reissued := database.MemberInvitation{ID: 8, GatheringID: 3, OwnerID: 11}
+	otherOwner := database.MemberInvitation{ID: 9, GatheringID: 3, OwnerID: 12}
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	hashed := func(inv database.MemberInvitation, method, secret string) sql.NullString {
+		return sql.NullString{String: s.hash(inv, method, secret), Valid: true}
+	}
+	withPIN := database.MemberVerification{PinHash: hashed(inv, MemberVerificationPIN, "123456")}
+	withCode := database.MemberVerification{
+		CodeHash:      hashed(inv, MemberVerificationCode, "654321"),
+		CodeExpiresAt: sql.NullTime{Time: now.Add(time.Minute), Valid: true},
+	}
+
+	tests := []struct {
+		name     string
+		v        database.MemberVerification
+		method   string
+		inv      database.MemberInvitation
+		secret   string
+		now      time.Time
+		expected bool
+	}{
+		{"right PIN", withPIN, MemberVerificationPIN, inv, "123456", now, true},
+		{"PIN typed with spaces", withPIN, MemberVerificationPIN, inv, " 123 456 ", now, true},
+		{"PIN of a reissued link", withPIN, MemberVerificationPIN, reissued, "123456", now, true},
+		{"wrong PIN", withPIN, MemberVerificationPIN, inv, "123457", now, false},
+		{"PIN of another owner", withPIN, MemberVerificationPIN, otherOwner, "123456", now, false},
+		{"no PIN issued", database.MemberVerification{}, MemberVerificationPIN, inv, "123456", now, false},
+		{"PIN given for a code", withPIN, MemberVerificationCode, inv, "123456", now, false},
+		{"right code", withCode, MemberVerificationCode, inv, "654321", now, true},
+		{"code typed with a dash", withCode, MemberVerificationCode, inv, "654-321", now, true},
+		{"expired code", withCode, MemberVerificationCode, inv, "654321", now.Add(time.Minute), false},
+		{"wrong code", withCode, MemberVerificationCode, inv, "000000", now, false},
+		{"no verification", withCode, MemberVerificationNone, inv, "654321", now, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchSecret(tt.v, tt.method, s.hash(tt.inv, tt.method, normalizeSecret(tt.secret)), tt.now)
+			if got != tt.expected {
+				t.Errorf("matchSecret() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestNewVerificationSecret tests that codes and PINs are six digits
+func TestNewVerificationSecret(t *testing.T) {
+	for range 100 {
+		secret, err := newVerificationSecret()
+		if err != nil {
+			t.Fatalf("newVerificationSecret() unexpected error: %v", err)
+		}
+		if len(secret) != verificationDigits || normalizeSecret(secret) != secret {
+			t.Fatalf("newVerificationSecret() = %q, expected %d digits", secret, verificationDigits)
+		}
+		for _, r := range secret {
+			if r < '0' || r > '9' {
+				t.Fatalf("newVerificationSecret() = %q, expected digits only", secret)
+			}
+		}
+	}
+}
+
+// TestVerificationChannels tests that codes are offered over the channels the owner has a
+// contact for, with the contact masked
+func TestVerificationChannels(t *testing.T) {
+	tests := []struct {
+		name     string
+		owner    database.Owner
+		expected []string
+	}{
+		{"both", database.Owner{ContactEmail: "ion@example.com", ContactPhone: "+373 69 123 456"}, []string{"email:i***@example.com", "sms:********456"}},
+		{"email only", database.Owner{ContactEmail: " maria@example.md "}, []string{"email:m***@example.md"}},
+		{"short phone", database.Owner{ContactPhone: "112"}, []string{"sms:***"}},
+		{"none", database.Owner{ContactPhone: "  "}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channels := verificationChannels(tt.owner)
+			if len(channels) != len(tt.expected) {
+				t.Fatalf("verificationChannels() = %v, expected %v", channels, tt.expected)
+			}
+			for i, c := range channels {
+				if got := c.Channel + ":" + c.Destination; got != tt.expected[i] {
+					t.Errorf("channel %d = %q, expected %q", i, got, tt.expected[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/office"
+)
+
+// ErrInvalidMinutesTemplate is returned for uploaded minutes templates that cannot be filled
+var ErrInvalidMinutesTemplate = errors.New("invalid minutes template")
+
+// MinutesTextPlaceholders are the placeholders of a minutes template replaced by text
+var MinutesTextPlaceholders = []string{
+	"association.name",
+	"association.address",
+	"association.administrator",
+	"gathering.title",
+	"gathering.description",
+	"gathering.date",
+	"gathering.location",
+	"gathering.type",
+	"gathering.voting_mode",
+	"gathering.repeats",
+	"participation.qualified_units",
+	"participation.qualified_weight",
+	"participation.participating_units",
+	"participation.participating_weight",
+	"participation.voted_units",
+	"participation.voted_weight",
+	"participation.rate",
+	"quorum.required_percentage",
+	"quorum.achieved_percentage",
+	"quorum.status",
+	"minutes.date",
+}
+
+// MinutesBlockPlaceholders are the placeholders of a minutes template that, alone in their
+// paragraph, are replaced by generated paragraphs and tables
+var MinutesBlockPlaceholders = []string{
+	"attendance", // Participants with the units they vote for
+	"quorum",     // Quorum threshold, participation achieved and whether the quorum is met
+	"agenda",     // Numbered titles of the matters
+	"matters",    // Tally, required majority and result of every matter
+	"signatures", // Signature lines of the chair and the secretary
+}
+
+// minutesDateFormat is how the date the minutes are drawn up on is printed
+const minutesDateFormat = "02.01.2006"
+
+// MinutesService produces the minutes of gatherings, filling the association's minutes template
+// or, when it has none, the default one
+type MinutesService struct {
+	db        *database.Queries
+	i18n      *I18nService
+	documents *DocumentService
+}
+
+// NewMinutesService creates a new MinutesService
+func NewMinutesService(db *database.Queries, i18n *I18nService, documents *DocumentService) *MinutesService {
+	return &MinutesService{
+		db:        db,
+		i18n:      i18n,
+		documents: documents,
+	}
+}
+
+// DefaultTemplate lays out the default minutes template in a language. Associations can
+// download it, reword it as their statute requires and upload it as their own.
+func (s *MinutesService) DefaultTemplate(format office.Format, lang string) ([]byte, error) {
+	t := func(key string) string { return s.i18n.Translate(key, lang) }
+	heading := func(number int, key string) office.Paragraph {
+		return office.Paragraph{Text: fmt.Sprintf("%d. %s", number, t(key)), Heading: true}
+	}
+
+	return office.New(format, t(KeyMinutesTitle), []office.Block{
+		office.Paragraph{Text: "{{association.name}}", Bold: true, Align: office.AlignCenter},
+		office.Paragraph{Text: "{{association.address}}", Align: office.AlignCenter},
+		office.Paragraph{Text: strings.ToUpper(t(KeyMinutesTitle)), Heading: true, Align: office.AlignCenter},
+		office.Paragraph{Text: t(KeyMinutesSubtitle), Align: office.AlignCenter},
+		office.Paragraph{Text: "{{gathering.title}}", Bold: true, Align: office.AlignCenter},
+		office.Paragraph{Text: t(KeyMinutesOpening) + " {{gathering.repeats}}"},
+		heading(1, KeyMinutesAttendance),
+		office.Paragraph{Text: t(KeyMinutesAttendanceText)},
+		office.Paragraph{Text: "{{attendance}}"},
+		heading(2, KeyQuorumInfo),
+		office.Paragraph{Text: "{{quorum}}"},
+		heading(3, KeyMinutesAgenda),
+		office.Paragraph{Text: "{{agenda}}"},
+		heading(4, KeyMinutesResults),
+		office.Paragraph{Text: "{{matters}}"},
+		office.Paragraph{Text: t(KeyMinutesClosing)},
+		office.Paragraph{Text: "{{signatures}}"},
+	})
+}
+
+// ValidateMinutesTemplate checks that an uploaded minutes template is a DOCX or ODT document
+// whose placeholders are all known, and returns its format
+func ValidateMinutesTemplate(template []byte) (office.Format, error) {
+	format, err := office.Detect(template)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidMinutesTemplate, err)
+	}
+	placeholders, err := office.Placeholders(template)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidMinutesTemplate, err)
+	}
+
+	var unknown []string
+	for _, name := range placeholders {
+		if !slices.Contains(MinutesTextPlaceholders, name) && !slices.Contains(MinutesBlockPlaceholders, name) {
+			unknown = append(unknown, "{{"+name+"}}")
+		}
+	}
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("%w: unknown placeholders %s", ErrInvalidMinutesTemplate, strings.Join(unknown, ", "))
+	}
+	return format, nil
+}
+
+// Render produces the minutes of a tallied gathering. The association's template sets the
+// format of the minutes; without one the default template is used in the requested format.
+func (s *MinutesService) Render(ctx context.Context, gathering database.Gathering, format office.Format, lang string) ([]byte, office.Format, error) {
+	var template []byte
+	stored, err := s.db.GetMinutesTemplate(ctx, gathering.AssociationID)
+	switch {
+	case err == nil:
+		template = stored.Content
+	case errors.Is(err, sql.ErrNoRows):
+		if template, err = s.DefaultTemplate(format, lang); err != nil {
+			return nil, "", fmt.Errorf("failed to lay out the default minutes template: %w", err)
+		}
+	default:
+		return nil, "", fmt.Errorf("failed to get minutes template: %w", err)
+	}
+
+	fields, err := s.fields(ctx, gathering, lang)
+	if err != nil {
+		return nil, "", err
+	}
+	return office.Fill(template, fields)
+}
+
+// fields gathers the values of the placeholders for a gathering
+func (s *MinutesService) fields(ctx context.Context, gathering database.Gathering, lang string) (office.Fields, error) {
+	t := func(key string) string { return s.i18n.Translate(key, lang) }
+
+	results, err := s.documents.votingResultsService.GetCachedResults(ctx, gathering.ID, gathering.AssociationID)
+	if err != nil {
+		return office.Fields{}, err
+	}
+	matters, err := s.db.GetVotingMatters(ctx, gathering.ID)
+	if err != nil {
+		return office.Fields{}, fmt.Errorf("failed to get voting matters: %w", err)
+	}
+	participants, err := s.db.GetGatheringParticipants(ctx, gathering.ID)
+	if err != nil {
+		return office.Fields{}, fmt.Errorf("failed to get participants: %w", err)
+	}
+	register, err := s.documents.voterRegisterService.Register(ctx, gathering)
+	if err != nil {
+		return office.Fields{}, err
+	}
+	association, err := s.db.GetAssociations(ctx, gathering.AssociationID)
+	if err != nil {
+		return office.Fields{}, fmt.Errorf("failed to get association: %w", err)
+	}
+
+	var repeats string
+	if header := s.documents.newHeader(ctx, gathering, lang); header.repeats != nil {
+		repeats = fmt.Sprintf(t(KeyGatheringRepeats), header.repeats.GatheringDate.Format(documentDateFormat)) + "."
+	}
+
+	summary := results.Summary
+	var rate float64
+	if summary.QualifiedWeight > 0 {
+		rate = summary.ParticipatingWeight / summary.QualifiedWeight * 100
+	}
+	text := map[string]string{
+		"association.name":                   association.Name,
+		"association.address":                association.Address,
+		"association.administrator":          association.Administrator,
+		"gathering.title":                    gathering.Title,
+		"gathering.description":              gathering.Description,
+		"gathering.date":                     gathering.GatheringDate.Format(documentDateFormat),
+		"gathering.location":                 gathering.Location,
+		"gathering.type":                     s.i18n.FormatGatheringType(gathering.GatheringType, lang),
+		"gathering.voting_mode":              s.i18n.FormatVotingMode(gathering.VotingMode, lang),
+		"gathering.repeats":                  repeats,
+		"participation.qualified_units":      fmt.Sprintf("%d", summary.QualifiedUnits),
+		"participation.qualified_weight":     fmt.Sprintf("%.4f", summary.QualifiedWeight),
+		"participation.participating_units":  fmt.Sprintf("%d", summary.ParticipatingUnits),
+		"participation.participating_weight": fmt.Sprintf("%.4f", summary.ParticipatingWeight),
+		"participation.voted_units":          fmt.Sprintf("%d", summary.VotedUnits),
+		"participation.voted_weight":         fmt.Sprintf("%.4f", summary.VotedWeight),
+		"participation.rate":                 fmt.Sprintf("%.2f%%", rate),
+		"quorum.required_percentage":         "",
+		"quorum.achieved_percentage":         "",
+		"quorum.status":                      "",
+		"minutes.date":                       time.Now().Format(minutesDateFormat),
+	}
+
+	matterResults, quorum := resultsByMatter(results)
+	var quorumBlocks []office.Block
+	if quorum != nil {
+		text["quorum.required_percentage"] = fmt.Sprintf("%.2f%%", quorum.RequiredPercentage)
+		text["quorum.achieved_percentage"] = fmt.Sprintf("%.2f%%", quorum.AchievedPercentage)
+		text["quorum.status"] = s.documents.quorumStatus(quorum, lang)
+		quorumBlocks = []office.Block{
+			office.Paragraph{Text: s.documents.quorumDetails(quorum, lang)},
+			office.Paragraph{Text: s.documents.quorumStatus(quorum, lang), Bold: true},
+		}
+	}
+
+	agenda := make([]office.Block, 0, len(matters))
+	for i, matter := range matters {
+		title := fmt.Sprintf("%d. %s", i+1, matterTitle(matter, lang))
+		if matter.IsInformative != 0 {
+			title += fmt.Sprintf(" (%s)", t(KeyResultsInformative))
+		}
+		agenda = append(agenda, office.Paragraph{Text: title})
+	}
+
+	return office.Fields{
+		Text: text,
+		Blocks: map[string][]office.Block{
+			"attendance": s.attendance(participants, register, lang),
+			"quorum":     quorumBlocks,
+			"agenda":     agenda,
+			"matters":    s.matters(matters, matterResults, lang),
+			"signatures": {office.Table{
+				Columns: []office.Column{{Width: 1}, {Width: 1}},
+				Rows: [][]string{
+					{t(KeySignatureChair), t(KeySignatureSecretary)},
+					{"", ""},
+					{"________________________", "________________________"},
+				},
+				Borderless: true,
+			}},
+		},
+	}, nil
+}
+
+// attendance lists the participants of the gathering, the owners they represent and the units
+// they vote for
+func (s *MinutesService) attendance(participants []database.GetGatheringParticipantsRow, register []domain.RegisterUnit, lang string) []office.Block {
+	t := func(key string) string { return s.i18n.Translate(key, lang) }
+	numbers := make(map[int64]string, len(register))
+	for _, unit := range register {
+		numbers[unit.UnitID] = unit.UnitNumber
+	}
+
+	var totalUnits int
+	var totalPart float64
+	rows := make([][]string, 0, len(participants))
+	for i, participant := range participants {
+		var unitIDs []int64
+		json.Unmarshal([]byte(participant.UnitsInfo), &unitIDs)
+		units := make([]string, len(unitIDs))
+		for j, unitID := range unitIDs {
+			units[j] = numbers[unitID]
+			if units[j] == "" {
+				units[j] = fmt.Sprintf("#%d", unitID)
+			}
+		}
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", i+1),
+			participant.ParticipantName,
+			participant.DelegatingOwnerName.String,
+			strings.Join(units, ", "),
+			fmt.Sprintf("%.4f", participant.UnitsPart),
+		})
+		totalUnits += len(unitIDs)
+		totalPart += participant.UnitsPart
+	}
+
+	return []office.Block{office.Table{
+		Columns: []office.Column{
+			{Header: t(KeyAttendanceNumber), Width: 0.6, Align: office.AlignRight},
+			{Header: t(KeyAttendanceParticipant), Width: 3},
+			{Header: t(KeyAttendanceRepresents), Width: 3},
+			{Header: t(KeyAttendanceUnits), Width: 1.8},
+			{Header: t(KeyStatisticsWeight), Width: 1.5, Align: office.AlignRight},
+		},
+		Rows:  rows,
+		Total: []string{"", t(KeyTotal), "", fmt.Sprintf("%d", totalUnits), fmt.Sprintf("%.4f", totalPart)},
+	}}
+}
+
+// matters describes every matter with its tally, the majority it required and its result
+func (s *MinutesService) matters(matters []database.VotingMatter, results map[int64]domain.VoteMatterResult, lang string) []office.Block {
+	t := func(key string) string { return s.i18n.Translate(key, lang) }
+	var blocks []office.Block
+	for i, matter := range matters {
+		blocks = append(blocks, office.Paragraph{Text: fmt.Sprintf("%d. %s", i+1, matterTitle(matter, lang)), Bold: true})
+		if description := matterDescription(matter, lang); description != "" {
+			blocks = append(blocks, office.Paragraph{Text: description})
+		}
+		if matter.IsInformative != 0 {
+			blocks = append(blocks, office.Paragraph{Text: t(KeyResultsInformative)})
+			continue
+		}
+
+		outcome := s.documents.matterOutcome(matter, results[matter.ID], lang)
+		blocks = append(blocks, office.Paragraph{Text: outcome.details})
+		if outcome.attendance != "" {
+			blocks = append(blocks, office.Paragraph{Text: outcome.attendance})
+		}
+		if outcome.ranking {
+			blocks = append(blocks, office.Paragraph{Text: outcome.orderHeading + ":\n" + strings.Join(outcome.order, "\n")})
+			if outcome.winner != "" {
+				blocks = append(blocks, office.Paragraph{Text: outcome.winner})
+			}
+		} else {
+			blocks = append(blocks, office.Table{
+				Columns: []office.Column{
+					{Header: t(KeyResultsOption), Width: 5},
+					{Header: t(KeyResultsVotes), Width: 1.5, Align: office.AlignRight},
+					{Header: t(KeyStatisticsWeight), Width: 2, Align: office.AlignRight},
+					{Header: t(KeyResultsWeightPercentage), Width: 2, Align: office.AlignRight},
+				},
+				Rows: outcome.rows,
+			})
+		}
+		blocks = append(blocks, office.Paragraph{Text: outcome.result, Bold: true})
+	}
+	return blocks
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/alexmarian/apc/api/office"
+)
+
+// TestDefaultMinutesTemplate tests that the default minutes template only uses known
+// placeholders and generates every part of the minutes, in every language and format
+func TestDefaultMinutesTemplate(t *testing.T) {
+	i18n, err := NewI18nService()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewMinutesService(nil, i18n, nil)
+
+	for _, lang := range SupportedLanguages {
+		for _, format := range []office.Format{office.DOCX, office.ODT} {
+			t.Run(lang+"/"+string(format), func(t *testing.T) {
+				template, err := s.DefaultTemplate(format, lang)
+				if err != nil {
+					t.Fatalf("DefaultTemplate() unexpected error: %v", err)
+				}
+				detected, err := ValidateMinutesTemplate(template)
+				if err != nil {
+					t.Fatalf("ValidateMinutesTemplate() unexpected error: %v", err)
+				}
+				if detected != format {
+					t.Errorf("ValidateMinutesTemplate() = %q, want %q", detected, format)
+				}
+
+				placeholders, _ := office.Placeholders(template)
+				for _, name := range MinutesBlockPlaceholders {
+					if !slices.Contains(placeholders, name) {
+						t.Errorf("{{%s}} not in the template", name)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestValidateMinutesTemplate tests that templates with unknown placeholders are rejected
+func TestValidateMinutesTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr bool
+	}{
+		{name: "known placeholders", text: "{{gathering.title}} on {{ gathering.date }}"},
+		{name: "no placeholders", text: "Minutes"},
+		{name: "unknown placeholder", text: "{{gathering.chair}}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template, err := office.New(office.DOCX, "Minutes", []office.Block{office.Paragraph{Text: tt.text}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = ValidateMinutesTemplate(template)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMinutesTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidMinutesTemplate) {
+				t.Errorf("ValidateMinutesTemplate() error = %v, want ErrInvalidMinutesTemplate", err)
+			}
+		})
+	}
+
+	if _, err := ValidateMinutesTemplate([]byte("%PDF-1.4")); !errors.Is(err, ErrInvalidMinutesTemplate) {
+		t.Errorf("ValidateMinutesTemplate(PDF) error = %v, want ErrInvalidMinutesTemplate", err)
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"github.com/alexmarian/apc/api/notify"
+	"go.uber.org/zap"
+)
+
+// DefaultDispatchInterval is how often the dispatcher looks for messages due in the outbox
+const DefaultDispatchInterval = time.Minute
+
+// MaxDeliveryAttempts is how many times a message is tried before it is given up on
+const MaxDeliveryAttempts = 8
+
+// dispatchBatchSize bounds the messages sent in one run, the rest wait for the next
+const dispatchBatchSize = 50
+
+// Outbox message statuses
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusFailed  = "failed"
+)
+
+// NotificationDispatcher delivers the messages of the notification outbox, retrying failed
+// deliveries with exponential backoff
+type NotificationDispatcher struct {
+	db        *database.Queries
+	transport notify.Transport
+	interval  time.Duration
+	wake      chan struct{}
+}
+
+// NewNotificationDispatcher creates a new NotificationDispatcher
+func NewNotificationDispatcher(db *database.Queries, transport notify.Transport, interval time.Duration) *NotificationDispatcher {
+	if interval <= 0 {
+		interval = DefaultDispatchInterval
+	}
+	return &NotificationDispatcher{
+		db:        db,
+		transport: transport,
+		interval:  interval,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Start runs the dispatcher in the background until ctx is cancelled
+func (d *NotificationDispatcher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		d.RunOnce(ctx, time.Now())
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				d.RunOnce(ctx, now)
+			case <-d.wake:
+				d.RunOnce(ctx, time.Now())
+			}
+		}
+	}()
+}
+
+// Wake makes a started dispatcher deliver newly queued messages without waiting for its next run
+func (d *NotificationDispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// RunOnce tries to deliver the messages that are due at the given time
+func (d *NotificationDispatcher) RunOnce(ctx context.Context, now time.Time) {
+	messages, err := d.db.GetDueOutboxMessages(ctx, database.GetDueOutboxMessagesParams{
+		Now:       now.UTC(),
+		BatchSize: dispatchBatchSize,
+	})
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error getting due outbox messages", zap.Error(err))
+		return
+	}
+	for _, msg := range messages {
+		d.deliver(ctx, msg, now)
+	}
+}
+
+func (d *NotificationDispatcher) deliver(ctx context.Context, msg database.NotificationOutbox, now time.Time) {
+	err := d.transport.Send(ctx, notify.Message{To: msg.Recipient, Subject: msg.Subject, Body: msg.Body})
+	if err == nil {
+		err = d.db.MarkOutboxMessageSent(ctx, database.MarkOutboxMessageSentParams{
+			SentAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+			ID:     msg.ID,
+		})
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error marking outbox message sent", zap.Int64("outbox_id", msg.ID), zap.Error(err))
+		}
+		return
+	}
+
+	attempt := msg.Attempts + 1
+	lastError := sql.NullString{String: err.Error(), Valid: true}
+	if errors.Is(err, notify.ErrRejected) || attempt >= MaxDeliveryAttempts {
+		logging.Logger.Log(zap.WarnLevel, "Notification delivery given up",
+			zap.Int64("outbox_id", msg.ID),
+			zap.Int64("attempts", attempt),
+			zap.Error(err))
+		err = d.db.FailOutboxMessage(ctx, database.FailOutboxMessageParams{LastError: lastError, ID: msg.ID})
+	} else {
+		logging.Logger.Log(zap.InfoLevel, "Notification delivery failed, will retry",
+			zap.Int64("outbox_id", msg.ID),
+			zap.Int64("attempts", attempt),
+			zap.Error(err))
+		err = d.db.RetryOutboxMessage(ctx, database.RetryOutboxMessageParams{
+			LastError:     lastError,
+			NextAttemptAt: now.UTC().Add(RetryBackoff(attempt)),
+			ID:            msg.ID,
+		})
+	}
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error recording outbox delivery failure", zap.Int64("outbox_id", msg.ID), zap.Error(err))
+	}
+}
+
+// RetryBackoff is how long to wait before retrying a message that failed its n-th attempt:
+// a minute after the first, doubling after each further one, at most six hours
+func RetryBackoff(attempt int64) time.Duration {
+	const maxBackoff = 6 * time.Hour
+	backoff := time.Minute
+	for i := int64(1); i < attempt; i++ {
+		backoff *= 2
+		if backoff >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return backoff
+}
@@ -0,0 +1,368 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// emails holds a <lang>.tmpl file per supported language, defining the <type>.subject and
+// <type>.body templates of every notification type
+//
+//go:embed emails/*.tmpl
+var emails embed.FS
+
+// Notification types
+const (
+	NotificationInvitation = "invitation"
+	NotificationReminder   = "reminder"
+	NotificationResults    = "results"
+)
+
+// NotificationTypes are the notifications owners can be sent
+var NotificationTypes = []string{NotificationInvitation, NotificationReminder, NotificationResults}
+
+var (
+	// ErrUnknownNotificationType is returned for notification types other than NotificationTypes
+	ErrUnknownNotificationType = errors.New("unknown notification type")
+	// ErrNotificationNotDue is returned when a notification does not fit the gathering's status,
+	// such as results before the gathering is tallied
+	ErrNotificationNotDue = errors.New("notification does not fit the gathering's status")
+)
+
+// noEmailAddress is recorded for owners a notification cannot be emailed to
+const noEmailAddress = "owner has no email address"
+
+// NotificationService renders the emails of notifications and queues them in the outbox, from
+// which the NotificationDispatcher delivers them
+type NotificationService struct {
+	db                   *database.Queries
+	conn                 *sql.DB
+	voterRegisterService *VoterRegisterService
+	votingResultsService *VotingResultsService
+	dispatcher           *NotificationDispatcher
+	memberOrigin         string // Where the member app is served, without a trailing slash
+	templates            map[string]*template.Template
+}
+
+// NewNotificationService creates a new NotificationService. Emails link to the member app at
+// memberOrigin, or carry no link when it is empty.
+func NewNotificationService(db *database.Queries, conn *sql.DB, votingResultsService *VotingResultsService, dispatcher *NotificationDispatcher, memberOrigin string) (*NotificationService, error) {
+	service := &NotificationService{
+		db:                   db,
+		conn:                 conn,
+		voterRegisterService: NewVoterRegisterService(db),
+		votingResultsService: votingResultsService,
+		dispatcher:           dispatcher,
+		memberOrigin:         strings.TrimSuffix(memberOrigin, "/"),
+		templates:            make(map[string]*template.Template),
+	}
+
+	funcs := template.FuncMap{"inc": func(i int) int { return i + 1 }}
+	for _, lang := range SupportedLanguages {
+		t, err := template.New(lang).Funcs(funcs).ParseFS(emails, "emails/"+lang+".tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s email templates: %w", lang, err)
+		}
+		service.templates[lang] = t
+	}
+	return service, nil
+}
+
+// notificationEmail is what the email templates are executed with
+type notificationEmail struct {
+	OwnerName   string
+	Association string
+	Title       string
+	Date        string
+	Location    string
+	ClosesAt    string // When online voting closes, for remote gatherings
+	Link        string // The owner's personal link to the member app
+	QuorumMet   bool
+	Matters     []notificationMatter
+}
+
+// notificationMatter is a matter on the agenda and, in the results email, its outcome
+type notificationMatter struct {
+	Title       string
+	Informative bool
+	Passed      bool
+}
+
+// recipient is an owner a notification is sent to
+type recipient struct {
+	ownerID int64
+	name    string
+	email   string
+}
+
+// Send queues a notification for the owners of a gathering. Invitations and reminders go to
+// every voting owner, reminders only to those who have not voted yet, unless owners are named.
+// Each email carries a fresh personal link to the member app, revoking the owner's earlier one.
+// Owners without an email address get a failed delivery, so they can be notified otherwise.
+func (s *NotificationService) Send(ctx context.Context, gathering database.Gathering, req domain.SendNotificationRequest) ([]domain.NotificationDelivery, error) {
+	if !slices.Contains(NotificationTypes, req.NotificationType) {
+		return nil, ErrUnknownNotificationType
+	}
+	if !notificationDue(req.NotificationType, gathering.Status) {
+		return nil, ErrNotificationNotDue
+	}
+
+	recipients, err := s.recipients(ctx, gathering, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.queue(ctx, gathering, req, recipients, sql.NullInt64{})
+}
+
+// queue renders a notification for each recipient and queues the emails, on behalf of a
+// reminder schedule when one is given
+func (s *NotificationService) queue(ctx context.Context, gathering database.Gathering, req domain.SendNotificationRequest, recipients []recipient, reminderScheduleID sql.NullInt64) ([]domain.NotificationDelivery, error) {
+	templates, ok := s.templates[req.Lang]
+	if !ok {
+		return nil, fmt.Errorf("unsupported language %q", req.Lang)
+	}
+	email, err := s.email(ctx, gathering, req)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+
+	now := time.Now().UTC()
+	deliveries := make([]domain.NotificationDelivery, 0, len(recipients))
+	for _, r := range recipients {
+		notification, err := qtx.CreateNotification(ctx, database.CreateNotificationParams{
+			GatheringID:      gathering.ID,
+			OwnerID:          r.ownerID,
+			NotificationType: req.NotificationType,
+			SentVia:          sql.NullString{String: "email", Valid: true},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create notification: %w", err)
+		}
+
+		params := database.CreateOutboxMessageParams{
+			NotificationID:     notification.ID,
+			Recipient:          r.email,
+			Status:             OutboxStatusPending,
+			NextAttemptAt:      now,
+			ReminderScheduleID: reminderScheduleID,
+		}
+		email.OwnerName = r.name
+		email.Link = ""
+		if r.email == "" {
+			params.Status = OutboxStatusFailed
+			params.LastError = sql.NullString{String: noEmailAddress, Valid: true}
+		} else if s.memberOrigin != "" {
+			if email.Link, err = s.issueLink(ctx, qtx, gathering.ID, r.ownerID); err != nil {
+				return nil, err
+			}
+		}
+
+		if params.Subject, err = execute(templates, req.NotificationType+".subject", email); err != nil {
+			return nil, err
+		}
+		if params.Status == OutboxStatusPending {
+			if params.Body, err = execute(templates, req.NotificationType+".body", email); err != nil {
+				return nil, err
+			}
+		}
+
+		msg, err := qtx.CreateOutboxMessage(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to queue notification: %w", err)
+		}
+		deliveries = append(deliveries, domain.DBNotificationDeliveryToResponse(database.GetNotificationDeliveriesRow{
+			ID:               msg.ID,
+			NotificationID:   notification.ID,
+			OwnerID:          r.ownerID,
+			OwnerName:        r.name,
+			NotificationType: req.NotificationType,
+			Recipient:        msg.Recipient,
+			Subject:          msg.Subject,
+			Status:           msg.Status,
+			Attempts:         msg.Attempts,
+			NextAttemptAt:    msg.NextAttemptAt,
+			LastError:        msg.LastError,
+			SentAt:           msg.SentAt,
+			CreatedAt:        msg.CreatedAt,
+		}))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit notifications: %w", err)
+	}
+	s.dispatcher.Wake()
+	return deliveries, nil
+}
+
+// notificationDue reports whether a notification fits a gathering's status: invitations and
+// reminders while the gathering is published or active, results once it is tallied
+func notificationDue(notificationType, status string) bool {
+	if notificationType == NotificationResults {
+		return status == GatheringStatusTallied
+	}
+	return status == GatheringStatusPublished || status == GatheringStatusActive
+}
+
+// recipients resolves the owners a notification goes to from the gathering's register, with
+// their current contact email
+func (s *NotificationService) recipients(ctx context.Context, gathering database.Gathering, req domain.SendNotificationRequest) ([]recipient, error) {
+	register, err := s.voterRegisterService.Register(ctx, gathering)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voter register: %w", err)
+	}
+	var available SlotAvailability
+	if req.NotificationType == NotificationReminder && len(req.OwnerIDs) == 0 {
+		if available, err = s.voterRegisterService.AvailableSlots(ctx, gathering.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Owners in register order, each once however many units they vote for
+	var owners []domain.RegisterOwner
+	remind := make(map[int64]bool)
+	for _, unit := range register {
+		for _, owner := range VotingOwners(gathering.CoOwnershipPolicy, unit) {
+			if !slices.ContainsFunc(owners, func(o domain.RegisterOwner) bool { return o.OwnerID == owner.OwnerID }) {
+				owners = append(owners, owner)
+			}
+			if available.Available(unit.UnitID, owner.OwnerID) {
+				remind[owner.OwnerID] = true
+			}
+		}
+	}
+
+	var selected []domain.RegisterOwner
+	switch {
+	case len(req.OwnerIDs) > 0:
+		for _, id := range req.OwnerIDs {
+			i := slices.IndexFunc(owners, func(o domain.RegisterOwner) bool { return o.OwnerID == id })
+			if i < 0 {
+				return nil, fmt.Errorf("%w: owner %d", ErrOwnerNotOnRegister, id)
+			}
+			selected = append(selected, owners[i])
+		}
+	case available != nil:
+		for _, o := range owners {
+			if remind[o.OwnerID] {
+				selected = append(selected, o)
+			}
+		}
+	default:
+		selected = owners
+	}
+
+	recipients := make([]recipient, len(selected))
+	for i, o := range selected {
+		recipients[i] = recipient{ownerID: o.OwnerID, name: o.Name, email: o.ContactEmail}
+		// The register is frozen at publication; owners may have updated their email since
+		if owner, err := s.db.GetAssociationOwner(ctx, database.GetAssociationOwnerParams{ID: o.OwnerID, AssociationID: gathering.AssociationID}); err == nil {
+			recipients[i].email = strings.TrimSpace(owner.ContactEmail)
+		}
+	}
+	return recipients, nil
+}
+
+// email gathers what the emails of a notification have in common
+func (s *NotificationService) email(ctx context.Context, gathering database.Gathering, req domain.SendNotificationRequest) (notificationEmail, error) {
+	email := notificationEmail{
+		Title:    gathering.Title,
+		Date:     gathering.GatheringDate.Format(documentDateFormat),
+		Location: gathering.Location,
+	}
+	if association, err := s.db.GetAssociations(ctx, gathering.AssociationID); err == nil {
+		email.Association = association.Name
+	}
+	if gathering.GatheringType == "remote" && gathering.ClosesAt.Valid {
+		email.ClosesAt = gathering.ClosesAt.Time.Format(documentDateFormat)
+	}
+
+	matters, err := s.db.GetVotingMatters(ctx, gathering.ID)
+	if err != nil {
+		return email, fmt.Errorf("failed to get voting matters: %w", err)
+	}
+	var results map[int64]domain.VoteMatterResult
+	if req.NotificationType == NotificationResults {
+		voteResults, err := s.votingResultsService.GetCachedResults(ctx, gathering.ID, gathering.AssociationID)
+		if err != nil {
+			return email, fmt.Errorf("failed to get voting results: %w", err)
+		}
+		var quorum *domain.QuorumInfo
+		results, quorum = resultsByMatter(voteResults)
+		email.QuorumMet = quorum != nil && quorum.Met
+	}
+	for _, matter := range matters {
+		email.Matters = append(email.Matters, notificationMatter{
+			Title:       matterTitle(matter, req.Lang),
+			Informative: matter.IsInformative != 0,
+			Passed:      results[matter.ID].IsPassed,
+		})
+	}
+	return email, nil
+}
+
+// issueLink replaces the owner's member invitation with a new one, keeping its PIN, and returns
+// its link
+func (s *NotificationService) issueLink(ctx context.Context, qtx *database.Queries, gatheringID, ownerID int64) (string, error) {
+	existing, err := qtx.GetActiveMemberInvitationByOwnerAndGathering(ctx, database.GetActiveMemberInvitationByOwnerAndGatheringParams{
+		GatheringID: gatheringID,
+		OwnerID:     ownerID,
+	})
+	if err == nil {
+		if err := qtx.RevokeMemberInvitation(ctx, existing.ID); err != nil {
+			return "", fmt.Errorf("failed to revoke invitation: %w", err)
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	token, tokenHash, err := NewMemberToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	inv, err := qtx.CreateMemberInvitation(ctx, database.CreateMemberInvitationParams{
+		GatheringID: gatheringID,
+		OwnerID:     ownerID,
+		TokenHash:   tokenHash,
+		ExpiresAt:   time.Now().Add(DefaultInvitationTTL),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create invitation: %w", err)
+	}
+	// The PIN the owner was handed out stays valid with the new link
+	if existing.ID != 0 {
+		err := qtx.CopyMemberPIN(ctx, database.CopyMemberPINParams{
+			ToInvitationID:   inv.ID,
+			FromInvitationID: existing.ID,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to carry over PIN: %w", err)
+		}
+	}
+	return s.memberOrigin + "/" + token, nil
+}
+
+// execute runs one of the templates of a language
+func execute(templates *template.Template, name string, email notificationEmail) (string, error) {
+	var b strings.Builder
+	if err := templates.ExecuteTemplate(&b, name, email); err != nil {
+		return "", fmt.Errorf("failed to render %s email: %w", name, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNotificationTemplates tests that every notification renders in every language, with the
+// owner's link when there is one
+func TestNotificationTemplates(t *testing.T) {
+	s, err := NewNotificationService(nil, nil, nil, nil, "https://vote.example.com/")
+	if err != nil {
+		t.Fatalf("NewNotificationService() unexpected error: %v", err)
+	}
+	email := notificationEmail{
+		OwnerName:   "Ion Popescu",
+		Association: "APC",
+		Title:       "General meeting",
+		Date:        "01.03.2026",
+		Location:    "Hall",
+		Link:        "https://vote.example.com/abc",
+		Matters:     []notificationMatter{{Title: "Budget", Passed: true}, {Title: "Report", Informative: true}},
+	}
+
+	for _, lang := range SupportedLanguages {
+		for _, notificationType := range NotificationTypes {
+			t.Run(lang+"/"+notificationType, func(t *testing.T) {
+				subject, err := execute(s.templates[lang], notificationType+".subject", email)
+				if err != nil {
+					t.Fatalf("subject: unexpected error: %v", err)
+				}
+				if !strings.Contains(subject, email.Title) || strings.Contains(subject, "\n") {
+					t.Errorf("subject = %q, expected the title on a single line", subject)
+				}
+
+				body, err := execute(s.templates[lang], notificationType+".body", email)
+				if err != nil {
+					t.Fatalf("body: unexpected error: %v", err)
+				}
+				wants := []string{email.OwnerName, email.Link}
+				if notificationType != NotificationReminder {
+					wants = append(wants, "1. Budget", "2. Report")
+				}
+				for _, want := range wants {
+					if !strings.Contains(body, want) {
+						t.Errorf("body does not contain %q:\n%s", want, body)
+					}
+				}
+
+				withoutLink := email
+				withoutLink.Link = ""
+				body, err = execute(s.templates[lang], notificationType+".body", withoutLink)
+				if err != nil {
+					t.Fatalf("body without link: unexpected error: %v", err)
+				}
+				if strings.Contains(body, "https://") {
+					t.Errorf("body without link mentions one:\n%s", body)
+				}
+			})
+		}
+	}
+}
+
+// TestRetryBackoff tests that retries back off exponentially up to a ceiling
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempt  int64
+		expected time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{5, 16 * time.Minute},
+		{9, 256 * time.Minute},
+		{10, 6 * time.Hour},
+		{MaxDeliveryAttempts * 10, 6 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := RetryBackoff(tt.attempt); got != tt.expected {
+			t.Errorf("RetryBackoff(%d) = %v, expected %v", tt.attempt, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/qualification"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"go.uber.org/zap"
+)
+
+// GatheringQualificationRule parses the gathering's custom qualification rule.
+// Gatherings created before rules were evaluated may hold free text; such a rule
+// is logged and ignored, so it keeps qualifying every unit as it did before.
+func GatheringQualificationRule(gathering database.Gathering) *qualification.Rule {
+	if !gathering.QualificationCustomRule.Valid {
+		return nil
+	}
+	rule, err := qualification.Parse(gathering.QualificationCustomRule.String)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Ignoring invalid qualification rule",
+			zap.Int64("gathering_id", gathering.ID), zap.Error(err))
+		return nil
+	}
+	return rule
+}
+
+// QualifiedUnitAttributes returns the attributes a qualification rule sees for a unit
+func QualifiedUnitAttributes(u database.GetQualifiedUnitsRow) qualification.Unit {
+	return qualification.Unit{
+		Number:          u.UnitNumber,
+		CadastralNumber: u.CadastralNumber,
+		Type:            u.UnitType,
+		Floor:           u.Floor,
+		Entrance:        u.Entrance,
+		Area:            u.Area,
+		Part:            u.Part,
+		RoomCount:       u.RoomCount,
+		BuildingName:    u.BuildingName,
+		BuildingAddress: u.BuildingAddress,
+	}
+}
+
+// FilterQualifiedUnits keeps the units matching the rule
+func FilterQualifiedUnits(units []database.GetQualifiedUnitsRow, rule *qualification.Rule) []database.GetQualifiedUnitsRow {
+	if rule == nil {
+		return units
+	}
+	filtered := make([]database.GetQualifiedUnitsRow, 0, len(units))
+	for _, u := range units {
+		if rule.Matches(QualifiedUnitAttributes(u)) {
+			filtered = append(filtered, u)
+		}
+	}
+	return filtered
+}
@@ -1,6 +1,12 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
 	"github.com/alexmarian/apc/api/internal/database"
 	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
 )
@@ -15,22 +21,16 @@ func NewQuorumService(db *database.Queries) *QuorumService {
 	return &QuorumService{db: db}
 }
 
-// CalculateQuorum calculates quorum information based on gathering type and voting mode.
-// Quorum is always expressed as a fraction of qualified units (by count or by part),
-// never of checked-in participants.
+// CalculateQuorum calculates quorum information based on gathering type and voting mode,
+// using the voting rules the gathering was published with. Quorum is always expressed as
+// a fraction of qualified units (by count or by part), never of checked-in participants.
 func (s *QuorumService) CalculateQuorum(gathering domain.Gathering, _ float64, _ int, votedWeight float64, votedCount int, _ VotingStrategy) domain.QuorumInfo {
-	// Threshold percentage by gathering type
-	var thresholdPercent float64
-	switch gathering.GatheringType {
-	case "initial":
-		thresholdPercent = 50.0
-	case "repeated":
-		thresholdPercent = 25.0
-	case "remote":
-		thresholdPercent = 100.0
-	default:
-		thresholdPercent = 50.0
+	// Threshold percentage by gathering type, as published with the gathering
+	rules := DefaultVotingRules()
+	if gathering.VotingRules != nil {
+		rules = *gathering.VotingRules
 	}
+	thresholdPercent := QuorumPercentage(rules, gathering.GatheringType)
 
 	// Denominator is always total qualified units
 	totalPossibleWeight := gathering.QualifiedUnitsTotalPart
@@ -62,6 +62,82 @@ func (s *QuorumService) CalculateQuorum(gathering domain.Gathering, _ float64, _
 	}
 }
 
+// CalculatePresentQuorum calculates the quorum held by the participants present at a moment of
+// the meeting, or while a matter took votes
+func (s *QuorumService) CalculatePresentQuorum(gathering domain.Gathering, present domain.Attendance) domain.QuorumInfo {
+	return s.CalculateQuorum(gathering, present.Weight, present.Units, present.Weight, present.Units, GetVotingStrategy(gathering.VotingMode))
+}
+
+// QuorumAt returns the attendance of a gathering at a moment of the meeting and the quorum it held
+func (s *QuorumService) QuorumAt(ctx context.Context, gathering database.Gathering, at time.Time) (domain.Attendance, domain.QuorumInfo, error) {
+	participants, spans, err := s.attendance(ctx, gathering.ID)
+	if err != nil {
+		return domain.Attendance{}, domain.QuorumInfo{}, err
+	}
+	present := AttendanceAt(participants, spans, at)
+	return present, s.CalculatePresentQuorum(domain.DBGatheringToResponse(gathering), present), nil
+}
+
+// MattersAttendance returns the attendance each matter of a gathering was voted with
+func (s *QuorumService) MattersAttendance(ctx context.Context, gathering database.Gathering, matters []database.VotingMatter, now time.Time) (map[int64]domain.MatterAttendance, error) {
+	participants, spans, err := s.attendance(ctx, gathering.ID)
+	if err != nil {
+		return nil, err
+	}
+	voters, err := s.matterVoters(ctx, gathering.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	attendance := make(map[int64]domain.MatterAttendance, len(matters))
+	for _, matter := range matters {
+		attendance[matter.ID] = AttendanceForMatter(matter, participants, spans, voters[matter.ID], now)
+	}
+	return attendance, nil
+}
+
+// attendance loads the participants of a gathering and when each was present
+func (s *QuorumService) attendance(ctx context.Context, gatheringID int64) ([]database.GetGatheringParticipantsRow, map[int64][]AttendanceSpan, error) {
+	participants, err := s.db.GetGatheringParticipants(ctx, gatheringID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get participants: %w", err)
+	}
+	events, err := s.db.GetAttendanceEvents(ctx, gatheringID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get attendance events: %w", err)
+	}
+	return participants, AttendanceSpans(participants, events), nil
+}
+
+// matterVoters returns, for each matter, the participants whose ballot in force votes on it
+func (s *QuorumService) matterVoters(ctx context.Context, gatheringID int64) (map[int64]map[int64]bool, error) {
+	ballots, err := s.db.GetBallotsForGathering(ctx, gatheringID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ballots: %w", err)
+	}
+	voters := make(map[int64]map[int64]bool)
+	for _, ballot := range ballots {
+		if !ballot.IsValid.Bool {
+			continue
+		}
+		var content map[string]domain.BallotVote
+		if err := json.Unmarshal([]byte(ballot.BallotContent), &content); err != nil {
+			continue
+		}
+		for key := range content {
+			matterID, err := strconv.ParseInt(key, 10, 64)
+			if err != nil {
+				continue
+			}
+			if voters[matterID] == nil {
+				voters[matterID] = make(map[int64]bool)
+			}
+			voters[matterID][ballot.ParticipantID] = true
+		}
+	}
+	return voters, nil
+}
+
 // CalculateIfPassed determines if a voting matter has passed based on its results.
 // Informative matters are handled by the caller via VotingMatter.IsInformative.
 func (s *QuorumService) CalculateIfPassed(result domain.VoteMatterResult, config domain.VotingConfig, gathering database.Gathering) bool {
@@ -98,9 +174,21 @@ func (s *QuorumService) CalculateIfPassed(result domain.VoteMatterResult, config
 		}
 	}
 
+	// Multi-seat elections pass when every seat was filled
+	if config.Type == "ranking" && result.Breakdown != nil && result.Breakdown.Method == RankingMethodSTV {
+		return len(result.Breakdown.Elected) == result.Breakdown.Seats
+	}
+
 	// Determine the winning vote weight and the denominator for majority calculation
 	var winningWeight float64
-	if config.Type == "yes_no" {
+	if config.Type == "ranking" && result.Breakdown != nil {
+		// Ranking scores are points or wins, the majority applies to the ballots preferring
+		// the winner over the runner-up (an unresolved tie fails)
+		if result.Breakdown.Winner == "" {
+			return false
+		}
+		winningWeight = result.Breakdown.Support
+	} else if config.Type == "yes_no" {
 		winningWeight = result.Tally["yes"].Weight
 	} else {
 		for _, tally := range result.Tally {
@@ -129,21 +217,22 @@ func (s *QuorumService) CalculateIfPassed(result domain.VoteMatterResult, config
 	}
 	percentage := winningWeight / denominator * 100
 
+	rules := GatheringVotingRules(gathering)
 	switch config.RequiredMajority {
 	case "simple":
-		return percentage > 50
+		return percentage > rules.MajoritySimple
 	case "absolute":
-		return percentage > 50
+		return percentage > rules.MajorityAbsolute
 	case "absolute_two_thirds":
-		return percentage >= 66.67
+		return percentage >= rules.MajorityAbsoluteTwoThirds
 	case "qualified":
 		threshold := config.RequiredMajorityValue
 		if threshold == 0 {
-			threshold = 66.67
+			threshold = rules.MajorityQualified
 		}
 		return percentage >= threshold
 	case "unanimous":
-		return percentage >= 100
+		return percentage >= rules.MajorityUnanimous
 	}
 
 	return false
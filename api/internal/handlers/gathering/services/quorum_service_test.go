@@ -0,0 +1,152 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// TestCalculateIfPassedVotingRules tests that majorities follow the rules snapshotted on the gathering
+func TestCalculateIfPassedVotingRules(t *testing.T) {
+	statutes := `{"quorum_initial":60,"quorum_repeated":30,"quorum_remote":100,"majority_simple":60,"majority_absolute":50,` +
+		`"majority_absolute_two_thirds":75,"majority_qualified":80,"majority_unanimous":100}`
+
+	tests := []struct {
+		name        string
+		votingRules sql.NullString
+		majority    string
+		value       float64
+		yesWeight   float64 // out of 1.0 voted and qualified
+		expected    bool
+	}{
+		{"simple with defaults", sql.NullString{}, "simple", 0, 0.55, true},
+		{"simple raised by statutes", sql.NullString{String: statutes, Valid: true}, "simple", 0, 0.55, false},
+		{"simple must be exceeded", sql.NullString{String: statutes, Valid: true}, "simple", 0, 0.60, false},
+		{"two thirds with defaults", sql.NullString{}, "absolute_two_thirds", 0, 0.70, true},
+		{"two thirds raised by statutes", sql.NullString{String: statutes, Valid: true}, "absolute_two_thirds", 0, 0.70, false},
+		{"two thirds reached", sql.NullString{String: statutes, Valid: true}, "absolute_two_thirds", 0, 0.75, true},
+		{"qualified from statutes", sql.NullString{String: statutes, Valid: true}, "qualified", 0, 0.78, false},
+		{"qualified set on the matter", sql.NullString{String: statutes, Valid: true}, "qualified", 70, 0.78, true},
+		{"unreadable snapshot falls back to defaults", sql.NullString{String: "{", Valid: true}, "simple", 0, 0.55, true},
+	}
+
+	s := NewQuorumService(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gathering := database.Gathering{
+				VotingMode:              "by_weight",
+				QualifiedUnitsCount:     sql.NullInt64{Int64: 10, Valid: true},
+				QualifiedUnitsTotalPart: sql.NullFloat64{Float64: 1, Valid: true},
+				VotingRules:             tt.votingRules,
+			}
+			result := domain.VoteMatterResult{
+				MatterType: "yes_no",
+				TotalVoted: 1,
+				Tally: map[string]domain.TallyResult{
+					"yes": {Weight: tt.yesWeight},
+					"no":  {Weight: 1 - tt.yesWeight},
+				},
+			}
+			config := domain.VotingConfig{Type: "yes_no", RequiredMajority: tt.majority, RequiredMajorityValue: tt.value}
+
+			if got := s.CalculateIfPassed(result, config, gathering); got != tt.expected {
+				t.Errorf("CalculateIfPassed() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestCalculateIfPassedRanking tests that a ranking winner needs the required majority of the ballots
+func TestCalculateIfPassedRanking(t *testing.T) {
+	ballot := func(weight float64, preferences ...string) RankedBallot {
+		return RankedBallot{Preferences: preferences, Weight: weight}
+	}
+	// Instant-runoff elects b with the 0.5 of b first and the 0.2 transferred from c
+	ballots := []RankedBallot{
+		ballot(0.3, "a"),
+		ballot(0.5, "b"),
+		ballot(0.2, "c", "b"),
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		ballots  []RankedBallot
+		majority string
+		value    float64
+		expected bool
+	}{
+		{"simple majority of the ballots", RankingMethodIRV, ballots, "simple", 0, true},
+		{"two thirds reached", RankingMethodIRV, ballots, "absolute_two_thirds", 0, true},
+		{"short of the qualified majority", RankingMethodIRV, ballots, "qualified", 75, false},
+		{"borda winner most ballots rank below the runner-up", RankingMethodBorda, []RankedBallot{
+			ballot(0.51, "a", "b", "c"),
+			ballot(0.49, "b", "c", "a"),
+		}, "simple", 0, false},
+		{"unresolved tie", RankingMethodBorda, []RankedBallot{
+			ballot(0.5, "a", "b"),
+			ballot(0.5, "b", "a"),
+		}, "simple", 0, false},
+	}
+
+	s := NewQuorumService(nil)
+	gathering := database.Gathering{
+		VotingMode:              "by_weight",
+		QualifiedUnitsCount:     sql.NullInt64{Int64: 10, Valid: true},
+		QualifiedUnitsTotalPart: sql.NullFloat64{Float64: 1, Valid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tally, breakdown := TallyRanking(tt.method, []string{"a", "b", "c"}, tt.ballots)
+			result := domain.VoteMatterResult{MatterType: "ranking", TotalVoted: breakdown.BallotWeight, Tally: tally, Breakdown: breakdown}
+			config := domain.VotingConfig{Type: "ranking", RequiredMajority: tt.majority, RequiredMajorityValue: tt.value}
+
+			if got := s.CalculateIfPassed(result, config, gathering); got != tt.expected {
+				t.Errorf("CalculateIfPassed() = %v, expected %v (support %v)", got, tt.expected, breakdown.Support)
+			}
+		})
+	}
+}
+
+// TestCalculateQuorumVotingRules tests that the quorum threshold follows the gathering type and its rules
+func TestCalculateQuorumVotingRules(t *testing.T) {
+	statutes := DefaultVotingRules()
+	statutes.QuorumInitial = 60
+	statutes.QuorumRepeated = 30
+
+	tests := []struct {
+		name          string
+		gatheringType string
+		rules         *domain.VotingRules
+		expected      float64
+		met           bool // with 55% of the weight voted
+	}{
+		{"initial with defaults", "initial", nil, 50, true},
+		{"repeated with defaults", "repeated", nil, 25, true},
+		{"remote with defaults", "remote", nil, 100, false},
+		{"initial from statutes", "initial", &statutes, 60, false},
+		{"repeated from statutes", "repeated", &statutes, 30, true},
+	}
+
+	s := NewQuorumService(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gathering := domain.Gathering{
+				GatheringType:           tt.gatheringType,
+				VotingMode:              "by_weight",
+				QualifiedUnitsCount:     10,
+				QualifiedUnitsTotalPart: 1,
+				VotingRules:             tt.rules,
+			}
+			info := s.CalculateQuorum(gathering, 0, 0, 0.55, 6, nil)
+			if info.RequiredPercentage != tt.expected {
+				t.Errorf("CalculateQuorum() required %v%%, expected %v%%", info.RequiredPercentage, tt.expected)
+			}
+			if info.Met != tt.met {
+				t.Errorf("CalculateQuorum() met = %v, expected %v", info.Met, tt.met)
+			}
+		})
+	}
+}
@@ -0,0 +1,388 @@
+package services
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// Ranking methods available for ranking matters
+const (
+	RankingMethodBorda   = "borda"
+	RankingMethodIRV     = "irv"
+	RankingMethodSchulze = "schulze"
+)
+
+// IsValidRankingMethod reports whether method names a supported ranking method.
+// An empty method is valid and means Borda count.
+func IsValidRankingMethod(method string) bool {
+	switch method {
+	case "", RankingMethodBorda, RankingMethodIRV, RankingMethodSchulze:
+		return true
+	}
+	return false
+}
+
+// ValidateVotingConfig checks the ranking settings of a voting matter's configuration
+func ValidateVotingConfig(matterType string, config domain.VotingConfig) error {
+	if !IsValidRankingMethod(config.RankingMethod) {
+		return errors.New("ranking_method must be 'borda', 'irv' or 'schulze'")
+	}
+	// Omitted seats mean a single seat
+	if config.Seats < 0 {
+		return errors.New("seats must be at least one")
+	}
+	if config.Seats > 1 {
+		if matterType != "election" || config.Type != "ranking" {
+			return errors.New("more than one seat requires an election matter with ranking votes")
+		}
+		if config.Seats > len(config.Options) {
+			return errors.New("seats cannot exceed the number of candidates")
+		}
+	}
+	return nil
+}
+
+// IsMultiSeatElection reports whether a matter fills several seats and is counted by STV
+func IsMultiSeatElection(matterType string, config domain.VotingConfig) bool {
+	return matterType == "election" && config.Type == "ranking" && config.Seats > 1
+}
+
+// RankedBallot is one voter's preference order together with the weight the vote carries
+// (unit part in by_weight mode, number of units in by_unit mode)
+type RankedBallot struct {
+	Preferences []string
+	Weight      float64
+}
+
+// TallyRanking counts ranked ballots with the given method. It returns the per-option
+// tally stored for the matter and the breakdown explaining the outcome.
+// Unknown or empty methods fall back to Borda count.
+func TallyRanking(method string, options []string, ballots []RankedBallot) (map[string]domain.TallyResult, *domain.RankingBreakdown) {
+	var tally map[string]domain.TallyResult
+	var breakdown *domain.RankingBreakdown
+	switch method {
+	case RankingMethodIRV:
+		tally, breakdown = instantRunoff(options, ballots)
+	case RankingMethodSchulze:
+		tally, breakdown = schulze(options, ballots)
+	default:
+		tally, breakdown = borda(options, ballots)
+	}
+
+	breakdown.BallotCount = len(ballots)
+	for _, ballot := range ballots {
+		breakdown.BallotWeight += ballot.Weight
+	}
+	if breakdown.Winner != "" {
+		runnerUp := ""
+		for _, opt := range breakdown.Order {
+			if opt != breakdown.Winner {
+				runnerUp = opt
+				break
+			}
+		}
+		breakdown.Support = support(breakdown.Winner, runnerUp, ballots)
+	}
+	return tally, breakdown
+}
+
+// support adds up the weight of the ballots ranking the winner above the runner-up. Options a
+// voter did not rank come below the ranked ones, and with no runner-up every ballot ranking the
+// winner counts.
+func support(winner, runnerUp string, ballots []RankedBallot) float64 {
+	var weight float64
+	for _, ballot := range ballots {
+		for _, opt := range ballot.Preferences {
+			if opt == runnerUp {
+				break
+			}
+			if opt == winner {
+				weight += ballot.Weight
+				break
+			}
+		}
+	}
+	return weight
+}
+
+// borda awards N-1 points for a first choice down to 0 for the last, where N is the
+// number of options the voter ranked
+func borda(options []string, ballots []RankedBallot) (map[string]domain.TallyResult, *domain.RankingBreakdown) {
+	tally := emptyTally(options)
+	for _, ballot := range ballots {
+		n := len(ballot.Preferences)
+		for i, optID := range ballot.Preferences {
+			points := n - 1 - i
+			if t, exists := tally[optID]; exists {
+				tally[optID] = domain.TallyResult{
+					Count:  t.Count + points,
+					Weight: t.Weight + ballot.Weight*float64(points),
+				}
+			}
+		}
+	}
+
+	order := orderByScore(options, func(opt string) float64 { return tally[opt].Weight })
+	return tally, &domain.RankingBreakdown{
+		Method: RankingMethodBorda,
+		Winner: uniqueLeader(order, func(opt string) float64 { return tally[opt].Weight }),
+		Order:  order,
+	}
+}
+
+// instantRunoff transfers each ballot to its highest continuing preference and eliminates the
+// weakest option each round until one option holds a majority of the continuing votes.
+// Ties for elimination are broken by the standing in earlier rounds, then by option order.
+func instantRunoff(options []string, ballots []RankedBallot) (map[string]domain.TallyResult, *domain.RankingBreakdown) {
+	continuing := make(map[string]bool, len(options))
+	for _, opt := range options {
+		continuing[opt] = true
+	}
+
+	breakdown := &domain.RankingBreakdown{Method: RankingMethodIRV}
+	var eliminatedOrder []string
+	var finalTally map[string]domain.TallyResult
+
+	for round := 1; len(continuing) > 0; round++ {
+		roundTally := make(map[string]domain.TallyResult, len(continuing))
+		for opt := range continuing {
+			roundTally[opt] = domain.TallyResult{}
+		}
+		var exhausted domain.TallyResult
+		for _, ballot := range ballots {
+			if opt, ok := topContinuing(ballot.Preferences, continuing); ok {
+				t := roundTally[opt]
+				roundTally[opt] = domain.TallyResult{Count: t.Count + 1, Weight: t.Weight + ballot.Weight}
+			} else {
+				exhausted = domain.TallyResult{Count: exhausted.Count + 1, Weight: exhausted.Weight + ballot.Weight}
+			}
+		}
+
+		var activeWeight float64
+		for _, t := range roundTally {
+			activeWeight += t.Weight
+		}
+
+		current := domain.RankingRound{Round: round, Tally: roundTally, Exhausted: exhausted}
+		finalTally = roundTally
+
+		if activeWeight == 0 {
+			// No ballot expresses a preference among the remaining options
+			breakdown.Rounds = append(breakdown.Rounds, current)
+			break
+		}
+
+		leader, leaderWeight := "", -1.0
+		for _, opt := range options {
+			if t, ok := roundTally[opt]; ok && t.Weight > leaderWeight {
+				leader, leaderWeight = opt, t.Weight
+			}
+		}
+		if leaderWeight*2 > activeWeight || len(continuing) == 1 {
+			current.Elected = []string{leader}
+			breakdown.Winner = leader
+			breakdown.Rounds = append(breakdown.Rounds, current)
+			break
+		}
+
+		loser, tieBroken := weakestOption(options, roundTally, breakdown.Rounds)
+		current.Eliminated = []string{loser}
+		current.TieBroken = tieBroken
+		breakdown.Rounds = append(breakdown.Rounds, current)
+
+		delete(continuing, loser)
+		eliminatedOrder = append(eliminatedOrder, loser)
+	}
+
+	// Final order: options still standing by final-round votes, then eliminated options latest first
+	var standing []string
+	for _, opt := range options {
+		if _, ok := finalTally[opt]; ok {
+			standing = append(standing, opt)
+		}
+	}
+	order := orderByScore(standing, func(opt string) float64 { return finalTally[opt].Weight })
+	for i := len(eliminatedOrder) - 1; i >= 0; i-- {
+		order = append(order, eliminatedOrder[i])
+	}
+	breakdown.Order = order
+
+	tally := emptyTally(options)
+	for opt, t := range finalTally {
+		tally[opt] = t
+	}
+	return tally, breakdown
+}
+
+// topContinuing returns the voter's most preferred option that is still in the count
+func topContinuing(preferences []string, continuing map[string]bool) (string, bool) {
+	for _, opt := range preferences {
+		if continuing[opt] {
+			return opt, true
+		}
+	}
+	return "", false
+}
+
+// weakestOption picks the option to eliminate from a round. It reports whether a tie had to be broken.
+func weakestOption(options []string, roundTally map[string]domain.TallyResult, previous []domain.RankingRound) (string, bool) {
+	lowest := -1.0
+	var tied []string
+	for _, opt := range options {
+		t, ok := roundTally[opt]
+		if !ok {
+			continue
+		}
+		switch {
+		case lowest < 0 || t.Weight < lowest:
+			lowest = t.Weight
+			tied = []string{opt}
+		case t.Weight == lowest:
+			tied = append(tied, opt)
+		}
+	}
+	if len(tied) == 1 {
+		return tied[0], false
+	}
+
+	// Look back through earlier rounds for the first one that separates the tied options
+	for i := len(previous) - 1; i >= 0 && len(tied) > 1; i-- {
+		lowestEarlier := -1.0
+		var weakest []string
+		for _, opt := range tied {
+			w := previous[i].Tally[opt].Weight
+			switch {
+			case lowestEarlier < 0 || w < lowestEarlier:
+				lowestEarlier = w
+				weakest = []string{opt}
+			case w == lowestEarlier:
+				weakest = append(weakest, opt)
+			}
+		}
+		tied = weakest
+	}
+
+	// Still tied: eliminate the option listed last on the ballot
+	return tied[len(tied)-1], true
+}
+
+// schulze elects the option that beats every other option through the strongest chain
+// of pairwise preferences. Options a voter did not rank are treated as ranked below all
+// ranked options and equal to each other.
+func schulze(options []string, ballots []RankedBallot) (map[string]domain.TallyResult, *domain.RankingBreakdown) {
+	pairwise := make(map[string]map[string]float64, len(options))
+	for _, a := range options {
+		pairwise[a] = make(map[string]float64, len(options))
+	}
+
+	for _, ballot := range ballots {
+		position := make(map[string]int, len(ballot.Preferences))
+		for i, opt := range ballot.Preferences {
+			if _, exists := pairwise[opt]; exists {
+				if _, seen := position[opt]; !seen {
+					position[opt] = i
+				}
+			}
+		}
+		for _, a := range options {
+			pa, rankedA := position[a]
+			if !rankedA {
+				continue
+			}
+			for _, b := range options {
+				if a == b {
+					continue
+				}
+				if pb, rankedB := position[b]; !rankedB || pa < pb {
+					pairwise[a][b] += ballot.Weight
+				}
+			}
+		}
+	}
+
+	// Widest path strengths (Floyd-Warshall variant)
+	paths := make(map[string]map[string]float64, len(options))
+	for _, a := range options {
+		paths[a] = make(map[string]float64, len(options))
+		for _, b := range options {
+			if a != b && pairwise[a][b] > pairwise[b][a] {
+				paths[a][b] = pairwise[a][b]
+			}
+		}
+	}
+	for _, i := range options {
+		for _, j := range options {
+			if i == j {
+				continue
+			}
+			for _, k := range options {
+				if i == k || j == k {
+					continue
+				}
+				if via := min(paths[j][i], paths[i][k]); via > paths[j][k] {
+					paths[j][k] = via
+				}
+			}
+		}
+	}
+
+	wins := make(map[string]int, len(options))
+	for _, a := range options {
+		for _, b := range options {
+			if a != b && paths[a][b] > paths[b][a] {
+				wins[a]++
+			}
+		}
+	}
+
+	// Each option's score is the number of options it defeats through strongest paths
+	tally := emptyTally(options)
+	for _, opt := range options {
+		tally[opt] = domain.TallyResult{Count: wins[opt], Weight: float64(wins[opt])}
+	}
+
+	order := orderByScore(options, func(opt string) float64 { return float64(wins[opt]) })
+	winner := ""
+	if len(order) > 0 && wins[order[0]] == len(options)-1 {
+		winner = order[0]
+	}
+
+	return tally, &domain.RankingBreakdown{
+		Method:         RankingMethodSchulze,
+		Winner:         winner,
+		Order:          order,
+		Pairwise:       pairwise,
+		StrongestPaths: paths,
+	}
+}
+
+// emptyTally creates a zeroed tally entry for every option
+func emptyTally(options []string) map[string]domain.TallyResult {
+	tally := make(map[string]domain.TallyResult, len(options))
+	for _, opt := range options {
+		tally[opt] = domain.TallyResult{}
+	}
+	return tally
+}
+
+// orderByScore sorts options by descending score, keeping the ballot order for equal scores
+func orderByScore(options []string, score func(string) float64) []string {
+	order := append([]string(nil), options...)
+	sort.SliceStable(order, func(i, j int) bool {
+		return score(order[i]) > score(order[j])
+	})
+	return order
+}
+
+// uniqueLeader returns the first option of an ordered list unless it is tied with the second
+func uniqueLeader(order []string, score func(string) float64) string {
+	if len(order) == 0 || score(order[0]) == 0 {
+		return ""
+	}
+	if len(order) > 1 && score(order[0]) == score(order[1]) {
+		return ""
+	}
+	return order[0]
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestTallyRanking tests the supported ranking methods
+func TestTallyRanking(t *testing.T) {
+	options := []string{"a", "b", "c"}
+	ballot := func(weight float64, preferences ...string) RankedBallot {
+		return RankedBallot{Preferences: preferences, Weight: weight}
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		ballots        []RankedBallot
+		expectedWinner string
+		expectedOrder  []string
+		expectedRounds int
+	}{
+		{
+			name:   "borda count",
+			method: RankingMethodBorda,
+			ballots: []RankedBallot{
+				ballot(1, "a", "b", "c"),
+				ballot(1, "b", "c", "a"),
+				ballot(1, "b", "a", "c"),
+			},
+			expectedWinner: "b",
+			expectedOrder:  []string{"b", "a", "c"},
+		},
+		{
+			name:   "empty method falls back to borda",
+			method: "",
+			ballots: []RankedBallot{
+				ballot(1, "c", "a", "b"),
+			},
+			expectedWinner: "c",
+			expectedOrder:  []string{"c", "a", "b"},
+		},
+		{
+			name:   "instant-runoff transfers eliminated preferences",
+			method: RankingMethodIRV,
+			ballots: []RankedBallot{
+				ballot(4, "a"),
+				ballot(3, "b"),
+				ballot(2, "c", "b"),
+			},
+			expectedWinner: "b",
+			expectedOrder:  []string{"b", "a", "c"},
+			expectedRounds: 2,
+		},
+		{
+			name:   "instant-runoff counts vote weight rather than voters",
+			method: RankingMethodIRV,
+			ballots: []RankedBallot{
+				ballot(0.5, "a"),
+				ballot(0.2, "b"),
+				ballot(0.2, "b"),
+			},
+			expectedWinner: "a",
+			expectedOrder:  []string{"a", "b", "c"},
+			expectedRounds: 1,
+		},
+		{
+			name:   "instant-runoff with equal unit weights",
+			method: RankingMethodIRV,
+			ballots: []RankedBallot{
+				ballot(1, "a"),
+				ballot(1, "b"),
+				ballot(1, "b"),
+			},
+			expectedWinner: "b",
+			expectedOrder:  []string{"b", "a", "c"},
+			expectedRounds: 1,
+		},
+		{
+			name:   "instant-runoff breaks elimination ties",
+			method: RankingMethodIRV,
+			ballots: []RankedBallot{
+				ballot(1, "a"),
+				ballot(1, "a"),
+				ballot(1, "b", "a"),
+				ballot(1, "c", "b"),
+			},
+			expectedWinner: "a",
+			expectedOrder:  []string{"a", "b", "c"},
+			expectedRounds: 3,
+		},
+		{
+			name:   "schulze elects the condorcet winner",
+			method: RankingMethodSchulze,
+			ballots: []RankedBallot{
+				ballot(3, "a", "b", "c"),
+				ballot(2, "b", "a", "c"),
+				ballot(1, "c", "a", "b"),
+			},
+			expectedWinner: "a",
+			expectedOrder:  []string{"a", "b", "c"},
+		},
+		{
+			name:   "schulze cycle without a winner",
+			method: RankingMethodSchulze,
+			ballots: []RankedBallot{
+				ballot(1, "a", "b", "c"),
+				ballot(1, "b", "c", "a"),
+				ballot(1, "c", "a", "b"),
+			},
+			expectedWinner: "",
+			expectedOrder:  []string{"a", "b", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tally, breakdown := TallyRanking(tt.method, options, tt.ballots)
+
+			if breakdown.Winner != tt.expectedWinner {
+				t.Errorf("Winner = %q, expected %q", breakdown.Winner, tt.expectedWinner)
+			}
+			if !reflect.DeepEqual(breakdown.Order, tt.expectedOrder) {
+				t.Errorf("Order = %v, expected %v", breakdown.Order, tt.expectedOrder)
+			}
+			if len(breakdown.Rounds) != tt.expectedRounds {
+				t.Errorf("got %d rounds, expected %d", len(breakdown.Rounds), tt.expectedRounds)
+			}
+			if breakdown.BallotCount != len(tt.ballots) {
+				t.Errorf("BallotCount = %d, expected %d", breakdown.BallotCount, len(tt.ballots))
+			}
+			if len(tally) != len(options) {
+				t.Errorf("tally has %d options, expected %d", len(tally), len(options))
+			}
+		})
+	}
+}
+
+// TestInstantRunoffTieBreak tests that elimination ties are recorded in the round breakdown
+func TestInstantRunoffTieBreak(t *testing.T) {
+	ballots := []RankedBallot{
+		{Preferences: []string{"a"}, Weight: 1},
+		{Preferences: []string{"a"}, Weight: 1},
+		{Preferences: []string{"b", "a"}, Weight: 1},
+		{Preferences: []string{"c", "b"}, Weight: 1},
+	}
+	_, breakdown := TallyRanking(RankingMethodIRV, []string{"a", "b", "c"}, ballots)
+
+	// Round 1: b and c tie with no earlier round, so the option listed last goes
+	first := breakdown.Rounds[0]
+	if !reflect.DeepEqual(first.Eliminated, []string{"c"}) || !first.TieBroken {
+		t.Errorf("round 1: eliminated %v (tie broken %v), expected [c] with tie break", first.Eliminated, first.TieBroken)
+	}
+	// Round 2: a and b tie, round 1 standing decides against b
+	second := breakdown.Rounds[1]
+	if !reflect.DeepEqual(second.Eliminated, []string{"b"}) || !second.TieBroken {
+		t.Errorf("round 2: eliminated %v (tie broken %v), expected [b] with tie break", second.Eliminated, second.TieBroken)
+	}
+	// Round 3: the ballot ranking only c and b is exhausted
+	third := breakdown.Rounds[2]
+	if third.Exhausted.Count != 1 || !reflect.DeepEqual(third.Elected, []string{"a"}) {
+		t.Errorf("round 3: exhausted %+v elected %v, expected 1 exhausted ballot and a elected", third.Exhausted, third.Elected)
+	}
+}
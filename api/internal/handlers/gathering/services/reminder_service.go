@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/logging"
+	"go.uber.org/zap"
+)
+
+// DefaultReminderInterval is how often the scheduler looks for reminders that are due
+const DefaultReminderInterval = time.Minute
+
+// maxReminderOffset bounds how long before voting closes a reminder can be scheduled
+const maxReminderOffset = 90 * 24 * time.Hour
+
+// Reminder schedule statuses
+const (
+	ReminderStatusScheduled = "scheduled"
+	ReminderStatusSent      = "sent"
+	ReminderStatusMissed    = "missed"
+	ReminderStatusFailed    = "failed"
+)
+
+var (
+	// ErrInvalidReminderSchedule is returned for reminder offsets that are not positive, too long
+	// or repeated
+	ErrInvalidReminderSchedule = errors.New("invalid reminder schedule")
+	// ErrRemindersClosed is returned when reminders are scheduled for a gathering whose voting
+	// has closed
+	ErrRemindersClosed = errors.New("voting has closed")
+)
+
+// ReminderService schedules the automatic reminders of gatherings and sends them to the owners
+// who have not voted yet
+type ReminderService struct {
+	db                  *database.Queries
+	conn                *sql.DB
+	notificationService *NotificationService
+}
+
+// NewReminderService creates a new ReminderService
+func NewReminderService(db *database.Queries, conn *sql.DB, notificationService *NotificationService) *ReminderService {
+	return &ReminderService{
+		db:                  db,
+		conn:                conn,
+		notificationService: notificationService,
+	}
+}
+
+// VotingClosesAt is when voting on a gathering closes: its closing time for remote gatherings,
+// the gathering itself otherwise
+func VotingClosesAt(gathering database.Gathering) time.Time {
+	if gathering.ClosesAt.Valid {
+		return gathering.ClosesAt.Time
+	}
+	return gathering.GatheringDate
+}
+
+// SetSchedules replaces the reminders of a gathering that have not been sent yet. Reminders
+// already sent or missed are kept, as the campaign's history.
+func (s *ReminderService) SetSchedules(ctx context.Context, gathering database.Gathering, req domain.ReminderScheduleRequest) (domain.ReminderCampaign, error) {
+	switch gathering.Status {
+	case GatheringStatusDraft, GatheringStatusPublished, GatheringStatusActive:
+	default:
+		return domain.ReminderCampaign{}, ErrRemindersClosed
+	}
+	for i, offset := range req.OffsetsMinutes {
+		if offset <= 0 || time.Duration(offset)*time.Minute > maxReminderOffset {
+			return domain.ReminderCampaign{}, fmt.Errorf("%w: offsets must be between 1 minute and %d days", ErrInvalidReminderSchedule, maxReminderOffset/(24*time.Hour))
+		}
+		if slices.Contains(req.OffsetsMinutes[:i], offset) {
+			return domain.ReminderCampaign{}, fmt.Errorf("%w: offset %d is repeated", ErrInvalidReminderSchedule, offset)
+		}
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.ReminderCampaign{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+
+	if err := qtx.DeleteScheduledReminders(ctx, gathering.ID); err != nil {
+		return domain.ReminderCampaign{}, fmt.Errorf("failed to delete reminders: %w", err)
+	}
+	for _, offset := range req.OffsetsMinutes {
+		err := qtx.CreateReminderSchedule(ctx, database.CreateReminderScheduleParams{
+			GatheringID:   gathering.ID,
+			OffsetMinutes: offset,
+			Lang:          req.Lang,
+		})
+		if err != nil {
+			return domain.ReminderCampaign{}, fmt.Errorf("failed to create reminder: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return domain.ReminderCampaign{}, fmt.Errorf("failed to commit reminders: %w", err)
+	}
+	return s.Campaign(ctx, gathering)
+}
+
+// Campaign reports the reminders of a gathering and the delivery of those sent
+func (s *ReminderService) Campaign(ctx context.Context, gathering database.Gathering) (domain.ReminderCampaign, error) {
+	rows, err := s.db.GetReminderSchedules(ctx, gathering.ID)
+	if err != nil {
+		return domain.ReminderCampaign{}, fmt.Errorf("failed to get reminders: %w", err)
+	}
+	campaign := domain.ReminderCampaign{
+		GatheringID: gathering.ID,
+		ClosesAt:    VotingClosesAt(gathering),
+		Schedules:   make([]domain.ReminderSchedule, len(rows)),
+	}
+	for i, row := range rows {
+		campaign.Schedules[i] = domain.DBReminderScheduleToResponse(row, campaign.ClosesAt)
+		campaign.QueuedCount += row.QueuedCount
+		campaign.DeliveredCount += row.DeliveredCount
+		campaign.FailedCount += row.FailedCount
+	}
+	return campaign, nil
+}
+
+// RunDue sends the reminders due at the given time and marks those voting closed before as missed
+func (s *ReminderService) RunDue(ctx context.Context, now time.Time) {
+	scheduled, err := s.db.GetScheduledReminders(ctx)
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error getting scheduled reminders", zap.Error(err))
+		return
+	}
+	var gatheringIDs []int64
+	for _, r := range scheduled {
+		if !slices.Contains(gatheringIDs, r.GatheringID) {
+			gatheringIDs = append(gatheringIDs, r.GatheringID)
+		}
+	}
+
+	for _, gatheringID := range gatheringIDs {
+		gathering, err := s.db.GetGatheringByID(ctx, gatheringID)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error getting gathering for reminders", zap.Int64("gathering_id", gatheringID), zap.Error(err))
+			continue
+		}
+		reminders, err := s.db.GetReminderSchedules(ctx, gatheringID)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error getting reminders", zap.Int64("gathering_id", gatheringID), zap.Error(err))
+			continue
+		}
+
+		closesAt := VotingClosesAt(gathering)
+		// Reminders come earliest first; each skips the owners reminded since the one before
+		var since time.Time
+		for _, r := range reminders {
+			dueAt := closesAt.Add(-time.Duration(r.OffsetMinutes) * time.Minute)
+			if r.Status == ReminderStatusScheduled {
+				switch reminderAction(now, dueAt, closesAt, gathering.Status) {
+				case ReminderStatusMissed:
+					s.complete(ctx, r.ID, ReminderStatusMissed, now, 0, 0, nil)
+				case ReminderStatusSent:
+					s.remind(ctx, gathering, r, since, now)
+				}
+			}
+			since = dueAt
+		}
+	}
+}
+
+// remind sends a reminder to the owners who have not voted, except those reminded since the
+// given time
+func (s *ReminderService) remind(ctx context.Context, gathering database.Gathering, reminder database.GetReminderSchedulesRow, since, now time.Time) {
+	req := domain.SendNotificationRequest{NotificationType: NotificationReminder, SendVia: "email", Lang: reminder.Lang}
+	recipients, err := s.notificationService.recipients(ctx, gathering, req)
+	if err != nil {
+		s.complete(ctx, reminder.ID, ReminderStatusFailed, now, 0, 0, err)
+		return
+	}
+	sent, err := s.db.GetReminderNotifications(ctx, gathering.ID)
+	if err != nil {
+		s.complete(ctx, reminder.ID, ReminderStatusFailed, now, 0, 0, err)
+		return
+	}
+	pending := notRemindedSince(recipients, sent, since)
+	eligible, skipped := int64(len(recipients)), int64(len(recipients)-len(pending))
+	if len(pending) > 0 {
+		_, err = s.notificationService.queue(ctx, gathering, req, pending, sql.NullInt64{Int64: reminder.ID, Valid: true})
+		if err != nil {
+			s.complete(ctx, reminder.ID, ReminderStatusFailed, now, eligible, skipped, err)
+			return
+		}
+	}
+	s.complete(ctx, reminder.ID, ReminderStatusSent, now, eligible, skipped, nil)
+	logging.Logger.Log(zap.InfoLevel, "Reminder sent",
+		zap.Int64("gathering_id", gathering.ID),
+		zap.Int64("offset_minutes", reminder.OffsetMinutes),
+		zap.Int64("eligible", eligible),
+		zap.Int64("skipped", skipped))
+}
+
+// reminderAction is what becomes of a scheduled reminder at the given time: it is sent once due
+// while the gathering takes votes, and missed once voting has closed
+func reminderAction(now, dueAt, closesAt time.Time, gatheringStatus string) string {
+	switch {
+	case !now.Before(closesAt):
+		return ReminderStatusMissed
+	case !now.Before(dueAt) && notificationDue(NotificationReminder, gatheringStatus):
+		return ReminderStatusSent
+	}
+	return ReminderStatusScheduled
+}
+
+// notRemindedSince leaves out of the recipients the owners sent a reminder since the given time.
+// An owner has a single reminder notification, updated each time one is sent.
+func notRemindedSince(recipients []recipient, reminders []database.GetReminderNotificationsRow, since time.Time) []recipient {
+	reminded := make(map[int64]bool, len(reminders))
+	for _, n := range reminders {
+		reminded[n.OwnerID] = n.SentAt.Valid && !n.SentAt.Time.Before(since)
+	}
+	var pending []recipient
+	for _, r := range recipients {
+		if !reminded[r.ownerID] {
+			pending = append(pending, r)
+		}
+	}
+	return pending
+}
+
+func (s *ReminderService) complete(ctx context.Context, id int64, status string, now time.Time, eligible, skipped int64, cause error) {
+	var lastError sql.NullString
+	if cause != nil {
+		lastError = sql.NullString{String: cause.Error(), Valid: true}
+		logging.Logger.Log(zap.WarnLevel, "Reminder failed", zap.Int64("reminder_id", id), zap.Error(cause))
+	}
+	err := s.db.CompleteReminderSchedule(ctx, database.CompleteReminderScheduleParams{
+		Status:        status,
+		RanAt:         sql.NullTime{Time: now.UTC(), Valid: true},
+		EligibleCount: eligible,
+		SkippedCount:  skipped,
+		LastError:     lastError,
+		ID:            id,
+	})
+	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error recording reminder outcome", zap.Int64("reminder_id", id), zap.Error(err))
+	}
+}
+
+// ReminderScheduler sends the reminders of gatherings when they are due
+type ReminderScheduler struct {
+	reminderService *ReminderService
+	interval        time.Duration
+}
+
+// NewReminderScheduler creates a new ReminderScheduler
+func NewReminderScheduler(reminderService *ReminderService, interval time.Duration) *ReminderScheduler {
+	if interval <= 0 {
+		interval = DefaultReminderInterval
+	}
+	return &ReminderScheduler{
+		reminderService: reminderService,
+		interval:        interval,
+	}
+}
+
+// Start runs the scheduler in the background until ctx is cancelled
+func (s *ReminderScheduler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.RunOnce(ctx, time.Now())
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.RunOnce(ctx, now)
+			}
+		}
+	}()
+}
+
+// RunOnce sends the reminders that are due at the given time
+func (s *ReminderScheduler) RunOnce(ctx context.Context, now time.Time) {
+	s.reminderService.RunDue(ctx, now)
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"database/sql"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+)
+
+// TestReminderAction tests when a scheduled reminder is sent or missed
+func TestReminderAction(t *testing.T) {
+	closesAt := time.Date(2026, 3, 10, 18, 0, 0, 0, time.UTC)
+	dueAt := closesAt.Add(-48 * time.Hour)
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		status   string
+		expected string
+	}{
+		{"before due", dueAt.Add(-time.Minute), GatheringStatusActive, ReminderStatusScheduled},
+		{"due while active", dueAt, GatheringStatusActive, ReminderStatusSent},
+		{"due while published", dueAt.Add(time.Hour), GatheringStatusPublished, ReminderStatusSent},
+		{"due while draft", dueAt.Add(time.Hour), GatheringStatusDraft, ReminderStatusScheduled},
+		{"due after an early close", dueAt.Add(time.Hour), GatheringStatusClosed, ReminderStatusScheduled},
+		{"voting closed", closesAt, GatheringStatusActive, ReminderStatusMissed},
+		{"tallied", closesAt.Add(time.Hour), GatheringStatusTallied, ReminderStatusMissed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reminderAction(tt.now, dueAt, closesAt, tt.status); got != tt.expected {
+				t.Errorf("reminderAction() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestNotRemindedSince tests that owners reminded since the previous reminder are skipped
+func TestNotRemindedSince(t *testing.T) {
+	since := time.Date(2026, 3, 3, 18, 0, 0, 0, time.UTC)
+	recipients := []recipient{{ownerID: 1}, {ownerID: 2}, {ownerID: 3}, {ownerID: 4}}
+	reminders := []database.GetReminderNotificationsRow{
+		{OwnerID: 1, SentAt: sql.NullTime{Time: since.Add(time.Hour), Valid: true}},
+		{OwnerID: 2, SentAt: sql.NullTime{Time: since.Add(-time.Hour), Valid: true}},
+		{OwnerID: 3, SentAt: sql.NullTime{Time: since, Valid: true}},
+	}
+
+	tests := []struct {
+		name     string
+		since    time.Time
+		expected []int64
+	}{
+		{"since the previous reminder", since, []int64{2, 4}},
+		{"first reminder", time.Time{}, []int64{4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int64
+			for _, r := range notRemindedSince(recipients, reminders, tt.since) {
+				got = append(got, r.ownerID)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("notRemindedSince() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
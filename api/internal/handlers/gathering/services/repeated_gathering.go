@@ -0,0 +1,29 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alexmarian/apc/api/internal/database"
+)
+
+// ErrNotRepeatable is returned when a gathering does not qualify for a repeated gathering
+var ErrNotRepeatable = errors.New("gathering cannot be repeated")
+
+// CheckRepeatable verifies a repeated gathering may be convened from the source: it must be an
+// initial gathering, voting must have ended and the stored results must show the quorum was missed
+func CheckRepeatable(source database.Gathering, results *database.VotingResult) error {
+	if source.GatheringType != "initial" {
+		return fmt.Errorf("%w: only initial gatherings are repeated, this one is %s", ErrNotRepeatable, source.GatheringType)
+	}
+	if source.Status != GatheringStatusClosed && source.Status != GatheringStatusTallied {
+		return fmt.Errorf("%w: gathering is %s, voting has not ended", ErrNotRepeatable, source.Status)
+	}
+	if results == nil {
+		return fmt.Errorf("%w: results have not been computed yet", ErrNotRepeatable)
+	}
+	if results.QuorumMet {
+		return fmt.Errorf("%w: quorum was met", ErrNotRepeatable)
+	}
+	return nil
+}
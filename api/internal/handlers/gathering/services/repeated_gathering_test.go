@@ -0,0 +1,43 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alexmarian/apc/api/internal/database"
+)
+
+// TestCheckRepeatable tests that only initial gatherings that ended without quorum can be repeated
+func TestCheckRepeatable(t *testing.T) {
+	missed := &database.VotingResult{QuorumMet: false}
+	met := &database.VotingResult{QuorumMet: true}
+
+	tests := []struct {
+		name          string
+		gatheringType string
+		status        string
+		results       *database.VotingResult
+		repeatable    bool
+	}{
+		{"closed without quorum", "initial", GatheringStatusClosed, missed, true},
+		{"tallied without quorum", "initial", GatheringStatusTallied, missed, true},
+		{"closed with quorum", "initial", GatheringStatusClosed, met, false},
+		{"closed without results", "initial", GatheringStatusClosed, nil, false},
+		{"still voting", "initial", GatheringStatusActive, missed, false},
+		{"draft", "initial", GatheringStatusDraft, nil, false},
+		{"repeated gathering", "repeated", GatheringStatusClosed, missed, false},
+		{"remote gathering", "remote", GatheringStatusClosed, missed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckRepeatable(database.Gathering{GatheringType: tt.gatheringType, Status: tt.status}, tt.results)
+			if tt.repeatable && err != nil {
+				t.Errorf("CheckRepeatable() = %v, expected no error", err)
+			}
+			if !tt.repeatable && !errors.Is(err, ErrNotRepeatable) {
+				t.Errorf("CheckRepeatable() = %v, expected ErrNotRepeatable", err)
+			}
+		})
+	}
+}
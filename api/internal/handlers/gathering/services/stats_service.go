@@ -3,10 +3,11 @@ package services
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
+	"fmt"
 	"math"
 
 	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
 	"github.com/alexmarian/apc/api/internal/logging"
 	"go.uber.org/zap"
 )
@@ -39,33 +40,10 @@ func (s *StatsService) UpdateGatheringStats(gatheringID, associationID int64) (i
 		return 0, 0.0, 0.0
 	}
 
-	// Parse qualification rules
-	var unitTypes []string
-	var floors []int64
-	var entrances []int64
-
-	if gathering.QualificationUnitTypes.Valid {
-		json.Unmarshal([]byte(gathering.QualificationUnitTypes.String), &unitTypes)
-	}
-	if gathering.QualificationFloors.Valid {
-		json.Unmarshal([]byte(gathering.QualificationFloors.String), &floors)
-	}
-	if gathering.QualificationEntrances.Valid {
-		json.Unmarshal([]byte(gathering.QualificationEntrances.String), &entrances)
-	}
-
-	// Get qualified units
-	units, err := s.db.GetQualifiedUnits(ctx, database.GetQualifiedUnitsParams{
-		AssociationID: associationID,
-		Column2:       len(unitTypes) > 0,
-		UnitTypes:     unitTypes,
-		Column4:       len(floors) > 0,
-		UnitFloors:    floors,
-		Column6:       len(entrances) > 0,
-		UnitEntrances: entrances,
-	})
-
+	// Qualified units come from the frozen register once the gathering is published
+	units, err := NewVoterRegisterService(s.db).Register(ctx, gathering)
 	if err != nil {
+		logging.Logger.Log(zap.WarnLevel, "Error getting voter register", zap.Error(err))
 		return 0, 0.0, 0.0
 	}
 
@@ -112,6 +90,62 @@ func (s *StatsService) UpdateGatheringParticipationStats(gatheringID, associatio
 	})
 }
 
+// LiveParticipation computes the current participation of a gathering from unit slots,
+// check-ins and valid ballots
+func (s *StatsService) LiveParticipation(ctx context.Context, dbGathering database.Gathering) (domain.LiveParticipation, error) {
+	gathering := domain.DBGatheringToResponse(dbGathering)
+
+	participatingStats, err := s.db.GetParticipatingUnitsStats(ctx, gathering.ID)
+	if err != nil {
+		return domain.LiveParticipation{}, fmt.Errorf("failed to get participating stats: %w", err)
+	}
+	votedStats, err := s.db.GetVotedUnitsStats(ctx, gathering.ID)
+	if err != nil {
+		return domain.LiveParticipation{}, fmt.Errorf("failed to get voted stats: %w", err)
+	}
+	participants, err := s.db.GetGatheringParticipants(ctx, gathering.ID)
+	if err != nil {
+		return domain.LiveParticipation{}, fmt.Errorf("failed to get participants: %w", err)
+	}
+	ballots, err := s.db.GetGatheringBallotCount(ctx, gathering.ID)
+	if err != nil {
+		return domain.LiveParticipation{}, fmt.Errorf("failed to count ballots: %w", err)
+	}
+
+	summary := domain.GatheringSummary{
+		QualifiedUnits:      gathering.QualifiedUnitsCount,
+		QualifiedWeight:     gathering.QualifiedUnitsTotalPart,
+		QualifiedArea:       gathering.QualifiedUnitsTotalArea,
+		ParticipatingUnits:  int(participatingStats.ParticipatingUnitsCount),
+		ParticipatingWeight: RoundTo3Decimals(sqliteFloat(participatingStats.ParticipatingUnitsTotalPart)),
+		ParticipatingArea:   RoundTo3Decimals(sqliteFloat(participatingStats.ParticipatingUnitsTotalArea)),
+		VotedUnits:          int(votedStats.VotedUnitsCount),
+		VotedWeight:         RoundTo3Decimals(sqliteFloat(votedStats.VotedUnitsTotalPart)),
+		VotedArea:           RoundTo3Decimals(sqliteFloat(votedStats.VotedUnitsTotalArea)),
+		VotingMode:          gathering.VotingMode,
+	}
+	if summary.QualifiedUnits > 0 {
+		summary.ParticipationRate = float64(summary.ParticipatingUnits) / float64(summary.QualifiedUnits) * 100
+	}
+	if summary.ParticipatingUnits > 0 {
+		summary.VotingCompletionRate = float64(summary.VotedUnits) / float64(summary.ParticipatingUnits) * 100
+	}
+
+	checkedIn := 0
+	for _, participant := range participants {
+		if participant.CheckInTime.Valid {
+			checkedIn++
+		}
+	}
+
+	return domain.LiveParticipation{
+		GatheringSummary: summary,
+		Participants:     len(participants),
+		CheckedIn:        checkedIn,
+		Ballots:          int(ballots),
+	}, nil
+}
+
 // CalculateFinalResults finalizes all tallies and participation stats
 func (s *StatsService) CalculateFinalResults(gatheringID, associationID int64, tallyService *TallyService) {
 	// Update participation stats with final unit counts
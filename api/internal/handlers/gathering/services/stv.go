@@ -0,0 +1,154 @@
+package services
+
+import (
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// RankingMethodSTV is the single transferable vote count used for multi-seat elections
+const RankingMethodSTV = "stv"
+
+// stvBallot tracks the part of a ranked ballot's weight that is still transferable
+type stvBallot struct {
+	RankedBallot
+	value float64 // Fraction of the weight still carried by the ballot
+}
+
+// TallySTV fills the given number of seats with a single transferable vote count.
+//
+// The quota is the Droop quota in vote weight: the weight of all ranked ballots divided by
+// seats+1. Each round either elects the strongest candidate above the quota, passing the
+// surplus on to the next preferences at a reduced value (every ballot of the candidate
+// transfers a share of surplus/votes), or eliminates the weakest candidate and passes its
+// ballots on at their current value. Once the continuing candidates are no more than the
+// open seats, they are all elected.
+//
+// The returned tally holds the first-preference votes of each candidate.
+func TallySTV(options []string, ballots []RankedBallot, seats int) (map[string]domain.TallyResult, *domain.RankingBreakdown) {
+	breakdown := &domain.RankingBreakdown{Method: RankingMethodSTV, Seats: seats, BallotCount: len(ballots)}
+
+	papers := make([]*stvBallot, len(ballots))
+	for i, ballot := range ballots {
+		papers[i] = &stvBallot{RankedBallot: ballot, value: 1}
+		breakdown.BallotWeight += ballot.Weight
+	}
+	if seats > 0 {
+		breakdown.Quota = breakdown.BallotWeight / float64(seats+1)
+	}
+
+	continuing := make(map[string]bool, len(options))
+	for _, opt := range options {
+		continuing[opt] = true
+	}
+
+	var eliminatedOrder []string
+	var lastTally map[string]domain.TallyResult
+
+	for round := 1; len(breakdown.Elected) < seats && len(continuing) > 0; round++ {
+		roundTally := make(map[string]domain.TallyResult, len(continuing))
+		for opt := range continuing {
+			roundTally[opt] = domain.TallyResult{}
+		}
+		var exhausted domain.TallyResult
+		for _, paper := range papers {
+			w := paper.Weight * paper.value
+			if opt, ok := topContinuing(paper.Preferences, continuing); ok {
+				t := roundTally[opt]
+				roundTally[opt] = domain.TallyResult{Count: t.Count + 1, Weight: t.Weight + w}
+			} else {
+				exhausted = domain.TallyResult{Count: exhausted.Count + 1, Weight: exhausted.Weight + w}
+			}
+		}
+
+		current := domain.RankingRound{Round: round, Tally: roundTally, Exhausted: exhausted}
+		lastTally = roundTally
+
+		// Remaining candidates fill the remaining seats without further transfers
+		if len(continuing) <= seats-len(breakdown.Elected) {
+			var standing []string
+			for _, opt := range options {
+				if continuing[opt] {
+					standing = append(standing, opt)
+				}
+			}
+			current.Elected = orderByScore(standing, func(opt string) float64 { return roundTally[opt].Weight })
+			breakdown.Elected = append(breakdown.Elected, current.Elected...)
+			breakdown.Rounds = append(breakdown.Rounds, current)
+			for _, opt := range standing {
+				delete(continuing, opt)
+			}
+			break
+		}
+
+		leader, leaderWeight := "", -1.0
+		for _, opt := range options {
+			if t, ok := roundTally[opt]; ok && t.Weight > leaderWeight {
+				leader, leaderWeight = opt, t.Weight
+			}
+		}
+
+		var moved string
+		factor := 1.0
+		if leaderWeight > breakdown.Quota {
+			// Elect the leader; its ballots pass on the surplus at a reduced value
+			factor = (leaderWeight - breakdown.Quota) / leaderWeight
+			current.Elected = []string{leader}
+			breakdown.Elected = append(breakdown.Elected, leader)
+			moved = leader
+		} else {
+			loser, tieBroken := weakestOption(options, roundTally, breakdown.Rounds)
+			current.Eliminated = []string{loser}
+			current.TieBroken = tieBroken
+			eliminatedOrder = append(eliminatedOrder, loser)
+			moved = loser
+		}
+
+		var pile []*stvBallot
+		for _, paper := range papers {
+			if opt, ok := topContinuing(paper.Preferences, continuing); ok && opt == moved {
+				pile = append(pile, paper)
+			}
+		}
+		delete(continuing, moved)
+
+		// Pass the candidate's ballots on and record where they go
+		current.Transfers = make(map[string]domain.TallyResult)
+		var notTransferred domain.TallyResult
+		for _, paper := range pile {
+			paper.value *= factor
+			w := paper.Weight * paper.value
+			if next, ok := topContinuing(paper.Preferences, continuing); ok {
+				t := current.Transfers[next]
+				current.Transfers[next] = domain.TallyResult{Count: t.Count + 1, Weight: t.Weight + w}
+			} else {
+				notTransferred = domain.TallyResult{Count: notTransferred.Count + 1, Weight: notTransferred.Weight + w}
+			}
+		}
+		if notTransferred.Count > 0 {
+			current.TransferExhausted = &notTransferred
+		}
+
+		breakdown.Rounds = append(breakdown.Rounds, current)
+	}
+
+	// Order: elected in order of election, then unelected candidates still standing, then eliminated latest first
+	order := append([]string(nil), breakdown.Elected...)
+	var standing []string
+	for _, opt := range options {
+		if continuing[opt] {
+			standing = append(standing, opt)
+		}
+	}
+	order = append(order, orderByScore(standing, func(opt string) float64 { return lastTally[opt].Weight })...)
+	for i := len(eliminatedOrder) - 1; i >= 0; i-- {
+		order = append(order, eliminatedOrder[i])
+	}
+	breakdown.Order = order
+
+	tally := emptyTally(options)
+	if len(breakdown.Rounds) > 0 {
+		for opt, t := range breakdown.Rounds[0].Tally {
+			tally[opt] = t
+		}
+	}
+	return tally, breakdown
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// TestTallySTV tests multi-seat elections counted by single transferable vote
+func TestTallySTV(t *testing.T) {
+	tests := []struct {
+		name            string
+		options         []string
+		seats           int
+		ballots         []RankedBallot
+		expectedElected []string
+		expectedOrder   []string
+		expectedRounds  int
+	}{
+		{
+			name:    "surplus and elimination transfers",
+			options: []string{"a", "b", "c", "d"},
+			seats:   2,
+			ballots: []RankedBallot{
+				{Preferences: []string{"a", "b"}, Weight: 6},
+				{Preferences: []string{"c"}, Weight: 2},
+				{Preferences: []string{"d", "c"}, Weight: 2},
+			},
+			expectedElected: []string{"a", "c"},
+			expectedOrder:   []string{"a", "c", "b", "d"},
+			expectedRounds:  3,
+		},
+		{
+			name:    "unit parts outweigh the number of voters",
+			options: []string{"a", "b", "c"},
+			seats:   2,
+			ballots: []RankedBallot{
+				{Preferences: []string{"a"}, Weight: 0.5},
+				{Preferences: []string{"b"}, Weight: 0.1},
+				{Preferences: []string{"b"}, Weight: 0.1},
+				{Preferences: []string{"c"}, Weight: 0.3},
+			},
+			expectedElected: []string{"a", "c"},
+			expectedOrder:   []string{"a", "c", "b"},
+			expectedRounds:  3,
+		},
+		{
+			name:    "remaining candidates fill the remaining seats",
+			options: []string{"a", "b", "c"},
+			seats:   2,
+			ballots: []RankedBallot{
+				{Preferences: []string{"a"}, Weight: 1},
+				{Preferences: []string{"b"}, Weight: 1},
+				{Preferences: []string{"c"}, Weight: 1},
+			},
+			expectedElected: []string{"a", "b"},
+			expectedOrder:   []string{"a", "b", "c"},
+			expectedRounds:  2,
+		},
+		{
+			name:    "as many seats as candidates",
+			options: []string{"a", "b"},
+			seats:   2,
+			ballots: []RankedBallot{
+				{Preferences: []string{"b", "a"}, Weight: 2},
+				{Preferences: []string{"a"}, Weight: 1},
+			},
+			expectedElected: []string{"b", "a"},
+			expectedOrder:   []string{"b", "a"},
+			expectedRounds:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, breakdown := TallySTV(tt.options, tt.ballots, tt.seats)
+
+			if !reflect.DeepEqual(breakdown.Elected, tt.expectedElected) {
+				t.Errorf("Elected = %v, expected %v", breakdown.Elected, tt.expectedElected)
+			}
+			if !reflect.DeepEqual(breakdown.Order, tt.expectedOrder) {
+				t.Errorf("Order = %v, expected %v", breakdown.Order, tt.expectedOrder)
+			}
+			if len(breakdown.Rounds) != tt.expectedRounds {
+				t.Errorf("got %d rounds, expected %d", len(breakdown.Rounds), tt.expectedRounds)
+			}
+		})
+	}
+}
+
+// TestTallySTVTransfers tests the quota and the transfer table of each round
+func TestTallySTVTransfers(t *testing.T) {
+	ballots := []RankedBallot{
+		{Preferences: []string{"a", "b"}, Weight: 6},
+		{Preferences: []string{"c"}, Weight: 2},
+		{Preferences: []string{"d", "c"}, Weight: 2},
+	}
+	tally, breakdown := TallySTV([]string{"a", "b", "c", "d"}, ballots, 2)
+
+	if math.Abs(breakdown.Quota-10.0/3) > 1e-9 {
+		t.Errorf("Quota = %v, expected %v", breakdown.Quota, 10.0/3)
+	}
+	if tally["a"].Weight != 6 {
+		t.Errorf("first preferences for a = %v, expected 6", tally["a"].Weight)
+	}
+
+	// Round 1: a is elected and passes its surplus of 6 - 10/3 on to b
+	if got := breakdown.Rounds[0].Transfers["b"].Weight; math.Abs(got-(6-10.0/3)) > 1e-9 {
+		t.Errorf("round 1 transfer to b = %v, expected %v", got, 6-10.0/3)
+	}
+	// Round 2: c and d are tied, d is eliminated and its ballot moves to c at full value
+	second := breakdown.Rounds[1]
+	if !reflect.DeepEqual(second.Eliminated, []string{"d"}) || !second.TieBroken {
+		t.Errorf("round 2: eliminated %v (tie broken %v), expected [d] with tie break", second.Eliminated, second.TieBroken)
+	}
+	if got := second.Transfers["c"]; got.Weight != 2 || got.Count != 1 {
+		t.Errorf("round 2 transfer to c = %+v, expected 1 ballot of weight 2", got)
+	}
+	if second.TransferExhausted != nil {
+		t.Errorf("round 2 exhausted transfer = %+v, expected none", second.TransferExhausted)
+	}
+}
+
+// TestValidateVotingConfig tests the ranking settings accepted for voting matters
+func TestValidateVotingConfig(t *testing.T) {
+	candidates := []domain.VotingOption{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+
+	tests := []struct {
+		name        string
+		matterType  string
+		config      domain.VotingConfig
+		expectError bool
+	}{
+		{"default ranking", "policy", domain.VotingConfig{Type: "ranking", Options: candidates}, false},
+		{"unknown ranking method", "policy", domain.VotingConfig{Type: "ranking", RankingMethod: "coombs"}, true},
+		{"multi-seat election", "election", domain.VotingConfig{Type: "ranking", Options: candidates, Seats: 2}, false},
+		{"seats outside elections", "policy", domain.VotingConfig{Type: "ranking", Options: candidates, Seats: 2}, true},
+		{"seats without ranking", "election", domain.VotingConfig{Type: "multiple_choice", Options: candidates, Seats: 2}, true},
+		{"as many seats as candidates", "election", domain.VotingConfig{Type: "ranking", Options: candidates, Seats: 3}, false},
+		{"more seats than candidates", "election", domain.VotingConfig{Type: "ranking", Options: candidates, Seats: 4}, true},
+		{"negative seats", "election", domain.VotingConfig{Type: "ranking", Options: candidates, Seats: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateVotingConfig(tt.matterType, tt.config)
+			if (err != nil) != tt.expectError {
+				t.Errorf("ValidateVotingConfig() error = %v, expectError %v", err, tt.expectError)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"strconv"
 
@@ -46,9 +47,92 @@ func (s *TallyService) UpdateVoteTallies(gatheringID int64, participantID int) {
 		return
 	}
 
+	gathering, err := s.db.GetGatheringByID(ctx, gatheringID)
+	if err != nil {
+		logging.Logger.Log(zap.ErrorLevel, "Failed to get gathering for tally update",
+			zap.Int64("gathering_id", gatheringID), zap.Error(err))
+		return
+	}
+
+	// Choices on anonymous matters are not on the ballots
+	anonymousTotals, err := s.db.GetAnonymousVoteTotals(ctx, gatheringID)
+	if err != nil {
+		logging.Logger.Log(zap.ErrorLevel, "Failed to get anonymous votes for tally update",
+			zap.Int64("gathering_id", gatheringID), zap.Error(err))
+		return
+	}
+
 	participantWeights := make(map[int64]float64)
+	participantUnits := make(map[int64]float64)
 	for _, p := range participants {
 		participantWeights[p.ID] = p.UnitsPart
+		participantUnits[p.ID] = float64(len(domain.DBParticipantRowToResponse(p).UnitsInfo))
+	}
+
+	// Valid ballots by participant, in the order they were cast
+	ballotContents := make(map[int64]map[string]domain.BallotVote)
+	var voters []int64
+	for _, ballot := range ballots {
+		if !ballot.IsValid.Bool {
+			continue
+		}
+
+		var ballotContent map[string]domain.BallotVote
+		if err := json.Unmarshal([]byte(ballot.BallotContent), &ballotContent); err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Failed to unmarshal ballot content",
+				zap.Int64("ballot_id", ballot.ID), zap.Error(err))
+			continue
+		}
+		ballotContents[ballot.ParticipantID] = ballotContent
+		voters = append(voters, ballot.ParticipantID)
+	}
+
+	// Collect the votes cast on each matter, from ballots and from the anonymous store
+	matterVotes := make(map[int64][]weightedVote)
+
+	// Under the agreement policy co-owners do not vote their shares, their unit votes whole when
+	// they all chose alike. Anonymous choices cannot be compared and count with the shares cast.
+	if gathering.CoOwnershipPolicy == CoOwnershipAgreement {
+		slots, err := s.db.GetCoOwnerUnitSlots(ctx, gatheringID)
+		if err != nil {
+			logging.Logger.Log(zap.ErrorLevel, "Failed to get co-owner unit slots for tally update",
+				zap.Int64("gathering_id", gatheringID), zap.Error(err))
+			return
+		}
+		withheld, agreed := AgreedVotes(slots, ballotContents)
+		for participantID, weight := range withheld {
+			participantWeights[participantID] -= weight
+			// Participants voting only for co-owned units have no say of their own
+			if participantWeights[participantID] <= shareTolerance {
+				delete(ballotContents, participantID)
+			}
+		}
+		for matterIDStr, votes := range agreed {
+			if matterID, err := strconv.ParseInt(matterIDStr, 10, 64); err == nil {
+				matterVotes[matterID] = append(matterVotes[matterID], votes...)
+			}
+		}
+	}
+
+	for _, participantID := range voters {
+		ballotContent, ok := ballotContents[participantID]
+		if !ok {
+			continue
+		}
+		for matterIDStr, vote := range ballotContent {
+			matterID, err := strconv.ParseInt(matterIDStr, 10, 64)
+			if err != nil || len(vote.Values) == 0 {
+				continue
+			}
+			matterVotes[matterID] = append(matterVotes[matterID], weightedVote{
+				Values: vote.Values,
+				Weight: participantWeights[participantID],
+				Units:  participantUnits[participantID],
+			})
+		}
+	}
+	for _, total := range anonymousTotals {
+		matterVotes[total.VotingMatterID] = append(matterVotes[total.VotingMatterID], anonymousVotes(total)...)
 	}
 
 	for _, matter := range matters {
@@ -60,26 +144,10 @@ func (s *TallyService) UpdateVoteTallies(gatheringID int64, participantID int) {
 		}
 
 		tally := initTally(votingConfig)
+		var rankedBallots []RankedBallot
 
-		for _, ballot := range ballots {
-			if !ballot.IsValid.Bool {
-				continue
-			}
-
-			weight := participantWeights[ballot.ParticipantID]
-
-			var ballotContent map[string]domain.BallotVote
-			if err := json.Unmarshal([]byte(ballot.BallotContent), &ballotContent); err != nil {
-				logging.Logger.Log(zap.WarnLevel, "Failed to unmarshal ballot content",
-					zap.Int64("ballot_id", ballot.ID), zap.Error(err))
-				continue
-			}
-
-			matterIDStr := strconv.FormatInt(matter.ID, 10)
-			vote, ok := ballotContent[matterIDStr]
-			if !ok || len(vote.Values) == 0 {
-				continue
-			}
+		for _, vote := range matterVotes[matter.ID] {
+			weight := vote.Weight
 
 			switch votingConfig.Type {
 			case "yes_no", "single_choice":
@@ -96,18 +164,35 @@ func (s *TallyService) UpdateVoteTallies(gatheringID int64, participantID int) {
 				}
 
 			case "ranking":
-				// Borda count: first choice gets N-1 points, last gets 0.
-				// IRV and first-choice plurality are alternative methods not used here.
-				n := len(vote.Values)
-				for i, optID := range vote.Values {
-					points := n - 1 - i
-					if t, exists := tally[optID]; exists {
-						tally[optID] = domain.TallyResult{
-							Count:  t.Count + points,
-							Weight: t.Weight + weight*float64(points),
-						}
+				// Rankings are counted once all ballots are collected
+				if vote.Values[0] == abstainValue {
+					if t, exists := tally[abstainValue]; exists {
+						tally[abstainValue] = domain.TallyResult{Count: t.Count + 1, Weight: t.Weight + weight}
 					}
+					continue
+				}
+				// Rankings respect the voting mode, weighing each ballot by unit count in by_unit mode
+				if gathering.VotingMode == "by_unit" {
+					weight = vote.Units
 				}
+				rankedBallots = append(rankedBallots, RankedBallot{Preferences: vote.Values, Weight: weight})
+			}
+		}
+
+		var breakdown *domain.RankingBreakdown
+		if votingConfig.Type == "ranking" {
+			optionIDs := make([]string, len(votingConfig.Options))
+			for i, opt := range votingConfig.Options {
+				optionIDs[i] = opt.ID
+			}
+			var rankingTally map[string]domain.TallyResult
+			if IsMultiSeatElection(matter.MatterType, votingConfig) {
+				rankingTally, breakdown = TallySTV(optionIDs, rankedBallots, votingConfig.Seats)
+			} else {
+				rankingTally, breakdown = TallyRanking(votingConfig.RankingMethod, optionIDs, rankedBallots)
+			}
+			for optID, t := range rankingTally {
+				tally[optID] = t
 			}
 		}
 
@@ -142,10 +227,22 @@ func (s *TallyService) UpdateVoteTallies(gatheringID int64, participantID int) {
 			continue
 		}
 
+		var breakdownData sql.NullString
+		if breakdown != nil {
+			breakdownJSON, err := json.Marshal(breakdown)
+			if err != nil {
+				logging.Logger.Log(zap.ErrorLevel, "Failed to marshal tally breakdown",
+					zap.Int64("matter_id", matter.ID), zap.Error(err))
+			} else {
+				breakdownData = sql.NullString{String: string(breakdownJSON), Valid: true}
+			}
+		}
+
 		_, err = s.db.UpsertVoteTally(ctx, database.UpsertVoteTallyParams{
 			GatheringID:    gatheringID,
 			VotingMatterID: matter.ID,
 			TallyData:      string(tallyJSON),
+			BreakdownData:  breakdownData,
 		})
 		if err != nil {
 			logging.Logger.Log(zap.ErrorLevel, "Failed to upsert vote tally",
@@ -157,6 +254,34 @@ func (s *TallyService) UpdateVoteTallies(gatheringID int64, participantID int) {
 		zap.Int64("gathering_id", gatheringID), zap.Int("matter_count", len(matters)))
 }
 
+// weightedVote is the selection made on a matter, the unit part it carries and the number of
+// units it is cast for
+type weightedVote struct {
+	Values []string
+	Weight float64
+	Units  float64
+}
+
+// anonymousVotes splits the total of the voters who made the same anonymous choice into one vote
+// each, sharing the total unit part and unit count evenly. Every count treats identical
+// selections alike, so the results match those of the votes as cast.
+func anonymousVotes(total database.AnonymousVoteTotal) []weightedVote {
+	var values []string
+	if err := json.Unmarshal([]byte(total.VoteValues), &values); err != nil || len(values) == 0 || total.Votes <= 0 {
+		return nil
+	}
+
+	votes := make([]weightedVote, total.Votes)
+	for i := range votes {
+		votes[i] = weightedVote{
+			Values: values,
+			Weight: total.UnitsPart / float64(total.Votes),
+			Units:  float64(total.UnitsCount) / float64(total.Votes),
+		}
+	}
+	return votes
+}
+
 func initTally(config domain.VotingConfig) map[string]domain.TallyResult {
 	tally := make(map[string]domain.TallyResult)
 	switch config.Type {
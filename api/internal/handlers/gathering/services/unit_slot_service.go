@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/qualification"
 	"github.com/alexmarian/apc/api/internal/logging"
 	"go.uber.org/zap"
 )
@@ -19,7 +20,7 @@ func NewUnitSlotService(db *database.Queries) *UnitSlotService {
 }
 
 // SyncUnitsSlots creates unit slots for all qualified units in a gathering
-func (s *UnitSlotService) SyncUnitsSlots(ctx context.Context, associationID int64, gatheringID int64, unitTypes []string, floors []int64, entrances []int64) error {
+func (s *UnitSlotService) SyncUnitsSlots(ctx context.Context, associationID int64, gatheringID int64, unitTypes []string, floors []int64, entrances []int64, rule *qualification.Rule) error {
 	units, err := s.db.GetQualifiedUnits(ctx, database.GetQualifiedUnitsParams{
 		AssociationID: associationID,
 		Column2:       len(unitTypes) > 0,
@@ -34,7 +35,7 @@ func (s *UnitSlotService) SyncUnitsSlots(ctx context.Context, associationID int6
 		return err
 	}
 
-	for _, unit := range units {
+	for _, unit := range FilterQualifiedUnits(units, rule) {
 		_, err := s.db.CreateUnitSlot(ctx, database.CreateUnitSlotParams{
 			GatheringID: gatheringID,
 			UnitID:      unit.ID,
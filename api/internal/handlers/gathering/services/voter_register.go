@@ -0,0 +1,522 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// ErrRegisterNotFrozen is returned when a gathering's frozen register is requested before publication
+var ErrRegisterNotFrozen = errors.New("voter register is frozen when the gathering is published")
+
+// VoterRegisterService builds and freezes the register of units and owners entitled to vote in a gathering
+type VoterRegisterService struct {
+	db *database.Queries
+}
+
+// NewVoterRegisterService creates a new VoterRegisterService
+func NewVoterRegisterService(db *database.Queries) *VoterRegisterService {
+	return &VoterRegisterService{db: db}
+}
+
+// IsRegisterFrozen reports whether the gathering reads its frozen register rather than the live one.
+// A gathering moved back to draft is live again until it is published anew.
+func IsRegisterFrozen(gathering database.Gathering) bool {
+	return gathering.RegisterFrozenAt.Valid && gathering.Status != GatheringStatusDraft
+}
+
+// Register returns the units qualified to vote in the gathering with their owners:
+// the register frozen at publication, or the live register while the gathering is a draft
+func (s *VoterRegisterService) Register(ctx context.Context, gathering database.Gathering) ([]domain.RegisterUnit, error) {
+	if IsRegisterFrozen(gathering) {
+		return s.Frozen(ctx, gathering.ID)
+	}
+	return s.Live(ctx, gathering)
+}
+
+// Live computes the register from the association's current units and ownerships
+func (s *VoterRegisterService) Live(ctx context.Context, gathering database.Gathering) ([]domain.RegisterUnit, error) {
+	criteria := domain.DBGatheringToResponse(gathering)
+	units, err := s.db.GetQualifiedUnits(ctx, database.GetQualifiedUnitsParams{
+		AssociationID: gathering.AssociationID,
+		Column2:       len(criteria.QualificationUnitTypes) > 0,
+		UnitTypes:     criteria.QualificationUnitTypes,
+		Column4:       len(criteria.QualificationFloors) > 0,
+		UnitFloors:    criteria.QualificationFloors,
+		Column6:       len(criteria.QualificationEntrances) > 0,
+		UnitEntrances: criteria.QualificationEntrances,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get qualified units: %w", err)
+	}
+	ownerships, err := s.db.GetActiveOwnershipsForAssociation(ctx, gathering.AssociationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ownerships: %w", err)
+	}
+
+	owners := make(map[int64][]domain.RegisterOwner)
+	for _, o := range ownerships {
+		owners[o.UnitID] = append(owners[o.UnitID], domain.RegisterOwner{
+			OwnerID:        o.OwnerID,
+			OwnershipID:    o.OwnershipID,
+			Name:           o.OwnerName,
+			Identification: o.OwnerIdentification,
+			ContactEmail:   o.OwnerContactEmail,
+			ContactPhone:   o.OwnerContactPhone,
+			IsVoting:       o.IsVoting,
+			Share:          o.Share,
+		})
+	}
+
+	register := make([]domain.RegisterUnit, 0, len(units))
+	for _, u := range FilterQualifiedUnits(units, GatheringQualificationRule(gathering)) {
+		register = append(register, domain.RegisterUnit{
+			UnitID:          u.ID,
+			UnitNumber:      u.UnitNumber,
+			CadastralNumber: u.CadastralNumber,
+			UnitType:        u.UnitType,
+			Floor:           u.Floor,
+			Entrance:        u.Entrance,
+			Area:            u.Area,
+			Part:            u.Part,
+			BuildingName:    u.BuildingName,
+			BuildingAddress: u.BuildingAddress,
+			Owners:          ownersOf(owners, u.ID),
+		})
+	}
+	return register, nil
+}
+
+// Frozen loads the register frozen when the gathering was published
+func (s *VoterRegisterService) Frozen(ctx context.Context, gatheringID int64) ([]domain.RegisterUnit, error) {
+	units, err := s.db.GetVoterRegisterUnits(ctx, gatheringID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get register units: %w", err)
+	}
+	registerOwners, err := s.db.GetVoterRegisterOwners(ctx, gatheringID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get register owners: %w", err)
+	}
+
+	owners := make(map[int64][]domain.RegisterOwner)
+	for _, o := range registerOwners {
+		owners[o.UnitID] = append(owners[o.UnitID], domain.RegisterOwner{
+			OwnerID:        o.OwnerID,
+			OwnershipID:    o.OwnershipID,
+			Name:           o.OwnerName,
+			Identification: o.OwnerIdentification,
+			ContactEmail:   o.OwnerContactEmail,
+			ContactPhone:   o.OwnerContactPhone,
+			IsVoting:       o.IsVoting,
+			Share:          o.Share,
+		})
+	}
+
+	register := make([]domain.RegisterUnit, len(units))
+	for i, u := range units {
+		register[i] = domain.RegisterUnit{
+			UnitID:          u.UnitID,
+			UnitNumber:      u.UnitNumber,
+			CadastralNumber: u.CadastralNumber,
+			UnitType:        u.UnitType,
+			Floor:           u.Floor,
+			Entrance:        u.Entrance,
+			Area:            u.Area,
+			Part:            u.Part,
+			BuildingName:    u.BuildingName,
+			BuildingAddress: u.BuildingAddress,
+			Owners:          ownersOf(owners, u.UnitID),
+		}
+	}
+	return register, nil
+}
+
+// ownersOf returns a unit's owners, empty rather than nil for a unit nobody owns
+func ownersOf(owners map[int64][]domain.RegisterOwner, unitID int64) []domain.RegisterOwner {
+	if unitOwners, ok := owners[unitID]; ok {
+		return unitOwners
+	}
+	return []domain.RegisterOwner{}
+}
+
+// Freeze snapshots the live register for the gathering, replacing any earlier snapshot.
+// Unit slots and qualified totals are brought in line with the snapshot.
+func (s *VoterRegisterService) Freeze(ctx context.Context, gathering database.Gathering) error {
+	register, err := s.Live(ctx, gathering)
+	if err != nil {
+		return err
+	}
+
+	// A gathering published, moved back to draft and published again is frozen anew
+	if err := s.db.DeleteVoterRegisterOwners(ctx, gathering.ID); err != nil {
+		return fmt.Errorf("failed to clear register owners: %w", err)
+	}
+	if err := s.db.DeleteVoterRegisterUnits(ctx, gathering.ID); err != nil {
+		return fmt.Errorf("failed to clear register units: %w", err)
+	}
+
+	totalPart, totalArea := 0.0, 0.0
+	for _, u := range register {
+		err := s.db.CreateVoterRegisterUnit(ctx, database.CreateVoterRegisterUnitParams{
+			GatheringID:     gathering.ID,
+			UnitID:          u.UnitID,
+			UnitNumber:      u.UnitNumber,
+			CadastralNumber: u.CadastralNumber,
+			UnitType:        u.UnitType,
+			Floor:           u.Floor,
+			Entrance:        u.Entrance,
+			Area:            u.Area,
+			Part:            u.Part,
+			BuildingName:    u.BuildingName,
+			BuildingAddress: u.BuildingAddress,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to register unit %d: %w", u.UnitID, err)
+		}
+		for _, o := range u.Owners {
+			err := s.db.CreateVoterRegisterOwner(ctx, database.CreateVoterRegisterOwnerParams{
+				GatheringID:         gathering.ID,
+				UnitID:              u.UnitID,
+				OwnerID:             o.OwnerID,
+				OwnershipID:         o.OwnershipID,
+				OwnerName:           o.Name,
+				OwnerIdentification: o.Identification,
+				OwnerContactEmail:   o.ContactEmail,
+				OwnerContactPhone:   o.ContactPhone,
+				IsVoting:            o.IsVoting,
+				Share:               o.Share,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to register owner %d of unit %d: %w", o.OwnerID, u.UnitID, err)
+			}
+		}
+		totalPart += u.Part
+		totalArea += u.Area
+	}
+
+	if err := s.syncSlots(ctx, gathering, register); err != nil {
+		return err
+	}
+
+	err = s.db.UpdateGatheringStats(ctx, database.UpdateGatheringStatsParams{
+		QualifiedUnitsCount:     sql.NullInt64{Int64: int64(len(register)), Valid: true},
+		QualifiedUnitsTotalPart: sql.NullFloat64{Float64: totalPart, Valid: true},
+		QualifiedUnitsTotalArea: sql.NullFloat64{Float64: totalArea, Valid: true},
+		ID:                      gathering.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update qualified totals: %w", err)
+	}
+
+	err = s.db.SetGatheringRegisterFrozenAt(ctx, database.SetGatheringRegisterFrozenAtParams{
+		RegisterFrozenAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:               gathering.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark register frozen: %w", err)
+	}
+	return nil
+}
+
+// Unfreeze drops the register frozen for a gathering moved back to draft, which reads the live
+// register until it is published again
+func (s *VoterRegisterService) Unfreeze(ctx context.Context, gathering database.Gathering) error {
+	if err := s.db.DeleteVoterRegisterOwners(ctx, gathering.ID); err != nil {
+		return fmt.Errorf("failed to clear register owners: %w", err)
+	}
+	if err := s.db.DeleteVoterRegisterUnits(ctx, gathering.ID); err != nil {
+		return fmt.Errorf("failed to clear register units: %w", err)
+	}
+	err := s.db.SetGatheringRegisterFrozenAt(ctx, database.SetGatheringRegisterFrozenAtParams{
+		RegisterFrozenAt: sql.NullTime{},
+		ID:               gathering.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark register live: %w", err)
+	}
+	return nil
+}
+
+// EligibleVoters lists each owner's units in the gathering's register with the weight they vote
+// and whether their slot is still free. Until the register is frozen the units come from the
+// live register, none of them taken yet.
+func (s *VoterRegisterService) EligibleVoters(ctx context.Context, gathering database.Gathering) ([]database.GetEligibleVotersWithUnitsRow, error) {
+	if IsRegisterFrozen(gathering) {
+		rows, err := s.db.GetEligibleVotersWithUnits(ctx, database.GetEligibleVotersWithUnitsParams{
+			GatheringID:   gathering.ID,
+			AssociationID: gathering.AssociationID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get eligible voters: %w", err)
+		}
+		return rows, nil
+	}
+
+	register, err := s.Live(ctx, gathering)
+	if err != nil {
+		return nil, err
+	}
+	var rows []database.GetEligibleVotersWithUnitsRow
+	for _, u := range register {
+		for _, slot := range UnitSlotsFor(gathering.CoOwnershipPolicy, u) {
+			for _, o := range u.Owners {
+				if (slot.OwnerID != 0 && slot.OwnerID != o.OwnerID) ||
+					(gathering.CoOwnershipPolicy == CoOwnershipDesignated && !o.IsVoting) {
+					continue
+				}
+				rows = append(rows, database.GetEligibleVotersWithUnitsRow{
+					OwnerID:             o.OwnerID,
+					OwnerName:           o.Name,
+					OwnerIdentification: o.Identification,
+					OwnerContactEmail:   o.ContactEmail,
+					OwnerContactPhone:   o.ContactPhone,
+					UnitID:              u.UnitID,
+					UnitNumber:          u.UnitNumber,
+					CadastralNumber:     u.CadastralNumber,
+					Floor:               u.Floor,
+					Entrance:            u.Entrance,
+					Area:                u.Area,
+					VotingWeight:        u.Part * slot.Share,
+					UnitType:            u.UnitType,
+					BuildingName:        u.BuildingName,
+					BuildingAddress:     u.BuildingAddress,
+					Share:               slot.Share,
+					IsAvailable:         1,
+				})
+			}
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].OwnerName != rows[j].OwnerName {
+			return rows[i].OwnerName < rows[j].OwnerName
+		}
+		return rows[i].UnitNumber < rows[j].UnitNumber
+	})
+	return rows, nil
+}
+
+// syncSlots replaces the unassigned unit slots with one slot per registered unit, or one per
+// co-owner when the gathering's co-owners vote their shares
+func (s *VoterRegisterService) syncSlots(ctx context.Context, gathering database.Gathering, register []domain.RegisterUnit) error {
+	if err := s.db.DeleteUnassignedUnitSlots(ctx, gathering.ID); err != nil {
+		return fmt.Errorf("failed to clear unit slots: %w", err)
+	}
+	assigned, err := s.db.GetGatheringUnitSlots(ctx, gathering.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get unit slots: %w", err)
+	}
+	hasSlot := make(map[unitOwner]bool, len(assigned))
+	for _, slot := range assigned {
+		hasSlot[unitOwner{slot.UnitID, slot.OwnerID}] = true
+	}
+	for _, u := range register {
+		for _, slot := range UnitSlotsFor(gathering.CoOwnershipPolicy, u) {
+			// A slot claimed for the whole unit leaves nothing for its co-owners
+			if hasSlot[unitOwner{u.UnitID, 0}] || hasSlot[unitOwner{u.UnitID, slot.OwnerID}] {
+				continue
+			}
+			slot.GatheringID = gathering.ID
+			if _, err := s.db.CreateUnitSlot(ctx, slot); err != nil {
+				return fmt.Errorf("failed to create unit slot for unit %d: %w", u.UnitID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// UnitSlotsFor lays out the slots of a registered unit: a single slot for the whole unit, or a
+// slot for each co-owner's share when co-owners vote their shares
+func UnitSlotsFor(policy string, u domain.RegisterUnit) []database.CreateUnitSlotParams {
+	if !VotesShares(policy) || len(u.Owners) == 0 {
+		return []database.CreateUnitSlotParams{{UnitID: u.UnitID, OwnerID: 0, Share: 1}}
+	}
+	slots := make([]database.CreateUnitSlotParams, len(u.Owners))
+	for i, o := range u.Owners {
+		slots[i] = database.CreateUnitSlotParams{UnitID: u.UnitID, OwnerID: o.OwnerID, Share: o.Share}
+	}
+	return slots
+}
+
+// unitOwner keys a unit slot, the owner is 0 on a slot for the whole unit
+type unitOwner struct {
+	UnitID  int64
+	OwnerID int64
+}
+
+// SlotAvailability tells which unit slots of a gathering are still unassigned
+type SlotAvailability map[unitOwner]bool
+
+// Available reports whether the owner can still vote for the unit: its slot for the whole unit,
+// or the owner's own slot, is unassigned
+func (a SlotAvailability) Available(unitID, ownerID int64) bool {
+	return a[unitOwner{unitID, 0}] || a[unitOwner{unitID, ownerID}]
+}
+
+// HasSlot reports whether the unit has a slot in the gathering, assigned or not
+func (a SlotAvailability) HasSlot(unitID int64) bool {
+	for key := range a {
+		if key.UnitID == unitID {
+			return true
+		}
+	}
+	return false
+}
+
+// Participating reports whether someone took part for the unit, for the whole of it or for a
+// co-owner's share
+func (a SlotAvailability) Participating(unitID int64) bool {
+	for key, available := range a {
+		if key.UnitID == unitID && !available {
+			return true
+		}
+	}
+	return false
+}
+
+// AvailableSlots maps each unit slot in the gathering to whether it is still unassigned
+func (s *VoterRegisterService) AvailableSlots(ctx context.Context, gatheringID int64) (SlotAvailability, error) {
+	slots, err := s.db.GetGatheringUnitSlots(ctx, gatheringID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unit slots: %w", err)
+	}
+	available := make(SlotAvailability, len(slots))
+	for _, slot := range slots {
+		available[unitOwner{slot.UnitID, slot.OwnerID}] = slot.ParticipantID == nil
+	}
+	return available, nil
+}
+
+// Diff compares the frozen register with the live one
+func (s *VoterRegisterService) Diff(ctx context.Context, gathering database.Gathering) (domain.RegisterDiff, error) {
+	if !IsRegisterFrozen(gathering) {
+		return domain.RegisterDiff{}, ErrRegisterNotFrozen
+	}
+	frozen, err := s.Frozen(ctx, gathering.ID)
+	if err != nil {
+		return domain.RegisterDiff{}, err
+	}
+	live, err := s.Live(ctx, gathering)
+	if err != nil {
+		return domain.RegisterDiff{}, err
+	}
+
+	diff := DiffRegisters(frozen, live)
+	diff.GatheringID = gathering.ID
+	diff.FrozenAt = gathering.RegisterFrozenAt.Time
+	return diff, nil
+}
+
+// DiffRegisters lists the units added to, removed from and changed in the live register
+// compared to the frozen one
+func DiffRegisters(frozen, live []domain.RegisterUnit) domain.RegisterDiff {
+	diff := domain.RegisterDiff{
+		Added:   make([]domain.RegisterUnit, 0),
+		Removed: make([]domain.RegisterUnit, 0),
+		Changed: make([]domain.RegisterUnitChange, 0),
+	}
+
+	liveUnits := make(map[int64]domain.RegisterUnit, len(live))
+	for _, u := range live {
+		liveUnits[u.UnitID] = u
+	}
+	frozenUnits := make(map[int64]bool, len(frozen))
+
+	for _, f := range frozen {
+		frozenUnits[f.UnitID] = true
+		l, ok := liveUnits[f.UnitID]
+		if !ok {
+			diff.Removed = append(diff.Removed, f)
+			continue
+		}
+		if fields := changedFields(f, l); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, domain.RegisterUnitChange{
+				UnitID:     f.UnitID,
+				UnitNumber: f.UnitNumber,
+				Fields:     fields,
+				Frozen:     f,
+				Live:       l,
+			})
+		}
+	}
+	for _, l := range live {
+		if !frozenUnits[l.UnitID] {
+			diff.Added = append(diff.Added, l)
+		}
+	}
+
+	diff.InSync = len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0
+	return diff
+}
+
+// changedFields names the register attributes that differ between two versions of a unit
+func changedFields(frozen, live domain.RegisterUnit) []string {
+	var fields []string
+	if frozen.Part != live.Part {
+		fields = append(fields, "part")
+	}
+	if frozen.Area != live.Area {
+		fields = append(fields, "area")
+	}
+	if frozen.UnitType != live.UnitType {
+		fields = append(fields, "unit_type")
+	}
+	if !sameOwners(frozen.Owners, live.Owners) {
+		fields = append(fields, "owners")
+	}
+	if votingOwnerID(frozen) != votingOwnerID(live) {
+		fields = append(fields, "voting_owner")
+	}
+	if !sameShares(frozen.Owners, live.Owners) {
+		fields = append(fields, "shares")
+	}
+	return fields
+}
+
+func sameOwners(a, b []domain.RegisterOwner) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	ids := func(owners []domain.RegisterOwner) []int64 {
+		out := make([]int64, len(owners))
+		for i, o := range owners {
+			out[i] = o.OwnerID
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+		return out
+	}
+	aIDs, bIDs := ids(a), ids(b)
+	for i := range aIDs {
+		if aIDs[i] != bIDs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameShares reports whether the owners of both versions of a unit hold the same shares
+func sameShares(a, b []domain.RegisterOwner) bool {
+	shares := make(map[int64]float64, len(a))
+	for _, o := range a {
+		shares[o.OwnerID] = o.Share
+	}
+	for _, o := range b {
+		if share, ok := shares[o.OwnerID]; ok && math.Abs(share-o.Share) > shareTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+func votingOwnerID(u domain.RegisterUnit) int64 {
+	if owner := u.VotingOwner(); owner != nil {
+		return owner.OwnerID
+	}
+	return 0
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// TestDiffRegisters tests how drift between the frozen and live registers is reported
+func TestDiffRegisters(t *testing.T) {
+	alice := domain.RegisterOwner{OwnerID: 1, Name: "Alice", IsVoting: true}
+	bob := domain.RegisterOwner{OwnerID: 2, Name: "Bob", IsVoting: true}
+	aliceCoOwner := domain.RegisterOwner{OwnerID: 1, Name: "Alice"}
+
+	unit := func(id int64, part float64, owners ...domain.RegisterOwner) domain.RegisterUnit {
+		return domain.RegisterUnit{UnitID: id, UnitNumber: "A", UnitType: "apartment", Area: 50, Part: part, Owners: owners}
+	}
+
+	tests := []struct {
+		name            string
+		frozen          []domain.RegisterUnit
+		live            []domain.RegisterUnit
+		expectedInSync  bool
+		expectedAdded   []int64
+		expectedRemoved []int64
+		expectedFields  map[int64][]string
+	}{
+		{
+			name:           "unchanged register",
+			frozen:         []domain.RegisterUnit{unit(1, 0.5, alice), unit(2, 0.5, bob)},
+			live:           []domain.RegisterUnit{unit(2, 0.5, bob), unit(1, 0.5, alice)},
+			expectedInSync: true,
+			expectedFields: map[int64][]string{},
+		},
+		{
+			name:            "unit added and removed",
+			frozen:          []domain.RegisterUnit{unit(1, 0.5, alice)},
+			live:            []domain.RegisterUnit{unit(2, 0.5, bob)},
+			expectedAdded:   []int64{2},
+			expectedRemoved: []int64{1},
+			expectedFields:  map[int64][]string{},
+		},
+		{
+			name:           "part changed",
+			frozen:         []domain.RegisterUnit{unit(1, 0.5, alice)},
+			live:           []domain.RegisterUnit{unit(1, 0.6, alice)},
+			expectedFields: map[int64][]string{1: {"part"}},
+		},
+		{
+			name:           "unit sold",
+			frozen:         []domain.RegisterUnit{unit(1, 0.5, alice)},
+			live:           []domain.RegisterUnit{unit(1, 0.5, bob)},
+			expectedFields: map[int64][]string{1: {"owners", "voting_owner"}},
+		},
+		{
+			name:           "voting owner changed between co-owners",
+			frozen:         []domain.RegisterUnit{unit(1, 0.5, alice, domain.RegisterOwner{OwnerID: 2, Name: "Bob"})},
+			live:           []domain.RegisterUnit{unit(1, 0.5, bob, aliceCoOwner)},
+			expectedFields: map[int64][]string{1: {"voting_owner"}},
+		},
+		{
+			name: "shares changed between co-owners",
+			frozen: []domain.RegisterUnit{unit(1, 0.5,
+				domain.RegisterOwner{OwnerID: 1, IsVoting: true, Share: 0.5},
+				domain.RegisterOwner{OwnerID: 2, Share: 0.5})},
+			live: []domain.RegisterUnit{unit(1, 0.5,
+				domain.RegisterOwner{OwnerID: 1, IsVoting: true, Share: 0.7},
+				domain.RegisterOwner{OwnerID: 2, Share: 0.3})},
+			expectedFields: map[int64][]string{1: {"shares"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := DiffRegisters(tt.frozen, tt.live)
+
+			if diff.InSync != tt.expectedInSync {
+				t.Errorf("InSync = %v, want %v", diff.InSync, tt.expectedInSync)
+			}
+			if got := registerUnitIDs(diff.Added); !reflect.DeepEqual(got, tt.expectedAdded) {
+				t.Errorf("Added = %v, want %v", got, tt.expectedAdded)
+			}
+			if got := registerUnitIDs(diff.Removed); !reflect.DeepEqual(got, tt.expectedRemoved) {
+				t.Errorf("Removed = %v, want %v", got, tt.expectedRemoved)
+			}
+			fields := make(map[int64][]string)
+			for _, c := range diff.Changed {
+				fields[c.UnitID] = c.Fields
+			}
+			if !reflect.DeepEqual(fields, tt.expectedFields) {
+				t.Errorf("Changed = %v, want %v", fields, tt.expectedFields)
+			}
+		})
+	}
+}
+
+// TestIsRegisterFrozen tests that only published gatherings read the frozen register
+func TestIsRegisterFrozen(t *testing.T) {
+	frozenAt := sql.NullTime{Time: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), Valid: true}
+
+	tests := []struct {
+		name      string
+		gathering database.Gathering
+		expected  bool
+	}{
+		{"draft never published", database.Gathering{Status: GatheringStatusDraft}, false},
+		{"published", database.Gathering{Status: GatheringStatusPublished, RegisterFrozenAt: frozenAt}, true},
+		{"moved back to draft", database.Gathering{Status: GatheringStatusDraft, RegisterFrozenAt: frozenAt}, false},
+		{"active", database.Gathering{Status: GatheringStatusActive, RegisterFrozenAt: frozenAt}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRegisterFrozen(tt.gathering); got != tt.expected {
+				t.Errorf("IsRegisterFrozen() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func registerUnitIDs(units []domain.RegisterUnit) []int64 {
+	var ids []int64
+	for _, u := range units {
+		ids = append(ids, u.UnitID)
+	}
+	return ids
+}
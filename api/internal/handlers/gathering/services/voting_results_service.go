@@ -58,6 +58,16 @@ func (s *VotingResultsService) ComputeAndStoreResults(ctx context.Context, gathe
 		return nil, fmt.Errorf("failed to get tallies: %w", err)
 	}
 
+	// Every recorded ballot, invalidated ones included, goes into the published Merkle root
+	dbBallots, err := s.db.GetBallotChain(ctx, gatheringID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ballots: %w", err)
+	}
+	ballotHashes := make([]string, len(dbBallots))
+	for i, ballot := range dbBallots {
+		ballotHashes[i] = ballot.BallotHash
+	}
+
 	// Get participation stats
 	participatingStats, err := s.db.GetParticipatingUnitsStats(ctx, gatheringID)
 	if err != nil {
@@ -83,16 +93,33 @@ func (s *VotingResultsService) ComputeAndStoreResults(ctx context.Context, gathe
 		strategy,
 	)
 
+	// Who was present when each matter was voted. Each matter has the quorum of those attending
+	// while it took votes, as participants came and went.
+	attendance, err := s.quorumService.MattersAttendance(ctx, dbGathering, dbMatters, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
 	// Build results for each matter
 	var results []domain.VoteMatterResult
 	for _, dbMatter := range dbMatters {
 		matter := domain.DBVotingMatterToResponse(dbMatter)
 
+		matterAttendance := attendance[dbMatter.ID]
+		matterQuorum := s.quorumService.CalculatePresentQuorum(gathering, matterAttendance.Attendance)
+
 		// Find tally for this matter
 		var tallyData map[string]domain.TallyResult
+		var breakdown *domain.RankingBreakdown
 		for _, tally := range dbTallies {
 			if tally.VotingMatterID == matter.ID {
 				json.Unmarshal([]byte(tally.TallyData), &tallyData)
+				if tally.BreakdownData.Valid {
+					breakdown = &domain.RankingBreakdown{}
+					if err := json.Unmarshal([]byte(tally.BreakdownData.String), breakdown); err != nil {
+						breakdown = nil
+					}
+				}
 				break
 			}
 		}
@@ -122,6 +149,11 @@ func (s *VotingResultsService) ComputeAndStoreResults(ctx context.Context, gathe
 			})
 		}
 
+		// Ranking scores are points or wins rather than ballot weight
+		if breakdown != nil {
+			totalVoted = breakdown.BallotWeight
+		}
+
 		// Determine if this matter passed
 		matterResult := domain.VoteMatterResult{
 			MatterID:       matter.ID,
@@ -129,7 +161,9 @@ func (s *VotingResultsService) ComputeAndStoreResults(ctx context.Context, gathe
 			MatterType:     matter.MatterType,
 			VotingConfig:   matter.VotingConfig,
 			Votes:          voteResults,
-			QuorumInfo:     &quorumInfo,
+			QuorumInfo:     &matterQuorum,
+			Breakdown:      breakdown,
+			Attendance:     &matterAttendance,
 			Tally:          tallyData,
 			TotalVoted:     totalVoted,
 			TotalAbstained: totalAbstained,
@@ -196,10 +230,11 @@ func (s *VotingResultsService) ComputeAndStoreResults(ctx context.Context, gathe
 
 	// Build final results structure
 	voteResults := &domain.VoteResults{
-		GatheringID: gatheringID,
-		Results:     results,
-		Summary:     summary,
-		GeneratedAt: time.Now().Format(time.RFC3339),
+		GatheringID:      gatheringID,
+		Results:          results,
+		Summary:          summary,
+		GeneratedAt:      time.Now().Format(time.RFC3339),
+		BallotMerkleRoot: MerkleRoot(ballotHashes),
 	}
 
 	// Cache the results in database
@@ -298,6 +333,7 @@ func (s *VotingResultsService) storeResults(ctx context.Context, gatheringID int
 		TotalPossibleVotesCount:   int64(totalPossibleCount),
 		QuorumThresholdPercentage: quorumInfo.RequiredPercentage,
 		QuorumMet:                 quorumInfo.Met,
+		BallotMerkleRoot:          sql.NullString{String: results.BallotMerkleRoot, Valid: true},
 		GatheringID:               gatheringID,
 	})
 
@@ -312,6 +348,7 @@ func (s *VotingResultsService) storeResults(ctx context.Context, gatheringID int
 			TotalPossibleVotesCount:   int64(totalPossibleCount),
 			QuorumThresholdPercentage: quorumInfo.RequiredPercentage,
 			QuorumMet:                 quorumInfo.Met,
+			BallotMerkleRoot:          sql.NullString{String: results.BallotMerkleRoot, Valid: true},
 		})
 		if err != nil {
 			return fmt.Errorf("failed to create cached results: %w", err)
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/alexmarian/apc/api/internal/database"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+)
+
+// ErrInvalidVotingRules is returned when a voting rules profile holds an unusable threshold
+var ErrInvalidVotingRules = errors.New("invalid voting rules")
+
+// DefaultVotingRules returns the rules that apply when an association has not stored its own profile.
+// Gatherings published before profiles existed are counted with these as well.
+func DefaultVotingRules() domain.VotingRules {
+	return domain.VotingRules{
+		QuorumInitial:             50,
+		QuorumRepeated:            25,
+		QuorumRemote:              100,
+		MajoritySimple:            50,
+		MajorityAbsolute:          50,
+		MajorityAbsoluteTwoThirds: 66.67,
+		MajorityQualified:         66.67,
+		MajorityUnanimous:         100,
+	}
+}
+
+// ValidateVotingRules checks that every threshold is a percentage above zero and that the proxy
+// limit is not negative
+func ValidateVotingRules(rules domain.VotingRules) error {
+	thresholds := []struct {
+		name  string
+		value float64
+	}{
+		{"quorum_initial", rules.QuorumInitial},
+		{"quorum_repeated", rules.QuorumRepeated},
+		{"quorum_remote", rules.QuorumRemote},
+		{"majority_simple", rules.MajoritySimple},
+		{"majority_absolute", rules.MajorityAbsolute},
+		{"majority_absolute_two_thirds", rules.MajorityAbsoluteTwoThirds},
+		{"majority_qualified", rules.MajorityQualified},
+		{"majority_unanimous", rules.MajorityUnanimous},
+	}
+	for _, threshold := range thresholds {
+		if threshold.value <= 0 || threshold.value > 100 {
+			return fmt.Errorf("%w: %s must be above 0 and at most 100", ErrInvalidVotingRules, threshold.name)
+		}
+	}
+	if rules.MaxProxiesPerDelegate < 0 {
+		return fmt.Errorf("%w: max_proxies_per_delegate must be 0 for no limit or above", ErrInvalidVotingRules)
+	}
+	return nil
+}
+
+// GatheringVotingRules returns the rules snapshotted when the gathering was published,
+// or the default rules if it carries no snapshot
+func GatheringVotingRules(gathering database.Gathering) domain.VotingRules {
+	if gathering.VotingRules.Valid {
+		var rules domain.VotingRules
+		if err := json.Unmarshal([]byte(gathering.VotingRules.String), &rules); err == nil {
+			return rules
+		}
+	}
+	return DefaultVotingRules()
+}
+
+// QuorumPercentage returns the quorum required for a gathering type
+func QuorumPercentage(rules domain.VotingRules, gatheringType string) float64 {
+	switch gatheringType {
+	case "repeated":
+		return rules.QuorumRepeated
+	case "remote":
+		return rules.QuorumRemote
+	default:
+		return rules.QuorumInitial
+	}
+}
+
+// VotingRulesService manages association voting rules profiles
+type VotingRulesService struct {
+	db *database.Queries
+}
+
+// NewVotingRulesService creates a new VotingRulesService
+func NewVotingRulesService(db *database.Queries) *VotingRulesService {
+	return &VotingRulesService{db: db}
+}
+
+// GetProfile returns the association's stored profile, or the default rules if it has none
+func (s *VotingRulesService) GetProfile(ctx context.Context, associationID int64) (domain.VotingRulesProfile, error) {
+	profile, err := s.db.GetVotingRulesProfile(ctx, associationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.VotingRulesProfile{
+			AssociationID: associationID,
+			Rules:         DefaultVotingRules(),
+			IsDefault:     true,
+		}, nil
+	}
+	if err != nil {
+		return domain.VotingRulesProfile{}, fmt.Errorf("failed to get voting rules: %w", err)
+	}
+	return dbVotingRulesProfileToResponse(profile), nil
+}
+
+// SaveProfile validates and stores the association's profile. Gatherings that are already
+// published keep the rules they were published with.
+func (s *VotingRulesService) SaveProfile(ctx context.Context, associationID int64, rules domain.VotingRules) (domain.VotingRulesProfile, error) {
+	if err := ValidateVotingRules(rules); err != nil {
+		return domain.VotingRulesProfile{}, err
+	}
+	profile, err := s.db.UpsertVotingRulesProfile(ctx, database.UpsertVotingRulesProfileParams{
+		AssociationID:             associationID,
+		QuorumInitial:             rules.QuorumInitial,
+		QuorumRepeated:            rules.QuorumRepeated,
+		QuorumRemote:              rules.QuorumRemote,
+		MajoritySimple:            rules.MajoritySimple,
+		MajorityAbsolute:          rules.MajorityAbsolute,
+		MajorityAbsoluteTwoThirds: rules.MajorityAbsoluteTwoThirds,
+		MajorityQualified:         rules.MajorityQualified,
+		MajorityUnanimous:         rules.MajorityUnanimous,
+		MaxProxiesPerDelegate:     rules.MaxProxiesPerDelegate,
+	})
+	if err != nil {
+		return domain.VotingRulesProfile{}, fmt.Errorf("failed to save voting rules: %w", err)
+	}
+	return dbVotingRulesProfileToResponse(profile), nil
+}
+
+// Snapshot copies the association's current rules onto the gathering
+func (s *VotingRulesService) Snapshot(ctx context.Context, gathering database.Gathering) error {
+	profile, err := s.GetProfile(ctx, gathering.AssociationID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(profile.Rules)
+	if err != nil {
+		return fmt.Errorf("failed to encode voting rules: %w", err)
+	}
+	err = s.db.SetGatheringVotingRules(ctx, database.SetGatheringVotingRulesParams{
+		VotingRules: sql.NullString{String: string(data), Valid: true},
+		ID:          gathering.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to snapshot voting rules: %w", err)
+	}
+	return nil
+}
+
+func dbVotingRulesProfileToResponse(profile database.VotingRulesProfile) domain.VotingRulesProfile {
+	return domain.VotingRulesProfile{
+		AssociationID: profile.AssociationID,
+		Rules: domain.VotingRules{
+			QuorumInitial:             profile.QuorumInitial,
+			QuorumRepeated:            profile.QuorumRepeated,
+			QuorumRemote:              profile.QuorumRemote,
+			MajoritySimple:            profile.MajoritySimple,
+			MajorityAbsolute:          profile.MajorityAbsolute,
+			MajorityAbsoluteTwoThirds: profile.MajorityAbsoluteTwoThirds,
+			MajorityQualified:         profile.MajorityQualified,
+			MajorityUnanimous:         profile.MajorityUnanimous,
+			MaxProxiesPerDelegate:     profile.MaxProxiesPerDelegate,
+		},
+		UpdatedAt: &profile.UpdatedAt,
+	}
+}
@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"github.com/alexmarian/apc/api/internal/auth"
 	"github.com/alexmarian/apc/api/internal/database"
 	"github.com/alexmarian/apc/api/internal/logging"
+	"github.com/alexmarian/apc/api/notify"
 	"go.uber.org/zap"
 	"net/http"
 	"time"
@@ -18,8 +20,13 @@ const startDateQueryKey = "start_date"
 const endDateQueryKey = "end_date"
 
 type ApiConfig struct {
-	Db     *database.Queries
-	Secret string
+	Db           *database.Queries
+	Conn         *sql.DB // Underlying connection, used to open transactions
+	Secret       string
+	SigningKey   string           // Encrypts the ballot signing keys stored in the database
+	MemberOrigin string           // Where the member app is served, linked to from notifications
+	Mailer       notify.Transport // Delivers notification emails, logged instead when nil
+	SMS          notify.Transport // Delivers text messages, logged instead when nil
 }
 
 type ErrorResponse struct {
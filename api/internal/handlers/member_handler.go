@@ -16,6 +16,10 @@ import (
 
 const MemberTokenPathValue = "memberToken"
 
+// MemberSessionHeader carries the session opened by a verified second factor, for gatherings
+// that require one to submit a ballot
+const MemberSessionHeader = "X-Member-Session"
+
 type memberContextKey string
 
 const memberInvitationKey memberContextKey = "memberInvitation"
@@ -116,9 +120,13 @@ type memberBallotInfo struct {
 	IsValid       bool            `json:"is_valid"`
 }
 
+// EligibleVotersFunc lists the units each owner votes for in a gathering, from its frozen
+// register or, while it is a draft, the live one
+type EligibleVotersFunc func(ctx context.Context, gathering database.Gathering) ([]database.GetEligibleVotersWithUnitsRow, error)
+
 // HandleGetMemberContext is the single token-scoped read endpoint for the member app.
 // Route: GET /v1/api/member/gatherings/{memberToken}
-func HandleGetMemberContext(cfg *ApiConfig) http.HandlerFunc {
+func HandleGetMemberContext(cfg *ApiConfig, eligibleVoters EligibleVotersFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		inv, ok := memberInvitationFromContext(r.Context())
 		if !ok {
@@ -140,10 +148,7 @@ func HandleGetMemberContext(cfg *ApiConfig) http.HandlerFunc {
 			return
 		}
 
-		eligibleRows, err := cfg.Db.GetEligibleVotersWithUnits(r.Context(), database.GetEligibleVotersWithUnitsParams{
-			GatheringID:   inv.GatheringID,
-			AssociationID: gathering.AssociationID,
-		})
+		eligibleRows, err := eligibleVoters(r.Context(), gathering)
 		if err != nil {
 			logging.Logger.Log(zap.WarnLevel, "failed to get eligible units", zap.Error(err))
 			RespondWithError(w, http.StatusInternalServerError, "failed to load units")
@@ -8,6 +8,7 @@ import (
 	"github.com/alexmarian/apc/api/internal/logging"
 	"go.uber.org/zap"
 	"net/http"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -49,12 +50,40 @@ type Ownership struct {
 	EndDate                   time.Time `json:"end_date"`
 	IsActive                  bool      `json:"is_active"`
 	IsVoting                  bool      `json:"is_voting"`
+	Share                     float64   `json:"share"` // Fraction of the unit held, the active ownerships of a unit add up to 1
 	RegistrationDocument      string    `json:"registration_document"`
 	RegistrationDate          time.Time `json:"registration_date"`
 	CreatedAt                 time.Time `json:"created_at"`
 	UpdatedAt                 time.Time `json:"updated_at"`
 }
 
+// CoOwnershipVotersFilter selects how reports weigh co-owned units: designated (the default),
+// split or agreement, as a gathering would
+const CoOwnershipVotersFilter = "co_ownership"
+
+var coOwnershipPolicies = []string{"designated", "split", "agreement"}
+
+// coOwnershipFromRequest reads the co-ownership policy a report is weighed by
+func coOwnershipFromRequest(req *http.Request) (string, bool) {
+	policy := req.URL.Query().Get(CoOwnershipVotersFilter)
+	if policy == "" {
+		return "designated", true
+	}
+	return policy, slices.Contains(coOwnershipPolicies, policy)
+}
+
+// votingPart returns the part of a unit an owner votes with: the whole part for the designated
+// voting owner, nothing for the other owners, or the owner's share of it when co-owners vote their shares
+func votingPart(policy string, part, share float64, isVoting bool) float64 {
+	if policy == "designated" {
+		if isVoting {
+			return part
+		}
+		return 0
+	}
+	return part * share
+}
+
 const UnitTypeVotersFilter = "unit_types"
 const EntranceVotersFilter = "entrances"
 const FloorVotersFilter = "floors"
@@ -273,6 +302,7 @@ func HandleGetUnitOwnership(cfg *ApiConfig) func(http.ResponseWriter, *http.Requ
 			EndDate:              ownership.EndDate.Time,
 			IsActive:             ownership.IsActive,
 			IsVoting:             ownership.IsVoting,
+			Share:                ownership.Share,
 			RegistrationDocument: ownership.RegistrationDocument,
 			CreatedAt:            ownership.CreatedAt.Time,
 			UpdatedAt:            ownership.UpdatedAt.Time,
@@ -323,6 +353,7 @@ func HandleUnitVotingOwnership(cfg *ApiConfig) func(http.ResponseWriter, *http.R
 			EndDate:              ownership.EndDate.Time,
 			IsActive:             ownership.IsActive,
 			IsVoting:             ownership.IsVoting,
+			Share:                ownership.Share,
 			RegistrationDocument: ownership.RegistrationDocument,
 			CreatedAt:            ownership.CreatedAt.Time,
 			UpdatedAt:            ownership.UpdatedAt.Time,
@@ -343,6 +374,7 @@ func HandleCreateUnitOwnership(cfg *ApiConfig) func(http.ResponseWriter, *http.R
 			RegistrationDocument string     `json:"registration_document"`
 			RegistrationDate     time.Time  `json:"registration_date"`
 			ExclusiveOwnership   bool       `json:"is_exclusive"`
+			Share                *float64   `json:"share,omitempty"` // Of a co-owned unit, an equal share of it when omitted
 		}
 
 		if err := decoder.Decode(&ownershipRequest); err != nil {
@@ -367,10 +399,32 @@ func HandleCreateUnitOwnership(cfg *ApiConfig) func(http.ResponseWriter, *http.R
 			return
 		}
 
-		if ownershipRequest.ExclusiveOwnership {
-			for _, ownership := range currentOwnerships {
+		// The new owner's share is taken from the current owners in proportion to theirs
+		share := 1.0
+		if !ownershipRequest.ExclusiveOwnership && len(currentOwnerships) > 0 {
+			share = 1 / float64(len(currentOwnerships)+1)
+			if ownershipRequest.Share != nil {
+				share = *ownershipRequest.Share
+			}
+			if share <= 0 || share >= 1 {
+				RespondWithError(rw, http.StatusBadRequest, "The share of a co-owner must be between 0 and 1")
+				return
+			}
+		}
+
+		tx, err := cfg.Conn.BeginTx(req.Context(), nil)
+		if err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error starting transaction", zap.String("error", err.Error()))
+			RespondWithError(rw, http.StatusInternalServerError, "Failed to create new ownership")
+			return
+		}
+		defer tx.Rollback()
+		qtx := cfg.Db.WithTx(tx)
+
+		for _, ownership := range currentOwnerships {
+			if ownershipRequest.ExclusiveOwnership {
 				now := time.Now()
-				err = cfg.Db.DeactivateOwnership(req.Context(), database.DeactivateOwnershipParams{
+				err = qtx.DeactivateOwnership(req.Context(), database.DeactivateOwnershipParams{
 					ID:      ownership.ID,
 					EndDate: sql.NullTime{Time: now, Valid: true},
 				})
@@ -379,6 +433,17 @@ func HandleCreateUnitOwnership(cfg *ApiConfig) func(http.ResponseWriter, *http.R
 					RespondWithError(rw, http.StatusInternalServerError, "Failed to deactivate current ownership")
 					return
 				}
+				continue
+			}
+			err = qtx.SetOwnershipShare(req.Context(), database.SetOwnershipShareParams{
+				Share:         ownership.Share * (1 - share),
+				ID:            ownership.ID,
+				UnitID:        ownership.UnitID,
+				AssociationID: ownership.AssociationID,
+			})
+			if err != nil {
+				RespondWithError(rw, http.StatusInternalServerError, "Failed to update current ownership shares")
+				return
 			}
 		}
 		// Create new ownership
@@ -387,7 +452,7 @@ func HandleCreateUnitOwnership(cfg *ApiConfig) func(http.ResponseWriter, *http.R
 			endDateParam = sql.NullTime{Time: *ownershipRequest.EndDate, Valid: true}
 		}
 
-		newOwnership, err := cfg.Db.CreateOwnership(req.Context(), database.CreateOwnershipParams{
+		newOwnership, err := qtx.CreateOwnership(req.Context(), database.CreateOwnershipParams{
 			UnitID:               int64(unitId),
 			OwnerID:              ownershipRequest.OwnerID,
 			AssociationID:        int64(associationId),
@@ -397,12 +462,18 @@ func HandleCreateUnitOwnership(cfg *ApiConfig) func(http.ResponseWriter, *http.R
 			IsVoting:             ownershipRequest.ExclusiveOwnership,
 			RegistrationDocument: ownershipRequest.RegistrationDocument,
 			RegistrationDate:     ownershipRequest.RegistrationDate,
+			Share:                share,
 		})
 
 		if err != nil {
 			RespondWithError(rw, http.StatusInternalServerError, "Failed to create new ownership")
 			return
 		}
+		if err := tx.Commit(); err != nil {
+			logging.Logger.Log(zap.WarnLevel, "Error committing transaction", zap.String("error", err.Error()))
+			RespondWithError(rw, http.StatusInternalServerError, "Failed to create new ownership")
+			return
+		}
 
 		// Return the new ownership
 		ownershipResponse := Ownership{
@@ -413,6 +484,7 @@ func HandleCreateUnitOwnership(cfg *ApiConfig) func(http.ResponseWriter, *http.R
 			StartDate:            newOwnership.StartDate.Time,
 			EndDate:              endDateParam.Time,
 			IsActive:             newOwnership.IsActive,
+			Share:                newOwnership.Share,
 			RegistrationDocument: newOwnership.RegistrationDocument,
 			RegistrationDate:     newOwnership.RegistrationDate,
 			CreatedAt:            newOwnership.CreatedAt.Time,
@@ -440,6 +512,11 @@ func HandleGetOwnerReport(cfg *ApiConfig) func(http.ResponseWriter, *http.Reques
 		// Parse query parameters for data inclusion options
 		includeUnits := req.URL.Query().Get("units") == "true"
 		includeCoOwners := req.URL.Query().Get("co_owners") == "true"
+		policy, ok := coOwnershipFromRequest(req)
+		if !ok {
+			RespondWithError(rw, http.StatusBadRequest, "Invalid co_ownership parameter, expected designated, split or agreement")
+			return
+		}
 
 		type OwnerUnit struct {
 			UnitID              int64   `json:"unit_id"`
@@ -451,12 +528,16 @@ func HandleGetOwnerReport(cfg *ApiConfig) func(http.ResponseWriter, *http.Reques
 			Area                float64 `json:"area"`
 			Part                float64 `json:"part"`
 			UnitType            string  `json:"unit_type"`
+			Share               float64 `json:"share"`
+			IsVoting            bool    `json:"is_voting"`
 		}
 
 		type OwnerStats struct {
 			TotalUnits     int     `json:"total_units"`
 			TotalArea      float64 `json:"total_area"`
 			TotalCondoPart float64 `json:"total_condo_part"`
+			OwnedPart      float64 `json:"owned_part"`  // Shares of co-owned units applied
+			VotingPart     float64 `json:"voting_part"` // Part voted with under the co-ownership policy
 		}
 
 		type CoOwner struct {
@@ -519,6 +600,8 @@ func HandleGetOwnerReport(cfg *ApiConfig) func(http.ResponseWriter, *http.Reques
 				ownerEntry.Statistics.TotalUnits++
 				ownerEntry.Statistics.TotalArea += row.Area
 				ownerEntry.Statistics.TotalCondoPart += row.Part
+				ownerEntry.Statistics.OwnedPart += row.Part * row.Share
+				ownerEntry.Statistics.VotingPart += votingPart(policy, row.Part, row.Share, row.IsVoting)
 
 				// Include unit details if requested
 				if includeUnits {
@@ -532,6 +615,8 @@ func HandleGetOwnerReport(cfg *ApiConfig) func(http.ResponseWriter, *http.Reques
 						Area:                row.Area,
 						Part:                row.Part,
 						UnitType:            row.UnitType,
+						Share:               row.Share,
+						IsVoting:            row.IsVoting,
 					})
 				}
 			}
@@ -613,6 +698,11 @@ func HandleGetVotersReport(cfg *ApiConfig) func(http.ResponseWriter, *http.Reque
 		unitTypes := strArrayToRelevantStrArray(strings.Split(req.URL.Query().Get(UnitTypeVotersFilter), ","))
 		entrance, _ := strArrayToInt64Array(strings.Split(req.URL.Query().Get(EntranceVotersFilter), ","))
 		floor, _ := strArrayToInt64Array(strings.Split(req.URL.Query().Get(FloorVotersFilter), ","))
+		policy, ok := coOwnershipFromRequest(req)
+		if !ok {
+			RespondWithError(rw, http.StatusBadRequest, "Invalid co_ownership parameter, expected designated, split or agreement")
+			return
+		}
 		type OwnerUnit struct {
 			UnitID          int64   `json:"unit_id"`
 			UnitNumber      string  `json:"unit_number"`
@@ -621,6 +711,7 @@ func HandleGetVotersReport(cfg *ApiConfig) func(http.ResponseWriter, *http.Reque
 			Area            float64 `json:"area"`
 			Part            float64 `json:"part"`
 			UnitType        string  `json:"unit_type"`
+			Share           float64 `json:"share"` // Of the unit voted for, 1 for the designated voting owner
 		}
 
 		type OwnerReportItem struct {
@@ -655,6 +746,15 @@ func HandleGetVotersReport(cfg *ApiConfig) func(http.ResponseWriter, *http.Reque
 		ownerMap := make(map[int64]*OwnerReportItem)
 
 		for _, row := range voterData {
+			// Only the designated voting owner votes for a co-owned unit unless co-owners vote their shares
+			if policy == "designated" && !row.IsVoting {
+				continue
+			}
+			share := 1.0
+			if policy != "designated" {
+				share = row.Share
+			}
+
 			// If this owner isn't in our map yet, create a new entry
 			if _, exists := ownerMap[row.OwnerID]; !exists {
 				ownerMap[row.OwnerID] = &OwnerReportItem{
@@ -681,9 +781,10 @@ func HandleGetVotersReport(cfg *ApiConfig) func(http.ResponseWriter, *http.Reque
 				Area:            row.Area,
 				Part:            row.Part,
 				UnitType:        row.UnitType,
+				Share:           share,
 			})
-			ownerEntry.TotalArea += row.Area
-			ownerEntry.VotingShare += row.Part
+			ownerEntry.TotalArea += row.Area * share
+			ownerEntry.VotingShare += votingPart(policy, row.Part, row.Share, row.IsVoting)
 			ownerEntry.TotalUnits++
 		}
 		for _, ownerEntry := range ownerMap {
@@ -3,9 +3,11 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"github.com/alexmarian/apc/api/internal/database"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
@@ -60,8 +62,17 @@ func HandleDisableOwnership(cfg *ApiConfig) func(http.ResponseWriter, *http.Requ
 			return
 		}
 
+		tx, err := cfg.Conn.BeginTx(req.Context(), nil)
+		if err != nil {
+			log.Printf("Error starting transaction: %s", err)
+			RespondWithError(rw, http.StatusInternalServerError, "Failed to deactivate ownership")
+			return
+		}
+		defer tx.Rollback()
+		qtx := cfg.Db.WithTx(tx)
+
 		// Update ownership to inactive
-		err = cfg.Db.DeactivateOwnership(req.Context(), database.DeactivateOwnershipParams{
+		err = qtx.DeactivateOwnership(req.Context(), database.DeactivateOwnershipParams{
 			ID:      int64(ownershipId),
 			EndDate: sql.NullTime{Time: endDate, Valid: true},
 			// You might want to add a comments/notes field to ownerships table for storing disable_reason
@@ -73,6 +84,40 @@ func HandleDisableOwnership(cfg *ApiConfig) func(http.ResponseWriter, *http.Requ
 			return
 		}
 
+		// The remaining co-owners take over the share in proportion to theirs
+		remaining, err := qtx.GetActiveUnitOwnerships(req.Context(), database.GetActiveUnitOwnershipsParams{
+			UnitID:        ownership.UnitID,
+			AssociationID: ownership.AssociationID,
+		})
+		if err != nil {
+			log.Printf("Error retrieving remaining ownerships: %s", err)
+			RespondWithError(rw, http.StatusInternalServerError, "Failed to deactivate ownership")
+			return
+		}
+		remainingShare := 0.0
+		for _, o := range remaining {
+			remainingShare += o.Share
+		}
+		for _, o := range remaining {
+			err = qtx.SetOwnershipShare(req.Context(), database.SetOwnershipShareParams{
+				Share:         o.Share / remainingShare,
+				ID:            o.ID,
+				UnitID:        o.UnitID,
+				AssociationID: o.AssociationID,
+			})
+			if err != nil {
+				log.Printf("Error updating ownership share: %s", err)
+				RespondWithError(rw, http.StatusInternalServerError, "Failed to deactivate ownership")
+				return
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Printf("Error committing transaction: %s", err)
+			RespondWithError(rw, http.StatusInternalServerError, "Failed to deactivate ownership")
+			return
+		}
+
 		// Return success response
 		type DisableResponse struct {
 			Message string    `json:"message"`
@@ -87,3 +132,111 @@ func HandleDisableOwnership(cfg *ApiConfig) func(http.ResponseWriter, *http.Requ
 		RespondWithJSON(rw, http.StatusOK, response)
 	}
 }
+
+// shareTolerance is how far from 1 the shares of a unit may add up, for shares such as thirds
+const shareTolerance = 1e-6
+
+// HandleSetOwnershipShares sets the shares of a unit's active ownerships. Every active ownership
+// is listed and the shares add up to 1.
+func HandleSetOwnershipShares(cfg *ApiConfig) func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		associationId, _ := strconv.Atoi(req.PathValue(AssociationIdPathValue))
+		unitId, _ := strconv.Atoi(req.PathValue(UnitIdPathValue))
+
+		var sharesReq struct {
+			Shares []struct {
+				OwnershipID int64   `json:"ownership_id"`
+				Share       float64 `json:"share"`
+			} `json:"shares"`
+		}
+		decoder := json.NewDecoder(req.Body)
+		if err := decoder.Decode(&sharesReq); err != nil {
+			RespondWithError(rw, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+
+		current, err := cfg.Db.GetActiveUnitOwnerships(req.Context(), database.GetActiveUnitOwnershipsParams{
+			UnitID:        int64(unitId),
+			AssociationID: int64(associationId),
+		})
+		if err != nil {
+			log.Printf("Error retrieving ownerships: %s", err)
+			RespondWithError(rw, http.StatusInternalServerError, "Failed to retrieve ownerships")
+			return
+		}
+
+		shares := make(map[int64]float64, len(sharesReq.Shares))
+		total := 0.0
+		for _, s := range sharesReq.Shares {
+			if s.Share <= 0 || s.Share > 1 {
+				RespondWithError(rw, http.StatusBadRequest, "Each share must be between 0 and 1")
+				return
+			}
+			shares[s.OwnershipID] = s.Share
+			total += s.Share
+		}
+		if len(shares) != len(current) || len(sharesReq.Shares) != len(current) {
+			RespondWithError(rw, http.StatusBadRequest, "Every active ownership of the unit must be given a share")
+			return
+		}
+		for _, o := range current {
+			if _, ok := shares[o.ID]; !ok {
+				RespondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Missing share of ownership %d", o.ID))
+				return
+			}
+		}
+		if math.Abs(total-1) > shareTolerance {
+			RespondWithError(rw, http.StatusBadRequest, fmt.Sprintf("Shares must add up to 1, they add up to %g", total))
+			return
+		}
+
+		tx, err := cfg.Conn.BeginTx(req.Context(), nil)
+		if err != nil {
+			log.Printf("Error starting transaction: %s", err)
+			RespondWithError(rw, http.StatusInternalServerError, "Failed to set ownership shares")
+			return
+		}
+		defer tx.Rollback()
+		qtx := cfg.Db.WithTx(tx)
+
+		ownerships := make([]Ownership, len(current))
+		for i, o := range current {
+			err := qtx.SetOwnershipShare(req.Context(), database.SetOwnershipShareParams{
+				Share:         shares[o.ID],
+				ID:            o.ID,
+				UnitID:        o.UnitID,
+				AssociationID: o.AssociationID,
+			})
+			if err != nil {
+				log.Printf("Error setting ownership share: %s", err)
+				RespondWithError(rw, http.StatusInternalServerError, "Failed to set ownership shares")
+				return
+			}
+			ownerships[i] = Ownership{
+				ID:                        o.ID,
+				UnitId:                    o.UnitID,
+				OwnerId:                   o.OwnerID,
+				OwnerName:                 o.OwnerName,
+				OwnerNormalizedName:       o.OwnerNormalizedName,
+				OwnerIdentificationNumber: o.IdentificationNumber,
+				AssociationId:             o.AssociationID,
+				StartDate:                 o.StartDate.Time,
+				EndDate:                   o.EndDate.Time,
+				IsActive:                  o.IsActive,
+				IsVoting:                  o.IsVoting,
+				Share:                     shares[o.ID],
+				RegistrationDocument:      o.RegistrationDocument,
+				RegistrationDate:          o.RegistrationDate,
+				CreatedAt:                 o.CreatedAt.Time,
+				UpdatedAt:                 o.UpdatedAt.Time,
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			log.Printf("Error committing transaction: %s", err)
+			RespondWithError(rw, http.StatusInternalServerError, "Failed to set ownership shares")
+			return
+		}
+
+		RespondWithJSON(rw, http.StatusOK, ownerships)
+	}
+}
@@ -176,6 +176,7 @@ func HandleGetBuildingUnitOwnerships(cfg *ApiConfig) func(http.ResponseWriter, *
 				EndDate:                   owner.EndDate.Time,
 				IsActive:                  owner.IsActive,
 				IsVoting:                  owner.IsVoting,
+				Share:                     owner.Share,
 				RegistrationDocument:      owner.RegistrationDocument,
 				RegistrationDate:          owner.RegistrationDate,
 				CreatedAt:                 owner.CreatedAt.Time,
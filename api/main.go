@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 	"fmt"
@@ -8,13 +9,16 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/alexmarian/apc/api/internal/database"
 	"github.com/alexmarian/apc/api/internal/handlers"
 	"github.com/alexmarian/apc/api/internal/handlers/gathering"
 	"github.com/alexmarian/apc/api/internal/handlers/gathering/domain"
+	"github.com/alexmarian/apc/api/internal/handlers/gathering/services"
 	"github.com/alexmarian/apc/api/internal/logging"
+	"github.com/alexmarian/apc/api/notify"
 	"github.com/joho/godotenv"
 	_ "github.com/mattn/go-sqlite3"
 	"go.uber.org/zap"
@@ -50,6 +54,11 @@ func main() {
 	if secret == "" {
 		log.Fatal("SECRET environment variable is not set")
 	}
+	signingKey := os.Getenv("SIGNING_KEY_SECRET")
+	if signingKey == "" {
+		logging.Logger.Log(zap.WarnLevel, "SIGNING_KEY_SECRET environment variable is not set, ballot signing keys are encrypted with SECRET")
+		signingKey = secret
+	}
 	uiOrigin := os.Getenv("UI_ORIGIN")
 	if uiOrigin == "" {
 		log.Fatal("UI_ORIGIN environment variable is not set")
@@ -57,7 +66,37 @@ func main() {
 	memberOrigin := os.Getenv("MEMBER_ORIGIN")
 
 	apiCfg := &handlers.ApiConfig{
-		Secret: secret,
+		Secret:       secret,
+		SigningKey:   signingKey,
+		MemberOrigin: memberOrigin,
+	}
+	if smtpHost := os.Getenv("SMTP_HOST"); smtpHost != "" {
+		smtpPort, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		mailer, err := notify.NewSMTP(notify.SMTPConfig{
+			Host:        smtpHost,
+			Port:        smtpPort,
+			Username:    os.Getenv("SMTP_USERNAME"),
+			Password:    os.Getenv("SMTP_PASSWORD"),
+			From:        os.Getenv("SMTP_FROM"),
+			ImplicitTLS: os.Getenv("SMTP_IMPLICIT_TLS") == "true",
+		})
+		if err != nil {
+			log.Fatalf("smtp: %v", err)
+		}
+		apiCfg.Mailer = mailer
+	} else {
+		logging.Logger.Log(zap.WarnLevel, "SMTP_HOST environment variable is not set, notification emails are logged instead of sent")
+	}
+	if smsURL := os.Getenv("SMS_GATEWAY_URL"); smsURL != "" {
+		sms, err := notify.NewSMSGateway(notify.SMSConfig{
+			URL:   smsURL,
+			Token: os.Getenv("SMS_GATEWAY_TOKEN"),
+			From:  os.Getenv("SMS_FROM"),
+		})
+		if err != nil {
+			log.Fatalf("sms: %v", err)
+		}
+		apiCfg.SMS = sms
 	}
 	dbURL := os.Getenv("DB_PATH")
 	if dbURL == "" {
@@ -77,6 +116,14 @@ func main() {
 		}
 		logging.Logger.Log(zap.InfoLevel, "Migrations applied")
 		apiCfg.Db = database.New(db)
+		apiCfg.Conn = db
+		encrypted, err := services.NewBallotSigner(apiCfg.Db, apiCfg.SigningKey).EncryptStoredKeys(context.Background())
+		if err != nil {
+			log.Fatalf("signing keys: %v", err)
+		}
+		if encrypted > 0 {
+			logging.Logger.Log(zap.InfoLevel, "Encrypted stored signing keys", zap.Int("count", encrypted))
+		}
 		logging.Logger.Log(zap.InfoLevel, "Connected to database!")
 	}
 
@@ -90,6 +137,11 @@ func main() {
 
 	// Initialize refactored gathering router
 	gatheringRouter := gathering.NewGatheringRouter(apiCfg)
+	if apiCfg.Db != nil {
+		gatheringRouter.Scheduler.Start(context.Background())
+		gatheringRouter.Dispatcher.Start(context.Background())
+		gatheringRouter.Reminders.Start(context.Background())
+	}
 
 	mux.HandleFunc("POST /v1/api/users", handlers.HandleCreateUserWithToken(apiCfg))
 	mux.HandleFunc("POST /v1/api/admin/tokens", apiCfg.MiddlewareAdminOnly(handlers.HandleCreateRegistrationToken(apiCfg)))
@@ -131,6 +183,7 @@ func main() {
 	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/owners", handlers.AssociationIdPathValue), apiCfg.MiddlewareAssociationResource(handlers.HandleCreateOwner(apiCfg)))
 	mux.HandleFunc(fmt.Sprintf("PUT /v1/api/associations/{%s}/owners/{%s}", handlers.AssociationIdPathValue, handlers.OwnerIdPathValue), apiCfg.MiddlewareAssociationResource(handlers.HandleUpdateAssociationOwner(apiCfg)))
 	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/buildings/{%s}/units/{%s}/ownerships", handlers.AssociationIdPathValue, handlers.BuildingIdPathValue, handlers.UnitIdPathValue), apiCfg.MiddlewareAssociationResource(handlers.HandleCreateUnitOwnership(apiCfg)))
+	mux.HandleFunc(fmt.Sprintf("PUT /v1/api/associations/{%s}/buildings/{%s}/units/{%s}/ownerships/shares", handlers.AssociationIdPathValue, handlers.BuildingIdPathValue, handlers.UnitIdPathValue), apiCfg.MiddlewareAssociationResource(handlers.HandleSetOwnershipShares(apiCfg)))
 
 	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/owners/report", handlers.AssociationIdPathValue), apiCfg.MiddlewareAssociationResource(handlers.HandleGetOwnerReport(apiCfg)))
 	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/owners/voters", handlers.AssociationIdPathValue), apiCfg.MiddlewareAssociationResource(handlers.HandleGetVotersReport(apiCfg)))
@@ -198,6 +251,16 @@ func main() {
 	mux.HandleFunc(fmt.Sprintf("PUT /v1/api/associations/{%s}/accounts/{%s}/disable", handlers.AssociationIdPathValue, handlers.AccountIdPathValue),
 		apiCfg.MiddlewareAssociationResource(handlers.HandleDisableAccount(apiCfg)))
 
+	// Voting rules
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/voting-rules", handlers.AssociationIdPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.VotingRules.HandleGetVotingRules()))
+	mux.HandleFunc(fmt.Sprintf("PUT /v1/api/associations/{%s}/voting-rules", handlers.AssociationIdPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.VotingRules.HandleUpdateVotingRules()))
+
+	// Qualification rules
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/qualification-rules/dry-run", handlers.AssociationIdPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Qualification.HandleDryRun()))
+
 	// Gatherings - using refactored handlers
 	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings", handlers.AssociationIdPathValue),
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Gathering.HandleGetGatherings()))
@@ -205,8 +268,26 @@ func main() {
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Gathering.HandleCreateGathering()))
 	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Gathering.HandleGetGathering()))
+	mux.HandleFunc(fmt.Sprintf("PUT /v1/api/associations/{%s}/gatherings/{%s}", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Gathering.HandleUpdateGathering()))
 	mux.HandleFunc(fmt.Sprintf("PUT /v1/api/associations/{%s}/gatherings/{%s}/status", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Gathering.HandleUpdateGatheringStatus()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/repeat", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Gathering.HandleRepeatGathering()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/clone", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Gathering.HandleCloneGathering()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/template", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Template.HandleSaveTemplate()))
+
+	// Gathering templates
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gathering-templates", handlers.AssociationIdPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Template.HandleGetTemplates()))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gathering-templates/{%s}", handlers.AssociationIdPathValue, domain.TemplateIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Template.HandleGetTemplate()))
+	mux.HandleFunc(fmt.Sprintf("DELETE /v1/api/associations/{%s}/gathering-templates/{%s}", handlers.AssociationIdPathValue, domain.TemplateIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Template.HandleDeleteTemplate()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gathering-templates/{%s}/gatherings", handlers.AssociationIdPathValue, domain.TemplateIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Template.HandleCreateGatheringFromTemplate()))
 
 	// Voting Matters - using refactored handlers
 	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/matters", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
@@ -218,6 +299,18 @@ func main() {
 	mux.HandleFunc(fmt.Sprintf("DELETE /v1/api/associations/{%s}/gatherings/{%s}/matters/{%s}", handlers.AssociationIdPathValue, domain.GatheringIDPathValue, domain.VotingMatterIDPathValue),
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.VotingMatter.HandleDeleteVotingMatter()))
 
+	// Live meetings: the chair opens and closes voting on each matter in turn
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/matter-voting", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.MatterVoting.HandleGetMatterVoting()))
+	mux.HandleFunc(fmt.Sprintf("PUT /v1/api/associations/{%s}/gatherings/{%s}/matter-voting", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.MatterVoting.HandleSetMatterVoting()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/matters/{%s}/open", handlers.AssociationIdPathValue, domain.GatheringIDPathValue, domain.VotingMatterIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.MatterVoting.HandleOpenMatter()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/matters/{%s}/close", handlers.AssociationIdPathValue, domain.GatheringIDPathValue, domain.VotingMatterIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.MatterVoting.HandleCloseMatter()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/matters/{%s}/votes", handlers.AssociationIdPathValue, domain.GatheringIDPathValue, domain.VotingMatterIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.MatterVoting.HandleSubmitMatterVote()))
+
 	// Participants - using refactored handlers
 	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/participants", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Participant.HandleGetParticipants()))
@@ -225,18 +318,51 @@ func main() {
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Participant.HandleAddParticipant()))
 	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/participants/{%s}/checkin", handlers.AssociationIdPathValue, domain.GatheringIDPathValue, domain.ParticipantIDPathValue),
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Participant.HandleCheckInParticipant()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/participants/{%s}/checkout", handlers.AssociationIdPathValue, domain.GatheringIDPathValue, domain.ParticipantIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Participant.HandleCheckOutParticipant()))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/attendance", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Participant.HandleGetAttendance()))
+
+	// Delegation registry: powers of attorney delegates take part under
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/delegations", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Delegation.HandleGetDelegations()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/delegations", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Delegation.HandleCreateDelegation()))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/delegations/{%s}", handlers.AssociationIdPathValue, domain.GatheringIDPathValue, domain.DelegationIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Delegation.HandleGetDelegation()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/delegations/{%s}/revoke", handlers.AssociationIdPathValue, domain.GatheringIDPathValue, domain.DelegationIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Delegation.HandleRevokeDelegation()))
+	mux.HandleFunc(fmt.Sprintf("PUT /v1/api/associations/{%s}/gatherings/{%s}/delegations/{%s}/document", handlers.AssociationIdPathValue, domain.GatheringIDPathValue, domain.DelegationIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Delegation.HandleUploadDelegationDocument()))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/delegations/{%s}/document", handlers.AssociationIdPathValue, domain.GatheringIDPathValue, domain.DelegationIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Delegation.HandleDownloadDelegationDocument()))
 
 	// Voting (Ballot submission) - using refactored handlers
 	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/ballot", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Ballot.HandleSubmitBallot()))
+	// Paper ballots imported from a CSV or XLSX sheet, validated first by the dry run
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/ballots/import/dry-run", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.BallotImport.HandleDryRunImport()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/ballots/import", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.BallotImport.HandleImport()))
 
 	// Results - using refactored handlers
 	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/results", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Results.HandleGetVoteResults()))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/results/stream", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		gatheringRouter.Results.MiddlewareStreamToken(gatheringRouter.Results.HandleStreamResults()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/results/stream/token", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Results.HandleCreateStreamToken()))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/integrity", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Results.HandleCheckIntegrity()))
 
 	// Ballots - using refactored handlers
 	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/ballots", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Ballot.HandleGetBallots()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/ballots/{%s}/invalidate", handlers.AssociationIdPathValue, domain.GatheringIDPathValue, domain.BallotIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Ballot.HandleInvalidateBallot()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/ballots/{%s}/replace", handlers.AssociationIdPathValue, domain.GatheringIDPathValue, domain.BallotIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Ballot.HandleReplaceBallot()))
 
 	// Download results and ballots as markdown - using refactored handlers
 	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/download/results", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
@@ -244,6 +370,26 @@ func main() {
 	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/download/ballots", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Export.HandleDownloadVotingBallots()))
 
+	// Printed documents as PDF, localized with ?lang=ro|ru|en
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/download/paper-ballots", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Export.HandleDownloadPaperBallots()))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/download/attendance-sheet", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Export.HandleDownloadAttendanceSheet()))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/download/results-report", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Export.HandleDownloadResultsReport()))
+
+	// Minutes as DOCX or ODT (?format=docx|odt), filled from the association's minutes template
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/download/minutes", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Export.HandleDownloadMinutes()))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/minutes-template", handlers.AssociationIdPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Minutes.HandleGetMinutesTemplate()))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/minutes-template/file", handlers.AssociationIdPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Minutes.HandleDownloadMinutesTemplate()))
+	mux.HandleFunc(fmt.Sprintf("PUT /v1/api/associations/{%s}/minutes-template", handlers.AssociationIdPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Minutes.HandleUploadMinutesTemplate()))
+	mux.HandleFunc(fmt.Sprintf("DELETE /v1/api/associations/{%s}/minutes-template", handlers.AssociationIdPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Minutes.HandleDeleteMinutesTemplate()))
+
 	// Utility endpoints - using refactored handlers
 	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/eligible-voters", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Results.HandleGetEligibleVoters()))
@@ -251,12 +397,22 @@ func main() {
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Results.HandleGetQualifiedUnits()))
 	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/non-participating-owners", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Results.HandleGetNonParticipatingOwners()))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/register", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Results.HandleGetVoterRegister()))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/register/diff", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Results.HandleGetVoterRegisterDiff()))
 	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/stats", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Results.HandleGetGatheringStats()))
 
 	// Notifications - using refactored handlers
 	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/notifications", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Notification.HandleSendNotification()))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/notifications", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Notification.HandleGetNotifications()))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/reminders", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Notification.HandleGetReminders()))
+	mux.HandleFunc(fmt.Sprintf("PUT /v1/api/associations/{%s}/gatherings/{%s}/reminders", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Notification.HandleSetReminders()))
 
 	// Audit logs - using refactored handlers
 	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/audit-logs", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
@@ -265,19 +421,41 @@ func main() {
 	// Member invitations - using refactored handlers
 	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/invitations", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Invitation.HandleCreateInvitation()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/invitations/bulk", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Invitation.HandleCreateBulkInvitations()))
 	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/invitations", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Invitation.HandleListInvitations()))
 	mux.HandleFunc(fmt.Sprintf("DELETE /v1/api/associations/{%s}/gatherings/{%s}/invitations/{%s}", handlers.AssociationIdPathValue, domain.GatheringIDPathValue, domain.InvitationIDPathValue),
 		apiCfg.MiddlewareAssociationResource(gatheringRouter.Invitation.HandleRevokeInvitation()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/associations/{%s}/gatherings/{%s}/invitations/{%s}/unlock", handlers.AssociationIdPathValue, domain.GatheringIDPathValue, domain.InvitationIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.Invitation.HandleUnlockInvitation()))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/member-verification", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.MemberVerification.HandleGetMemberVerification()))
+	mux.HandleFunc(fmt.Sprintf("PUT /v1/api/associations/{%s}/gatherings/{%s}/member-verification", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.MemberVerification.HandleSetMemberVerification()))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/associations/{%s}/gatherings/{%s}/co-ownership", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.CoOwnership.HandleGetCoOwnership()))
+	mux.HandleFunc(fmt.Sprintf("PUT /v1/api/associations/{%s}/gatherings/{%s}/co-ownership", handlers.AssociationIdPathValue, domain.GatheringIDPathValue),
+		apiCfg.MiddlewareAssociationResource(gatheringRouter.CoOwnership.HandleSetCoOwnership()))
 
 	// Ballot verification (public endpoint) - using refactored handlers
 	mux.HandleFunc("POST /v1/api/ballot/verify", gatheringRouter.Ballot.HandleVerifyBallot())
+	mux.HandleFunc("POST /v1/api/ballot/receipt/verify", gatheringRouter.Ballot.HandleVerifyReceipt())
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/ballot/keys/{%s}", domain.SigningKeyIDPathValue), gatheringRouter.Ballot.HandleGetSigningKey())
 
 	// Member app endpoints (token-scoped, no JWT required)
 	mux.HandleFunc(fmt.Sprintf("GET /v1/api/member/gatherings/{%s}", handlers.MemberTokenPathValue),
-		apiCfg.MiddlewareMemberToken(handlers.HandleGetMemberContext(apiCfg)))
+		apiCfg.MiddlewareMemberToken(handlers.HandleGetMemberContext(apiCfg, services.NewVoterRegisterService(apiCfg.Db).EligibleVoters)))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/member/gatherings/{%s}/verification", handlers.MemberTokenPathValue),
+		apiCfg.MiddlewareMemberToken(gatheringRouter.MemberVerification.HandleGetVerificationStatus()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/member/gatherings/{%s}/verification", handlers.MemberTokenPathValue),
+		apiCfg.MiddlewareMemberToken(gatheringRouter.MemberVerification.HandleVerify()))
+	mux.HandleFunc(fmt.Sprintf("POST /v1/api/member/gatherings/{%s}/verification/code", handlers.MemberTokenPathValue),
+		apiCfg.MiddlewareMemberToken(gatheringRouter.MemberVerification.HandleSendVerificationCode()))
 	mux.HandleFunc(fmt.Sprintf("POST /v1/api/member/gatherings/{%s}/ballot", handlers.MemberTokenPathValue),
-		apiCfg.MiddlewareMemberToken(gatheringRouter.MemberBallot.HandleSubmitMemberBallot()))
+		apiCfg.MiddlewareMemberToken(gatheringRouter.MemberVerification.MiddlewareVerifiedMember(gatheringRouter.MemberBallot.HandleSubmitMemberBallot())))
+	mux.HandleFunc(fmt.Sprintf("GET /v1/api/member/gatherings/{%s}/results/stream", handlers.MemberTokenPathValue),
+		apiCfg.MiddlewareMemberToken(gatheringRouter.MemberBallot.HandleStreamMemberResults()))
 
 	allowedOrigins := []string{uiOrigin}
 	if memberOrigin != "" {
@@ -293,7 +471,7 @@ func main() {
 				}
 			}
 			w.Header().Add("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Add("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Add("Access-Control-Allow-Headers", "Content-Type, Authorization, "+handlers.MemberSessionHeader)
 
 			if r.Method == http.MethodOptions {
 				w.WriteHeader(http.StatusNoContent)
@@ -0,0 +1,38 @@
+// Package notify delivers notifications to owners, such as the invitation to a gathering.
+//
+// A Transport sends a Message over one channel. SMTP sends email through a mail server and
+// SMSGateway text messages through an HTTP gateway; Log only logs the messages, for development
+// without either. Transports report whether a failure is worth
+// retrying: errors wrapping ErrRejected are permanent, for example an address the mail server
+// refuses, while any other error, such as a lost connection, may pass on a later attempt.
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// ErrRejected is wrapped by errors for messages that would fail again if retried
+var ErrRejected = errors.New("message rejected")
+
+// Message is a plain text message to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Transport sends messages
+type Transport interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Log is a Transport that logs messages instead of sending them
+type Log struct{}
+
+// Send logs a message in full, links included
+func (Log) Send(_ context.Context, msg Message) error {
+	log.Printf("[notify] to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
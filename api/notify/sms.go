@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultSMSTimeout bounds a delivery when the context has no deadline
+const defaultSMSTimeout = 30 * time.Second
+
+// SMSConfig configures an SMS gateway transport
+type SMSConfig struct {
+	URL   string // Endpoint the messages are posted to
+	Token string // Sent as a bearer token when set
+	From  string // Sender name or number, when the gateway lets it be chosen
+}
+
+// SMSGateway is a Transport sending text messages through an HTTP gateway. Each message is
+// posted as JSON: {"from": ..., "to": ..., "text": ...}, the subject left out. The gateway
+// accepts a message with a 2xx status; other 4xx statuses reject it, except 408 and 429, which
+// are worth retrying like 5xx ones.
+type SMSGateway struct {
+	cfg    SMSConfig
+	client *http.Client
+}
+
+// NewSMSGateway creates an SMS gateway transport
+func NewSMSGateway(cfg SMSConfig) (*SMSGateway, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid SMS gateway URL %q", cfg.URL)
+	}
+	return &SMSGateway{cfg: cfg, client: &http.Client{Timeout: defaultSMSTimeout}}, nil
+}
+
+type smsRequest struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+	Text string `json:"text"`
+}
+
+// Send posts a message to the gateway
+func (g *SMSGateway) Send(ctx context.Context, msg Message) error {
+	to, ok := phoneNumber(msg.To)
+	if !ok {
+		return fmt.Errorf("%w: invalid phone number %q", ErrRejected, msg.To)
+	}
+	body, err := json.Marshal(smsRequest{From: g.cfg.From, To: to, Text: msg.Body})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.cfg.Token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("SMS gateway: %w", err)
+	}
+	defer resp.Body.Close()
+	reply, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests:
+		return fmt.Errorf("%w: SMS gateway: %s %s", ErrRejected, resp.Status, strings.TrimSpace(string(reply)))
+	}
+	return errors.New("SMS gateway: " + resp.Status + " " + strings.TrimSpace(string(reply)))
+}
+
+// phoneNumber normalizes a phone number written with spaces, dashes, dots or parentheses to its
+// digits, keeping a leading +
+func phoneNumber(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case strings.ContainsRune(" -.()", r):
+		default:
+			return "", false
+		}
+	}
+	digits := strings.TrimPrefix(b.String(), "+")
+	return b.String(), len(digits) >= 6 && len(digits) <= 15
+}
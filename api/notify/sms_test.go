@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestSMSGatewaySend tests delivery to a local gateway stand-in replying with a given status
+func TestSMSGatewaySend(t *testing.T) {
+	msg := Message{To: "+373 (69) 123-456", Subject: "Not sent", Body: "Codul dumneavoastră: 123456"}
+
+	tests := []struct {
+		name      string
+		status    int
+		rejected  bool
+		delivered bool
+	}{
+		{"accepted", http.StatusAccepted, false, true},
+		{"invalid number", http.StatusBadRequest, true, false},
+		{"rate limited", http.StatusTooManyRequests, false, false},
+		{"unavailable", http.StatusServiceUnavailable, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received smsRequest
+			var authorization string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authorization = r.Header.Get("Authorization")
+				json.NewDecoder(r.Body).Decode(&received)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			transport, err := NewSMSGateway(SMSConfig{URL: server.URL, Token: "secret", From: "APC"})
+			if err != nil {
+				t.Fatalf("NewSMSGateway() unexpected error: %v", err)
+			}
+			err = transport.Send(context.Background(), msg)
+			if got := errors.Is(err, ErrRejected); got != tt.rejected {
+				t.Errorf("Send() error = %v, rejected = %v, expected %v", err, got, tt.rejected)
+			}
+			if tt.delivered != (err == nil) {
+				t.Fatalf("Send() error = %v, expected delivered = %v", err, tt.delivered)
+			}
+
+			expected := smsRequest{From: "APC", To: "+37369123456", Text: msg.Body}
+			if received != expected {
+				t.Errorf("gateway received %+v, expected %+v", received, expected)
+			}
+			if authorization != "Bearer secret" {
+				t.Errorf("Authorization = %q, expected the bearer token", authorization)
+			}
+		})
+	}
+
+	if _, err := NewSMSGateway(SMSConfig{URL: "localhost:8080"}); err == nil {
+		t.Error("NewSMSGateway() with an invalid URL should fail")
+	}
+	transport, _ := NewSMSGateway(SMSConfig{URL: "http://localhost"})
+	for _, to := range []string{"", "12345", "069 12 34 56 ext", "+373+69123456"} {
+		if err := transport.Send(context.Background(), Message{To: to}); !errors.Is(err, ErrRejected) {
+			t.Errorf("Send() to %q error = %v, expected ErrRejected", to, err)
+		}
+	}
+}
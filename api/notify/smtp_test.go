@@ -0,0 +1,165 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// smtpStandIn is a local mail server answering RCPT TO with a given reply and recording the
+// messages it accepts
+type smtpStandIn struct {
+	listener  net.Listener
+	rcptReply string
+	messages  chan string
+}
+
+func newSMTPStandIn(t *testing.T, rcptReply string) *smtpStandIn {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpStandIn{listener: listener, rcptReply: rcptReply, messages: make(chan string, 1)}
+	t.Cleanup(func() { listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpStandIn) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.session(conn)
+	}
+}
+
+func (s *smtpStandIn) session(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 stand-in ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"):
+			reply("250-stand-in")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(command, "AUTH PLAIN"):
+			reply("235 authenticated")
+		case strings.HasPrefix(command, "MAIL FROM"):
+			reply("250 sender ok")
+		case strings.HasPrefix(command, "RCPT TO"):
+			reply(s.rcptReply)
+		case command == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			s.messages <- data.String()
+			reply("250 queued")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// TestSMTPSend tests delivery to a local SMTP stand-in and how refusals are classified
+func TestSMTPSend(t *testing.T) {
+	msg := Message{
+		To:      "Ion Țurcanu <ion@example.com>",
+		Subject: "Convocare: Adunarea generală",
+		Body:    "Bună ziua,\n\nLinkul dumneavoastră: https://vot.example.com/abc\n.\nO linie care începe cu punct.",
+	}
+
+	tests := []struct {
+		name      string
+		rcptReply string
+		rejected  bool
+		delivered bool
+	}{
+		{"accepted", "250 recipient ok", false, true},
+		{"mailbox unavailable", "550 no such user", true, false},
+		{"mailbox busy", "451 try again later", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newSMTPStandIn(t, tt.rcptReply)
+			address := server.listener.Addr().(*net.TCPAddr)
+			host, port := address.IP.String(), address.Port
+			transport, err := NewSMTP(SMTPConfig{
+				Host:     host,
+				Port:     port,
+				Username: "apc",
+				Password: "secret",
+				From:     "Asociația APC <noreply@example.com>",
+			})
+			if err != nil {
+				t.Fatalf("NewSMTP() unexpected error: %v", err)
+			}
+
+			err = transport.Send(context.Background(), msg)
+			if got := errors.Is(err, ErrRejected); got != tt.rejected {
+				t.Errorf("Send() error = %v, rejected = %v, expected %v", err, got, tt.rejected)
+			}
+			if tt.delivered != (err == nil) {
+				t.Fatalf("Send() error = %v, expected delivered = %v", err, tt.delivered)
+			}
+			if !tt.delivered {
+				return
+			}
+
+			parsed, err := mail.ReadMessage(strings.NewReader(<-server.messages))
+			if err != nil {
+				t.Fatalf("delivered message does not parse: %v", err)
+			}
+			subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+			if subject != msg.Subject {
+				t.Errorf("Subject = %q, expected %q", subject, msg.Subject)
+			}
+			to, _ := parsed.Header.AddressList("To")
+			if len(to) != 1 || to[0].Address != "ion@example.com" || to[0].Name != "Ion Țurcanu" {
+				t.Errorf("To = %v, expected Ion Țurcanu <ion@example.com>", to)
+			}
+			body, _ := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+			// DATA ends with a line break the message did not have
+			if got := strings.TrimSuffix(strings.ReplaceAll(string(body), "\r\n", "\n"), "\n"); got != msg.Body {
+				t.Errorf("Body = %q, expected %q", got, msg.Body)
+			}
+		})
+	}
+
+	if _, err := NewSMTP(SMTPConfig{Host: "localhost", From: "not an address"}); err == nil {
+		t.Error("NewSMTP() with an invalid sender should fail")
+	}
+	transport, _ := NewSMTP(SMTPConfig{Host: "localhost", From: "noreply@example.com"})
+	if err := transport.Send(context.Background(), Message{To: "nobody"}); !errors.Is(err, ErrRejected) {
+		t.Errorf("Send() to an invalid address error = %v, expected ErrRejected", err)
+	}
+}
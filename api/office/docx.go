@@ -0,0 +1,214 @@
+package office
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// docxTextWidth is the width between the margins of an A4 page with 2 cm margins, in twips
+const docxTextWidth = 9638
+
+var (
+	docxHeaderFooter = regexp.MustCompile(`^word/(header|footer)\d*\.xml$`)
+	docxText         = regexp.MustCompile(`<w:t(?:\s[^>]*)?>([^<]*)</w:t>`)
+	docxParagraphPr  = regexp.MustCompile(`(?s)<w:pPr>.*?</w:pPr>`)
+	docxStyle        = regexp.MustCompile(`<w:pStyle [^>]*/>`)
+	docxFonts        = regexp.MustCompile(`<w:rFonts [^>]*/>`)
+	docxSize         = regexp.MustCompile(`<w:sz w:val="(\d+)"/>`)
+)
+
+// docx is the WordprocessingML markup of DOCX documents
+type docx struct{}
+
+func (docx) paragraphs(xml string) []span {
+	return elements(xml, "w:p")
+}
+
+func (docx) texts(paragraph string) []text {
+	var texts []text
+	for _, match := range docxText.FindAllStringSubmatchIndex(paragraph, -1) {
+		texts = append(texts, text{span{match[0], match[1]}, unescape(paragraph[match[2]:match[3]])})
+	}
+	return texts
+}
+
+func (docx) text(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = `<w:t xml:space="preserve">` + escape(line) + "</w:t>"
+	}
+	return strings.Join(lines, "<w:br/>")
+}
+
+// docxFormat is the formatting generated content takes from the paragraph it replaces
+type docxFormat struct {
+	style string // pStyle element of the paragraph
+	fonts string // rFonts element of its first run
+	size  int    // Font size of its first run, in half points
+}
+
+func (d docx) blocks(paragraph string, blocks []Block) string {
+	properties := docxParagraphPr.FindString(paragraph)
+	runs := paragraph[strings.Index(paragraph, properties)+len(properties):]
+	format := docxFormat{
+		style: docxStyle.FindString(properties),
+		fonts: docxFonts.FindString(runs),
+	}
+	if match := docxSize.FindStringSubmatch(runs); match != nil {
+		format.size, _ = strconv.Atoi(match[1])
+	}
+
+	var b strings.Builder
+	for _, block := range blocks {
+		d.block(&b, block, format)
+	}
+	// A paragraph ending a section carries the section's page setup, which must stay last.
+	// A table is followed by a paragraph, as it must be at the end of a table cell.
+	if strings.Contains(properties, "<w:sectPr") || len(blocks) == 0 {
+		b.WriteString("<w:p>" + properties + "</w:p>")
+	} else if _, ok := blocks[len(blocks)-1].(Table); ok {
+		b.WriteString(`<w:p><w:pPr><w:spacing w:before="0" w:after="0"/></w:pPr></w:p>`)
+	}
+	return b.String()
+}
+
+func (docx) finish(xml string) string {
+	return xml
+}
+
+func (d docx) block(b *strings.Builder, block Block, format docxFormat) {
+	switch block := block.(type) {
+	case Paragraph:
+		d.paragraph(b, block, format)
+	case Table:
+		d.table(b, block, format)
+	}
+}
+
+func (d docx) paragraph(b *strings.Builder, p Paragraph, format docxFormat) {
+	b.WriteString("<w:p><w:pPr>" + format.style)
+	if p.Heading {
+		b.WriteString("<w:keepNext/>")
+	}
+	b.WriteString(docxAlign(p.Align) + "</w:pPr>")
+	d.run(b, p.Text, p.Bold || p.Heading, p.Heading, format)
+	b.WriteString("</w:p>")
+}
+
+func (d docx) run(b *strings.Builder, s string, bold, large bool, format docxFormat) {
+	b.WriteString("<w:r><w:rPr>" + format.fonts)
+	if bold {
+		b.WriteString("<w:b/><w:bCs/>")
+	}
+	size := format.size
+	if large {
+		size = max(size, 24) + 4
+	}
+	if size > 0 {
+		fmt.Fprintf(b, `<w:sz w:val="%d"/><w:szCs w:val="%d"/>`, size, size)
+	}
+	b.WriteString("</w:rPr>" + d.text(s) + "</w:r>")
+}
+
+func (d docx) table(b *strings.Builder, t Table, format docxFormat) {
+	b.WriteString(`<w:tbl><w:tblPr><w:tblW w:w="5000" w:type="pct"/>`)
+	if !t.Borderless {
+		b.WriteString("<w:tblBorders>")
+		for _, side := range []string{"top", "left", "bottom", "right", "insideH", "insideV"} {
+			fmt.Fprintf(b, `<w:%s w:val="single" w:sz="4" w:space="0" w:color="000000"/>`, side)
+		}
+		b.WriteString("</w:tblBorders>")
+	}
+	b.WriteString(`<w:tblLayout w:type="fixed"/><w:tblCellMar><w:left w:w="80" w:type="dxa"/><w:right w:w="80" w:type="dxa"/></w:tblCellMar></w:tblPr>`)
+
+	columns := widths(t.Columns, docxTextWidth)
+	b.WriteString("<w:tblGrid>")
+	for _, width := range columns {
+		fmt.Fprintf(b, `<w:gridCol w:w="%.0f"/>`, width)
+	}
+	b.WriteString("</w:tblGrid>")
+
+	if t.hasHeader() {
+		headers := make([]string, len(t.Columns))
+		for i, c := range t.Columns {
+			headers[i] = c.Header
+		}
+		d.row(b, t, columns, headers, true, true, format)
+	}
+	for _, row := range t.Rows {
+		d.row(b, t, columns, row, false, false, format)
+	}
+	if t.Total != nil {
+		d.row(b, t, columns, t.Total, true, false, format)
+	}
+	b.WriteString("</w:tbl>")
+}
+
+func (d docx) row(b *strings.Builder, t Table, columns []float64, cells []string, bold, header bool, format docxFormat) {
+	b.WriteString("<w:tr>")
+	if header {
+		b.WriteString("<w:trPr><w:tblHeader/></w:trPr>")
+	}
+	for i, c := range t.Columns {
+		var cell string
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		fmt.Fprintf(b, `<w:tc><w:tcPr><w:tcW w:w="%.0f" w:type="dxa"/></w:tcPr>`, columns[i])
+		b.WriteString(`<w:p><w:pPr><w:spacing w:before="0" w:after="0"/>` + docxAlign(c.Align) + "</w:pPr>")
+		d.run(b, cell, bold, false, format)
+		b.WriteString("</w:p></w:tc>")
+	}
+	b.WriteString("</w:tr>")
+}
+
+func docxAlign(align Align) string {
+	switch align {
+	case AlignCenter:
+		return `<w:jc w:val="center"/>`
+	case AlignRight:
+		return `<w:jc w:val="right"/>`
+	}
+	return ""
+}
+
+// newDocx lays out a DOCX document on A4 pages in Times New Roman
+func newDocx(title string, blocks []Block) ([]byte, error) {
+	var body strings.Builder
+	d := docx{}
+	for _, block := range blocks {
+		d.block(&body, block, docxFormat{})
+	}
+
+	const header = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+	return writePackage([]entry{
+		{"[Content_Types].xml", header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+			`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>` +
+			`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+			`</Relationships>`},
+		{"docProps/core.xml", header + `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">` +
+			"<dc:title>" + escape(title) + "</dc:title></cp:coreProperties>"},
+		{"word/_rels/document.xml.rels", header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+			`</Relationships>`},
+		{"word/styles.xml", header + `<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+			`<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Times New Roman" w:hAnsi="Times New Roman" w:eastAsia="Times New Roman" w:cs="Times New Roman"/>` +
+			`<w:sz w:val="24"/><w:szCs w:val="24"/></w:rPr></w:rPrDefault>` +
+			`<w:pPrDefault><w:pPr><w:spacing w:after="120"/></w:pPr></w:pPrDefault></w:docDefaults>` +
+			`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>` +
+			`</w:styles>`},
+		{"word/document.xml", header + `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+			body.String() +
+			`<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1134" w:right="1134" w:bottom="1134" w:left="1134" w:header="709" w:footer="709" w:gutter="0"/></w:sectPr>` +
+			`</w:body></w:document>`},
+	})
+}
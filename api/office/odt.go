@@ -0,0 +1,229 @@
+package office
+
+import (
+	"fmt"
+	"strings"
+)
+
+// odtTextWidth is the width between the margins of an A4 page with 2 cm margins, in cm
+const odtTextWidth = 17.0
+
+// odtStyles are the automatic styles generated content refers to
+const odtStyles = `<style:style style:name="apcBold" style:family="text">` +
+	`<style:text-properties fo:font-weight="bold" style:font-weight-asian="bold" style:font-weight-complex="bold"/></style:style>` +
+	`<style:style style:name="apcHeading" style:family="text">` +
+	`<style:text-properties fo:font-size="14pt" fo:font-weight="bold" style:font-weight-asian="bold" style:font-weight-complex="bold"/></style:style>` +
+	`<style:style style:name="apcCenter" style:family="paragraph"><style:paragraph-properties fo:text-align="center"/></style:style>` +
+	`<style:style style:name="apcRight" style:family="paragraph"><style:paragraph-properties fo:text-align="end"/></style:style>` +
+	`<style:style style:name="apcKeep" style:family="paragraph"><style:paragraph-properties fo:keep-with-next="always"/></style:style>` +
+	`<style:style style:name="apcCell" style:family="table-cell">` +
+	`<style:table-cell-properties fo:padding="0.1cm" fo:border="0.5pt solid #000000"/></style:style>` +
+	`<style:style style:name="apcPlainCell" style:family="table-cell">` +
+	`<style:table-cell-properties fo:padding="0.1cm" fo:border="none"/></style:style>`
+
+// odt is the OpenDocument markup of ODT documents. It collects the styles of the content it
+// generates, added to the part when it is finished.
+type odt struct {
+	generated bool
+	tables    int // Tables generated in the part, including by an earlier fill of the template
+	styles    strings.Builder
+}
+
+func (o *odt) paragraphs(xml string) []span {
+	o.tables = strings.Count(xml, `table:name="apcTable`)
+	return elements(xml, "text:p", "text:h")
+}
+
+// texts returns the character data of a paragraph, between its tags
+func (*odt) texts(paragraph string) []text {
+	var texts []text
+	for i := 0; i < len(paragraph); {
+		open := strings.IndexByte(paragraph[i:], '>')
+		if open < 0 {
+			break
+		}
+		start := i + open + 1
+		end := strings.IndexByte(paragraph[start:], '<')
+		if end < 0 {
+			break
+		}
+		if end > 0 {
+			texts = append(texts, text{span{start, start + end}, unescape(paragraph[start : start+end])})
+		}
+		i = start + end
+	}
+	return texts
+}
+
+func (*odt) text(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = escape(line)
+	}
+	return strings.Join(lines, "<text:line-break/>")
+}
+
+func (o *odt) blocks(paragraph string, blocks []Block) string {
+	style := attribute(paragraph, "text:style-name")
+	var b strings.Builder
+	for _, block := range blocks {
+		o.block(&b, block, style)
+	}
+	return b.String()
+}
+
+// finish adds the styles of generated content to the automatic styles of the part
+func (o *odt) finish(xml string) string {
+	if !o.generated {
+		return xml
+	}
+	styles := o.styles.String()
+	if !strings.Contains(xml, `style:name="apcBold"`) {
+		styles = odtStyles + styles
+	}
+	if i := strings.Index(xml, "</office:automatic-styles>"); i >= 0 {
+		return xml[:i] + styles + xml[i:]
+	}
+	if i := strings.Index(xml, "<office:automatic-styles/>"); i >= 0 {
+		return xml[:i] + "<office:automatic-styles>" + styles + "</office:automatic-styles>" + xml[i+len("<office:automatic-styles/>"):]
+	}
+	if i := strings.Index(xml, "<office:body"); i >= 0 {
+		return xml[:i] + "<office:automatic-styles>" + styles + "</office:automatic-styles>" + xml[i:]
+	}
+	return xml
+}
+
+func (o *odt) block(b *strings.Builder, block Block, style string) {
+	o.generated = true
+	switch block := block.(type) {
+	case Paragraph:
+		o.paragraph(b, block, style)
+	case Table:
+		o.table(b, block)
+	}
+}
+
+func (o *odt) paragraph(b *strings.Builder, p Paragraph, style string) {
+	switch {
+	case p.Align != AlignLeft:
+		style = odtAlign(p.Align)
+	case p.Heading:
+		style = "apcKeep"
+	}
+	o.styled(b, style, p.Text, p.Bold, p.Heading)
+}
+
+// styled writes a paragraph in a paragraph style, its text in bold or as a heading
+func (o *odt) styled(b *strings.Builder, style, s string, bold, heading bool) {
+	b.WriteString("<text:p")
+	if style != "" {
+		b.WriteString(` text:style-name="` + escape(style) + `"`)
+	}
+	b.WriteString(">")
+	switch {
+	case heading:
+		b.WriteString(`<text:span text:style-name="apcHeading">` + o.text(s) + "</text:span>")
+	case bold:
+		b.WriteString(`<text:span text:style-name="apcBold">` + o.text(s) + "</text:span>")
+	default:
+		b.WriteString(o.text(s))
+	}
+	b.WriteString("</text:p>")
+}
+
+func (o *odt) table(b *strings.Builder, t Table) {
+	o.tables++
+	name := fmt.Sprintf("apcTable%d", o.tables)
+	fmt.Fprintf(&o.styles, `<style:style style:name="%s" style:family="table"><style:table-properties style:width="%.2fcm" table:align="margins"/></style:style>`,
+		name, odtTextWidth)
+	fmt.Fprintf(b, `<table:table table:name="%s" table:style-name="%s">`, name, name)
+	for i, width := range widths(t.Columns, odtTextWidth) {
+		column := fmt.Sprintf("%s.C%d", name, i+1)
+		fmt.Fprintf(&o.styles, `<style:style style:name="%s" style:family="table-column"><style:table-column-properties style:column-width="%.2fcm" style:rel-column-width="%.0f*"/></style:style>`,
+			column, width, width*1000)
+		fmt.Fprintf(b, `<table:table-column table:style-name="%s"/>`, column)
+	}
+
+	cell := "apcCell"
+	if t.Borderless {
+		cell = "apcPlainCell"
+	}
+	if t.hasHeader() {
+		headers := make([]string, len(t.Columns))
+		for i, c := range t.Columns {
+			headers[i] = c.Header
+		}
+		b.WriteString("<table:table-header-rows>")
+		o.row(b, t, cell, headers, true)
+		b.WriteString("</table:table-header-rows>")
+	}
+	for _, row := range t.Rows {
+		o.row(b, t, cell, row, false)
+	}
+	if t.Total != nil {
+		o.row(b, t, cell, t.Total, true)
+	}
+	b.WriteString("</table:table>")
+}
+
+func (o *odt) row(b *strings.Builder, t Table, style string, cells []string, bold bool) {
+	b.WriteString("<table:table-row>")
+	for i, c := range t.Columns {
+		var cell string
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		fmt.Fprintf(b, `<table:table-cell table:style-name="%s" office:value-type="string">`, style)
+		o.styled(b, odtAlign(c.Align), cell, bold, false)
+		b.WriteString("</table:table-cell>")
+	}
+	b.WriteString("</table:table-row>")
+}
+
+func odtAlign(align Align) string {
+	switch align {
+	case AlignCenter:
+		return "apcCenter"
+	case AlignRight:
+		return "apcRight"
+	}
+	return ""
+}
+
+// newOdt lays out an ODT document on A4 pages in Times New Roman
+func newOdt(title string, blocks []Block) ([]byte, error) {
+	o := &odt{}
+	var body strings.Builder
+	for _, block := range blocks {
+		o.block(&body, block, "")
+	}
+
+	const header = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+	const namespaces = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+		`xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" ` +
+		`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" ` +
+		`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" ` +
+		`xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" ` +
+		`xmlns:dc="http://purl.org/dc/elements/1.1/" ` +
+		`xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" office:version="1.2"`
+	return writePackage([]entry{
+		{"mimetype", odtMimeType},
+		{"META-INF/manifest.xml", header + `<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">` +
+			`<manifest:file-entry manifest:full-path="/" manifest:version="1.2" manifest:media-type="` + odtMimeType + `"/>` +
+			`<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>` +
+			`<manifest:file-entry manifest:full-path="styles.xml" manifest:media-type="text/xml"/>` +
+			`<manifest:file-entry manifest:full-path="meta.xml" manifest:media-type="text/xml"/>` +
+			`</manifest:manifest>`},
+		{"meta.xml", header + `<office:document-meta ` + namespaces + `><office:meta>` +
+			"<dc:title>" + escape(title) + "</dc:title></office:meta></office:document-meta>"},
+		{"styles.xml", header + `<office:document-styles ` + namespaces + `><office:styles>` +
+			`<style:default-style style:family="paragraph"><style:paragraph-properties fo:margin-bottom="0.21cm"/>` +
+			`<style:text-properties fo:font-family="'Times New Roman'" fo:font-size="12pt"/></style:default-style>` +
+			`</office:styles><office:automatic-styles><style:page-layout style:name="apcPage">` +
+			`<style:page-layout-properties fo:page-width="21cm" fo:page-height="29.7cm" fo:margin-top="2cm" fo:margin-bottom="2cm" fo:margin-left="2cm" fo:margin-right="2cm"/>` +
+			`</style:page-layout></office:automatic-styles><office:master-styles>` +
+			`<style:master-page style:name="Standard" style:page-layout-name="apcPage"/></office:master-styles></office:document-styles>`},
+		{"content.xml", o.finish(header + `<office:document-content ` + namespaces + `><office:automatic-styles/>` +
+			`<office:body><office:text>` + body.String() + `</office:text></office:body></office:document-content>`)},
+	})
+}
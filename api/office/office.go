@@ -0,0 +1,476 @@
+// Package office fills DOCX and ODT templates, the documents edited with Word or LibreOffice.
+//
+// A template marks where values go with placeholders such as {{gathering.title}}. A placeholder
+// that is alone in its paragraph can also stand for generated content, paragraphs and tables,
+// which then replaces the whole paragraph. Word processors often split the text of a paragraph
+// into differently formatted runs; placeholders are found across runs all the same.
+//
+// New lays out a plain document from blocks, for example a default template to start from.
+package office
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"html"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Format is the file format of a document
+type Format string
+
+const (
+	DOCX Format = "docx" // Office Open XML, Word
+	ODT  Format = "odt"  // OpenDocument Text, LibreOffice
+)
+
+// odtMimeType is the content of the mimetype entry an ODT package starts with
+const odtMimeType = "application/vnd.oasis.opendocument.text"
+
+// ErrUnknownFormat is returned for files that are neither DOCX nor ODT documents
+var ErrUnknownFormat = errors.New("not a DOCX or ODT document")
+
+// ParseFormat returns the format named by s, such as a file extension without the dot
+func ParseFormat(s string) (Format, bool) {
+	switch Format(strings.ToLower(s)) {
+	case DOCX:
+		return DOCX, true
+	case ODT:
+		return ODT, true
+	}
+	return "", false
+}
+
+// ContentType returns the media type of documents in the format
+func (f Format) ContentType() string {
+	if f == ODT {
+		return odtMimeType
+	}
+	return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+}
+
+// Align is the horizontal alignment of a paragraph or a column
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// Block is content generated in place of a placeholder, a Paragraph or a Table
+type Block interface {
+	block()
+}
+
+// Paragraph is a paragraph of text. Line breaks in the text are kept.
+type Paragraph struct {
+	Text    string
+	Bold    bool
+	Heading bool // Larger and bold, kept with the next paragraph
+	Align   Align
+}
+
+// Column is a column of a table. Widths are relative to each other.
+type Column struct {
+	Header string
+	Width  float64
+	Align  Align
+}
+
+// Table is a table whose header row, repeated on every page, and total row are bold.
+// A borderless table without headers lays out content side by side, like signature lines.
+type Table struct {
+	Columns    []Column
+	Rows       [][]string
+	Total      []string // Optional
+	Borderless bool
+}
+
+func (Paragraph) block() {}
+func (Table) block()     {}
+
+// Fields are the values of the placeholders of a template, by placeholder name
+type Fields struct {
+	Text   map[string]string  // Text replacing the placeholder
+	Blocks map[string][]Block // Content replacing the paragraph the placeholder is alone in
+}
+
+// placeholderPattern matches a placeholder, capturing its name
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z][A-Za-z0-9_.]*)\s*\}\}`)
+
+// Detect returns the format of a document
+func Detect(data []byte) (Format, error) {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", ErrUnknownFormat
+	}
+	return detect(r)
+}
+
+func detect(r *zip.Reader) (Format, error) {
+	for _, f := range r.File {
+		switch f.Name {
+		case "word/document.xml":
+			return DOCX, nil
+		case "mimetype":
+			data, err := readFile(f)
+			if err == nil && strings.TrimSpace(string(data)) == odtMimeType {
+				return ODT, nil
+			}
+		}
+	}
+	return "", ErrUnknownFormat
+}
+
+// Placeholders returns the names of the placeholders of a template, in order of appearance
+func Placeholders(template []byte) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	_, _, err := eachPart(template, func(m markup, xml string, body bool) string {
+		for _, p := range m.paragraphs(xml) {
+			text, _ := joinTexts(m, xml[p.start:p.end])
+			for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+				if !seen[match[1]] {
+					seen[match[1]] = true
+					names = append(names, match[1])
+				}
+			}
+		}
+		return xml
+	})
+	return names, err
+}
+
+// Fill replaces the placeholders of a template with the fields and returns the document, in the
+// format of the template. Placeholders without a value are left as they are.
+func Fill(template []byte, fields Fields) ([]byte, Format, error) {
+	return eachPart(template, func(m markup, xml string, body bool) string {
+		return fill(m, xml, fields, body)
+	})
+}
+
+// New lays out a document from blocks; placeholders in their text can be filled later
+func New(format Format, title string, blocks []Block) ([]byte, error) {
+	switch format {
+	case DOCX:
+		return newDocx(title, blocks)
+	case ODT:
+		return newOdt(title, blocks)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// markup is the XML vocabulary of a format
+type markup interface {
+	// paragraphs returns the spans of the paragraphs of a part
+	paragraphs(xml string) []span
+	// texts returns the spans of the text of a paragraph with the text they hold
+	texts(paragraph string) []text
+	// text returns the markup of text replacing a span returned by texts
+	text(s string) string
+	// blocks returns the markup of blocks replacing a paragraph, formatted like it
+	blocks(paragraph string, blocks []Block) string
+	// finish completes a part once its paragraphs are filled
+	finish(xml string) string
+}
+
+type span struct {
+	start, end int
+}
+
+type text struct {
+	span
+	value string
+}
+
+// eachPart applies edit to the parts of a document holding its text, with body set for the
+// part holding the body, and returns the edited document
+func eachPart(data []byte, edit func(m markup, xml string, body bool) string) ([]byte, Format, error) {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, "", ErrUnknownFormat
+	}
+	format, err := detect(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	edited := make(map[string][]byte)
+	for _, f := range r.File {
+		var m markup
+		body := false
+		switch {
+		case format == DOCX && f.Name == "word/document.xml":
+			m, body = &docx{}, true
+		case format == DOCX && docxHeaderFooter.MatchString(f.Name):
+			m = &docx{}
+		case format == ODT && f.Name == "content.xml":
+			m, body = &odt{}, true
+		case format == ODT && f.Name == "styles.xml":
+			m = &odt{}
+		default:
+			continue
+		}
+		part, err := readFile(f)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		edited[f.Name] = []byte(edit(m, string(part), body))
+	}
+
+	out, err := rewrite(r, edited)
+	return out, format, err
+}
+
+// fill replaces the placeholders in the paragraphs of a part
+func fill(m markup, xml string, fields Fields, body bool) string {
+	var b strings.Builder
+	last := 0
+	for _, p := range m.paragraphs(xml) {
+		paragraph := xml[p.start:p.end]
+		full, texts := joinTexts(m, paragraph)
+		matches := placeholderPattern.FindAllStringSubmatchIndex(full, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		b.WriteString(xml[last:p.start])
+		last = p.end
+
+		if match := matches[0]; body && len(matches) == 1 && strings.TrimSpace(full) == full[match[0]:match[1]] {
+			if blocks, ok := fields.Blocks[full[match[2]:match[3]]]; ok {
+				b.WriteString(m.blocks(paragraph, blocks))
+				continue
+			}
+		}
+		b.WriteString(replaceTexts(m, paragraph, full, texts, matches, fields.Text))
+	}
+	b.WriteString(xml[last:])
+	return m.finish(b.String())
+}
+
+// joinTexts returns the text of a paragraph and the spans it comes from
+func joinTexts(m markup, paragraph string) (string, []text) {
+	texts := m.texts(paragraph)
+	var b strings.Builder
+	for _, t := range texts {
+		b.WriteString(t.value)
+	}
+	return b.String(), texts
+}
+
+// replaceTexts replaces the placeholders matched in the text of a paragraph. A placeholder
+// spanning several runs is replaced in the first; the rest of its text is removed from the others.
+func replaceTexts(m markup, paragraph, full string, texts []text, matches [][]int, values map[string]string) string {
+	// owner[i] is the span byte i of the text comes from
+	owner := make([]int, len(full))
+	at := 0
+	for i, t := range texts {
+		for range len(t.value) {
+			owner[at] = i
+			at++
+		}
+	}
+
+	replaced := make([]strings.Builder, len(texts))
+	keep := func(from, to int) {
+		for i := from; i < to; i++ {
+			replaced[owner[i]].WriteByte(full[i])
+		}
+	}
+	pos := 0
+	for _, match := range matches {
+		keep(pos, match[0])
+		value, ok := values[full[match[2]:match[3]]]
+		if !ok {
+			value = full[match[0]:match[1]]
+		}
+		replaced[owner[match[0]]].WriteString(value)
+		pos = match[1]
+	}
+	keep(pos, len(full))
+
+	var b strings.Builder
+	last := 0
+	for i, t := range texts {
+		b.WriteString(paragraph[last:t.start])
+		if value := replaced[i].String(); value != t.value {
+			b.WriteString(m.text(value))
+		} else {
+			b.WriteString(paragraph[t.start:t.end])
+		}
+		last = t.end
+	}
+	b.WriteString(paragraph[last:])
+	return b.String()
+}
+
+// elements returns the spans of the elements with one of the names, from the start of the
+// opening tag to the end of the closing tag. Empty elements are skipped.
+func elements(xml string, names ...string) []span {
+	var spans []span
+	for i := 0; i < len(xml); {
+		j := strings.IndexByte(xml[i:], '<')
+		if j < 0 {
+			break
+		}
+		start := i + j
+		name := tagName(xml[start+1:])
+		i = start + 1
+		if !slices.Contains(names, name) {
+			continue
+		}
+		open := strings.IndexByte(xml[start:], '>')
+		if open < 0 {
+			break
+		}
+		if xml[start+open-1] == '/' {
+			i = start + open + 1
+			continue
+		}
+		end := strings.Index(xml[start:], "</"+name+">")
+		if end < 0 {
+			break
+		}
+		i = start + end + len(name) + 3
+		spans = append(spans, span{start, i})
+	}
+	return spans
+}
+
+// tagName returns the name of the tag s starts with
+func tagName(s string) string {
+	end := strings.IndexAny(s, " \t\r\n/>")
+	if end < 0 {
+		return s
+	}
+	return s[:end]
+}
+
+// attribute returns the value of an attribute of the opening tag an element starts with
+func attribute(element, name string) string {
+	tag := element[:strings.IndexByte(element, '>')+1]
+	_, value, ok := strings.Cut(tag, " "+name+`="`)
+	if !ok {
+		return ""
+	}
+	value, _, _ = strings.Cut(value, `"`)
+	return value
+}
+
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+// escape escapes text for character data and attribute values
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// unescape decodes the character references of XML character data
+func unescape(s string) string {
+	return html.UnescapeString(s)
+}
+
+func readFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// rewrite copies a package, replacing the content of the edited entries
+func rewrite(r *zip.Reader, edited map[string][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, f := range r.File {
+		data, ok := edited[f.Name]
+		if !ok {
+			if err := w.Copy(f); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		fw, err := w.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: f.Modified})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// entry is a file of a package being written
+type entry struct {
+	name string
+	data string
+}
+
+// writePackage writes the entries of a new package, storing an ODT mimetype uncompressed and
+// without a data descriptor as the first entry must be
+func writePackage(entries []entry) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, e := range entries {
+		var fw io.Writer
+		var err error
+		if e.name == "mimetype" {
+			fw, err = w.CreateRaw(&zip.FileHeader{
+				Name:               e.name,
+				Method:             zip.Store,
+				CRC32:              crc32.ChecksumIEEE([]byte(e.data)),
+				CompressedSize64:   uint64(len(e.data)),
+				UncompressedSize64: uint64(len(e.data)),
+			})
+		} else {
+			fw, err = w.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Deflate})
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(fw, e.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// widths returns the widths of the columns as shares of total
+func widths(columns []Column, total float64) []float64 {
+	var sum float64
+	for _, c := range columns {
+		sum += max(c.Width, 0)
+	}
+	result := make([]float64, len(columns))
+	for i, c := range columns {
+		if sum == 0 {
+			result[i] = total / float64(len(columns))
+		} else {
+			result[i] = total * max(c.Width, 0) / sum
+		}
+	}
+	return result
+}
+
+// hasHeader reports whether a table has a header row
+func (t Table) hasHeader() bool {
+	for _, c := range t.Columns {
+		if c.Header != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package office
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestFill tests that a template laid out by New is filled with text and generated content
+func TestFill(t *testing.T) {
+	template := []Block{
+		Paragraph{Text: "{{association.name}}", Align: AlignCenter},
+		Paragraph{Text: "MINUTES", Heading: true, Align: AlignCenter},
+		Paragraph{Text: "Held on {{gathering.date}} at {{ gathering.location }}, chaired by {{chair}}."},
+		Paragraph{Text: "{{matters}}"},
+		Paragraph{Text: "{{signatures}}"},
+	}
+	fields := Fields{
+		Text: map[string]string{
+			"association.name":   "Asociația „Ștefan cel Mare” & Co",
+			"gathering.date":     "05.11.2026",
+			"gathering.location": "Sala <mare>",
+		},
+		Blocks: map[string][]Block{
+			"matters": {
+				Paragraph{Text: "1. Budget", Bold: true},
+				Table{
+					Columns: []Column{{Header: "Option", Width: 3}, {Header: "Votes", Width: 1, Align: AlignRight}},
+					Rows:    [][]string{{"Yes", "12"}, {"No", "3"}},
+					Total:   []string{"Total", "15"},
+				},
+			},
+			"signatures": {
+				Table{Columns: []Column{{Width: 1}, {Width: 1}}, Rows: [][]string{{"Chair", "Secretary"}}, Borderless: true},
+			},
+		},
+	}
+
+	for _, format := range []Format{DOCX, ODT} {
+		t.Run(string(format), func(t *testing.T) {
+			data, err := New(format, "Minutes", template)
+			if err != nil {
+				t.Fatalf("New() unexpected error: %v", err)
+			}
+			placeholders, err := Placeholders(data)
+			if err != nil {
+				t.Fatalf("Placeholders() unexpected error: %v", err)
+			}
+			expected := []string{"association.name", "gathering.date", "gathering.location", "chair", "matters", "signatures"}
+			if !reflect.DeepEqual(placeholders, expected) {
+				t.Errorf("Placeholders() = %q, want %q", placeholders, expected)
+			}
+
+			filled, filledFormat, err := Fill(data, fields)
+			if err != nil {
+				t.Fatalf("Fill() unexpected error: %v", err)
+			}
+			if filledFormat != format {
+				t.Errorf("Fill() format = %q, want %q", filledFormat, format)
+			}
+			if detected, _ := Detect(filled); detected != format {
+				t.Errorf("Detect() = %q, want %q", detected, format)
+			}
+
+			text := documentText(t, filled, format)
+			for _, want := range []string{
+				"Asociația „Ștefan cel Mare” & Co",
+				"Held on 05.11.2026 at Sala <mare>, chaired by {{chair}}.",
+				"1. Budget", "Option", "Votes", "Yes", "12", "Total", "15", "Chair", "Secretary",
+			} {
+				if !strings.Contains(text, want) {
+					t.Errorf("filled document does not contain %q", want)
+				}
+			}
+			for _, gone := range []string{"{{matters}}", "{{signatures}}", "{{association.name}}"} {
+				if strings.Contains(text, gone) {
+					t.Errorf("filled document still contains %q", gone)
+				}
+			}
+
+			// Filling the filled document again keeps it valid
+			if _, _, err := Fill(filled, fields); err != nil {
+				t.Errorf("Fill() again unexpected error: %v", err)
+			}
+		})
+	}
+
+	if _, _, err := Fill([]byte("not a document"), fields); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Fill() error = %v, want ErrUnknownFormat", err)
+	}
+}
+
+// TestFillSplitRuns tests that placeholders split across runs by a word processor are replaced
+// and the formatting of the runs is kept
+func TestFillSplitRuns(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        markup
+		xml      string
+		expected string
+	}{
+		{
+			name:     "docx",
+			m:        &docx{},
+			xml:      `<w:p><w:r><w:rPr><w:b/></w:rPr><w:t>Title: {{gath</w:t></w:r><w:r><w:t xml:space="preserve">ering.title}} held</w:t></w:r></w:p>`,
+			expected: `<w:p><w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">Title: AGM &amp; vote</w:t></w:r><w:r><w:t xml:space="preserve"> held</w:t></w:r></w:p>`,
+		},
+		{
+			name:     "odt",
+			m:        &odt{},
+			xml:      `<text:p text:style-name="P1">Title: <text:span text:style-name="T1">{{gathering.</text:span>title}}<text:s/>held</text:p>`,
+			expected: `<text:p text:style-name="P1">Title: <text:span text:style-name="T1">AGM &amp; vote</text:span><text:s/>held</text:p>`,
+		},
+		{
+			name:     "untouched paragraphs",
+			m:        &docx{},
+			xml:      `<w:p><w:r><w:t>No placeholder {{</w:t></w:r></w:p><w:p/>`,
+			expected: `<w:p><w:r><w:t>No placeholder {{</w:t></w:r></w:p><w:p/>`,
+		},
+	}
+
+	fields := Fields{Text: map[string]string{"gathering.title": "AGM & vote"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fill(tt.m, tt.xml, fields, true); got != tt.expected {
+				t.Errorf("fill() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+// documentText checks that the parts of a document are well-formed XML and returns the text
+// of its body
+func documentText(t *testing.T, data []byte, format Format) string {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("not a zip package: %v", err)
+	}
+	if format == ODT && (r.File[0].Name != "mimetype" || r.File[0].Method != zip.Store) {
+		t.Error("mimetype is not the first entry, stored")
+	}
+
+	body := "word/document.xml"
+	if format == ODT {
+		body = "content.xml"
+	}
+	var text string
+	for _, f := range r.File {
+		if !strings.HasSuffix(f.Name, ".xml") && !strings.HasSuffix(f.Name, ".rels") {
+			continue
+		}
+		part, err := readFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoder := xml.NewDecoder(bytes.NewReader(part))
+		for {
+			if _, err := decoder.Token(); err != nil {
+				if err != io.EOF {
+					t.Errorf("%s is not well-formed: %v", f.Name, err)
+				}
+				break
+			}
+		}
+		if f.Name == body {
+			text = plainText(string(part))
+		}
+	}
+	return text
+}
+
+var tags = regexp.MustCompile(`<[^>]*>`)
+
+// plainText returns the character data of XML, paragraphs and cells separated by new lines
+func plainText(s string) string {
+	s = strings.NewReplacer("</w:p>", "\n", "</text:p>", "\n").Replace(s)
+	return unescape(tags.ReplaceAllString(s, ""))
+}
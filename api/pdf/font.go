@@ -0,0 +1,420 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Font is a parsed TrueType font. Only the tables needed to measure text and to embed a
+// subset of the glyphs are read.
+type Font struct {
+	name       string // PostScript name
+	tables     map[string][]byte
+	unitsPerEm int
+	ascent     int
+	descent    int
+	capHeight  int
+	bbox       [4]int
+	flags      int // PDF font descriptor flags
+	numGlyphs  int
+	advances   []int // Advance width per glyph, in font units
+	cmap       map[rune]uint16
+	longLoca   bool
+}
+
+var errBadFont = errors.New("invalid TrueType font")
+
+// ParseFont reads a TrueType (glyf outlines) font file
+func ParseFont(data []byte) (*Font, error) {
+	if len(data) < 12 {
+		return nil, errBadFont
+	}
+	if v := binary.BigEndian.Uint32(data); v != 0x00010000 && v != 0x74727565 {
+		return nil, fmt.Errorf("%w: not a TrueType outline font", errBadFont)
+	}
+
+	f := &Font{tables: make(map[string][]byte)}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		record := 12 + 16*i
+		if record+16 > len(data) {
+			return nil, errBadFont
+		}
+		tag := string(data[record : record+4])
+		offset := int(binary.BigEndian.Uint32(data[record+8:]))
+		length := int(binary.BigEndian.Uint32(data[record+12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, fmt.Errorf("%w: table %s out of bounds", errBadFont, tag)
+		}
+		f.tables[tag] = data[offset : offset+length]
+	}
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "loca", "glyf", "cmap"} {
+		if _, ok := f.tables[tag]; !ok {
+			return nil, fmt.Errorf("%w: missing %s table", errBadFont, tag)
+		}
+	}
+
+	head := f.tables["head"]
+	hhea := f.tables["hhea"]
+	maxp := f.tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, errBadFont
+	}
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	if f.unitsPerEm == 0 {
+		return nil, errBadFont
+	}
+	for i := range f.bbox {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	f.longLoca = binary.BigEndian.Uint16(head[50:]) == 1
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	f.capHeight = f.ascent * 7 / 10
+	f.flags = 32 // Nonsymbolic
+	if os2 := f.tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		f.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:])))
+	}
+	if post := f.tables["post"]; len(post) >= 16 && binary.BigEndian.Uint32(post[12:]) != 0 {
+		f.flags |= 1 // FixedPitch
+	}
+	f.numGlyphs = int(binary.BigEndian.Uint16(maxp[4:]))
+
+	// Glyphs past the last long metric share its advance width
+	numMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := f.tables["hmtx"]
+	if numMetrics == 0 || numMetrics > f.numGlyphs || len(hmtx) < 4*numMetrics {
+		return nil, fmt.Errorf("%w: bad horizontal metrics", errBadFont)
+	}
+	f.advances = make([]int, f.numGlyphs)
+	for i := range f.advances {
+		if i < numMetrics {
+			f.advances[i] = int(binary.BigEndian.Uint16(hmtx[4*i:]))
+		} else {
+			f.advances[i] = f.advances[numMetrics-1]
+		}
+	}
+
+	if err := f.parseCmap(); err != nil {
+		return nil, err
+	}
+	f.name = f.postScriptName()
+	return f, nil
+}
+
+// parseCmap reads the Unicode character to glyph mapping, preferring the full repertoire
+// (format 12) over the Basic Multilingual Plane (format 4)
+func (f *Font) parseCmap() error {
+	cmap := f.tables["cmap"]
+	if len(cmap) < 4 {
+		return errBadFont
+	}
+	var bmp, full []byte
+	numSubtables := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < numSubtables; i++ {
+		record := 4 + 8*i
+		if record+8 > len(cmap) {
+			return errBadFont
+		}
+		platform := binary.BigEndian.Uint16(cmap[record:])
+		encoding := binary.BigEndian.Uint16(cmap[record+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[record+4:]))
+		if offset+4 > len(cmap) {
+			return errBadFont
+		}
+		subtable := cmap[offset:]
+		switch format := binary.BigEndian.Uint16(subtable); {
+		case format == 12 && (platform == 0 || platform == 3 && encoding == 10):
+			full = subtable
+		case format == 4 && (platform == 0 || platform == 3 && encoding == 1):
+			bmp = subtable
+		}
+	}
+
+	f.cmap = make(map[rune]uint16)
+	switch {
+	case full != nil:
+		if len(full) < 16 {
+			return errBadFont
+		}
+		numGroups := int(binary.BigEndian.Uint32(full[12:]))
+		if 16+12*numGroups > len(full) {
+			return errBadFont
+		}
+		for i := 0; i < numGroups; i++ {
+			group := full[16+12*i:]
+			start := rune(binary.BigEndian.Uint32(group))
+			end := rune(binary.BigEndian.Uint32(group[4:]))
+			glyph := binary.BigEndian.Uint32(group[8:])
+			for r := start; r <= end && r <= 0x10ffff; r++ {
+				f.cmap[r] = uint16(glyph + uint32(r-start))
+			}
+		}
+	case bmp != nil:
+		if len(bmp) < 14 {
+			return errBadFont
+		}
+		segCount := int(binary.BigEndian.Uint16(bmp[6:])) / 2
+		ends := 14
+		starts := ends + 2*segCount + 2
+		deltas := starts + 2*segCount
+		rangeOffsets := deltas + 2*segCount
+		if rangeOffsets+2*segCount > len(bmp) {
+			return errBadFont
+		}
+		for i := 0; i < segCount; i++ {
+			end := int(binary.BigEndian.Uint16(bmp[ends+2*i:]))
+			start := int(binary.BigEndian.Uint16(bmp[starts+2*i:]))
+			delta := int(binary.BigEndian.Uint16(bmp[deltas+2*i:]))
+			rangeOffset := int(binary.BigEndian.Uint16(bmp[rangeOffsets+2*i:]))
+			for c := start; c <= end && c != 0xffff; c++ {
+				glyph := 0
+				if rangeOffset == 0 {
+					glyph = (c + delta) & 0xffff
+				} else {
+					at := rangeOffsets + 2*i + rangeOffset + 2*(c-start)
+					if at+2 > len(bmp) {
+						continue
+					}
+					if glyph = int(binary.BigEndian.Uint16(bmp[at:])); glyph != 0 {
+						glyph = (glyph + delta) & 0xffff
+					}
+				}
+				if glyph != 0 {
+					f.cmap[rune(c)] = uint16(glyph)
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("%w: no Unicode character map", errBadFont)
+	}
+	return nil
+}
+
+// postScriptName reads the PostScript name (name ID 6) from the naming table
+func (f *Font) postScriptName() string {
+	name := f.tables["name"]
+	if len(name) < 6 {
+		return "Font"
+	}
+	count := int(binary.BigEndian.Uint16(name[2:]))
+	storage := int(binary.BigEndian.Uint16(name[4:]))
+	for i := 0; i < count; i++ {
+		record := 6 + 12*i
+		if record+12 > len(name) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(name[record:])
+		nameID := binary.BigEndian.Uint16(name[record+6:])
+		length := int(binary.BigEndian.Uint16(name[record+8:]))
+		offset := storage + int(binary.BigEndian.Uint16(name[record+10:]))
+		if nameID != 6 || offset+length > len(name) {
+			continue
+		}
+		raw := name[offset : offset+length]
+		var value []byte
+		if platform == 1 {
+			value = raw
+		} else {
+			// UTF-16BE; PostScript names are ASCII
+			for j := 1; j < len(raw); j += 2 {
+				value = append(value, raw[j])
+			}
+		}
+		if len(value) > 0 {
+			return string(value)
+		}
+	}
+	return "Font"
+}
+
+// glyph returns the glyph of a character, the missing glyph (0) if the font lacks it
+func (f *Font) glyph(r rune) uint16 {
+	if glyph := f.cmap[r]; int(glyph) < f.numGlyphs {
+		return glyph
+	}
+	return 0
+}
+
+// Width returns the width of the text set at the given size, in points
+func (f *Font) Width(text string, size float64) float64 {
+	units := 0
+	for _, r := range text {
+		units += f.advances[f.glyph(r)]
+	}
+	return float64(units) * size / float64(f.unitsPerEm)
+}
+
+// scale converts font units to the 1/1000 text space units of PDF
+func (f *Font) scale(units int) int {
+	return units * 1000 / f.unitsPerEm
+}
+
+// glyphData returns the outline of a glyph
+func (f *Font) glyphData(glyph uint16) []byte {
+	loca := f.tables["loca"]
+	glyf := f.tables["glyf"]
+	i := int(glyph)
+	var start, end int
+	if f.longLoca {
+		if 4*i+8 > len(loca) {
+			return nil
+		}
+		start = int(binary.BigEndian.Uint32(loca[4*i:]))
+		end = int(binary.BigEndian.Uint32(loca[4*i+4:]))
+	} else {
+		if 2*i+4 > len(loca) {
+			return nil
+		}
+		start = 2 * int(binary.BigEndian.Uint16(loca[2*i:]))
+		end = 2 * int(binary.BigEndian.Uint16(loca[2*i+2:]))
+	}
+	if start >= end || end > len(glyf) {
+		return nil
+	}
+	return glyf[start:end]
+}
+
+// Composite glyph flags
+const (
+	argsAreWords   = 0x0001
+	haveScale      = 0x0008
+	moreComponents = 0x0020
+	haveXYScale    = 0x0040
+	haveTwoByTwo   = 0x0080
+)
+
+// components returns the glyphs a composite glyph is built from
+func components(data []byte) []uint16 {
+	if len(data) < 10 || int16(binary.BigEndian.Uint16(data)) >= 0 {
+		return nil
+	}
+	var glyphs []uint16
+	for at := 10; at+4 <= len(data); {
+		flags := binary.BigEndian.Uint16(data[at:])
+		glyphs = append(glyphs, binary.BigEndian.Uint16(data[at+2:]))
+		at += 4
+		if flags&argsAreWords != 0 {
+			at += 4
+		} else {
+			at += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			at += 2
+		case flags&haveXYScale != 0:
+			at += 4
+		case flags&haveTwoByTwo != 0:
+			at += 8
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return glyphs
+}
+
+// subsetTables are copied into an embedded subset; glyf and loca are rebuilt
+var subsetTables = []string{"cmap", "cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "prep"}
+
+// Subset returns a font file keeping only the outlines of the given glyphs and of the
+// components they are built from. Glyph IDs are unchanged, so text can be shown with
+// the same IDs as in the full font; the other glyphs are left empty.
+func (f *Font) Subset(glyphs map[uint16]bool) []byte {
+	keep := make(map[uint16]bool)
+	pending := []uint16{0} // The missing glyph is always embedded
+	for glyph := range glyphs {
+		pending = append(pending, glyph)
+	}
+	for len(pending) > 0 {
+		glyph := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if keep[glyph] || int(glyph) >= f.numGlyphs {
+			continue
+		}
+		keep[glyph] = true
+		pending = append(pending, components(f.glyphData(glyph))...)
+	}
+
+	var glyf bytes.Buffer
+	loca := make([]byte, 4*(f.numGlyphs+1))
+	for i := 0; i < f.numGlyphs; i++ {
+		binary.BigEndian.PutUint32(loca[4*i:], uint32(glyf.Len()))
+		if keep[uint16(i)] {
+			glyf.Write(f.glyphData(uint16(i)))
+			for glyf.Len()%4 != 0 {
+				glyf.WriteByte(0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(loca[4*f.numGlyphs:], uint32(glyf.Len()))
+
+	head := append([]byte(nil), f.tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0)  // checkSumAdjustment, set below
+	binary.BigEndian.PutUint16(head[50:], 1) // Long loca offsets
+
+	tables := make(map[string][]byte)
+	for _, tag := range subsetTables {
+		if table, ok := f.tables[tag]; ok {
+			tables[tag] = table
+		}
+	}
+	tables["glyf"] = glyf.Bytes()
+	tables["loca"] = loca
+	tables["head"] = head
+
+	out, offsets := writeFontFile(tables)
+	binary.BigEndian.PutUint32(out[offsets["head"]+8:], 0xb1b0afba-checksum(out))
+	return out
+}
+
+// writeFontFile lays out a font file with the tables in tag order and returns it with the
+// offset of each table
+func writeFontFile(tables map[string][]byte) ([]byte, map[string]int) {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= len(tags) {
+		entrySelector++
+	}
+	searchRange := 16 << entrySelector
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.BigEndian, []uint32{0x00010000})
+	binary.Write(&out, binary.BigEndian, []uint16{
+		uint16(len(tags)), uint16(searchRange), uint16(entrySelector), uint16(16*len(tags) - searchRange),
+	})
+	offsets := make(map[string]int, len(tags))
+	offset := 12 + 16*len(tags)
+	for _, tag := range tags {
+		out.WriteString(tag)
+		binary.Write(&out, binary.BigEndian, []uint32{checksum(tables[tag]), uint32(offset), uint32(len(tables[tag]))})
+		offsets[tag] = offset
+		offset += (len(tables[tag]) + 3) &^ 3
+	}
+	for _, tag := range tags {
+		out.Write(tables[tag])
+		for out.Len()%4 != 0 {
+			out.WriteByte(0)
+		}
+	}
+	return out.Bytes(), offsets
+}
+
+// checksum sums data as big-endian 32-bit words, zero padded
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
@@ -0,0 +1,489 @@
+// Package pdf lays out simple A4 documents, such as ballots, attendance sheets and reports,
+// and writes them as PDF.
+//
+// Content flows from the top of the page down: text wraps to the page width and a new page
+// is started when the current one is full, repeating the header of a table that continues.
+// Text is set in DejaVu Sans, which covers the Latin (Romanian diacritics included) and
+// Cyrillic alphabets; only the glyphs used are embedded. The package only depends on the
+// standard library.
+package pdf
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Page geometry, in points
+const (
+	PageWidth  = 595.28 // A4
+	PageHeight = 841.89
+	Margin     = 50
+	TextWidth  = PageWidth - 2*Margin
+)
+
+// DefaultSize is the font size used when a style does not set one
+const DefaultSize = 10
+
+const (
+	lineSpacing = 1.35 // Line height as a multiple of the font size
+	footerSize  = 8
+	footerY     = 28 // Baseline of the footer above the bottom edge
+	cellPadding = 4
+	boxSize     = 9 // Side of a checkbox
+)
+
+//go:embed fonts/DejaVuSans.ttf
+var regularFontData []byte
+
+//go:embed fonts/DejaVuSans-Bold.ttf
+var boldFontData []byte
+
+var (
+	loadFontsOnce sync.Once
+	fonts         [2]*Font // Regular and bold
+	fontsErr      error
+)
+
+func loadFonts() ([2]*Font, error) {
+	loadFontsOnce.Do(func() {
+		for i, data := range [][]byte{regularFontData, boldFontData} {
+			if fonts[i], fontsErr = ParseFont(data); fontsErr != nil {
+				return
+			}
+		}
+	})
+	return fonts, fontsErr
+}
+
+// Align positions text horizontally
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// Style sets how text is drawn
+type Style struct {
+	Size   float64 // Font size in points, DefaultSize when zero
+	Bold   bool
+	Align  Align
+	Indent float64 // Left indent in points
+}
+
+func (s Style) size() float64 {
+	if s.Size <= 0 {
+		return DefaultSize
+	}
+	return s.Size
+}
+
+// Column is a column of a table
+type Column struct {
+	Header string
+	Width  float64 // Share of the table width, relative to the other columns
+	Align  Align
+}
+
+// Table is a grid of wrapped text. The header is repeated on every page the table spans.
+type Table struct {
+	Columns   []Column
+	Rows      [][]string
+	Total     []string // Last row, in bold, omitted when empty
+	Size      float64  // Font size, DefaultSize when zero
+	RowHeight float64  // Minimum row height, to leave room to write or sign in a cell
+}
+
+// usedFont is a font with the glyphs drawn with it, each with the text it stands for
+type usedFont struct {
+	font   *Font
+	glyphs map[uint16]rune
+}
+
+// Document is a PDF being laid out
+type Document struct {
+	// Footer returns the footer of a page, centered at its bottom. It is called when the
+	// document is written, once the number of pages is known.
+	Footer func(page, pages int) string
+
+	title string
+	fonts [2]*usedFont
+	pages []*bytes.Buffer
+	y     float64 // Distance of the cursor from the top of the current page
+}
+
+// New creates an empty document with the given title
+func New(title string) (*Document, error) {
+	loaded, err := loadFonts()
+	if err != nil {
+		return nil, err
+	}
+	d := &Document{title: title}
+	for i, font := range loaded {
+		d.fonts[i] = &usedFont{font: font, glyphs: make(map[uint16]rune)}
+	}
+	return d, nil
+}
+
+// AddPage starts a new page
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = Margin
+}
+
+// PageCount returns the number of pages started so far
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// EnsureSpace starts a new page unless the given height still fits on the current one
+func (d *Document) EnsureSpace(height float64) {
+	if len(d.pages) == 0 || d.y+height > PageHeight-Margin {
+		d.AddPage()
+	}
+}
+
+// Space moves the cursor down
+func (d *Document) Space(height float64) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	d.y += height
+}
+
+// Text draws a paragraph wrapped to the page width. Line breaks in the text are kept.
+func (d *Document) Text(text string, style Style) {
+	size := style.size()
+	font := d.font(style.Bold)
+	width := TextWidth - style.Indent
+	for _, line := range wrap(font.font, size, text, width) {
+		d.EnsureSpace(size * lineSpacing)
+		x := Margin + style.Indent + offset(style.Align, width, font.font.Width(line, size))
+		d.show(font, size, x, d.y+size, line)
+		d.y += size * lineSpacing
+	}
+}
+
+// TextHeight returns the height a paragraph takes when drawn with Text
+func (d *Document) TextHeight(text string, style Style) float64 {
+	size := style.size()
+	return float64(len(wrap(d.font(style.Bold).font, size, text, TextWidth-style.Indent))) * size * lineSpacing
+}
+
+// Rule draws a horizontal line across the page
+func (d *Document) Rule() {
+	d.EnsureSpace(6)
+	d.y += 3
+	d.line(Margin, d.y, PageWidth-Margin, d.y, 0.5)
+	d.y += 3
+}
+
+// Checkboxes draws an empty box before each label, in a row when they fit on one line and
+// one below the other otherwise
+func (d *Document) Checkboxes(labels []string, style Style) {
+	size := style.size()
+	font := d.font(style.Bold)
+	lineHeight := size * lineSpacing
+	gap := 2 * size
+
+	rowWidth := style.Indent
+	for _, label := range labels {
+		rowWidth += boxSize + size/2 + font.font.Width(label, size) + gap
+	}
+	if rowWidth-gap <= TextWidth {
+		d.EnsureSpace(lineHeight)
+		x := Margin + style.Indent
+		for _, label := range labels {
+			d.box(x, d.y+size)
+			x += boxSize + size/2
+			d.show(font, size, x, d.y+size, label)
+			x += font.font.Width(label, size) + gap
+		}
+		d.y += lineHeight
+		return
+	}
+
+	labelX := Margin + style.Indent + boxSize + size/2
+	for _, label := range labels {
+		lines := wrap(font.font, size, label, PageWidth-Margin-labelX)
+		for i, line := range lines {
+			d.EnsureSpace(lineHeight)
+			if i == 0 {
+				d.box(Margin+style.Indent, d.y+size)
+			}
+			d.show(font, size, labelX, d.y+size, line)
+			d.y += lineHeight
+		}
+		d.y += size / 4
+	}
+}
+
+// FillIn draws a label followed by a short line to write on, such as a rank or a number
+func (d *Document) FillIn(label string, style Style) {
+	const blank = 60
+	size := style.size()
+	font := d.font(style.Bold)
+	lineHeight := size * lineSpacing
+	lines := wrap(font.font, size, label, TextWidth-style.Indent-blank-size)
+
+	d.EnsureSpace(float64(len(lines))*lineHeight + size/2)
+	x := Margin + style.Indent
+	for i, line := range lines {
+		d.show(font, size, x, d.y+size, line)
+		if i == len(lines)-1 {
+			start := x + font.font.Width(line, size) + size/2
+			d.line(start, d.y+size, start+blank, d.y+size, 0.5)
+		}
+		d.y += lineHeight
+	}
+	d.y += size / 2
+}
+
+// SignatureLines draws a line to sign on for each caption, side by side
+func (d *Document) SignatureLines(captions []string) {
+	if len(captions) == 0 {
+		return
+	}
+	const gap = 30
+	height := 30 + footerSize*lineSpacing
+	d.EnsureSpace(height)
+	width := (TextWidth - gap*float64(len(captions)-1)) / float64(len(captions))
+	font := d.font(false)
+	for i, caption := range captions {
+		x := Margin + float64(i)*(width+gap)
+		d.line(x, d.y+24, x+width, d.y+24, 0.5)
+		d.show(font, footerSize, x+offset(AlignCenter, width, font.font.Width(caption, footerSize)), d.y+24+footerSize*1.2, caption)
+	}
+	d.y += height
+}
+
+// Matrix draws a square grid of dark and light modules, such as a QR code, the given number of
+// points wide. Light modules are left blank, so the page shows through as the quiet zone.
+func (d *Document) Matrix(dark [][]bool, width float64, align Align) {
+	if len(dark) == 0 {
+		return
+	}
+	d.EnsureSpace(width)
+	module := width / float64(len(dark))
+	left := Margin + offset(align, TextWidth, width)
+	page := d.pages[len(d.pages)-1]
+	page.WriteString("0 g\n")
+	for row, modules := range dark {
+		top := PageHeight - d.y - float64(row+1)*module
+		// A run of dark modules is drawn as one rectangle
+		for col := 0; col < len(modules); col++ {
+			if !modules[col] {
+				continue
+			}
+			run := col
+			for run < len(modules) && modules[run] {
+				run++
+			}
+			fmt.Fprintf(page, "%.3f %.3f %.3f %.3f re\n", left+float64(col)*module, top, float64(run-col)*module, module)
+			col = run
+		}
+	}
+	page.WriteString("f\n")
+	d.y += width
+}
+
+// Table draws a table across the page width
+func (d *Document) Table(table Table) {
+	size := table.Size
+	if size <= 0 {
+		size = DefaultSize
+	}
+	total := 0.0
+	for _, column := range table.Columns {
+		total += column.Width
+	}
+	widths := make([]float64, len(table.Columns))
+	for i, column := range table.Columns {
+		if total > 0 {
+			widths[i] = TextWidth * column.Width / total
+		} else {
+			widths[i] = TextWidth / float64(len(table.Columns))
+		}
+	}
+
+	header := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		header[i] = column.Header
+	}
+	drawHeader := func() {
+		d.tableRow(table, widths, header, size, true, true, 0)
+	}
+
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	// Keep the header with at least the first row
+	first := header
+	if len(table.Rows) > 0 {
+		first = table.Rows[0]
+	}
+	d.EnsureSpace(d.rowHeight(widths, header, size, true, 0) + d.rowHeight(widths, first, size, false, table.RowHeight))
+	drawHeader()
+
+	rows := table.Rows
+	if len(table.Total) > 0 {
+		rows = append(rows[:len(rows):len(rows)], table.Total)
+	}
+	for i, row := range rows {
+		bold := len(table.Total) > 0 && i == len(rows)-1
+		height := d.rowHeight(widths, row, size, bold, table.RowHeight)
+		if d.y+height > PageHeight-Margin {
+			d.AddPage()
+			drawHeader()
+		}
+		d.tableRow(table, widths, row, size, bold, false, table.RowHeight)
+	}
+}
+
+// rowHeight returns the height of a table row
+func (d *Document) rowHeight(widths []float64, cells []string, size float64, bold bool, minHeight float64) float64 {
+	font := d.font(bold).font
+	lines := 1
+	for i, width := range widths {
+		if i < len(cells) {
+			lines = max(lines, len(wrap(font, size, cells[i], width-2*cellPadding)))
+		}
+	}
+	return max(float64(lines)*size*lineSpacing+2*cellPadding, minHeight)
+}
+
+// tableRow draws a row of cells at the cursor and moves below it
+func (d *Document) tableRow(table Table, widths []float64, cells []string, size float64, bold, shaded bool, minHeight float64) {
+	font := d.font(bold)
+	height := d.rowHeight(widths, cells, size, bold, minHeight)
+	page := d.pages[len(d.pages)-1]
+	x := float64(Margin)
+	for i, width := range widths {
+		if shaded {
+			fmt.Fprintf(page, "0.9 g %.2f %.2f %.2f %.2f re f 0 g\n", x, PageHeight-d.y-height, width, height)
+		}
+		fmt.Fprintf(page, "0.5 w %.2f %.2f %.2f %.2f re S\n", x, PageHeight-d.y-height, width, height)
+		if i < len(cells) {
+			align := table.Columns[i].Align
+			if shaded {
+				align = AlignLeft
+			}
+			baseline := d.y + cellPadding + size
+			for _, line := range wrap(font.font, size, cells[i], width-2*cellPadding) {
+				d.show(font, size, x+cellPadding+offset(align, width-2*cellPadding, font.font.Width(line, size)), baseline, line)
+				baseline += size * lineSpacing
+			}
+		}
+		x += width
+	}
+	d.y += height
+}
+
+func (d *Document) font(bold bool) *usedFont {
+	if bold {
+		return d.fonts[1]
+	}
+	return d.fonts[0]
+}
+
+// show draws a line of text with its baseline at the given distance from the top of the page
+func (d *Document) show(font *usedFont, size, x, baseline float64, text string) {
+	d.showOn(d.pages[len(d.pages)-1], font, size, x, baseline, text)
+}
+
+// showOn draws a line of text on the given page content
+func (d *Document) showOn(page *bytes.Buffer, font *usedFont, size, x, baseline float64, text string) {
+	if text == "" {
+		return
+	}
+	var glyphs strings.Builder
+	for _, r := range text {
+		glyph := font.font.glyph(r)
+		if _, ok := font.glyphs[glyph]; !ok {
+			font.glyphs[glyph] = r
+		}
+		fmt.Fprintf(&glyphs, "%04X", glyph)
+	}
+	fmt.Fprintf(page, "BT /F%d %.2f Tf %.2f %.2f Td <%s> Tj ET\n",
+		d.fontIndex(font), size, x, PageHeight-baseline, glyphs.String())
+}
+
+func (d *Document) fontIndex(font *usedFont) int {
+	if font == d.fonts[1] {
+		return 1
+	}
+	return 0
+}
+
+// line draws a straight line between two points given from the top of the page
+func (d *Document) line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.pages[len(d.pages)-1], "%.2f w %.2f %.2f m %.2f %.2f l S\n",
+		width, x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+// box draws a checkbox sitting on the given baseline
+func (d *Document) box(x, baseline float64) {
+	fmt.Fprintf(d.pages[len(d.pages)-1], "0.8 w %.2f %.2f %d %d re S\n", x, PageHeight-baseline-1, boxSize, boxSize)
+}
+
+// offset returns where text of the given width starts within a space for the alignment
+func offset(align Align, space, width float64) float64 {
+	switch align {
+	case AlignCenter:
+		return (space - width) / 2
+	case AlignRight:
+		return space - width
+	default:
+		return 0
+	}
+}
+
+// wrap breaks text into lines that fit the width, at spaces when possible. Line breaks in
+// the text are kept and blank lines are returned empty.
+func wrap(font *Font, size float64, text string, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		line := ""
+		for _, word := range words {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if font.Width(candidate, size) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			// Words wider than the line are broken where they reach the edge
+			for font.Width(word, size) > width && utf8.RuneCountInString(word) > 1 {
+				cut := 0
+				for i := range word {
+					if i > 0 && font.Width(word[:i], size) > width {
+						break
+					}
+					cut = i
+				}
+				if cut == 0 {
+					_, cut = utf8.DecodeRuneInString(word)
+				}
+				lines = append(lines, word[:cut])
+				word = word[cut:]
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,159 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestWrap tests that text is broken into lines that fit the width
+func TestWrap(t *testing.T) {
+	fonts, err := loadFonts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	font := fonts[0]
+	// Every DejaVu Sans digit is 0.636 em wide: 6.36pt at size 10
+	tests := []struct {
+		name     string
+		text     string
+		width    float64
+		expected []string
+	}{
+		{name: "fits", text: "11 22", width: 100, expected: []string{"11 22"}},
+		{name: "breaks at spaces", text: "11 22 33", width: 40, expected: []string{"11 22", "33"}},
+		{name: "keeps line breaks", text: "11\n\n22", width: 100, expected: []string{"11", "", "22"}},
+		{name: "breaks long words", text: "123456789", width: 40, expected: []string{"123456", "789"}},
+		{name: "empty", text: "", width: 100, expected: []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := wrap(font, 10, tt.text, tt.width)
+			if !reflect.DeepEqual(lines, tt.expected) {
+				t.Errorf("wrap() = %q, want %q", lines, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSubset tests that a subset keeps the outlines of the glyphs used, their components and
+// their IDs, and is a valid font file
+func TestSubset(t *testing.T) {
+	fonts, err := loadFonts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	font := fonts[0]
+
+	used := map[uint16]bool{font.glyph('A'): true, font.glyph('ș'): true, font.glyph('Ж'): true}
+	subset, err := ParseFont(font.Subset(used))
+	if err != nil {
+		t.Fatalf("ParseFont(subset) unexpected error: %v", err)
+	}
+	if checksum(font.Subset(used)) != 0xb1b0afba {
+		t.Error("subset checksum adjustment is wrong")
+	}
+	if subset.numGlyphs != font.numGlyphs {
+		t.Errorf("subset has %d glyphs, want %d", subset.numGlyphs, font.numGlyphs)
+	}
+
+	for glyph := range used {
+		if !bytes.Equal(subset.glyphData(glyph), font.glyphData(glyph)) {
+			t.Errorf("glyph %d outline not kept", glyph)
+		}
+		// ș is built from s and the comma below
+		for _, component := range components(font.glyphData(glyph)) {
+			if !bytes.Equal(subset.glyphData(component), font.glyphData(component)) {
+				t.Errorf("component %d of glyph %d not kept", component, glyph)
+			}
+		}
+	}
+	if unused := font.glyph('Z'); subset.glyphData(unused) != nil {
+		t.Errorf("unused glyph %d kept", unused)
+	}
+}
+
+// TestDocument tests that a laid out document is a well-formed PDF with searchable text
+func TestDocument(t *testing.T) {
+	d, err := New("Buletin de vot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Footer = func(page, pages int) string {
+		return "Pagina " + strconv.Itoa(page) + " / " + strconv.Itoa(pages)
+	}
+	d.Text("BULETIN DE VOT", Style{Size: 16, Bold: true, Align: AlignCenter})
+	d.Text("Abțineri și împuterniciri — Голосование", Style{})
+	d.Checkboxes([]string{"DA", "NU", "ABȚINERE"}, Style{})
+	d.FillIn("Opțiunea A", Style{})
+	rows := make([][]string, 80)
+	for i := range rows {
+		rows[i] = []string{strconv.Itoa(i + 1), "Ion Popescu", ""}
+	}
+	d.Table(Table{
+		Columns:   []Column{{Header: "Nr.", Width: 1}, {Header: "Nume", Width: 4}, {Header: "Semnătură", Width: 3}},
+		Rows:      rows,
+		Total:     []string{"", "Total", ""},
+		RowHeight: 24,
+	})
+	d.SignatureLines([]string{"Președinte", "Secretar"})
+	d.Matrix([][]bool{{true, true, false}, {false, true, false}, {true, false, true}}, 60, AlignCenter)
+
+	data := d.Bytes()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+	if d.PageCount() < 2 {
+		t.Errorf("table did not flow onto a second page, got %d pages", d.PageCount())
+	}
+	if !bytes.Equal(data, d.Bytes()) {
+		t.Error("writing the document again gave a different file")
+	}
+
+	// Every cross-reference entry points at its object
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	if startxref == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(startxref[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1)
+	for i, entry := range entries {
+		at, _ := strconv.Atoi(string(entry[1]))
+		if prefix := strconv.Itoa(i+1) + " 0 obj"; !bytes.HasPrefix(data[at:], []byte(prefix)) {
+			t.Errorf("xref entry %d points at %q", i+1, data[at:min(at+12, len(data))])
+		}
+	}
+
+	// The text is recoverable through the ToUnicode maps
+	var content, cmaps strings.Builder
+	streams := regexp.MustCompile(`(?s)/Length (\d+) /Filter /FlateDecode[^>]*>>\nstream\n`)
+	for _, match := range streams.FindAllSubmatchIndex(data, -1) {
+		length, _ := strconv.Atoi(string(data[match[2]:match[3]]))
+		z, err := zlib.NewReader(bytes.NewReader(data[match[1] : match[1]+length]))
+		if err != nil {
+			t.Fatalf("stream does not decompress: %v", err)
+		}
+		decoded, _ := io.ReadAll(z)
+		switch {
+		case bytes.Contains(decoded, []byte("begincmap")):
+			cmaps.Write(decoded)
+		case bytes.Contains(decoded, []byte(" Tj ET")):
+			content.Write(decoded)
+		}
+	}
+	for _, r := range "ȚșГолосование" {
+		if entry := fmt.Sprintf("<%04X> <%04X>", d.fonts[0].font.glyph(r), r); !strings.Contains(cmaps.String(), entry) {
+			t.Errorf("no ToUnicode entry for %q", r)
+		}
+	}
+	if !strings.Contains(content.String(), "/F1 16.00 Tf") {
+		t.Error("title not set in bold")
+	}
+}
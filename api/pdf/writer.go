@@ -0,0 +1,193 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// Bytes returns the document as a PDF file, with the page footers drawn
+func (d *Document) Bytes() []byte {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	// Footers are drawn on copies so the document can be written again
+	contents := make([][]byte, len(d.pages))
+	for i, page := range d.pages {
+		contents[i] = page.Bytes()
+		if d.Footer == nil {
+			continue
+		}
+		if text := d.Footer(i+1, len(d.pages)); text != "" {
+			font := d.font(false)
+			footer := bytes.NewBuffer(append([]byte(nil), contents[i]...))
+			x := Margin + offset(AlignCenter, TextWidth, font.font.Width(text, footerSize))
+			d.showOn(footer, font, footerSize, x, PageHeight-footerY, text)
+			contents[i] = footer.Bytes()
+		}
+	}
+
+	w := &writer{}
+	const (
+		catalogID = 1
+		pagesID   = 2
+		infoID    = 3
+	)
+	next := infoID + 1
+
+	// Each font is a Type0 font with a CIDFontType2 descendant, its descriptor, the embedded
+	// subset and the map back to Unicode, in that order
+	fontIDs := make([]int, len(d.fonts))
+	for i := range d.fonts {
+		fontIDs[i] = next
+		next += 5
+	}
+	pageIDs := make([]int, len(d.pages))
+	for i := range d.pages {
+		pageIDs[i] = next
+		next += 2
+	}
+	w.offsets = make([]int, next)
+
+	w.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	w.object(catalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+
+	kids := make([]string, len(pageIDs))
+	for i, id := range pageIDs {
+		kids[i] = fmt.Sprintf("%d 0 R", id)
+	}
+	w.object(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pageIDs)))
+	w.object(infoID, fmt.Sprintf("<< /Title %s /Producer (apc) >>", textString(d.title)))
+
+	for i, font := range d.fonts {
+		w.font(fontIDs[i], font)
+	}
+
+	resources := fmt.Sprintf("<< /Font << /F0 %d 0 R /F1 %d 0 R >> >>", fontIDs[0], fontIDs[1])
+	for i := range d.pages {
+		w.object(pageIDs[i], fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources %s /Contents %d 0 R >>",
+			pagesID, PageWidth, PageHeight, resources, pageIDs[i]+1))
+		w.stream(pageIDs[i]+1, "", contents[i])
+	}
+
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets))
+	for _, at := range w.offsets[1:] {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", at)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(w.offsets), catalogID, infoID, xref)
+	return w.buf.Bytes()
+}
+
+// writer serializes numbered objects and records where each starts for the cross-reference table
+type writer struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func (w *writer) object(id int, body string) {
+	w.offsets[id] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// stream writes a compressed stream; entries are added to its dictionary
+func (w *writer) stream(id int, entries string, data []byte) {
+	var compressed bytes.Buffer
+	z := zlib.NewWriter(&compressed)
+	z.Write(data)
+	z.Close()
+
+	w.offsets[id] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< /Length %d /Filter /FlateDecode%s >>\nstream\n", id, compressed.Len(), entries)
+	w.buf.Write(compressed.Bytes())
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+// font writes the objects of a font starting at id, embedding the glyphs used
+func (w *writer) font(id int, used *usedFont) {
+	glyphs := make([]uint16, 0, len(used.glyphs))
+	keep := make(map[uint16]bool, len(used.glyphs))
+	for glyph := range used.glyphs {
+		glyphs = append(glyphs, glyph)
+		keep[glyph] = true
+	}
+	sort.Slice(glyphs, func(i, j int) bool { return glyphs[i] < glyphs[j] })
+
+	font := used.font
+	name := subsetTag(font.name, glyphs) + "+" + font.name
+
+	w.object(id, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		name, id+1, id+4))
+
+	var widths strings.Builder
+	for _, glyph := range glyphs {
+		fmt.Fprintf(&widths, "%d [%d] ", glyph, font.scale(font.advances[glyph]))
+	}
+	w.object(id+1, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s "+
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+		"/FontDescriptor %d 0 R /CIDToGIDMap /Identity /DW %d /W [%s] >>",
+		name, id+2, font.scale(font.advances[0]), strings.TrimSpace(widths.String())))
+
+	w.object(id+2, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags %d /FontBBox [%d %d %d %d] "+
+		"/ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		name, font.flags, font.scale(font.bbox[0]), font.scale(font.bbox[1]), font.scale(font.bbox[2]), font.scale(font.bbox[3]),
+		font.scale(font.ascent), font.scale(font.descent), font.scale(font.capHeight), id+3))
+
+	subset := font.Subset(keep)
+	w.stream(id+3, fmt.Sprintf(" /Length1 %d", len(subset)), subset)
+	w.stream(id+4, "", toUnicode(glyphs, used.glyphs))
+}
+
+// subsetTag derives the six capital letters that prefix the name of an embedded subset
+func subsetTag(name string, glyphs []uint16) string {
+	h := sha256.New()
+	h.Write([]byte(name))
+	for _, glyph := range glyphs {
+		h.Write([]byte{byte(glyph >> 8), byte(glyph)})
+	}
+	sum := h.Sum(nil)
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + sum[i]%26
+	}
+	return string(tag)
+}
+
+// toUnicode builds the CMap that lets viewers extract and search the text
+func toUnicode(glyphs []uint16, text map[uint16]rune) []byte {
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(glyphs); start += 100 {
+		end := min(start+100, len(glyphs))
+		fmt.Fprintf(&b, "%d beginbfchar\n", end-start)
+		for _, glyph := range glyphs[start:end] {
+			fmt.Fprintf(&b, "<%04X> <", glyph)
+			for _, unit := range utf16.Encode([]rune{text[glyph]}) {
+				fmt.Fprintf(&b, "%04X", unit)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+// textString encodes a text string as UTF-16BE with a byte order mark
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	b.WriteString(">")
+	return b.String()
+}
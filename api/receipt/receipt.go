@@ -0,0 +1,133 @@
+// Package receipt creates and verifies the signed receipts handed out for recorded ballots.
+//
+// A receipt is compact text of the form "<payload>.<signature>", both parts base64url encoded
+// without padding. The payload is JSON naming the ballot and its SHA-256 hash; the signature is
+// an Ed25519 signature over the encoded payload made with the association's signing key.
+//
+// Owners can check a receipt offline with Verify and the association's public key, and then
+// compare the ballot hash with the one published for the gathering to prove the ballot was
+// recorded unchanged. The package only depends on the standard library.
+package receipt
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Version is the receipt format produced by Sign
+const Version = 1
+
+var (
+	// ErrMalformed is returned when a receipt cannot be decoded
+	ErrMalformed = errors.New("malformed receipt")
+	// ErrInvalidSignature is returned when the signature does not match the payload and key
+	ErrInvalidSignature = errors.New("invalid receipt signature")
+)
+
+var encoding = base64.RawURLEncoding
+
+// Payload is the signed content of a receipt
+type Payload struct {
+	Version       int    `json:"v"`
+	KeyID         string `json:"k"` // Identifies the signing key, see KeyID
+	AssociationID int64  `json:"a"`
+	GatheringID   int64  `json:"g"`
+	BallotID      int64  `json:"b"`
+	BallotHash    string `json:"h"` // Hex SHA-256 of the recorded ballot content
+	SignedAt      int64  `json:"t"` // Unix seconds
+}
+
+// SignedTime returns the signing time of the payload
+func (p Payload) SignedTime() time.Time {
+	return time.Unix(p.SignedAt, 0).UTC()
+}
+
+// Sign encodes the payload and signs it with the private key
+func Sign(key ed25519.PrivateKey, payload Payload) (string, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return "", errors.New("invalid private key")
+	}
+	if payload.Version == 0 {
+		payload.Version = Version
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode receipt: %w", err)
+	}
+	encoded := encoding.EncodeToString(data)
+	signature := ed25519.Sign(key, []byte(encoded))
+	return encoded + "." + encoding.EncodeToString(signature), nil
+}
+
+// Parse decodes a receipt without checking its signature. Use it to find the key to verify with.
+func Parse(receipt string) (Payload, []byte, error) {
+	encoded, sig, found := strings.Cut(strings.TrimSpace(receipt), ".")
+	if !found {
+		return Payload{}, nil, ErrMalformed
+	}
+	data, err := encoding.DecodeString(encoded)
+	if err != nil {
+		return Payload{}, nil, ErrMalformed
+	}
+	signature, err := encoding.DecodeString(sig)
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return Payload{}, nil, ErrMalformed
+	}
+	var payload Payload
+	if err := json.Unmarshal(data, &payload); err != nil || payload.Version != Version {
+		return Payload{}, nil, ErrMalformed
+	}
+	return payload, signature, nil
+}
+
+// Verify checks the receipt signature against the public key and returns the signed payload
+func Verify(key ed25519.PublicKey, receipt string) (Payload, error) {
+	payload, signature, err := Parse(receipt)
+	if err != nil {
+		return Payload{}, err
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return Payload{}, errors.New("invalid public key")
+	}
+	encoded, _, _ := strings.Cut(strings.TrimSpace(receipt), ".")
+	if !ed25519.Verify(key, []byte(encoded), signature) {
+		return Payload{}, ErrInvalidSignature
+	}
+	if payload.KeyID != KeyID(key) {
+		return Payload{}, ErrInvalidSignature
+	}
+	return payload, nil
+}
+
+// Signature returns the base64url encoded signature part of a receipt
+func Signature(receipt string) string {
+	_, sig, _ := strings.Cut(strings.TrimSpace(receipt), ".")
+	return sig
+}
+
+// KeyID returns the short fingerprint identifying a public key: the first 16 hex digits of its SHA-256
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// EncodePublicKey returns the base64 (standard encoding) form of a public key
+func EncodePublicKey(key ed25519.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// DecodePublicKey parses a public key produced by EncodePublicKey
+func DecodePublicKey(s string) (ed25519.PublicKey, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(data) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid public key")
+	}
+	return ed25519.PublicKey(data), nil
+}
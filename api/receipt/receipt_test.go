@@ -0,0 +1,72 @@
+package receipt
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestSignAndVerify tests that receipts verify only with the signing key and unaltered content
+func TestSignAndVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+
+	payload := Payload{
+		KeyID:         KeyID(pub),
+		AssociationID: 1,
+		GatheringID:   2,
+		BallotID:      3,
+		BallotHash:    "abc123",
+		SignedAt:      1700000000,
+	}
+	signed, err := Sign(priv, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, sig, _ := strings.Cut(signed, ".")
+	tampered, _ := Sign(priv, Payload{KeyID: KeyID(pub), BallotID: 4, BallotHash: "abc123"})
+	tamperedPayload, _, _ := strings.Cut(tampered, ".")
+
+	tests := []struct {
+		name          string
+		key           ed25519.PublicKey
+		receipt       string
+		expectedError error
+	}{
+		{"valid receipt", pub, signed, nil},
+		{"surrounding whitespace", pub, " " + signed + "\n", nil},
+		{"other key", otherPub, signed, ErrInvalidSignature},
+		{"payload swapped", pub, tamperedPayload + "." + sig, ErrInvalidSignature},
+		{"missing signature", pub, encoded, ErrMalformed},
+		{"garbage", pub, "not.a-receipt", ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Verify(tt.key, tt.receipt)
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Verify() error = %v, expected %v", err, tt.expectedError)
+			}
+			if err == nil && (got.BallotID != payload.BallotID || got.BallotHash != payload.BallotHash || got.Version != Version) {
+				t.Errorf("Verify() payload = %+v, expected %+v", got, payload)
+			}
+		})
+	}
+}
+
+// TestPublicKeyEncoding tests the public key round trip
+func TestPublicKeyEncoding(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	decoded, err := DecodePublicKey(EncodePublicKey(pub))
+	if err != nil || !decoded.Equal(pub) {
+		t.Errorf("DecodePublicKey() = %v, %v, expected the original key", decoded, err)
+	}
+	if _, err := DecodePublicKey("c2hvcnQ="); err == nil {
+		t.Error("DecodePublicKey() accepted a short key")
+	}
+}
@@ -0,0 +1,31 @@
+-- name: CreateAnonymousVote :exec
+INSERT INTO anonymous_votes (receipt, gathering_id, voting_matter_id, vote_values)
+VALUES (?, ?, ?, ?);
+
+-- name: GetAnonymousVotesByReceipt :many
+SELECT * FROM anonymous_votes
+WHERE gathering_id = ?
+  AND receipt = ?
+  AND is_valid = TRUE
+ORDER BY voting_matter_id;
+
+-- name: InvalidateAnonymousVotes :execrows
+UPDATE anonymous_votes
+SET is_valid = FALSE
+WHERE gathering_id = ?
+  AND receipt = ?
+  AND is_valid = TRUE;
+
+-- name: AddAnonymousVoteTotals :exec
+INSERT INTO anonymous_vote_totals (gathering_id, voting_matter_id, vote_values, votes, units_part, units_count)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (gathering_id, voting_matter_id, vote_values) DO UPDATE
+    SET votes       = votes + excluded.votes,
+        units_part  = units_part + excluded.units_part,
+        units_count = units_count + excluded.units_count;
+
+-- name: GetAnonymousVoteTotals :many
+SELECT * FROM anonymous_vote_totals
+WHERE gathering_id = ?
+  AND votes > 0
+ORDER BY voting_matter_id, vote_values;
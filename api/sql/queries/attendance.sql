@@ -0,0 +1,25 @@
+-- name: CreateAttendanceEvent :one
+INSERT INTO attendance_events (gathering_id, participant_id, event_type, recorded_by)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetAttendanceEvents :many
+SELECT ae.id,
+       ae.gathering_id,
+       ae.participant_id,
+       ae.event_type,
+       ae.occurred_at,
+       ae.recorded_by,
+       gp.participant_name
+FROM attendance_events ae
+         JOIN gathering_participants gp ON ae.participant_id = gp.id
+WHERE ae.gathering_id = ?
+ORDER BY ae.occurred_at, ae.id;
+
+-- name: ReEnterParticipant :execrows
+UPDATE gathering_participants
+SET check_out_time = NULL,
+    updated_at     = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND gathering_id = ?
+  AND check_out_time IS NOT NULL;
@@ -0,0 +1,70 @@
+-- name: CreateDelegation :one
+INSERT INTO delegations (gathering_id, delegate_name, delegate_identification, document_ref, valid_from, valid_until)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: CreateDelegationUnit :exec
+INSERT INTO delegation_units (delegation_id, owner_id, unit_id)
+VALUES (?, ?, ?);
+
+-- name: GetDelegation :one
+SELECT *
+FROM delegations
+WHERE id = ?
+  AND gathering_id = ?;
+
+-- name: GetDelegationsForGathering :many
+SELECT *
+FROM delegations
+WHERE gathering_id = ?
+ORDER BY id;
+
+-- name: GetDelegationUnitsForGathering :many
+SELECT du.delegation_id,
+       du.owner_id,
+       du.unit_id,
+       du.revoked_at,
+       du.revocation_note,
+       o.name AS owner_name,
+       d.delegate_name,
+       d.delegate_identification,
+       d.valid_from,
+       d.valid_until
+FROM delegation_units du
+         JOIN delegations d ON d.id = du.delegation_id
+         JOIN owners o ON o.id = du.owner_id
+WHERE d.gathering_id = ?
+ORDER BY du.delegation_id, du.owner_id, du.unit_id;
+
+-- name: RevokeDelegationUnits :execrows
+UPDATE delegation_units
+SET revoked_at      = ?,
+    revocation_note = ?
+WHERE delegation_id = ?
+  AND owner_id = ?
+  AND revoked_at IS NULL;
+
+-- name: GetDelegationDocument :one
+SELECT *
+FROM delegation_documents
+WHERE delegation_id = ?;
+
+-- name: GetDelegationDocumentsForGathering :many
+SELECT dd.delegation_id, dd.file_name, dd.content_type, length(dd.content) AS size, dd.uploaded_at
+FROM delegation_documents dd
+         JOIN delegations d ON d.id = dd.delegation_id
+WHERE d.gathering_id = ?;
+
+-- name: UpsertDelegationDocument :exec
+INSERT INTO delegation_documents (delegation_id, file_name, content_type, content)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (delegation_id) DO UPDATE SET file_name    = excluded.file_name,
+                                          content_type = excluded.content_type,
+                                          content      = excluded.content,
+                                          uploaded_at  = CURRENT_TIMESTAMP;
+
+-- name: GetOwnerParticipations :many
+SELECT *
+FROM gathering_participants
+WHERE gathering_id = ?
+  AND (owner_id = ? OR delegating_owner_id = ?);
@@ -0,0 +1,50 @@
+-- name: CreateGatheringTemplate :one
+INSERT INTO gathering_templates (association_id, name, title, description, intent, location, gathering_type,
+                                 voting_mode, qualification_unit_types, qualification_floors,
+                                 qualification_entrances, qualification_custom_rule)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: GetGatheringTemplates :many
+SELECT *
+FROM gathering_templates
+WHERE association_id = ?
+ORDER BY name, id;
+
+-- name: GetGatheringTemplate :one
+SELECT *
+FROM gathering_templates
+WHERE id = ?
+  AND association_id = ?;
+
+-- name: DeleteGatheringTemplate :execrows
+DELETE
+FROM gathering_templates
+WHERE id = ?
+  AND association_id = ?;
+
+-- name: DeleteGatheringTemplateMatters :exec
+DELETE
+FROM gathering_template_matters
+WHERE template_id = (SELECT id FROM gathering_templates WHERE gathering_templates.id = ? AND association_id = ?);
+
+-- name: CopyVotingMattersToTemplate :execrows
+INSERT INTO gathering_template_matters (template_id, order_index, title, title_ru, description, description_ru,
+                                        matter_type, voting_config, is_informative)
+SELECT sqlc.arg(template_id),
+       order_index,
+       title,
+       title_ru,
+       description,
+       description_ru,
+       matter_type,
+       voting_config,
+       is_informative
+FROM voting_matters
+WHERE gathering_id = sqlc.arg(gathering_id)
+ORDER BY order_index;
+
+-- name: GetGatheringTemplateMatters :many
+SELECT *
+FROM gathering_template_matters
+WHERE template_id = ?
+ORDER BY order_index;
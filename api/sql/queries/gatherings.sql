@@ -15,8 +15,36 @@ INSERT INTO gatherings (association_id, title, description, intent, location, ga
                         gathering_type, voting_mode, status, qualification_unit_types,
                         qualification_floors, qualification_entrances,
                         qualification_custom_rule, qualified_units_count, qualified_units_total_part,
-                        qualified_units_total_area)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+                        qualified_units_total_area, opens_at, closes_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: CreateRepeatedGathering :one
+INSERT INTO gatherings (association_id, title, description, intent, location, gathering_date,
+                        gathering_type, voting_mode, status, qualification_unit_types,
+                        qualification_floors, qualification_entrances, qualification_custom_rule,
+                        repeated_from_id)
+SELECT association_id,
+       sqlc.arg(title),
+       description,
+       intent,
+       sqlc.arg(location),
+       sqlc.arg(gathering_date),
+       'repeated',
+       voting_mode,
+       'draft',
+       qualification_unit_types,
+       qualification_floors,
+       qualification_entrances,
+       qualification_custom_rule,
+       id
+FROM gatherings
+WHERE id = sqlc.arg(source_id)
+  AND association_id = sqlc.arg(association_id) RETURNING *;
+
+-- name: GetRepeatedGathering :one
+SELECT *
+FROM gatherings
+WHERE repeated_from_id = ?;
 
 -- name: UpdateGathering :one
 UPDATE gatherings
@@ -27,6 +55,8 @@ SET title                          = ?,
     gathering_date                 = ?,
     gathering_type                 = ?,
     voting_mode                    = ?,
+    opens_at                       = ?,
+    closes_at                      = ?,
     qualification_unit_types       = ?,
     qualification_floors           = ?,
     qualification_entrances        = ?,
@@ -77,8 +107,14 @@ FROM unit_slots us
 WHERE us.gathering_id = ?
   AND us.id = ?;
 -- name: CreateUnitSlot :one
-INSERT INTO unit_slots (gathering_id, unit_id)
-VALUES (?, ?) RETURNING *;
+INSERT INTO unit_slots (gathering_id, unit_id, owner_id, share)
+VALUES (?, ?, ?, ?) RETURNING *;
+
+-- name: DeleteUnassignedUnitSlots :exec
+DELETE
+FROM unit_slots
+WHERE gathering_id = ?
+  AND participant_id IS NULL;
 
 -- name: AssignUnitSlot :one
 UPDATE unit_slots
@@ -86,7 +122,21 @@ SET participant_id = ?,
     updated_at     = CURRENT_TIMESTAMP
 WHERE gathering_id = ?
   AND participant_id IS NULL
-  AND unit_id = ? RETURNING *;
+  AND unit_id = ?
+  AND owner_id IN (0, sqlc.arg(owner_id)) RETURNING *;
+
+-- name: GetCoOwnerUnitSlots :many
+SELECT us.unit_id,
+       us.owner_id,
+       us.participant_id,
+       us.share,
+       vru.part
+FROM unit_slots us
+         JOIN voter_register_units vru ON vru.gathering_id = us.gathering_id AND vru.unit_id = us.unit_id
+WHERE us.gathering_id = ?
+  AND us.owner_id != 0
+  AND us.share < 1
+ORDER BY us.unit_id, us.owner_id;
 
 -- name: RemoveUnitSlot :exec
 DELETE
@@ -99,6 +149,72 @@ SET status     = ?,
 WHERE id = ?
   AND association_id = ? RETURNING *;
 
+-- name: TransitionGatheringStatus :one
+UPDATE gatherings
+SET status     = sqlc.arg(to_status),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)
+  AND association_id = sqlc.arg(association_id)
+  AND status = sqlc.arg(from_status) RETURNING *;
+
+-- name: SetGatheringVotingRules :exec
+UPDATE gatherings
+SET voting_rules = ?
+WHERE id = ?;
+
+-- name: SetGatheringRegisterFrozenAt :exec
+UPDATE gatherings
+SET register_frozen_at = ?
+WHERE id = ?;
+
+-- name: SetGatheringCoOwnershipPolicy :exec
+UPDATE gatherings
+SET co_ownership_policy = ?,
+    updated_at          = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: SetGatheringMemberVerification :exec
+UPDATE gatherings
+SET member_verification = ?,
+    updated_at          = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: SetGatheringVotingWindows :exec
+UPDATE gatherings
+SET voting_windows = ?,
+    updated_at     = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: GetGatheringsDueToOpen :many
+SELECT *
+FROM gatherings
+WHERE status = 'published'
+  AND gathering_type = 'remote'
+  AND opens_at IS NOT NULL
+  AND opens_at <= sqlc.arg(now)
+ORDER BY opens_at;
+
+-- name: GetGatheringsDueToClose :many
+SELECT *
+FROM gatherings
+WHERE status = 'active'
+  AND gathering_type = 'remote'
+  AND closes_at IS NOT NULL
+  AND closes_at <= sqlc.arg(now)
+ORDER BY closes_at;
+
+-- name: ClearPassedGatheringClose :exec
+UPDATE gatherings
+SET closes_at  = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)
+  AND closes_at <= sqlc.arg(now);
+
+-- name: CountGatheringUnitSlots :one
+SELECT COUNT(*) as count
+FROM unit_slots
+WHERE gathering_id = ?;
+
 -- name: UpdateGatheringStats :exec
 UPDATE gatherings
 SET qualified_units_count      = ?,
@@ -121,6 +237,11 @@ FROM voting_matters
 WHERE gathering_id = ?
 ORDER BY order_index;
 
+-- name: CountVotingMatters :one
+SELECT COUNT(*) as count
+FROM voting_matters
+WHERE gathering_id = ?;
+
 -- name: GetVotingMatter :one
 SELECT *
 FROM voting_matters
@@ -131,6 +252,22 @@ WHERE id = ?
 INSERT INTO voting_matters (gathering_id, order_index, title, title_ru, description, description_ru, matter_type, voting_config, is_informative)
 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
 
+-- name: CopyVotingMatters :execrows
+INSERT INTO voting_matters (gathering_id, order_index, title, title_ru, description, description_ru, matter_type,
+                            voting_config, is_informative)
+SELECT sqlc.arg(to_gathering_id),
+       order_index,
+       title,
+       title_ru,
+       description,
+       description_ru,
+       matter_type,
+       voting_config,
+       is_informative
+FROM voting_matters
+WHERE gathering_id = sqlc.arg(from_gathering_id)
+ORDER BY order_index;
+
 -- name: UpdateVotingMatter :one
 UPDATE voting_matters
 SET title          = ?,
@@ -151,6 +288,46 @@ FROM voting_matters
 WHERE id = ?
   AND gathering_id = ?;
 
+-- name: OpenVotingMatter :one
+UPDATE voting_matters
+SET voting_state     = 'open',
+    voting_opened_at = CURRENT_TIMESTAMP,
+    updated_at       = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND gathering_id = ?
+  AND voting_state = 'pending' RETURNING *;
+
+-- name: CloseVotingMatter :one
+UPDATE voting_matters
+SET voting_state     = 'closed',
+    voting_closed_at = CURRENT_TIMESTAMP,
+    updated_at       = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND gathering_id = ?
+  AND voting_state = 'open' RETURNING *;
+
+-- name: CloseOpenVotingMatters :execrows
+UPDATE voting_matters
+SET voting_state     = 'closed',
+    voting_closed_at = CURRENT_TIMESTAMP,
+    updated_at       = CURRENT_TIMESTAMP
+WHERE gathering_id = ?
+  AND voting_state = 'open';
+
+-- name: OpenVotingWindow :exec
+UPDATE voting_matters
+SET voting_opened_at = COALESCE(voting_opened_at, CURRENT_TIMESTAMP),
+    voting_closed_at = NULL,
+    updated_at       = CURRENT_TIMESTAMP
+WHERE gathering_id = ?;
+
+-- name: CloseVotingWindow :exec
+UPDATE voting_matters
+SET voting_closed_at = CURRENT_TIMESTAMP,
+    updated_at       = CURRENT_TIMESTAMP
+WHERE gathering_id = ?
+  AND voting_closed_at IS NULL;
+
 -- name: GetGatheringParticipants :many
 SELECT gp.*,
        o.name                  as owner_name,
@@ -196,12 +373,22 @@ SET check_in_time = CURRENT_TIMESTAMP,
 WHERE id = ?
   AND gathering_id = ?;
 
+-- name: CheckOutParticipant :execrows
+UPDATE gathering_participants
+SET check_out_time = CURRENT_TIMESTAMP,
+    updated_at     = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND gathering_id = ?
+  AND check_in_time IS NOT NULL
+  AND check_out_time IS NULL;
+
 -- name: GetQualifiedUnits :many
 SELECT u.id,
        u.unit_number,
        u.cadastral_number,
        u.floor,
        u.entrance,
+       u.room_count,
        u.area,
        u.part,
        u.unit_type,
@@ -235,6 +422,7 @@ WHERE b.association_id = ?
                  AND (false = ? OR u2.unit_type IN (sqlc.slice('unit_types')))
                  AND (false = ? OR u2.floor IN (sqlc.slice('unit_floors')))
                  AND (false = ? OR u2.entrance IN (sqlc.slice('unit_entrances'))))
+  AND (false = ? OR u.id IN (sqlc.slice('unit_ids')))
   AND u.id NOT IN (SELECT unit_id
                    FROM gathering_participants
                    WHERE gathering_id = ?)
@@ -250,7 +438,9 @@ VALUES (?, ?, ?, ?, ?, ?) RETURNING *;
 SELECT *
 FROM voting_ballots
 WHERE gathering_id = ?
-  AND participant_id = ?;
+  AND participant_id = ?
+ORDER BY is_valid DESC, id DESC
+LIMIT 1;
 
 -- name: GetBallotsForGathering :many
 SELECT vb.*,
@@ -263,11 +453,30 @@ FROM voting_ballots vb
 WHERE vb.gathering_id = ?
 ORDER BY vb.submitted_at;
 
--- name: InvalidateBallot :exec
+-- name: GetBallot :one
+SELECT *
+FROM voting_ballots
+WHERE id = ?
+  AND gathering_id = ?;
+
+-- name: CreateReplacementBallot :one
+INSERT INTO voting_ballots (gathering_id, participant_id, ballot_content, ballot_hash,
+                            submitted_ip, submitted_user_agent, replaces_ballot_id)
+VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: InvalidateBallot :execrows
 UPDATE voting_ballots
 SET is_valid            = FALSE,
     invalidated_at      = CURRENT_TIMESTAMP,
     invalidation_reason = ?
+WHERE id = ?
+  AND is_valid = TRUE;
+
+-- name: SignBallot :exec
+UPDATE voting_ballots
+SET signature             = ?,
+    signature_timestamp   = ?,
+    signature_certificate = ?
 WHERE id = ?;
 
 -- name: GetVoteTally :one
@@ -277,11 +486,12 @@ WHERE gathering_id = ?
   AND voting_matter_id = ?;
 
 -- name: UpsertVoteTally :one
-INSERT INTO vote_tallies (gathering_id, voting_matter_id, tally_data)
-VALUES (?, ?, ?)
+INSERT INTO vote_tallies (gathering_id, voting_matter_id, tally_data, breakdown_data)
+VALUES (?, ?, ?, ?)
 ON CONFLICT (gathering_id, voting_matter_id)
 DO UPDATE SET
     tally_data = EXCLUDED.tally_data,
+    breakdown_data = EXCLUDED.breakdown_data,
     last_updated = CURRENT_TIMESTAMP
 RETURNING *;
 
@@ -306,13 +516,69 @@ FROM voting_audit_log
 WHERE gathering_id = ?
 ORDER BY performed_at DESC LIMIT ?;
 
+-- name: GetAuditLogChain :many
+SELECT *
+FROM voting_audit_log
+WHERE gathering_id = ?
+ORDER BY id;
+
+-- name: GetAuditLogChainHead :one
+SELECT id, chain_hash
+FROM voting_audit_log
+WHERE gathering_id = ?
+  AND chain_hash IS NOT NULL
+ORDER BY id DESC LIMIT 1;
+
+-- name: GetUnchainedAuditLogs :many
+SELECT *
+FROM voting_audit_log
+WHERE gathering_id = ?
+  AND id > ?
+  AND chain_hash IS NULL
+ORDER BY id;
+
+-- name: SetAuditLogChainHash :execrows
+UPDATE voting_audit_log
+SET prev_hash  = ?,
+    chain_hash = ?
+WHERE id = ?
+  AND chain_hash IS NULL;
+
+-- name: GetBallotChain :many
+SELECT *
+FROM voting_ballots
+WHERE gathering_id = ?
+ORDER BY id;
+
+-- name: GetBallotChainHead :one
+SELECT id, chain_hash
+FROM voting_ballots
+WHERE gathering_id = ?
+  AND chain_hash IS NOT NULL
+ORDER BY id DESC LIMIT 1;
+
+-- name: GetUnchainedBallots :many
+SELECT *
+FROM voting_ballots
+WHERE gathering_id = ?
+  AND id > ?
+  AND chain_hash IS NULL
+ORDER BY id;
+
+-- name: SetBallotChainHash :execrows
+UPDATE voting_ballots
+SET prev_hash  = ?,
+    chain_hash = ?
+WHERE id = ?
+  AND chain_hash IS NULL;
+
 -- name: CreateNotification :one
 INSERT INTO voting_notifications (gathering_id, owner_id, notification_type, sent_via)
 VALUES (?, ?, ?, ?) ON CONFLICT (gathering_id, owner_id, notification_type)
 DO
 UPDATE SET
     sent_at = CURRENT_TIMESTAMP,
-    sent_via = ?
+    sent_via = excluded.sent_via
     RETURNING *;
 
 -- name: UpdateNotificationRead :exec
@@ -363,6 +629,7 @@ SELECT o.id                    as owner_id,
        u.unit_type,
        u.floor,
        u.entrance,
+       u.room_count,
        b.id                    as building_id,
        b.name                  as building_name,
        b.address               as building_address,
@@ -381,39 +648,41 @@ WHERE own.is_active = TRUE
 ORDER BY o.name, u.unit_number;
 
 -- name: GetEligibleVotersWithUnits :many
-SELECT o.id                    as owner_id,
-       o.name                  as owner_name,
-       o.identification_number as owner_identification,
-       o.contact_email         as owner_contact_email,
-       o.contact_phone         as owner_contact_phone,
-       u.id                    as unit_id,
-       u.unit_number,
-       u.cadastral_number,
-       u.floor,
-       u.entrance,
-       u.area,
-       u.part                  as voting_weight,
-       u.unit_type,
-       b.name                  as building_name,
-       b.address               as building_address,
+SELECT vro.owner_id,
+       vro.owner_name,
+       vro.owner_identification,
+       vro.owner_contact_email,
+       vro.owner_contact_phone,
+       vru.unit_id,
+       vru.unit_number,
+       vru.cadastral_number,
+       vru.floor,
+       vru.entrance,
+       vru.area,
+       vru.part * us.share     as voting_weight,
+       vru.unit_type,
+       vru.building_name,
+       vru.building_address,
+       us.share,
        us.participant_id       as assigned_participant_id,
        CASE WHEN us.participant_id IS NULL THEN 1 ELSE 0 END as is_available
-FROM owners o
-         JOIN ownerships own ON o.id = own.owner_id
-         JOIN units u ON own.unit_id = u.id
-         JOIN buildings b ON u.building_id = b.id
-         JOIN unit_slots us ON us.unit_id = u.id AND us.gathering_id = ?
-WHERE own.is_active = TRUE
-  AND own.is_voting = TRUE
-  AND b.association_id = ?
-ORDER BY o.name, u.unit_number;
+FROM voter_register_units vru
+         JOIN voter_register_owners vro
+              ON vro.gathering_id = vru.gathering_id AND vro.unit_id = vru.unit_id
+         JOIN unit_slots us ON us.unit_id = vru.unit_id AND us.gathering_id = vru.gathering_id
+                                   AND us.owner_id IN (0, vro.owner_id)
+         JOIN gatherings g ON g.id = vru.gathering_id
+WHERE vru.gathering_id = ?
+  AND g.association_id = ?
+  AND (g.co_ownership_policy != 'designated' OR vro.is_voting = TRUE)
+ORDER BY vro.owner_name, vru.unit_number;
 
 -- name: GetParticipatingUnitsStats :one
-SELECT COUNT(DISTINCT us.unit_id)     as participating_units_count,
-       COALESCE(SUM(u.part), 0)       as participating_units_total_part,
-       COALESCE(SUM(u.area), 0)       as participating_units_total_area
+SELECT COUNT(DISTINCT us.unit_id)          as participating_units_count,
+       COALESCE(SUM(vru.part * us.share), 0) as participating_units_total_part,
+       COALESCE(SUM(vru.area * us.share), 0) as participating_units_total_area
 FROM unit_slots us
-         JOIN units u ON us.unit_id = u.id
+         JOIN voter_register_units vru ON vru.gathering_id = us.gathering_id AND vru.unit_id = us.unit_id
 WHERE us.gathering_id = ?
   AND us.participant_id IS NOT NULL;
 
@@ -430,11 +699,11 @@ FROM gatherings
 WHERE id = ?;
 
 -- name: GetVotedUnitsStats :one
-SELECT COUNT(DISTINCT us.unit_id)     as voted_units_count,
-       COALESCE(SUM(u.part), 0)       as voted_units_total_part,
-       COALESCE(SUM(u.area), 0)       as voted_units_total_area
+SELECT COUNT(DISTINCT us.unit_id)          as voted_units_count,
+       COALESCE(SUM(vru.part * us.share), 0) as voted_units_total_part,
+       COALESCE(SUM(vru.area * us.share), 0) as voted_units_total_area
 FROM unit_slots us
-         JOIN units u ON us.unit_id = u.id
+         JOIN voter_register_units vru ON vru.gathering_id = us.gathering_id AND vru.unit_id = us.unit_id
          JOIN gathering_participants gp ON us.participant_id = gp.id
          JOIN voting_ballots vb ON gp.id = vb.participant_id AND vb.gathering_id = us.gathering_id
 WHERE us.gathering_id = ?
@@ -0,0 +1,96 @@
+-- name: GetMemberVerification :one
+SELECT *
+FROM member_verifications
+WHERE invitation_id = ?;
+
+-- name: SetMemberPIN :exec
+INSERT INTO member_verifications (invitation_id, pin_hash)
+VALUES (?, ?)
+ON CONFLICT (invitation_id) DO UPDATE SET pin_hash   = excluded.pin_hash,
+                                          updated_at = CURRENT_TIMESTAMP;
+
+-- name: CopyMemberPIN :exec
+INSERT INTO member_verifications (invitation_id, pin_hash)
+SELECT sqlc.arg(to_invitation_id), pin_hash
+FROM member_verifications
+WHERE member_verifications.invitation_id = sqlc.arg(from_invitation_id)
+  AND pin_hash IS NOT NULL
+ON CONFLICT (invitation_id) DO UPDATE SET pin_hash   = excluded.pin_hash,
+                                          updated_at = CURRENT_TIMESTAMP;
+
+-- name: SetMemberCode :exec
+INSERT INTO member_verifications (invitation_id, code_hash, code_channel, code_expires_at, code_sent_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (invitation_id) DO UPDATE SET code_hash       = excluded.code_hash,
+                                          code_channel    = excluded.code_channel,
+                                          code_expires_at = excluded.code_expires_at,
+                                          code_sent_at    = excluded.code_sent_at,
+                                          updated_at      = CURRENT_TIMESTAMP;
+
+-- name: ReserveVerificationAttempt :one
+INSERT INTO member_verifications (invitation_id, failed_attempts)
+VALUES (sqlc.arg(invitation_id), 1)
+ON CONFLICT (invitation_id) DO UPDATE SET failed_attempts = failed_attempts + 1,
+                                          updated_at      = CURRENT_TIMESTAMP
+WHERE member_verifications.locked_at IS NULL
+  AND member_verifications.failed_attempts < sqlc.arg(max_attempts)
+RETURNING *;
+
+-- name: LockMemberVerification :exec
+UPDATE member_verifications
+SET locked_at  = ?,
+    code_hash  = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE invitation_id = ?
+  AND locked_at IS NULL;
+
+-- name: CompleteMemberVerification :execrows
+UPDATE member_verifications
+SET failed_attempts = 0,
+    code_hash       = NULL,
+    code_expires_at = NULL,
+    updated_at      = CURRENT_TIMESTAMP
+WHERE invitation_id = ?
+  AND locked_at IS NULL;
+
+-- name: UnlockMemberVerification :execrows
+UPDATE member_verifications
+SET failed_attempts = 0,
+    locked_at       = NULL,
+    updated_at      = CURRENT_TIMESTAMP
+WHERE invitation_id = (SELECT mi.id
+                       FROM member_invitations mi
+                       WHERE mi.id = sqlc.arg(invitation_id)
+                         AND mi.gathering_id = sqlc.arg(gathering_id))
+  AND (failed_attempts > 0 OR locked_at IS NOT NULL);
+
+-- name: GetLockedMemberInvitations :many
+SELECT mv.invitation_id
+FROM member_verifications mv
+         JOIN member_invitations mi ON mi.id = mv.invitation_id
+WHERE mi.gathering_id = ?
+  AND mv.locked_at IS NOT NULL;
+
+-- name: CountInvitationsWithoutPIN :one
+SELECT COUNT(*)
+FROM member_invitations mi
+         LEFT JOIN member_verifications mv ON mv.invitation_id = mi.id
+WHERE mi.gathering_id = ?
+  AND mi.revoked_at IS NULL
+  AND mi.expires_at > datetime('now')
+  AND mv.pin_hash IS NULL;
+
+-- name: CreateMemberSession :exec
+INSERT INTO member_sessions (invitation_id, token_hash, expires_at)
+VALUES (?, ?, ?);
+
+-- name: GetMemberSession :one
+SELECT *
+FROM member_sessions
+WHERE token_hash = ?
+  AND invitation_id = ?;
+
+-- name: DeleteMemberSessions :exec
+DELETE
+FROM member_sessions
+WHERE invitation_id = ?;
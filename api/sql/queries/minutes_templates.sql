@@ -0,0 +1,18 @@
+-- name: GetMinutesTemplate :one
+SELECT *
+FROM minutes_templates
+WHERE association_id = ?;
+
+-- name: UpsertMinutesTemplate :one
+INSERT INTO minutes_templates (association_id, file_name, format, content)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (association_id) DO UPDATE SET file_name  = excluded.file_name,
+                                           format     = excluded.format,
+                                           content    = excluded.content,
+                                           updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteMinutesTemplate :execrows
+DELETE
+FROM minutes_templates
+WHERE association_id = ?;
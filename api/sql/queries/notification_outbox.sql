@@ -0,0 +1,60 @@
+-- name: CreateOutboxMessage :one
+INSERT INTO notification_outbox (notification_id, recipient, subject, body, status, last_error, next_attempt_at,
+                                 reminder_schedule_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetDueOutboxMessages :many
+SELECT *
+FROM notification_outbox
+WHERE status = 'pending'
+  AND next_attempt_at <= sqlc.arg(now)
+ORDER BY next_attempt_at, id
+LIMIT sqlc.arg(batch_size);
+
+-- name: MarkOutboxMessageSent :exec
+UPDATE notification_outbox
+SET status     = 'sent',
+    attempts   = attempts + 1,
+    body       = '',
+    last_error = NULL,
+    sent_at    = sqlc.arg(sent_at),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+-- name: RetryOutboxMessage :exec
+UPDATE notification_outbox
+SET attempts        = attempts + 1,
+    last_error      = sqlc.arg(last_error),
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    updated_at      = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+-- name: FailOutboxMessage :exec
+UPDATE notification_outbox
+SET status     = 'failed',
+    attempts   = attempts + 1,
+    body       = '',
+    last_error = sqlc.arg(last_error),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+-- name: GetNotificationDeliveries :many
+SELECT nob.id,
+       nob.notification_id,
+       vn.owner_id,
+       o.name AS owner_name,
+       vn.notification_type,
+       nob.recipient,
+       nob.subject,
+       nob.status,
+       nob.attempts,
+       nob.next_attempt_at,
+       nob.last_error,
+       nob.sent_at,
+       nob.created_at
+FROM notification_outbox nob
+         JOIN voting_notifications vn ON vn.id = nob.notification_id
+         JOIN owners o ON o.id = vn.owner_id
+WHERE vn.gathering_id = ?
+ORDER BY nob.created_at DESC, nob.id DESC;
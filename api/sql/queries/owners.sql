@@ -71,8 +71,8 @@ WHERE id = ?;
 -- name: CreateOwnership :one
 INSERT INTO ownerships (unit_id, owner_id, association_id,
                         start_date, end_date, is_active, is_voting,
-                        registration_document, registration_date)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+                        registration_document, registration_date, share)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
 --
 
 -- name: GetOwnerUnitsWithDetails :many
@@ -83,6 +83,8 @@ SELECT u.id                     as unit_id,
        u.unit_type,
        b.name                   as building_name,
        b.address                as building_address,
+       os.is_voting,
+       os.share,
        o2.id                    as co_owner_id,
        o2.name                  as co_owner_name,
        o2.normalized_name       as co_owner_normalized_name,
@@ -115,14 +117,15 @@ SELECT o.id                    as owner_id,
        u.part,
        u.unit_type,
        b.name                  as building_name,
-       b.address               as building_address
+       b.address               as building_address,
+       os.is_voting,
+       os.share
 FROM owners o
          JOIN ownerships os ON o.id = os.owner_id
          JOIN units u ON os.unit_id = u.id
          JOIN buildings b ON u.building_id = b.id
 WHERE o.association_id = ?
   AND os.is_active = true
-  AND os.is_voting = true
   AND (false=? OR u.unit_type in(sqlc.slice('unit_types')))
   AND (false=? OR u.floor in(sqlc.slice('unit_floors')))
   AND (false=? OR u.entrance in(sqlc.slice('unit_entrances')))
@@ -150,6 +153,8 @@ SELECT o.id                     as owner_id,
        u.building_id            as building_id,
        b.name                   as building_name,
        b.address                as building_address,
+       os.is_voting,
+       os.share,
        o2.id                    as co_owner_id,
        o2.name                  as co_owner_name,
        o2.normalized_name       as co_owner_normalized_name,
@@ -59,4 +59,14 @@ SELECT *
 FROM ownerships
 WHERE id = ? LIMIT 1;
 
---
\ No newline at end of file
+--
+
+-- name: SetOwnershipShare :exec
+UPDATE ownerships
+SET share      = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND unit_id = ?
+  AND association_id = ?
+  AND is_active = true;
+--
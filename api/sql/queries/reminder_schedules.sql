@@ -0,0 +1,44 @@
+-- name: CreateReminderSchedule :exec
+INSERT INTO reminder_schedules (gathering_id, offset_minutes, lang)
+VALUES (?, ?, ?) ON CONFLICT (gathering_id, offset_minutes) DO NOTHING;
+
+-- name: DeleteScheduledReminders :exec
+DELETE
+FROM reminder_schedules
+WHERE gathering_id = ?
+  AND status = 'scheduled';
+
+-- name: GetReminderSchedules :many
+SELECT rs.*,
+       CAST(COUNT(nob.id) AS INTEGER)                                                AS queued_count,
+       CAST(COALESCE(SUM(CASE WHEN nob.status = 'pending' THEN 1 ELSE 0 END), 0) AS INTEGER) AS pending_count,
+       CAST(COALESCE(SUM(CASE WHEN nob.status = 'sent' THEN 1 ELSE 0 END), 0) AS INTEGER)    AS delivered_count,
+       CAST(COALESCE(SUM(CASE WHEN nob.status = 'failed' THEN 1 ELSE 0 END), 0) AS INTEGER)  AS failed_count
+FROM reminder_schedules rs
+         LEFT JOIN notification_outbox nob ON nob.reminder_schedule_id = rs.id
+WHERE rs.gathering_id = ?
+GROUP BY rs.id
+ORDER BY rs.offset_minutes DESC;
+
+-- name: GetScheduledReminders :many
+SELECT *
+FROM reminder_schedules
+WHERE status = 'scheduled'
+ORDER BY gathering_id, offset_minutes DESC;
+
+-- name: CompleteReminderSchedule :exec
+UPDATE reminder_schedules
+SET status         = ?,
+    ran_at         = ?,
+    eligible_count = ?,
+    skipped_count  = ?,
+    last_error     = ?,
+    updated_at     = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND status = 'scheduled';
+
+-- name: GetReminderNotifications :many
+SELECT owner_id, sent_at
+FROM voting_notifications
+WHERE gathering_id = ?
+  AND notification_type = 'reminder';
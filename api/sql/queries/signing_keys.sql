@@ -0,0 +1,22 @@
+-- name: GetAssociationSigningKey :one
+SELECT * FROM association_signing_keys
+WHERE association_id = ?;
+
+-- name: GetSigningKeyByKeyID :one
+SELECT * FROM association_signing_keys
+WHERE key_id = ?;
+
+-- name: CreateAssociationSigningKey :one
+INSERT INTO association_signing_keys (association_id, key_id, public_key, private_key)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (association_id) DO NOTHING
+RETURNING *;
+
+-- name: GetUnencryptedSigningKeys :many
+SELECT * FROM association_signing_keys
+WHERE private_key NOT LIKE 'aesgcm:%';
+
+-- name: SetSigningKeyPrivateKey :exec
+UPDATE association_signing_keys
+SET private_key = ?
+WHERE id = ?;
@@ -0,0 +1,49 @@
+-- name: CreateVoterRegisterUnit :exec
+INSERT INTO voter_register_units (gathering_id, unit_id, unit_number, cadastral_number, unit_type, floor, entrance,
+                                  area, part, building_name, building_address)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: CreateVoterRegisterOwner :exec
+INSERT INTO voter_register_owners (gathering_id, unit_id, owner_id, ownership_id, owner_name, owner_identification,
+                                   owner_contact_email, owner_contact_phone, is_voting, share)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetVoterRegisterUnits :many
+SELECT *
+FROM voter_register_units
+WHERE gathering_id = ?
+ORDER BY building_name, unit_number;
+
+-- name: GetVoterRegisterOwners :many
+SELECT *
+FROM voter_register_owners
+WHERE gathering_id = ?
+ORDER BY owner_name, unit_id;
+
+-- name: DeleteVoterRegisterUnits :exec
+DELETE
+FROM voter_register_units
+WHERE gathering_id = ?;
+
+-- name: DeleteVoterRegisterOwners :exec
+DELETE
+FROM voter_register_owners
+WHERE gathering_id = ?;
+
+-- name: GetActiveOwnershipsForAssociation :many
+SELECT own.id                  as ownership_id,
+       own.unit_id,
+       own.is_voting,
+       own.share,
+       o.id                    as owner_id,
+       o.name                  as owner_name,
+       o.identification_number as owner_identification,
+       o.contact_email         as owner_contact_email,
+       o.contact_phone         as owner_contact_phone
+FROM ownerships own
+         JOIN owners o ON own.owner_id = o.id
+         JOIN units u ON own.unit_id = u.id
+         JOIN buildings b ON u.building_id = b.id
+WHERE own.is_active = TRUE
+  AND b.association_id = ?
+ORDER BY o.name, own.unit_id;
@@ -11,9 +11,10 @@ INSERT INTO voting_results (
     total_possible_votes_weight,
     total_possible_votes_count,
     quorum_threshold_percentage,
-    quorum_met
+    quorum_met,
+    ballot_merkle_root
 )
-VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
 
 -- name: UpdateVotingResults :one
 UPDATE voting_results
@@ -24,6 +25,7 @@ SET results_data = ?,
     total_possible_votes_count = ?,
     quorum_threshold_percentage = ?,
     quorum_met = ?,
+    ballot_merkle_root = ?,
     computed_at = CURRENT_TIMESTAMP
 WHERE gathering_id = ? RETURNING *;
 
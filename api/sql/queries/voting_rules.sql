@@ -0,0 +1,20 @@
+-- name: GetVotingRulesProfile :one
+SELECT * FROM voting_rules_profiles
+WHERE association_id = ?;
+
+-- name: UpsertVotingRulesProfile :one
+INSERT INTO voting_rules_profiles (association_id, quorum_initial, quorum_repeated, quorum_remote,
+                                   majority_simple, majority_absolute, majority_absolute_two_thirds,
+                                   majority_qualified, majority_unanimous, max_proxies_per_delegate)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (association_id) DO UPDATE SET quorum_initial               = excluded.quorum_initial,
+                                           quorum_repeated              = excluded.quorum_repeated,
+                                           quorum_remote                = excluded.quorum_remote,
+                                           majority_simple              = excluded.majority_simple,
+                                           majority_absolute            = excluded.majority_absolute,
+                                           majority_absolute_two_thirds = excluded.majority_absolute_two_thirds,
+                                           majority_qualified           = excluded.majority_qualified,
+                                           majority_unanimous           = excluded.majority_unanimous,
+                                           max_proxies_per_delegate     = excluded.max_proxies_per_delegate,
+                                           updated_at                   = CURRENT_TIMESTAMP
+RETURNING *;
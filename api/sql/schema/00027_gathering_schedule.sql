@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Adding scheduled opening and closing times to gatherings';
+
+ALTER TABLE gatherings ADD COLUMN opens_at TIMESTAMP;
+ALTER TABLE gatherings ADD COLUMN closes_at TIMESTAMP;
+
+CREATE INDEX idx_gatherings_schedule ON gatherings (status, opens_at, closes_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Removing scheduled opening and closing times from gatherings';
+
+DROP INDEX IF EXISTS idx_gatherings_schedule;
+ALTER TABLE gatherings DROP COLUMN closes_at;
+ALTER TABLE gatherings DROP COLUMN opens_at;
+
+-- +goose StatementEnd
@@ -0,0 +1,113 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Allowing invalidated ballots to be replaced by a corrected ballot';
+
+-- SQLite cannot drop an inline constraint, so the table is rebuilt.
+-- One ballot per participant is now enforced only among valid ballots,
+-- which lets the counting commission record a replacement and keep the original.
+CREATE TABLE voting_ballots_new
+(
+    id                    INTEGER PRIMARY KEY,
+    gathering_id          INTEGER NOT NULL REFERENCES gatherings (id) ON DELETE CASCADE,
+    participant_id        INTEGER NOT NULL REFERENCES gathering_participants (id) ON DELETE CASCADE,
+
+    -- The complete ballot content
+    ballot_content        TEXT    NOT NULL, -- JSON with all votes: {matter_id: {option_id, vote_value}, ...}
+    ballot_hash           TEXT    NOT NULL, -- SHA256 hash of ballot_content
+
+    -- Submission metadata
+    submitted_at          TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    submitted_ip          TEXT,
+    submitted_user_agent  TEXT,
+
+    -- Digital signature (future)
+    signature             TEXT,
+    signature_timestamp   TIMESTAMP,
+    signature_certificate TEXT,
+
+    -- Status
+    is_valid              BOOLEAN   DEFAULT TRUE,
+    invalidated_at        TIMESTAMP,
+    invalidation_reason   TEXT,
+
+    -- Set when this ballot was recorded as the correction of an invalidated one
+    replaces_ballot_id    INTEGER REFERENCES voting_ballots (id)
+);
+
+INSERT INTO voting_ballots_new (id, gathering_id, participant_id, ballot_content, ballot_hash, submitted_at,
+                                submitted_ip, submitted_user_agent, signature, signature_timestamp,
+                                signature_certificate, is_valid, invalidated_at, invalidation_reason)
+SELECT id,
+       gathering_id,
+       participant_id,
+       ballot_content,
+       ballot_hash,
+       submitted_at,
+       submitted_ip,
+       submitted_user_agent,
+       signature,
+       signature_timestamp,
+       signature_certificate,
+       is_valid,
+       invalidated_at,
+       invalidation_reason
+FROM voting_ballots;
+
+DROP TABLE voting_ballots;
+ALTER TABLE voting_ballots_new RENAME TO voting_ballots;
+
+CREATE INDEX idx_ballots_gathering ON voting_ballots (gathering_id);
+CREATE INDEX idx_ballots_participant ON voting_ballots (participant_id);
+CREATE UNIQUE INDEX idx_ballots_valid_per_participant ON voting_ballots (gathering_id, participant_id) WHERE is_valid = TRUE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Restoring one ballot per participant';
+
+CREATE TABLE voting_ballots_old
+(
+    id                    INTEGER PRIMARY KEY,
+    gathering_id          INTEGER NOT NULL REFERENCES gatherings (id) ON DELETE CASCADE,
+    participant_id        INTEGER NOT NULL REFERENCES gathering_participants (id) ON DELETE CASCADE,
+    ballot_content        TEXT    NOT NULL,
+    ballot_hash           TEXT    NOT NULL,
+    submitted_at          TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    submitted_ip          TEXT,
+    submitted_user_agent  TEXT,
+    signature             TEXT,
+    signature_timestamp   TIMESTAMP,
+    signature_certificate TEXT,
+    is_valid              BOOLEAN   DEFAULT TRUE,
+    invalidated_at        TIMESTAMP,
+    invalidation_reason   TEXT,
+    CONSTRAINT unique_ballot_per_participant UNIQUE (gathering_id, participant_id)
+);
+
+-- Replacement ballots cannot coexist with the originals under the old constraint
+INSERT INTO voting_ballots_old (id, gathering_id, participant_id, ballot_content, ballot_hash, submitted_at,
+                                submitted_ip, submitted_user_agent, signature, signature_timestamp,
+                                signature_certificate, is_valid, invalidated_at, invalidation_reason)
+SELECT id,
+       gathering_id,
+       participant_id,
+       ballot_content,
+       ballot_hash,
+       submitted_at,
+       submitted_ip,
+       submitted_user_agent,
+       signature,
+       signature_timestamp,
+       signature_certificate,
+       is_valid,
+       invalidated_at,
+       invalidation_reason
+FROM voting_ballots
+WHERE replaces_ballot_id IS NULL;
+
+DROP TABLE voting_ballots;
+ALTER TABLE voting_ballots_old RENAME TO voting_ballots;
+
+CREATE INDEX idx_ballots_gathering ON voting_ballots (gathering_id);
+CREATE INDEX idx_ballots_participant ON voting_ballots (participant_id);
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Adding method-specific breakdown to vote tallies';
+-- JSON explaining how the outcome was reached (instant-runoff rounds, Schulze paths, ...)
+ALTER TABLE vote_tallies ADD COLUMN breakdown_data TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Removing vote tally breakdown';
+ALTER TABLE vote_tallies DROP COLUMN breakdown_data;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Adding an unlinkable store for votes on anonymous matters';
+
+-- Choices on matters whose voting config is anonymous are kept apart from the ballot.
+-- A row is keyed only by the random receipt handed to the voter: there is no participant,
+-- ballot or timestamp, and WITHOUT ROWID keeps rows ordered by receipt, not by insertion.
+-- The ballot itself only records that the participant voted on the matter.
+CREATE TABLE anonymous_votes
+(
+    receipt          TEXT    NOT NULL,
+    gathering_id     INTEGER NOT NULL REFERENCES gatherings (id) ON DELETE CASCADE,
+    voting_matter_id INTEGER NOT NULL REFERENCES voting_matters (id) ON DELETE CASCADE,
+    vote_values      TEXT    NOT NULL, -- JSON array with the selected values
+    -- Needed to weigh the vote under either voting mode
+    units_part       REAL    NOT NULL,
+    units_count      INTEGER NOT NULL,
+    is_valid         BOOLEAN NOT NULL DEFAULT TRUE,
+    PRIMARY KEY (receipt, voting_matter_id)
+) WITHOUT ROWID;
+
+CREATE INDEX idx_anonymous_votes_gathering ON anonymous_votes (gathering_id, voting_matter_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Removing anonymous votes';
+DROP INDEX IF EXISTS idx_anonymous_votes_gathering;
+DROP TABLE IF EXISTS anonymous_votes;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Adding per-association ballot signing keys';
+
+-- Ed25519 key used to sign the receipts of recorded ballots.
+-- The public key is published so owners can verify receipts offline.
+CREATE TABLE association_signing_keys
+(
+    id             INTEGER PRIMARY KEY,
+    association_id INTEGER NOT NULL UNIQUE REFERENCES associations (id) ON DELETE CASCADE,
+    key_id         TEXT    NOT NULL UNIQUE, -- Fingerprint of the public key, carried in receipts
+    public_key     TEXT    NOT NULL,        -- base64
+    private_key    TEXT    NOT NULL,        -- base64 Ed25519 seed
+    created_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Removing ballot signing keys';
+DROP TABLE IF EXISTS association_signing_keys;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Chaining ballots and audit entries into tamper-evident hash chains';
+-- Each row links to the chain hash of the previous row of the same gathering.
+-- chain_hash = SHA256(prev_hash || the row's recorded fields), see services/integrity_service.go
+ALTER TABLE voting_ballots ADD COLUMN prev_hash TEXT;
+ALTER TABLE voting_ballots ADD COLUMN chain_hash TEXT;
+ALTER TABLE voting_audit_log ADD COLUMN prev_hash TEXT;
+ALTER TABLE voting_audit_log ADD COLUMN chain_hash TEXT;
+
+-- Merkle root over all ballot hashes, published with the results at close
+ALTER TABLE voting_results ADD COLUMN ballot_merkle_root TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Removing integrity chains';
+ALTER TABLE voting_results DROP COLUMN ballot_merkle_root;
+ALTER TABLE voting_audit_log DROP COLUMN chain_hash;
+ALTER TABLE voting_audit_log DROP COLUMN prev_hash;
+ALTER TABLE voting_ballots DROP COLUMN chain_hash;
+ALTER TABLE voting_ballots DROP COLUMN prev_hash;
+-- +goose StatementEnd
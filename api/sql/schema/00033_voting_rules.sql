@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Adding association voting rules profiles';
+
+-- Quorum per gathering type and the thresholds behind each required_majority keyword,
+-- as set by the association's statutes. Percentages, 0-100.
+CREATE TABLE voting_rules_profiles
+(
+    id                           INTEGER PRIMARY KEY,
+    association_id               INTEGER   NOT NULL UNIQUE REFERENCES associations (id) ON DELETE CASCADE,
+
+    quorum_initial               REAL      NOT NULL DEFAULT 50,
+    quorum_repeated              REAL      NOT NULL DEFAULT 25,
+    quorum_remote                REAL      NOT NULL DEFAULT 100,
+
+    majority_simple              REAL      NOT NULL DEFAULT 50,    -- more than
+    majority_absolute            REAL      NOT NULL DEFAULT 50,    -- more than
+    majority_absolute_two_thirds REAL      NOT NULL DEFAULT 66.67, -- at least
+    majority_qualified           REAL      NOT NULL DEFAULT 66.67, -- at least, when the matter sets no value
+    majority_unanimous           REAL      NOT NULL DEFAULT 100,   -- at least
+
+    created_at                   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at                   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The rules in force when the gathering was published (JSON), so later profile edits
+-- do not change its results
+ALTER TABLE gatherings ADD COLUMN voting_rules TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Removing association voting rules profiles';
+ALTER TABLE gatherings DROP COLUMN voting_rules;
+DROP TABLE IF EXISTS voting_rules_profiles;
+-- +goose StatementEnd
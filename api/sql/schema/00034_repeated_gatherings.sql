@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Linking repeated gatherings to the gathering they repeat';
+-- A repeated gathering is convened with the same agenda when an initial gathering misses quorum
+ALTER TABLE gatherings ADD COLUMN repeated_from_id INTEGER REFERENCES gatherings (id);
+-- A gathering is repeated at most once
+CREATE UNIQUE INDEX idx_gatherings_repeated_from ON gatherings (repeated_from_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Removing repeated gathering links';
+DROP INDEX IF EXISTS idx_gatherings_repeated_from;
+ALTER TABLE gatherings DROP COLUMN repeated_from_id;
+-- +goose StatementEnd
@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Adding gathering templates';
+
+-- Reusable gathering setup, e.g. the agenda of the annual general meeting
+CREATE TABLE gathering_templates
+(
+    id                        INTEGER PRIMARY KEY,
+    association_id            INTEGER NOT NULL REFERENCES associations (id) ON DELETE CASCADE,
+    name                      TEXT    NOT NULL,
+
+    -- Gathering defaults
+    title                     TEXT    NOT NULL,
+    description               TEXT    NOT NULL,
+    intent                    TEXT    NOT NULL,
+    location                  TEXT    NOT NULL DEFAULT '',
+    gathering_type            TEXT    NOT NULL CHECK (gathering_type IN ('initial', 'repeated', 'remote')),
+    voting_mode               TEXT    NOT NULL DEFAULT 'by_weight' CHECK (voting_mode IN ('by_weight', 'by_unit')),
+
+    -- Qualification criteria, as on gatherings
+    qualification_unit_types  TEXT,
+    qualification_floors      TEXT,
+    qualification_entrances   TEXT,
+    qualification_custom_rule TEXT,
+
+    created_at                TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at                TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_gathering_templates_association ON gathering_templates (association_id);
+
+-- Agenda of a template, mirroring voting_matters
+CREATE TABLE gathering_template_matters
+(
+    id             INTEGER PRIMARY KEY,
+    template_id    INTEGER NOT NULL REFERENCES gathering_templates (id) ON DELETE CASCADE,
+    order_index    INTEGER NOT NULL,
+    title          TEXT    NOT NULL,
+    title_ru       TEXT    NOT NULL DEFAULT '',
+    description    TEXT,
+    description_ru TEXT,
+    matter_type    TEXT    NOT NULL CHECK (matter_type IN ('budget', 'election', 'policy', 'poll', 'extraordinary')),
+    voting_config  TEXT    NOT NULL,
+    is_informative INTEGER NOT NULL DEFAULT 0,
+
+    UNIQUE (template_id, order_index)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Removing gathering templates';
+DROP TABLE IF EXISTS gathering_template_matters;
+DROP INDEX IF EXISTS idx_gathering_templates_association;
+DROP TABLE IF EXISTS gathering_templates;
+-- +goose StatementEnd
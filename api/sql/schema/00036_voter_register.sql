@@ -0,0 +1,94 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Adding frozen voter registers';
+
+-- Qualified units as they stood when the gathering was published
+CREATE TABLE voter_register_units
+(
+    id               INTEGER PRIMARY KEY,
+    gathering_id     INTEGER NOT NULL REFERENCES gatherings (id) ON DELETE CASCADE,
+    unit_id          INTEGER NOT NULL REFERENCES units (id),
+    unit_number      TEXT    NOT NULL,
+    cadastral_number TEXT    NOT NULL,
+    unit_type        TEXT    NOT NULL,
+    floor            INTEGER NOT NULL,
+    entrance         INTEGER NOT NULL,
+    area             REAL    NOT NULL,
+    part             REAL    NOT NULL,
+    building_name    TEXT    NOT NULL,
+    building_address TEXT    NOT NULL,
+    created_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_register_unit UNIQUE (gathering_id, unit_id)
+);
+
+-- Active owners of the registered units; is_voting marks the owner who votes for the unit
+CREATE TABLE voter_register_owners
+(
+    id                   INTEGER PRIMARY KEY,
+    gathering_id         INTEGER NOT NULL REFERENCES gatherings (id) ON DELETE CASCADE,
+    unit_id              INTEGER NOT NULL REFERENCES units (id),
+    owner_id             INTEGER NOT NULL REFERENCES owners (id),
+    ownership_id         INTEGER NOT NULL REFERENCES ownerships (id),
+    owner_name           TEXT    NOT NULL,
+    owner_identification TEXT    NOT NULL,
+    owner_contact_email  TEXT    NOT NULL,
+    owner_contact_phone  TEXT    NOT NULL,
+    is_voting            BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at           TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_register_owner UNIQUE (gathering_id, unit_id, owner_id)
+);
+
+CREATE INDEX idx_voter_register_owners_owner ON voter_register_owners (gathering_id, owner_id);
+
+ALTER TABLE gatherings ADD COLUMN register_frozen_at TIMESTAMP;
+
+-- Gatherings already past draft are frozen as they stand now, their unit slots are the qualified units
+INSERT INTO voter_register_units (gathering_id, unit_id, unit_number, cadastral_number, unit_type, floor, entrance,
+                                  area, part, building_name, building_address)
+SELECT us.gathering_id,
+       u.id,
+       u.unit_number,
+       u.cadastral_number,
+       u.unit_type,
+       u.floor,
+       u.entrance,
+       u.area,
+       u.part,
+       b.name,
+       b.address
+FROM unit_slots us
+         JOIN gatherings g ON us.gathering_id = g.id
+         JOIN units u ON us.unit_id = u.id
+         JOIN buildings b ON u.building_id = b.id
+WHERE g.status != 'draft';
+
+INSERT INTO voter_register_owners (gathering_id, unit_id, owner_id, ownership_id, owner_name, owner_identification,
+                                   owner_contact_email, owner_contact_phone, is_voting)
+SELECT vru.gathering_id,
+       vru.unit_id,
+       o.id,
+       own.id,
+       o.name,
+       o.identification_number,
+       o.contact_email,
+       o.contact_phone,
+       own.is_voting
+FROM voter_register_units vru
+         JOIN ownerships own ON own.unit_id = vru.unit_id AND own.is_active = TRUE
+         JOIN owners o ON own.owner_id = o.id;
+
+UPDATE gatherings
+SET register_frozen_at = CURRENT_TIMESTAMP
+WHERE status != 'draft';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Removing frozen voter registers';
+ALTER TABLE gatherings DROP COLUMN register_frozen_at;
+DROP INDEX IF EXISTS idx_voter_register_owners_owner;
+DROP TABLE IF EXISTS voter_register_owners;
+DROP TABLE IF EXISTS voter_register_units;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Adding minutes templates';
+
+-- The association's template for the minutes of its gatherings, a DOCX or ODT document
+-- worded as its statute requires, with {{placeholders}} for the gathering's details
+CREATE TABLE minutes_templates
+(
+    id             INTEGER PRIMARY KEY,
+    association_id INTEGER   NOT NULL UNIQUE REFERENCES associations (id) ON DELETE CASCADE,
+    file_name      TEXT      NOT NULL,
+    format         TEXT      NOT NULL CHECK (format IN ('docx', 'odt')),
+    content        BLOB      NOT NULL,
+    created_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Removing minutes templates';
+DROP TABLE IF EXISTS minutes_templates;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Adding notification outbox';
+
+-- Messages waiting to be delivered, and the outcome of those that were. A notification gets
+-- a message each time it is sent; failed deliveries are retried with backoff until they go
+-- through or are given up on. The body carries the owner's invitation link, so it is cleared
+-- once the message is delivered or given up on.
+CREATE TABLE notification_outbox
+(
+    id              INTEGER PRIMARY KEY,
+    notification_id INTEGER   NOT NULL REFERENCES voting_notifications (id) ON DELETE CASCADE,
+    recipient       TEXT      NOT NULL,
+    subject         TEXT      NOT NULL,
+    body            TEXT      NOT NULL,
+    status          TEXT      NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts        INTEGER   NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error      TEXT,
+    sent_at         TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notification_outbox_due ON notification_outbox (status, next_attempt_at);
+CREATE INDEX idx_notification_outbox_notification ON notification_outbox (notification_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Removing notification outbox';
+DROP TABLE IF EXISTS notification_outbox;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Adding reminder schedules';
+
+-- Reminders sent automatically to the owners who have not voted yet, each a given time before
+-- voting closes: closes_at for remote gatherings, the gathering date otherwise. A schedule is
+-- sent once; it is missed when voting closes before it could be sent.
+CREATE TABLE reminder_schedules
+(
+    id             INTEGER PRIMARY KEY,
+    gathering_id   INTEGER   NOT NULL REFERENCES gatherings (id) ON DELETE CASCADE,
+    offset_minutes INTEGER   NOT NULL CHECK (offset_minutes > 0),
+    lang           TEXT      NOT NULL DEFAULT 'ro',
+    status         TEXT      NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'sent', 'missed', 'failed')),
+    ran_at         TIMESTAMP,
+    eligible_count INTEGER   NOT NULL DEFAULT 0, -- Owners who had not voted when it was sent
+    skipped_count  INTEGER   NOT NULL DEFAULT 0, -- Of those, owners already reminded since the previous schedule
+    last_error     TEXT,
+    created_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (gathering_id, offset_minutes)
+);
+
+CREATE INDEX idx_reminder_schedules_status ON reminder_schedules (status);
+
+-- The schedule an outbox message was sent for, to follow a campaign's deliveries
+ALTER TABLE notification_outbox ADD COLUMN reminder_schedule_id INTEGER REFERENCES reminder_schedules (id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Removing reminder schedules';
+ALTER TABLE notification_outbox DROP COLUMN reminder_schedule_id;
+DROP TABLE IF EXISTS reminder_schedules;
+-- +goose StatementEnd
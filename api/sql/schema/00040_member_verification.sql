@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Adding member verification';
+
+-- The second factor owners give before submitting a ballot with their invitation link: none,
+-- a one-time code sent by email or SMS, or a PIN printed on the invitation letter
+ALTER TABLE gatherings ADD COLUMN member_verification TEXT NOT NULL DEFAULT 'none' CHECK (member_verification IN ('none', 'code', 'pin'));
+
+-- The second factor of a member invitation. Failed attempts are counted per invitation, which is
+-- locked once they reach the limit, until an administrator unlocks it.
+CREATE TABLE member_verifications
+(
+    invitation_id   INTEGER PRIMARY KEY REFERENCES member_invitations (id) ON DELETE CASCADE,
+    pin_hash        TEXT,               -- Keyed hash of the PIN printed on the letter
+    code_hash       TEXT,               -- Keyed hash of the last one-time code sent, until used
+    code_channel    TEXT CHECK (code_channel IN ('email', 'sms')),
+    code_expires_at TIMESTAMP,
+    code_sent_at    TIMESTAMP,
+    failed_attempts INTEGER   NOT NULL DEFAULT 0,
+    locked_at       TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Short-lived sessions opened by a verified second factor, one per invitation at a time
+CREATE TABLE member_sessions
+(
+    id            INTEGER PRIMARY KEY,
+    invitation_id INTEGER   NOT NULL REFERENCES member_invitations (id) ON DELETE CASCADE,
+    token_hash    TEXT      NOT NULL UNIQUE,
+    expires_at    TIMESTAMP NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_member_sessions_invitation ON member_sessions (invitation_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Removing member verification';
+DROP TABLE IF EXISTS member_sessions;
+DROP TABLE IF EXISTS member_verifications;
+ALTER TABLE gatherings DROP COLUMN member_verification;
+-- +goose StatementEnd
@@ -0,0 +1,58 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Adding the delegation registry';
+
+-- How many owners a single delegate may represent in a gathering, 0 for no limit
+ALTER TABLE voting_rules_profiles ADD COLUMN max_proxies_per_delegate INTEGER NOT NULL DEFAULT 0;
+
+-- Powers of attorney registered for a gathering: the delegate, what they were given and for how long
+CREATE TABLE delegations
+(
+    id                      INTEGER PRIMARY KEY,
+    gathering_id            INTEGER   NOT NULL REFERENCES gatherings (id) ON DELETE CASCADE,
+    delegate_name           TEXT      NOT NULL,
+    delegate_identification TEXT      NOT NULL,
+    document_ref            TEXT      NOT NULL DEFAULT '', -- Number and date of the power of attorney
+    valid_from              TIMESTAMP NOT NULL,
+    valid_until             TIMESTAMP NOT NULL,
+    created_at              TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (valid_until > valid_from)
+);
+
+CREATE INDEX idx_delegations_gathering ON delegations (gathering_id);
+
+-- The owners a delegation represents, one row per unit. An owner revokes the delegation
+-- for all of their units at once.
+CREATE TABLE delegation_units
+(
+    delegation_id   INTEGER   NOT NULL REFERENCES delegations (id) ON DELETE CASCADE,
+    owner_id        INTEGER   NOT NULL REFERENCES owners (id),
+    unit_id         INTEGER   NOT NULL REFERENCES units (id),
+    revoked_at      TIMESTAMP,
+    revocation_note TEXT      NOT NULL DEFAULT '',
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (delegation_id, unit_id)
+);
+
+CREATE INDEX idx_delegation_units_owner ON delegation_units (owner_id);
+
+-- The scanned power of attorney, a PDF or image
+CREATE TABLE delegation_documents
+(
+    delegation_id INTEGER PRIMARY KEY REFERENCES delegations (id) ON DELETE CASCADE,
+    file_name     TEXT      NOT NULL,
+    content_type  TEXT      NOT NULL,
+    content       BLOB      NOT NULL,
+    uploaded_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Removing the delegation registry';
+DROP TABLE IF EXISTS delegation_documents;
+DROP TABLE IF EXISTS delegation_units;
+DROP TABLE IF EXISTS delegations;
+ALTER TABLE voting_rules_profiles DROP COLUMN max_proxies_per_delegate;
+-- +goose StatementEnd
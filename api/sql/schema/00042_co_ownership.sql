@@ -0,0 +1,86 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Adding co-ownership shares';
+
+-- The fraction of the unit an owner holds; the active ownerships of a unit add up to 1
+ALTER TABLE ownerships ADD COLUMN share REAL NOT NULL DEFAULT 1 CHECK (share > 0 AND share <= 1);
+
+-- Co-owners recorded so far hold equal shares
+UPDATE ownerships
+SET share = 1.0 / (SELECT COUNT(*)
+                   FROM ownerships o
+                   WHERE o.unit_id = ownerships.unit_id
+                     AND o.is_active = TRUE)
+WHERE is_active = TRUE;
+
+ALTER TABLE voter_register_owners ADD COLUMN share REAL NOT NULL DEFAULT 1;
+
+UPDATE voter_register_owners
+SET share = (SELECT own.share FROM ownerships own WHERE own.id = voter_register_owners.ownership_id);
+
+-- How a co-owned unit votes: its designated voting owner carries the whole part, each co-owner
+-- carries their share (split), or the unit's vote counts only when all co-owners vote alike (agreement)
+ALTER TABLE gatherings ADD COLUMN co_ownership_policy TEXT NOT NULL DEFAULT 'designated' CHECK (co_ownership_policy IN ('designated', 'split', 'agreement'));
+
+-- A unit has one slot, or one slot per co-owner when co-owners vote their shares.
+-- owner_id is 0 on a slot for the whole unit.
+CREATE TABLE unit_slots_co_owned
+(
+    id             INTEGER PRIMARY KEY,
+    gathering_id   INTEGER NOT NULL REFERENCES gatherings (id) ON DELETE CASCADE,
+    unit_id        INTEGER NOT NULL REFERENCES units (id),
+    participant_id INTEGER NULL REFERENCES gathering_participants (id),
+
+    created_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    owner_id       INTEGER NOT NULL DEFAULT 0,
+    share          REAL    NOT NULL DEFAULT 1,
+
+    UNIQUE (gathering_id, unit_id, participant_id),
+    CONSTRAINT unique_unit_owner_per_gathering UNIQUE (gathering_id, unit_id, owner_id)
+);
+
+INSERT INTO unit_slots_co_owned (id, gathering_id, unit_id, participant_id, created_at, updated_at)
+SELECT id, gathering_id, unit_id, participant_id, created_at, updated_at
+FROM unit_slots;
+
+DROP TABLE unit_slots;
+ALTER TABLE unit_slots_co_owned RENAME TO unit_slots;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Removing co-ownership shares';
+
+CREATE TABLE unit_slots_whole
+(
+    id            INTEGER PRIMARY KEY,
+    gathering_id  INTEGER NOT NULL REFERENCES gatherings (id) ON DELETE CASCADE,
+    unit_id       INTEGER NOT NULL REFERENCES units (id),
+    participant_id INTEGER NULL REFERENCES gathering_participants (id),
+
+    created_at    TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at    TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (gathering_id, unit_id, participant_id),
+    CONSTRAINT unique_unit_per_gathering UNIQUE (gathering_id, unit_id)
+);
+
+-- Co-owner slots collapse into one slot per unit, the first one claimed
+INSERT INTO unit_slots_whole (id, gathering_id, unit_id, participant_id, created_at, updated_at)
+SELECT us.id, us.gathering_id, us.unit_id, us.participant_id, us.created_at, us.updated_at
+FROM unit_slots us
+WHERE us.id = (SELECT first.id
+               FROM unit_slots first
+               WHERE first.gathering_id = us.gathering_id
+                 AND first.unit_id = us.unit_id
+               ORDER BY first.participant_id IS NULL, first.updated_at, first.id
+               LIMIT 1);
+
+DROP TABLE unit_slots;
+ALTER TABLE unit_slots_whole RENAME TO unit_slots;
+
+ALTER TABLE gatherings DROP COLUMN co_ownership_policy;
+ALTER TABLE voter_register_owners DROP COLUMN share;
+ALTER TABLE ownerships DROP COLUMN share;
+-- +goose StatementEnd
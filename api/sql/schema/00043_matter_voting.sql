@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Adding per-matter voting windows';
+
+-- Whether all matters take votes while the gathering is active (gathering), or the chair opens
+-- and closes voting on each matter in turn (per_matter), as in a live general meeting
+ALTER TABLE gatherings ADD COLUMN voting_windows TEXT NOT NULL DEFAULT 'gathering' CHECK (voting_windows IN ('gathering', 'per_matter'));
+
+-- Voting on a matter of a per_matter gathering goes from pending to open to closed
+ALTER TABLE voting_matters ADD COLUMN voting_state TEXT NOT NULL DEFAULT 'pending' CHECK (voting_state IN ('pending', 'open', 'closed'));
+ALTER TABLE voting_matters ADD COLUMN voting_opened_at TIMESTAMP;
+ALTER TABLE voting_matters ADD COLUMN voting_closed_at TIMESTAMP;
+
+-- When a checked-in participant left the meeting
+ALTER TABLE gathering_participants ADD COLUMN check_out_time TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Removing per-matter voting windows';
+ALTER TABLE gathering_participants DROP COLUMN check_out_time;
+ALTER TABLE voting_matters DROP COLUMN voting_closed_at;
+ALTER TABLE voting_matters DROP COLUMN voting_opened_at;
+ALTER TABLE voting_matters DROP COLUMN voting_state;
+ALTER TABLE gatherings DROP COLUMN voting_windows;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Adding attendance events';
+
+-- Each arrival, departure and return of a participant during a gathering, so the attendance and
+-- the quorum at any moment of the meeting can be worked out
+CREATE TABLE attendance_events
+(
+    id             INTEGER PRIMARY KEY,
+    gathering_id   INTEGER   NOT NULL REFERENCES gatherings (id) ON DELETE CASCADE,
+    participant_id INTEGER   NOT NULL REFERENCES gathering_participants (id) ON DELETE CASCADE,
+    event_type     TEXT      NOT NULL CHECK (event_type IN ('check_in', 'check_out', 're_entry')),
+    occurred_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    recorded_by    TEXT
+);
+
+CREATE INDEX idx_attendance_events_gathering ON attendance_events (gathering_id, occurred_at);
+
+-- Check-ins and departures recorded so far
+INSERT INTO attendance_events (gathering_id, participant_id, event_type, occurred_at)
+SELECT gathering_id, id, 'check_in', check_in_time
+FROM gathering_participants
+WHERE check_in_time IS NOT NULL;
+
+INSERT INTO attendance_events (gathering_id, participant_id, event_type, occurred_at)
+SELECT gathering_id, id, 'check_out', check_out_time
+FROM gathering_participants
+WHERE check_out_time IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Removing attendance events';
+DROP INDEX IF EXISTS idx_attendance_events_gathering;
+DROP TABLE IF EXISTS attendance_events;
+-- +goose StatementEnd
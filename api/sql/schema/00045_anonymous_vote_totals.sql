@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Tallying anonymous votes from running totals';
+
+-- The weight of an anonymous vote nearly always identifies the participant who cast it, so it is
+-- no longer kept with the vote. Each row adds up the votes and the weight of every voter who made
+-- the same selection on a matter; withdrawn votes are taken back out of it.
+CREATE TABLE anonymous_vote_totals
+(
+    gathering_id     INTEGER NOT NULL REFERENCES gatherings (id) ON DELETE CASCADE,
+    voting_matter_id INTEGER NOT NULL REFERENCES voting_matters (id) ON DELETE CASCADE,
+    vote_values      TEXT    NOT NULL, -- JSON array with the selected values
+    votes            INTEGER NOT NULL,
+    units_part       REAL    NOT NULL,
+    units_count      INTEGER NOT NULL,
+    PRIMARY KEY (gathering_id, voting_matter_id, vote_values)
+) WITHOUT ROWID;
+
+INSERT INTO anonymous_vote_totals (gathering_id, voting_matter_id, vote_values, votes, units_part, units_count)
+SELECT gathering_id, voting_matter_id, vote_values, COUNT(*), SUM(units_part), SUM(units_count)
+FROM anonymous_votes
+WHERE is_valid = TRUE
+GROUP BY gathering_id, voting_matter_id, vote_values;
+
+ALTER TABLE anonymous_votes DROP COLUMN units_part;
+ALTER TABLE anonymous_votes DROP COLUMN units_count;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Keeping the weight with each anonymous vote';
+ALTER TABLE anonymous_votes ADD COLUMN units_part REAL NOT NULL DEFAULT 0;
+ALTER TABLE anonymous_votes ADD COLUMN units_count INTEGER NOT NULL DEFAULT 0;
+DROP TABLE IF EXISTS anonymous_vote_totals;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'Recording when matters voted throughout the gathering took votes';
+
+-- Matters of a gathering voting on all of them at once take votes from when it first opened to
+-- when it last closed, as recorded in its audit log
+UPDATE voting_matters
+SET voting_opened_at = (SELECT MIN(a.performed_at)
+                        FROM voting_audit_log a
+                        WHERE a.gathering_id = voting_matters.gathering_id
+                          AND a.action = 'status_changed'
+                          AND json_extract(a.details, '$.to') = 'active')
+WHERE voting_opened_at IS NULL
+  AND gathering_id IN (SELECT id FROM gatherings WHERE voting_windows = 'gathering');
+
+UPDATE voting_matters
+SET voting_closed_at = (SELECT MAX(a.performed_at)
+                        FROM voting_audit_log a
+                        WHERE a.gathering_id = voting_matters.gathering_id
+                          AND a.action = 'status_changed'
+                          AND json_extract(a.details, '$.to') = 'closed')
+WHERE voting_closed_at IS NULL
+  AND gathering_id IN (SELECT id FROM gatherings WHERE voting_windows = 'gathering' AND status IN ('closed', 'tallied'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'Forgetting when matters voted throughout the gathering took votes';
+UPDATE voting_matters
+SET voting_opened_at = NULL,
+    voting_closed_at = NULL
+WHERE gathering_id IN (SELECT id FROM gatherings WHERE voting_windows = 'gathering');
+-- +goose StatementEnd
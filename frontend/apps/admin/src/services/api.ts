@@ -29,6 +29,7 @@ import type {
   GatheringParticipant,
   ParticipantCreateRequest,
   ParticipantCheckInRequest,
+  Delegation,
   BallotSubmissionRequest,
   VotingResults,
   QualifiedUnit,
@@ -481,6 +482,13 @@ export const participantApi = {
     api.post<GatheringParticipant>(`/associations/${associationId}/gatherings/${gatheringId}/participants/${participantId}/checkin`, checkInData)
 }
 
+// Delegation registry APIs
+export const delegationApi = {
+  // Get the delegations registered for a gathering
+  getDelegations: (associationId: number, gatheringId: number) =>
+    api.get<Delegation[]>(`/associations/${associationId}/gatherings/${gatheringId}/delegations`)
+}
+
 // Voting APIs
 export const votingApi = {
   // Submit a ballot (new simplified endpoint)
@@ -401,6 +401,8 @@ export interface Gathering {
   status: GatheringStatus;
   type: GatheringType;
   voting_mode: 'by_weight' | 'by_unit';
+  opens_at?: string;
+  closes_at?: string;
   qualification_criteria: QualificationCriteria;
   qualified_units: number;
   qualified_area: number;
@@ -443,6 +445,8 @@ export interface GatheringCreateRequest {
   qualification_floors: number[];
   qualification_entrances: number[];
   qualification_custom_rule: string;
+  opens_at?: string;
+  closes_at?: string;
 }
 
 export interface GatheringUpdateRequest {
@@ -457,6 +461,8 @@ export interface GatheringUpdateRequest {
   qualification_floors?: number[];
   qualification_entrances?: number[];
   qualification_custom_rule?: string;
+  opens_at?: string;
+  closes_at?: string;
 }
 
 export interface GatheringStatusUpdateRequest {
@@ -576,10 +582,31 @@ export interface ParticipantCreateRequest {
   participant_type: ParticipantType;
   owner_id?: number;
   unit_ids: number[];
+  delegation_id?: number;
   delegating_owner_id?: number;
-  delegation_document_ref?: string;
-  delegate_name?: string;
-  delegate_contact?: string;
+}
+
+// Delegation registry of a gathering
+export interface DelegatedOwner {
+  owner_id: number;
+  owner_name: string;
+  unit_ids: number[];
+  revoked_at?: string;
+  revocation_note?: string;
+  used: boolean;
+}
+
+export interface Delegation {
+  id: number;
+  gathering_id: number;
+  delegate_name: string;
+  delegate_identification: string;
+  document_ref: string;
+  valid_from: string;
+  valid_until: string;
+  status: 'pending' | 'active' | 'expired' | 'revoked';
+  owners: DelegatedOwner[];
+  created_at: string;
 }
 
 export interface ParticipantCheckInRequest {
@@ -604,8 +631,8 @@ export interface BallotVote {
 export interface BallotSubmissionRequest {
   voter_type: 'owner' | 'delegate';
   owner_id: number;
+  delegation_id?: number;
   delegating_owner_id?: number;
-  delegation_document_ref?: string;
   unit_ids: number[];
   ballot_content: Record<string, BallotVote>;
 }